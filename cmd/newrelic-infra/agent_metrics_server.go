@@ -0,0 +1,54 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/httpapi"
+	"github.com/newrelic/infrastructure-agent/internal/instrumentation"
+)
+
+const (
+	agentMetricsReadHeaderTimeout = 10 * time.Second
+	// unixSocketPrefix of the addresses served on a Unix domain socket.
+	unixSocketPrefix = "unix://"
+)
+
+// serveAgentMetrics serves the agent internal metrics in the Prometheus text format on the address, until ctx is
+// done. A "unix://" prefixed address is served on a Unix domain socket, removed once ctx is done. It returns the
+// address it listens on once the server is ready.
+func serveAgentMetrics(ctx context.Context, address string) (net.Addr, error) {
+	var listener net.Listener
+	var err error
+	if strings.HasPrefix(address, unixSocketPrefix) {
+		listener, err = httpapi.ListenUnixSocket(strings.TrimPrefix(address, unixSocketPrefix))
+	} else {
+		listener, err = net.Listen("tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen on the agent metrics endpoint: %w", err)
+	}
+
+	server := &http.Server{
+		Handler:           instrumentation.AgentMetrics.Handler(),
+		ReadHeaderTimeout: agentMetricsReadHeaderTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			aslog.WithError(err).Error("Agent metrics server stopped.")
+		}
+	}()
+
+	return listener.Addr(), nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+	"github.com/newrelic/infrastructure-agent/pkg/metrics/sampler"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+)
+
+type agentMetricsTestSampler struct{}
+
+func (agentMetricsTestSampler) Sample() (sample.EventBatch, error) {
+	return sample.EventBatch{&sample.BaseEvent{}, &sample.BaseEvent{}}, nil
+}
+func (agentMetricsTestSampler) OnStartup()              {}
+func (agentMetricsTestSampler) Name() string            { return "AgentMetricsTestSampler" }
+func (agentMetricsTestSampler) Interval() time.Duration { return time.Hour }
+func (agentMetricsTestSampler) Disabled() bool          { return false }
+
+func Test_serveAgentMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr, err := serveAgentMetrics(ctx, "localhost:0")
+	require.NoError(t, err)
+
+	// GIVEN a submission and a sample
+	backendhttp.SubmissionStatuses.Record("https://agent-metrics.test/ingest", &http.Response{StatusCode: http.StatusAccepted}, nil)
+	queue := make(chan sample.EventBatch, 1)
+	routine := sampler.StartStaggeredSamplerRoutine(agentMetricsTestSampler{}, queue, time.Millisecond)
+	<-queue
+	routine.Stop()
+
+	// WHEN the agent metrics endpoint is scraped
+	res, err := http.Get(fmt.Sprintf("http://%s", addr))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	// THEN the agent internal metrics are served in the Prometheus text format
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	metrics := string(body)
+	assert.Contains(t, metrics, "# TYPE nria_submissions_total counter\n")
+	assert.Contains(t, metrics, `nria_submissions_total{endpoint="https://agent-metrics.test/ingest",outcome="success"} 1`+"\n")
+	assert.Contains(t, metrics, "# TYPE nria_sampler_samples_total counter\n")
+	assert.Contains(t, metrics, `nria_sampler_samples_total{sampler="AgentMetricsTestSampler"} 2`+"\n")
+	assert.Contains(t, metrics, "# TYPE nria_plugin_restarts_total counter\n")
+}
+
+func Test_serveAgentMetrics_UnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are served on unix-like systems")
+	}
+	dir, err := ioutil.TempDir("", "sock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metrics.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// GIVEN the agent metrics served on a unix:// endpoint
+	_, err = serveAgentMetrics(ctx, "unix://"+path)
+	require.NoError(t, err)
+
+	// THEN the socket is only accessible by the agent user
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// AND the metrics are scraped through it
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	res, err := client.Get("http://unix/metrics")
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// AND the socket is removed on shutdown
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+}
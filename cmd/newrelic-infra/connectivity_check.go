@@ -0,0 +1,89 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+)
+
+// connectivityCheckEndpoints returns the endpoints the agent has to reach: the status endpoints and the dimensional
+// metrics ingest URL.
+func connectivityCheckEndpoints(cfg *config.Config) (endpoints []string) {
+	seen := map[string]bool{}
+	for _, endpoint := range append(append([]string{}, cfg.StatusEndpoints...), cfg.DMIngestURL()) {
+		if endpoint == "" || seen[endpoint] {
+			continue
+		}
+		seen[endpoint] = true
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// runConnectivityCheck checks the reachability of the agent endpoints with the agent HTTP transport, so proxy and
+// CA settings apply as in normal operation, and prints the outcome as a table. It returns false if any endpoint is
+// unreachable.
+func runConnectivityCheck(cfg *config.Config, w io.Writer) bool {
+	userAgent := agent.GenerateUserAgent("New Relic Infrastructure Agent", buildVersion)
+	clientTimeout := backendhttp.ClientTimeoutFromConfig(cfg)
+	transport := backendhttp.BuildTransport(cfg, clientTimeout)
+
+	return checkConnectivity(connectivityCheckEndpoints(cfg), cfg.License, userAgent, clientTimeout, transport, w)
+}
+
+func checkConnectivity(endpoints []string, license, userAgent string, timeout time.Duration, transport http.RoundTripper, w io.Writer) bool {
+	results := make([]backendhttp.EndpointConnectivity, len(endpoints))
+	wg := sync.WaitGroup{}
+	wg.Add(len(endpoints))
+	for i, endpoint := range endpoints {
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			results[i] = backendhttp.CheckEndpointConnectivity(context.Background(), aslog, endpoint, license, userAgent, "", timeout, transport)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	reachable := true
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ENDPOINT\tRESOLVED IPS\tTLS HANDSHAKE\tSTATUS\tLATENCY")
+	for _, r := range results {
+		status := fmt.Sprint(r.StatusCode)
+		if r.Err != nil {
+			reachable = false
+			status = fmt.Sprintf("unreachable: %s", r.Err)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.URL, resolvedIPs(r), tlsHandshake(r), status, r.Latency.Round(time.Millisecond))
+	}
+	_ = tw.Flush()
+
+	return reachable
+}
+
+func resolvedIPs(r backendhttp.EndpointConnectivity) string {
+	if len(r.ResolvedIPs) == 0 {
+		return "-"
+	}
+	return strings.Join(r.ResolvedIPs, ",")
+}
+
+func tlsHandshake(r backendhttp.EndpointConnectivity) string {
+	switch {
+	case !r.TLSHandshake:
+		return "-"
+	case r.TLSHandshakeErr != nil:
+		return fmt.Sprintf("failed: %s", r.TLSHandshakeErr)
+	default:
+		return "ok"
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+)
+
+func Test_connectivityCheckEndpoints(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.StatusEndpoints = []string{"https://infra-api.newrelic.com", "https://metric-api.newrelic.com", "https://infra-api.newrelic.com"}
+	cfg.MetricURL = "https://metric-api.newrelic.com"
+	cfg.DMIngestEndpoint = "/metric/v1/infra"
+
+	assert.Equal(t, []string{
+		"https://infra-api.newrelic.com",
+		"https://metric-api.newrelic.com",
+		"https://metric-api.newrelic.com/metric/v1/infra",
+	}, connectivityCheckEndpoints(cfg))
+}
+
+func Test_checkConnectivity(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	out := &bytes.Buffer{}
+	assert.True(t, checkConnectivity([]string{srv.URL}, "license", "agent", time.Second, http.DefaultTransport, out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Regexp(t, `^ENDPOINT\s+RESOLVED IPS\s+TLS HANDSHAKE\s+STATUS\s+LATENCY$`, lines[0])
+	assert.Regexp(t, `^`+srv.URL+`\s+127\.0\.0\.1\s+-\s+202\s+`, lines[1])
+}
+
+func Test_checkConnectivity_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	unreachableURL := srv.URL
+	srv.Close()
+
+	out := &bytes.Buffer{}
+	assert.False(t, checkConnectivity([]string{unreachableURL}, "license", "agent", time.Second, http.DefaultTransport, out))
+	assert.Contains(t, out.String(), "unreachable:")
+}
@@ -29,6 +29,9 @@ import (
 	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel"
 	ccBackoff "github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel/backoff"
 	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel/fflag"
+	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel/loglevel"
+	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel/profile"
+	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel/resendinventory"
 	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel/runintegration"
 	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel/service"
 	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel/stopintegration"
@@ -36,8 +39,10 @@ import (
 	"github.com/newrelic/infrastructure-agent/internal/agent/status"
 	"github.com/newrelic/infrastructure-agent/internal/feature_flags"
 	"github.com/newrelic/infrastructure-agent/internal/httpapi"
+	"github.com/newrelic/infrastructure-agent/internal/instrumentation"
 	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/files"
 	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/integration"
+	integrationsRunner "github.com/newrelic/infrastructure-agent/internal/integrations/v4/runner"
 	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/v3legacy"
 	"github.com/newrelic/infrastructure-agent/internal/socketapi"
 	"github.com/newrelic/infrastructure-agent/pkg/backend/backoff"
@@ -58,9 +63,11 @@ import (
 	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/dm"
 	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/emitter"
 	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/logs"
+	"github.com/newrelic/infrastructure-agent/pkg/ipc"
 	wlog "github.com/newrelic/infrastructure-agent/pkg/log"
 	logFilter "github.com/newrelic/infrastructure-agent/pkg/log/filter"
 	"github.com/newrelic/infrastructure-agent/pkg/plugins"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
 	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
 	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/hostname"
 )
@@ -73,6 +80,9 @@ var (
 
 	configFile  string
 	validate    bool
+	dumpConfig  bool
+	connCheck   bool
+	testEvent   bool
 	showVersion bool
 	debug       bool
 	cpuprofile  string
@@ -95,7 +105,10 @@ func init() {
 
 	flag.StringVar(&integrationConfigPath, "integration_config_path", "", "Path of the newrelic integrations configuration files when running in dry-run mode. Can be a file or a directory. (Default: plugin_dir)")
 	flag.StringVar(&configFile, "config", "", "Overrides default configuration file")
-	flag.BoolVar(&validate, "validate", false, "Validate agent config and exit")
+	flag.BoolVar(&validate, "validate", false, "Validates the agent config, the integrations and the logging config files and exit, non-zero if any is invalid")
+	flag.BoolVar(&dumpConfig, "dump-config", false, "Prints the resolved agent config, with secrets redacted, and exit")
+	flag.BoolVar(&connCheck, "connectivity-check", false, "Checks the reachability of the agent endpoints and exit, non-zero if any is unreachable")
+	flag.BoolVar(&testEvent, "send-test-event", false, "Sends a test event to verify the data submission end-to-end and exit, non-zero if it is not accepted")
 	flag.BoolVar(&showVersion, "version", false, "Shows version details")
 	flag.BoolVar(&debug, "debug", false, "Enables agent debugging functionality")
 	flag.StringVar(&cpuprofile, "cpuprofile", "", "Writes cpu profile to `file`")
@@ -180,22 +193,42 @@ func main() {
 
 	timedLog.Debug("Loading configuration.")
 
-	cfg, err := config.LoadConfig(configFile)
-
 	if validate {
-		if err != nil {
-			alog.Info(fmt.Sprintf("config validation failed with error: %s", err.Error()))
-			os.Exit(0)
+		if !runValidation(configFile, os.Stdout) {
+			os.Exit(1)
 		}
-		alog.Info("config validation finished without errors")
 		os.Exit(0)
 	}
 
+	cfg, err := config.LoadConfig(configFile)
+
 	if err != nil {
 		alog.WithError(err).Error("can't load configuration file")
 		os.Exit(1)
 	}
 
+	if dumpConfig {
+		if err = cfg.DumpResolved(os.Stdout, true); err != nil {
+			alog.WithError(err).Error("can't dump configuration")
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if connCheck {
+		if !runConnectivityCheck(cfg, os.Stdout) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if testEvent {
+		if !runSendTestEvent(cfg, os.Stdout) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	if dryRun {
 		executeIntegrationsDryRunMode(integrationConfigPath, cfg)
 		os.Exit(0)
@@ -224,6 +257,7 @@ func main() {
 	}
 
 	configureLogFormat(cfg.Log)
+	http2.SetTraceSampleRate(cfg.Log.HTTPTraceSampleRate)
 
 	// Send logging where it's supposed to go.
 	agentLogsToFile := configureLogRedirection(&cfg.Log, memLog)
@@ -265,6 +299,9 @@ func logConfig(c *config.Config) {
 			"maxProcs":       runtime.GOMAXPROCS(-1),
 			"agentUser":      c.AgentUser,
 			"executablePath": c.ExecutablePath,
+			// inventory payloads may override the compression level of the payloads
+			"payloadCompressionLevel":   c.PayloadCompressionLevel,
+			"inventoryCompressionLevel": c.InventoryPayloadCompressionLevel(),
 		}
 		if wlog.IsLevelEnabled(logrus.DebugLevel) {
 			fields["identityURL"] = c.IdentityURL
@@ -288,12 +325,19 @@ func initializeAgentAndRun(c *config.Config, logFwCfg config.LogForward) error {
 		c.PluginInstanceDirs,
 		pluginSourceDirs,
 	)
+	v4ManagerConfig.ReloadCoalesceWindow = time.Duration(c.ConfigReloadCoalesceWindowMs) * time.Millisecond
 
 	userAgent := agent.GenerateUserAgent("New Relic Infrastructure Agent", buildVersion)
-	transport := backendhttp.BuildTransport(c, backendhttp.ClientTimeout)
+	clientTimeout := backendhttp.ClientTimeoutFromConfig(c)
+	transport := backendhttp.BuildTransport(c, clientTimeout)
 	transport = backendhttp.NewRequestDecoratorTransport(c, transport)
+	if c.DisableHTTP2 {
+		aslog.Info("HTTP/2 is disabled, the agent connections use HTTP/1.1 only.")
+	} else {
+		aslog.Info("HTTP/2 is not disabled, the agent connections use the HTTP protocol negotiated with the server.")
+	}
 
-	httpClient := backendhttp.GetHttpClient(backendhttp.ClientTimeout, transport)
+	httpClient := backendhttp.GetHttpClient(clientTimeout, transport)
 
 	cmdChannelURL := strings.TrimSuffix(c.CommandChannelURL, "/")
 	ccSvcURL := fmt.Sprintf("%s%s", cmdChannelURL, c.CommandChannelEndpoint)
@@ -350,6 +394,38 @@ func initializeAgentAndRun(c *config.Config, logFwCfg config.LogForward) error {
 	}
 
 	selfInstrumentation.InitSelfInstrumentation(c, agt.Context.HostnameResolver())
+	selfInstrumentation.InitSelfInstrumentationSpans(agt.Context.Ctx, c, agt.Context.HostnameResolver())
+
+	if c.EnableTLSHandshakeMetric {
+		// dedicated transport, as its connections are closed after every check
+		checkTransport := backendhttp.NewRequestDecoratorTransport(c, backendhttp.BuildTransport(c, clientTimeout))
+		check := newTLSHandshakeCheck(c.CollectorURL, c.License, userAgent, clientTimeout, checkTransport)
+		go reportTLSHandshakeDuration(agt.Context.Ctx, check, recordTLSHandshakeDuration, tlsHandshakeCheckInterval)
+	}
+
+	reloadableCfg := config.NewReloadableConfig(c, configFile)
+	reloadableCfg.RegisterHook(func(cfg *config.Config) error {
+		configureLogLevel(cfg.Log)
+		configureLogFormat(cfg.Log)
+		http2.SetTraceSampleRate(cfg.Log.HTTPTraceSampleRate)
+		return nil
+	}, config.LogReloadOptions...)
+	reloadableCfg.RegisterHook(func(cfg *config.Config) error {
+		agt.SetSampleRates(cfg.EffectiveSampleRates())
+		return nil
+	}, config.SampleRateReloadOptions...)
+	reloadableCfg.RegisterHook(func(cfg *config.Config) error {
+		agt.SetDeploymentMarker(cfg.DeploymentMarker)
+		return nil
+	}, config.DeploymentMarkerReloadOptions...)
+	reloadableCfg.RegisterHook(func(cfg *config.Config) error {
+		agt.SetCustomAttributes(cfg.CustomAttributes)
+		return nil
+	}, config.CustomAttributesReloadOptions...)
+	agt.RegisterNotificationHandler(ipc.ReloadConfig, func() error {
+		_, _, err := reloadableCfg.Reload()
+		return err
+	})
 
 	defer agt.Terminate()
 
@@ -373,12 +449,17 @@ func initializeAgentAndRun(c *config.Config, logFwCfg config.LogForward) error {
 	// track stoppable integrations
 	tracker := track.NewTracker(dmEmitter)
 
+	integrationsRunner.ExitHistories.SetSize(c.IntegrationsExitHistorySize)
+	if c.IntegrationsExitEventsEnabled {
+		integrationsRunner.ExitHistories.SetEventSender(agt.Context.SendEvent)
+	}
+
 	integrationEmitter := emitter.NewIntegrationEmittor(agt, dmEmitter, ffManager)
 	cfgLoader := integrationsConfig.NewPathLoader()
 	integrationManager := v4.NewManager(
 		v4ManagerConfig,
 		cfgLoader,
-		integrationEmitter,
+		emitter.NewOutputLimitEmitter(integrationEmitter, emitter.OutputLimits(c)),
 		il,
 		definitionQ,
 		configEntryQ,
@@ -393,6 +474,14 @@ func initializeAgentAndRun(c *config.Config, logFwCfg config.LogForward) error {
 	ffHandler := cmdchannel.NewCmdHandler("set_feature_flag", ffHandle.Handle)
 	riHandler := runintegration.NewHandler(definitionQ, il, dmEmitter, wlog.WithComponent("runintegration.Handler"))
 	siHandler := stopintegration.NewHandler(tracker, il, dmEmitter, wlog.WithComponent("stopintegration.Handler"))
+	llHandler := loglevel.NewHandler(func() config.LogConfig {
+		return reloadableCfg.Config().Log
+	}, configureLogLevel, wlog.WithComponent("loglevel.Handler"))
+	profileCaptures := profile.NewCaptures(c.AgentDir)
+	cpHandler := profile.NewHandler(func() bool {
+		return reloadableCfg.Config().AllowRemoteProfiling
+	}, profileCaptures, wlog.WithComponent("profile.Handler"))
+	rinvHandler := resendinventory.NewHandler(agt.ResendInventory, wlog.WithComponent("resendinventory.Handler"))
 	// Command channel service
 	ccService := service.NewService(
 		caClient,
@@ -402,6 +491,9 @@ func initializeAgentAndRun(c *config.Config, logFwCfg config.LogForward) error {
 		ffHandler,
 		riHandler,
 		siHandler,
+		llHandler,
+		cpHandler,
+		rinvHandler,
 	)
 	initCmdResponse, err := ccService.InitialFetch(agt.Context.Ctx)
 	if err != nil {
@@ -411,6 +503,38 @@ func initializeAgentAndRun(c *config.Config, logFwCfg config.LogForward) error {
 	// Initialise the agent after fetching FF.
 	agt.Init()
 
+	fbVerbose := c.Log.Level == config.LogLevelTrace && c.Log.HasIncludeFilter(config.TracesFieldName, config.SupervisorTrace)
+	confTempFolder := filepath.Join(c.AgentTempDir, v4.FbConfTempFolderNameDefault)
+	fbIntCfg := v4.NewFBSupervisorConfig(
+		ffManager,
+		c.AgentDir,
+		config.DefaultIntegrationsDir,
+		c.LoggingBinDir,
+		c.FluentBitExePath,
+		c.FluentBitNRLibPath,
+		c.FluentBitParsersPath,
+		fbVerbose,
+		confTempFolder,
+	)
+	fbIntCfg.RestartPolicy = v4.RestartPolicy{
+		MaxRestarts: c.LoggingRestartMaxCount,
+		Window:      time.Duration(c.LoggingRestartWindowSec) * time.Second,
+		MaxBackOff:  time.Duration(c.LoggingRestartMaxBackoffSec) * time.Second,
+	}
+	fbIntCfg.ConfigReloadCoalesceWindow = time.Duration(c.ConfigReloadCoalesceWindowMs) * time.Millisecond
+
+	var logSupervisor *v4.Supervisor
+	if fbIntCfg.IsLogForwarderAvailable() {
+		logCfgLoader := logs.NewFolderLoader(logFwCfg, agt.Context.Identity, agt.Context.HostnameResolver())
+		logSupervisor = v4.NewFBSupervisor(
+			fbIntCfg,
+			logCfgLoader,
+			agt.Context.AgentIDUpdateNotifier(),
+			agt.Context.HostnameChangeNotifier(),
+			agt.Context.SendEvent,
+		)
+	}
+
 	if c.StatusServerEnabled || c.HTTPServerEnabled {
 		rlog := wlog.WithComponent("status.Reporter")
 		timeoutD, err := time.ParseDuration(c.StartupConnectionTimeout)
@@ -418,7 +542,7 @@ func initializeAgentAndRun(c *config.Config, logFwCfg config.LogForward) error {
 			// This should never happen, as the correct format is checked during NormalizeConfig.
 			aslog.WithError(err).Error("invalid startup_connection_timeout value, cannot run status server")
 		} else {
-			rep := status.NewReporter(agt.Context.Ctx, rlog, c.StatusEndpoints, c.HealthEndpoint, timeoutD, transport, agt.Context.AgentIdnOrEmpty, agt.Context.EntityKey, c.License, userAgent)
+			rep := status.NewReporter(agt.Context.Ctx, rlog, c.StatusEndpoints, c.HealthEndpoint, timeoutD, transport, agt.Context.AgentIdnOrEmpty, agt.Context.EntityKey, logForwarderStatus(logSupervisor), c.License, userAgent)
 
 			apiSrv, err := httpapi.NewServer(rep, integrationEmitter)
 			if c.HTTPServerEnabled {
@@ -433,9 +557,18 @@ func initializeAgentAndRun(c *config.Config, logFwCfg config.LogForward) error {
 				apiSrv.Ingest.VerifyTLSClient(c.HTTPServerCA)
 			}
 
-			if c.StatusServerEnabled {
+			if c.StatusServerEnabled && c.StatusServerSocket != "" {
+				apiSrv.Status.EnableUnixSocket(c.StatusServerSocket)
+			} else if c.StatusServerEnabled {
 				apiSrv.Status.Enable("localhost", c.StatusServerPort)
 			}
+			if c.StatusServerEnabled {
+				apiSrv.SetSampleRatesProvider(func() map[string]config.SampleRateStatus {
+					return reloadableCfg.Config().SampleRatesStatus()
+				})
+				apiSrv.SetConfigReloadStatusProvider(reloadableCfg.Status)
+				apiSrv.SetProfileProvider(profileCaptures.Take)
+			}
 
 			if err != nil {
 				aslog.WithError(err).Error("cannot run api server")
@@ -449,37 +582,26 @@ func initializeAgentAndRun(c *config.Config, logFwCfg config.LogForward) error {
 		go socketapi.NewServer(integrationEmitter, c.TCPServerPort).Serve(agt.Context.Ctx)
 	}
 
+	if c.AgentMetricsEndpoint != "" {
+		if _, err := serveAgentMetrics(agt.Context.Ctx, c.AgentMetricsEndpoint); err != nil {
+			aslog.WithError(err).Error("cannot serve the agent metrics")
+		} else {
+			wlog.Instrument(instrumentation.AgentMetrics.Measure)
+		}
+	}
+
 	// Start all plugins we want the agent to run.
 	if err = plugins.RegisterPlugins(agt); err != nil {
 		aslog.WithError(err).Error("fatal error while registering plugins")
 		os.Exit(1)
 	}
 
-	fbVerbose := c.Log.Level == config.LogLevelTrace && c.Log.HasIncludeFilter(config.TracesFieldName, config.SupervisorTrace)
-	confTempFolder := filepath.Join(c.AgentTempDir, v4.FbConfTempFolderNameDefault)
-	fbIntCfg := v4.NewFBSupervisorConfig(
-		ffManager,
-		c.AgentDir,
-		config.DefaultIntegrationsDir,
-		c.LoggingBinDir,
-		c.FluentBitExePath,
-		c.FluentBitNRLibPath,
-		c.FluentBitParsersPath,
-		fbVerbose,
-		confTempFolder,
-	)
-
-	if fbIntCfg.IsLogForwarderAvailable() {
-		logCfgLoader := logs.NewFolderLoader(logFwCfg, agt.Context.Identity, agt.Context.HostnameResolver())
-		logSupervisor := v4.NewFBSupervisor(
-			fbIntCfg,
-			logCfgLoader,
-			agt.Context.AgentIDUpdateNotifier(),
-			agt.Context.HostnameChangeNotifier(),
-			agt.Context.SendEvent,
-		)
+	if logSupervisor != nil {
 		ffHandle.SetFBRestarter(logSupervisor)
 		go logSupervisor.Run(agt.Context.Ctx)
+
+		fbVersionPluginID := ids.PluginID{Category: "metadata", Term: "log_forwarder"}
+		agt.RegisterPlugin(plugins.NewLogForwarderPlugin(fbVersionPluginID, agt.Context, fbIntCfg.FluentBitVersion))
 	} else {
 		aslog.Debug("Log forwarder is not available for this platform. The agent will start without log forwarding support.")
 	}
@@ -514,6 +636,26 @@ func initializeAgentAndRun(c *config.Config, logFwCfg config.LogForward) error {
 	return agt.Run()
 }
 
+// logForwarderStatus returns the log forwarder status provider for the status reporter.
+func logForwarderStatus(logSupervisor *v4.Supervisor) status.LogForwarderStatusFn {
+	if logSupervisor == nil {
+		return nil
+	}
+
+	return func() *status.LogForwarderReport {
+		state := logSupervisor.State()
+		report := &status.LogForwarderReport{
+			Running:  state.Running,
+			Restarts: state.Restarts,
+		}
+		if state.Stopped {
+			report.Error = "log forwarder stopped after exceeding the maximum number of restarts"
+		}
+
+		return report
+	}
+}
+
 // newInstancesLookup creates an instance lookup that:
 // - looks in the v3 legacy definitions repository for defined commands
 // - looks in the definition folders (and bin/ subfolders) for executable names
@@ -536,19 +678,50 @@ func newInstancesLookup(cfg v4.ManagerConfig) integration.InstancesLookup {
 	}
 }
 
+// configureLogLevel sets the log level from the config, defaulting to info when it's not valid.
+func configureLogLevel(cfg config.LogConfig) {
+	if cfg.IsSmartLogging() {
+		wlog.EnableSmartVerboseMode(cfg.GetSmartLogLevelLimit())
+		return
+	}
+	wlog.DisableSmartVerboseMode()
+	logLevel, err := wlog.ParseLevel(cfg.Level)
+	if err != nil {
+		logLevel = logrus.InfoLevel
+	}
+	wlog.SetLevel(logLevel)
+	logrus.SetLevel(logLevel)
+}
+
+// textLogFormatter is the default logrus formatter. The log format and filters are always set up from it, so
+// reloading them doesn't wrap the previously configured ones.
+var textLogFormatter = wlog.GetFormatter()
+
 // configureLogFormat checks the config and sets the log format accordingly.
 func configureLogFormat(cfg config.LogConfig) {
-	// get default logrus formatter
-	var formatter logrus.Formatter = wlog.GetFormatter()
+	formatter := textLogFormatter
 	if cfg.Format == config.LogFormatJSON {
-		jsonFormatter := &logrus.JSONFormatter{
-			DataKey: "context",
-
-			FieldMap: logrus.FieldMap{
-				logrus.FieldKeyTime: "timestamp",
-			},
+		// Field map is already validated while loading the configuration.
+		fieldMap, _ := cfg.JSONFieldMap()
+		// logrus field keys type is not exported, so the standard keys are taken from a FieldMap literal.
+		standardFields := logrus.FieldMap{
+			logrus.FieldKeyTime:        "",
+			logrus.FieldKeyLevel:       "",
+			logrus.FieldKeyMsg:         "",
+			logrus.FieldKeyFunc:        "",
+			logrus.FieldKeyFile:        "",
+			logrus.FieldKeyLogrusError: "",
+		}
+		jsonFieldMap := logrus.FieldMap{}
+		for field := range standardFields {
+			if name, ok := fieldMap[string(field)]; ok {
+				jsonFieldMap[field] = name
+			}
+		}
+		formatter = &logrus.JSONFormatter{
+			DataKey:  config.LogJSONDataKey,
+			FieldMap: jsonFieldMap,
 		}
-		formatter = jsonFormatter
 	}
 	// Apply filters to agent logs. Filters are only available in the log configuration object.
 	logFilterCfg := logFilter.FilteringFormatterConfig{
@@ -608,6 +781,9 @@ func newLogWriter(config *config.LogConfig) (io.Writer, error) {
 		MaxFiles:        logRotateConfig.MaxFiles,
 		Compress:        logRotateConfig.CompressionEnabled,
 	}
+	if logRotateConfig.MaxTotalSizeMb != nil {
+		rotateCfg.MaxTotalSizeInBytes = int64(*logRotateConfig.MaxTotalSizeMb) << 20
+	}
 	return wlog.NewFileWithRotation(rotateCfg).Open()
 }
 
@@ -666,7 +842,7 @@ func checkEndpointReachable(
 		return false, fmt.Errorf("unable to prepare reachability request: %v, error: %s", request, err)
 	}
 
-	if wlog.IsLevelEnabled(logrus.TraceLevel) {
+	if http2.TracingEnabled() {
 		request = http2.WithTracer(request, "checkEndpointReachable")
 	}
 	client := backendhttp.GetHttpClient(timeout, transport)
@@ -714,6 +890,7 @@ func executeIntegrationsDryRunMode(configPath string, ac *config.Config) {
 		integrationConfigPaths,
 		getPluginSourceDirs(ac),
 	)
+	v4ManagerConfig.ReloadCoalesceWindow = time.Duration(ac.ConfigReloadCoalesceWindowMs) * time.Millisecond
 
 	var definitionQ chan integration.Definition
 	var configEntryQ chan configrequest.Entry
@@ -723,8 +900,8 @@ func executeIntegrationsDryRunMode(configPath string, ac *config.Config) {
 		ac.OverrideHostname, ac.OverrideHostnameShort, ac.DnsHostnameResolution)
 
 	// Initialize the cloudDetector.
-	cloudHarvester := cloud.NewDetector(ac.DisableCloudMetadata, ac.CloudMaxRetryCount, ac.CloudRetryBackOffSec, ac.CloudMetadataExpiryInSec, ac.CloudMetadataDisableKeepAlive)
-	cloudHarvester.Initialize()
+	cloudHarvester := cloud.NewDetector(ac.DisableCloudMetadata, ac.CloudMaxRetryCount, ac.CloudRetryBackOffSec, ac.CloudMetadataExpiryInSec, ac.CloudMetadataDisableKeepAlive, ac.CloudDetectDisabledProviders)
+	cloudHarvester.Initialize(cloud.WithDiskCache(ac.CloudMetadataCacheFile()))
 
 	agentIDLookup := agent.NewIdLookup(hostnameResolver, cloudHarvester, ac.DisplayName)
 
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -35,3 +36,19 @@ func Test_configureLogRedirection(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "example logs here", string(dat))
 }
+
+func Test_configureLogFormat_Reconfigured(t *testing.T) {
+	defer configureLogFormat(*config.NewLogConfig())
+
+	logConf := config.NewLogConfig()
+	logConf.Format = config.LogFormatJSON
+	configureLogFormat(*logConf)
+	configureLogFormat(*config.NewLogConfig())
+
+	// the text format doesn't keep the previous json formatter wrapped
+	entry := logrus.NewEntry(logrus.New())
+	entry.Message = "reconfigured"
+	out, err := log.GetFormatter().Format(entry)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `msg=reconfigured`)
+}
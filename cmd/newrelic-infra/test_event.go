@@ -0,0 +1,32 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+)
+
+// runSendTestEvent submits a test event with the agent HTTP client, and prints the outcome. It returns false if the
+// event was not accepted.
+func runSendTestEvent(cfg *config.Config, w io.Writer) bool {
+	userAgent := agent.GenerateUserAgent("New Relic Infrastructure Agent", buildVersion)
+	clientTimeout := backendhttp.ClientTimeoutFromConfig(cfg)
+	transport := backendhttp.BuildTransport(cfg, clientTimeout)
+	transport = backendhttp.NewRequestDecoratorTransport(cfg, transport)
+	httpClient := backendhttp.GetHttpClient(clientTimeout, transport)
+
+	testEventID := fmt.Sprintf("%x", time.Now().UnixNano())
+	if err := agent.SendTestEvent(cfg, userAgent, httpClient.Do, testEventID); err != nil {
+		_, _ = fmt.Fprintf(w, "Test event %s failed: %s\n", testEventID, err)
+		return false
+	}
+
+	_, _ = fmt.Fprintf(w, "Test event %s accepted. Query it with: SELECT * FROM InfrastructureEvent WHERE testEventId = '%s'\n", testEventID, testEventID)
+	return true
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/instrumentation"
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+)
+
+const (
+	tlsHandshakeCheckInterval = time.Minute
+	tlsHandshakeMetricName    = "agent.tlsHandshakeDurationMs"
+)
+
+// newTLSHandshakeCheck returns a check of the connectivity with the collector. The idle connections of the transport
+// are closed after every check, so each one performs a new TLS handshake. Thus, the transport must not be shared with
+// the agent senders.
+func newTLSHandshakeCheck(collectorURL, license, userAgent string, timeout time.Duration, transport http.RoundTripper) func(ctx context.Context) backendhttp.EndpointConnectivity {
+	client := backendhttp.GetHttpClient(timeout, transport)
+	return func(ctx context.Context) backendhttp.EndpointConnectivity {
+		defer client.CloseIdleConnections()
+		return backendhttp.CheckEndpointConnectivity(ctx, aslog, collectorURL, license, userAgent, "", timeout, transport)
+	}
+}
+
+// reportTLSHandshakeDuration checks the connectivity with the collector on every interval and records the duration
+// of the TLS handshake, until ctx is done.
+func reportTLSHandshakeDuration(
+	ctx context.Context,
+	check func(ctx context.Context) backendhttp.EndpointConnectivity,
+	record func(ctx context.Context, endpoint string, duration time.Duration),
+	interval time.Duration,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c := check(ctx)
+			switch {
+			case c.TLSHandshakeErr != nil:
+				aslog.WithError(c.TLSHandshakeErr).WithField("url", c.URL).Warn("TLS handshake with the collector failed.")
+			case !c.TLSHandshake:
+				aslog.WithError(c.Err).WithField("url", c.URL).Debug("No TLS handshake performed with the collector.")
+			default:
+				record(ctx, c.URL, c.TLSHandshakeDuration)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func recordTLSHandshakeDuration(ctx context.Context, endpoint string, duration time.Duration) {
+	aslog.WithField("url", endpoint).WithField("duration", duration).Debug("TLS handshake with the collector.")
+	metric := instrumentation.NewGaugeWithAttributes(tlsHandshakeMetricName, float64(duration)/float64(time.Millisecond), map[string]interface{}{"endpoint": endpoint})
+	instrumentation.SelfInstrumentation.RecordMetric(ctx, metric)
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+)
+
+type recordedHandshake struct {
+	endpoint string
+	duration time.Duration
+}
+
+func Test_reportTLSHandshakeDuration(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	recorded := make(chan recordedHandshake)
+	record := func(_ context.Context, endpoint string, duration time.Duration) {
+		recorded <- recordedHandshake{endpoint: endpoint, duration: duration}
+	}
+	check := newTLSHandshakeCheck(srv.URL, "license", "agent", time.Second, srv.Client().Transport)
+	go reportTLSHandshakeDuration(ctx, check, record, 10*time.Millisecond)
+
+	// every check performs a new handshake, as connections aren't reused
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-recorded:
+			assert.Equal(t, srv.URL, r.endpoint)
+			assert.Greater(t, r.duration, time.Duration(0))
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "TLS handshake duration not recorded")
+		}
+	}
+}
+
+func Test_reportTLSHandshakeDuration_FailedHandshake(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	checked := 0
+	// the server certificate isn't trusted by a default transport
+	untrusted := newTLSHandshakeCheck(srv.URL, "license", "agent", time.Second, &http.Transport{})
+	check := func(ctx context.Context) (c backendhttp.EndpointConnectivity) {
+		checked++
+		return untrusted(ctx)
+	}
+	reportTLSHandshakeDuration(ctx, check, func(context.Context, string, time.Duration) {
+		assert.Fail(t, "failed handshakes must not be recorded")
+	}, 10*time.Millisecond)
+
+	assert.Greater(t, checked, 0)
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/files"
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/integration"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/databind/pkg/databind"
+	"github.com/newrelic/infrastructure-agent/pkg/integrations/legacy"
+	v4 "github.com/newrelic/infrastructure-agent/pkg/integrations/v4"
+	integrationsConfig "github.com/newrelic/infrastructure-agent/pkg/integrations/v4/config"
+	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/fs"
+	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/logs"
+)
+
+// validationProblem a problem found validating the configuration, and the file or section it was found in.
+type validationProblem struct {
+	source string
+	err    error
+}
+
+// runValidation validates the agent config, the integrations config files with their databind sources, and the
+// logging config files, without connecting to the backend, and prints every problem found instead of stopping on the
+// first one. It returns false if there is any problem.
+func runValidation(configFile string, w io.Writer) bool {
+	var problems []validationProblem
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		problems = append(problems, validationProblem{source: "agent config", err: err})
+	}
+	if cfg != nil {
+		integrationsDirs := cfg.PluginInstanceDirs
+		if len(integrationsDirs) == 0 && cfg.PluginDir != "" {
+			// the agent config normalization stopped on an error, so only the configured dir is known
+			integrationsDirs = []string{cfg.PluginDir}
+		}
+		problems = append(problems, validateIntegrationsConfig(cfg, integrationsDirs)...)
+		problems = append(problems, validateLoggingConfig(cfg)...)
+	}
+
+	for _, p := range problems {
+		_, _ = fmt.Fprintf(w, "%s: %s\n", p.source, p.err)
+	}
+	if len(problems) > 0 {
+		_, _ = fmt.Fprintf(w, "config validation failed with %d errors\n", len(problems))
+		return false
+	}
+	_, _ = fmt.Fprintln(w, "config validation finished without errors")
+	return true
+}
+
+// validateIntegrationsConfig loads the integrations config files in dirs, fetches their databind sources and builds
+// the definitions of their integrations, as the integrations manager does, without running them.
+func validateIntegrationsConfig(cfg *config.Config, dirs []string) (problems []validationProblem) {
+	managerConfig := v4.NewManagerConfig(
+		cfg.Log.VerboseEnabled(),
+		cfg.DefaultIntegrationsTempDir,
+		cfg.Features,
+		cfg.PassthroughEnvironment,
+		dirs,
+		getPluginSourceDirs(cfg),
+	)
+	lookup := newInstancesLookup(managerConfig)
+	cfgLoader := integrationsConfig.NewPathLoader()
+	legacyRegistry := legacy.NewPluginRegistry(getPluginSourceDirs(cfg), dirs)
+	if err := legacyRegistry.LoadPluginSources(); err != nil {
+		problems = append(problems, validationProblem{source: "integrations definitions", err: err})
+	}
+
+	for _, dir := range dirs {
+		yamlFiles, err := files.AllYAMLs(dir)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				problems = append(problems, validationProblem{source: dir, err: err})
+			}
+			continue
+		}
+
+		for _, file := range yamlFiles {
+			path := filepath.Join(dir, file.Name())
+			for _, err = range validateIntegrationsFile(cfgLoader, legacyRegistry, path, lookup, cfg.PassthroughEnvironment) {
+				problems = append(problems, validationProblem{source: path, err: err})
+			}
+		}
+	}
+	return problems
+}
+
+// validateIntegrationsFile returns the problems of an integrations config file. The v3 legacy ones are loaded by the
+// legacy plugins registry, as the agent does.
+func validateIntegrationsFile(cfgLoader integrationsConfig.Loader, legacyRegistry *legacy.PluginRegistry, path string, lookup integration.InstancesLookup, passthroughEnv []string) (errs []error) {
+	cfg, err := cfgLoader.LoadFile(path)
+	if err != nil {
+		if errors.Is(err, integrationsConfig.LegacyYAML) {
+			return legacyRegistry.ValidatePluginInstanceFile(path)
+		}
+		return []error{err}
+	}
+
+	dSources, err := cfg.Databind.DataSources()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("databind: %w", err))
+	} else if dSources != nil {
+		if _, err = databind.Fetch(dSources); err != nil {
+			errs = append(errs, fmt.Errorf("databind: %w", err))
+		}
+	}
+
+	for _, cfgEntry := range cfg.Integrations {
+		template, err := integration.LoadConfigTemplate(cfgEntry.TemplatePath, cfgEntry.Config)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("integration %q: %w", cfgEntry.InstanceName, err))
+			continue
+		}
+		if _, err = integration.NewDefinition(cfgEntry, lookup, passthroughEnv, template); err != nil {
+			errs = append(errs, fmt.Errorf("integration %q: %w", cfgEntry.InstanceName, err))
+		}
+	}
+	return errs
+}
+
+// validateLoggingConfig parses the logging config files in the logging configs dir, and generates the log forwarder
+// configuration from them, as the log forwarder does.
+func validateLoggingConfig(cfg *config.Config) (problems []validationProblem) {
+	if cfg.LoggingConfigsDir == "" {
+		return nil
+	}
+
+	loggingFiles, err := fs.OSFilesInFolderFn(cfg.LoggingConfigsDir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrFilesNotFound) && !errors.Is(err, fs.ErrFolderNotFound) {
+			problems = append(problems, validationProblem{source: cfg.LoggingConfigsDir, err: err})
+		}
+		return problems
+	}
+
+	logFwdCfg := config.NewLogForward(cfg, config.NewTroubleshootCfg(false, false, ""))
+	fileProblems := logs.ValidateFiles(loggingFiles, logFwdCfg)
+	for _, file := range loggingFiles {
+		for _, err = range fileProblems[file] {
+			problems = append(problems, validationProblem{source: file, err: err})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validationDirs writes an agent config file pointing to empty integrations and logging config dirs, and returns
+// the config file and those dirs.
+func validationDirs(t *testing.T) (configFile, integrationsDir, loggingDir string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	integrationsDir = filepath.Join(dir, "integrations.d")
+	loggingDir = filepath.Join(dir, "logging.d")
+	require.NoError(t, os.Mkdir(integrationsDir, 0755))
+	require.NoError(t, os.Mkdir(loggingDir, 0755))
+
+	configFile = filepath.Join(dir, "newrelic-infra.yml")
+	require.NoError(t, os.WriteFile(configFile, []byte(
+		"license_key: abc123\n"+
+			"agent_dir: "+filepath.Join(dir, "agent")+"\n"+
+			"plugin_dir: "+integrationsDir+"\n"+
+			"logging_configs_dir: "+loggingDir+"\n"), 0600))
+	return configFile, integrationsDir, loggingDir
+}
+
+func writeValidationFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	file := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+	return file
+}
+
+func Test_runValidation(t *testing.T) {
+	configFile, integrationsDir, loggingDir := validationDirs(t)
+	writeValidationFile(t, integrationsDir, "valid.yml", "integrations:\n  - name: echo\n    exec: echo hello\n")
+	writeValidationFile(t, loggingDir, "valid.yml", "logs:\n  - name: app\n    file: /var/log/app.log\n")
+
+	out := &bytes.Buffer{}
+	assert.True(t, runValidation(configFile, out), out.String())
+	assert.Equal(t, "config validation finished without errors\n", out.String())
+}
+
+func Test_runValidation_ReportsAllProblems(t *testing.T) {
+	configFile, integrationsDir, loggingDir := validationDirs(t)
+	malformed := writeValidationFile(t, integrationsDir, "malformed.yml", "integrations:\n  - name: echo\n   exec: echo hello\n")
+	missing := writeValidationFile(t, integrationsDir, "missing.yml", "integrations:\n  - name: nri-missing\n  - name: nri-also-missing\n")
+	databind := writeValidationFile(t, integrationsDir, "databind.yml",
+		"variables:\n  creds:\n    unknown-provider: {}\nintegrations:\n  - name: echo\n    exec: echo hello\n")
+	legacyFile := writeValidationFile(t, integrationsDir, "legacy.yml",
+		"integration_name: com.example.missing\ninstances:\n  - name: legacy\n    command: metrics\n")
+	logging := writeValidationFile(t, loggingDir, "invalid.yml", "logs:\n  - name: no-input\n")
+	generation := writeValidationFile(t, loggingDir, "generation.yml",
+		"logs:\n  - name: undefined-parser\n    file: /var/log/app.log\n    parser: undefined\n"+
+			"  - name: bad-syslog\n    syslog:\n      uri: invalid-uri\n")
+
+	out := &bytes.Buffer{}
+	assert.False(t, runValidation(configFile, out))
+
+	output := out.String()
+	assert.Contains(t, output, malformed+": ")
+	assert.Contains(t, output, missing+`: integration "nri-missing": `)
+	assert.Contains(t, output, missing+`: integration "nri-also-missing": `)
+	assert.Contains(t, output, databind+": databind: ")
+	assert.Contains(t, output, legacyFile+": couldn't load integration instances from config file")
+	assert.Contains(t, output, logging+": log source #1 lacks a name or an input")
+	// the log forwarder configuration generation errors are reported
+	assert.Contains(t, output, generation+`: log source "undefined-parser": parser "undefined" is not defined`)
+	assert.Contains(t, output, generation+`: log source "bad-syslog": `)
+	assert.Contains(t, output, "config validation failed with 8 errors\n")
+}
+
+func Test_runValidation_AgentConfigError(t *testing.T) {
+	t.Setenv("NRIA_LICENSE_KEY", "")
+	configFile, integrationsDir, loggingDir := validationDirs(t)
+	writeValidationFile(t, filepath.Dir(configFile), filepath.Base(configFile),
+		"plugin_dir: "+integrationsDir+"\nlogging_configs_dir: "+loggingDir+"\n")
+	missing := writeValidationFile(t, integrationsDir, "missing.yml", "integrations:\n  - name: nri-missing\n")
+	logging := writeValidationFile(t, loggingDir, "invalid.yml", "logs:\n  - name: no-input\n")
+
+	out := &bytes.Buffer{}
+	assert.False(t, runValidation(configFile, out))
+	assert.Contains(t, out.String(), "agent config: no license key")
+	// the integrations and logging config are validated despite the agent config error
+	assert.Contains(t, out.String(), missing+`: integration "nri-missing": `)
+	assert.Contains(t, out.String(), logging+": log source #1 lacks a name or an input")
+}
@@ -4,6 +4,7 @@ package agent
 
 import (
 	context2 "context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -23,6 +24,7 @@ import (
 	"github.com/newrelic/infrastructure-agent/internal/agent/types"
 
 	"github.com/newrelic/infrastructure-agent/internal/feature_flags"
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
 	"github.com/newrelic/infrastructure-agent/pkg/entity/host"
 	"github.com/newrelic/infrastructure-agent/pkg/helpers/metric"
 	"github.com/newrelic/infrastructure-agent/pkg/metrics/sampler"
@@ -61,6 +63,8 @@ const (
 	defaultBulkInventoryQueueLength = 1000
 )
 
+var errInventorySubmissionDisabled = errors.New("inventory submission is disabled in forward only mode")
+
 type registerableSender interface {
 	Start() error
 	Stop() error
@@ -95,11 +99,17 @@ type Agent struct {
 	agentID             *entity.ID                               // pointer as it's referred from several points
 	mtx                 sync.Mutex                               // Protect plugins
 	notificationHandler *ctl.NotificationHandlerWithCancellation // Handle ipc messaging.
+	resendInventoryCh   chan resendInventoryRequest              // Resend requests run by the inventory routine.
+	shutdownFlushOnce   sync.Once
+	shutdownFlushCtx    context2.Context // Bounds the flush of the queued data, shared by all the shutdown phases.
+	shutdownFlushCancel context2.CancelFunc
 }
 
 type inventoryState struct {
 	readyToReap    bool
 	sendErrorCount uint32
+	// retryStatusClass status class of the failed deltas submission the next one retries, empty if it didn't fail.
+	retryStatusClass string
 }
 
 var (
@@ -162,6 +172,11 @@ type context struct {
 	idLookup           host.IDLookup
 	shouldIncludeEvent sampler.IncludeProcessSampleMatchFn
 	shouldExcludeEvent sampler.ExcludeProcessSampleMatchFn
+	droppedSamples     *sampler.DroppedSamplesCounter // Counter of the samples dropped by the matchers, if enabled
+	eventTypeFilter    *sampler.EventTypeFilter       // Filter of the submitted events by event type, if configured
+	liveness           *livenessFile                  // Liveness file written while samples are produced, if enabled
+	deploymentMarker   atomic.Value                   // Deployment marker the submitted events are decorated with
+	customAttributes   atomic.Value                   // Custom attributes the host is decorated with, as last reloaded
 }
 
 func (c *context) Context() context2.Context {
@@ -214,6 +229,28 @@ func NewContext(
 
 	var agentKey atomic.Value
 	agentKey.Store("")
+
+	var droppedSamples *sampler.DroppedSamplesCounter
+	if cfg != nil && cfg.EnableDroppedSamplesCount {
+		droppedSamples = sampler.DroppedSamples
+	}
+
+	var eventTypeFilter *sampler.EventTypeFilter
+	if cfg != nil {
+		eventTypeFilter = sampler.NewEventTypeFilter(cfg.EventTypeAllowlist, cfg.EventTypeDenylist)
+	}
+
+	var liveness *livenessFile
+	if cfg != nil && cfg.LivenessFile != "" {
+		liveness = newLivenessFile(cfg)
+	}
+
+	var deploymentMarker, customAttributes atomic.Value
+	if cfg != nil {
+		deploymentMarker.Store(cfg.DeploymentMarker)
+		customAttributes.Store(cfg.CustomAttributes)
+	}
+
 	return &context{
 		cfg:                cfg,
 		Ctx:                ctx,
@@ -227,7 +264,12 @@ func NewContext(
 		idLookup:           lookup,
 		shouldIncludeEvent: sampleMatchFn,
 		shouldExcludeEvent: sampleExcludeFn,
+		droppedSamples:     droppedSamples,
+		eventTypeFilter:    eventTypeFilter,
+		liveness:           liveness,
 		agentKey:           agentKey,
+		deploymentMarker:   deploymentMarker,
+		customAttributes:   customAttributes,
 	}
 }
 
@@ -280,10 +322,12 @@ func NewAgent(
 		cfg.OverrideHostname, cfg.OverrideHostnameShort, cfg.DnsHostnameResolution)
 
 	// Initialize the cloudDetector.
-	cloudHarvester := cloud.NewDetector(cfg.DisableCloudMetadata, cfg.CloudMaxRetryCount, cfg.CloudRetryBackOffSec, cfg.CloudMetadataExpiryInSec, cfg.CloudMetadataDisableKeepAlive)
-	cloudHarvester.Initialize(cloud.WithProvider(cloud.Type(cfg.CloudProvider)))
+	cloudHarvester := cloud.NewDetector(cfg.DisableCloudMetadata, cfg.CloudMaxRetryCount, cfg.CloudRetryBackOffSec, cfg.CloudMetadataExpiryInSec, cfg.CloudMetadataDisableKeepAlive, cfg.CloudDetectDisabledProviders)
+	cloudHarvester.Initialize(cloud.WithProvider(cloud.Type(cfg.CloudProvider)), cloud.WithDiskCache(cfg.CloudMetadataCacheFile()))
 
-	idLookupTable := NewIdLookup(hostnameResolver, cloudHarvester, cfg.DisplayName)
+	// The configured display name is kept untouched, as it's reported in the config inventory.
+	displayName := disambiguateDisplayName(cfg.DisplayName, hostnameResolver, cfg.DisplayNameAppendInstanceID, cloudHarvester)
+	idLookupTable := NewIdLookup(hostnameResolver, cloudHarvester, displayName)
 
 	// Matchers logic:
 	// * If enable_process_metrics is defined and false, no process will be sent
@@ -333,11 +377,13 @@ func NewAgent(
 	}
 
 	s := delta.NewStore(dataDir, ctx.EntityKey(), maxInventorySize, cfg.InventoryArchiveEnabled)
+	s.SetDroppedMetricEnabled(cfg.InventoryDroppedMetricEnabled)
 
-	transport := backendhttp.BuildTransport(cfg, backendhttp.ClientTimeout)
+	clientTimeout := backendhttp.ClientTimeoutFromConfig(cfg)
+	transport := backendhttp.BuildTransport(cfg, clientTimeout)
 	transport = backendhttp.NewRequestDecoratorTransport(cfg, transport)
 
-	httpClient := backendhttp.GetHttpClient(backendhttp.ClientTimeout, transport)
+	httpClient := backendhttp.GetHttpClient(clientTimeout, transport)
 
 	identityURL := fmt.Sprintf("%s/%s", cfg.IdentityURL, strings.TrimPrefix(cfg.IdentityIngestEndpoint, "/"))
 	if os.Getenv("DEV_IDENTITY_INGEST_URL") != "" {
@@ -368,7 +414,7 @@ func NewAgent(
 	}
 
 	provideIDs := NewProvideIDs(registerClient, state.NewRegisterSM())
-	fpHarvester, err := fingerprint.NewHarvestor(cfg, hostnameResolver, cloudHarvester)
+	fpHarvester, err := fingerprint.NewHarvestor(cfg, displayName, hostnameResolver, cloudHarvester)
 	if err != nil {
 		return nil, err
 	}
@@ -426,6 +472,7 @@ func New(
 
 	a.plugins = make([]Plugin, 0)
 	a.oldPlugins = make([]ids.PluginID, 0)
+	a.resendInventoryCh = make(chan resendInventoryRequest)
 
 	a.Context.cfg = cfg
 	a.agentDir = cfg.AgentDir
@@ -538,7 +585,12 @@ func (a *Agent) registerEntityInventory(entity entity.Entity) error {
 func (a *Agent) newPatchSender(entity entity.Entity) (inventory.PatchSender, error) {
 	fileName := a.store.EntityFolder(entity.Key.String())
 	lastSubmission := delta.NewLastSubmissionStore(a.store.DataDir, fileName)
-	lastEntityID := delta.NewEntityIDFilePersist(a.store.DataDir, fileName)
+	var lastEntityID delta.EntityIDPersist
+	if identityFile := a.Context.cfg.IdentityCacheFile; identityFile != "" && entity.Key.String() == a.Context.EntityKey() {
+		lastEntityID = delta.NewEntityIDFilePersistAt(identityFile)
+	} else {
+		lastEntityID = delta.NewEntityIDFilePersist(a.store.DataDir, fileName)
+	}
 
 	return newPatchSender(entity, a.Context, a.store, lastSubmission, lastEntityID, a.userAgent, a.Context.Identity, a.httpClient)
 }
@@ -555,6 +607,51 @@ func (a *Agent) unregisterEntityInventory(entityKey string) error {
 	return a.store.RemoveEntity(entityKey)
 }
 
+// resendInventoryRequest asks the inventory routine to resend the whole inventory of the plugins.
+type resendInventoryRequest struct {
+	plugins []string
+	result  chan<- resendInventoryResult
+}
+
+type resendInventoryResult struct {
+	triggered int
+	size      int64
+	err       error
+}
+
+// ResendInventory forces the whole inventory of the given plugins, or of all of them when none is given, to be sent
+// on the next reap. It returns the number of plugins triggered and their approximate payload size in bytes.
+// The inventory cache is removed by the routine reaping the inventory.
+func (a *Agent) ResendInventory(plugins []string) (int, int64, error) {
+	if !a.shouldSendInventory() {
+		return 0, 0, errInventorySubmissionDisabled
+	}
+	if a.inventoryHandler != nil {
+		return a.inventoryHandler.ResendInventory(plugins)
+	}
+
+	result := make(chan resendInventoryResult, 1)
+	select {
+	case a.resendInventoryCh <- resendInventoryRequest{plugins: plugins, result: result}:
+	case <-a.Context.Ctx.Done():
+		return 0, 0, a.Context.Ctx.Err()
+	}
+	r := <-result
+	return r.triggered, r.size, r.err
+}
+
+// resendInventory removes the inventory cache of the plugins and marks every entity for reaping, so the next reap
+// stores their full inventory.
+func (a *Agent) resendInventory(request resendInventoryRequest) {
+	triggered, size, err := a.store.ResendInventory(request.plugins)
+	if triggered > 0 {
+		for _, inventory := range a.inventories {
+			inventory.needsReaping = true
+		}
+	}
+	request.result <- resendInventoryResult{triggered: triggered, size: size, err: err}
+}
+
 func (a *Agent) Plugins() []Plugin {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
@@ -578,6 +675,36 @@ func (a *Agent) RegisterMetricsSender(s registerableSender) {
 	a.metricsSender = s
 }
 
+// sampleRatesSetter is implemented by the metrics senders able to reschedule their samplers at runtime.
+type sampleRatesSetter interface {
+	SetSampleRates(sampleRates map[string]time.Duration)
+}
+
+// SetSampleRates reschedules the metrics samplers with the given sample rates, if the metrics sender supports it.
+func (a *Agent) SetSampleRates(sampleRates map[string]time.Duration) {
+	if setter, ok := a.metricsSender.(sampleRatesSetter); ok {
+		setter.SetSampleRates(sampleRates)
+	}
+}
+
+// SetDeploymentMarker sets the deployment marker the events submitted from now on are decorated with.
+func (a *Agent) SetDeploymentMarker(marker string) {
+	a.Context.SetDeploymentMarker(marker)
+}
+
+// SetCustomAttributes sets the custom attributes the host is decorated with, reporting them again.
+func (a *Agent) SetCustomAttributes(attributes config.CustomAttributeMap) {
+	a.Context.SetCustomAttributes(attributes)
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	for _, p := range a.plugins {
+		if p.Id() == ids.CustomAttrsID {
+			go p.Run()
+		}
+	}
+}
+
 // RegisterPlugin takes a Plugin instance and registers it in the
 // agent's plugin map
 func (a *Agent) RegisterPlugin(p Plugin) {
@@ -593,6 +720,11 @@ func (a *Agent) ExternalPluginsHealthCheck() {
 	}
 }
 
+// RegisterNotificationHandler registers a handler for an ipc message. It must be called before running the agent.
+func (a *Agent) RegisterNotificationHandler(message ipc.Message, handler func() error) {
+	a.notificationHandler.RegisterHandler(message, handler)
+}
+
 func (a *Agent) GetContext() AgentContext {
 	return a.Context
 }
@@ -711,6 +843,7 @@ func (a *Agent) Init() {
 			IgnoredPaths:         cfg.IgnoredInventoryPathsMap,
 			AgentEntity:          entity.NewFromNameWithoutID(a.Context.EntityKey()),
 			RemoveEntitiesPeriod: removeEntitiesPeriod,
+			RemoveEntitiesAudit:  cfg.RemoveEntitiesAudit,
 		}
 		patcher := inventory.NewEntityPatcher(patcherConfig, a.store, a.newPatchSender)
 
@@ -719,11 +852,18 @@ func (a *Agent) Init() {
 		}
 
 		inventoryHandlerCfg := inventory.HandlerConfig{
-			SendInterval:      cfg.SendInterval,
-			FirstReapInterval: cfg.FirstReapInterval,
-			ReapInterval:      cfg.ReapInterval,
-			InventoryQueueLen: cfg.InventoryQueueLen,
+			SendInterval:       cfg.SendInterval,
+			FirstReapInterval:  cfg.FirstReapInterval,
+			ReapInterval:       cfg.ReapInterval,
+			InventoryQueueLen:  cfg.InventoryQueueLen,
+			MinSendInterval:    time.Duration(cfg.InventoryMinSubmissionIntervalSec) * time.Second,
+			Workers:            cfg.InventoryHandlerConcurrency,
+			CompactionInterval: a.compactionInterval(),
 		}
+		if a.shutdownFlushTimeout() > 0 {
+			inventoryHandlerCfg.ShutdownFlushContext = a.shutdownFlushContext
+		}
+		inventoryHandlerCfg.RetryBackoffMax, inventoryHandlerCfg.RetryBackoffStep = inventoryRetryBackoff(cfg)
 		a.inventoryHandler = inventory.NewInventoryHandler(a.Context.Ctx, inventoryHandlerCfg, patcher)
 		a.Context.pluginOutputHandleFn = a.inventoryHandler.Handle
 		a.Context.updateIDLookupTableFn = a.updateIDLookupTable
@@ -733,6 +873,21 @@ func (a *Agent) Init() {
 	}
 }
 
+// inventoryQueueDepth returns the number of inventory payloads waiting to be processed.
+func (a *Agent) inventoryQueueDepth() float64 {
+	if a.inventoryHandler != nil {
+		return float64(a.inventoryHandler.QueueDepth())
+	}
+	return float64(len(a.Context.ch))
+}
+
+func (a *Agent) lastCompactionTimestamp() float64 {
+	if a.store == nil || a.store.LastCompaction().IsZero() {
+		return 0
+	}
+	return float64(a.store.LastCompaction().Unix())
+}
+
 // Run is the main event loop for the agent it starts up the plugins
 // kicks off a filesystem seed and watcher and listens for data from
 // the plugins
@@ -750,6 +905,33 @@ func (a *Agent) Run() (err error) {
 
 	go a.intervalMemoryProfile()
 
+	if a.Context.droppedSamples != nil {
+		go reportIntervalCounts(a.Context.Ctx, a.Context.droppedSamples.FlushInterval, droppedSamplesMetric, droppedSamplesReportInterval)
+	}
+
+	if cfg.EnableConfigParseErrorsMetric {
+		go reportIntervalCounts(a.Context.Ctx, config.ParseErrors.FlushInterval, configParseErrorsMetric, configParseErrorsReportInterval)
+	}
+
+	if cfg.EnableFileHandlesMetric {
+		if usage, err := readSelfFileHandles(); err != nil {
+			alog.WithError(err).Warn("Can't report the agent file handles usage.")
+		} else {
+			gauges := newFileHandlesGauges(openmetrics.AgentMetrics)
+			gauges.record(usage)
+			go reportFileHandles(a.Context.Ctx, readSelfFileHandles, gauges, fileHandlesReportInterval)
+		}
+	}
+
+	if a.Context.liveness != nil {
+		go a.Context.liveness.run(a.Context.Ctx)
+	}
+
+	openmetrics.AgentMetrics.SetGaugeFunc("nria_inventory_queue_depth",
+		"Plugin and integration inventory payloads waiting to be processed.", a.inventoryQueueDepth)
+	openmetrics.AgentMetrics.SetGaugeFunc("nria_inventory_last_compaction_timestamp_seconds",
+		"Unix time of the last inventory storage compaction, 0 if the storage wasn't compacted yet.", a.lastCompactionTimestamp)
+
 	if cloud.Type(cfg.CloudProvider).IsValidCloud() {
 		err = a.checkInstanceIDRetry(cfg.CloudMaxRetryCount, cfg.CloudRetryBackOffSec)
 		// If the cloud provider was specified but we cannot get the instance ID, agent fails
@@ -811,6 +993,12 @@ func (a *Agent) Run() (err error) {
 	}
 
 	exit := make(chan struct{})
+	defer func() {
+		// once exited, nothing waits for the shutdown flush deadline anymore
+		if a.shutdownFlushCancel != nil {
+			a.shutdownFlushCancel()
+		}
+	}()
 
 	go func() {
 		<-a.Context.Ctx.Done()
@@ -839,7 +1027,7 @@ func (a *Agent) handleInventory(exit chan struct{}) {
 
 	// Timers
 	reapInventoryTimer := time.NewTicker(cfg.FirstReapInterval)
-	sendInventoryTimer := time.NewTimer(cfg.SendInterval) // Send any deltas every X seconds
+	sendInventoryTimer := time.NewTimer(a.inventorySendInterval(cfg.SendInterval)) // Send any deltas every X seconds
 
 	// Remove send timer
 	if !a.shouldSendInventory() {
@@ -852,15 +1040,17 @@ func (a *Agent) handleInventory(exit chan struct{}) {
 	}
 
 	// Timer to engage the process of deleting entities that haven't been reported information during this time
-	removeEntitiesPeriod, err := time.ParseDuration(a.Context.Config().RemoveEntitiesPeriod)
-	if removeEntitiesPeriod <= 0 || err != nil {
-		removeEntitiesPeriod = defaultRemoveEntitiesPeriod
-		err = nil
-	}
-
-	removeEntitiesTicker := time.NewTicker(removeEntitiesPeriod)
+	removeEntitiesTicker := time.NewTicker(a.removeEntitiesPeriod())
 	reportedEntities := map[string]bool{}
 
+	// Compactions of the whole storage run here, as the deltas are stored and sent from this routine
+	var compactInventoryTimer *time.Timer
+	var compactInventoryC <-chan time.Time
+	if interval := a.compactionInterval(); interval > 0 {
+		compactInventoryTimer = time.NewTimer(interval)
+		compactInventoryC = compactInventoryTimer.C
+	}
+
 	// Wait no more than this long for initial inventory reap even if some plugins haven't reported data
 	initialReapTimeout := time.NewTimer(config.INITIAL_REAP_MAX_WAIT_SECONDS * time.Second)
 
@@ -885,7 +1075,12 @@ func (a *Agent) handleInventory(exit chan struct{}) {
 	// ready to consume events
 	for {
 		select {
-		case <-exit:
+		case <-a.Context.Ctx.Done():
+			// deltas are flushed while the senders are stopped, exiting once they are
+			if a.shouldSendInventory() {
+				a.flushInventory()
+			}
+			<-exit
 			if sendInventoryTimer != nil {
 				sendInventoryTimer.Stop()
 			}
@@ -895,6 +1090,9 @@ func (a *Agent) handleInventory(exit chan struct{}) {
 			if removeEntitiesTicker != nil {
 				removeEntitiesTicker.Stop()
 			}
+			if compactInventoryTimer != nil {
+				compactInventoryTimer.Stop()
+			}
 			return
 			// agent gets notified about active entities
 		case ent := <-a.Context.activeEntities:
@@ -966,6 +1164,14 @@ func (a *Agent) handleInventory(exit chan struct{}) {
 			reportedEntities = map[string]bool{} // reset the set of reporting entities the next period
 			alog.Debug("Triggered periodic removal of outdated entities.")
 			a.removeOutdatedEntities(pastPeriodReportedEntities)
+		case request := <-a.resendInventoryCh:
+			a.resendInventory(request)
+		case <-compactInventoryC:
+			next, err := a.store.CompactOnInterval(a.compactionInterval())
+			if err != nil {
+				alog.WithError(err).Error("compaction error")
+			}
+			compactInventoryTimer.Reset(next)
 		}
 	}
 }
@@ -1059,17 +1265,25 @@ func (a *Agent) dumpMemoryProfile(agentRuntimeMark int) {
 
 func (a *Agent) exitGracefully() {
 	log.Info("Gracefully Exiting")
+	// the deadline to flush the queued data starts now, shared by the events and inventory flushes
+	flushCtx := a.shutdownFlushContext()
 
-	if a.Context.eventSender != nil {
-		if err := a.Context.eventSender.Stop(); err != nil {
-			log.WithError(err).Error("failed to stop event sender")
-		}
-	}
+	// samplers are stopped first, so no more events are queued while they are flushed
 	if a.metricsSender != nil {
 		if err := a.metricsSender.Stop(); err != nil {
 			log.WithError(err).Error("failed to stop metrics subsystem")
 		}
 	}
+	if a.Context.liveness != nil {
+		a.Context.liveness.remove()
+	}
+	if a.Context.eventSender != nil {
+		if err := a.Context.eventSender.Stop(); err != nil {
+			log.WithError(err).Error("failed to stop event sender")
+		} else {
+			a.flushEvents(flushCtx)
+		}
+	}
 
 	if a.inventoryHandler != nil {
 		a.inventoryHandler.Stop()
@@ -1080,31 +1294,136 @@ func (a *Agent) exitGracefully() {
 	}
 }
 
+// eventFlusher is implemented by the event senders able to send the events queued before being stopped.
+type eventFlusher interface {
+	Flush(ctx context2.Context) (dropped int)
+}
+
+// shutdownFlushTimeout returns the maximum time to flush the queued data on shutdown. Zero disables the flush.
+func (a *Agent) shutdownFlushTimeout() time.Duration {
+	timeout, err := time.ParseDuration(a.Context.cfg.ShutdownFlushTimeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+// shutdownFlushContext returns the context bounding the flush of the queued data on shutdown. Its deadline is set
+// the first time it's requested, once the shutdown starts, so all the shutdown phases share it.
+func (a *Agent) shutdownFlushContext() context2.Context {
+	a.shutdownFlushOnce.Do(func() {
+		a.shutdownFlushCtx, a.shutdownFlushCancel = context2.WithTimeout(context2.Background(), a.shutdownFlushTimeout())
+	})
+	return a.shutdownFlushCtx
+}
+
+// flushEvents sends the events queued by the stopped event sender, until the shutdown flush deadline.
+func (a *Agent) flushEvents(ctx context2.Context) {
+	flusher, ok := a.Context.eventSender.(eventFlusher)
+	if !ok || a.shutdownFlushTimeout() <= 0 {
+		return
+	}
+
+	if dropped := flusher.Flush(ctx); dropped > 0 {
+		alog.WithField("droppedEvents", dropped).WithField("timeout", a.shutdownFlushTimeout()).
+			Warn("Cannot flush all the queued events on shutdown.")
+	}
+}
+
+// flushInventory submits the pending inventory deltas on shutdown, until the shutdown flush deadline. Deltas not
+// accepted by then are kept and submitted on the next start.
+func (a *Agent) flushInventory() {
+	if a.shutdownFlushTimeout() <= 0 {
+		return
+	}
+	ctx := a.shutdownFlushContext()
+
+	sent := make(chan error, 1)
+	go func() {
+		for _, i := range a.inventories {
+			if err := i.sender.Process(); err != nil {
+				sent <- err
+				return
+			}
+		}
+		sent <- nil
+	}()
+
+	select {
+	case err := <-sent:
+		if err != nil {
+			alog.WithError(err).Warn("Cannot flush the inventory deltas on shutdown, they will be submitted on the next start.")
+		}
+	case <-ctx.Done():
+		alog.WithField("timeout", a.shutdownFlushTimeout()).
+			Warn("Inventory deltas flush timed out on shutdown, they will be submitted on the next start.")
+	}
+}
+
 func (a *Agent) sendInventory(sendTimer *time.Timer) {
-	backoffMax := config.MAX_BACKOFF
+	backoffMax, backoffStep := inventoryRetryBackoff(a.Context.cfg)
+	if a.inv.retryStatusClass != "" {
+		backoff.SubmissionRetries.Inc(backoff.InventorySender, a.inv.retryStatusClass)
+	}
+	var retryAfter time.Duration
 	for _, i := range a.inventories {
 		err := i.sender.Process()
 		if err != nil {
-			if ingestError, ok := err.(*inventoryapi.IngestError); ok &&
-				ingestError.StatusCode == http.StatusTooManyRequests {
+			statusCode := 0
+			ingestError, ok := err.(*inventoryapi.IngestError)
+			if ok {
+				statusCode = ingestError.StatusCode
+				retryAfter = ingestError.RetryAfter
+			}
+			if ok && ingestError.StatusCode == http.StatusTooManyRequests {
 				alog.Warn("server is rate limiting inventory submission")
-				backoffMax = config.RATE_LIMITED_BACKOFF
+				backoffMax = time.Duration(config.RATE_LIMITED_BACKOFF) * time.Second
 				a.inv.sendErrorCount = helpers.MaxBackoffErrorCount
 			} else {
 				a.inv.sendErrorCount++
 			}
+			a.inv.retryStatusClass = backoff.StatusClass(statusCode)
 			alog.WithError(err).WithField("errorCount", a.inv.sendErrorCount).
 				Debug("Inventory sender can't process after retrying.")
 			// Assuming break will try to send later the data from the missing inventory senders
 			break
 		} else {
 			a.inv.sendErrorCount = 0
+			a.inv.retryStatusClass = ""
 		}
 	}
-	sendTimerVal := helpers.ExpBackoff(a.Context.cfg.SendInterval,
-		time.Duration(backoffMax)*time.Second,
-		a.inv.sendErrorCount)
-	sendTimer.Reset(sendTimerVal)
+	sendTimerVal := helpers.ExpBackoffWithStep(a.Context.cfg.SendInterval, backoffStep, backoffMax, a.inv.sendErrorCount)
+	if retryAfter > 0 && sendTimerVal > retryAfter {
+		sendTimerVal = retryAfter
+	}
+	sendTimer.Reset(a.inventorySendInterval(sendTimerVal))
+}
+
+// inventorySendInterval returns the interval until the next inventory deltas submission, which is never lower than
+// the configured minimum submission interval, so frequent inventory changes are coalesced.
+func (a *Agent) inventorySendInterval(interval time.Duration) time.Duration {
+	minInterval := time.Duration(a.Context.cfg.InventoryMinSubmissionIntervalSec) * time.Second
+	if interval < minInterval {
+		return minInterval
+	}
+	return interval
+}
+
+// compactionInterval returns the interval between compactions of the whole inventory storage, 0 if disabled.
+func (a *Agent) compactionInterval() time.Duration {
+	if !a.Context.cfg.CompactEnabled {
+		return 0
+	}
+	return time.Duration(a.Context.cfg.CompactionIntervalHours) * time.Hour
+}
+
+// removeEntitiesPeriod returns the period after which the entities that haven't reported information are removed.
+func (a *Agent) removeEntitiesPeriod() time.Duration {
+	removeEntitiesPeriod, err := time.ParseDuration(a.Context.Config().RemoveEntitiesPeriod)
+	if removeEntitiesPeriod <= 0 || err != nil {
+		return defaultRemoveEntitiesPeriod
+	}
+	return removeEntitiesPeriod
 }
 
 func (a *Agent) removeOutdatedEntities(reportedEntities map[string]bool) {
@@ -1124,6 +1443,10 @@ func (a *Agent) removeOutdatedEntities(reportedEntities map[string]bool) {
 		elog.Debug("Removing inventory for entity.")
 		if err := a.unregisterEntityInventory(entityKey); err != nil {
 			elog.WithError(err).Warn("unregistering inventory for entity")
+			continue
+		}
+		if a.Context.cfg.RemoveEntitiesAudit {
+			inventory.AuditEntityRemoval(entityKey, a.removeEntitiesPeriod())
 		}
 	}
 	// Remove folders from unregistered entities that still have folders in the data directory (e.g. from
@@ -1167,6 +1490,10 @@ func (c *context) SendEvent(event sample.Event, entityKey entity.Key) {
 	_, txn := instrumentation.SelfInstrumentation.StartTransaction(context2.Background(), "agent.queue_event")
 	defer txn.End()
 
+	if c.liveness != nil {
+		c.liveness.sampleProduced()
+	}
+
 	if c.eventSender == nil {
 		aclog.
 			WithField("entity_key", entityKey.String()).
@@ -1174,6 +1501,14 @@ func (c *context) SendEvent(event sample.Event, entityKey entity.Key) {
 		return
 	}
 
+	if c.eventTypeFilter != nil && !c.eventTypeFilter.Allows(event) {
+		aclog.
+			WithField("entity_key", entityKey.String()).
+			WithField("event_type", sample.TypeOf(event)).
+			Debug("event dropped by event type filter")
+		return
+	}
+
 	// truncates string fields larger than 4095 chars
 	if c.cfg.TruncTextValues {
 		var truncated bool
@@ -1204,6 +1539,9 @@ func (c *context) SendEvent(event sample.Event, entityKey entity.Key) {
 			WithField("entity_key", entityKey.String()).
 			WithField("event", fmt.Sprintf("%#v", event)).
 			Debug("event excluded by metric matcher")
+		if c.droppedSamples != nil {
+			c.droppedSamples.Inc(sampleType(event))
+		}
 		return
 	}
 
@@ -1238,6 +1576,39 @@ func (c *context) Config() *config.Config {
 	return c.cfg
 }
 
+// DeploymentMarker returns the deployment marker the submitted events are decorated with, empty when not set.
+func (c *context) DeploymentMarker() string {
+	marker, _ := c.deploymentMarker.Load().(string)
+	return marker
+}
+
+// SetDeploymentMarker sets the deployment marker the submitted events are decorated with.
+func (c *context) SetDeploymentMarker(marker string) {
+	c.deploymentMarker.Store(marker)
+}
+
+// CustomAttributes returns the custom attributes the host is decorated with.
+func (c *context) CustomAttributes() config.CustomAttributeMap {
+	attributes, _ := c.customAttributes.Load().(config.CustomAttributeMap)
+	return attributes
+}
+
+// SetCustomAttributes sets the custom attributes the host is decorated with.
+func (c *context) SetCustomAttributes(attributes config.CustomAttributeMap) {
+	c.customAttributes.Store(attributes)
+}
+
+// CustomAttributes returns the custom attributes the host is decorated with, as last reloaded when the context
+// supports reloading them, or as configured otherwise.
+func CustomAttributes(ctx AgentContext) config.CustomAttributeMap {
+	if reloadable, ok := ctx.(interface {
+		CustomAttributes() config.CustomAttributeMap
+	}); ok {
+		return reloadable.CustomAttributes()
+	}
+	return ctx.Config().CustomAttributes
+}
+
 func (c *context) EntityKey() string {
 	return c.getAgentKey()
 }
@@ -6,6 +6,7 @@ package agent
 
 import (
 	"bytes"
+	"compress/gzip"
 	context2 "context"
 	"encoding/json"
 	"fmt"
@@ -61,14 +62,14 @@ func newTesting(cfg *config.Config) *Agent {
 	if cfg == nil {
 		cfg = config.NewTest(dataDir)
 	}
-	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false)
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
 	lookups := NewIdLookup(hostname.CreateResolver("", "", true), cloudDetector, cfg.DisplayName)
 
 	ctx := NewContext(cfg, "1.2.3", testhelpers.NullHostnameResolver, lookups, matcher, matcher)
 
 	st := delta.NewStore(dataDir, "default", cfg.MaxInventorySize, true)
 
-	fpHarvester, err := fingerprint.NewHarvestor(cfg, testhelpers.NullHostnameResolver, cloudDetector)
+	fpHarvester, err := fingerprint.NewHarvestor(cfg, cfg.DisplayName, testhelpers.NullHostnameResolver, cloudDetector)
 	if err != nil {
 		panic(err)
 	}
@@ -375,6 +376,33 @@ func TestRemoveOutdatedEntities(t *testing.T) {
 	}
 }
 
+func TestNewPatchSender_IdentityCacheFile(t *testing.T) {
+	// Given an agent configured with a custom identity cache file
+	identityFile := filepath.Join(t.TempDir(), "identity")
+	cfg := config.NewTest(t.TempDir())
+	cfg.IdentityCacheFile = identityFile
+	agent := newTesting(cfg)
+	defer os.RemoveAll(agent.store.DataDir)
+
+	// When the patch sender for the agent entity persists its entity ID
+	ps, err := agent.newPatchSender(entity.NewFromNameWithoutID(agent.Context.EntityKey()))
+	require.NoError(t, err)
+	require.NoError(t, ps.(*patchSenderIngest).lastEntityID.UpdateEntityID(entity.ID(123)))
+
+	// Then the identity is written at the configured path
+	content, err := ioutil.ReadFile(identityFile)
+	require.NoError(t, err)
+	assert.Equal(t, "123", string(content))
+
+	// And other entities keep using the data directory
+	ps, err = agent.newPatchSender(entity.NewFromNameWithoutID("remote:entity"))
+	require.NoError(t, err)
+	require.NoError(t, ps.(*patchSenderIngest).lastEntityID.UpdateEntityID(entity.ID(456)))
+	content, err = ioutil.ReadFile(identityFile)
+	require.NoError(t, err)
+	assert.Equal(t, "123", string(content))
+}
+
 func TestReconnectablePlugins(t *testing.T) {
 	// Given an agent
 	a := newTesting(nil)
@@ -403,6 +431,32 @@ func TestReconnectablePlugins(t *testing.T) {
 	assert.Equal(t, 2, rp.invocations)
 }
 
+func TestAgent_SetCustomAttributes(t *testing.T) {
+	// Given an agent with custom attributes
+	cfg := config.NewTest(t.TempDir())
+	cfg.CustomAttributes = config.CustomAttributeMap{"env": "staging"}
+	a := newTesting(cfg)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	nrp := nonReconnectingPlugin{invocations: 0, wg: &wg}
+	a.RegisterPlugin(&nrp)
+	attrsPlugin := &customAttrsPlugin{context: a.Context, reported: make(chan config.CustomAttributeMap, 1)}
+	a.RegisterPlugin(attrsPlugin)
+	a.startPlugins()
+	assert.NoError(t, wait(time.Second, &wg))
+	assert.Equal(t, config.CustomAttributeMap{"env": "staging"}, <-attrsPlugin.reported)
+
+	// When the custom attributes are reloaded
+	a.SetCustomAttributes(config.CustomAttributeMap{"env": "production"})
+
+	// The custom attributes plugin reports them again
+	assert.Equal(t, config.CustomAttributeMap{"env": "production"}, <-attrsPlugin.reported)
+	assert.Equal(t, config.CustomAttributeMap{"env": "production"}, CustomAttributes(a.Context))
+	// And the other plugins are not invoked again
+	assert.Equal(t, 1, nrp.invocations)
+}
+
 func TestCheckConnectionRetry(t *testing.T) {
 	// Given a server that returns timeouts and eventually accepts the requests
 	ts := NewTimeoutServer(2)
@@ -681,6 +735,31 @@ func (nonReconnectingPlugin) GetExternalPluginName() string {
 	return ""
 }
 
+type customAttrsPlugin struct {
+	context  AgentContext
+	reported chan config.CustomAttributeMap
+}
+
+func (p *customAttrsPlugin) Run() {
+	p.reported <- CustomAttributes(p.context)
+}
+
+func (customAttrsPlugin) Id() ids.PluginID {
+	return ids.CustomAttrsID
+}
+
+func (customAttrsPlugin) LogInfo() {}
+
+func (customAttrsPlugin) ScheduleHealthCheck() {}
+
+func (customAttrsPlugin) IsExternal() bool {
+	return false
+}
+
+func (customAttrsPlugin) GetExternalPluginName() string {
+	return ""
+}
+
 type TimeoutServer struct {
 	unblock        chan interface{}
 	invocations    *int32
@@ -1233,3 +1312,65 @@ func TestAgent_checkInstanceIDRetry(t *testing.T) {
 		})
 	}
 }
+
+func TestAgent_ExitGracefully_FlushesQueuedEvents(t *testing.T) {
+	collector := newEventsCollector(t)
+	cfg := config.NewTest(t.TempDir())
+	cfg.CollectorURL = collector.URL
+	cfg.PayloadCompressionLevel = gzip.NoCompression
+	cfg.EventQueueDepth = 2000
+	cfg.ShutdownFlushTimeout = "5s"
+	cfg.ConnectEnabled = false
+	a := newTesting(cfg)
+
+	sender := newMetricsIngestSender(a.Context, "license", "userAgent", collector.Client().Do, false)
+	a.Context.eventSender = sender
+	require.NoError(t, sender.Start())
+	for i := 0; i < 1200; i++ {
+		require.NoError(t, sender.QueueEvent(mapEvent{"eventType": "TestEvent", "value": i}, ""))
+	}
+
+	a.exitGracefully()
+
+	events, _ := collector.received()
+	assert.Equal(t, 1200, events)
+}
+
+// blockingFlushSender event sender whose flush takes until the flush deadline.
+type blockingFlushSender struct{}
+
+func (blockingFlushSender) QueueEvent(sample.Event, entity.Key) error { return nil }
+func (blockingFlushSender) Start() error                              { return nil }
+func (blockingFlushSender) Stop() error                               { return nil }
+func (blockingFlushSender) Flush(ctx context2.Context) int {
+	<-ctx.Done()
+	return 1
+}
+
+// blockingPatchSender patch sender blocking the submissions until unblocked.
+type blockingPatchSender struct {
+	unblock chan struct{}
+}
+
+func (p *blockingPatchSender) Process() error {
+	<-p.unblock
+	return nil
+}
+
+func TestAgent_ExitGracefully_SharesFlushDeadline(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+	cfg := config.NewTest(t.TempDir())
+	cfg.ShutdownFlushTimeout = timeout.String()
+	a := newTesting(cfg)
+	a.Context.eventSender = blockingFlushSender{}
+	inventorySender := &blockingPatchSender{unblock: make(chan struct{})}
+	defer close(inventorySender.unblock)
+	a.inventories = map[string]*inventoryEntity{"localhost": {sender: inventorySender}}
+
+	start := time.Now()
+	a.exitGracefully()
+	a.flushInventory()
+
+	// the inventory flush doesn't get a timeout of its own once the events flush spent it
+	assert.Less(t, time.Since(start), 2*timeout)
+}
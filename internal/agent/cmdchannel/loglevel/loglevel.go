@@ -0,0 +1,143 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package loglevel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel"
+	"github.com/newrelic/infrastructure-agent/pkg/backend/commandapi"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+)
+
+const cmdName = "set_log_level"
+
+// Errors
+var (
+	ErrInvalidLevel = errors.New("invalid log level, expected one of info, debug, trace or smart")
+	ErrInvalidTTL   = errors.New("invalid ttl, expected a positive duration as \"30m\"")
+)
+
+// levels that can be set from the command channel.
+var levels = map[string]struct{}{ //nolint:gochecknoglobals
+	config.LogLevelInfo:  {},
+	config.LogLevelDebug: {},
+	config.LogLevelTrace: {},
+	config.LogLevelSmart: {},
+}
+
+type args struct {
+	Level string `json:"level"`
+	// TTL after which the configured level is restored, as "30m". The level is kept until the agent restarts or
+	// its config is reloaded when empty.
+	TTL string `json:"ttl"`
+}
+
+// ConfiguredLogProvide provides the log configuration of the agent config, restored once the ttl expires.
+type ConfiguredLogProvide func() config.LogConfig
+
+// ApplyLogConfigF applies the level of a log configuration at runtime.
+type ApplyLogConfigF func(config.LogConfig)
+
+// handler handles the log level change requests, keeping the pending revert to the configured level.
+type handler struct {
+	configured ConfiguredLogProvide
+	apply      ApplyLogConfigF
+	logger     log.Entry
+	lock       sync.Mutex
+	revert     *time.Timer
+	// requests counts the handled requests, so a revert that already fired doesn't restore a newer request level
+	requests int
+}
+
+// NewHandler creates a cmd-channel handler for set-log-level requests. The level is applied through apply, with the
+// configured smart level entry limit, so switching into the smart level honors it.
+func NewHandler(configured ConfiguredLogProvide, apply ApplyLogConfigF, logger log.Entry) *cmdchannel.CmdHandler {
+	h := &handler{
+		configured: configured,
+		apply:      apply,
+		logger:     logger,
+	}
+
+	return cmdchannel.NewCmdHandler(cmdName, h.handle)
+}
+
+func (h *handler) handle(_ context.Context, cmd commandapi.Command, _ bool) error {
+	var a args
+	if err := json.Unmarshal(cmd.Args, &a); err != nil {
+		return cmdchannel.NewArgsErr(err)
+	}
+
+	level := strings.ToLower(strings.TrimSpace(a.Level))
+	if _, ok := levels[level]; !ok {
+		return cmdchannel.NewArgsErr(fmt.Errorf("%w: %q", ErrInvalidLevel, a.Level))
+	}
+
+	var ttl time.Duration
+	if a.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(a.TTL)
+		if err != nil || ttl <= 0 {
+			return cmdchannel.NewArgsErr(fmt.Errorf("%w: %q", ErrInvalidTTL, a.TTL))
+		}
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// a newer request replaces the pending revert of the previous one
+	if h.revert != nil {
+		h.revert.Stop()
+		h.revert = nil
+	}
+	h.requests++
+	request := h.requests
+
+	logCfg := h.configured()
+	previousLevel := log.GetLevel()
+	logCfg.Level = level
+	h.apply(logCfg)
+
+	entry := h.logger.
+		WithField("cmd_id", cmd.ID).
+		WithField("cmd_hash", cmd.Hash).
+		WithField("cmd_metadata", fmt.Sprintf("%+v", cmd.Metadata)).
+		WithField("changed_at", time.Now().Format(time.RFC3339)).
+		WithField("previous_level", previousLevel.String()).
+		WithField("level", level)
+	if ttl > 0 {
+		entry = entry.WithField("ttl", ttl.String())
+		h.revert = time.AfterFunc(ttl, func() {
+			h.restoreConfigured(request)
+		})
+	}
+	entry.Info("Log level changed from command channel.")
+
+	return nil
+}
+
+// restoreConfigured applies the log level of the agent config once the ttl of the request level expires, unless a
+// newer request was handled meanwhile.
+func (h *handler) restoreConfigured(request int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if request != h.requests {
+		return
+	}
+
+	logCfg := h.configured()
+	h.apply(logCfg)
+	h.revert = nil
+	h.logger.
+		WithField("restored_at", time.Now().Format(time.RFC3339)).
+		WithField("level", logCfg.Level).
+		Info("Log level restored to the configured one.")
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package loglevel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel"
+	"github.com/newrelic/infrastructure-agent/pkg/backend/commandapi"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+)
+
+var l = log.WithComponent("test")
+
+// appliedLogConfigs records the log configurations applied by the handler.
+type appliedLogConfigs struct {
+	lock    sync.Mutex
+	applied []config.LogConfig
+}
+
+func (a *appliedLogConfigs) apply(cfg config.LogConfig) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.applied = append(a.applied, cfg)
+}
+
+func (a *appliedLogConfigs) levels() []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	var levels []string
+	for _, cfg := range a.applied {
+		levels = append(levels, cfg.Level)
+	}
+	return levels
+}
+
+func configuredLog() config.LogConfig {
+	smartLimit := 50
+	return config.LogConfig{Level: config.LogLevelInfo, SmartLevelEntryLimit: &smartLimit}
+}
+
+func setLogLevelCmd(args string) commandapi.Command {
+	return commandapi.Command{
+		ID:       1,
+		Hash:     "abc",
+		Name:     cmdName,
+		Args:     []byte(args),
+		Metadata: map[string]interface{}{"requested_by": "jane@example.com"},
+	}
+}
+
+func TestHandle_SetsLevel(t *testing.T) {
+	applied := &appliedLogConfigs{}
+	h := NewHandler(configuredLog, applied.apply, l)
+
+	for _, level := range []string{"debug", "TRACE", " info "} {
+		require.NoError(t, h.Handle(context.Background(), setLogLevelCmd(`{"level": "`+level+`"}`), false))
+	}
+
+	assert.Equal(t, []string{"debug", "trace", "info"}, applied.levels())
+}
+
+func TestHandle_SmartLevelHonorsEntryLimit(t *testing.T) {
+	applied := &appliedLogConfigs{}
+	h := NewHandler(configuredLog, applied.apply, l)
+
+	require.NoError(t, h.Handle(context.Background(), setLogLevelCmd(`{"level": "smart"}`), false))
+
+	require.Len(t, applied.applied, 1)
+	assert.True(t, applied.applied[0].IsSmartLogging())
+	assert.Equal(t, 50, applied.applied[0].GetSmartLogLevelLimit())
+}
+
+func TestHandle_RejectsInvalidArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		wantErr error
+	}{
+		{name: "unknown level", args: `{"level": "verbose"}`, wantErr: ErrInvalidLevel},
+		{name: "level not allowed", args: `{"level": "error"}`, wantErr: ErrInvalidLevel},
+		{name: "missing level", args: `{}`, wantErr: ErrInvalidLevel},
+		{name: "invalid ttl", args: `{"level": "debug", "ttl": "soon"}`, wantErr: ErrInvalidTTL},
+		{name: "negative ttl", args: `{"level": "debug", "ttl": "-5m"}`, wantErr: ErrInvalidTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied := &appliedLogConfigs{}
+			h := NewHandler(configuredLog, applied.apply, l)
+
+			err := h.Handle(context.Background(), setLogLevelCmd(tt.args), false)
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantErr), err.Error())
+			assert.Contains(t, err.Error(), cmdchannel.ErrMsgInvalidArgs)
+			assert.Empty(t, applied.levels())
+		})
+	}
+
+	applied := &appliedLogConfigs{}
+	err := NewHandler(configuredLog, applied.apply, l).Handle(context.Background(), setLogLevelCmd(`not json`), false)
+	assert.Error(t, err)
+	assert.Empty(t, applied.levels())
+}
+
+func TestHandle_RestoresConfiguredLevelAfterTTL(t *testing.T) {
+	applied := &appliedLogConfigs{}
+	h := NewHandler(configuredLog, applied.apply, l)
+
+	require.NoError(t, h.Handle(context.Background(), setLogLevelCmd(`{"level": "trace", "ttl": "50ms"}`), false))
+
+	assert.Eventually(t, func() bool {
+		levels := applied.levels()
+		return len(levels) == 2 && levels[1] == config.LogLevelInfo
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, config.LogLevelTrace, applied.levels()[0])
+}
+
+func TestHandle_NewerRequestCancelsRestore(t *testing.T) {
+	applied := &appliedLogConfigs{}
+	h := NewHandler(configuredLog, applied.apply, l)
+
+	require.NoError(t, h.Handle(context.Background(), setLogLevelCmd(`{"level": "trace", "ttl": "50ms"}`), false))
+	require.NoError(t, h.Handle(context.Background(), setLogLevelCmd(`{"level": "debug"}`), false))
+
+	// the level of the newer request, without ttl, is kept
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, []string{"trace", "debug"}, applied.levels())
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package profile
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	capturesDir = "profiles"
+	// defaultRetention time a captured profile can be downloaded for, before it's removed.
+	defaultRetention = time.Hour
+	tokenBytes       = 16
+)
+
+// Captures stores the profiles captured on request, each downloadable once through its token until it expires.
+type Captures struct {
+	dir       string
+	retention time.Duration
+	lock      sync.Mutex
+	byToken   map[string]string
+}
+
+// NewCaptures creates the store of captured profiles, written under the agent directory. Profiles left by a previous
+// run are removed.
+func NewCaptures(agentDir string) *Captures {
+	dir := filepath.Join(agentDir, capturesDir)
+	_ = os.RemoveAll(dir)
+
+	return &Captures{
+		dir:       dir,
+		retention: defaultRetention,
+		byToken:   make(map[string]string),
+	}
+}
+
+// Take returns the path of the profile of the token, which is no longer valid afterwards. Removing the file once read
+// is up to the caller.
+func (c *Captures) Take(token string) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	path, ok := c.byToken[token]
+	delete(c.byToken, token)
+	return path, ok
+}
+
+// prepare creates the directory profiles are written to.
+func (c *Captures) prepare() error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("cannot create profiles directory: %w", err)
+	}
+	return nil
+}
+
+// add stores the profile at path, returning its download token. The profile is removed if not taken before it expires.
+func (c *Captures) add(path string) (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate download token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	c.lock.Lock()
+	c.byToken[token] = path
+	c.lock.Unlock()
+
+	time.AfterFunc(c.retention, func() {
+		if _, ok := c.Take(token); ok {
+			_ = os.Remove(path)
+		}
+	})
+
+	return token, nil
+}
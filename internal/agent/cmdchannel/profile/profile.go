@@ -0,0 +1,176 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel"
+	"github.com/newrelic/infrastructure-agent/pkg/backend/commandapi"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+)
+
+const (
+	cmdName = "capture_profile"
+
+	profileTypeCPU  = "cpu"
+	profileTypeHeap = "heap"
+
+	defaultCPUDuration = 30 * time.Second
+	// maxCPUDuration hard cap for the duration of a CPU profile, longer requested durations are cut to it.
+	maxCPUDuration = 5 * time.Minute
+)
+
+// Errors
+var (
+	ErrNotAllowed        = errors.New("remote profiling is not allowed, enable allow_remote_profiling")
+	ErrInvalidType       = errors.New("invalid profile type, expected cpu or heap")
+	ErrInvalidDuration   = errors.New("invalid duration, expected a positive duration as \"30s\"")
+	ErrCaptureInProgress = errors.New("a profile capture is already in progress")
+)
+
+type args struct {
+	Type string `json:"type"`
+	// Duration of the CPU profile, as "30s". Ignored by the heap profile, which is a snapshot.
+	Duration string `json:"duration"`
+}
+
+// AllowedProvide provides whether remote profiling is allowed by the agent config.
+type AllowedProvide func() bool
+
+// handler handles the profile capture requests, running one capture at a time.
+type handler struct {
+	allowed        AllowedProvide
+	captures       *Captures
+	logger         log.Entry
+	maxCPUDuration time.Duration
+	capturing      atomic.Bool
+}
+
+// NewHandler creates a cmd-channel handler for capture-profile requests. Captured profiles are stored in captures,
+// from where they can be downloaded once through the status server with the logged token.
+func NewHandler(allowed AllowedProvide, captures *Captures, logger log.Entry) *cmdchannel.CmdHandler {
+	return cmdchannel.NewCmdHandler(cmdName, newHandler(allowed, captures, logger).handle)
+}
+
+func newHandler(allowed AllowedProvide, captures *Captures, logger log.Entry) *handler {
+	return &handler{
+		allowed:        allowed,
+		captures:       captures,
+		logger:         logger,
+		maxCPUDuration: maxCPUDuration,
+	}
+}
+
+func (h *handler) handle(ctx context.Context, cmd commandapi.Command, _ bool) error {
+	if !h.allowed() {
+		return ErrNotAllowed
+	}
+
+	var a args
+	if err := json.Unmarshal(cmd.Args, &a); err != nil {
+		return cmdchannel.NewArgsErr(err)
+	}
+
+	profileType := strings.ToLower(strings.TrimSpace(a.Type))
+	if profileType != profileTypeCPU && profileType != profileTypeHeap {
+		return cmdchannel.NewArgsErr(fmt.Errorf("%w: %q", ErrInvalidType, a.Type))
+	}
+
+	duration := defaultCPUDuration
+	if a.Duration != "" {
+		var err error
+		duration, err = time.ParseDuration(a.Duration)
+		if err != nil || duration <= 0 {
+			return cmdchannel.NewArgsErr(fmt.Errorf("%w: %q", ErrInvalidDuration, a.Duration))
+		}
+	}
+
+	if !h.capturing.CompareAndSwap(false, true) {
+		return ErrCaptureInProgress
+	}
+	defer h.capturing.Store(false)
+
+	entry := h.logger.
+		WithField("cmd_id", cmd.ID).
+		WithField("cmd_hash", cmd.Hash).
+		WithField("cmd_metadata", fmt.Sprintf("%+v", cmd.Metadata)).
+		WithField("type", profileType)
+
+	if err := h.captures.prepare(); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(h.captures.dir, profileType+"-*.pprof")
+	if err != nil {
+		return fmt.Errorf("cannot create profile file: %w", err)
+	}
+
+	if profileType == profileTypeCPU {
+		if duration > h.maxCPUDuration {
+			entry.WithField("requested_duration", duration.String()).Warn("Requested CPU profile duration is capped.")
+			duration = h.maxCPUDuration
+		}
+		entry = entry.WithField("duration", duration.String())
+		entry.Info("Capturing CPU profile.")
+		err = captureCPU(ctx, f, duration)
+	} else {
+		err = captureHeap(f)
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	token, err := h.captures.add(f.Name())
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	entry.
+		WithField("file", f.Name()).
+		WithField("download_token", token).
+		WithField("expires_in", h.captures.retention.String()).
+		Info("Profile captured, it can be downloaded once from the status server profile endpoint with the token.")
+
+	return nil
+}
+
+// captureCPU writes the CPU profile of the given duration, stopping earlier when ctx is done.
+func captureCPU(ctx context.Context, f *os.File, duration time.Duration) error {
+	if err := pprof.StartCPUProfile(f); err != nil {
+		// another CPU profile is running, as the one of the cpuprofile flag
+		return fmt.Errorf("cannot start the CPU profile: %w", err)
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		pprof.StopCPUProfile()
+		return nil
+	case <-ctx.Done():
+		pprof.StopCPUProfile()
+		return ctx.Err()
+	}
+}
+
+// captureHeap writes a snapshot of the heap profile, up to date as of the last garbage collection.
+func captureHeap(f *os.File) error {
+	runtime.GC()
+	return pprof.Lookup("heap").WriteTo(f, 0)
+}
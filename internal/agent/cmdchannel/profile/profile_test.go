@@ -0,0 +1,210 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package profile
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel"
+	"github.com/newrelic/infrastructure-agent/pkg/backend/commandapi"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+)
+
+var l = log.WithComponent("test")
+
+func allowed() bool { return true }
+
+func newTestCaptures(t *testing.T) *Captures {
+	t.Helper()
+	agentDir, err := ioutil.TempDir("", "agent")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(agentDir) })
+	return NewCaptures(agentDir)
+}
+
+func captureProfileCmd(args string) commandapi.Command {
+	return commandapi.Command{
+		ID:       1,
+		Hash:     "abc",
+		Name:     cmdName,
+		Args:     []byte(args),
+		Metadata: map[string]interface{}{"requested_by": "jane@example.com"},
+	}
+}
+
+// capturedProfiles returns the paths of the profiles stored by captures.
+func capturedProfiles(c *Captures) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var paths []string
+	for _, path := range c.byToken {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestHandle_CapturesProfile(t *testing.T) {
+	for _, tt := range []struct {
+		args   string
+		prefix string
+	}{
+		{args: `{"type": "heap"}`, prefix: "heap-"},
+		{args: `{"type": "CPU", "duration": "50ms"}`, prefix: "cpu-"},
+	} {
+		t.Run(tt.prefix, func(t *testing.T) {
+			captures := newTestCaptures(t)
+
+			require.NoError(t, newHandler(allowed, captures, l).handle(context.Background(), captureProfileCmd(tt.args), false))
+
+			paths := capturedProfiles(captures)
+			require.Len(t, paths, 1)
+			assert.Equal(t, captures.dir, filepath.Dir(paths[0]))
+			assert.Contains(t, filepath.Base(paths[0]), tt.prefix)
+			info, err := os.Stat(paths[0])
+			require.NoError(t, err)
+			assert.NotZero(t, info.Size())
+		})
+	}
+}
+
+func TestHandle_CPUDurationIsCapped(t *testing.T) {
+	h := newHandler(allowed, newTestCaptures(t), l)
+	h.maxCPUDuration = 50 * time.Millisecond
+
+	start := time.Now()
+	require.NoError(t, h.handle(context.Background(), captureProfileCmd(`{"type": "cpu", "duration": "1h"}`), false))
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestHandle_OneCaptureAtATime(t *testing.T) {
+	captures := newTestCaptures(t)
+	h := newHandler(allowed, captures, l)
+
+	// GIVEN a CPU capture in progress
+	done := make(chan error)
+	go func() {
+		done <- h.handle(context.Background(), captureProfileCmd(`{"type": "cpu", "duration": "500ms"}`), false)
+	}()
+	require.Eventually(t, h.capturing.Load, time.Second, time.Millisecond)
+
+	// WHEN another capture is requested
+	err := h.handle(context.Background(), captureProfileCmd(`{"type": "heap"}`), false)
+
+	// THEN it's rejected
+	assert.True(t, errors.Is(err, ErrCaptureInProgress))
+	require.NoError(t, <-done)
+	assert.Len(t, capturedProfiles(captures), 1)
+
+	// AND a capture can be requested once the previous one is done
+	assert.NoError(t, h.handle(context.Background(), captureProfileCmd(`{"type": "heap"}`), false))
+}
+
+func TestHandle_CPUCaptureStopsOnCancel(t *testing.T) {
+	captures := newTestCaptures(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := newHandler(allowed, captures, l).handle(ctx, captureProfileCmd(`{"type": "cpu", "duration": "1m"}`), false)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Empty(t, capturedProfiles(captures))
+	files, err := ioutil.ReadDir(captures.dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestHandle_NotAllowed(t *testing.T) {
+	captures := newTestCaptures(t)
+	notAllowed := func() bool { return false }
+
+	err := NewHandler(notAllowed, captures, l).Handle(context.Background(), captureProfileCmd(`{"type": "heap"}`), false)
+
+	assert.True(t, errors.Is(err, ErrNotAllowed))
+	assert.Empty(t, capturedProfiles(captures))
+}
+
+func TestHandle_RejectsInvalidArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		wantErr error
+	}{
+		{name: "unknown type", args: `{"type": "goroutine"}`, wantErr: ErrInvalidType},
+		{name: "missing type", args: `{}`, wantErr: ErrInvalidType},
+		{name: "invalid duration", args: `{"type": "cpu", "duration": "soon"}`, wantErr: ErrInvalidDuration},
+		{name: "negative duration", args: `{"type": "cpu", "duration": "-5s"}`, wantErr: ErrInvalidDuration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captures := newTestCaptures(t)
+
+			err := newHandler(allowed, captures, l).handle(context.Background(), captureProfileCmd(tt.args), false)
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantErr), err.Error())
+			assert.Contains(t, err.Error(), cmdchannel.ErrMsgInvalidArgs)
+			assert.Empty(t, capturedProfiles(captures))
+		})
+	}
+}
+
+func TestCaptures_TakeOnce(t *testing.T) {
+	captures := newTestCaptures(t)
+	require.NoError(t, captures.prepare())
+	path := filepath.Join(captures.dir, "heap-1.pprof")
+	require.NoError(t, ioutil.WriteFile(path, []byte("profile"), 0o600))
+
+	token, err := captures.add(path)
+	require.NoError(t, err)
+
+	_, ok := captures.Take("unknown")
+	assert.False(t, ok)
+
+	got, ok := captures.Take(token)
+	assert.True(t, ok)
+	assert.Equal(t, path, got)
+
+	_, ok = captures.Take(token)
+	assert.False(t, ok)
+}
+
+func TestCaptures_RemovesExpired(t *testing.T) {
+	captures := newTestCaptures(t)
+	captures.retention = 50 * time.Millisecond
+	require.NoError(t, captures.prepare())
+	path := filepath.Join(captures.dir, "heap-1.pprof")
+	require.NoError(t, ioutil.WriteFile(path, []byte("profile"), 0o600))
+
+	token, err := captures.add(path)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+	_, ok := captures.Take(token)
+	assert.False(t, ok)
+}
+
+func TestNewCaptures_RemovesPreviousRunProfiles(t *testing.T) {
+	captures := newTestCaptures(t)
+	require.NoError(t, captures.prepare())
+	path := filepath.Join(captures.dir, "cpu-1.pprof")
+	require.NoError(t, ioutil.WriteFile(path, []byte("profile"), 0o600))
+
+	NewCaptures(filepath.Dir(captures.dir))
+
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package resendinventory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel"
+	"github.com/newrelic/infrastructure-agent/pkg/backend/commandapi"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+)
+
+const cmdName = "resend_inventory"
+
+// Errors
+var (
+	ErrInvalidPlugin = errors.New("invalid plugin, expected a plugin source as \"metadata/agent_config\" or category as \"metadata\"")
+)
+
+type args struct {
+	// Plugins whose inventory is resent, all of them when empty.
+	Plugins []string `json:"plugins"`
+}
+
+// ResendF forces the whole inventory of the given plugins, or of all of them when empty, to be sent on the next reap.
+// It returns the number of plugins triggered and their approximate payload size in bytes.
+type ResendF func(plugins []string) (triggered int, size int64, err error)
+
+type handler struct {
+	resend ResendF
+	logger log.Entry
+}
+
+// NewHandler creates a cmd-channel handler for resend-inventory requests.
+func NewHandler(resend ResendF, logger log.Entry) *cmdchannel.CmdHandler {
+	h := &handler{
+		resend: resend,
+		logger: logger,
+	}
+
+	return cmdchannel.NewCmdHandler(cmdName, h.handle)
+}
+
+func (h *handler) handle(_ context.Context, cmd commandapi.Command, _ bool) error {
+	var a args
+	if len(cmd.Args) > 0 {
+		if err := json.Unmarshal(cmd.Args, &a); err != nil {
+			return cmdchannel.NewArgsErr(err)
+		}
+	}
+
+	plugins := make([]string, 0, len(a.Plugins))
+	for _, plugin := range a.Plugins {
+		p := strings.Trim(strings.TrimSpace(plugin), "/")
+		if p == "" {
+			return cmdchannel.NewArgsErr(fmt.Errorf("%w: %q", ErrInvalidPlugin, plugin))
+		}
+		plugins = append(plugins, p)
+	}
+
+	triggered, size, err := h.resend(plugins)
+	if err != nil {
+		return fmt.Errorf("cannot trigger the inventory resend: %w", err)
+	}
+
+	entry := h.logger.
+		WithField("cmd_id", cmd.ID).
+		WithField("cmd_hash", cmd.Hash).
+		WithField("cmd_metadata", fmt.Sprintf("%+v", cmd.Metadata)).
+		WithField("plugins", plugins).
+		WithField("triggered_plugins", triggered).
+		WithField("approx_payload_bytes", size)
+
+	if triggered == 0 {
+		entry.Warn("No inventory plugin matched, nothing to resend.")
+		return nil
+	}
+	entry.Info("Full inventory will be sent on the next reap.")
+
+	return nil
+}
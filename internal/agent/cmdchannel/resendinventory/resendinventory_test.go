@@ -0,0 +1,86 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package resendinventory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel"
+	"github.com/newrelic/infrastructure-agent/pkg/backend/commandapi"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+)
+
+var l = log.WithComponent("test")
+
+// resends records the plugins of the requested resends.
+type resends struct {
+	requested [][]string
+	err       error
+}
+
+func (r *resends) resend(plugins []string) (int, int64, error) {
+	r.requested = append(r.requested, plugins)
+	return len(plugins), 100, r.err
+}
+
+func resendInventoryCmd(args string) commandapi.Command {
+	return commandapi.Command{
+		ID:       1,
+		Hash:     "abc",
+		Name:     cmdName,
+		Args:     []byte(args),
+		Metadata: map[string]interface{}{"requested_by": "jane@example.com"},
+	}
+}
+
+func TestHandle_ResendsPlugins(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want []string
+	}{
+		{name: "no args", args: ``, want: []string{}},
+		{name: "all plugins", args: `{}`, want: []string{}},
+		{name: "named plugins", args: `{"plugins": [" metadata/agent_config ", "packages/"]}`, want: []string{"metadata/agent_config", "packages"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &resends{}
+
+			require.NoError(t, (&handler{resend: r.resend, logger: l}).handle(context.Background(), resendInventoryCmd(tt.args), false))
+
+			assert.Equal(t, [][]string{tt.want}, r.requested)
+		})
+	}
+}
+
+func TestHandle_RejectsInvalidArgs(t *testing.T) {
+	for _, args := range []string{`{"plugins": ["metadata", " "]}`, `{"plugins": "metadata"}`} {
+		t.Run(args, func(t *testing.T) {
+			r := &resends{}
+
+			err := NewHandler(r.resend, l).Handle(context.Background(), resendInventoryCmd(args), false)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cmdchannel.ErrMsgInvalidArgs)
+			assert.Empty(t, r.requested)
+		})
+	}
+
+	err := NewHandler((&resends{}).resend, l).Handle(context.Background(), resendInventoryCmd(`{"plugins": ["/"]}`), false)
+	assert.True(t, errors.Is(err, ErrInvalidPlugin))
+}
+
+func TestHandle_ResendError(t *testing.T) {
+	r := &resends{err: errors.New("boom")}
+
+	err := NewHandler(r.resend, l).Handle(context.Background(), resendInventoryCmd(`{}`), false)
+
+	assert.True(t, errors.Is(err, r.err))
+}
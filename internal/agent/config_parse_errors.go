@@ -0,0 +1,14 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"time"
+
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
+)
+
+const configParseErrorsReportInterval = time.Minute
+
+var configParseErrorsMetric = openmetrics.AgentMetrics.NewCounterVec("nria_config_parse_errors_total",
+	"Integrations and log forwarding configuration files that failed to parse, by file.", "file")
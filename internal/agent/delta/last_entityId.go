@@ -35,6 +35,15 @@ func NewEntityIDFilePersist(dataDir string, fileName string) *EntityIDFilePersis
 	}
 }
 
+// NewEntityIDFilePersistAt create a new instance of EntityIDFilePersist storing the EntityID in the given file path.
+func NewEntityIDFilePersistAt(filePath string) *EntityIDFilePersist {
+	return &EntityIDFilePersist{
+		readFile:  readFileFn,
+		writeFile: writeFileFn,
+		filePath:  filePath,
+	}
+}
+
 // GetEntityID will return entityID from memory or disk.
 func (e *EntityIDFilePersist) GetEntityID() (entity.ID, error) {
 	var err error
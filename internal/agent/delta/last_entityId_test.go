@@ -222,3 +222,22 @@ func cleanFolder(path string) {
 		_ = os.RemoveAll(path)
 	}()
 }
+
+func TestEntityIDFilePersistAt_ReadWriteConfiguredPath(t *testing.T) {
+	//GIVEN a custom identity file location
+	filePath := filepath.Join(t.TempDir(), "custom", "identity")
+	le := NewEntityIDFilePersistAt(filePath)
+
+	//WHEN UpdateEntityID
+	require.NoError(t, le.UpdateEntityID(entity.ID(1234)))
+
+	//THEN the entityID is written in the configured path
+	persistedID, err := ioutil.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "1234", string(persistedID))
+
+	//AND it can be read back from the configured path
+	id, err := NewEntityIDFilePersistAt(filePath).GetEntityID()
+	require.NoError(t, err)
+	assert.Equal(t, entity.ID(1234), id)
+}
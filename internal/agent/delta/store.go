@@ -5,7 +5,9 @@ package delta
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -13,6 +15,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -20,6 +23,8 @@ import (
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 
 	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/newrelic/infrastructure-agent/internal/agent/instrumentation"
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
 	"github.com/newrelic/infrastructure-agent/pkg/backend/inventoryapi"
 	"github.com/newrelic/infrastructure-agent/pkg/disk"
 	"github.com/newrelic/infrastructure-agent/pkg/helpers"
@@ -44,8 +49,20 @@ var EMPTY_DELTA = []byte{'{', '}'}
 var NULL = []byte{'n', 'u', 'l', 'l'}
 var ErrNoPreviousSuccessSubmissionTime = fmt.Errorf("no previous success submission time")
 
+// ErrInventoryTooLarge is returned when the plugin data exceeds the max inventory size, so it's dropped.
+var ErrInventoryTooLarge = errors.New("plugin data is larger than max inventory size")
+
 var slog = log.WithComponent("Delta Store")
 
+// Triggers of the storage compaction, as reported by the compaction metrics.
+const (
+	compactionTriggerSize     = "size"
+	compactionTriggerInterval = "interval"
+)
+
+var compactionReclaimedMetric = openmetrics.AgentMetrics.NewCounterVec("nria_inventory_compaction_reclaimed_bytes_total",
+	"Bytes reclaimed by the inventory storage compactions, by trigger.", "trigger")
+
 // Folders that do not belong to entities nor plugins, so they have to be ignored
 var nonEntityFolders = map[string]bool{
 	CACHE_DIR:                   true,
@@ -106,6 +123,16 @@ type Store struct {
 	lastSuccessSubmission time.Time
 	// if enabled, will save archive deltas in .sent files
 	archiveEnabled bool
+	// droppedLock guards dropped
+	droppedLock sync.Mutex
+	// dropped counts, per plugin, the inventory data dropped for exceeding maxInventorySize
+	dropped map[string]uint64
+	// if enabled, dropped inventory is reported as a self-instrumentation metric
+	droppedMetricEnabled bool
+	// compactionLock serializes the compactions, as the size and interval ones are triggered from different routines
+	compactionLock sync.Mutex
+	// lastCompaction time of the last compaction in Unix nanoseconds, 0 if the storage wasn't compacted yet
+	lastCompaction atomic.Int64
 }
 
 // NewStore creates a new Store and returns a pointer to it. If maxInventorySize <= 0, the inventory splitting is disabled
@@ -122,6 +149,7 @@ func NewStore(dataDir string, defaultEntityKey string, maxInventorySize int, arc
 		defaultEntityKey: defaultEntityKey,
 		plugins:          make(pluginSource2Info),
 		archiveEnabled:   archiveEnabled,
+		dropped:          make(map[string]uint64),
 	}
 
 	// Nice2Have: remove side effects from constructor
@@ -212,12 +240,21 @@ func (s *Store) clearPluginDeltaStore(pluginItem *PluginInfo, entityKey string)
 }
 
 func (s *Store) compactCacheStorage(entityKey string, _ uint64) (err error) {
+	for _, p := range s.compactEntityFolder(s.EntityFolder(entityKey)) {
+		delete(s.plugins, p.Source)
+	}
+	return
+}
+
+// compactEntityFolder removes the stored inventory of the plugins that don't exist anymore for the entity folder, and
+// the archived deltas of the active ones. It returns the removed plugins.
+func (s *Store) compactEntityFolder(entityFolder string) (removed []*PluginInfo) {
 	// Strategy:
 	// For any plugins that don't exist anymore, we can complete clean those out
 	// For plugins that do exist with N generations of data, remove all sent generations
 
-	if activePlugins, err := s.collectPluginFiles(s.DataDir, entityKey, helpers.JsonFilesRegexp); err == nil {
-		if reapedPlugins, err := s.collectPluginFiles(s.CacheDir, entityKey, helpers.JsonFilesRegexp); err == nil {
+	if activePlugins, err := s.collectFolderPluginFiles(s.DataDir, entityFolder); err == nil {
+		if reapedPlugins, err := s.collectFolderPluginFiles(s.CacheDir, entityFolder); err == nil {
 			// clear out unused plugins
 			removedPlugins := make(map[string]*PluginInfo)
 			for _, plugin := range reapedPlugins {
@@ -227,16 +264,19 @@ func (s *Store) compactCacheStorage(entityKey string, _ uint64) (err error) {
 				delete(removedPlugins, plugin.Source)
 			}
 			for _, p := range removedPlugins {
-				_ = s.clearPluginDeltaStore(p, entityKey)
-				delete(s.plugins, p.Source)
+				cachedFilePath := filepath.Join(s.CacheDir, p.Plugin, entityFolder, p.FileName)
+				journalPath := strings.TrimSuffix(cachedFilePath, filepath.Ext(cachedFilePath))
+				_ = os.Remove(cachedFilePath)
+				_ = os.Remove(journalPath + UNSENT_DELTA_JOURNAL_EXT)
+				_ = os.Remove(journalPath + ARCHIVE_DELTA_JOURNAL_EXT)
+				removed = append(removed, p)
 			}
 
 			// Now for the active ones, remove their archives
 			for _, p := range activePlugins {
-				archiveFilePath := s.archiveFilePath(p, entityKey)
-				_ = os.Remove(archiveFilePath)
+				cachedFilePath := filepath.Join(s.CacheDir, p.Plugin, entityFolder, p.FileName)
+				_ = os.Remove(strings.TrimSuffix(cachedFilePath, filepath.Ext(cachedFilePath)) + ARCHIVE_DELTA_JOURNAL_EXT)
 			}
-
 		}
 	}
 	return
@@ -244,6 +284,9 @@ func (s *Store) compactCacheStorage(entityKey string, _ uint64) (err error) {
 
 // CompactStorage reduces the size of the Delta Storage
 func (s *Store) CompactStorage(entityKey string, threshold uint64) (err error) {
+	s.compactionLock.Lock()
+	defer s.compactionLock.Unlock()
+
 	var repoSize, newRepoSize uint64
 	repoSize, err = s.StorageSize(s.CacheDir)
 	if err == nil && repoSize > 0 && repoSize > threshold {
@@ -259,6 +302,7 @@ func (s *Store) CompactStorage(entityKey string, threshold uint64) (err error) {
 		if nil != err {
 			return
 		}
+		s.recordCompaction(compactionTriggerSize, repoSize, newRepoSize)
 
 		cslog.WithFieldsF(func() logrus.Fields {
 			savedPct := (float64(repoSize-newRepoSize) / float64(repoSize)) * 100
@@ -270,6 +314,104 @@ func (s *Store) CompactStorage(entityKey string, threshold uint64) (err error) {
 	return
 }
 
+// CompactAll compacts the storage of all the entities regardless of its size, removing the stored inventory of the
+// plugins that don't exist anymore and the archived deltas of the active ones. The plugins are forgotten once they
+// don't exist for any entity.
+func (s *Store) CompactAll() (err error) {
+	s.compactionLock.Lock()
+	defer s.compactionLock.Unlock()
+
+	repoSize, err := s.StorageSize(s.CacheDir)
+	if err != nil {
+		return
+	}
+
+	entityFolders, err := s.cachedEntityFolders()
+	if err != nil {
+		return
+	}
+	removed := make(map[string]*PluginInfo)
+	for _, entityFolder := range entityFolders {
+		for _, p := range s.compactEntityFolder(entityFolder) {
+			removed[p.Source] = p
+		}
+	}
+	for _, entityFolder := range entityFolders {
+		if activePlugins, err := s.collectFolderPluginFiles(s.DataDir, entityFolder); err == nil {
+			for _, p := range activePlugins {
+				delete(removed, p.Source)
+			}
+		}
+	}
+	for source := range removed {
+		delete(s.plugins, source)
+	}
+
+	newRepoSize, err := s.StorageSize(s.CacheDir)
+	if err != nil {
+		return
+	}
+	s.recordCompaction(compactionTriggerInterval, repoSize, newRepoSize)
+	slog.WithFieldsF(func() logrus.Fields {
+		return logrus.Fields{"repoSize": repoSize, "newRepoSize": newRepoSize, "entities": len(entityFolders)}
+	}).Debug("Local repo compacted on interval.")
+
+	return s.SaveState()
+}
+
+// CompactOnInterval compacts the storage of all the entities once interval passed since the last compaction, so
+// compactions triggered by the storage size postpone it. It returns the time until the next compaction is due.
+// Like CompactStorage, it must run in the routine that reaps and submits the deltas.
+func (s *Store) CompactOnInterval(interval time.Duration) (next time.Duration, err error) {
+	if last := s.LastCompaction(); !last.IsZero() {
+		if next = time.Until(last.Add(interval)); next > 0 {
+			return next, nil
+		}
+	}
+	return interval, s.CompactAll()
+}
+
+// LastCompaction returns the time of the last compaction, zero if the storage wasn't compacted yet.
+func (s *Store) LastCompaction() time.Time {
+	nanos := s.lastCompaction.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+func (s *Store) recordCompaction(trigger string, repoSize, newRepoSize uint64) {
+	s.lastCompaction.Store(time.Now().UnixNano())
+	if newRepoSize < repoSize {
+		compactionReclaimedMetric.Add(float64(repoSize-newRepoSize), trigger)
+	}
+}
+
+// cachedEntityFolders returns the folders of the entities with cached inventory.
+func (s *Store) cachedEntityFolders() ([]string, error) {
+	entityFolders, err := filepath.Glob(filepath.Join(s.CacheDir, "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var folders []string
+	for _, entityFolder := range entityFolders {
+		if nonEntityFolders[filepath.Base(filepath.Dir(entityFolder))] {
+			continue
+		}
+		if info, err := os.Stat(entityFolder); err != nil || !info.IsDir() {
+			continue
+		}
+		folder := filepath.Base(entityFolder)
+		if !seen[folder] {
+			seen[folder] = true
+			folders = append(folders, folder)
+		}
+	}
+	return folders, nil
+}
+
 // StorageSize returns the size used in bytes of all the loose objects in the cache, non-inclusive of dirs
 func (s *Store) StorageSize(path string) (uint64, error) {
 	var size int64
@@ -332,6 +474,59 @@ func (s *Store) ResetAllDeltas(entityKey string) {
 	}
 }
 
+// ResendInventory removes the inventory cache of the given plugins of all the entities, or of all the plugins when
+// none is given, so the next reap stores full deltas with their whole inventory. Plugins are named either by their
+// source, as "metadata/agent_config", or by their category, as "metadata". Pending and archived deltas are kept, and
+// full deltas are split by the max inventory size as any other one.
+// It returns the number of entity plugins triggered and the size of their inventory, as an approximation of the
+// payload to be sent. It must run in the routine that reaps the deltas, which has to reap all the entities next.
+func (s *Store) ResendInventory(plugins []string) (triggered int, size int64, err error) {
+	sourceFiles, err := filepath.Glob(filepath.Join(s.DataDir, "*", "*", "*.json"))
+	if err != nil {
+		return
+	}
+
+	for _, sourceFile := range sourceFiles {
+		rel, relErr := filepath.Rel(s.DataDir, sourceFile)
+		if relErr != nil {
+			continue
+		}
+		category := filepath.Dir(filepath.Dir(rel))
+		if nonEntityFolders[category] {
+			continue
+		}
+		pluginItem := newPluginInfo(category, filepath.Base(rel))
+		if !matchesPlugin(pluginItem, plugins) {
+			continue
+		}
+
+		info, statErr := os.Stat(sourceFile)
+		if statErr != nil {
+			continue
+		}
+		if rmErr := os.Remove(filepath.Join(s.CacheDir, rel)); rmErr != nil && !os.IsNotExist(rmErr) {
+			slog.WithField("plugin", pluginItem.ID()).WithError(rmErr).Warn("can't remove plugin inventory cache")
+			continue
+		}
+		triggered++
+		size += info.Size()
+	}
+	return
+}
+
+// matchesPlugin returns whether the plugin is named by its source or category in plugins, or plugins is empty.
+func matchesPlugin(pluginItem *PluginInfo, plugins []string) bool {
+	if len(plugins) == 0 {
+		return true
+	}
+	for _, plugin := range plugins {
+		if plugin == pluginItem.Source || plugin == pluginItem.Plugin {
+			return true
+		}
+	}
+	return false
+}
+
 // UpdateState updates in disk the state of the deltas according to the passed PostDeltaBody, whose their ExternalKeys
 // field may be empty.
 func (s *Store) UpdateState(entityKey string, deltas []*inventoryapi.RawDelta, deltaStateResults *inventoryapi.DeltaStateMap) {
@@ -488,13 +683,17 @@ func (s *Store) writePluginIDMap() (err error) {
 }
 
 func (s *Store) collectPluginFiles(dir string, entityKey string, fileFilterRE *regexp.Regexp) (pluginList []*PluginInfo, err error) {
+	return s.collectFolderPluginFiles(dir, s.EntityFolder(entityKey))
+}
+
+// collectFolderPluginFiles returns the plugins with data files in dir for the entity folder.
+func (s *Store) collectFolderPluginFiles(dir string, entityFolder string) (pluginList []*PluginInfo, err error) {
 	pluginsFileInfo, err := ioutil.ReadDir(dir)
 	if err != nil {
 		return
 	}
 
 	pluginList = make([]*PluginInfo, 0, len(pluginsFileInfo))
-	entityFolder := s.EntityFolder(entityKey)
 
 	for _, dirInfo := range pluginsFileInfo {
 		if dirInfo != nil && dirInfo.IsDir() && !nonEntityFolders[dirInfo.Name()] {
@@ -997,8 +1196,10 @@ func (s *Store) SavePluginSource(entityKey, category, term string, source map[st
 	}
 
 	if len(sourceB) > s.maxInventorySize {
+		s.droppedInventory(entityKey, category, term, len(sourceB))
 		err = fmt.Errorf(
-			"Plugin data for entity %v plugin %v/%v is larger than max size of %v",
+			"%w: plugin data for entity %v plugin %v/%v is larger than max size of %v",
+			ErrInventoryTooLarge,
 			entityKey,
 			category,
 			term,
@@ -1010,6 +1211,44 @@ func (s *Store) SavePluginSource(entityKey, category, term string, source map[st
 	return
 }
 
+// droppedInventory accounts the plugin data dropped for exceeding the max inventory size.
+func (s *Store) droppedInventory(entityKey, category, term string, size int) {
+	plugin := fmt.Sprintf("%s/%s", category, term)
+
+	s.droppedLock.Lock()
+	s.dropped[plugin]++
+	count := s.dropped[plugin]
+	s.droppedLock.Unlock()
+
+	slog.WithFields(logrus.Fields{
+		"entity":       entityKey,
+		"plugin":       plugin,
+		"size":         size,
+		"maxSize":      s.maxInventorySize,
+		"droppedCount": count,
+	}).Warn("Inventory data exceeds the max inventory size, dropping it.")
+
+	if s.droppedMetricEnabled {
+		metric := instrumentation.NewGaugeWithAttributes("agent.inventoryDropped", float64(count),
+			map[string]interface{}{"plugin": plugin})
+		instrumentation.SelfInstrumentation.RecordMetric(context.Background(), metric)
+	}
+}
+
+// DroppedInventory returns how many times the data of a plugin (category/term) was dropped for exceeding the max
+// inventory size.
+func (s *Store) DroppedInventory(plugin string) uint64 {
+	s.droppedLock.Lock()
+	defer s.droppedLock.Unlock()
+
+	return s.dropped[plugin]
+}
+
+// SetDroppedMetricEnabled enables reporting the dropped inventory as a self-instrumentation metric.
+func (s *Store) SetDroppedMetricEnabled(enabled bool) {
+	s.droppedMetricEnabled = enabled
+}
+
 func (s *Store) IsArchiveEnabled() bool {
 	return s.archiveEnabled
 }
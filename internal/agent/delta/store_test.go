@@ -9,11 +9,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
 	"github.com/newrelic/infrastructure-agent/pkg/backend/inventoryapi"
 	"github.com/newrelic/infrastructure-agent/pkg/helpers"
 	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
@@ -248,6 +251,63 @@ func TestResetAllSentDeltas(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestResendInventory(t *testing.T) {
+	s := SetUpTest(t)
+	defer s.TearDownTest()
+	ds := NewStore(s.repoDir, "default", maxInventorySize, true)
+	plugin := newPluginInfo("metadata", "plugin.json")
+	otherPlugin := newPluginInfo("packages", "rpm.json")
+	inventory := []byte(`{"hostname":{"alias":"eee-opsmatic","id":"hostname"}}`)
+
+	// GIVEN the inventory of two plugins for two entities, already reaped
+	for _, eKey := range []string{"default", "entityKey"} {
+		for _, p := range []*PluginInfo{plugin, otherPlugin} {
+			srcFile := ds.SourceFilePath(p, eKey)
+			require.NoError(t, os.MkdirAll(filepath.Dir(srcFile), 0755))
+			require.NoError(t, ioutil.WriteFile(srcFile, inventory, 0644))
+		}
+		require.NoError(t, ds.UpdatePluginsInventoryCache(eKey))
+	}
+
+	// WHEN the inventory of one of the plugins is resent
+	triggered, size, err := ds.ResendInventory([]string{"metadata/plugin"})
+	require.NoError(t, err)
+
+	// THEN it's triggered for both entities
+	assert.Equal(t, 2, triggered)
+	assert.Equal(t, int64(2*len(inventory)), size)
+	for _, eKey := range []string{"default", "entityKey"} {
+		assert.False(t, exists(ds.cachedFilePath(plugin, eKey)))
+		assert.True(t, exists(ds.cachedFilePath(otherPlugin, eKey)))
+		// AND pending deltas are kept
+		assert.True(t, exists(ds.DeltaFilePath(plugin, eKey)))
+	}
+
+	// AND the next reap stores a full delta for it
+	updated, err := ds.updatePluginInventoryCache(plugin, "entityKey")
+	require.NoError(t, err)
+	assert.True(t, updated)
+	deltas, err := ds.ReadDeltas("entityKey")
+	require.NoError(t, err)
+	var full []int64
+	for _, block := range deltas {
+		for _, d := range block {
+			if d.Source == plugin.Source && d.FullDiff {
+				full = append(full, d.ID)
+			}
+		}
+	}
+	assert.Equal(t, []int64{1, 2}, full)
+
+	// AND all the plugins are triggered by category or when none is given
+	triggered, _, err = ds.ResendInventory([]string{"packages"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, triggered)
+	triggered, _, err = ds.ResendInventory(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, triggered)
+}
+
 func TestUpdateLastDeltaSentNoHint(t *testing.T) {
 	s := SetUpTest(t)
 	defer s.TearDownTest()
@@ -841,6 +901,90 @@ func TestCompactStoreRemoveUnusedPlugin(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestCompactAll(t *testing.T) {
+	const eKey = "entity:ID"
+	s := SetUpTest(t)
+	defer s.TearDownTest()
+
+	ds := s.SetupSavedState(t)
+	ds.plugins["metadata/plugin"].setLastSentID(eKey, 2)
+	require.NoError(t, ds.archivePlugin(ds.plugins["metadata/plugin"], eKey))
+	unused := newPluginInfo("fancy", "plugin.json")
+	fancyFile := ds.SourceFilePath(unused, eKey)
+	require.NoError(t, os.MkdirAll(filepath.Dir(fancyFile), 0755))
+	require.NoError(t, ioutil.WriteFile(fancyFile, []byte(`{"fancy":{"alias":"thing1","id":"one"}}`), 0644))
+	_, err := ds.updatePluginInventoryCache(unused, eKey)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(fancyFile))
+	size, err := ds.StorageSize(ds.CacheDir)
+	require.NoError(t, err)
+	assert.True(t, ds.LastCompaction().IsZero())
+
+	// compacted regardless of the storage size
+	require.NoError(t, ds.CompactAll())
+
+	newSize, err := ds.StorageSize(ds.CacheDir)
+	require.NoError(t, err)
+	assert.Greater(t, size, newSize)
+	assert.False(t, exists(ds.archiveFilePath(ds.plugins["metadata/plugin"], eKey)))
+	assert.True(t, exists(ds.cachedFilePath(ds.plugins["metadata/plugin"], eKey)))
+	assert.False(t, exists(ds.cachedFilePath(unused, eKey)))
+	assert.False(t, exists(ds.DeltaFilePath(unused, eKey)))
+	assert.NotContains(t, ds.plugins, unused.Source)
+	assert.False(t, ds.LastCompaction().IsZero())
+
+	var metrics strings.Builder
+	require.NoError(t, openmetrics.AgentMetrics.Write(&metrics))
+	assert.Contains(t, metrics.String(), `nria_inventory_compaction_reclaimed_bytes_total{trigger="interval"}`)
+}
+
+func TestCompactOnInterval(t *testing.T) {
+	s := SetUpTest(t)
+	defer s.TearDownTest()
+	ds := s.SetupSavedState(t)
+
+	next, err := ds.CompactOnInterval(time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, next)
+	assert.False(t, ds.LastCompaction().IsZero())
+}
+
+func TestCompactOnInterval_PostponedBySizeCompaction(t *testing.T) {
+	s := SetUpTest(t)
+	defer s.TearDownTest()
+	ds := s.SetupSavedState(t)
+
+	require.NoError(t, ds.CompactStorage("", 0))
+	sizeCompaction := ds.LastCompaction()
+	require.False(t, sizeCompaction.IsZero())
+
+	next, err := ds.CompactOnInterval(time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, sizeCompaction, ds.LastCompaction())
+	assert.True(t, next > 0 && next <= time.Hour, "next compaction in %s", next)
+}
+
+func TestCompact_Concurrent(t *testing.T) {
+	s := SetUpTest(t)
+	defer s.TearDownTest()
+	ds := s.SetupSavedState(t)
+
+	// run with -race to check the size and interval compactions don't overlap
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, ds.CompactStorage("", 0))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, ds.CompactAll())
+		}()
+	}
+	wg.Wait()
+}
+
 func TestStoreNotArchiving(t *testing.T) {
 	const eKey = "entity:ID"
 	s := SetUpTest(t)
@@ -1162,3 +1306,34 @@ func TestStore_Path_PermissionDenied(t *testing.T) {
 	// THEN should return that exists as a bool
 	assert.True(t, exists)
 }
+
+func TestSavePluginSource_DroppedInventory(t *testing.T) {
+	dataDir, err := TempDeltaStoreDir()
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	ds := NewStore(dataDir, "default", 50, true)
+
+	oversized := map[string]interface{}{
+		"package": map[string]interface{}{"id": "package", "version": strings.Repeat("1", 100)},
+	}
+
+	// oversized inventory is dropped and accounted per plugin
+	for i := 0; i < 2; i++ {
+		err = ds.SavePluginSource("default", "packages", "dpkg", oversized)
+		assert.ErrorIs(t, err, ErrInventoryTooLarge)
+	}
+	err = ds.SavePluginSource("default", "packages", "rpm", oversized)
+	assert.ErrorIs(t, err, ErrInventoryTooLarge)
+
+	assert.Equal(t, uint64(2), ds.DroppedInventory("packages/dpkg"))
+	assert.Equal(t, uint64(1), ds.DroppedInventory("packages/rpm"))
+
+	_, err = os.Stat(filepath.Join(ds.PluginDirPath("packages", "default"), "dpkg.json"))
+	assert.True(t, os.IsNotExist(err))
+
+	// inventory fitting the max size is stored
+	err = ds.SavePluginSource("default", "packages", "apk", map[string]interface{}{"id": "apk"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), ds.DroppedInventory("packages/apk"))
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/hostname"
+)
+
+// defaultDisplayNamePatterns match display names that are likely shared by many hosts, like the default names
+// assigned by cloud providers or OS images.
+var defaultDisplayNamePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^localhost(\.localdomain)?$`),
+	regexp.MustCompile(`^ip-\d{1,3}-\d{1,3}-\d{1,3}-\d{1,3}(\..+)?$`),
+	regexp.MustCompile(`^(ip|domU)-[0-9a-fA-F-]+\.(ec2|compute)\.internal$`),
+	regexp.MustCompile(`^\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}$`),
+}
+
+// isLikelyCollidingDisplayName returns true when the display name matches a default name pattern.
+func isLikelyCollidingDisplayName(displayName string) bool {
+	for _, pattern := range defaultDisplayNamePatterns {
+		if pattern.MatchString(displayName) {
+			return true
+		}
+	}
+	return false
+}
+
+// disambiguateDisplayName warns when the effective display name, the configured one or otherwise the resolved
+// hostname, is likely to collide with the ones from other hosts, causing their entities to be merged. If
+// appendInstanceID is enabled, the cloud instance ID is appended to it and returned as the display name to use.
+func disambiguateDisplayName(displayName string, resolver hostname.Resolver, appendInstanceID bool, cloudHarvester cloud.Harvester) string {
+	effectiveName := displayName
+	if effectiveName == "" {
+		fullHostname, _, err := resolver.Query()
+		if err != nil {
+			return displayName
+		}
+		effectiveName = fullHostname
+	}
+
+	if !isLikelyCollidingDisplayName(effectiveName) {
+		return displayName
+	}
+
+	llog := alog.WithField("displayName", effectiveName)
+	if !appendInstanceID {
+		llog.Warn("display name looks like a default name that may be shared with other hosts, which would " +
+			"merge their entities. Set a unique display_name or enable display_name_append_instance_id")
+		return displayName
+	}
+
+	instanceID, err := cloudHarvester.GetInstanceID()
+	if err != nil || instanceID == "" {
+		llog.WithError(err).Warn("display name looks like a default name that may be shared with other hosts, " +
+			"but no instance ID is available to disambiguate it")
+		return displayName
+	}
+
+	disambiguated := fmt.Sprintf("%s-%s", effectiveName, instanceID)
+	llog.WithField("newDisplayName", disambiguated).
+		Warn("display name looks like a default name that may be shared with other hosts, appending instance ID")
+
+	return disambiguated
+}
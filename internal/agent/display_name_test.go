@@ -0,0 +1,62 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/testhelpers"
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLikelyCollidingDisplayName(t *testing.T) {
+	colliding := []string{"localhost", "localhost.localdomain", "ip-10-0-1-23", "ip-10-0-1-23.ec2.internal", "192.168.1.10"}
+	for _, name := range colliding {
+		assert.True(t, isLikelyCollidingDisplayName(name), name)
+	}
+
+	unique := []string{"payments-db-01", "my-ip-host", "web.example.com"}
+	for _, name := range unique {
+		assert.False(t, isLikelyCollidingDisplayName(name), name)
+	}
+}
+
+func TestDisambiguateDisplayName(t *testing.T) {
+	testCases := []struct {
+		name             string
+		displayName      string
+		hostname         string
+		appendInstanceID bool
+		expected         string
+	}{
+		{"colliding name enabled", "ip-10-0-1-23", "payments-db-01", true, "ip-10-0-1-23-Got cloud ID on first try!"},
+		{"colliding name disabled", "ip-10-0-1-23", "payments-db-01", false, "ip-10-0-1-23"},
+		{"unique name enabled", "payments-db-01", "ip-10-0-1-23", true, "payments-db-01"},
+		{"empty name colliding hostname enabled", "", "ip-10-0-1-23.ec2.internal", true, "ip-10-0-1-23.ec2.internal-Got cloud ID on first try!"},
+		{"empty name colliding hostname disabled", "", "ip-10-0-1-23.ec2.internal", false, ""},
+		{"empty name unique hostname enabled", "", "payments-db-01", true, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			harvester := NewMockHarvester(t, cloud.TypeAWS, true)
+			resolver := testhelpers.NewFakeHostnameResolver(tc.hostname, "short", nil)
+			assert.Equal(t, tc.expected, disambiguateDisplayName(tc.displayName, resolver, tc.appendInstanceID, harvester))
+		})
+	}
+}
+
+func TestDisambiguateDisplayName_NoInstanceID(t *testing.T) {
+	harvester := NewMockHarvester(t, cloud.TypeNoCloud, false)
+
+	assert.Equal(t, "localhost", disambiguateDisplayName("localhost", testhelpers.NullHostnameResolver, true, harvester))
+}
+
+func TestDisambiguateDisplayName_HostnameError(t *testing.T) {
+	harvester := NewMockHarvester(t, cloud.TypeAWS, true)
+	resolver := testhelpers.NewFakeHostnameResolver("", "", errors.New("no hostname"))
+
+	assert.Equal(t, "", disambiguateDisplayName("", resolver, true, harvester))
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"time"
+
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
+	process_sample_types "github.com/newrelic/infrastructure-agent/pkg/metrics/types"
+)
+
+const (
+	droppedSamplesReportInterval = time.Minute
+	unknownSampleType            = "unknown"
+)
+
+var droppedSamplesMetric = openmetrics.AgentMetrics.NewCounterVec("nria_dropped_samples_total",
+	"Samples dropped by the include_matching_metrics and exclude_matching_metrics matchers, by sample type.",
+	"sample_type")
+
+// sampleType returns the event type of a sample the matchers apply to.
+func sampleType(event any) string {
+	eventType := ""
+	switch s := event.(type) {
+	case *process_sample_types.ProcessSample:
+		eventType = s.EventType
+	case *process_sample_types.FlatProcessSample:
+		eventType, _ = (*s)["eventType"].(string)
+	}
+	if eventType == "" {
+		return unknownSampleType
+	}
+	return eventType
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"bytes"
+	context2 "context"
+	"testing"
+	"time"
+
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
+	"github.com/newrelic/infrastructure-agent/internal/testhelpers"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/metrics/sampler"
+	"github.com/newrelic/infrastructure-agent/pkg/metrics/types"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext_SendEvent_CountsDroppedSamples(t *testing.T) {
+	cfg := &config.Config{EnableDroppedSamplesCount: true}
+	includeNone := func(interface{}) bool { return false }
+	excludeAll := func(interface{}) bool { return true }
+	c := NewContext(cfg, "0.0.0", testhelpers.NullHostnameResolver, NilIDLookup, includeNone, excludeAll)
+	require.NotNil(t, c.droppedSamples)
+	c.droppedSamples = sampler.NewDroppedSamplesCounter()
+	c.eventSender = fakeEventSender{}
+
+	processSample := &types.ProcessSample{ProcessDisplayName: "some-process"}
+	processSample.Type("ProcessSample")
+	flatSample := &types.FlatProcessSample{}
+	flatSample.Type("ProcessSample")
+
+	c.SendEvent(processSample, "some key")
+	c.SendEvent(flatSample, "some key")
+	// not filtered by the matchers
+	c.SendEvent(&sample.BaseEvent{EventType: "SystemSample"}, "some key")
+
+	assert.Equal(t, map[string]uint64{"ProcessSample": 2}, c.droppedSamples.FlushInterval())
+	assert.Empty(t, c.droppedSamples.FlushInterval())
+
+	c.SendEvent(processSample, "some key")
+	assert.Equal(t, map[string]uint64{"ProcessSample": 1}, c.droppedSamples.FlushInterval())
+	assert.Equal(t, map[string]uint64{"ProcessSample": 3}, c.droppedSamples.Counts())
+}
+
+func TestContext_SendEvent_DroppedSamplesNotCountedByDefault(t *testing.T) {
+	excludeAll := func(interface{}) bool { return true }
+	c := NewContext(&config.Config{}, "0.0.0", testhelpers.NullHostnameResolver, NilIDLookup, excludeAll, excludeAll)
+
+	assert.Nil(t, c.droppedSamples)
+}
+
+func TestSampleType(t *testing.T) {
+	processSample := &types.ProcessSample{}
+	processSample.Type("ProcessSample")
+
+	assert.Equal(t, "ProcessSample", sampleType(processSample))
+	assert.Equal(t, "ProcessSample", sampleType(&types.FlatProcessSample{"eventType": "ProcessSample"}))
+	assert.Equal(t, unknownSampleType, sampleType(&types.FlatProcessSample{}))
+}
+
+func TestReportIntervalCounts(t *testing.T) {
+	registry := openmetrics.NewRegistry()
+	counter := registry.NewCounterVec("nria_test_total", "Test counts.", "key")
+	droppedSamples := sampler.NewDroppedSamplesCounter()
+	droppedSamples.Inc("ProcessSample")
+	droppedSamples.Inc("ProcessSample")
+
+	ctx, cancel := context2.WithCancel(context2.Background())
+	done := make(chan struct{})
+	go func() {
+		reportIntervalCounts(ctx, droppedSamples.FlushInterval, counter, time.Hour)
+		close(done)
+	}()
+	// counts of the unfinished interval are added when the context is done
+	cancel()
+	<-done
+
+	var metrics bytes.Buffer
+	require.NoError(t, registry.Write(&metrics))
+	assert.Contains(t, metrics.String(), `nria_test_total{key="ProcessSample"} 2`+"\n")
+	assert.Empty(t, droppedSamples.FlushInterval())
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"encoding/json"
+)
+
+// deploymentMarkerAttribute event attribute holding the deployment_marker config option.
+const deploymentMarkerAttribute = "deploymentMarker"
+
+// identityAttributes are the event attributes identifying the event and the entity it belongs to, which the backend
+// relies on, so they are never prefixed.
+var identityAttributes = map[string]bool{
+	"eventType":    true,
+	"timestamp":    true,
+	"entityKey":    true,
+	"entityID":     true,
+	"entityId":     true,
+	"entityGuid":   true,
+	"entityName":   true,
+	"hostname":     true,
+	"fullHostname": true,
+	"displayName":  true,
+}
+
+// decorateEvent prefixes the attribute names of a marshalled event, but the identity ones, and adds the deployment
+// marker attribute, replacing the one it may already have. The marker isn't prefixed, as the custom attributes. The
+// event is decoded only when there is a prefix or a marker. Events that are not JSON objects are returned as they are.
+func decorateEvent(eventData []byte, prefix, marker string) ([]byte, error) {
+	if prefix == "" && marker == "" {
+		return eventData, nil
+	}
+
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(eventData, &attributes); err != nil || attributes == nil {
+		return eventData, nil
+	}
+
+	if prefix != "" {
+		prefixed := make(map[string]json.RawMessage, len(attributes)+1)
+		for name, value := range attributes {
+			if !identityAttributes[name] {
+				name = prefix + name
+			}
+			prefixed[name] = value
+		}
+		attributes = prefixed
+	}
+
+	if marker != "" {
+		value, err := json.Marshal(marker)
+		if err != nil {
+			return nil, err
+		}
+		attributes[deploymentMarkerAttribute] = value
+	}
+
+	return json.Marshal(attributes)
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/testhelpers"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/entity/host"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecorateEvent_Prefix(t *testing.T) {
+	event := []byte(`{"eventType":"SystemSample","timestamp":1,"entityKey":"host","hostname":"foo","cpuPercent":12.5,"memoryUsedBytes":10}`)
+
+	prefixed, err := decorateEvent(event, "infra.", "")
+	require.NoError(t, err)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(prefixed, &got))
+	assert.Equal(t, map[string]interface{}{
+		"eventType":             "SystemSample",
+		"timestamp":             float64(1),
+		"entityKey":             "host",
+		"hostname":              "foo",
+		"infra.cpuPercent":      12.5,
+		"infra.memoryUsedBytes": float64(10),
+	}, got)
+}
+
+func TestDecorateEvent_DeploymentMarker(t *testing.T) {
+	event := []byte(`{"eventType":"SystemSample","cpuPercent":12.5,"deploymentMarker":"from-integration"}`)
+
+	decorated, err := decorateEvent(event, "", "v1.42.0")
+	require.NoError(t, err)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(decorated, &got))
+	assert.Equal(t, map[string]interface{}{
+		"eventType":        "SystemSample",
+		"cpuPercent":       12.5,
+		"deploymentMarker": "v1.42.0",
+	}, got)
+}
+
+func TestDecorateEvent_PrefixAndDeploymentMarker(t *testing.T) {
+	event := []byte(`{"eventType":"SystemSample","cpuPercent":12.5,"deploymentMarker":"from-integration"}`)
+
+	decorated, err := decorateEvent(event, "infra.", "v1.42.0")
+	require.NoError(t, err)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(decorated, &got))
+	assert.Equal(t, map[string]interface{}{
+		"eventType":              "SystemSample",
+		"infra.cpuPercent":       12.5,
+		"infra.deploymentMarker": "from-integration",
+		"deploymentMarker":       "v1.42.0",
+	}, got)
+}
+
+func TestDecorateEvent_NoDecoration(t *testing.T) {
+	// not even a valid event, as it isn't decoded
+	event := []byte(`{"eventType":"SystemSample",`)
+
+	decorated, err := decorateEvent(event, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, event, decorated)
+}
+
+func TestDecorateEvent_NotAnObject(t *testing.T) {
+	event := []byte(`["cpuPercent"]`)
+
+	decorated, err := decorateEvent(event, "infra.", "v1.42.0")
+	require.NoError(t, err)
+	assert.Equal(t, event, decorated)
+}
+
+func TestMetricsIngestSender_QueueEvent_PrefixesMetricNames(t *testing.T) {
+	cfg := &config.Config{MetricNamePrefix: "infra."}
+	c := NewContext(cfg, "1.2.3", testhelpers.NullHostnameResolver, host.IDLookup{}, nil, nil)
+	c.setAgentKey("my-agent")
+	sender := newMetricsIngestSender(c, "license", "userAgent", nil, false)
+
+	require.NoError(t, sender.QueueEvent(mapEvent{"eventType": "SystemSample", "cpuPercent": 12.5}, entity.Key("my-agent")))
+
+	queued := <-sender.eventQueue
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(queued.data, &got))
+	assert.Equal(t, map[string]interface{}{
+		"eventType":        "SystemSample",
+		"entityKey":        "my-agent",
+		"infra.cpuPercent": 12.5,
+	}, got)
+}
+
+func TestMetricsIngestSender_QueueEvent_DeploymentMarker(t *testing.T) {
+	cfg := &config.Config{MetricNamePrefix: "infra.", DeploymentMarker: "v1.42.0"}
+	c := NewContext(cfg, "1.2.3", testhelpers.NullHostnameResolver, host.IDLookup{}, nil, nil)
+	c.setAgentKey("my-agent")
+	sender := newMetricsIngestSender(c, "license", "userAgent", nil, false)
+	c.eventSender = sender
+	a := &Agent{Context: c}
+
+	queuedEvent := func() map[string]interface{} {
+		t.Helper()
+		c.SendEvent(mapEvent{"eventType": "SystemSample", "cpuPercent": 12.5}, entity.Key("my-agent"))
+		queued := <-sender.eventQueue
+		var got map[string]interface{}
+		require.NoError(t, json.Unmarshal(queued.data, &got))
+		return got
+	}
+
+	// the marker is not prefixed, as the custom attributes
+	assert.Equal(t, map[string]interface{}{
+		"eventType":        "SystemSample",
+		"entityKey":        "my-agent",
+		"infra.cpuPercent": 12.5,
+		"deploymentMarker": "v1.42.0",
+	}, queuedEvent())
+
+	// as on a configuration reload
+	a.SetDeploymentMarker("v1.43.0")
+	assert.Equal(t, "v1.43.0", queuedEvent()["deploymentMarker"])
+
+	a.SetDeploymentMarker("")
+	assert.NotContains(t, queuedEvent(), "deploymentMarker")
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/newrelic/infrastructure-agent/pkg/backend/backoff"
 
 	"github.com/newrelic/infrastructure-agent/internal/agent/id"
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
 
 	"github.com/newrelic/infrastructure-agent/pkg/entity"
 	"github.com/newrelic/infrastructure-agent/pkg/sample"
@@ -80,7 +81,12 @@ type metricsIngestSender struct {
 	agentIDProvide           id.Provide
 	connectEnabled           bool
 	getBackoffTimer          func(time.Duration) *time.Timer
-	postCount                uint64 // counts post requests for debugging purposes
+	retry                    *submissionRetry
+	metricNamePrefix         string     // Prefix for the event attribute names, but the identity ones
+	postCount                uint64     // counts post requests for debugging purposes
+	pendingBatch             eventBatch // Batch being accumulated when the sender was stopped
+	// Batches that couldn't be submitted because of an outage, nil when disabled
+	offlineBuffer *offlineBuffer
 }
 
 func newMetricsIngestSender(ctx *context, licenseKey, userAgent string, httpClient backendhttp.Client, connectEnabled bool) *metricsIngestSender {
@@ -93,6 +99,8 @@ func newMetricsIngestSender(ctx *context, licenseKey, userAgent string, httpClie
 		metricIngestURL = os.Getenv("DEV_METRICS_INGEST_URL")
 	}
 	metricIngestURL = strings.TrimSuffix(metricIngestURL, "/")
+	backendhttp.SubmissionStatuses.Register(metricIngestURL)
+
 	eventQueue := EVENT_QUEUE_CAPACITY
 	if cfg.EventQueueDepth > eventQueue {
 		eventQueue = cfg.EventQueueDepth
@@ -108,6 +116,15 @@ func newMetricsIngestSender(ctx *context, licenseKey, userAgent string, httpClie
 		maxMetricsBatchSizeBytes = config.DefaultMaxMetricsBatchSizeBytes
 	}
 
+	var buffer *offlineBuffer
+	if cfg.OfflineBufferDir != "" {
+		var err error
+		buffer, err = newOfflineBuffer(cfg.OfflineBufferDir, int64(cfg.OfflineBufferMaxMB)*1024*1024)
+		if err != nil {
+			ilog.WithError(err).Warn("Offline metrics buffer is disabled.")
+		}
+	}
+
 	return &metricsIngestSender{
 		eventQueue:               make(chan eventData, eventQueue),
 		batchQueue:               make(chan eventBatch, batchQueue),
@@ -122,7 +139,10 @@ func newMetricsIngestSender(ctx *context, licenseKey, userAgent string, httpClie
 		agentIDProvide:           ctx.Identity,
 		connectEnabled:           connectEnabled,
 		getBackoffTimer:          time.NewTimer,
+		retry:                    newSubmissionRetry(cfg),
+		metricNamePrefix:         cfg.MetricNamePrefix,
 		postCount:                0,
+		offlineBuffer:            buffer,
 	}
 }
 
@@ -135,6 +155,11 @@ func (sender *metricsIngestSender) Start() (err error) {
 	// Set up the stop channel so the routines can wait for it to be closed
 	sender.stopChannel = make(chan bool)
 
+	openmetrics.AgentMetrics.SetGaugeFunc("nria_event_queue_depth", "Events waiting to be batched for the metrics submission.",
+		func() float64 { return float64(len(sender.eventQueue)) })
+	openmetrics.AgentMetrics.SetGaugeFunc("nria_batch_queue_depth", "Event batches waiting to be submitted.",
+		func() float64 { return float64(len(sender.batchQueue)) })
+
 	// Wait for accumulateBatches and sendBatches to complete
 	sender.internalRoutineWaits.Add(3)
 
@@ -184,6 +209,11 @@ func (sender *metricsIngestSender) QueueEvent(event sample.Event, key entity.Key
 		return fmt.Errorf("error marshalling event to JSON: %+v (%+v)", event, err)
 	}
 
+	edata, err = decorateEvent(edata, sender.metricNamePrefix, sender.Context.DeploymentMarker())
+	if err != nil {
+		return fmt.Errorf("error decorating event: %+v (%+v)", event, err)
+	}
+
 	if len(edata) > sender.maxMetricsBatchSizeBytes {
 		return fmt.Errorf("Could not queue event: Event is larger than the maximum event post size (%d > %d).", len(edata), sender.maxMetricsBatchSizeBytes)
 	}
@@ -242,13 +272,14 @@ func (sender *metricsIngestSender) accumulateBatches() {
 				event.entityID = sender.agentIDProvide().ID
 			}
 
-			if batchBytes+len(event.data) > sender.maxMetricsBatchSizeBytes || len(batch) == MAX_EVENT_BATCH_COUNT {
+			if !sender.fitsBatch(batch, batchBytes, event) {
 				// Current batch + this event would either be too many events or too many bytes, so queue the batch first.
 				select {
 				case sender.batchQueue <- batch:
 					batch = make(eventBatch, 0)
 					batchBytes = 0
 				case <-sender.stopChannel:
+					sender.pendingBatch = append(batch, event)
 					return
 				}
 			}
@@ -262,6 +293,7 @@ func (sender *metricsIngestSender) accumulateBatches() {
 					batch = make(eventBatch, 0)
 					batchBytes = 0
 				case <-sender.stopChannel:
+					sender.pendingBatch = batch
 					return
 				}
 			}
@@ -269,11 +301,106 @@ func (sender *metricsIngestSender) accumulateBatches() {
 		case <-sender.stopChannel:
 			// Stop channel has been closed - exit.
 			// There might still be some events in the queue, but they'll still be there in case we start the sender back up.
+			sender.pendingBatch = batch
 			return
 		}
 	}
 }
 
+// fitsBatch returns whether the event can be added to the batch without exceeding the maximum events or bytes of a post.
+func (sender *metricsIngestSender) fitsBatch(batch eventBatch, batchBytes int, event eventData) bool {
+	return batchBytes+len(event.data) <= sender.maxMetricsBatchSizeBytes && len(batch) < MAX_EVENT_BATCH_COUNT
+}
+
+// Flush posts the events left in the queues by a stopped sender until all of them are sent or ctx is done, and
+// returns the number of events that couldn't be sent nor stored. Failed posts are not retried, but stored in the
+// offline buffer, if enabled, when the backend is unavailable, as the batches left unposted once ctx is done. As the
+// agent ID may never be available, Flush returns once ctx is done even if the posts are still waiting for it.
+func (sender *metricsIngestSender) Flush(ctx goContext.Context) (dropped int) {
+	if sender.stopChannel != nil {
+		ilog.Warn("Cannot flush a running sender.")
+		return 0
+	}
+
+	var batches []eventBatch
+	for len(sender.batchQueue) > 0 {
+		batches = append(batches, <-sender.batchQueue)
+	}
+	var batch eventBatch
+	var batchBytes int
+	addEvent := func(event eventData) {
+		if !sender.fitsBatch(batch, batchBytes, event) {
+			batches = append(batches, batch)
+			batch = nil
+			batchBytes = 0
+		}
+		batch = append(batch, event)
+		batchBytes += len(event.data)
+	}
+	for _, event := range sender.pendingBatch {
+		addEvent(event)
+	}
+	sender.pendingBatch = nil
+	for len(sender.eventQueue) > 0 {
+		addEvent(<-sender.eventQueue)
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	// outcome of the post of each batch, guarded by lock as the posts might outlive ctx
+	var lock sync.Mutex
+	sent := make([]bool, len(batches))
+	postErrs := make([]error, len(batches))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for n, batch := range batches {
+			if ctx.Err() != nil {
+				return
+			}
+			for i := range batch {
+				// Add entityID if connect is enabled and if is not a remote entity, as for the accumulated events.
+				if sender.connectEnabled && batch[i].IsAgent() && batch[i].entityID.IsEmpty() {
+					batch[i].entityID = sender.agentIDProvide().ID
+				}
+			}
+			bulkPost, agentKey := sender.newBulkPost(batch)
+			err := sender.doPost(ctx, bulkPost, agentKey)
+			if err != nil {
+				ilog.WithError(err).WithField("numEvents", len(batch)).Warn("Cannot flush events.")
+			}
+			lock.Lock()
+			sent[n] = err == nil
+			postErrs[n] = err
+			lock.Unlock()
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	for i, batch := range batches {
+		if sent[i] {
+			continue
+		}
+		// batches not posted yet were kept from being submitted by the flush timeout, as by an outage
+		if sender.offlineBuffer != nil && (postErrs[i] == nil || isSubmissionOutage(postErrs[i])) {
+			err := sender.offlineBuffer.push(batch)
+			if err == nil {
+				continue
+			}
+			ilog.WithError(err).Warn("Cannot store metrics batch in the offline buffer.")
+		}
+		dropped += len(batch)
+	}
+	return dropped
+}
+
 // MetricPost entity item for the HTTP post to be sent to the ingest service.
 type MetricPost struct {
 	ExternalKeys []string          `json:"ExternalKeys,omitempty"`
@@ -336,7 +463,6 @@ func (mp *MetricPost) getTimestampLoggingFields() logrus.Fields {
 
 // Wait for queued batches and send any to the ingest API
 func (sender *metricsIngestSender) sendBatches() {
-	retryBO := backoff.NewDefaultBackoff()
 	for {
 		select {
 
@@ -347,48 +473,33 @@ func (sender *metricsIngestSender) sendBatches() {
 			pclog := ilog.WithField("postCount", sender.postCount)
 			sender.postCount++
 
-			agentKey := ""
-			dataByEntity := make(map[entity.Key]*MetricPost)
-
-			ctx, seg := txn.StartSegment(ctx, "getAgentId")
-			agentID := sender.agentID()
-			seg.End()
-
-			ctx, seg = txn.StartSegment(ctx, "rebuildEvents")
-			// We need to rebuild the array of events as a []json.RawMessage, or else JSON marshalling won't handle them correctly.
-			for _, event := range batch {
-				entityData := dataByEntity[event.entityKey]
-				if entityData == nil {
-					entityData = newMetricPost(event.entityKey, event.entityID, agentID, event.agentKey)
-					dataByEntity[event.entityKey] = entityData
-				}
-				entityData.Events = append(entityData.Events, event.data)
-				if event.agentKey != "" {
-					agentKey = event.agentKey
-				}
-			}
+			ctx, seg := txn.StartSegment(ctx, "rebuildEvents")
+			bulkPost, agentKey := sender.newBulkPost(batch)
 			seg.End()
 
 			ctx, seg = txn.StartSegment(ctx, "prepareBulkPost")
-			var bulkPost MetricPostBatch
-			for _, entityData := range dataByEntity {
+			for _, entityData := range bulkPost {
 				metric := instrumentation.NewGauge("agent.postEventsNum", float64(len(entityData.Events)))
 				instrumentation.SelfInstrumentation.RecordMetric(ctx, metric)
 				pclog.WithFieldsF(entityData.getLoggingField).
 					WithFieldsF(entityData.getTimestampLoggingFields).
 					WithField("numEvents", len(entityData.Events)).
 					Debug("Sending events to metrics-ingest.")
-				bulkPost = append(bulkPost, entityData)
 			}
 			pclog.Debug("Preparing metrics post.")
 			seg.End()
 
 			err := sender.doPost(ctx, bulkPost, agentKey)
+			err = sender.retry.retry(err, sender.backoff, func() error {
+				pclog.Debug("Retrying metrics post.")
+				return sender.doPost(ctx, bulkPost, agentKey)
+			})
 
 			if err == nil {
 				pclog.Debug("Metrics post succeeded.")
 				sender.sendErrorCount = 0
-				retryBO.Reset()
+				sender.retry.reset()
+				sender.replayOffline(ctx)
 				txn.End()
 				continue
 			}
@@ -396,6 +507,14 @@ func (sender *metricsIngestSender) sendBatches() {
 			sender.sendErrorCount++
 			pclog.WithError(err).WithField("sendErrorCount", sender.sendErrorCount).Error("metric sender can't process")
 
+			if sender.offlineBuffer != nil && isSubmissionOutage(err) {
+				if bufErr := sender.offlineBuffer.push(batch); bufErr != nil {
+					pclog.WithError(bufErr).Warn("Cannot store metrics batch in the offline buffer.")
+				} else {
+					pclog.WithField("offlineBatches", sender.offlineBuffer.len()).Debug("Metrics batch stored in the offline buffer.")
+				}
+			}
+
 			e, ok := err.(*errRetry)
 			if !ok {
 				txn.NoticeError(err)
@@ -404,15 +523,15 @@ func (sender *metricsIngestSender) sendBatches() {
 			}
 
 			if e.retryPolicy.After > 0 {
-				pclog.WithField("retryAfter", e.retryPolicy.After).Debug("Metric sender retry requested.")
-				retryBO.Reset()
-				sender.backoff(e.retryPolicy.After)
+				retryAfter := sender.retry.retryAfter(e.retryPolicy)
+				pclog.WithField("retryAfter", retryAfter).Debug("Metric sender retry requested.")
+				sender.backoff(retryAfter)
 				txn.NoticeError(e)
-				txn.AddAttribute("retryAfter", e.retryPolicy.After)
+				txn.AddAttribute("retryAfter", retryAfter)
 				txn.End()
 				continue
 			}
-			retryBOAfter := retryBO.DurationWithMax(e.retryPolicy.MaxBackOff)
+			retryBOAfter := sender.retry.backoffAfter(e.retryPolicy)
 			pclog.WithField("retryBackoffAfter", retryBOAfter).Debug("Metric sender backoff and retry requested.")
 			sender.backoff(retryBOAfter)
 			txn.AddAttribute("retryBackoffAfter", retryBOAfter)
@@ -426,6 +545,58 @@ func (sender *metricsIngestSender) sendBatches() {
 	}
 }
 
+// replayOffline submits the oldest batch stored in the offline buffer, if any. It's called after each successful
+// submission, so the stored batches are replayed at the pace of the regular ones, and a failed replay backs off as
+// a failed submission does. Batches rejected by the backend are discarded.
+func (sender *metricsIngestSender) replayOffline(ctx goContext.Context) {
+	if sender.offlineBuffer == nil {
+		return
+	}
+	batch, ok := sender.offlineBuffer.oldest()
+	if !ok {
+		return
+	}
+
+	bulkPost, agentKey := sender.newBulkPost(batch)
+	err := sender.doPost(ctx, bulkPost, agentKey)
+	if err == nil {
+		sender.offlineBuffer.removeOldest()
+		ilog.WithField("numEvents", len(batch)).WithField("offlineBatches", sender.offlineBuffer.len()).
+			Debug("Offline metrics batch submitted.")
+		return
+	}
+
+	if !isSubmissionOutage(err) {
+		ilog.WithError(err).WithField("numEvents", len(batch)).Warn("Discarding offline metrics batch not accepted by the backend.")
+		sender.offlineBuffer.removeOldest()
+		return
+	}
+	ilog.WithError(err).Debug("Offline metrics batch submission failed, it will be retried.")
+	sender.backoff(sender.retry.wait(err))
+}
+
+// newBulkPost groups the events of the batch by entity, and returns them along with the agent key.
+func (sender *metricsIngestSender) newBulkPost(batch eventBatch) (MetricPostBatch, string) {
+	agentKey := ""
+	agentID := sender.agentID()
+	dataByEntity := make(map[entity.Key]*MetricPost)
+	var bulkPost MetricPostBatch
+	// We need to rebuild the array of events as a []json.RawMessage, or else JSON marshalling won't handle them correctly.
+	for _, event := range batch {
+		entityData := dataByEntity[event.entityKey]
+		if entityData == nil {
+			entityData = newMetricPost(event.entityKey, event.entityID, agentID, event.agentKey)
+			dataByEntity[event.entityKey] = entityData
+			bulkPost = append(bulkPost, entityData)
+		}
+		entityData.Events = append(entityData.Events, event.data)
+		if event.agentKey != "" {
+			agentKey = event.agentKey
+		}
+	}
+	return bulkPost, agentKey
+}
+
 func (s *metricsIngestSender) agentID() entity.ID {
 	if s.Context != nil &&
 		s.Context.Config() != nil &&
@@ -437,12 +608,14 @@ func (s *metricsIngestSender) agentID() entity.ID {
 }
 
 // backoff waits for the specified duration or a signal from the stop
-// channel, whichever happens first.
-func (s *metricsIngestSender) backoff(d time.Duration) {
+// channel, whichever happens first. It returns false when the sender was stopped.
+func (s *metricsIngestSender) backoff(d time.Duration) bool {
 	backoffTimer := s.getBackoffTimer(d)
 	select {
 	case <-s.stopChannel:
+		return false
 	case <-backoffTimer.C:
+		return true
 	}
 }
 
@@ -485,7 +658,7 @@ func (sender *metricsIngestSender) doPost(ctx goContext.Context, post []*MetricP
 	} else {
 		reqBuf = bytes.NewBuffer(postBytes)
 	}
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/events/bulk", sender.metricIngestURL), reqBuf)
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/events/bulk", sender.metricIngestURL), reqBuf)
 	if err != nil {
 		return fmt.Errorf("Error creating event POST: %v", err)
 	}
@@ -509,7 +682,7 @@ func (sender *metricsIngestSender) doPost(ctx goContext.Context, post []*MetricP
 
 	ilog.WithField(config.TracesFieldName, config.FeatureTrace)
 
-	if log.IsLevelEnabled(logrus.TraceLevel) {
+	if http2.TracingEnabled() {
 		req = http2.WithTracer(req, "eventSender")
 	}
 
@@ -517,9 +690,10 @@ func (sender *metricsIngestSender) doPost(ctx goContext.Context, post []*MetricP
 	extSeg.AddAttribute("postSize", len(postBytes))
 	resp, err := sender.HttpClient(req)
 	extSeg.End()
+	backendhttp.SubmissionStatuses.Record(sender.metricIngestURL, resp, err)
 
 	if err != nil {
-		return fmt.Errorf("error sending events: %v", err)
+		return fmt.Errorf("error sending events: %w", err)
 	}
 
 	// To let the http client reusing the connections, the response body
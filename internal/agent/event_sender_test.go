@@ -4,6 +4,7 @@ package agent
 
 import (
 	"compress/gzip"
+	goContext "context"
 	"crypto/tls"
 	"encoding/json"
 	"io/ioutil"
@@ -13,10 +14,12 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
 	"github.com/newrelic/infrastructure-agent/internal/testhelpers"
 	"github.com/newrelic/infrastructure-agent/pkg/entity/host"
 	infra "github.com/newrelic/infrastructure-agent/test/infra/http"
@@ -47,6 +50,18 @@ func (s *EventSenderSuite) TestMetricsEntryPointConfig(c *C) {
 	c.Assert(sender.metricIngestURL, Equals, "http://test.com/metrics")
 }
 
+func TestMetricsIngestSender_QueueDepthAgentMetrics(t *testing.T) {
+	context := newTestContext("testAgent", &config.Config{})
+	sender := newMetricsIngestSender(context, "license", "userAgent", http2.NullHttpClient, false)
+	assert.NoError(t, sender.Start())
+	defer sender.Stop()
+
+	var metrics strings.Builder
+	assert.NoError(t, openmetrics.AgentMetrics.Write(&metrics))
+	assert.Contains(t, metrics.String(), "\nnria_event_queue_depth 0\n")
+	assert.Contains(t, metrics.String(), "\nnria_batch_queue_depth 0\n")
+}
+
 func (s *EventSenderSuite) TestSingleEventBatch(c *C) {
 	accumulatedBatches := make(map[int][]byte) // A map of number -> event payload
 	// We're using a map so we can add to it within the handler function and later access the data.
@@ -540,6 +555,132 @@ func TestEventSender_ResponseError(t *testing.T) {
 	}
 }
 
+// eventsCollector is a fake metrics ingest service counting the received events and posts.
+type eventsCollector struct {
+	*httptest.Server
+	lock   sync.Mutex
+	events int
+	posts  int
+}
+
+func newEventsCollector(t *testing.T) *eventsCollector {
+	collector := &eventsCollector{}
+	collector.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// ignores other requests, as the connectivity check
+		if !strings.HasSuffix(r.URL.Path, "/events/bulk") {
+			return
+		}
+		var posts []MetricPost
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&posts))
+		collector.lock.Lock()
+		defer collector.lock.Unlock()
+		collector.posts++
+		for _, post := range posts {
+			collector.events += len(post.Events)
+		}
+	}))
+	t.Cleanup(collector.Close)
+	return collector
+}
+
+func (c *eventsCollector) received() (events, posts int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.events, c.posts
+}
+
+func TestEventSender_Flush(t *testing.T) {
+	collector := newEventsCollector(t)
+	cfg := &config.Config{
+		PayloadCompressionLevel: gzip.NoCompression,
+		CollectorURL:            collector.URL,
+		EventQueueDepth:         2000,
+	}
+	sender := newMetricsIngestSender(newTestContext("testAgent", cfg), "license", "userAgent", collector.Client().Do, false)
+
+	// fill the queues of a stopped sender
+	for i := 0; i < 1500; i++ {
+		assert.NoError(t, sender.QueueEvent(mapEvent{"eventType": "TestEvent", "value": i}, ""))
+	}
+	sender.batchQueue <- eventBatch{{entityKey: "testAgent", agentKey: "testAgent", data: []byte(`{"eventType":"TestEvent"}`)}}
+	sender.pendingBatch = eventBatch{{entityKey: "remote", agentKey: "testAgent", data: []byte(`{"eventType":"TestEvent"}`)}}
+
+	assert.Equal(t, 0, sender.Flush(goContext.Background()))
+
+	events, posts := collector.received()
+	assert.Equal(t, 1502, events)
+	// the queued batch, and the queued events batched by the maximum events per post
+	assert.Equal(t, 5, posts)
+	assert.Empty(t, sender.eventQueue)
+	assert.Empty(t, sender.batchQueue)
+	assert.Empty(t, sender.pendingBatch)
+}
+
+func TestEventSender_Flush_Stopped(t *testing.T) {
+	collector := newEventsCollector(t)
+	cfg := &config.Config{
+		PayloadCompressionLevel: gzip.NoCompression,
+		CollectorURL:            collector.URL,
+	}
+	sender := newMetricsIngestSender(newTestContext("testAgent", cfg), "license", "userAgent", collector.Client().Do, false)
+	assert.NoError(t, sender.Start())
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, sender.QueueEvent(mapEvent{"eventType": "TestEvent", "value": i}, ""))
+	}
+	// let the events be accumulated into a batch, which is not sent until the batch timer fires
+	assert.Eventually(t, func() bool { return len(sender.eventQueue) == 0 }, time.Second, 10*time.Millisecond)
+	assert.NoError(t, sender.Stop())
+
+	assert.Equal(t, 0, sender.Flush(goContext.Background()))
+
+	events, _ := collector.received()
+	assert.Equal(t, 10, events)
+}
+
+func TestEventSender_Flush_Timeout(t *testing.T) {
+	unblock := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer collector.Close()
+	defer close(unblock)
+
+	cfg := &config.Config{
+		PayloadCompressionLevel: gzip.NoCompression,
+		CollectorURL:            collector.URL,
+	}
+	sender := newMetricsIngestSender(newTestContext("testAgent", cfg), "license", "userAgent", collector.Client().Do, false)
+	for i := 0; i < 600; i++ {
+		assert.NoError(t, sender.QueueEvent(mapEvent{"eventType": "TestEvent", "value": i}, ""))
+	}
+
+	ctx, cancel := goContext.WithTimeout(goContext.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.Equal(t, 600, sender.Flush(ctx))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestEventSender_Flush_NoAgentID(t *testing.T) {
+	collector := newEventsCollector(t)
+	cfg := &config.Config{
+		ConnectEnabled:          true,
+		PayloadCompressionLevel: gzip.NoCompression,
+		CollectorURL:            collector.URL,
+	}
+	// the agent identity is never provided
+	c := NewContext(cfg, "1.2.3", testhelpers.NullHostnameResolver, host.IDLookup{}, nil, nil)
+	c.setAgentKey(agentKey)
+	sender := newMetricsIngestSender(c, "license", "userAgent", collector.Client().Do, true)
+	assert.NoError(t, sender.QueueEvent(ev, ""))
+
+	ctx, cancel := goContext.WithTimeout(goContext.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, 1, sender.Flush(ctx))
+	events, _ := collector.received()
+	assert.Equal(t, 0, events)
+}
+
 func newTestContext(agentKey string, cfg *config.Config) *context {
 	var atomicAgentKey atomic.Value
 	atomicAgentKey.Store(agentKey)
@@ -91,6 +91,8 @@ type vortexEventSender struct {
 	registerBatchSize        int
 	registerFrequency        time.Duration
 	getBackoffTimer          func(time.Duration) *time.Timer
+	retry                    *submissionRetry
+	metricNamePrefix         string // Prefix for the event attribute names, but the identity ones
 }
 
 // IsAgent returns true when event belongs to the agent/local entity.
@@ -145,6 +147,8 @@ func newVortexEventSender(ctx *context, licenseKey, userAgent string, httpClient
 		registerBatchSize:        cfg.RegisterBatchSize,
 		registerFrequency:        time.Duration(cfg.RegisterFrequencySecs) * time.Second,
 		getBackoffTimer:          time.NewTimer,
+		retry:                    newSubmissionRetry(cfg),
+		metricNamePrefix:         cfg.MetricNamePrefix,
 		sendErrorCount:           new(uint32),
 	}
 }
@@ -203,6 +207,11 @@ func (s *vortexEventSender) QueueEvent(event sample.Event, key entity.Key) (err
 		return fmt.Errorf("error marshalling event to JSON: %+v (%+v)", event, err)
 	}
 
+	edata, err = decorateEvent(edata, s.metricNamePrefix, s.Context.DeploymentMarker())
+	if err != nil {
+		return fmt.Errorf("error decorating event: %+v (%+v)", event, err)
+	}
+
 	if len(edata) > s.maxMetricsBatchSizeBytes {
 		return fmt.Errorf("cannot queue event: larger than max size (%d > %d)", len(edata), s.maxMetricsBatchSizeBytes)
 	}
@@ -421,7 +430,6 @@ func newMetricVortexPost(entityKey entity.Key, entityID, agentID entity.ID) *Met
 
 // Wait for queued batches and send any to the ingest API
 func (s *vortexEventSender) sendBatches() {
-	retryBO := backoff.NewDefaultBackoff()
 	for {
 		select {
 
@@ -453,10 +461,14 @@ func (s *vortexEventSender) sendBatches() {
 			}
 
 			err := s.doPost(bulkPost, agentKey)
+			err = s.retry.retry(err, s.backoff, func() error {
+				vlog.Debug("Retrying metrics post.")
+				return s.doPost(bulkPost, agentKey)
+			})
 
 			if err == nil {
 				atomic.StoreUint32(s.sendErrorCount, 0)
-				retryBO.Reset()
+				s.retry.reset()
 				continue
 			}
 
@@ -469,12 +481,12 @@ func (s *vortexEventSender) sendBatches() {
 			}
 
 			if e.retryPolicy.After > 0 {
-				vlog.WithField("retryAfter", e.retryPolicy.After).Debug("Metric sender retry requested.")
-				retryBO.Reset()
-				s.backoff(e.retryPolicy.After)
+				retryAfter := s.retry.retryAfter(e.retryPolicy)
+				vlog.WithField("retryAfter", retryAfter).Debug("Metric sender retry requested.")
+				s.backoff(retryAfter)
 				continue
 			}
-			retryBOAfter := retryBO.DurationWithMax(e.retryPolicy.MaxBackOff)
+			retryBOAfter := s.retry.backoffAfter(e.retryPolicy)
 			vlog.WithField("retryBackoffAfter", retryBOAfter).Debug("Metric sender backoff and retry requested.")
 			s.backoff(retryBOAfter)
 
@@ -487,12 +499,14 @@ func (s *vortexEventSender) sendBatches() {
 }
 
 // backoff waits for the specified duration or a signal from the stop
-// channel, whichever happens first.
-func (s *vortexEventSender) backoff(d time.Duration) {
+// channel, whichever happens first. It returns false when the sender was stopped.
+func (s *vortexEventSender) backoff(d time.Duration) bool {
 	backoffTimer := s.getBackoffTimer(d)
 	select {
 	case <-s.stopChannel:
+		return false
 	case <-backoffTimer.C:
+		return true
 	}
 }
 
@@ -0,0 +1,83 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/testhelpers"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/metrics/types"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingEventSender records the types of the queued events.
+type recordingEventSender struct {
+	fakeEventSender
+	eventTypes *[]string
+}
+
+func (r recordingEventSender) QueueEvent(event sample.Event, _ entity.Key) error {
+	*r.eventTypes = append(*r.eventTypes, sample.TypeOf(event))
+	return nil
+}
+
+func sendEventsOfTypes(c *context) {
+	includeAll := func(interface{}) bool { return true }
+	c.shouldIncludeEvent = includeAll
+	c.shouldExcludeEvent = includeAll
+
+	processSample := &types.ProcessSample{}
+	processSample.Type("ProcessSample")
+	c.SendEvent(processSample, "some key")
+	c.SendEvent(&sample.BaseEvent{EventType: "SystemSample"}, "some key")
+	c.SendEvent(&sample.BaseEvent{EventType: "NetworkSample"}, "some key")
+	// from integrations
+	c.SendEvent(mapEvent{"eventType": "RedisSample"}, "some key")
+	c.SendEvent(mapEvent{"eventType": "InfrastructureEvent"}, "some key")
+}
+
+func TestContext_SendEvent_EventTypeFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist []string
+		denylist  []string
+		expected  []string
+	}{
+		{
+			name:     "no lists",
+			expected: []string{"ProcessSample", "SystemSample", "NetworkSample", "RedisSample", "InfrastructureEvent"},
+		},
+		{
+			name:      "allowlist",
+			allowlist: []string{"SystemSample", "RedisSample"},
+			expected:  []string{"SystemSample", "RedisSample"},
+		},
+		{
+			name:     "denylist",
+			denylist: []string{"NetworkSample", "InfrastructureEvent"},
+			expected: []string{"ProcessSample", "SystemSample", "RedisSample"},
+		},
+		{
+			name:      "denylist takes precedence",
+			allowlist: []string{"SystemSample", "NetworkSample"},
+			denylist:  []string{"NetworkSample"},
+			expected:  []string{"SystemSample"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{EventTypeAllowlist: tt.allowlist, EventTypeDenylist: tt.denylist}
+			c := NewContext(cfg, "0.0.0", testhelpers.NullHostnameResolver, NilIDLookup, nil, nil)
+			var sent []string
+			c.eventSender = recordingEventSender{eventTypes: &sent}
+
+			sendEventsOfTypes(c)
+
+			assert.Equal(t, tt.expected, sent)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	context2 "context"
+	"sync"
+	"time"
+
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
+	"github.com/sirupsen/logrus"
+)
+
+const fileHandlesReportInterval = time.Minute
+
+// fileHandlesUsage open file handles of the agent process and its limits.
+type fileHandlesUsage struct {
+	Open      uint64
+	SoftLimit uint64
+	HardLimit uint64
+}
+
+// fileHandlesGauges last file handles usage read, served as agent metrics gauges.
+type fileHandlesGauges struct {
+	lock  sync.Mutex
+	usage fileHandlesUsage
+}
+
+// newFileHandlesGauges registers the file handles gauges on the registry, reading the usage last recorded.
+func newFileHandlesGauges(registry *openmetrics.Registry) *fileHandlesGauges {
+	g := &fileHandlesGauges{}
+	registry.SetGaugeFunc("nria_agent_open_file_handles", "Open file handles of the agent process.",
+		func() float64 { return float64(g.get().Open) })
+	registry.SetGaugeFunc("nria_agent_file_handles_soft_limit", "Soft limit of open file handles of the agent process.",
+		func() float64 { return float64(g.get().SoftLimit) })
+	registry.SetGaugeFunc("nria_agent_file_handles_hard_limit", "Hard limit of open file handles of the agent process.",
+		func() float64 { return float64(g.get().HardLimit) })
+	return g
+}
+
+func (g *fileHandlesGauges) get() fileHandlesUsage {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.usage
+}
+
+func (g *fileHandlesGauges) record(usage fileHandlesUsage) {
+	alog.WithFieldsF(func() logrus.Fields {
+		return logrus.Fields{"open": usage.Open, "softLimit": usage.SoftLimit, "hardLimit": usage.HardLimit}
+	}).Debug("Agent file handles usage.")
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.usage = usage
+}
+
+// reportFileHandles records on every interval the file handles usage of the agent process on the gauges, until ctx
+// is done.
+func reportFileHandles(ctx context2.Context, read func() (fileHandlesUsage, error), gauges *fileHandlesGauges, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			usage, err := read()
+			if err != nil {
+				alog.WithError(err).Debug("Can't read the agent file handles usage.")
+				continue
+			}
+			gauges.record(usage)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"os"
+	"syscall"
+)
+
+// procSelfFDDir holds an entry for every file descriptor open by the agent process.
+const procSelfFDDir = "/proc/self/fd"
+
+// readSelfFileHandles returns the file handles usage of the agent process.
+func readSelfFileHandles() (fileHandlesUsage, error) {
+	return readFileHandles(procSelfFDDir, func(rlimit *syscall.Rlimit) error {
+		return syscall.Getrlimit(syscall.RLIMIT_NOFILE, rlimit)
+	})
+}
+
+// readFileHandles counts the file descriptors in fdDir, including the one used to read it, and gets the file
+// descriptors limits from getrlimit.
+func readFileHandles(fdDir string, getrlimit func(*syscall.Rlimit) error) (fileHandlesUsage, error) {
+	fds, err := os.ReadDir(fdDir)
+	if err != nil {
+		return fileHandlesUsage{}, err
+	}
+
+	var rlimit syscall.Rlimit
+	if err = getrlimit(&rlimit); err != nil {
+		return fileHandlesUsage{}, err
+	}
+
+	return fileHandlesUsage{
+		Open:      uint64(len(fds)),
+		SoftLimit: rlimit.Cur,
+		HardLimit: rlimit.Max,
+	}, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockRlimit(soft, hard uint64) func(*syscall.Rlimit) error {
+	return func(rlimit *syscall.Rlimit) error {
+		rlimit.Cur = soft
+		rlimit.Max = hard
+		return nil
+	}
+}
+
+func TestReadFileHandles(t *testing.T) {
+	fdDir := t.TempDir()
+	for fd := 0; fd < 7; fd++ {
+		require.NoError(t, os.Symlink("/dev/null", filepath.Join(fdDir, strconv.Itoa(fd))))
+	}
+
+	usage, err := readFileHandles(fdDir, mockRlimit(1024, 4096))
+	require.NoError(t, err)
+	assert.Equal(t, fileHandlesUsage{Open: 7, SoftLimit: 1024, HardLimit: 4096}, usage)
+}
+
+func TestReadFileHandles_MissingFDDir(t *testing.T) {
+	_, err := readFileHandles(filepath.Join(t.TempDir(), "fd"), mockRlimit(1024, 4096))
+	assert.Error(t, err)
+}
+
+func TestReadFileHandles_RlimitError(t *testing.T) {
+	rlimitErr := errors.New("getrlimit failed")
+
+	_, err := readFileHandles(t.TempDir(), func(*syscall.Rlimit) error { return rlimitErr })
+	assert.ErrorIs(t, err, rlimitErr)
+}
+
+func TestReadSelfFileHandles(t *testing.T) {
+	usage, err := readSelfFileHandles()
+	require.NoError(t, err)
+	// at least stdin, stdout, stderr
+	assert.GreaterOrEqual(t, usage.Open, uint64(3))
+	assert.LessOrEqual(t, usage.Open, usage.SoftLimit)
+	assert.LessOrEqual(t, usage.SoftLimit, usage.HardLimit)
+}
+
+func TestFileHandlesGauges(t *testing.T) {
+	registry := openmetrics.NewRegistry()
+	gauges := newFileHandlesGauges(registry)
+	gauges.record(fileHandlesUsage{Open: 7, SoftLimit: 1024, HardLimit: 4096})
+
+	var metrics bytes.Buffer
+	require.NoError(t, registry.Write(&metrics))
+	assert.Contains(t, metrics.String(), "\nnria_agent_open_file_handles 7\n")
+	assert.Contains(t, metrics.String(), "\nnria_agent_file_handles_soft_limit 1024\n")
+	assert.Contains(t, metrics.String(), "\nnria_agent_file_handles_hard_limit 4096\n")
+}
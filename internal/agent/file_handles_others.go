@@ -0,0 +1,17 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build !linux
+// +build !linux
+
+package agent
+
+import (
+	"errors"
+)
+
+var errFileHandlesUnsupported = errors.New("the agent file handles usage is only available on Linux")
+
+// readSelfFileHandles returns the file handles usage of the agent process.
+func readSelfFileHandles() (fileHandlesUsage, error) {
+	return fileHandlesUsage{}, errFileHandlesUnsupported
+}
@@ -4,9 +4,12 @@
 package instrumentation
 
 import (
+	"context"
+	"net/http"
+	"strings"
+
 	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/hostname"
-	"strings"
 )
 
 func InitSelfInstrumentation(c *config.Config, resolver hostname.Resolver) {
@@ -22,3 +25,17 @@ func InitSelfInstrumentation(c *config.Config, resolver hostname.Resolver) {
 		}
 	}
 }
+
+// InitSelfInstrumentationSpans exports the agent spans to the configured OTLP traces endpoint, until ctx is done.
+func InitSelfInstrumentationSpans(ctx context.Context, c *config.Config, resolver hostname.Resolver) {
+	if c.SelfInstrumentationTracesEndpoint == "" {
+		return
+	}
+	exporter := NewOTLPSpanExporter(
+		c.SelfInstrumentationTracesEndpoint,
+		&http.Client{Timeout: otlpExportTimeout},
+		map[string]interface{}{"service.name": appName, "host.name": resolver.Long()},
+	)
+	SelfInstrumentationSpans = exporter
+	go exporter.Run(ctx, otlpSpansFlushInterval)
+}
@@ -0,0 +1,216 @@
+// Copyright New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package instrumentation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	otlpSpansQueueLen      = 1000
+	otlpSpansBatchSize     = 100
+	otlpSpansFlushInterval = 5 * time.Second
+	otlpExportTimeout      = 10 * time.Second
+	otlpScopeName          = "github.com/newrelic/infrastructure-agent"
+
+	// OTLP span kind and status codes
+	otlpSpanKindInternal = 1
+	otlpSpanKindClient   = 3
+	otlpStatusCodeError  = 2
+)
+
+// OTLPSpanExporter exports the recorded spans in batches to an OTLP/HTTP traces endpoint, JSON encoded. Spans
+// recorded while the queue is full are dropped.
+type OTLPSpanExporter struct {
+	endpoint string
+	client   *http.Client
+	resource otlpResource
+	spans    chan Span
+}
+
+// NewOTLPSpanExporter creates an exporter of the spans to the endpoint, e.g. http://localhost:4318/v1/traces,
+// described by the resource attributes.
+func NewOTLPSpanExporter(endpoint string, client *http.Client, resource map[string]interface{}) *OTLPSpanExporter {
+	return &OTLPSpanExporter{
+		endpoint: endpoint,
+		client:   client,
+		resource: otlpResource{Attributes: otlpAttributes(resource)},
+		spans:    make(chan Span, otlpSpansQueueLen),
+	}
+}
+
+func (e *OTLPSpanExporter) Enabled() bool {
+	return true
+}
+
+func (e *OTLPSpanExporter) RecordSpan(span Span) {
+	select {
+	case e.spans <- span:
+	default:
+		slog.WithField("span", span.Name).Debug("Spans queue is full, dropping span.")
+	}
+}
+
+// Run exports the recorded spans on every interval, or as soon as a batch is full, until ctx is done.
+func (e *OTLPSpanExporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var batch []Span
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.export(ctx, batch); err != nil {
+			slog.WithError(err).WithField("spans", len(batch)).Warn("Cannot export spans.")
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case span := <-e.spans:
+			batch = append(batch, span)
+			if len(batch) >= otlpSpansBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (e *OTLPSpanExporter) export(ctx context.Context, spans []Span) error {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		otlpSpans = append(otlpSpans, newOTLPSpan(span))
+	}
+	body, err := json.Marshal(otlpTracesRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   e.resource,
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: otlpScopeName}, Spans: otlpSpans}},
+	}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// OTLP/HTTP JSON encoding of the ExportTraceServiceRequest, where 64 bit integers are encoded as strings.
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+func newOTLPSpan(span Span) otlpSpan {
+	s := otlpSpan{
+		TraceID:           span.TraceID,
+		SpanID:            span.SpanID,
+		ParentSpanID:      span.ParentSpanID,
+		Name:              span.Name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+		Attributes:        otlpAttributes(span.Attributes),
+	}
+	// root spans are the requests to other services
+	if span.ParentSpanID == "" {
+		s.Kind = otlpSpanKindClient
+	}
+	if span.Err != nil {
+		s.Status = &otlpStatus{Code: otlpStatusCodeError, Message: span.Err.Error()}
+	}
+	return s
+}
+
+func otlpAttributes(attributes map[string]interface{}) []otlpAttribute {
+	otlpAttrs := make([]otlpAttribute, 0, len(attributes))
+	for key, value := range attributes {
+		var v otlpValue
+		switch val := value.(type) {
+		case bool:
+			v.BoolValue = &val
+		case int:
+			i := strconv.Itoa(val)
+			v.IntValue = &i
+		case int64:
+			i := strconv.FormatInt(val, 10)
+			v.IntValue = &i
+		case float64:
+			v.DoubleValue = &val
+		default:
+			s := fmt.Sprint(val)
+			v.StringValue = &s
+		}
+		otlpAttrs = append(otlpAttrs, otlpAttribute{Key: key, Value: v})
+	}
+	return otlpAttrs
+}
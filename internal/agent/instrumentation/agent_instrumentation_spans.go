@@ -0,0 +1,61 @@
+// Copyright New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package instrumentation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// SelfInstrumentationSpans records the spans of the agent traces, such as the ones of its HTTP requests.
+var SelfInstrumentationSpans SpanRecorder = noopSpanRecorder{}
+
+// SpanRecorder records finished spans.
+type SpanRecorder interface {
+	// Enabled returns false when the recorded spans are discarded, so there is no need to build them.
+	Enabled() bool
+	RecordSpan(span Span)
+}
+
+// Span an operation of a trace. Identifiers are hex encoded, as in OpenTelemetry.
+type Span struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]interface{}
+	// Err fails the span when set.
+	Err error
+}
+
+// NewSpan starts a span. It starts a new trace when parent is nil.
+func NewSpan(name string, parent *Span) Span {
+	span := Span{SpanID: randomID(8), Name: name, Start: time.Now(), Attributes: map[string]interface{}{}}
+	if parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentSpanID = parent.SpanID
+	} else {
+		span.TraceID = randomID(16)
+	}
+	return span
+}
+
+func randomID(bytes int) string {
+	id := make([]byte, bytes)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+type noopSpanRecorder struct{}
+
+func (n noopSpanRecorder) Enabled() bool {
+	return false
+}
+
+func (n noopSpanRecorder) RecordSpan(_ Span) {
+	//intentionally left empty
+}
@@ -0,0 +1,33 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	context2 "context"
+	"time"
+
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
+)
+
+// reportIntervalCounts adds on every interval the counts flushed since the previous one to the counter, labelled by
+// their key, until ctx is done.
+func reportIntervalCounts(ctx context2.Context, flush func() map[string]uint64, counter *openmetrics.CounterVec, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			addIntervalCounts(counter, flush())
+		case <-ctx.Done():
+			addIntervalCounts(counter, flush())
+			return
+		}
+	}
+}
+
+func addIntervalCounts(counter *openmetrics.CounterVec, counts map[string]uint64) {
+	for key, count := range counts {
+		counter.Add(float64(count), key)
+	}
+}
@@ -16,7 +16,10 @@ import (
 type PatchSenderProviderFunc func(entity.Entity) (PatchSender, error)
 
 type EntityPatcher struct {
-	m sync.Mutex
+	// sources guards the entities source files, which are saved concurrently, and read or removed exclusively. It's
+	// always acquired before m.
+	sources sync.RWMutex
+	m       sync.Mutex
 
 	BasePatcher
 	entities map[entity.Key]struct {
@@ -52,10 +55,12 @@ func NewEntityPatcher(cfg PatcherConfig, deltaStore *delta.Store, patchSenderPro
 
 func (ep *EntityPatcher) Send() error {
 	if ep.needsCleanup() {
+		ep.sources.Lock()
 		ep.m.Lock()
 		ep.seenEntities = make(map[entity.Key]struct{})
 		ep.cleanOutdatedEntities()
 		ep.m.Unlock()
+		ep.sources.Unlock()
 	}
 
 	ep.m.Lock()
@@ -77,6 +82,8 @@ func (ep *EntityPatcher) Send() error {
 }
 
 func (ep *EntityPatcher) Reap() {
+	ep.sources.Lock()
+	defer ep.sources.Unlock()
 	ep.m.Lock()
 	defer ep.m.Unlock()
 
@@ -89,15 +96,40 @@ func (ep *EntityPatcher) Reap() {
 	}
 }
 
-func (ep *EntityPatcher) Save(data types.PluginOutput) error {
+// ResendInventory removes the inventory cache of the plugins, so their whole inventory is sent once the registered
+// entities are reaped again.
+func (ep *EntityPatcher) ResendInventory(plugins []string) (triggered int, size int64, err error) {
+	ep.sources.Lock()
+	defer ep.sources.Unlock()
 	ep.m.Lock()
 	defer ep.m.Unlock()
 
+	return ep.deltaStore.ResendInventory(plugins)
+}
+
+func (ep *EntityPatcher) Compact(interval time.Duration) (time.Duration, error) {
+	ep.sources.Lock()
+	defer ep.sources.Unlock()
+	ep.m.Lock()
+	defer ep.m.Unlock()
+
+	return ep.deltaStore.CompactOnInterval(interval)
+}
+
+// Save stores the plugin data as the entity source. Data of different entities is saved concurrently, while the data
+// of the same entity must be saved in order by the caller.
+func (ep *EntityPatcher) Save(data types.PluginOutput) error {
 	if data.NotApplicable {
 		return nil
 	}
 
-	if err := ep.registerEntity(data.Entity); err != nil {
+	ep.sources.RLock()
+	defer ep.sources.RUnlock()
+
+	ep.m.Lock()
+	err := ep.registerEntity(data.Entity)
+	ep.m.Unlock()
+	if err != nil {
 		return fmt.Errorf("failed to save plugin inventory data, error: %w", err)
 	}
 
@@ -105,6 +137,8 @@ func (ep *EntityPatcher) Save(data types.PluginOutput) error {
 		return fmt.Errorf("failed to save plugin inventory data, error: %w", err)
 	}
 
+	ep.m.Lock()
+	defer ep.m.Unlock()
 	ep.seenEntities[data.Entity.Key] = struct{}{}
 
 	e := ep.entities[data.Entity.Key]
@@ -166,6 +200,10 @@ func (ep *EntityPatcher) cleanOutdatedEntities() {
 		ilog.WithField("entityKey", entityKey.String()).Debug("Removing inventory for entity.")
 		if err := ep.unregisterEntity(entityKey); err != nil {
 			ilog.WithError(err).Warn("unregistering inventory for entity")
+			continue
+		}
+		if ep.cfg.RemoveEntitiesAudit {
+			AuditEntityRemoval(entityKey.String(), ep.removePeriod())
 		}
 	}
 	// Remove folders from unregistered entities that still have folders in the data directory (e.g. from
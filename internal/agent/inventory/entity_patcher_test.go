@@ -4,17 +4,22 @@
 package inventory
 
 import (
+	"fmt"
 	"github.com/newrelic/infrastructure-agent/internal/agent/delta"
 	agentTypes "github.com/newrelic/infrastructure-agent/internal/agent/types"
 	"github.com/newrelic/infrastructure-agent/pkg/entity"
 	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
 	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+	logHelper "github.com/newrelic/infrastructure-agent/test/log"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestCleanOutdatedEntities(t *testing.T) {
@@ -103,3 +108,53 @@ func TestCleanOutdatedEntities(t *testing.T) {
 		}
 	}
 }
+
+func TestCleanOutdatedEntities_AuditRecord(t *testing.T) {
+	dataDir := t.TempDir()
+	deltaStore := delta.NewStore(dataDir, "default", 1024, false)
+
+	hook := logHelper.NewInMemoryEntriesHook([]logrus.Level{logrus.InfoLevel})
+	log.AddHook(hook)
+
+	for _, auditEnabled := range []bool{true, false} {
+		// GIVEN an entity patcher with removal audit toggled
+		entityPatcher := &EntityPatcher{
+			BasePatcher: BasePatcher{
+				cfg: PatcherConfig{
+					RemoveEntitiesPeriod: time.Hour,
+					RemoveEntitiesAudit:  auditEnabled,
+				},
+				deltaStore: deltaStore,
+			},
+			patchSenderProviderFn: func(e entity.Entity) (PatchSender, error) {
+				return nil, nil
+			},
+			entities: map[entity.Key]struct {
+				sender       PatchSender
+				needsReaping bool
+			}{},
+			seenEntities: map[entity.Key]struct{}{},
+		}
+		entityKey := fmt.Sprintf("audited:%t", auditEnabled)
+		require.NoError(t, entityPatcher.registerEntity(entity.NewFromNameWithoutID(entityKey)))
+
+		// WHEN the entity is removed for not reporting
+		entityPatcher.cleanOutdatedEntities()
+
+		// THEN an audit record is emitted only when enabled
+		var found *logrus.Entry
+		for _, e := range hook.GetEntries() {
+			if e.Data["entityKey"] == entityKey {
+				found = &e
+				break
+			}
+		}
+		if !auditEnabled {
+			assert.Nil(t, found)
+			continue
+		}
+		require.NotNil(t, found)
+		assert.Equal(t, "Removed non-reporting entity.", found.Message)
+		assert.Equal(t, "no data reported for 1h0m0s", found.Data["reason"])
+	}
+}
@@ -6,10 +6,14 @@ package inventory
 import (
 	context2 "context"
 	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
+	"github.com/newrelic/infrastructure-agent/pkg/backend/backoff"
 	"github.com/newrelic/infrastructure-agent/pkg/backend/inventoryapi"
 	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
 	"github.com/newrelic/infrastructure-agent/pkg/helpers"
 	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"hash/fnv"
 	"net/http"
 	"time"
 )
@@ -23,6 +27,20 @@ type HandlerConfig struct {
 	ReapInterval      time.Duration
 	SendInterval      time.Duration
 	InventoryQueueLen int
+	// MinSendInterval minimum interval between deltas submissions, so changes in between are coalesced.
+	MinSendInterval time.Duration
+	// Workers number of routines processing the inventory data. The data of an entity is always processed by the same
+	// one, so it's processed in order. Values lower than 1 run a single one.
+	Workers int
+	// RetryBackoffStep interval doubled on each failed submission to back off. Zero uses a second.
+	RetryBackoffStep time.Duration
+	// RetryBackoffMax upper bound of the backoff after failed submissions. Zero uses config.MAX_BACKOFF.
+	RetryBackoffMax time.Duration
+	// CompactionInterval interval between compactions of the storage of all the entities. Zero disables them.
+	CompactionInterval time.Duration
+	// ShutdownFlushContext returns the context bounding the submission of the pending deltas once stopped, shared with
+	// the rest of the shutdown. Nil doesn't submit them.
+	ShutdownFlushContext func() context2.Context
 }
 
 // Handler maintains the infrastructure inventory in an updated state.
@@ -37,50 +55,116 @@ type Handler struct {
 
 	initialReap bool
 
-	dataCh chan types.PluginOutput
+	// dataChs queues of the inventory data, one per worker.
+	dataChs []chan types.PluginOutput
 
-	sendTimer *time.Timer
+	sendTimer    *time.Timer
+	getSendTimer func(time.Duration) *time.Timer
+
+	resendCh chan resendRequest
 
 	sendErrorCount uint32
+	// retryStatusClass status class of the failed deltas submission the next one retries, empty if it didn't fail.
+	retryStatusClass string
+	retries          *openmetrics.CounterVec
 }
 
 // NewInventoryHandler returns a new instances of an inventory.Handler.
 func NewInventoryHandler(ctx context2.Context, cfg HandlerConfig, patcher Patcher) *Handler {
 	ctx2, cancelFn := context2.WithCancel(ctx)
 
+	workers := cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	// The queue length is shared among the workers.
+	dataChs := make([]chan types.PluginOutput, workers)
+	for i := range dataChs {
+		dataChs[i] = make(chan types.PluginOutput, (cfg.InventoryQueueLen+workers-1)/workers)
+	}
+
 	return &Handler{
-		cfg:         cfg,
-		dataCh:      make(chan types.PluginOutput, cfg.InventoryQueueLen),
-		ctx:         ctx2,
-		cancelFn:    cancelFn,
-		patcher:     patcher,
-		initialReap: true,
+		cfg:          cfg,
+		dataChs:      dataChs,
+		ctx:          ctx2,
+		cancelFn:     cancelFn,
+		patcher:      patcher,
+		initialReap:  true,
+		getSendTimer: time.NewTimer,
+		resendCh:     make(chan resendRequest),
+		retries:      backoff.SubmissionRetries,
 	}
 }
 
 // Handle the inventory data from a plugin/integration.
 func (h *Handler) Handle(data types.PluginOutput) {
-	h.dataCh <- data
+	h.entityDataCh(data.Entity.Key) <- data
+}
+
+// entityDataCh returns the queue of the worker processing the data of the entity.
+func (h *Handler) entityDataCh(entityKey entity.Key) chan types.PluginOutput {
+	if len(h.dataChs) == 1 {
+		return h.dataChs[0]
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(entityKey))
+	return h.dataChs[hash.Sum32()%uint32(len(h.dataChs))]
+}
+
+// QueueDepth returns the number of inventory payloads waiting to be processed.
+func (h *Handler) QueueDepth() int {
+	depth := 0
+	for _, dataCh := range h.dataChs {
+		depth += len(dataCh)
+	}
+	return depth
 }
 
 // Start will run the routines that periodically checks for deltas and submit them.
 func (h *Handler) Start() {
-	go h.listenForData()
+	for _, dataCh := range h.dataChs {
+		go h.listenForData(dataCh)
+	}
 	h.doProcess()
 }
 
+// resendRequest asks the processing routine to resend the whole inventory of the plugins.
+type resendRequest struct {
+	plugins []string
+	result  chan<- resendResult
+}
+
+type resendResult struct {
+	triggered int
+	size      int64
+	err       error
+}
+
+// ResendInventory forces the whole inventory of the given plugins, or of all of them when none is given, to be sent
+// on the next reap. It's run by the routine reaping the deltas, so it waits for the handler to be started.
+func (h *Handler) ResendInventory(plugins []string) (triggered int, size int64, err error) {
+	result := make(chan resendResult, 1)
+	select {
+	case h.resendCh <- resendRequest{plugins: plugins, result: result}:
+	case <-h.ctx.Done():
+		return 0, 0, h.ctx.Err()
+	}
+	r := <-result
+	return r.triggered, r.size, r.err
+}
+
 // Stop will gracefully stop the inventory.Handler.
 func (h *Handler) Stop() {
 	h.cancelFn()
 }
 
-// listenForData from plugins/integrations.
-func (h *Handler) listenForData() {
+// listenForData from plugins/integrations. Every worker runs its own listener on its queue.
+func (h *Handler) listenForData(dataCh <-chan types.PluginOutput) {
 	for {
 		select {
 		case <-h.ctx.Done():
 			return
-		case data := <-h.dataCh:
+		case data := <-dataCh:
 			err := h.patcher.Save(data)
 			if err != nil {
 				ilog.WithError(err).Error("problem storing plugin output")
@@ -91,17 +175,28 @@ func (h *Handler) listenForData() {
 
 // doProcess does the inventory processing.
 func (h *Handler) doProcess() {
-	h.sendTimer = time.NewTimer(h.cfg.SendInterval)
+	h.sendTimer = h.getSendTimer(h.sendInterval(h.cfg.SendInterval))
 	reapTimer := time.NewTicker(h.cfg.FirstReapInterval)
 
+	var compactTimer *time.Timer
+	var compactC <-chan time.Time
+	if h.cfg.CompactionInterval > 0 {
+		compactTimer = time.NewTimer(h.cfg.CompactionInterval)
+		compactC = compactTimer.C
+	}
+
 	defer func() {
 		h.sendTimer.Stop()
 		reapTimer.Stop()
+		if compactTimer != nil {
+			compactTimer.Stop()
+		}
 	}()
 
 	for {
 		select {
 		case <-h.ctx.Done():
+			h.flush()
 			return
 		case <-reapTimer.C:
 			if h.initialReap {
@@ -111,35 +206,101 @@ func (h *Handler) doProcess() {
 			h.patcher.Reap()
 		case <-h.sendTimer.C:
 			h.send()
+		case request := <-h.resendCh:
+			triggered, size, err := h.patcher.ResendInventory(request.plugins)
+			request.result <- resendResult{triggered: triggered, size: size, err: err}
+		case <-compactC:
+			next, err := h.patcher.Compact(h.cfg.CompactionInterval)
+			if err != nil {
+				ilog.WithError(err).Error("compaction error")
+			}
+			compactTimer.Reset(next)
+		}
+	}
+}
+
+// flush submits the pending deltas once the handler is stopped, until the shutdown flush context is done. Deltas not
+// accepted by then are kept and submitted on the next start.
+func (h *Handler) flush() {
+	if h.cfg.ShutdownFlushContext == nil {
+		return
+	}
+	ctx := h.cfg.ShutdownFlushContext()
+
+	sent := make(chan error, 1)
+	go func() {
+		sent <- h.patcher.Send()
+	}()
+
+	select {
+	case err := <-sent:
+		if err != nil {
+			ilog.WithError(err).Warn("Cannot flush the inventory deltas on shutdown, they will be submitted on the next start.")
 		}
+	case <-ctx.Done():
+		ilog.Warn("Inventory deltas flush timed out on shutdown, they will be submitted on the next start.")
 	}
 }
 
-// send will submit the deltas.
+// send will submit the deltas and schedule the next submission. A submission after a failed one retries its deltas,
+// so it is counted as a retry.
 func (h *Handler) send() {
-	backoffMax := config.MAX_BACKOFF
+	if h.retryStatusClass != "" {
+		h.retries.Inc(backoff.InventorySender, h.retryStatusClass)
+	}
+	h.sendTimer = h.getSendTimer(h.sendInterval(h.nextSend(h.patcher.Send())))
+}
+
+// nextSend returns the interval until the next deltas submission after one that returned err, backing off on errors.
+func (h *Handler) nextSend(err error) time.Duration {
+	backoffMax := time.Duration(config.MAX_BACKOFF) * time.Second
+	if h.cfg.RetryBackoffMax > 0 {
+		backoffMax = h.cfg.RetryBackoffMax
+	}
+	backoffStep := time.Second
+	if h.cfg.RetryBackoffStep > 0 {
+		backoffStep = h.cfg.RetryBackoffStep
+	}
 
-	err := h.patcher.Send()
+	var retryAfter time.Duration
 	if err != nil {
-		if ingestError, ok := err.(*inventoryapi.IngestError); ok &&
-			ingestError.StatusCode == http.StatusTooManyRequests {
+		statusCode := 0
+		ingestError, ok := err.(*inventoryapi.IngestError)
+		if ok {
+			statusCode = ingestError.StatusCode
+			retryAfter = ingestError.RetryAfter
+		}
+		if ok && ingestError.StatusCode == http.StatusTooManyRequests {
 
 			ilog.Warn("server is rate limiting inventory submission")
 
-			backoffMax = config.RATE_LIMITED_BACKOFF
+			backoffMax = time.Duration(config.RATE_LIMITED_BACKOFF) * time.Second
 			h.sendErrorCount = helpers.MaxBackoffErrorCount
 		} else {
 			h.sendErrorCount++
 		}
+		h.retryStatusClass = backoff.StatusClass(statusCode)
 
 		ilog.WithError(err).WithField("errorCount", h.sendErrorCount).
 			Debug("Inventory sender can't process data.")
 	} else {
 		h.sendErrorCount = 0
+		h.retryStatusClass = ""
 	}
 
-	sendTimerVal := helpers.ExpBackoff(h.cfg.SendInterval,
-		time.Duration(backoffMax)*time.Second,
-		h.sendErrorCount)
-	h.sendTimer.Reset(sendTimerVal)
+	sendTimerVal := helpers.ExpBackoffWithStep(h.cfg.SendInterval, backoffStep, backoffMax, h.sendErrorCount)
+	// Retry-After is an upper bound of the wait, as the backend is ready to accept the deltas by then.
+	if retryAfter > 0 && sendTimerVal > retryAfter {
+		sendTimerVal = retryAfter
+	}
+	return sendTimerVal
+}
+
+// sendInterval returns the interval until the next deltas submission, which is never lower than the configured
+// minimum submission interval.
+func (h *Handler) sendInterval(interval time.Duration) time.Duration {
+	if interval < h.cfg.MinSendInterval {
+		return h.cfg.MinSendInterval
+	}
+	return interval
 }
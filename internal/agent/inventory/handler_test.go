@@ -0,0 +1,586 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/delta"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
+	"github.com/newrelic/infrastructure-agent/pkg/backend/inventoryapi"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingPatcher counts the submissions and the data saved in between.
+type countingPatcher struct {
+	lock        sync.Mutex
+	saved       int
+	pending     int
+	sends       []int
+	compactions int
+}
+
+func (p *countingPatcher) Save(types.PluginOutput) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.saved++
+	p.pending++
+	return nil
+}
+
+func (p *countingPatcher) Reap() {}
+
+func (p *countingPatcher) Send() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.pending > 0 {
+		p.sends = append(p.sends, p.pending)
+		p.pending = 0
+	}
+	return nil
+}
+
+func (p *countingPatcher) Compact(interval time.Duration) (time.Duration, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.compactions++
+	return interval, nil
+}
+
+func (p *countingPatcher) ResendInventory([]string) (int, int64, error) {
+	return 0, 0, nil
+}
+
+func (p *countingPatcher) compacted() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.compactions
+}
+
+func (p *countingPatcher) submissions() (saved int, sends []int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.saved, append([]int(nil), p.sends...)
+}
+
+// scheduledHandler starts a handler whose submissions are fired by the test. The returned channel receives every
+// scheduled submission interval, and the submission happens once the returned submit func is called, which waits
+// for the following interval to be scheduled.
+func scheduledHandler(t *testing.T, cfg HandlerConfig, patcher Patcher) (h *Handler, intervals <-chan time.Duration, submit func() time.Duration) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	scheduled := make(chan time.Duration)
+	fire := make(chan struct{})
+	h = NewInventoryHandler(ctx, cfg, patcher)
+	h.getSendTimer = func(d time.Duration) *time.Timer {
+		select {
+		case scheduled <- d:
+		case <-ctx.Done():
+			return time.NewTimer(0)
+		}
+		select {
+		case <-fire:
+		case <-ctx.Done():
+		}
+		return time.NewTimer(0)
+	}
+	go h.Start()
+
+	submit = func() time.Duration {
+		fire <- struct{}{}
+		return <-scheduled
+	}
+	return h, scheduled, submit
+}
+
+func handleChanges(t *testing.T, h *Handler, patcher *countingPatcher, changes int) {
+	t.Helper()
+
+	savedBefore, _ := patcher.submissions()
+	data := types.NewPluginOutput(ids.PluginID{Category: "test", Term: "handler"}, entity.NewFromNameWithoutID("localhost"), nil)
+	for i := 0; i < changes; i++ {
+		h.Handle(data)
+	}
+	assert.Eventually(t, func() bool {
+		saved, _ := patcher.submissions()
+		return saved == savedBefore+changes
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestHandler_MinSendIntervalCoalescesChanges(t *testing.T) {
+	cfg := HandlerConfig{
+		FirstReapInterval: time.Hour,
+		ReapInterval:      time.Hour,
+		SendInterval:      5 * time.Millisecond,
+		InventoryQueueLen: 10,
+		MinSendInterval:   100 * time.Millisecond,
+	}
+	patcher := &countingPatcher{}
+	h, intervals, submit := scheduledHandler(t, cfg, patcher)
+
+	// the first submission is already delayed
+	assert.Equal(t, 100*time.Millisecond, <-intervals)
+
+	// changes in between submissions are coalesced, and every submission is followed by the minimum interval
+	handleChanges(t, h, patcher, 5)
+	assert.Equal(t, 100*time.Millisecond, submit())
+	handleChanges(t, h, patcher, 3)
+	assert.Equal(t, 100*time.Millisecond, submit())
+
+	_, sends := patcher.submissions()
+	assert.Equal(t, []int{5, 3}, sends)
+}
+
+func TestHandler_NoMinSendInterval(t *testing.T) {
+	cfg := HandlerConfig{
+		FirstReapInterval: time.Hour,
+		ReapInterval:      time.Hour,
+		SendInterval:      5 * time.Millisecond,
+		InventoryQueueLen: 10,
+	}
+	patcher := &countingPatcher{}
+	h, intervals, submit := scheduledHandler(t, cfg, patcher)
+
+	assert.Equal(t, 5*time.Millisecond, <-intervals)
+	handleChanges(t, h, patcher, 1)
+	assert.Equal(t, 5*time.Millisecond, submit())
+	handleChanges(t, h, patcher, 1)
+	assert.Equal(t, 5*time.Millisecond, submit())
+
+	_, sends := patcher.submissions()
+	assert.Equal(t, []int{1, 1}, sends)
+}
+
+// concurrentPatcher tracks the maximum number of concurrent Save calls, and the order of the data saved per entity.
+type concurrentPatcher struct {
+	countingPatcher
+	running    int32
+	maxRunning int32
+	saveDelay  time.Duration
+	orderLock  sync.Mutex
+	order      map[entity.Key][]string
+}
+
+func (p *concurrentPatcher) Save(data types.PluginOutput) error {
+	running := atomic.AddInt32(&p.running, 1)
+	defer atomic.AddInt32(&p.running, -1)
+
+	for {
+		maxRunning := atomic.LoadInt32(&p.maxRunning)
+		if running <= maxRunning || atomic.CompareAndSwapInt32(&p.maxRunning, maxRunning, running) {
+			break
+		}
+	}
+
+	time.Sleep(p.saveDelay)
+
+	p.orderLock.Lock()
+	if p.order == nil {
+		p.order = make(map[entity.Key][]string)
+	}
+	p.order[data.Entity.Key] = append(p.order[data.Entity.Key], data.Id.Term)
+	p.orderLock.Unlock()
+
+	return p.countingPatcher.Save(data)
+}
+
+func (p *concurrentPatcher) savedOrder() map[entity.Key][]string {
+	p.orderLock.Lock()
+	defer p.orderLock.Unlock()
+	return p.order
+}
+
+func TestHandler_Workers(t *testing.T) {
+	testCases := []struct {
+		name    string
+		workers int
+	}{
+		{name: "WhenNotSet_SingleWorker", workers: 0},
+		{name: "WhenOne", workers: 1},
+		{name: "WhenSeveral", workers: 4},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cfg := HandlerConfig{
+				FirstReapInterval: time.Hour,
+				ReapInterval:      time.Hour,
+				SendInterval:      time.Hour,
+				InventoryQueueLen: 80,
+				Workers:           testCase.workers,
+			}
+
+			patcher := &concurrentPatcher{saveDelay: 5 * time.Millisecond}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			h := NewInventoryHandler(ctx, cfg, patcher)
+			go h.Start()
+
+			const entities = 8
+			const items = 10
+			expectedOrder := make(map[entity.Key][]string)
+			for i := 0; i < items; i++ {
+				for e := 0; e < entities; e++ {
+					ent := entity.NewFromNameWithoutID(fmt.Sprintf("entity:%d", e))
+					term := fmt.Sprintf("item%d", i)
+					h.Handle(types.NewPluginOutput(ids.PluginID{Category: "test", Term: term}, ent, nil))
+					expectedOrder[ent.Key] = append(expectedOrder[ent.Key], term)
+				}
+			}
+
+			assert.Eventually(t, func() bool {
+				saved, _ := patcher.submissions()
+				return saved == entities*items
+			}, 5*time.Second, 10*time.Millisecond)
+			// the data of every entity is saved in order
+			assert.Equal(t, expectedOrder, patcher.savedOrder())
+
+			maxRunning := atomic.LoadInt32(&patcher.maxRunning)
+			if testCase.workers > 1 {
+				assert.Greater(t, maxRunning, int32(1))
+				assert.LessOrEqual(t, maxRunning, int32(testCase.workers))
+			} else {
+				assert.Equal(t, int32(1), maxRunning)
+			}
+		})
+	}
+}
+
+// Saves of different entities run concurrently in the entity patcher, while reaping waits for them.
+func TestEntityPatcher_ConcurrentSaves(t *testing.T) {
+	deltaStore := delta.NewStore(t.TempDir(), "localhost", 1024, false)
+	patcher := NewEntityPatcher(PatcherConfig{AgentEntity: entity.NewFromNameWithoutID("localhost")}, deltaStore,
+		func(entity.Entity) (PatchSender, error) {
+			return noopPatchSender{}, nil
+		})
+
+	var wg sync.WaitGroup
+	for e := 0; e < 4; e++ {
+		wg.Add(1)
+		go func(ent entity.Entity) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				value := strconv.Itoa(i)
+				assert.NoError(t, patcher.Save(types.NewPluginOutput(ids.PluginID{Category: "test", Term: "plugin"}, ent,
+					types.PluginInventoryDataset{&testInventoryData{Name: "item", Value: &value}})))
+				patcher.Reap()
+			}
+		}(entity.NewFromNameWithoutID(fmt.Sprintf("entity:%d", e)))
+	}
+	wg.Wait()
+
+	for e := 0; e < 4; e++ {
+		source, err := os.ReadFile(filepath.Join(deltaStore.DataDir, "test", fmt.Sprintf("entity%d", e), "plugin.json"))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"item":{"Name":"item","Value":"19"}}`, string(source))
+	}
+}
+
+func TestHandler_NextSendBackoff(t *testing.T) {
+	h := NewInventoryHandler(context.Background(), HandlerConfig{
+		SendInterval:     10 * time.Second,
+		RetryBackoffStep: 5 * time.Second,
+		RetryBackoffMax:  30 * time.Second,
+	}, &countingPatcher{})
+
+	serverErr := inventoryapi.NewIngestError("not accepted", http.StatusServiceUnavailable, "503", "")
+	assert.Equal(t, 15*time.Second, h.nextSend(serverErr))
+	assert.Equal(t, 20*time.Second, h.nextSend(serverErr))
+	assert.Equal(t, 30*time.Second, h.nextSend(serverErr))
+	assert.Equal(t, 30*time.Second, h.nextSend(serverErr))
+
+	serverErr.RetryAfter = 12 * time.Second
+	assert.Equal(t, 12*time.Second, h.nextSend(serverErr), "Retry-After bounds the backoff")
+
+	assert.Equal(t, 10*time.Second, h.nextSend(nil))
+}
+
+func TestHandler_NextSendDefaultBackoff(t *testing.T) {
+	h := NewInventoryHandler(context.Background(), HandlerConfig{SendInterval: 10 * time.Second}, &countingPatcher{})
+
+	serverErr := inventoryapi.NewIngestError("not accepted", http.StatusServiceUnavailable, "503", "")
+	assert.Equal(t, 11*time.Second, h.nextSend(serverErr))
+	assert.Equal(t, 12*time.Second, h.nextSend(serverErr))
+
+	rateLimited := inventoryapi.NewIngestError("not accepted", http.StatusTooManyRequests, "429", "")
+	assert.Equal(t, time.Duration(config.RATE_LIMITED_BACKOFF)*time.Second, h.nextSend(rateLimited))
+}
+
+func TestHandler_SendCountsRetries(t *testing.T) {
+	serverErr := inventoryapi.NewIngestError("not accepted", http.StatusServiceUnavailable, "503", "")
+	patcher := &failingPatcher{errs: []error{serverErr, serverErr, nil, nil}}
+	h := NewInventoryHandler(context.Background(), HandlerConfig{SendInterval: time.Second}, patcher)
+	registry := openmetrics.NewRegistry()
+	h.retries = registry.NewCounterVec("nria_submission_retries_total", "Test retries.", "sender", "status_class")
+
+	for i := 0; i < 4; i++ {
+		h.send()
+		h.sendTimer.Stop()
+	}
+
+	// only the submissions following a failed one are retries
+	var metrics bytes.Buffer
+	require.NoError(t, registry.Write(&metrics))
+	assert.Contains(t, metrics.String(), `nria_submission_retries_total{sender="inventory",status_class="5xx"} 2`+"\n")
+}
+
+// failingPatcher returns the errors on the consecutive submissions.
+type failingPatcher struct {
+	countingPatcher
+	errs []error
+}
+
+func (p *failingPatcher) Send() error {
+	err := p.errs[0]
+	p.errs = p.errs[1:]
+	return err
+}
+
+// blockingPatcher blocks the submissions until unblocked.
+type blockingPatcher struct {
+	countingPatcher
+	unblock chan struct{}
+}
+
+func (p *blockingPatcher) Send() error {
+	<-p.unblock
+	return p.countingPatcher.Send()
+}
+
+// shutdownFlushContext returns a shutdown flush context provider whose deadline starts once requested.
+func shutdownFlushContext(t *testing.T, timeout time.Duration) func() context.Context {
+	return func() context.Context {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		t.Cleanup(cancel)
+		return ctx
+	}
+}
+
+func TestHandler_ShutdownFlush(t *testing.T) {
+	testCases := []struct {
+		name          string
+		flushTimeout  time.Duration
+		expectedSends []int
+	}{
+		{name: "WhenEnabled_SendsPendingDeltas", flushTimeout: time.Second, expectedSends: []int{2}},
+		{name: "WhenDisabled", flushTimeout: 0, expectedSends: nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cfg := HandlerConfig{
+				FirstReapInterval: time.Hour,
+				ReapInterval:      time.Hour,
+				SendInterval:      time.Hour,
+				InventoryQueueLen: 10,
+			}
+			if testCase.flushTimeout > 0 {
+				cfg.ShutdownFlushContext = shutdownFlushContext(t, testCase.flushTimeout)
+			}
+			patcher := &countingPatcher{}
+			ctx, cancel := context.WithCancel(context.Background())
+			h := NewInventoryHandler(ctx, cfg, patcher)
+			stopped := make(chan struct{})
+			go func() {
+				h.Start()
+				close(stopped)
+			}()
+
+			handleChanges(t, h, patcher, 2)
+			cancel()
+			<-stopped
+
+			_, sends := patcher.submissions()
+			assert.Equal(t, testCase.expectedSends, sends)
+		})
+	}
+}
+
+func TestHandler_ShutdownFlush_Timeout(t *testing.T) {
+	cfg := HandlerConfig{
+		FirstReapInterval:    time.Hour,
+		ReapInterval:         time.Hour,
+		SendInterval:         time.Hour,
+		InventoryQueueLen:    10,
+		ShutdownFlushContext: shutdownFlushContext(t, 50*time.Millisecond),
+	}
+	patcher := &blockingPatcher{unblock: make(chan struct{})}
+	defer close(patcher.unblock)
+	ctx, cancel := context.WithCancel(context.Background())
+	h := NewInventoryHandler(ctx, cfg, patcher)
+	stopped := make(chan struct{})
+	go func() {
+		h.Start()
+		close(stopped)
+	}()
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "handler didn't stop once the flush timed out")
+	}
+}
+
+func TestHandler_Compaction(t *testing.T) {
+	testCases := []struct {
+		name     string
+		interval time.Duration
+	}{
+		{name: "WhenEnabled", interval: 10 * time.Millisecond},
+		{name: "WhenDisabled", interval: 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cfg := HandlerConfig{
+				FirstReapInterval:  time.Hour,
+				ReapInterval:       time.Hour,
+				SendInterval:       time.Hour,
+				CompactionInterval: testCase.interval,
+			}
+
+			patcher := &countingPatcher{}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go NewInventoryHandler(ctx, cfg, patcher).Start()
+
+			if testCase.interval > 0 {
+				assert.Eventually(t, func() bool {
+					return patcher.compacted() >= 2
+				}, 5*time.Second, 10*time.Millisecond)
+			} else {
+				time.Sleep(50 * time.Millisecond)
+				assert.Zero(t, patcher.compacted())
+			}
+		})
+	}
+}
+
+// noopPatchSender doesn't submit the deltas.
+type noopPatchSender struct{}
+
+func (noopPatchSender) Process() error { return nil }
+
+// Run with -race: the storage must be compacted without racing with the deltas being stored.
+func TestHandler_CompactionWhileStoringDeltas(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "compaction")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	deltaStore := delta.NewStore(dataDir, "localhost", 1024, false)
+	localhost := entity.NewFromNameWithoutID("localhost")
+	patcher := NewEntityPatcher(PatcherConfig{AgentEntity: localhost}, deltaStore, func(entity.Entity) (PatchSender, error) {
+		return noopPatchSender{}, nil
+	})
+
+	cfg := HandlerConfig{
+		FirstReapInterval:  time.Millisecond,
+		ReapInterval:       time.Millisecond,
+		SendInterval:       time.Hour,
+		InventoryQueueLen:  10,
+		CompactionInterval: time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	h := NewInventoryHandler(ctx, cfg, patcher)
+	stopped := make(chan struct{})
+	go func() {
+		h.Start()
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	for i := 0; i < 200; i++ {
+		value := strconv.Itoa(i)
+		h.Handle(types.NewPluginOutput(ids.PluginID{Category: "test", Term: fmt.Sprintf("plugin%d", i%3)}, localhost,
+			types.PluginInventoryDataset{&testInventoryData{Name: "item", Value: &value}}))
+	}
+
+	assert.Eventually(t, func() bool {
+		return !deltaStore.LastCompaction().IsZero()
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestHandler_ResendInventory(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "resend")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	deltaStore := delta.NewStore(dataDir, "localhost", 1024, false)
+	localhost := entity.NewFromNameWithoutID("localhost")
+	patcher := NewEntityPatcher(PatcherConfig{AgentEntity: localhost}, deltaStore, func(entity.Entity) (PatchSender, error) {
+		return noopPatchSender{}, nil
+	})
+
+	cfg := HandlerConfig{
+		FirstReapInterval: 10 * time.Millisecond,
+		ReapInterval:      10 * time.Millisecond,
+		SendInterval:      time.Hour,
+		InventoryQueueLen: 10,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	h := NewInventoryHandler(ctx, cfg, patcher)
+	stopped := make(chan struct{})
+	go func() {
+		h.Start()
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	fullDeltas := func() (ids []int64) {
+		deltas, err := deltaStore.ReadDeltas(localhost.Key.String())
+		require.NoError(t, err)
+		for _, block := range deltas {
+			for _, d := range block {
+				if d.FullDiff {
+					ids = append(ids, d.ID)
+				}
+			}
+		}
+		return ids
+	}
+
+	// GIVEN the inventory of a plugin already reaped
+	value := "value"
+	h.Handle(types.NewPluginOutput(ids.PluginID{Category: "test", Term: "plugin"}, localhost,
+		types.PluginInventoryDataset{&testInventoryData{Name: "item", Value: &value}}))
+	require.Eventually(t, func() bool {
+		return len(fullDeltas()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// WHEN its inventory is resent, without new data reported
+	triggered, _, err := h.ResendInventory(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, triggered)
+
+	// THEN the next reap stores a full delta with the whole inventory
+	assert.Eventually(t, func() bool {
+		return len(fullDeltas()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -34,12 +34,22 @@ type Patcher interface {
 
 	// Send will look for deltas in the storage and submit them to the backend.
 	Send() error
+
+	// Compact will compact the storage of all the entities once interval passed since the last compaction, returning
+	// the time until the next one is due.
+	Compact(interval time.Duration) (time.Duration, error)
+
+	// ResendInventory will remove the inventory cache of the given plugins, or of all of them when none is given,
+	// so the next reap stores full deltas for every entity. It returns the number of plugins triggered and their
+	// approximate payload size in bytes.
+	ResendInventory(plugins []string) (triggered int, size int64, err error)
 }
 
 type PatcherConfig struct {
 	IgnoredPaths         map[string]struct{}
 	AgentEntity          entity.Entity
 	RemoveEntitiesPeriod time.Duration
+	RemoveEntitiesAudit  bool
 }
 
 // BasePatcher will keep the common functionality of a patcher.
@@ -55,18 +65,21 @@ func (b *BasePatcher) needsCleanup() bool {
 		return false
 	}
 
-	removePeriod := b.cfg.RemoveEntitiesPeriod
-	if removePeriod <= 0 {
-		removePeriod = defaultRemoveEntitiesPeriod
-	}
-
-	needsCleanup := b.lastClean.Add(removePeriod).Before(time.Now())
+	needsCleanup := b.lastClean.Add(b.removePeriod()).Before(time.Now())
 	if needsCleanup {
 		b.lastClean = time.Now()
 	}
 	return needsCleanup
 }
 
+// removePeriod returns the period after which non-reporting entities are removed.
+func (b *BasePatcher) removePeriod() time.Duration {
+	if b.cfg.RemoveEntitiesPeriod <= 0 {
+		return defaultRemoveEntitiesPeriod
+	}
+	return b.cfg.RemoveEntitiesPeriod
+}
+
 // save will take a PluginOutput and persist it in the store.
 func (b *BasePatcher) save(pluginOutput types.PluginOutput) error {
 
@@ -121,3 +134,12 @@ func (b *BasePatcher) reapEntity(entityKey entity.Key) {
 		return
 	}
 }
+
+// AuditEntityRemoval logs an audit record for an entity whose inventory has been removed because it didn't report
+// any data during the removal period.
+func AuditEntityRemoval(entityKey string, removePeriod time.Duration) {
+	ilog.WithFields(logrus.Fields{
+		"entityKey": entityKey,
+		"reason":    fmt.Sprintf("no data reported for %s", removePeriod),
+	}).Info("Removed non-reporting entity.")
+}
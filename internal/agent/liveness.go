@@ -0,0 +1,127 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	context2 "context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+)
+
+var llog = log.WithComponent("Liveness")
+
+// livenessFile periodically writes the current time and an increasing counter to a file, so an external watchdog
+// can detect the agent hangs. The file isn't written while no sample is produced within the grace period, and it's
+// removed on graceful shutdown.
+type livenessFile struct {
+	path     string
+	interval time.Duration
+	grace    time.Duration
+	now      func() time.Time
+
+	lastSample atomic.Int64 // Unix time in nanoseconds of the last sample produced.
+
+	lock    sync.Mutex
+	counter uint64
+	stalled bool
+	removed bool
+}
+
+func newLivenessFile(cfg *config.Config) *livenessFile {
+	l := &livenessFile{
+		path:     cfg.LivenessFile,
+		interval: time.Duration(cfg.LivenessIntervalSec) * time.Second,
+		grace:    time.Duration(cfg.LivenessGracePeriodSec) * time.Second,
+		now:      time.Now,
+	}
+	// the samplers have the grace period to produce their first samples
+	l.sampleProduced()
+	return l
+}
+
+// sampleProduced records that the agent is sampling.
+func (l *livenessFile) sampleProduced() {
+	l.lastSample.Store(l.now().UnixNano())
+}
+
+// run writes the liveness file on every interval until ctx is done.
+func (l *livenessFile) run(ctx context2.Context) {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	l.touch()
+	for {
+		select {
+		case <-ticker.C:
+			l.touch()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// touch writes the liveness file, unless the sampling stalled or the file was removed.
+func (l *livenessFile) touch() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.removed {
+		return
+	}
+	sinceLastSample := l.now().Sub(time.Unix(0, l.lastSample.Load()))
+	if sinceLastSample > l.grace {
+		if !l.stalled {
+			llog.WithField("sinceLastSample", sinceLastSample).Warn("No sample produced within the liveness grace period, the liveness file is not written anymore.")
+			l.stalled = true
+		}
+		return
+	}
+	if l.stalled {
+		llog.Info("Samples are produced again, resuming the liveness file writes.")
+		l.stalled = false
+	}
+
+	l.counter++
+	if err := l.write(fmt.Sprintf("%s %d\n", l.now().UTC().Format(time.RFC3339), l.counter)); err != nil {
+		llog.WithError(err).WithField("file", l.path).Warn("Cannot write the liveness file.")
+	}
+}
+
+// write replaces the liveness file atomically, so the watchdog never reads it partially written.
+func (l *livenessFile) write(content string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.WriteString(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), l.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// remove deletes the liveness file, which isn't written anymore.
+func (l *livenessFile) remove() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.removed = true
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		llog.WithError(err).WithField("file", l.path).Warn("Cannot remove the liveness file.")
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/infrastructure-agent/internal/testhelpers"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+)
+
+func newTestLivenessFile(t *testing.T, now *time.Time) *livenessFile {
+	t.Helper()
+
+	cfg := &config.Config{
+		LivenessFile:           filepath.Join(t.TempDir(), "liveness"),
+		LivenessIntervalSec:    15,
+		LivenessGracePeriodSec: 60,
+	}
+	l := newLivenessFile(cfg)
+	l.now = func() time.Time { return *now }
+	l.sampleProduced()
+	return l
+}
+
+func readLiveness(t *testing.T, l *livenessFile) string {
+	t.Helper()
+
+	content, err := os.ReadFile(l.path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestLivenessFile_Touch(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	l := newTestLivenessFile(t, &now)
+
+	l.touch()
+	assert.Equal(t, "2026-10-16T10:00:00Z 1\n", readLiveness(t, l))
+
+	now = now.Add(15 * time.Second)
+	l.touch()
+	assert.Equal(t, "2026-10-16T10:00:15Z 2\n", readLiveness(t, l))
+
+	// the temporary files are renamed over the liveness file
+	entries, err := os.ReadDir(filepath.Dir(l.path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestLivenessFile_Touch_Stalled(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	l := newTestLivenessFile(t, &now)
+	l.touch()
+
+	// no sample within the grace period
+	now = now.Add(61 * time.Second)
+	l.touch()
+	assert.Equal(t, "2026-10-16T10:00:00Z 1\n", readLiveness(t, l))
+
+	l.sampleProduced()
+	l.touch()
+	assert.Equal(t, "2026-10-16T10:01:01Z 2\n", readLiveness(t, l))
+}
+
+func TestLivenessFile_Remove(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	l := newTestLivenessFile(t, &now)
+	l.touch()
+
+	l.remove()
+	assert.NoFileExists(t, l.path)
+
+	l.touch()
+	assert.NoFileExists(t, l.path)
+	// removing twice is harmless
+	l.remove()
+}
+
+func TestContext_SendEvent_RecordsLiveness(t *testing.T) {
+	cfg := &config.Config{
+		LivenessFile:           filepath.Join(t.TempDir(), "liveness"),
+		LivenessIntervalSec:    15,
+		LivenessGracePeriodSec: 60,
+	}
+	includeAll := func(interface{}) bool { return true }
+	excludeNone := func(interface{}) bool { return false }
+	c := NewContext(cfg, "0.0.0", testhelpers.NullHostnameResolver, NilIDLookup, includeAll, excludeNone)
+	require.NotNil(t, c.liveness)
+	c.liveness.lastSample.Store(0)
+	c.eventSender = fakeEventSender{}
+
+	c.SendEvent(&sample.BaseEvent{EventType: "SystemSample"}, "some key")
+	assert.NotZero(t, c.liveness.lastSample.Load())
+}
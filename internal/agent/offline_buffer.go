@@ -0,0 +1,198 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+)
+
+const (
+	offlineBatchExt    = ".json"
+	offlineBatchTmpExt = ".tmp"
+)
+
+// offlineEvent event of a metrics batch stored in the offline buffer.
+type offlineEvent struct {
+	EntityKey entity.Key      `json:"entityKey"`
+	EntityID  entity.ID       `json:"entityID,omitempty"`
+	AgentKey  string          `json:"agentKey"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// offlineBatch metrics batch file of the offline buffer.
+type offlineBatch struct {
+	seq  uint64
+	size int64
+}
+
+// offlineBuffer disk-backed queue of the metrics batches that couldn't be submitted, oldest first. The batches are
+// stored as one file each, named by their sequence number, so the queue is recovered after a restart. When the total
+// size of the files exceeds maxBytes, the oldest batches are discarded. It's not safe for concurrent use.
+type offlineBuffer struct {
+	dir      string
+	maxBytes int64
+	batches  []offlineBatch
+	size     int64
+	nextSeq  uint64
+}
+
+// newOfflineBuffer creates the offline buffer at dir, loading the batches stored by a previous run.
+func newOfflineBuffer(dir string, maxBytes int64) (*offlineBuffer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("cannot create offline buffer directory: %w", err)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read offline buffer directory: %w", err)
+	}
+
+	b := &offlineBuffer{dir: dir, maxBytes: maxBytes}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(f.Name(), offlineBatchTmpExt) {
+			// batch which write was interrupted
+			_ = os.Remove(filepath.Join(dir, f.Name()))
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(f.Name(), offlineBatchExt), 10, 64)
+		if err != nil || !strings.HasSuffix(f.Name(), offlineBatchExt) {
+			continue
+		}
+		b.batches = append(b.batches, offlineBatch{seq: seq, size: f.Size()})
+		b.size += f.Size()
+		if seq >= b.nextSeq {
+			b.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(b.batches, func(i, j int) bool { return b.batches[i].seq < b.batches[j].seq })
+
+	b.discardOverBudget()
+	return b, nil
+}
+
+func (b *offlineBuffer) path(seq uint64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%020d%s", seq, offlineBatchExt))
+}
+
+// len returns the number of stored batches.
+func (b *offlineBuffer) len() int {
+	return len(b.batches)
+}
+
+// push stores the batch as the newest one, discarding the oldest ones when the size budget is exceeded.
+func (b *offlineBuffer) push(batch eventBatch) error {
+	events := make([]offlineEvent, 0, len(batch))
+	for _, event := range batch {
+		events = append(events, offlineEvent{
+			EntityKey: event.entityKey,
+			EntityID:  event.entityID,
+			AgentKey:  event.agentKey,
+			Data:      event.data,
+		})
+	}
+	content, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("cannot marshal metrics batch: %w", err)
+	}
+
+	seq := b.nextSeq
+	path := b.path(seq)
+	// written aside and renamed, so a partially written batch is never replayed
+	if err := ioutil.WriteFile(path+offlineBatchTmpExt, content, 0o600); err != nil {
+		_ = os.Remove(path + offlineBatchTmpExt)
+		return fmt.Errorf("cannot write metrics batch: %w", err)
+	}
+	if err := os.Rename(path+offlineBatchTmpExt, path); err != nil {
+		_ = os.Remove(path + offlineBatchTmpExt)
+		return fmt.Errorf("cannot write metrics batch: %w", err)
+	}
+
+	b.nextSeq++
+	b.batches = append(b.batches, offlineBatch{seq: seq, size: int64(len(content))})
+	b.size += int64(len(content))
+
+	b.discardOverBudget()
+	return nil
+}
+
+// oldest returns the oldest stored batch. Batches that cannot be read are discarded.
+func (b *offlineBuffer) oldest() (eventBatch, bool) {
+	for len(b.batches) > 0 {
+		path := b.path(b.batches[0].seq)
+		content, err := ioutil.ReadFile(path)
+		var events []offlineEvent
+		if err == nil {
+			err = json.Unmarshal(content, &events)
+		}
+		if err != nil {
+			ilog.WithError(err).WithField("file", path).Warn("Discarding unreadable offline metrics batch.")
+			b.removeOldest()
+			continue
+		}
+
+		batch := make(eventBatch, 0, len(events))
+		for _, event := range events {
+			batch = append(batch, eventData{
+				entityKey: event.EntityKey,
+				entityID:  event.EntityID,
+				agentKey:  event.AgentKey,
+				data:      event.Data,
+			})
+		}
+		return batch, true
+	}
+	return nil, false
+}
+
+// removeOldest removes the oldest stored batch.
+func (b *offlineBuffer) removeOldest() {
+	if len(b.batches) == 0 {
+		return
+	}
+	oldest := b.batches[0]
+	if err := os.Remove(b.path(oldest.seq)); err != nil && !os.IsNotExist(err) {
+		ilog.WithError(err).Warn("Cannot remove offline metrics batch.")
+	}
+	b.batches = b.batches[1:]
+	b.size -= oldest.size
+}
+
+func (b *offlineBuffer) discardOverBudget() {
+	discarded := 0
+	for b.size > b.maxBytes && len(b.batches) > 0 {
+		b.removeOldest()
+		discarded++
+	}
+	if discarded > 0 {
+		ilog.WithField("discardedBatches", discarded).WithField("maxBytes", b.maxBytes).
+			Warn("Offline metrics buffer is full, discarding the oldest batches.")
+	}
+}
+
+// isSubmissionOutage returns whether a submission failed because the backend couldn't be reached or failed to
+// process it, so it can be submitted later on. Rejected submissions, as the ones of an invalid license, are not.
+func isSubmissionOutage(err error) bool {
+	var retryErr *errRetry
+	if errors.As(err, &retryErr) {
+		return retryErr.StatusCode >= http.StatusInternalServerError ||
+			retryErr.StatusCode == http.StatusTooManyRequests ||
+			retryErr.StatusCode == http.StatusRequestTimeout
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
@@ -0,0 +1,244 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"compress/gzip"
+	goContext "context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+)
+
+func testBatch(values ...string) eventBatch {
+	var batch eventBatch
+	for _, value := range values {
+		batch = append(batch, eventData{
+			entityKey: "testAgent",
+			entityID:  42,
+			agentKey:  "testAgent",
+			data:      json.RawMessage(`{"eventType":"TestEvent","value":"` + value + `","timestamp":1600000000}`),
+		})
+	}
+	return batch
+}
+
+func newTestOfflineBuffer(t *testing.T, maxBytes int64) *offlineBuffer {
+	t.Helper()
+	b, err := newOfflineBuffer(t.TempDir(), maxBytes)
+	require.NoError(t, err)
+	return b
+}
+
+func TestOfflineBuffer_FIFO(t *testing.T) {
+	b := newTestOfflineBuffer(t, 1024*1024)
+
+	_, ok := b.oldest()
+	assert.False(t, ok)
+
+	require.NoError(t, b.push(testBatch("a", "b")))
+	require.NoError(t, b.push(testBatch("c")))
+	assert.Equal(t, 2, b.len())
+
+	batch, ok := b.oldest()
+	require.True(t, ok)
+	assert.Equal(t, testBatch("a", "b"), batch)
+	// the batch is kept until removed
+	batch, _ = b.oldest()
+	assert.Equal(t, testBatch("a", "b"), batch)
+
+	b.removeOldest()
+	batch, ok = b.oldest()
+	require.True(t, ok)
+	assert.Equal(t, testBatch("c"), batch)
+
+	b.removeOldest()
+	assert.Equal(t, 0, b.len())
+	files, err := ioutil.ReadDir(b.dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestOfflineBuffer_DiscardsOldestOverBudget(t *testing.T) {
+	b := newTestOfflineBuffer(t, 1024*1024)
+	require.NoError(t, b.push(testBatch("a")))
+	b.maxBytes = 2 * b.size
+
+	require.NoError(t, b.push(testBatch("b")))
+	require.NoError(t, b.push(testBatch("c")))
+
+	assert.Equal(t, 2, b.len())
+	batch, _ := b.oldest()
+	assert.Equal(t, testBatch("b"), batch)
+	files, err := ioutil.ReadDir(b.dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+}
+
+func TestOfflineBuffer_RecoversStoredBatches(t *testing.T) {
+	b := newTestOfflineBuffer(t, 1024*1024)
+	require.NoError(t, b.push(testBatch("a")))
+	require.NoError(t, b.push(testBatch("b")))
+	b.removeOldest()
+	// left by an interrupted write
+	require.NoError(t, ioutil.WriteFile(filepath.Join(b.dir, "00000000000000000007.json.tmp"), []byte("[{"), 0o600))
+
+	recovered, err := newOfflineBuffer(b.dir, 1024*1024)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, recovered.len())
+	assert.Equal(t, b.size, recovered.size)
+	require.NoError(t, recovered.push(testBatch("c")))
+	batch, _ := recovered.oldest()
+	assert.Equal(t, testBatch("b"), batch)
+	recovered.removeOldest()
+	batch, _ = recovered.oldest()
+	assert.Equal(t, testBatch("c"), batch)
+	_, err = os.Stat(filepath.Join(b.dir, "00000000000000000007.json.tmp"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestOfflineBuffer_DiscardsUnreadableBatches(t *testing.T) {
+	b := newTestOfflineBuffer(t, 1024*1024)
+	require.NoError(t, b.push(testBatch("a")))
+	require.NoError(t, b.push(testBatch("b")))
+	require.NoError(t, ioutil.WriteFile(b.path(b.batches[0].seq), []byte("[{"), 0o600))
+
+	batch, ok := b.oldest()
+
+	require.True(t, ok)
+	assert.Equal(t, testBatch("b"), batch)
+	assert.Equal(t, 1, b.len())
+}
+
+func TestIsSubmissionOutage(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"connection error":  {err: &url.Error{Op: "Post", URL: "http://collector", Err: errors.New("connection refused")}, want: true},
+		"server error":      {err: newErrRetry("", http.StatusServiceUnavailable, "", "", backendhttp.RetryPolicy{}), want: true},
+		"too many requests": {err: newErrRetry("", http.StatusTooManyRequests, "", "", backendhttp.RetryPolicy{}), want: true},
+		"invalid license":   {err: newErrRetry("", http.StatusForbidden, "", "", backendhttp.RetryPolicy{}), want: false},
+		"other error":       {err: errors.New("empty agent-id on metrics sender"), want: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isSubmissionOutage(tt.err))
+		})
+	}
+}
+
+func TestEventSender_OfflineBuffer(t *testing.T) {
+	// GIVEN a collector which is down
+	var down atomic.Bool
+	down.Store(true)
+	var lock sync.Mutex
+	var received []map[string]interface{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/events/bulk") {
+			return
+		}
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var posts []struct{ Events []map[string]interface{} }
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&posts))
+		lock.Lock()
+		defer lock.Unlock()
+		for _, post := range posts {
+			received = append(received, post.Events...)
+		}
+	}))
+	defer collector.Close()
+
+	bufferDir := t.TempDir()
+	cfg := &config.Config{
+		PayloadCompressionLevel: gzip.NoCompression,
+		CollectorURL:            collector.URL,
+		OfflineBufferDir:        bufferDir,
+		OfflineBufferMaxMB:      1,
+	}
+	sender := newMetricsIngestSender(newTestContext("testAgent", cfg), "license", "userAgent", collector.Client().Do, false)
+	sender.getBackoffTimer = func(time.Duration) *time.Timer { return time.NewTimer(0) }
+	require.NoError(t, sender.Start())
+	defer sender.Stop()
+
+	storedBatches := func() int {
+		files, err := ioutil.ReadDir(bufferDir)
+		require.NoError(t, err)
+		return len(files)
+	}
+
+	// WHEN an event can't be submitted
+	require.NoError(t, sender.QueueEvent(mapEvent{"eventType": "TestEvent", "value": "offline", "timestamp": 1600000000}, ""))
+
+	// THEN it's stored in the offline buffer
+	require.Eventually(t, func() bool { return storedBatches() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// AND it's submitted, with its timestamp, once the collector is up again
+	down.Store(false)
+	require.NoError(t, sender.QueueEvent(mapEvent{"eventType": "TestEvent", "value": "online", "timestamp": 1600000060}, ""))
+	require.Eventually(t, func() bool { return storedBatches() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Len(t, received, 2)
+	assert.Equal(t, "online", received[0]["value"])
+	assert.Equal(t, "offline", received[1]["value"])
+	assert.Equal(t, float64(1600000000), received[1]["timestamp"])
+}
+
+func TestEventSender_Flush_OfflineBuffer(t *testing.T) {
+	tests := map[string]struct {
+		status  int
+		stored  int
+		dropped int
+	}{
+		"unavailable": {status: http.StatusServiceUnavailable, stored: 1},
+		"rejected":    {status: http.StatusBadRequest, dropped: 2},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer collector.Close()
+
+			bufferDir := t.TempDir()
+			cfg := &config.Config{
+				PayloadCompressionLevel: gzip.NoCompression,
+				CollectorURL:            collector.URL,
+				OfflineBufferDir:        bufferDir,
+				OfflineBufferMaxMB:      1,
+			}
+			sender := newMetricsIngestSender(newTestContext("testAgent", cfg), "license", "userAgent", collector.Client().Do, false)
+			require.NoError(t, sender.QueueEvent(mapEvent{"eventType": "TestEvent", "value": 1}, ""))
+			require.NoError(t, sender.QueueEvent(mapEvent{"eventType": "TestEvent", "value": 2}, ""))
+
+			assert.Equal(t, tt.dropped, sender.Flush(goContext.Background()))
+
+			// the batches failed by an outage are stored to be submitted on the next start
+			files, err := ioutil.ReadDir(bufferDir)
+			require.NoError(t, err)
+			assert.Len(t, files, tt.stored)
+		})
+	}
+}
@@ -67,7 +67,7 @@ func newPatchSender(entityInfo entity.Entity, context AgentContext, store delta.
 		inventoryURL,
 		context.Config().License,
 		userAgent,
-		context.Config().PayloadCompressionLevel,
+		context.Config().InventoryPayloadCompressionLevel(),
 		context.EntityKey(),
 		agentIDProvide,
 		context.Config().ConnectEnabled,
@@ -3,12 +3,17 @@
 package agent
 
 import (
+	"bytes"
+	"compress/gzip"
 	ctx "context"
+	"encoding/json"
 	"fmt"
 	"github.com/newrelic/infrastructure-agent/internal/agent/inventory"
 	"github.com/stretchr/testify/mock"
 	"io/ioutil"
 	"math"
+	gohttp "net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"sync"
@@ -607,3 +612,53 @@ func getLastEntityIDMock() *mockEntityIDPersist {
 	persistEntityID.On("UpdateEntityID", mock.Anything).Return(nil)
 	return persistEntityID
 }
+
+func TestPatchSender_InventoryCompressionLevel(t *testing.T) {
+	// GIVEN an inventory ingest service recording the received requests
+	type request struct {
+		contentEncoding string
+		body            []byte
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(gohttp.HandlerFunc(func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		requests <- request{contentEncoding: r.Header.Get("Content-Encoding"), body: body}
+		w.WriteHeader(gohttp.StatusAccepted)
+		_, _ = w.Write([]byte(`{"payload":{}}`))
+	}))
+	defer server.Close()
+
+	// AND an agent config overriding the payload compression level for the inventory
+	cfg := config.NewTestWithDeltas("")
+	cfg.CollectorURL = server.URL
+	cfg.PayloadCompressionLevel = gzip.NoCompression
+	inventoryLevel := gzip.BestCompression
+	cfg.InventoryCompressionLevel = &inventoryLevel
+	var agentKeyVal atomic.Value
+	agentKeyVal.Store(agentKey)
+	aCtx := &context{agentKey: agentKeyVal, cfg: cfg}
+	idCtx := id.NewContext(ctx.Background())
+	idCtx.SetAgentIdentity(entity.Identity{ID: 123})
+	psI, err := newPatchSender(entity.NewFromNameWithoutID(entityKey), aCtx, &delta.Store{}, delta.NewLastSubmissionInMemory(),
+		getLastEntityIDMock(), "user agent", idCtx.AgentIdnOrEmpty, server.Client().Do)
+	require.NoError(t, err)
+
+	// WHEN inventory is submitted
+	_, err = psI.(*patchSenderIngest).postDeltas([]string{entityKey}, entity.EmptyID, false, &inventoryapi.RawDelta{
+		Source: "metadata/plugin",
+		ID:     1,
+		Diff:   map[string]interface{}{"hostname": map[string]interface{}{"alias": "aaa-opsmatic"}},
+	})
+	require.NoError(t, err)
+
+	// THEN its payload is compressed
+	req := <-requests
+	assert.Equal(t, "gzip", req.contentEncoding)
+	reader, err := gzip.NewReader(bytes.NewReader(req.body))
+	require.NoError(t, err)
+	var body inventoryapi.PostDeltaBody
+	require.NoError(t, json.NewDecoder(reader).Decode(&body))
+	require.Len(t, body.Deltas, 1)
+	assert.Equal(t, "metadata/plugin", body.Deltas[0].Source)
+}
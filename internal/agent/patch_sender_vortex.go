@@ -64,7 +64,7 @@ func newPatchSenderVortex(entityKey, agentKey string, context AgentContext, stor
 		inventoryURL,
 		context.Config().License,
 		userAgent,
-		context.Config().PayloadCompressionLevel,
+		context.Config().InventoryPayloadCompressionLevel(),
 		context.EntityKey(),
 		agentIDProvide,
 		context.Config().ConnectEnabled,
@@ -154,6 +154,11 @@ func (m mapEvent) Type(eventType string) {
 	m["eventType"] = eventType
 }
 
+func (m mapEvent) GetEventType() string {
+	eventType, _ := m["eventType"].(string)
+	return eventType
+}
+
 func (m mapEvent) Entity(key entity.Key) {
 	m["entityKey"] = key
 }
@@ -26,8 +26,9 @@ const (
 // - configuration
 // fields will be empty when ReportErrors() report no errors.
 type Report struct {
-	Checks *ChecksReport `json:"checks,omitempty"`
-	Config *ConfigReport `json:"config,omitempty"`
+	Checks       *ChecksReport       `json:"checks,omitempty"`
+	Config       *ConfigReport       `json:"config,omitempty"`
+	LogForwarder *LogForwarderReport `json:"log_forwarder,omitempty"`
 }
 
 type ChecksReport struct {
@@ -53,6 +54,16 @@ type HealthReport struct {
 	Error   string `json:"error,omitempty"`
 }
 
+// LogForwarderReport represents the log forwarder process status.
+type LogForwarderReport struct {
+	Running  bool   `json:"running"`
+	Restarts int    `json:"restarts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// LogForwarderStatusFn returns the log forwarder status, nil when log forwarding is not running.
+type LogForwarderStatusFn func() *LogForwarderReport
+
 // ReportEntity agent entity report.
 type ReportEntity struct {
 	GUID string `json:"guid"`
@@ -80,6 +91,7 @@ type nrReporter struct {
 	userAgent              string
 	idProvide              id.Provide
 	agentEntityKeyProvider func() string
+	logForwarderStatus     LogForwarderStatusFn
 	timeout                time.Duration
 	transport              http.RoundTripper
 }
@@ -167,6 +179,12 @@ func (r *nrReporter) report(onlyErrors bool) (report Report, err error) {
 		}
 	}
 
+	if r.logForwarderStatus != nil {
+		if lfReport := r.logForwarderStatus(); lfReport != nil && (!onlyErrors || lfReport.Error != "") {
+			report.LogForwarder = lfReport
+		}
+	}
+
 	return
 }
 
@@ -216,6 +234,7 @@ func NewReporter(
 	transport http.RoundTripper,
 	agentIDProvide id.Provide,
 	agentEntityKeyProvider func() string,
+	logForwarderStatus LogForwarderStatusFn,
 	license,
 	userAgent string,
 ) Reporter {
@@ -228,6 +247,7 @@ func NewReporter(
 		userAgent:              userAgent,
 		idProvide:              agentIDProvide,
 		agentEntityKeyProvider: agentEntityKeyProvider,
+		logForwarderStatus:     logForwarderStatus,
 		timeout:                timeout,
 		transport:              transport,
 	}
@@ -119,7 +119,7 @@ func TestNewReporter_Report(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			l := log.WithComponent(tt.name)
-			r := NewReporter(context.Background(), l, tt.endpoints, tt.healthEndpoint, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, "user-agent", "agent-key")
+			r := NewReporter(context.Background(), l, tt.endpoints, tt.healthEndpoint, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, nil, "user-agent", "agent-key")
 
 			got, err := r.Report()
 
@@ -201,7 +201,7 @@ func TestNewReporter_ReportErrors(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			l := log.WithComponent(tt.name)
-			r := NewReporter(context.Background(), l, tt.endpoints, healthEndpointOK, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, "user-agent", "agent-key")
+			r := NewReporter(context.Background(), l, tt.endpoints, healthEndpointOK, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, nil, "user-agent", "agent-key")
 
 			got, err := r.ReportErrors()
 
@@ -262,7 +262,7 @@ func TestNewReporter_ReportEntity(t *testing.T) {
 			entityKeyProvider := func() string {
 				return tt.entityKey
 			}
-			r := NewReporter(context.Background(), l, []string{}, "", timeout, transport, idProvide, entityKeyProvider, "user-agent", "agent-key")
+			r := NewReporter(context.Background(), l, []string{}, "", timeout, transport, idProvide, entityKeyProvider, nil, "user-agent", "agent-key")
 
 			got, err := r.ReportEntity()
 
@@ -345,7 +345,7 @@ func TestNewReporter_ReportHealth(t *testing.T) {
 	for _, testCase := range tests {
 		t.Run(testCase.name, func(t *testing.T) {
 			l := log.WithComponent(testCase.name)
-			r := NewReporter(context.Background(), l, nil, testCase.healthEndpoint, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, "user-agent", "agent-key")
+			r := NewReporter(context.Background(), l, nil, testCase.healthEndpoint, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, nil, "user-agent", "agent-key")
 
 			got := r.ReportHealth()
 
@@ -354,3 +354,43 @@ func TestNewReporter_ReportHealth(t *testing.T) {
 		})
 	}
 }
+
+func TestNewReporter_ReportLogForwarder(t *testing.T) {
+	timeout := 10 * time.Millisecond
+	transport := &http.Transport{}
+	emptyIDProvide := func() entity.Identity { return entity.EmptyIdentity }
+	emptyEntityKeyProvider := func() string { return "" }
+
+	running := &LogForwarderReport{Running: true, Restarts: 1}
+	stopped := &LogForwarderReport{Restarts: 3, Error: "stopped"}
+
+	tests := []struct {
+		name       string
+		status     LogForwarderStatusFn
+		onlyErrors bool
+		want       *LogForwarderReport
+	}{
+		{"no log forwarder", nil, false, nil},
+		{"running", func() *LogForwarderReport { return running }, false, running},
+		{"running, only errors", func() *LogForwarderReport { return running }, true, nil},
+		{"stopped, only errors", func() *LogForwarderReport { return stopped }, true, stopped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := log.WithComponent(tt.name)
+			r := NewReporter(context.Background(), l, []string{}, "", timeout, transport, emptyIDProvide, emptyEntityKeyProvider, tt.status, "user-agent", "agent-key")
+
+			var report Report
+			var err error
+			if tt.onlyErrors {
+				report, err = r.ReportErrors()
+			} else {
+				report, err = r.Report()
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, report.LogForwarder)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"time"
+
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
+	"github.com/newrelic/infrastructure-agent/pkg/backend/backoff"
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+)
+
+// submissionRetry retries the failed metrics submissions and computes the backoff after them, as configured by the
+// submission_retry_* options.
+type submissionRetry struct {
+	max        int
+	backoff    *backoff.Backoff
+	backoffMax time.Duration           // 0 to use the default maximum
+	retries    *openmetrics.CounterVec // counts the retries by status class
+}
+
+func newSubmissionRetry(cfg *config.Config) *submissionRetry {
+	r := &submissionRetry{backoff: backoff.NewDefaultBackoff(), retries: backoff.SubmissionRetries}
+	if cfg == nil {
+		return r
+	}
+
+	r.max = cfg.SubmissionRetryMax
+	if cfg.SubmissionRetryBackoffBaseSec > 0 {
+		r.backoff.Min = time.Duration(cfg.SubmissionRetryBackoffBaseSec) * time.Second
+	}
+	if cfg.SubmissionRetryBackoffMaxSec > 0 {
+		r.backoffMax = time.Duration(cfg.SubmissionRetryBackoffMaxSec) * time.Second
+		r.backoff.Max = r.backoffMax
+	}
+	return r
+}
+
+// retryAfter returns the wait requested by the backend through the Retry-After header, bounded by the configured
+// maximum backoff.
+func (r *submissionRetry) retryAfter(policy backendhttp.RetryPolicy) time.Duration {
+	r.backoff.Reset()
+	if r.backoffMax > 0 && policy.After > r.backoffMax {
+		return r.backoffMax
+	}
+	return policy.After
+}
+
+// backoffAfter returns the exponential backoff for the next attempt. The configured maximum replaces the default
+// one, but not the longer ones of errors such as an invalid license.
+func (r *submissionRetry) backoffAfter(policy backendhttp.RetryPolicy) time.Duration {
+	max := policy.MaxBackOff
+	if r.backoffMax > 0 && max <= backoff.DefaultMax {
+		max = r.backoffMax
+	}
+	return r.backoff.DurationWithMax(max)
+}
+
+// wait returns how long to wait before retrying a submission that failed with err.
+func (r *submissionRetry) wait(err error) time.Duration {
+	e, ok := err.(*errRetry)
+	if !ok {
+		return r.backoff.Duration()
+	}
+	if e.retryPolicy.After > 0 {
+		return r.retryAfter(e.retryPolicy)
+	}
+	return r.backoffAfter(e.retryPolicy)
+}
+
+// retry runs post again while it fails because of a backend outage, up to the configured number of retries. Rejected
+// submissions and local errors are not retried. Before each retry it calls backoffFn, which returns false when the
+// sender is stopped. It returns the error of the last attempt.
+func (r *submissionRetry) retry(err error, backoffFn func(time.Duration) bool, post func() error) error {
+	for retries := 0; isSubmissionOutage(err) && retries < r.max; retries++ {
+		if !backoffFn(r.wait(err)) {
+			return err
+		}
+		statusCode := 0
+		if e, ok := err.(*errRetry); ok {
+			statusCode = e.StatusCode
+		}
+		r.retries.Inc(backoff.MetricsSender, backoff.StatusClass(statusCode))
+		err = post()
+	}
+	return err
+}
+
+// reset restarts the backoff after a successful submission.
+func (r *submissionRetry) reset() {
+	r.backoff.Reset()
+}
+
+// inventoryRetryBackoff returns the maximum and the step of the backoff after failed inventory submissions.
+func inventoryRetryBackoff(cfg *config.Config) (max, step time.Duration) {
+	max = time.Duration(config.MAX_BACKOFF) * time.Second
+	step = time.Second
+	if cfg.SubmissionRetryBackoffMaxSec > 0 {
+		max = time.Duration(cfg.SubmissionRetryBackoffMaxSec) * time.Second
+	}
+	if cfg.SubmissionRetryBackoffBaseSec > 0 {
+		step = time.Duration(cfg.SubmissionRetryBackoffBaseSec) * time.Second
+	}
+	return max, step
+}
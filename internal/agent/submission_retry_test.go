@@ -0,0 +1,138 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
+	"github.com/newrelic/infrastructure-agent/pkg/backend/backoff"
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmissionRetry_DefaultsDontRetry(t *testing.T) {
+	r := newSubmissionRetry(config.NewConfig())
+
+	posts := 0
+	err := r.retry(errors.New("failed"), func(time.Duration) bool { return true }, func() error {
+		posts++
+		return nil
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, posts)
+}
+
+func TestSubmissionRetry_Retry(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.SubmissionRetryMax = 3
+	cfg.SubmissionRetryBackoffBaseSec = 2
+	cfg.SubmissionRetryBackoffMaxSec = 10
+	r := newSubmissionRetry(cfg)
+	r.backoff.Jitter = false
+	registry := openmetrics.NewRegistry()
+	r.retries = registry.NewCounterVec("nria_submission_retries_total", "Test retries.", "sender", "status_class")
+
+	var waits []time.Duration
+	serverErr := newErrRetry("failed", http.StatusServiceUnavailable, "503", "", backendhttp.RetryPolicy{})
+	posts := 0
+	err := r.retry(serverErr, func(d time.Duration) bool {
+		waits = append(waits, d)
+		return true
+	}, func() error {
+		posts++
+		if posts == 2 {
+			return nil
+		}
+		return serverErr
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, posts)
+	assert.Equal(t, []time.Duration{2 * time.Second, 4 * time.Second}, waits)
+	var metrics bytes.Buffer
+	require.NoError(t, registry.Write(&metrics))
+	assert.Contains(t, metrics.String(), `nria_submission_retries_total{sender="metrics",status_class="5xx"} 2`+"\n")
+}
+
+func TestSubmissionRetry_StopsWhenBackoffIsInterrupted(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.SubmissionRetryMax = 3
+	r := newSubmissionRetry(cfg)
+
+	posts := 0
+	serverErr := newErrRetry("failed", http.StatusServiceUnavailable, "503", "", backendhttp.RetryPolicy{})
+	err := r.retry(serverErr, func(time.Duration) bool { return false }, func() error {
+		posts++
+		return nil
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, posts)
+}
+
+func TestSubmissionRetry_DoesntRetryRejectedSubmissions(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.SubmissionRetryMax = 3
+	r := newSubmissionRetry(cfg)
+
+	tests := map[string]error{
+		"bad request":    newErrRetry("failed", http.StatusBadRequest, "400", "", backendhttp.RetryPolicy{}),
+		"invalid":        newErrRetry("failed", http.StatusUnauthorized, "401", "", backendhttp.RetryPolicy{}),
+		"too large":      newErrRetry("failed", http.StatusRequestEntityTooLarge, "413", "", backendhttp.RetryPolicy{}),
+		"marshal failed": errors.New("failed"),
+	}
+	for name, postErr := range tests {
+		t.Run(name, func(t *testing.T) {
+			posts := 0
+			err := r.retry(postErr, func(time.Duration) bool { return true }, func() error {
+				posts++
+				return nil
+			})
+
+			assert.Equal(t, postErr, err)
+			assert.Equal(t, 0, posts)
+		})
+	}
+}
+
+func TestSubmissionRetry_RetryAfterIsBoundedByMax(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.SubmissionRetryBackoffMaxSec = 10
+	r := newSubmissionRetry(cfg)
+
+	assert.Equal(t, 5*time.Second, r.retryAfter(backendhttp.RetryPolicy{After: 5 * time.Second}))
+	assert.Equal(t, 10*time.Second, r.retryAfter(backendhttp.RetryPolicy{After: time.Minute}))
+}
+
+func TestSubmissionRetry_BackoffKeepsLongerMaximums(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.SubmissionRetryBackoffMaxSec = 1
+	r := newSubmissionRetry(cfg)
+	r.backoff.Jitter = false
+	r.backoff.Min = time.Hour
+
+	assert.Equal(t, time.Second, r.backoffAfter(backendhttp.RetryPolicy{MaxBackOff: backoff.DefaultMax}))
+	r.reset()
+	assert.Equal(t, time.Hour, r.backoffAfter(backendhttp.RetryPolicy{MaxBackOff: 2 * time.Hour}))
+}
+
+func TestInventoryRetryBackoff(t *testing.T) {
+	max, step := inventoryRetryBackoff(config.NewConfig())
+	assert.Equal(t, time.Duration(config.MAX_BACKOFF)*time.Second, max)
+	assert.Equal(t, time.Second, step)
+
+	cfg := config.NewConfig()
+	cfg.SubmissionRetryBackoffBaseSec = 3
+	cfg.SubmissionRetryBackoffMaxSec = 30
+	max, step = inventoryRetryBackoff(cfg)
+	assert.Equal(t, 30*time.Second, max)
+	assert.Equal(t, 3*time.Second, step)
+}
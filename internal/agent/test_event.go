@@ -0,0 +1,90 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	context2 "context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/entity/host"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo"
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/hostname"
+)
+
+const (
+	testEventCategory = "test"
+	testEventSummary  = "New Relic Infrastructure agent test event"
+)
+
+// TestEvent InfrastructureEvent clearly marked as a test, submitted on demand to verify the data submission
+// pipeline and the account the license key routes the data to.
+type TestEvent struct {
+	sample.BaseEvent
+	Category  string `json:"category"`
+	Summary   string `json:"summary"`
+	TestEvent bool   `json:"testEvent"`
+	// TestEventID identifies the submission, so the event can be queried for.
+	TestEventID string `json:"testEventId"`
+}
+
+// NewTestEvent creates a test event identified by testEventID.
+func NewTestEvent(testEventID string, now time.Time) *TestEvent {
+	return &TestEvent{
+		BaseEvent: sample.BaseEvent{
+			EventType: "InfrastructureEvent",
+			Timestmp:  now.Unix(),
+		},
+		Category:    testEventCategory,
+		Summary:     testEventSummary,
+		TestEvent:   true,
+		TestEventID: testEventID,
+	}
+}
+
+// SendTestEvent submits a single test event for the host through the metrics ingest sender, returning an error
+// if it isn't accepted. The host is identified by its hostname or display name, as the agent is not connected.
+func SendTestEvent(cfg *config.Config, userAgent string, httpClient backendhttp.Client, testEventID string) error {
+	resolver := hostname.CreateResolver(cfg.OverrideHostname, cfg.OverrideHostnameShort, cfg.DnsHostnameResolution)
+	full, short, err := resolver.Query()
+	if err != nil {
+		return fmt.Errorf("cannot determine hostname: %w", err)
+	}
+	lookup := host.IDLookup{
+		sysinfo.HOST_SOURCE_HOSTNAME:       full,
+		sysinfo.HOST_SOURCE_HOSTNAME_SHORT: short,
+	}
+	if cfg.DisplayName != "" {
+		lookup[sysinfo.HOST_SOURCE_DISPLAY_NAME] = cfg.DisplayName
+	}
+	agentKey, err := lookup.AgentKey()
+	if err != nil {
+		return err
+	}
+
+	ctx := NewContext(cfg, "", resolver, lookup, nil, nil)
+	ctx.setAgentKey(agentKey)
+	return sendTestEvent(ctx, userAgent, httpClient, NewTestEvent(testEventID, time.Now()))
+}
+
+// sendTestEvent posts the event for the agent entity, without queueing nor retrying it.
+func sendTestEvent(ctx *context, userAgent string, httpClient backendhttp.Client, event *TestEvent) error {
+	sender := newMetricsIngestSender(ctx, ctx.Config().License, userAgent, httpClient, false)
+
+	agentKey := ctx.EntityKey()
+	event.Entity(entity.Key(agentKey))
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshalling test event: %w", err)
+	}
+
+	post := newMetricPost(entity.Key(agentKey), entity.EmptyID, entity.EmptyID, agentKey)
+	post.Events = []json.RawMessage{data}
+
+	return sender.doPost(context2.Background(), []*MetricPost{post}, agentKey)
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package agent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/testhelpers"
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity/host"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTestEvent(t *testing.T) {
+	now := time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)
+	event := NewTestEvent("abc", now)
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"eventType": "InfrastructureEvent",
+		"timestamp": 1614852000,
+		"entityKey": "",
+		"category": "test",
+		"summary": "New Relic Infrastructure agent test event",
+		"testEvent": true,
+		"testEventId": "abc"
+	}`, string(data))
+}
+
+func newTestEventContext() *context {
+	cfg := &config.Config{
+		License:                 "license",
+		CollectorURL:            "https://collector",
+		MetricsIngestEndpoint:   "/metrics",
+		PayloadCompressionLevel: gzip.NoCompression,
+	}
+	ctx := NewContext(cfg, "1.2.3", testhelpers.NullHostnameResolver, host.IDLookup{}, nil, nil)
+	ctx.setAgentKey("my-host")
+	return ctx
+}
+
+func TestSendTestEvent(t *testing.T) {
+	var requests []*http.Request
+	var body []byte
+	client := func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req)
+		body, _ = ioutil.ReadAll(req.Body)
+		return &http.Response{StatusCode: http.StatusAccepted, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+	}
+
+	event := NewTestEvent("abc", time.Now())
+	require.NoError(t, sendTestEvent(newTestEventContext(), "userAgent", client, event))
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "https://collector/metrics/events/bulk", requests[0].URL.String())
+	assert.Equal(t, "license", requests[0].Header.Get(backendhttp.LicenseHeader))
+	assert.Equal(t, "my-host", requests[0].Header.Get(backendhttp.EntityKeyHeader))
+
+	var posts []MetricPost
+	require.NoError(t, json.Unmarshal(body, &posts))
+	require.Len(t, posts, 1)
+	assert.True(t, posts[0].IsAgent)
+	assert.Equal(t, []string{"my-host"}, posts[0].ExternalKeys)
+	require.Len(t, posts[0].Events, 1)
+
+	var submitted TestEvent
+	require.NoError(t, json.Unmarshal(posts[0].Events[0], &submitted))
+	assert.True(t, submitted.TestEvent)
+	assert.Equal(t, "abc", submitted.TestEventID)
+	assert.Equal(t, "my-host", submitted.EntityKey)
+}
+
+func TestSendTestEvent_NotAccepted(t *testing.T) {
+	client := func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusForbidden, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+	}
+
+	err := sendTestEvent(newTestEventContext(), "userAgent", client, NewTestEvent("abc", time.Now()))
+	assert.Error(t, err)
+}
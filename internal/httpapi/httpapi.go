@@ -9,15 +9,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/newrelic/infrastructure-agent/internal/agent/status"
 	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/integration"
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/runner"
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/emitter"
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 	"github.com/sirupsen/logrus"
@@ -31,6 +37,11 @@ const (
 	statusEntityAPIPath        = "/v1/status/entity"
 	statusAPIPathReady         = "/v1/status/ready"
 	statusHealthAPIPath        = "/v1/status/health"
+	statusSamplingAPIPath      = "/v1/status/sampling"
+	statusConfigAPIPath        = "/v1/status/config"
+	statusSubmissionAPIPath    = "/v1/status/submission"
+	statusIntegrationsAPIPath  = "/v1/status/integrations"
+	statusProfileAPIPath       = "/v1/status/profile/:token"
 	ingestAPIPath              = "/v1/data"
 	ingestAPIPathReady         = "/v1/data/ready"
 	readinessProbeRetryBackoff = 100 * time.Millisecond
@@ -49,6 +60,9 @@ type Server struct {
 	Ingest        ComponentConfig
 	Status        ComponentConfig
 	reporter      status.Reporter
+	sampleRates   SampleRatesProvider
+	configReload  ConfigReloadStatusProvider
+	profiles      ProfileProvider
 	logger        log.Entry
 	definition    integration.Definition
 	emitter       emitter.Emitter
@@ -57,11 +71,22 @@ type Server struct {
 	timeout       time.Duration
 }
 
+// SampleRatesProvider provides the effective sample rates of the metrics samplers.
+type SampleRatesProvider func() map[string]config.SampleRateStatus
+
+// ConfigReloadStatusProvider provides the outcome of the configuration reloads.
+type ConfigReloadStatusProvider func() config.ReloadStatus
+
+// ProfileProvider provides the path of the profile captured on request for a download token, that is valid only once.
+type ProfileProvider func(token string) (path string, ok bool)
+
 // ComponentConfig stores configuration for a server component.
 type ComponentConfig struct {
 	enabled bool
 	address string
-	tls     tlsConfig
+	// socketPath of the Unix domain socket listened on instead of the address, when set.
+	socketPath string
+	tls        tlsConfig
 }
 
 // tlsConfig stores tls-related configuration.
@@ -79,6 +104,12 @@ func (sc *ComponentConfig) Enable(host string, port int) {
 	sc.address = net.JoinHostPort(host, fmt.Sprint(port))
 }
 
+// EnableUnixSocket configures and enables a server component listening on a Unix domain socket.
+func (sc *ComponentConfig) EnableUnixSocket(path string) {
+	sc.enabled = true
+	sc.socketPath = path
+}
+
 // TLS configures and enables TLS for a server component.
 func (sc *ComponentConfig) TLS(certPath, keyPath string) {
 	sc.tls.enabled = true
@@ -112,6 +143,21 @@ func NewServer(r status.Reporter, em emitter.Emitter) (*Server, error) {
 	}, nil
 }
 
+// SetSampleRatesProvider enables reporting the effective sample rates on the status API.
+func (s *Server) SetSampleRatesProvider(p SampleRatesProvider) {
+	s.sampleRates = p
+}
+
+// SetConfigReloadStatusProvider enables reporting the outcome of the configuration reloads on the status API.
+func (s *Server) SetConfigReloadStatusProvider(p ConfigReloadStatusProvider) {
+	s.configReload = p
+}
+
+// SetProfileProvider enables downloading the profiles captured on request from the status API.
+func (s *Server) SetProfileProvider(p ProfileProvider) {
+	s.profiles = p
+}
+
 // Serve serves status API requests and ingest.
 // Nice2Have: context cancellation.
 func (s *Server) Serve(ctx context.Context) {
@@ -160,7 +206,24 @@ func (s *Server) Serve(ctx context.Context) {
 }
 
 // serveStatus serves status API requests.
-func (s *Server) serveStatus(_ context.Context) error {
+func (s *Server) serveStatus(ctx context.Context) error {
+	router := httprouter.New()
+	// read only API
+	router.GET(statusAPIPathReady, s.handleReady)
+	router.GET(statusEntityAPIPath, s.handleEntity)
+	router.GET(statusAPIPath, s.handle(false))
+	router.GET(statusOnlyErrorsAPIPath, s.handle(true))
+	router.GET(statusHealthAPIPath, s.handleHealth)
+	router.GET(statusSamplingAPIPath, s.handleSampling)
+	router.GET(statusConfigAPIPath, s.handleConfigReload)
+	router.GET(statusSubmissionAPIPath, s.handleSubmission)
+	router.GET(statusIntegrationsAPIPath, s.handleIntegrations)
+	router.GET(statusProfileAPIPath, s.handleProfile)
+
+	if s.Status.socketPath != "" {
+		return s.serveStatusOnSocket(ctx, router)
+	}
+
 	statusServerErr := make(chan error, 1)
 
 	go func() {
@@ -169,13 +232,6 @@ func (s *Server) serveStatus(_ context.Context) error {
 			"address": s.Status.address,
 		}).Debug("Status API starting listening.")
 
-		router := httprouter.New()
-		// read only API
-		router.GET(statusAPIPathReady, s.handleReady)
-		router.GET(statusEntityAPIPath, s.handleEntity)
-		router.GET(statusAPIPath, s.handle(false))
-		router.GET(statusOnlyErrorsAPIPath, s.handle(true))
-		router.GET(statusHealthAPIPath, s.handleHealth)
 		// local only API
 		err := http.ListenAndServe(s.Status.address, router)
 		statusServerErr <- err
@@ -192,6 +248,32 @@ func (s *Server) serveStatus(_ context.Context) error {
 	return s.waitUntilReadyOrError(s.Status.address, statusAPIPathReady, s.Status.tls.enabled, s.Status.tls.validateClient, statusServerErr)
 }
 
+// serveStatusOnSocket serves status API requests on the Unix domain socket until ctx is done, removing the socket
+// then. The server is ready once it listens, so no readiness probe is needed.
+func (s *Server) serveStatusOnSocket(ctx context.Context, router http.Handler) error {
+	listener, err := ListenUnixSocket(s.Status.socketPath)
+	if err != nil {
+		return fmt.Errorf("cannot listen on the status server socket: %w", err)
+	}
+	s.logger.WithField("socket", s.Status.socketPath).Debug("Status API starting listening.")
+
+	server := &http.Server{Handler: router}
+	go func() {
+		<-ctx.Done()
+		// closing the listener removes the socket file
+		_ = server.Close()
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.WithError(err).Error("Status API stopped.")
+			return
+		}
+		s.logger.Debug("Status API stopped.")
+	}()
+
+	return nil
+}
+
 // serveIngest creates and starts an HTTP server handling ingestAPIPathReady and ingestAPIPath using Config.Ingest
 func (s *Server) serveIngest(_ context.Context) error {
 	serverErr := make(chan error, 1)
@@ -334,7 +416,7 @@ func (s *Server) handle(onlyErrors bool) func(http.ResponseWriter, *http.Request
 			return
 		}
 
-		if rep.Checks == nil {
+		if rep.Checks == nil && rep.LogForwarder == nil {
 			w.WriteHeader(http.StatusCreated) // 201
 		}
 
@@ -374,6 +456,124 @@ func (s *Server) handleHealth(writer http.ResponseWriter, _ *http.Request, _ htt
 	}
 }
 
+func (s *Server) handleSampling(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if s.sampleRates == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	b, err := json.Marshal(s.sampleRates())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		s.logger.WithError(err).Warn("couldn't encode sample rates")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	_, err = w.Write(b)
+	if err != nil {
+		s.logger.Warn("cannot write sampling response, error: " + err.Error())
+	}
+}
+
+func (s *Server) handleConfigReload(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if s.configReload == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	b, err := json.Marshal(s.configReload())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		s.logger.WithError(err).Warn("couldn't encode config reload status")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	_, err = w.Write(b)
+	if err != nil {
+		s.logger.Warn("cannot write config reload response, error: " + err.Error())
+	}
+}
+
+// submissionReport outcome of the submissions to the ingest endpoints.
+type submissionReport struct {
+	Endpoints []backendhttp.SubmissionStatus `json:"endpoints"`
+}
+
+func (s *Server) handleSubmission(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	b, err := json.Marshal(submissionReport{
+		Endpoints: backendhttp.SubmissionStatuses.Statuses(),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		s.logger.WithError(err).Warn("couldn't encode submission status")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	_, err = w.Write(b)
+	if err != nil {
+		s.logger.Warn("cannot write submission response, error: " + err.Error())
+	}
+}
+
+// integrationsReport last exits of the integrations.
+type integrationsReport struct {
+	Integrations []runner.ExitHistory `json:"integrations"`
+}
+
+func (s *Server) handleIntegrations(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	b, err := json.Marshal(integrationsReport{
+		Integrations: runner.ExitHistories.Histories(),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		s.logger.WithError(err).Warn("couldn't encode integrations exit history")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	_, err = w.Write(b)
+	if err != nil {
+		s.logger.Warn("cannot write integrations response, error: " + err.Error())
+	}
+}
+
+// handleProfile serves the profile of a download token, removing it once served.
+func (s *Server) handleProfile(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	if s.profiles == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	path, ok := s.profiles(ps.ByName("token"))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		s.logger.WithError(err).Warn("cannot open profile")
+		return
+	}
+	defer func() {
+		_ = f.Close()
+		if err := os.Remove(path); err != nil {
+			s.logger.WithError(err).Warn("cannot remove served profile")
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(path)))
+	_, err = io.Copy(w, f)
+	if err != nil {
+		s.logger.Warn("cannot write profile response, error: " + err.Error())
+	}
+}
+
 func (s *Server) handleEntity(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	re, err := s.reporter.ReportEntity()
 	if err != nil {
@@ -15,13 +15,18 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"sort"
 	"testing"
 	"time"
 
 	"github.com/newrelic/infrastructure-agent/internal/agent/id"
 	"github.com/newrelic/infrastructure-agent/internal/agent/status"
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/integration"
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/runner"
 	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/testhelp/testemit"
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/entity"
 	networkHelpers "github.com/newrelic/infrastructure-agent/pkg/helpers/network"
 	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/fixtures"
@@ -66,7 +71,7 @@ func (suite *HTTPAPITestSuite) TestServe_Status() {
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	r := status.NewReporter(ctx, logger, endpoints, healthEndpoint, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, "user-agent", "agent-key")
+	r := status.NewReporter(ctx, logger, endpoints, healthEndpoint, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, nil, "user-agent", "agent-key")
 
 	// When agent status API server is ready
 	em := &testemit.RecordEmitter{}
@@ -131,7 +136,7 @@ func (suite *HTTPAPITestSuite) TestServe_OnlyErrors() {
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	r := status.NewReporter(ctx, logger, endpoints, healthEndpoint, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, "user-agent", "agent-key")
+	r := status.NewReporter(ctx, logger, endpoints, healthEndpoint, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, nil, "user-agent", "agent-key")
 
 	// When agent status API server is ready
 	em := &testemit.RecordEmitter{}
@@ -198,7 +203,7 @@ func (suite *HTTPAPITestSuite) TestServe_Entity() {
 			port, err := networkHelpers.TCPPort()
 			require.NoError(t, err)
 
-			r := status.NewReporter(ctx, logger, []string{}, "", timeout, transport, tt.idProvide, emptyEntityKeyProvider, "user-agent", "agent-key")
+			r := status.NewReporter(ctx, logger, []string{}, "", timeout, transport, tt.idProvide, emptyEntityKeyProvider, nil, "user-agent", "agent-key")
 			// When agent status API server is ready
 			em := &testemit.RecordEmitter{}
 			s, err := NewServer(r, em)
@@ -260,7 +265,7 @@ func (suite *HTTPAPITestSuite) TestServe_Health() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	r := status.NewReporter(ctx, logger, []string{}, serverOk.URL, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, "user-agent", "agent-key")
+	r := status.NewReporter(ctx, logger, []string{}, serverOk.URL, timeout, transport, emptyIDProvide, emptyEntityKeyProvider, nil, "user-agent", "agent-key")
 
 	// When agent status API server is ready
 	em := &testemit.RecordEmitter{}
@@ -446,6 +451,219 @@ func (suite *HTTPAPITestSuite) TestServe_IngestData_mTLS() {
 	}
 }
 
+func (suite *HTTPAPITestSuite) TestServe_Sampling() {
+	port, err := networkHelpers.TCPPort()
+	suite.Require().NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	em := &testemit.RecordEmitter{}
+	s, err := NewServer(&noopReporter{}, em)
+	suite.Require().NoError(err)
+	s.Status.Enable("localhost", port)
+	s.SetSampleRatesProvider(func() map[string]config.SampleRateStatus {
+		return map[string]config.SampleRateStatus{
+			"system":  {IntervalSec: 5, Floored: true},
+			"storage": {IntervalSec: -1, Disabled: true},
+		}
+	})
+
+	go s.Serve(ctx)
+
+	s.waitUntilReady()
+
+	res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", port, statusSamplingAPIPath))
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+
+	suite.Require().Equal(http.StatusOK, res.StatusCode)
+	var got map[string]config.SampleRateStatus
+	suite.Require().NoError(json.NewDecoder(res.Body).Decode(&got))
+	suite.Equal(map[string]config.SampleRateStatus{
+		"system":  {IntervalSec: 5, Floored: true},
+		"storage": {IntervalSec: -1, Disabled: true},
+	}, got)
+}
+
+func (suite *HTTPAPITestSuite) TestServe_ConfigReload() {
+	port, err := networkHelpers.TCPPort()
+	suite.Require().NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lastReload := time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)
+	em := &testemit.RecordEmitter{}
+	s, err := NewServer(&noopReporter{}, em)
+	suite.Require().NoError(err)
+	s.Status.Enable("localhost", port)
+	s.SetConfigReloadStatusProvider(func() config.ReloadStatus {
+		return config.ReloadStatus{LastReload: &lastReload, LastReloadFailed: true, LastReloadError: "malformed yaml"}
+	})
+
+	go s.Serve(ctx)
+
+	s.waitUntilReady()
+
+	res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", port, statusConfigAPIPath))
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+
+	suite.Require().Equal(http.StatusOK, res.StatusCode)
+	var got config.ReloadStatus
+	suite.Require().NoError(json.NewDecoder(res.Body).Decode(&got))
+	suite.Require().NotNil(got.LastReload)
+	suite.True(lastReload.Equal(*got.LastReload))
+	suite.True(got.LastReloadFailed)
+	suite.Equal("malformed yaml", got.LastReloadError)
+}
+
+func (suite *HTTPAPITestSuite) TestServe_Profile() {
+	port, err := networkHelpers.TCPPort()
+	suite.Require().NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	profileFile, err := ioutil.TempFile("", "heap-*.pprof")
+	suite.Require().NoError(err)
+	defer os.Remove(profileFile.Name())
+	_, err = profileFile.WriteString("profile")
+	suite.Require().NoError(err)
+	suite.Require().NoError(profileFile.Close())
+
+	// GIVEN a profile downloadable once with its token
+	tokens := map[string]string{"abc": profileFile.Name()}
+	s, err := NewServer(&noopReporter{}, &testemit.RecordEmitter{})
+	suite.Require().NoError(err)
+	s.Status.Enable("localhost", port)
+	s.SetProfileProvider(func(token string) (string, bool) {
+		path, ok := tokens[token]
+		delete(tokens, token)
+		return path, ok
+	})
+
+	go s.Serve(ctx)
+
+	s.waitUntilReady()
+
+	profileURL := func(token string) string {
+		return fmt.Sprintf("http://localhost:%d/v1/status/profile/%s", port, token)
+	}
+
+	// WHEN it's downloaded with an unknown token THEN it's not found
+	res, err := http.Get(profileURL("unknown"))
+	suite.Require().NoError(err)
+	_ = res.Body.Close()
+	suite.Equal(http.StatusNotFound, res.StatusCode)
+
+	// WHEN it's downloaded with its token THEN it's served and removed
+	res, err = http.Get(profileURL("abc"))
+	suite.Require().NoError(err)
+	body, err := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+	suite.Require().NoError(err)
+	suite.Equal(http.StatusOK, res.StatusCode)
+	suite.Equal("profile", string(body))
+	_, err = os.Stat(profileFile.Name())
+	suite.True(os.IsNotExist(err))
+
+	// AND the token is no longer valid
+	res, err = http.Get(profileURL("abc"))
+	suite.Require().NoError(err)
+	_ = res.Body.Close()
+	suite.Equal(http.StatusNotFound, res.StatusCode)
+}
+
+func (suite *HTTPAPITestSuite) TestServe_Submission() {
+	port, err := networkHelpers.TCPPort()
+	suite.Require().NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	neverSubmitted := "https://never-submitted.test/ingest"
+	submitted := "https://submitted.test/ingest"
+	backendhttp.SubmissionStatuses.Register(neverSubmitted)
+	backendhttp.SubmissionStatuses.Record(submitted, &http.Response{StatusCode: http.StatusAccepted}, nil)
+
+	em := &testemit.RecordEmitter{}
+	s, err := NewServer(&noopReporter{}, em)
+	suite.Require().NoError(err)
+	s.Status.Enable("localhost", port)
+
+	go s.Serve(ctx)
+
+	s.waitUntilReady()
+
+	res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", port, statusSubmissionAPIPath))
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+
+	suite.Require().Equal(http.StatusOK, res.StatusCode)
+	var got struct {
+		Endpoints []map[string]interface{} `json:"endpoints"`
+	}
+	suite.Require().NoError(json.NewDecoder(res.Body).Decode(&got))
+	endpoints := map[string]map[string]interface{}{}
+	for _, endpoint := range got.Endpoints {
+		endpoints[endpoint["endpoint"].(string)] = endpoint
+	}
+
+	// the endpoints with no submission report explicit nulls
+	suite.Require().Contains(endpoints, neverSubmitted)
+	for _, field := range []string{"last_success", "last_error", "last_error_time"} {
+		value, ok := endpoints[neverSubmitted][field]
+		suite.True(ok, field)
+		suite.Nil(value, field)
+	}
+
+	suite.Require().Contains(endpoints, submitted)
+	suite.NotNil(endpoints[submitted]["last_success"])
+	suite.Nil(endpoints[submitted]["last_error"])
+}
+
+func (suite *HTTPAPITestSuite) TestServe_Integrations() {
+	port, err := networkHelpers.TCPPort()
+	suite.Require().NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exitTime := time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)
+	runner.ExitHistories.Record(integration.Definition{Name: "nri-flapping"}, runner.Exit{ExitCode: 1, Time: exitTime, DurationMs: 1500})
+	runner.ExitHistories.Record(integration.Definition{Name: "nri-flapping"}, runner.Exit{ExitCode: 0, Time: exitTime.Add(time.Minute), DurationMs: 900})
+
+	em := &testemit.RecordEmitter{}
+	s, err := NewServer(&noopReporter{}, em)
+	suite.Require().NoError(err)
+	s.Status.Enable("localhost", port)
+
+	go s.Serve(ctx)
+
+	s.waitUntilReady()
+
+	res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", port, statusIntegrationsAPIPath))
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+
+	suite.Require().Equal(http.StatusOK, res.StatusCode)
+	var got integrationsReport
+	suite.Require().NoError(json.NewDecoder(res.Body).Decode(&got))
+	var flapping *runner.ExitHistory
+	for i := range got.Integrations {
+		if got.Integrations[i].IntegrationName == "nri-flapping" {
+			flapping = &got.Integrations[i]
+		}
+	}
+	suite.Require().NotNil(flapping)
+	suite.Equal([]runner.Exit{
+		{ExitCode: 1, Time: exitTime, DurationMs: 1500},
+		{ExitCode: 0, Time: exitTime.Add(time.Minute), DurationMs: 900},
+	}, flapping.Exits)
+}
+
 func (suite *HTTPAPITestSuite) TestServer_ServeShouldEndSyncrhonouslyIfDisabled() {
 	em := &testemit.RecordEmitter{}
 	srv, err := NewServer(&noopReporter{}, em)
@@ -0,0 +1,37 @@
+// Copyright 2021 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package httpapi
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// unixSocketPerm restricts the access to the local servers to the agent user.
+const unixSocketPerm = 0o600
+
+// ListenUnixSocket listens on a Unix domain socket only accessible by the agent user. A stale socket left by a
+// previous run is replaced, while any other file in the path is kept and an error returned. The socket file is
+// removed when the listener is closed.
+func ListenUnixSocket(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("cannot listen on %s, the file exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("cannot remove stale socket %s: %w", path, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, unixSocketPerm); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("cannot restrict the permissions of socket %s: %w", path, err)
+	}
+
+	return listener, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux || darwin
+// +build linux darwin
+
+package httpapi
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/testhelp/testemit"
+)
+
+// socketDir returns a temporary directory short enough for the Unix socket path length limit.
+func socketDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "sock")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func unixSocketClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(socketDir(t), "status.sock")
+
+	listener, err := ListenUnixSocket(path)
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// the socket is removed once closed
+	require.NoError(t, listener.Close())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestListenUnixSocket_ReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(socketDir(t), "status.sock")
+
+	// GIVEN a socket file left by a previous run
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	// WHEN listening on it
+	listener, err := ListenUnixSocket(path)
+
+	// THEN the stale socket is replaced
+	require.NoError(t, err)
+	defer listener.Close()
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	_ = conn.Close()
+}
+
+func TestListenUnixSocket_KeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(socketDir(t), "status.sock")
+	require.NoError(t, ioutil.WriteFile(path, []byte("not a socket"), 0o600))
+
+	_, err := ListenUnixSocket(path)
+	assert.Error(t, err)
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "not a socket", string(content))
+}
+
+func TestServe_StatusOnUnixSocket(t *testing.T) {
+	path := filepath.Join(socketDir(t), "status.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// GIVEN a status server enabled on a Unix socket
+	s, err := NewServer(&noopReporter{}, &testemit.RecordEmitter{})
+	require.NoError(t, err)
+	s.Status.EnableUnixSocket(path)
+
+	go s.Serve(ctx)
+	s.waitUntilReady()
+
+	// WHEN a status request is sent through the socket
+	res, err := unixSocketClient(path).Get("http://unix" + statusAPIPathReady)
+
+	// THEN it's served
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// AND the socket is removed on shutdown
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,250 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package instrumentation
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// AgentMetrics registry of the agent internal metrics, served in the Prometheus text format on the
+// agent_metrics_endpoint.
+//
+// Metric names follow the nria_<subsystem>_<measure> scheme, in snake case, and counters end in "_total":
+//   - nria_event_queue_depth: events waiting to be batched for the metrics submission.
+//   - nria_batch_queue_depth: event batches waiting to be submitted.
+//   - nria_inventory_queue_depth: plugin and integration inventory payloads waiting to be processed.
+//   - nria_inventory_last_compaction_timestamp_seconds: Unix time of the last inventory storage compaction, 0 if none.
+//   - nria_inventory_compaction_reclaimed_bytes_total{trigger}: bytes reclaimed by the inventory storage compactions,
+//     by trigger "size" or "interval".
+//   - nria_submissions_total{endpoint,outcome}: submissions to the ingest endpoints, by outcome "success" or "failure".
+//   - nria_submission_retries_total{sender,status_class}: retried submissions, by sender "metrics" or "inventory" and
+//     status class of the failure that caused them, as "5xx" or "connection_error".
+//   - nria_sampler_samples_total{sampler}: samples produced by the metrics samplers.
+//   - nria_dropped_samples_total{sample_type}: samples dropped by the metrics matchers, if enable_dropped_samples_count
+//     is set.
+//   - nria_config_parse_errors_total{file}: configuration files that failed to parse, if
+//     enable_config_parse_errors_metric is set.
+//   - nria_agent_open_file_handles, nria_agent_file_handles_soft_limit, nria_agent_file_handles_hard_limit: open file
+//     handles of the agent process and their limits, if enable_file_handles_metric is set.
+//   - nria_plugin_restarts_total{plugin}: restarts of the plugin processes after crashing. Only the log forwarder is
+//     run by a supervisor, so it's the only plugin counted, while the integrations exits are kept by the status API.
+//
+// The metrics measured through the Instrumenter interface are served here too, as the Registry Measure function
+// implements it, named after their MetricName, as nria_logged_errors_total.
+var AgentMetrics = NewRegistry()
+
+const prometheusTextContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Registry keeps the agent internal metrics and writes them in the Prometheus text format.
+type Registry struct {
+	lock     sync.Mutex
+	families map[string]*metricFamily
+}
+
+// metricFamily a metric and the values of its label combinations.
+type metricFamily struct {
+	name       string
+	help       string
+	metricType MetricType
+	labelNames []string
+	// series values by their label values, joined by a separator that can't be part of a label value.
+	series  map[string]*metricSeries
+	gaugeFn func() float64
+}
+
+type metricSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]*metricFamily{}}
+}
+
+// CounterVec counter partitioned by the values of its labels.
+type CounterVec struct {
+	registry *Registry
+	family   *metricFamily
+}
+
+// NewCounterVec registers a counter with the labels, returning the already registered one if it exists.
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	family, ok := r.families[name]
+	if !ok {
+		family = &metricFamily{
+			name:       name,
+			help:       help,
+			metricType: Counter,
+			labelNames: labelNames,
+			series:     map[string]*metricSeries{},
+		}
+		r.families[name] = family
+	}
+	return &CounterVec{registry: r, family: family}
+}
+
+// Inc increments by one the counter for the label values, given in the order of the label names.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter for the label values, given in the order of the label names.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if len(labelValues) != len(c.family.labelNames) {
+		return
+	}
+
+	c.registry.lock.Lock()
+	defer c.registry.lock.Unlock()
+
+	key := strings.Join(labelValues, "\xff")
+	series, ok := c.family.series[key]
+	if !ok {
+		series = &metricSeries{labelValues: append([]string(nil), labelValues...)}
+		c.family.series[key] = series
+	}
+	series.value += delta
+}
+
+// SetGaugeFunc registers a gauge whose value is read from fn on every scrape, replacing the function of the gauge
+// if it was already registered.
+func (r *Registry) SetGaugeFunc(name, help string, fn func() float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.families[name] = &metricFamily{
+		name:       name,
+		help:       help,
+		metricType: Gauge,
+		gaugeFn:    fn,
+	}
+}
+
+// Measure records a measurement of the metric, as a Measure function: counters are increased by the value and gauges
+// set to it. Metrics without a registered name are ignored.
+func (r *Registry) Measure(metricType MetricType, name MetricName, val int64) {
+	metricName, ok := metricsToRegister[name]
+	if !ok {
+		return
+	}
+	metricName = "nria_" + strings.ReplaceAll(metricName, ".", "_")
+	if metricType == Counter {
+		metricName += "_total"
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	family, ok := r.families[metricName]
+	if !ok || family.metricType != metricType || family.gaugeFn != nil {
+		family = &metricFamily{
+			name:       metricName,
+			help:       fmt.Sprintf("Agent %s measurements.", metricsToRegister[name]),
+			metricType: metricType,
+			series:     map[string]*metricSeries{"": {}},
+		}
+		r.families[metricName] = family
+	}
+	series := family.series[""]
+	if metricType == Counter {
+		series.value += float64(val)
+	} else {
+		series.value = float64(val)
+	}
+}
+
+// Handler returns the HTTP handler serving the metrics in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", prometheusTextContentType)
+		_ = r.Write(w)
+	})
+}
+
+// Write writes the metrics in the Prometheus text format, sorted by name and label values.
+func (r *Registry) Write(w io.Writer) error {
+	r.lock.Lock()
+	families := make([]metricFamily, 0, len(r.families))
+	for _, family := range r.families {
+		snapshot := *family
+		snapshot.series = make(map[string]*metricSeries, len(family.series))
+		for key, series := range family.series {
+			seriesCopy := *series
+			snapshot.series[key] = &seriesCopy
+		}
+		families = append(families, snapshot)
+	}
+	r.lock.Unlock()
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+	// gauge functions are called without holding the lock, as they could record metrics themselves
+	var b strings.Builder
+	for _, family := range families {
+		family.write(&b)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (f *metricFamily) write(b *strings.Builder) {
+	typeName := "counter"
+	if f.metricType == Gauge {
+		typeName = "gauge"
+	}
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, typeName)
+
+	if f.gaugeFn != nil {
+		fmt.Fprintf(b, "%s %s\n", f.name, formatValue(f.gaugeFn()))
+		return
+	}
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		series := f.series[key]
+		if len(f.labelNames) == 0 {
+			fmt.Fprintf(b, "%s %s\n", f.name, formatValue(series.value))
+			continue
+		}
+		labels := make([]string, len(f.labelNames))
+		for i, labelName := range f.labelNames {
+			labels[i] = fmt.Sprintf("%s=\"%s\"", labelName, escapeLabelValue(series.labelValues[i]))
+		}
+		fmt.Fprintf(b, "%s{%s} %s\n", f.name, strings.Join(labels, ","), formatValue(series.value))
+	}
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
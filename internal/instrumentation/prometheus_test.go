@@ -0,0 +1,88 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package instrumentation
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("nria_test_requests_total", "Test requests.", "endpoint", "outcome")
+	requests.Inc("https://b", "success")
+	requests.Inc("https://a", "failure")
+	requests.Add(2, "https://a", "failure")
+	// ignored, as the label values don't match the label names
+	requests.Inc("https://a")
+	// registering it again returns the same counter
+	r.NewCounterVec("nria_test_requests_total", "Test requests.", "endpoint", "outcome").Inc("https://b", "success")
+
+	r.NewCounterVec("nria_test_escaped_total", "Help with \\ and\nnew line.", "value").Inc("quoted \"value\"\n")
+	r.NewCounterVec("nria_test_unused_total", "Not increased.")
+
+	depth := 3
+	r.SetGaugeFunc("nria_test_queue_depth", "Test queue.", func() float64 { return float64(depth) })
+	depth = 5
+
+	var b strings.Builder
+	require.NoError(t, r.Write(&b))
+	assert.Equal(t, `# HELP nria_test_escaped_total Help with \\ and\nnew line.
+# TYPE nria_test_escaped_total counter
+nria_test_escaped_total{value="quoted \"value\"\n"} 1
+# HELP nria_test_queue_depth Test queue.
+# TYPE nria_test_queue_depth gauge
+nria_test_queue_depth 5
+# HELP nria_test_requests_total Test requests.
+# TYPE nria_test_requests_total counter
+nria_test_requests_total{endpoint="https://a",outcome="failure"} 3
+nria_test_requests_total{endpoint="https://b",outcome="success"} 2
+# HELP nria_test_unused_total Not increased.
+# TYPE nria_test_unused_total counter
+`, b.String())
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.SetGaugeFunc("nria_test_queue_depth", "Test queue.", func() float64 { return 1.5 })
+
+	ts := httptest.NewServer(r.Handler())
+	defer ts.Close()
+	res, err := http.Get(ts.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), "nria_test_queue_depth 1.5\n")
+}
+
+func TestRegistry_Measure(t *testing.T) {
+	r := NewRegistry()
+	var measure Measure = r.Measure
+	measure(Counter, LoggedErrors, 1)
+	measure(Counter, LoggedErrors, 2)
+	measure(Gauge, DMDatasetsReceived, 4)
+	measure(Gauge, DMDatasetsReceived, 3)
+	// ignored, as it doesn't have a registered name
+	measure(Counter, MetricName(-1), 1)
+
+	var b strings.Builder
+	require.NoError(t, r.Write(&b))
+	assert.Equal(t, `# HELP nria_dm_datasets_received Agent dm.datasets_received measurements.
+# TYPE nria_dm_datasets_received gauge
+nria_dm_datasets_received 3
+# HELP nria_logged_errors_total Agent logged.errors measurements.
+# TYPE nria_logged_errors_total counter
+nria_logged_errors_total 3
+`, b.String())
+}
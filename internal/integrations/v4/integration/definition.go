@@ -96,13 +96,14 @@ func (d *Definition) PluginID(integrationName string) ids.PluginID {
 	return ids.NewDefaultInventoryPluginID(d.Name)
 }
 
-func (d *Definition) Run(ctx context.Context, bindVals *databind.Values, discoveryInfo databind.DiscovererInfo, pidC, exitCodeC chan<- int) ([]Output, error) {
+func (d *Definition) Run(ctx context.Context, bindVals *databind.Values, discoveryInfo databind.DiscovererInfo, pidC chan<- int) ([]Output, error) {
 	logger := elog.WithField("integration_name", d.Name)
 	logger.Debug("Running task.")
 	// no discovery data: execute a single instance
 	if bindVals == nil {
 		logger.Debug("Running single instance.")
-		return []Output{{Receive: d.runnable.Execute(ctx, pidC, exitCodeC)}}, nil
+		exitCode := make(chan int, 1)
+		return []Output{{Receive: d.runnable.Execute(ctx, pidC, exitCode), ExitCode: exitCode}}, nil
 	}
 
 	// apply discovered data to run multiple instances
@@ -176,11 +177,12 @@ func (d *Definition) Run(ctx context.Context, bindVals *databind.Values, discove
 		}
 
 		logger.Debug("Executing task.")
-		taskOutput := dc.Executor.Execute(ctx, nil, nil)
+		exitCode := make(chan int, 1)
+		taskOutput := dc.Executor.Execute(ctx, nil, exitCode)
 		if removeFile != nil {
 			go removeFile(taskOutput.Done)
 		}
-		tasksOutput = append(tasksOutput, Output{Receive: taskOutput, ExtraLabels: ir.MetricAnnotations, EntityRewrite: ir.EntityRewrites})
+		tasksOutput = append(tasksOutput, Output{Receive: taskOutput, ExtraLabels: ir.MetricAnnotations, EntityRewrite: ir.EntityRewrites, ExitCode: exitCode})
 	}
 	return tasksOutput, nil
 }
@@ -35,7 +35,7 @@ func TestRun(t *testing.T) {
 	require.NoError(t, err)
 
 	// WHEN it is executed
-	outs, err := def.Run(context.Background(), nil, databind.DiscovererInfo{}, nil, nil)
+	outs, err := def.Run(context.Background(), nil, databind.DiscovererInfo{}, nil)
 	require.NoError(t, err)
 	require.Len(t, outs, 1)
 
@@ -59,7 +59,7 @@ func TestRun_NoDiscovery(t *testing.T) {
 	require.NoError(t, err)
 
 	// WHEN the def is executed with no discovery matches
-	outs, err := def.Run(context.Background(), &databind.Values{}, databind.DiscovererInfo{}, nil, nil)
+	outs, err := def.Run(context.Background(), &databind.Values{}, databind.DiscovererInfo{}, nil)
 	require.NoError(t, err)
 
 	// THEN no tasks are executed
@@ -81,7 +81,7 @@ func TestRun_NoDiscoveryButFlexVariables(t *testing.T) {
 	require.NoError(t, err)
 
 	// WHEN the def is executed with no discovery matches
-	outs, err := def.Run(context.Background(), &databind.Values{}, databind.DiscovererInfo{}, nil, nil)
+	outs, err := def.Run(context.Background(), &databind.Values{}, databind.DiscovererInfo{}, nil)
 	require.NoError(t, err)
 
 	// THEN no tasks are executed
@@ -114,7 +114,7 @@ func TestRun_Discovery(t *testing.T) {
 		databind.NewDiscovery(data.Map{"prefix": "bye", "argument": "people"}, data.InterfaceMap{"special": false, "label.two": "two"}, nil),
 		databind.NewDiscovery(data.Map{"prefix": "kon", "argument": "nichiwa"}, data.InterfaceMap{"other_tag": "true", "label.tree": "three"}, nil),
 	)
-	outs, err := def.Run(context.Background(), &vals, databind.DiscovererInfo{}, nil, nil)
+	outs, err := def.Run(context.Background(), &vals, databind.DiscovererInfo{}, nil)
 	require.NoError(t, err)
 	require.Len(t, outs, 3)
 
@@ -149,7 +149,7 @@ func TestRun_CmdSlice(t *testing.T) {
 	require.NoError(t, err)
 
 	// WHEN the def is executed
-	outs, err := def.Run(context.Background(), &databind.Values{}, databind.DiscovererInfo{}, nil, nil)
+	outs, err := def.Run(context.Background(), &databind.Values{}, databind.DiscovererInfo{}, nil)
 	require.NoError(t, err)
 	require.Len(t, outs, 1)
 
@@ -177,7 +177,7 @@ func TestRun_CancelPropagation(t *testing.T) {
 	)
 
 	parentContext, cancel := context.WithCancel(context.Background())
-	outs, err := def.Run(parentContext, &vals, databind.DiscovererInfo{}, nil, nil)
+	outs, err := def.Run(parentContext, &vals, databind.DiscovererInfo{}, nil)
 	require.NoError(t, err)
 	require.Len(t, outs, 3)
 
@@ -221,7 +221,7 @@ func TestRun_CancelPropagationWithoutReads(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	outs, err := def.Run(ctx, nil, databind.DiscovererInfo{}, nil, nil)
+	outs, err := def.Run(ctx, nil, databind.DiscovererInfo{}, nil)
 	require.NoError(t, err)
 	require.Len(t, outs, 1)
 
@@ -263,7 +263,7 @@ func TestRun_Cancel_Partial(t *testing.T) {
 	)
 
 	parentContext, cancel := context.WithCancel(context.Background())
-	outs, err := def.Run(parentContext, &vals, databind.DiscovererInfo{}, nil, nil)
+	outs, err := def.Run(parentContext, &vals, databind.DiscovererInfo{}, nil)
 	require.NoError(t, err)
 	require.Len(t, outs, 2)
 
@@ -305,7 +305,7 @@ func TestRun_Directory(t *testing.T) {
 	require.NoError(t, err)
 
 	// WHEN it is executed
-	outs, err := def.Run(context.Background(), nil, databind.DiscovererInfo{}, nil, nil)
+	outs, err := def.Run(context.Background(), nil, databind.DiscovererInfo{}, nil)
 	require.NoError(t, err)
 	require.Len(t, outs, 1)
 
@@ -349,7 +349,7 @@ func TestRun_RemoveExternalConfig(t *testing.T) {
 		}
 		return path, err
 	}
-	outputs, err := def.Run(ctx, &vals, databind.DiscovererInfo{}, nil, nil)
+	outputs, err := def.Run(ctx, &vals, databind.DiscovererInfo{}, nil)
 	require.NoError(t, err)
 	require.Len(t, outputs, 2)
 	require.Len(t, createdConfigs, 2)
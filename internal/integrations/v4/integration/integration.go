@@ -45,6 +45,9 @@ type Output struct {
 	Receive       executor.OutputReceive
 	ExtraLabels   data.Map
 	EntityRewrite []data.EntityRewrite
+	// ExitCode receives the exit code of the instance before its Receive channels are closed. Nothing is received if
+	// the executor failed before running it.
+	ExitCode <-chan int
 }
 
 // InstancesLookup helps looking for integration executables that are not explicitly
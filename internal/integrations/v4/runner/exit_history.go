@@ -0,0 +1,165 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/integration"
+	"github.com/newrelic/infrastructure-agent/pkg/config" //nolint:depguard
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+)
+
+// ExitHistories records the last exits of the running integrations.
+var ExitHistories = NewExitHistoryRegistry(config.DefaultIntegrationsExitHistorySize)
+
+// SendEventFn sends an event to New Relic.
+type SendEventFn func(event sample.Event, entityKey entity.Key)
+
+// Exit of an integration execution.
+type Exit struct {
+	ExitCode int       `json:"exit_code"`
+	Time     time.Time `json:"time"`
+	// DurationMs time the execution took, in milliseconds.
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// ExitHistory last exits of an integration, oldest first.
+type ExitHistory struct {
+	IntegrationName string `json:"integration_name"`
+	RunnerUID       string `json:"runner_uid"`
+	Exits           []Exit `json:"exits"`
+}
+
+// IntegrationExitEvent is submitted as an InfrastructureEvent when an integration exits with a non-zero exit code.
+type IntegrationExitEvent struct {
+	sample.BaseEvent
+	Summary         string `json:"summary"`
+	IntegrationName string `json:"integrationName"`
+	ExitStatus      string `json:"exitStatus"`
+	DurationMs      int64  `json:"durationMs"`
+}
+
+// exitQueue ring buffer keeping the last exits of an integration.
+type exitQueue struct {
+	integrationName string
+	runnerUID       string
+	nextExit        int
+	queue           []Exit
+}
+
+func (eq *exitQueue) add(exit Exit) {
+	eq.queue[eq.nextExit%len(eq.queue)] = exit
+	eq.nextExit++
+}
+
+// exits returns the kept exits, oldest first.
+func (eq *exitQueue) exits() []Exit {
+	if eq.nextExit < len(eq.queue) {
+		return append([]Exit{}, eq.queue[:eq.nextExit]...)
+	}
+	start := eq.nextExit % len(eq.queue)
+	return append(append([]Exit{}, eq.queue[start:]...), eq.queue[:start]...)
+}
+
+// ExitHistoryRegistry keeps the last exits of every integration, by integration definition.
+type ExitHistoryRegistry struct {
+	lock      sync.Mutex
+	size      int
+	queues    map[string]*exitQueue
+	sendEvent SendEventFn
+}
+
+// NewExitHistoryRegistry creates an ExitHistoryRegistry keeping the last size exits of every integration.
+func NewExitHistoryRegistry(size int) *ExitHistoryRegistry {
+	return &ExitHistoryRegistry{
+		size:   size,
+		queues: map[string]*exitQueue{},
+	}
+}
+
+// SetSize sets the number of exits kept for every integration, discarding the ones already kept. When 0, the exits
+// are not kept.
+func (r *ExitHistoryRegistry) SetSize(size int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.size = size
+	r.queues = map[string]*exitQueue{}
+}
+
+// SetEventSender enables submitting an IntegrationExitEvent for every non-zero exit.
+func (r *ExitHistoryRegistry) SetEventSender(sendEvent SendEventFn) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.sendEvent = sendEvent
+}
+
+// Record records the exit of an execution of the integration.
+func (r *ExitHistoryRegistry) Record(def integration.Definition, exit Exit) {
+	r.lock.Lock()
+	sendEvent := r.sendEvent
+	if r.size > 0 {
+		hash := def.Hash()
+		queue, ok := r.queues[hash]
+		if !ok {
+			queue = &exitQueue{
+				integrationName: def.Name,
+				runnerUID:       hash[:10],
+				queue:           make([]Exit, r.size),
+			}
+			r.queues[hash] = queue
+		}
+		queue.add(exit)
+	}
+	r.lock.Unlock()
+
+	if sendEvent != nil && exit.ExitCode != 0 {
+		sendEvent(&IntegrationExitEvent{
+			BaseEvent: sample.BaseEvent{
+				EventType: "InfrastructureEvent",
+				Timestmp:  exit.Time.Unix(),
+			},
+			Summary:         "Integration exited with error",
+			IntegrationName: def.Name,
+			ExitStatus:      fmt.Sprintf("%d", exit.ExitCode),
+			DurationMs:      exit.DurationMs,
+		}, entity.EmptyKey)
+	}
+}
+
+// Remove discards the exits of the integration, once its definition is unloaded.
+func (r *ExitHistoryRegistry) Remove(def integration.Definition) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.queues, def.Hash())
+}
+
+// Histories returns a copy of the exit histories, sorted by integration name.
+func (r *ExitHistoryRegistry) Histories() []ExitHistory {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	histories := make([]ExitHistory, 0, len(r.queues))
+	for _, queue := range r.queues {
+		histories = append(histories, ExitHistory{
+			IntegrationName: queue.integrationName,
+			RunnerUID:       queue.runnerUID,
+			Exits:           queue.exits(),
+		})
+	}
+	sort.Slice(histories, func(i, j int) bool {
+		if histories[i].IntegrationName != histories[j].IntegrationName {
+			return histories[i].IntegrationName < histories[j].IntegrationName
+		}
+		return histories[i].RunnerUID < histories[j].RunnerUID
+	})
+	return histories
+}
@@ -0,0 +1,200 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/fixtures"
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/integration"
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/testhelp"
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/testhelp/testemit"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/entity/host"
+	"github.com/newrelic/infrastructure-agent/pkg/integrations/cmdrequest"
+	"github.com/newrelic/infrastructure-agent/pkg/integrations/configrequest"
+	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/config"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exitsWithCodes(codes ...int) []Exit {
+	exits := make([]Exit, 0, len(codes))
+	for i, code := range codes {
+		exits = append(exits, Exit{ExitCode: code, Time: time.Unix(int64(1600000000+i), 0), DurationMs: int64(i)})
+	}
+	return exits
+}
+
+func TestExitHistoryRegistry_RecordsInOrder(t *testing.T) {
+	r := NewExitHistoryRegistry(3)
+	def := integration.Definition{Name: "nri-flapping"}
+
+	for _, exit := range exitsWithCodes(0, 1) {
+		r.Record(def, exit)
+	}
+	histories := r.Histories()
+	require.Len(t, histories, 1)
+	assert.Equal(t, "nri-flapping", histories[0].IntegrationName)
+	assert.Equal(t, def.Hash()[:10], histories[0].RunnerUID)
+	assert.Equal(t, exitsWithCodes(0, 1), histories[0].Exits)
+
+	// only the last exits are kept, oldest first
+	for _, exit := range exitsWithCodes(0, 1, 2, 3, 4)[2:] {
+		r.Record(def, exit)
+	}
+	assert.Equal(t, exitsWithCodes(0, 1, 2, 3, 4)[2:], r.Histories()[0].Exits)
+}
+
+func TestExitHistoryRegistry_ByIntegration(t *testing.T) {
+	r := NewExitHistoryRegistry(3)
+
+	r.Record(integration.Definition{Name: "nri-redis"}, exitsWithCodes(1)[0])
+	r.Record(integration.Definition{Name: "nri-mysql"}, exitsWithCodes(0)[0])
+
+	histories := r.Histories()
+	require.Len(t, histories, 2)
+	assert.Equal(t, "nri-mysql", histories[0].IntegrationName)
+	assert.Equal(t, exitsWithCodes(0), histories[0].Exits)
+	assert.Equal(t, "nri-redis", histories[1].IntegrationName)
+	assert.Equal(t, exitsWithCodes(1), histories[1].Exits)
+}
+
+func TestExitHistoryRegistry_Disabled(t *testing.T) {
+	r := NewExitHistoryRegistry(3)
+	r.Record(integration.Definition{Name: "nri-redis"}, exitsWithCodes(1)[0])
+
+	r.SetSize(0)
+	r.Record(integration.Definition{Name: "nri-redis"}, exitsWithCodes(1)[0])
+
+	assert.Empty(t, r.Histories())
+}
+
+func TestExitHistoryRegistry_Events(t *testing.T) {
+	r := NewExitHistoryRegistry(0)
+	var events []sample.Event
+	r.SetEventSender(func(event sample.Event, entityKey entity.Key) {
+		assert.Equal(t, entity.EmptyKey, entityKey)
+		events = append(events, event)
+	})
+
+	def := integration.Definition{Name: "nri-redis"}
+	exits := exitsWithCodes(0, 3)
+	r.Record(def, exits[0])
+	r.Record(def, exits[1])
+
+	// only the non-zero exits are reported
+	assert.Equal(t, []sample.Event{&IntegrationExitEvent{
+		BaseEvent: sample.BaseEvent{
+			EventType: "InfrastructureEvent",
+			Timestmp:  exits[1].Time.Unix(),
+		},
+		Summary:         "Integration exited with error",
+		IntegrationName: "nri-redis",
+		ExitStatus:      "3",
+		DurationMs:      exits[1].DurationMs,
+	}}, events)
+}
+
+func TestExitHistoryRegistry_Remove(t *testing.T) {
+	r := NewExitHistoryRegistry(3)
+	r.Record(integration.Definition{Name: "nri-redis"}, exitsWithCodes(1)[0])
+	r.Record(integration.Definition{Name: "nri-mysql"}, exitsWithCodes(0)[0])
+
+	r.Remove(integration.Definition{Name: "nri-redis"})
+
+	histories := r.Histories()
+	require.Len(t, histories, 1)
+	assert.Equal(t, "nri-mysql", histories[0].IntegrationName)
+}
+
+func Test_runner_Run_recordsExitHistory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip()
+	}
+
+	registry := NewExitHistoryRegistry(3)
+	runOnce := func(instanceName string, script testhelp.Script) {
+		def, err := integration.NewDefinition(config.ConfigEntry{
+			InstanceName: instanceName,
+			Exec:         testhelp.Command(script),
+		}, integration.ErrLookup, nil, nil)
+		require.NoError(t, err)
+		def.Interval = 0
+
+		r := NewRunner(def, &testemit.RecordEmitter{}, nil, func() runnerErrorHandler {
+			return func(_ context.Context, errs <-chan error) {
+				for range errs {
+				}
+			}
+		}, cmdrequest.NoopHandleFn, configrequest.NoopHandleFn, nil, host.IDLookup{})
+		r.exitHistories = registry
+
+		exitCodeCh := make(chan int, 1)
+		// single runs return once the errors are handled, so the exit is already recorded
+		r.Run(context.Background(), nil, exitCodeCh)
+		assert.Len(t, exitCodeCh, 1)
+	}
+
+	runOnce("failing", fixtures.ErrorCmd)
+	runOnce("succeeding", fixtures.IntegrationScript)
+
+	histories := registry.Histories()
+	require.Len(t, histories, 2)
+	assert.Equal(t, "failing", histories[0].IntegrationName)
+	require.Len(t, histories[0].Exits, 1)
+	assert.Equal(t, 3, histories[0].Exits[0].ExitCode)
+	assert.Equal(t, "succeeding", histories[1].IntegrationName)
+	require.Len(t, histories[1].Exits, 1)
+	assert.Equal(t, 0, histories[1].Exits[0].ExitCode)
+}
+
+func Test_runner_recordExit_UsesExecutorExitCode(t *testing.T) {
+	def := integration.Definition{Name: "nri-redis"}
+	r := NewRunner(def, &testemit.RecordEmitter{}, nil, nil, cmdrequest.NoopHandleFn, configrequest.NoopHandleFn, nil, host.IDLookup{})
+	r.exitHistories = NewExitHistoryRegistry(3)
+
+	errs := make(chan error, 1)
+	exitCode := make(chan int, 1)
+	// errors that aren't the process exit status don't change the exit code reported by the executor
+	errs <- errors.New("read |0: file already closed")
+	exitCode <- 0
+	close(errs)
+
+	forward := r.recordExit(context.Background(), errs, exitCode, nil, time.Now())
+	for range forward {
+	}
+
+	histories := r.exitHistories.Histories()
+	require.Len(t, histories, 1)
+	require.Len(t, histories[0].Exits, 1)
+	assert.Equal(t, 0, histories[0].Exits[0].ExitCode)
+}
+
+func Test_runner_Run_RemovesExitHistoryOnceInterrupted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip()
+	}
+
+	def, err := integration.NewDefinition(config.ConfigEntry{
+		InstanceName: "nri-redis",
+		Exec:         testhelp.Command(fixtures.IntegrationScript),
+		Interval:     "1h",
+	}, integration.ErrLookup, nil, nil)
+	require.NoError(t, err)
+	r := NewRunner(def, &testemit.RecordEmitter{}, nil, nil, cmdrequest.NoopHandleFn, configrequest.NoopHandleFn, nil, host.IDLookup{})
+	r.exitHistories = NewExitHistoryRegistry(3)
+	r.exitHistories.Record(def, exitsWithCodes(1)[0])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Run(ctx, nil, nil)
+
+	assert.Empty(t, r.exitHistories.Histories())
+}
@@ -66,6 +66,7 @@ type runner struct {
 	cache          cache.Cache
 	terminateQueue chan<- string
 	idLookup       host.IDLookup
+	exitHistories  *ExitHistoryRegistry
 }
 
 // NewRunner creates an integration runner instance.
@@ -92,6 +93,7 @@ func NewRunner(
 		terminateQueue: terminateQ,
 		cache:          cache.CreateCache(),
 		idLookup:       idLookup,
+		exitHistories:  ExitHistories,
 	}
 	if handleErrorsProvide != nil {
 		r.handleErrors = handleErrorsProvide()
@@ -135,6 +137,8 @@ func (r *runner) Run(ctx context.Context, pidWCh, exitCodeCh chan<- int) {
 		select {
 		case <-ctx.Done():
 			r.log.Debug("Integration has been interrupted")
+			// the definition is unloaded, either removed or replaced
+			r.exitHistories.Remove(r.definition)
 			return
 		case <-waitForNextExecution:
 		}
@@ -248,7 +252,7 @@ func (r *runner) execute(ctx context.Context, matches *databind.Values, discover
 	}
 
 	// Runs all the matching integration instances
-	outputs, err := r.definition.Run(ctx, matches, discoveryInfo, pidWCh, exitCodeCh)
+	outputs, err := r.definition.Run(ctx, matches, discoveryInfo, pidWCh)
 	if err != nil {
 		txn.NoticeError(err)
 		r.log.WithError(err).Error("can't start integration")
@@ -259,8 +263,14 @@ func (r *runner) execute(ctx context.Context, matches *databind.Values, discover
 	wg := sync.WaitGroup{}
 	waitForCurrent := make(chan struct{})
 	wg.Add(len(outputs) * 3)
+	startTime := time.Now()
+	// only the single instance runs hand off their exit code
+	if matches != nil {
+		exitCodeCh = nil
+	}
 	for _, out := range outputs {
 		o := out
+		o.Receive.Errors = r.recordExit(ctx, o.Receive.Errors, o.ExitCode, exitCodeCh, startTime)
 		go func(txn instrumentation.Transaction) {
 			defer wg.Done()
 			r.handleLines(ctx, o.Receive.Stdout, o.ExtraLabels, o.EntityRewrite)
@@ -345,6 +355,41 @@ func (r *runner) handleStderr(stderr <-chan []byte) {
 	}
 }
 
+// recordExit forwards the execution errors of an integration instance and, once they end, hands off its exit code
+// to exitCodeCh, if any, recording the exit in the exit histories. Exits caused by the cancellation of the
+// integration are not recorded, nor the instances the executor failed to run, as they don't have an exit code.
+func (r *runner) recordExit(ctx context.Context, errs <-chan error, exitCode <-chan int, exitCodeCh chan<- int, startTime time.Time) <-chan error {
+	forward := make(chan error, cap(errs))
+	go func() {
+		defer close(forward)
+		for err := range errs {
+			select {
+			case forward <- err:
+			case <-ctx.Done():
+			}
+		}
+
+		var code int
+		select {
+		case code = <-exitCode:
+		default:
+			return
+		}
+		if exitCodeCh != nil {
+			exitCodeCh <- code
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		r.exitHistories.Record(r.definition, Exit{
+			ExitCode:   code,
+			Time:       time.Now(),
+			DurationMs: time.Since(startTime).Milliseconds(),
+		})
+	}()
+	return forward
+}
+
 // implementation of the "handleErrors" property
 func (r *runner) logErrors(ctx context.Context, errs <-chan error) {
 	for {
@@ -16,6 +16,7 @@ import (
 	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/executor"
 	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/files"
 	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/integration"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
 )
@@ -89,6 +90,7 @@ func (dr *DefinitionsRepo) loadDefinitions(folder string) {
 		}
 		var def Definition
 		if err := yaml.Unmarshal(contents, &def); err != nil {
+			config.ParseErrors.Inc(filepath.Join(folder, file.Name()))
 			fflog.WithError(err).Warn("invalid YAML file. Ignoring")
 			continue
 		}
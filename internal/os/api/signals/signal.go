@@ -6,6 +6,8 @@ const (
 	// NotificationStr string representation for signal used to send notification. Used for Docker.
 	NotificationStr = "SIGUSR1"
 	GracefulStopStr = "SIGUSR2"
+	// ReloadStr string representation for signal used to reload the agent configuration.
+	ReloadStr = "SIGHUP"
 	// GracefulShutdownStr is not a real POSIX signal, it's a custom signal we use when we detect a host shutdown
 	GracefulShutdownStr = "SHUTDOWN"
 )
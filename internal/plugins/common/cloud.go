@@ -98,3 +98,19 @@ func getCloudData(cloudHarvester cloud.Harvester) (cloudData CloudData, err erro
 
 	return
 }
+
+// CloudType returns the cloud type the gathered cloud data belongs to, TypeNoCloud if there is none.
+func (c CloudData) CloudType() cloud.Type {
+	switch {
+	case c.RegionAWS != "":
+		return cloud.TypeAWS
+	case c.RegionAzure != "":
+		return cloud.TypeAzure
+	case c.RegionGCP != "":
+		return cloud.TypeGCP
+	case c.RegionAlibaba != "":
+		return cloud.TypeAlibaba
+	default:
+		return cloud.TypeNoCloud
+	}
+}
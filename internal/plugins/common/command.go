@@ -0,0 +1,34 @@
+// Copyright New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"strings"
+)
+
+const redactedArgs = "(redacted)"
+
+// RedactCommandArgs keeps the program of a command line and replaces its arguments, which may hold credentials.
+// Programs whose path contains spaces are expected to be quoted, as in `"C:\Program Files\app.exe" -p secret`.
+func RedactCommandArgs(command string) string {
+	command = strings.TrimSpace(command)
+
+	var program, args string
+	if strings.HasPrefix(command, `"`) {
+		if end := strings.IndexByte(command[1:], '"'); end >= 0 {
+			program, args = command[:end+2], command[end+2:]
+		} else {
+			program = command
+		}
+	} else if i := strings.IndexAny(command, " \t"); i >= 0 {
+		program, args = command[:i], command[i:]
+	} else {
+		program = command
+	}
+
+	if strings.TrimSpace(args) == "" {
+		return program
+	}
+	return program + " " + redactedArgs
+}
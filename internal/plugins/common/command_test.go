@@ -0,0 +1,31 @@
+// Copyright New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactCommandArgs(t *testing.T) {
+	testCases := []struct {
+		command  string
+		expected string
+	}{
+		{command: "/usr/bin/backup.sh", expected: "/usr/bin/backup.sh"},
+		{command: "  /usr/bin/backup.sh  ", expected: "/usr/bin/backup.sh"},
+		{command: "mysqldump -u root -psecret db > /tmp/db.sql", expected: "mysqldump (redacted)"},
+		{command: "run-parts\t--report /etc/cron.daily", expected: "run-parts (redacted)"},
+		{command: `"C:\Program Files\Backup\backup.exe" /password secret`, expected: `"C:\Program Files\Backup\backup.exe" (redacted)`},
+		{command: `"C:\Program Files\Backup\backup.exe"`, expected: `"C:\Program Files\Backup\backup.exe"`},
+		{command: "", expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.command, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, RedactCommandArgs(testCase.command))
+		})
+	}
+}
@@ -5,6 +5,7 @@ package common
 
 import (
 	"fmt"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
 )
 
@@ -21,6 +22,7 @@ type HostInfoCommon struct {
 	cloudMonitoring bool
 	agentVersion    string
 	cloud.Harvester
+	publicIP *PublicIPResolver
 }
 
 var _ HostInfo = (*HostInfoCommon)(nil)
@@ -40,6 +42,8 @@ type HostInfoData struct {
 	AgentVersion    string `json:"agent_version"`
 	AgentName       string `json:"agent_name"`
 	OperatingSystem string `json:"operating_system"`
+	MachineID       string `json:"machine_id,omitempty"`
+	PublicIP        string `json:"public_ip,omitempty"`
 
 	// cloud metadata
 	CloudData `mapstructure:",squash"`
@@ -48,12 +52,18 @@ type HostInfoData struct {
 // NewHostInfoCommon return a new HostInfoCommon structure that implements HostInfo.
 func NewHostInfoCommon(agentVersion string, enableCloudMonitoring bool, cloudHarvester cloud.Harvester) *HostInfoCommon {
 	return &HostInfoCommon{
-		enableCloudMonitoring,
-		agentVersion,
-		cloudHarvester,
+		cloudMonitoring: enableCloudMonitoring,
+		agentVersion:    agentVersion,
+		Harvester:       cloudHarvester,
 	}
 }
 
+// WithPublicIP makes the host information report the public IP detected by the given resolver.
+func (h *HostInfoCommon) WithPublicIP(resolver *PublicIPResolver) *HostInfoCommon {
+	h.publicIP = resolver
+	return h
+}
+
 // GetHostInfo returns the common host information data agnostic to the OS.
 func (h *HostInfoCommon) GetHostInfo() (HostInfoData, error) {
 	var err error
@@ -63,6 +73,10 @@ func (h *HostInfoCommon) GetHostInfo() (HostInfoData, error) {
 		AgentVersion: h.agentVersion,
 	}
 
+	if h.publicIP != nil {
+		hostInfo.PublicIP = h.publicIP.PublicIP()
+	}
+
 	if h.cloudMonitoring {
 		hostInfo.CloudData, err = getCloudData(h)
 		if err != nil {
@@ -73,6 +87,20 @@ func (h *HostInfoCommon) GetHostInfo() (HostInfoData, error) {
 	return hostInfo, nil
 }
 
+// OverrideOS replaces the detected OS attributes by the configured overrides, if any. The OS label is the
+// distribution or platform name reported by each OS.
+func (d *HostInfoData) OverrideOS(label *string, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	if cfg.OSLabelOverride != "" {
+		*label = cfg.OSLabelOverride
+	}
+	if cfg.OSFamilyOverride != "" {
+		d.OperatingSystem = cfg.OSFamilyOverride
+	}
+}
+
 // GetCloudHostType returns the cloud host type if available, "unknown" if not.
 func (h *HostInfoCommon) GetCloudHostType() (string, error) {
 	hostType := "unknown"
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -255,3 +256,49 @@ func TestGetCloudHostType(t *testing.T) {
 		})
 	}
 }
+
+func TestHostInfoData_OverrideOS(t *testing.T) {
+	testCases := []struct {
+		name           string
+		cfg            *config.Config
+		expectedLabel  string
+		expectedFamily string
+	}{
+		{
+			name:           "no config",
+			cfg:            nil,
+			expectedLabel:  "Ubuntu 22.04.1 LTS",
+			expectedFamily: "linux",
+		},
+		{
+			name:           "no overrides keep detected values",
+			cfg:            &config.Config{},
+			expectedLabel:  "Ubuntu 22.04.1 LTS",
+			expectedFamily: "linux",
+		},
+		{
+			name:           "label override",
+			cfg:            &config.Config{OSLabelOverride: "Custom Linux 2.1"},
+			expectedLabel:  "Custom Linux 2.1",
+			expectedFamily: "linux",
+		},
+		{
+			name:           "label and family overrides",
+			cfg:            &config.Config{OSLabelOverride: "Custom OS", OSFamilyOverride: "custom"},
+			expectedLabel:  "Custom OS",
+			expectedFamily: "custom",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := HostInfoData{OperatingSystem: "linux"}
+			label := "Ubuntu 22.04.1 LTS"
+
+			data.OverrideOS(&label, tc.cfg)
+
+			assert.Equal(t, tc.expectedLabel, label)
+			assert.Equal(t, tc.expectedFamily, data.OperatingSystem)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
+)
+
+// maxReflectorResponseBytes limits the reflector response read, an IP address is way shorter.
+const maxReflectorResponseBytes = 1024
+
+var (
+	plog = log.WithComponent("PublicIPResolver")
+
+	errNoPublicIPSource = errors.New("no public IP source available")
+)
+
+// PublicIPResolver detects the public IP of the host from the cloud metadata, falling back to an external reflector
+// service, and caches it for a TTL.
+type PublicIPResolver struct {
+	lock         sync.Mutex
+	cloud        cloud.PublicIPHarvester // Not used when nil.
+	reflectorURL string                  // Not used when empty.
+	client       *http.Client
+	ttl          time.Duration
+	now          func() time.Time
+	publicIP     string
+	expiry       time.Time
+}
+
+// NewPublicIPResolver returns a PublicIPResolver querying the cloud metadata through cloudHarvester, if not nil, and
+// otherwise the reflectorURL through the client, if not empty.
+func NewPublicIPResolver(cloudHarvester cloud.PublicIPHarvester, reflectorURL string, client *http.Client, ttl time.Duration) *PublicIPResolver {
+	return &PublicIPResolver{
+		cloud:        cloudHarvester,
+		reflectorURL: reflectorURL,
+		client:       client,
+		ttl:          ttl,
+		now:          time.Now,
+	}
+}
+
+// PublicIP returns the cached public IP, detecting it again once the TTL expires. The last detected IP is kept when
+// the detection fails, empty if it never succeeded.
+func (r *PublicIPResolver) PublicIP() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.publicIP != "" && r.now().Before(r.expiry) {
+		return r.publicIP
+	}
+
+	publicIP, err := r.detect()
+	if err != nil {
+		plog.WithError(err).Debug("Cannot detect the public IP.")
+		return r.publicIP
+	}
+	r.publicIP = publicIP
+	r.expiry = r.now().Add(r.ttl)
+	return r.publicIP
+}
+
+func (r *PublicIPResolver) detect() (string, error) {
+	err := errNoPublicIPSource
+	if r.cloud != nil {
+		var publicIP string
+		if publicIP, err = r.cloud.GetPublicIP(); err == nil {
+			return publicIP, nil
+		}
+		plog.WithError(err).Debug("Public IP not available from the cloud metadata.")
+	}
+	if r.reflectorURL != "" {
+		return r.fromReflector()
+	}
+	return "", err
+}
+
+// fromReflector requests the public IP to the reflector, which answers with the caller IP in the body.
+func (r *PublicIPResolver) fromReflector() (string, error) {
+	response, err := r.client.Get(r.reflectorURL)
+	if err != nil {
+		return "", fmt.Errorf("unable to request public IP reflector: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, response.Body)
+		return "", fmt.Errorf("public IP reflector returned non-OK response: %s", response.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, maxReflectorResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("unable to read public IP reflector response: %w", err)
+	}
+	return cloud.ParsePublicIP(string(body))
+}
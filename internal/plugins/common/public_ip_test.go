@@ -0,0 +1,113 @@
+// Copyright New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePublicIPHarvester struct {
+	publicIP string
+	err      error
+	calls    int
+}
+
+func (f *fakePublicIPHarvester) GetPublicIP() (string, error) {
+	f.calls++
+	return f.publicIP, f.err
+}
+
+func newFakeReflector(t *testing.T, response string, calls *int) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		_, _ = fmt.Fprintln(w, response)
+	}))
+}
+
+func TestPublicIPResolver_CloudMetadata(t *testing.T) {
+	var reflectorCalls int
+	reflector := newFakeReflector(t, "198.51.100.1", &reflectorCalls)
+	defer reflector.Close()
+
+	harvester := &fakePublicIPHarvester{publicIP: "203.0.113.10"}
+	resolver := NewPublicIPResolver(harvester, reflector.URL, reflector.Client(), time.Hour)
+
+	assert.Equal(t, "203.0.113.10", resolver.PublicIP())
+	assert.Equal(t, 0, reflectorCalls)
+}
+
+func TestPublicIPResolver_ReflectorFallback(t *testing.T) {
+	var reflectorCalls int
+	reflector := newFakeReflector(t, "198.51.100.1", &reflectorCalls)
+	defer reflector.Close()
+
+	harvester := &fakePublicIPHarvester{err: errors.New("no public IP")}
+	resolver := NewPublicIPResolver(harvester, reflector.URL, reflector.Client(), time.Hour)
+
+	assert.Equal(t, "198.51.100.1", resolver.PublicIP())
+	assert.Equal(t, 1, reflectorCalls)
+}
+
+func TestPublicIPResolver_NoSource(t *testing.T) {
+	resolver := NewPublicIPResolver(&fakePublicIPHarvester{err: errors.New("no public IP")}, "", http.DefaultClient, time.Hour)
+
+	assert.Empty(t, resolver.PublicIP())
+}
+
+func TestPublicIPResolver_InvalidReflectorResponse(t *testing.T) {
+	var reflectorCalls int
+	reflector := newFakeReflector(t, "<html>captive portal</html>", &reflectorCalls)
+	defer reflector.Close()
+
+	resolver := NewPublicIPResolver(nil, reflector.URL, reflector.Client(), time.Hour)
+
+	assert.Empty(t, resolver.PublicIP())
+}
+
+func TestPublicIPResolver_CachesForTTL(t *testing.T) {
+	harvester := &fakePublicIPHarvester{publicIP: "203.0.113.10"}
+	resolver := NewPublicIPResolver(harvester, "", http.DefaultClient, time.Minute)
+	now := time.Now()
+	resolver.now = func() time.Time { return now }
+
+	assert.Equal(t, "203.0.113.10", resolver.PublicIP())
+	harvester.publicIP = "203.0.113.20"
+	assert.Equal(t, "203.0.113.10", resolver.PublicIP())
+	assert.Equal(t, 1, harvester.calls)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, "203.0.113.20", resolver.PublicIP())
+	assert.Equal(t, 2, harvester.calls)
+}
+
+func TestPublicIPResolver_KeepsLastIPOnFailure(t *testing.T) {
+	harvester := &fakePublicIPHarvester{publicIP: "203.0.113.10"}
+	resolver := NewPublicIPResolver(harvester, "", http.DefaultClient, time.Minute)
+	now := time.Now()
+	resolver.now = func() time.Time { return now }
+
+	assert.Equal(t, "203.0.113.10", resolver.PublicIP())
+
+	now = now.Add(time.Minute)
+	harvester.err = errors.New("metadata unavailable")
+	assert.Equal(t, "203.0.113.10", resolver.PublicIP())
+}
+
+func TestHostInfoCommon_PublicIP(t *testing.T) {
+	resolver := NewPublicIPResolver(&fakePublicIPHarvester{publicIP: "203.0.113.10"}, "", http.DefaultClient, time.Hour)
+	hostInfo := NewHostInfoCommon("test", false, nil).WithPublicIP(resolver)
+
+	data, err := hostInfo.GetHostInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "203.0.113.10", data.PublicIP)
+}
@@ -136,6 +136,7 @@ func (hip *HostinfoPlugin) gatherHostinfo(context agent.AgentContext) *HostInfoD
 	data.Ram = ho.Memory
 	data.UpSince = getUpSince()
 	data.OperatingSystem = "macOS"
+	data.OverrideOS(&data.Distro, context.Config())
 
 	helpers.LogStructureDetails(hlog, data, "HostInfoDarwin", "raw", nil)
 
@@ -0,0 +1,194 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/docker/docker/client"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+const (
+	runtimeDocker     = "docker"
+	runtimeContainerd = "containerd"
+	runtimePodman     = "podman"
+
+	// podmanEngineComponent name of the engine component in the version reported by the podman Docker-compatible API.
+	podmanEngineComponent = "Podman Engine"
+	podmanSocket          = "/run/podman/podman.sock"
+
+	containerRuntimeQueryTimeout = 10 * time.Second
+)
+
+var crlog = log.WithPlugin("ContainerRuntime")
+
+var errRuntimeNotRunning = errors.New("container runtime not running")
+
+// ContainerRuntime container runtime detected in the host.
+type ContainerRuntime struct {
+	Name       string `json:"id"`
+	Version    string `json:"version"`
+	APIVersion string `json:"api_version,omitempty"`
+	Revision   string `json:"revision,omitempty"`
+}
+
+func (r ContainerRuntime) SortKey() string {
+	return r.Name
+}
+
+// runtimeQuery returns the version of a container runtime, or errRuntimeNotRunning when it's not found in the host.
+type runtimeQuery func(ctx context.Context) (ContainerRuntime, error)
+
+// ContainerRuntimePlugin reports the version of the container runtimes running in the host: Docker, containerd and
+// podman.
+type ContainerRuntimePlugin struct {
+	agent.PluginCommon
+	frequency time.Duration
+	queries   []runtimeQuery
+}
+
+func NewContainerRuntimePlugin(id ids.PluginID, ctx agent.AgentContext) *ContainerRuntimePlugin {
+	cfg := ctx.Config()
+	return &ContainerRuntimePlugin{
+		PluginCommon: agent.PluginCommon{ID: id, Context: ctx},
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.ContainerRuntimeRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_CONTAINER_RUNTIME_UPDATES,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+		queries: []runtimeQuery{
+			dockerQuery(dockerHost(), cfg.DockerApiVersion),
+			dockerQuery(podmanHost(), cfg.DockerApiVersion),
+			containerdVersion,
+		},
+	}
+}
+
+// dockerHost returns the Docker host of the DOCKER_HOST environment variable, as used by the Docker client, or the
+// default socket when it's running.
+func dockerHost() string {
+	if host := os.Getenv(client.EnvOverrideHost); host != "" {
+		return host
+	}
+	if helpers.IsDockerRunning() {
+		return client.DefaultDockerHost
+	}
+	return ""
+}
+
+// podmanHost returns the host of the podman Docker-compatible API socket, when it's running.
+func podmanHost() string {
+	if sock, err := os.Stat(podmanSocket); err == nil && sock.Mode()&os.ModeSocket != 0 {
+		return "unix://" + podmanSocket
+	}
+	return ""
+}
+
+// dockerQuery queries the version endpoint of the Docker API at host, which is also served by podman.
+func dockerQuery(host, apiVersion string) runtimeQuery {
+	return func(ctx context.Context) (ContainerRuntime, error) {
+		if host == "" {
+			return ContainerRuntime{}, errRuntimeNotRunning
+		}
+
+		c, err := client.NewClientWithOpts(client.WithHost(host), client.WithVersion(apiVersion))
+		if err != nil {
+			return ContainerRuntime{}, fmt.Errorf("failed to initialize docker client: %w", err)
+		}
+		defer c.Close()
+
+		v, err := c.ServerVersion(ctx)
+		if err != nil {
+			return ContainerRuntime{}, err
+		}
+
+		runtime := ContainerRuntime{Name: runtimeDocker, Version: v.Version, APIVersion: v.APIVersion}
+		for _, component := range v.Components {
+			if strings.EqualFold(component.Name, podmanEngineComponent) {
+				runtime.Name = runtimePodman
+				runtime.Version = component.Version
+			}
+		}
+		return runtime, nil
+	}
+}
+
+// containerdVersion queries the version service of containerd.
+func containerdVersion(ctx context.Context) (ContainerRuntime, error) {
+	if !helpers.IsContainerdRunning() {
+		return ContainerRuntime{}, errRuntimeNotRunning
+	}
+
+	c, err := containerd.New(helpers.UnixContainerdSocket, containerd.WithTimeout(containerRuntimeQueryTimeout))
+	if err != nil {
+		return ContainerRuntime{}, fmt.Errorf("failed to initialize containerd client: %w", err)
+	}
+	defer c.Close()
+
+	v, err := c.Version(ctx)
+	if err != nil {
+		return ContainerRuntime{}, err
+	}
+	return ContainerRuntime{Name: runtimeContainerd, Version: v.Version, Revision: v.Revision}, nil
+}
+
+// readContainerRuntimes returns the runtimes found in the host. Runtimes that are not running, or that cannot be
+// queried, are not reported.
+func (p *ContainerRuntimePlugin) readContainerRuntimes() types.PluginInventoryDataset {
+	dataset := types.PluginInventoryDataset{}
+	found := map[string]bool{}
+	for _, query := range p.queries {
+		ctx, cancel := context.WithTimeout(context.Background(), containerRuntimeQueryTimeout)
+		runtime, err := query(ctx)
+		cancel()
+		if errors.Is(err, errRuntimeNotRunning) {
+			continue
+		}
+		if err != nil {
+			crlog.WithError(err).Warn("cannot query the container runtime version")
+			continue
+		}
+		// DOCKER_HOST may point to the podman socket
+		if found[runtime.Name] {
+			continue
+		}
+		found[runtime.Name] = true
+		dataset = append(dataset, runtime)
+	}
+	return dataset
+}
+
+func (p *ContainerRuntimePlugin) Run() {
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		crlog.Debug("Disabled.")
+		return
+	}
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		dataset := p.readContainerRuntimes()
+		if len(dataset) == 0 {
+			crlog.Debug("No container runtime found.")
+		}
+		p.EmitInventory(dataset, entity.NewFromNameWithoutID(p.Context.EntityKey()))
+		<-refreshTimer.C
+	}
+}
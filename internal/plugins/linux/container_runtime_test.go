@@ -0,0 +1,100 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRuntimeEndpoint serves the version of the Docker API, returning the host to query it.
+func fakeRuntimeEndpoint(t *testing.T, version string) (host string, paths *[]string) {
+	t.Helper()
+	paths = &[]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		if !strings.HasSuffix(r.URL.Path, "/version") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(version))
+	}))
+	t.Cleanup(server.Close)
+	return strings.Replace(server.URL, "http://", "tcp://", 1), paths
+}
+
+func TestContainerRuntimePlugin_Docker(t *testing.T) {
+	host, paths := fakeRuntimeEndpoint(t, `{
+		"Platform": {"Name": "Docker Engine - Community"},
+		"Components": [{"Name": "Engine", "Version": "26.1.5"}, {"Name": "containerd", "Version": "1.7.19"}],
+		"Version": "26.1.5",
+		"ApiVersion": "1.45"
+	}`)
+
+	runtime, err := dockerQuery(host, "1.24")(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, ContainerRuntime{Name: "docker", Version: "26.1.5", APIVersion: "1.45"}, runtime)
+	// the configured API version is used
+	assert.Equal(t, []string{"/v1.24/version"}, *paths)
+}
+
+func TestContainerRuntimePlugin_Podman(t *testing.T) {
+	host, _ := fakeRuntimeEndpoint(t, `{
+		"Platform": {"Name": "linux/amd64/fedora-39"},
+		"Components": [{"Name": "Podman Engine", "Version": "4.9.4"}, {"Name": "Conmon", "Version": "2.1.10"}],
+		"Version": "4.9.4",
+		"ApiVersion": "1.41"
+	}`)
+
+	runtime, err := dockerQuery(host, "1.24")(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, ContainerRuntime{Name: "podman", Version: "4.9.4", APIVersion: "1.41"}, runtime)
+}
+
+func TestContainerRuntimePlugin_NotRunning(t *testing.T) {
+	_, err := dockerQuery("", "1.24")(context.Background())
+
+	assert.True(t, errors.Is(err, errRuntimeNotRunning))
+}
+
+func TestContainerRuntimePlugin_ReadContainerRuntimes(t *testing.T) {
+	dockerHost, _ := fakeRuntimeEndpoint(t, `{"Version": "26.1.5", "ApiVersion": "1.45"}`)
+	unavailableHost, _ := fakeRuntimeEndpoint(t, `not json`)
+	p := &ContainerRuntimePlugin{
+		queries: []runtimeQuery{
+			dockerQuery(dockerHost, "1.24"),
+			// the same runtime from another host is only reported once
+			dockerQuery(dockerHost, "1.24"),
+			dockerQuery(unavailableHost, "1.24"),
+			dockerQuery("", "1.24"),
+			func(context.Context) (ContainerRuntime, error) {
+				return ContainerRuntime{Name: "containerd", Version: "v1.7.13", Revision: "7c3aca7"}, nil
+			},
+		},
+	}
+
+	assert.Equal(t, types.PluginInventoryDataset{
+		ContainerRuntime{Name: "docker", Version: "26.1.5", APIVersion: "1.45"},
+		ContainerRuntime{Name: "containerd", Version: "v1.7.13", Revision: "7c3aca7"},
+	}, p.readContainerRuntimes())
+}
+
+func TestContainerRuntimePlugin_ReadContainerRuntimes_NoRuntime(t *testing.T) {
+	p := &ContainerRuntimePlugin{queries: []runtimeQuery{dockerQuery("", "1.24")}}
+
+	assert.Empty(t, p.readContainerRuntimes())
+}
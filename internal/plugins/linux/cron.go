@@ -0,0 +1,192 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/internal/plugins/common"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+var cronlog = log.WithPlugin("Cron")
+
+// cronEnvVariable matches the environment variable definitions of a crontab, as "MAILTO=root".
+var cronEnvVariable = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s*=`)
+
+// CronPlugin reports the system cron jobs, defined in /etc/crontab and /etc/cron.d, and the user cron jobs,
+// defined in /var/spool/cron.
+type CronPlugin struct {
+	agent.PluginCommon
+	frequency  time.Duration
+	redactArgs bool
+}
+
+// CronJob command run by cron. Its id is the crontab file and line the job is defined in.
+type CronJob struct {
+	ID       string `json:"id"`
+	User     string `json:"user"`
+	Schedule string `json:"schedule"`
+	Command  string `json:"command"`
+}
+
+func (j CronJob) SortKey() string {
+	return j.ID
+}
+
+// crontab file to read cron jobs from. System crontabs define the user of each job, while user crontabs run all
+// of their jobs as the crontab owner.
+type crontab struct {
+	path string // path to read, honoring the host directory overrides
+	name string // path as reported
+	user string // owner of a user crontab, empty for system crontabs
+}
+
+func NewCronPlugin(id ids.PluginID, ctx agent.AgentContext) *CronPlugin {
+	cfg := ctx.Config()
+	return &CronPlugin{
+		PluginCommon: agent.PluginCommon{ID: id, Context: ctx},
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.ScheduledTasksRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_SCHEDULED_TASKS,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+		redactArgs: cfg.ScheduledTasksRedactArgs,
+	}
+}
+
+// isCrontabFile returns false for the hidden files and the backups left by editors and package managers, which cron
+// doesn't read.
+func isCrontabFile(name string) bool {
+	return !strings.HasPrefix(name, ".") &&
+		!strings.HasSuffix(name, "~") &&
+		!strings.Contains(name, ".dpkg-") &&
+		!strings.Contains(name, ".rpm")
+}
+
+// crontabsIn returns the crontab files of a directory, in lexical order. When userCrontabs is set, the file names
+// are the crontab owners.
+func crontabsIn(dir, reportedDir string, userCrontabs bool) (crontabs []crontab) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		cronlog.WithError(err).WithField("dir", dir).Debug("Cannot read crontabs directory.")
+		return nil
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isCrontabFile(entry.Name()) {
+			continue
+		}
+		tab := crontab{
+			path: filepath.Join(dir, entry.Name()),
+			name: path.Join(reportedDir, entry.Name()),
+		}
+		if userCrontabs {
+			tab.user = entry.Name()
+		}
+		crontabs = append(crontabs, tab)
+	}
+	sort.Slice(crontabs, func(i, j int) bool { return crontabs[i].name < crontabs[j].name })
+	return
+}
+
+// crontabs returns the system crontabs, followed by the user crontabs of both Red Hat (/var/spool/cron) and Debian
+// (/var/spool/cron/crontabs) based distributions.
+func crontabs() []crontab {
+	tabs := []crontab{{path: helpers.HostEtc("crontab"), name: "/etc/crontab"}}
+	tabs = append(tabs, crontabsIn(helpers.HostEtc("cron.d"), "/etc/cron.d", false)...)
+	tabs = append(tabs, crontabsIn(helpers.HostVar("spool/cron"), "/var/spool/cron", true)...)
+	tabs = append(tabs, crontabsIn(helpers.HostVar("spool/cron/crontabs"), "/var/spool/cron/crontabs", true)...)
+	return tabs
+}
+
+// parseCrontab returns the jobs defined in a crontab. Schedules are either five time and date fields or a
+// "@" nickname, as "@daily".
+func parseCrontab(r io.Reader, tab crontab, redactArgs bool) (jobs []CronJob, err error) {
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || cronEnvVariable.MatchString(line) {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		scheduleFields := 5
+		if strings.HasPrefix(fields[0], "@") {
+			scheduleFields = 1
+		}
+		userFields := 0
+		if tab.user == "" {
+			userFields = 1
+		}
+		if len(fields) <= scheduleFields+userFields {
+			cronlog.WithField("file", tab.name).WithField("line", lineNumber).Debug("Ignoring invalid cron job.")
+			continue
+		}
+
+		job := CronJob{
+			ID:       fmt.Sprintf("%s:%d", tab.name, lineNumber),
+			User:     tab.user,
+			Schedule: strings.Join(fields[:scheduleFields], " "),
+			Command:  strings.Join(fields[scheduleFields+userFields:], " "),
+		}
+		if tab.user == "" {
+			job.User = fields[scheduleFields]
+		}
+		if redactArgs {
+			job.Command = common.RedactCommandArgs(job.Command)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, scanner.Err()
+}
+
+func (p *CronPlugin) readCronJobs() (dataset types.PluginInventoryDataset) {
+	for _, tab := range crontabs() {
+		file, err := os.Open(tab.path)
+		if err != nil {
+			cronlog.WithError(err).WithField("file", tab.name).Debug("Cannot open crontab.")
+			continue
+		}
+		jobs, err := parseCrontab(file, tab, p.redactArgs)
+		_ = file.Close()
+		if err != nil {
+			cronlog.WithError(err).WithField("file", tab.name).Debug("Cannot read crontab.")
+		}
+		for _, job := range jobs {
+			dataset = append(dataset, job)
+		}
+	}
+	return dataset
+}
+
+func (p *CronPlugin) Run() {
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		cronlog.Debug("Disabled.")
+		return
+	}
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		p.EmitInventory(p.readCronJobs(), entity.NewFromNameWithoutID(p.Context.EntityKey()))
+		<-refreshTimer.C
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSystemCrontab = `# /etc/crontab: system-wide crontab
+SHELL=/bin/sh
+PATH=/usr/local/sbin:/usr/local/bin:/sbin:/bin:/usr/sbin:/usr/bin
+
+17 *	* * *	root    cd / && run-parts --report /etc/cron.hourly
+`
+
+const testCronD = `MAILTO = ops@example.com
+@reboot   root  /usr/local/bin/warmup
+*/5 * * * * backup /usr/bin/mysqldump -u backup -psecret app
+`
+
+const testUserCrontab = `# m h dom mon dow command
+0 3 * * 1 /home/alice/bin/report.sh --weekly
+@daily /home/alice/bin/cleanup
+`
+
+// mockCrontabs writes the given files, relative to a root directory, and points HOST_ETC and HOST_VAR to it.
+func mockCrontabs(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	root := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	t.Setenv("HOST_ETC", filepath.Join(root, "etc"))
+	t.Setenv("HOST_VAR", filepath.Join(root, "var"))
+}
+
+func TestCronPlugin_ReadCronJobs(t *testing.T) {
+	mockCrontabs(t, map[string]string{
+		"etc/crontab":                    testSystemCrontab,
+		"etc/cron.d/backup":              testCronD,
+		"etc/cron.d/backup.dpkg-old":     testCronD,
+		"etc/cron.d/.placeholder":        "",
+		"var/spool/cron/crontabs/alice":  testUserCrontab,
+		"var/spool/cron/crontabs/alice~": testUserCrontab,
+	})
+
+	tests := []struct {
+		name     string
+		redact   bool
+		expected types.PluginInventoryDataset
+	}{
+		{
+			name: "commands with arguments",
+			expected: types.PluginInventoryDataset{
+				CronJob{ID: "/etc/crontab:5", User: "root", Schedule: "17 * * * *", Command: "cd / && run-parts --report /etc/cron.hourly"},
+				CronJob{ID: "/etc/cron.d/backup:2", User: "root", Schedule: "@reboot", Command: "/usr/local/bin/warmup"},
+				CronJob{ID: "/etc/cron.d/backup:3", User: "backup", Schedule: "*/5 * * * *", Command: "/usr/bin/mysqldump -u backup -psecret app"},
+				CronJob{ID: "/var/spool/cron/crontabs/alice:2", User: "alice", Schedule: "0 3 * * 1", Command: "/home/alice/bin/report.sh --weekly"},
+				CronJob{ID: "/var/spool/cron/crontabs/alice:3", User: "alice", Schedule: "@daily", Command: "/home/alice/bin/cleanup"},
+			},
+		},
+		{
+			name:   "redacted arguments",
+			redact: true,
+			expected: types.PluginInventoryDataset{
+				CronJob{ID: "/etc/crontab:5", User: "root", Schedule: "17 * * * *", Command: "cd (redacted)"},
+				CronJob{ID: "/etc/cron.d/backup:2", User: "root", Schedule: "@reboot", Command: "/usr/local/bin/warmup"},
+				CronJob{ID: "/etc/cron.d/backup:3", User: "backup", Schedule: "*/5 * * * *", Command: "/usr/bin/mysqldump (redacted)"},
+				CronJob{ID: "/var/spool/cron/crontabs/alice:2", User: "alice", Schedule: "0 3 * * 1", Command: "/home/alice/bin/report.sh (redacted)"},
+				CronJob{ID: "/var/spool/cron/crontabs/alice:3", User: "alice", Schedule: "@daily", Command: "/home/alice/bin/cleanup"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &CronPlugin{redactArgs: tt.redact}
+			assert.Equal(t, tt.expected, p.readCronJobs())
+		})
+	}
+}
+
+func TestCronPlugin_RedHatUserCrontabs(t *testing.T) {
+	mockCrontabs(t, map[string]string{
+		"var/spool/cron/bob": "30 2 * * * /usr/bin/backup\n",
+	})
+
+	p := &CronPlugin{}
+	assert.Equal(t, types.PluginInventoryDataset{
+		CronJob{ID: "/var/spool/cron/bob:1", User: "bob", Schedule: "30 2 * * *", Command: "/usr/bin/backup"},
+	}, p.readCronJobs())
+}
+
+func TestCronPlugin_InvalidJobs(t *testing.T) {
+	mockCrontabs(t, map[string]string{
+		"etc/crontab": "* * * * * root\n@hourly\n0 * * * * root /bin/true\n",
+	})
+
+	p := &CronPlugin{}
+	assert.Equal(t, types.PluginInventoryDataset{
+		CronJob{ID: "/etc/crontab:3", User: "root", Schedule: "0 * * * *", Command: "/bin/true"},
+	}, p.readCronJobs())
+}
+
+func TestCronPlugin_NoCrontabs(t *testing.T) {
+	mockCrontabs(t, nil)
+
+	p := &CronPlugin{}
+	assert.Empty(t, p.readCronJobs())
+}
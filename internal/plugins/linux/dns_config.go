@@ -0,0 +1,125 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+var dnslog = log.WithPlugin("DnsConfig")
+
+// DnsConfigPlugin reports the host resolver configuration, as defined in /etc/resolv.conf.
+type DnsConfigPlugin struct {
+	agent.PluginCommon
+	frequency time.Duration
+}
+
+type DnsConfigValue struct {
+	Key   string `json:"id"`
+	Value string `json:"value"`
+}
+
+func (v DnsConfigValue) SortKey() string {
+	return v.Key
+}
+
+// DnsConfig resolver configuration relevant for diagnosing name resolution issues.
+type DnsConfig struct {
+	Nameservers []string
+	Search      []string
+}
+
+func NewDnsConfigPlugin(id ids.PluginID, ctx agent.AgentContext) *DnsConfigPlugin {
+	cfg := ctx.Config()
+	return &DnsConfigPlugin{
+		PluginCommon: agent.PluginCommon{ID: id, Context: ctx},
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.DnsConfigRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_DNS_CONFIG_UPDATES,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+	}
+}
+
+// parseResolvConf parses the nameservers and search domains of a resolv.conf file. As the resolver does, the last
+// "search" or "domain" entry takes precedence.
+func parseResolvConf(r io.Reader) (dnsConfig DnsConfig, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "nameserver":
+			dnsConfig.Nameservers = append(dnsConfig.Nameservers, fields[1])
+		case "search":
+			dnsConfig.Search = fields[1:]
+		case "domain":
+			dnsConfig.Search = fields[1:2]
+		}
+	}
+
+	return dnsConfig, scanner.Err()
+}
+
+func (d DnsConfig) dataset() (dataset types.PluginInventoryDataset) {
+	if len(d.Nameservers) > 0 {
+		dataset = append(dataset, DnsConfigValue{"nameservers", strings.Join(d.Nameservers, " ")})
+	}
+	if len(d.Search) > 0 {
+		dataset = append(dataset, DnsConfigValue{"search", strings.Join(d.Search, " ")})
+	}
+	return
+}
+
+func readDnsConfig() (DnsConfig, error) {
+	file, err := os.Open(helpers.HostEtc("resolv.conf"))
+	if err != nil {
+		return DnsConfig{}, err
+	}
+	defer file.Close()
+
+	return parseResolvConf(file)
+}
+
+func (p *DnsConfigPlugin) Run() {
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		dnslog.Debug("Disabled.")
+		return
+	}
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		dnsConfig, err := readDnsConfig()
+		if err != nil {
+			dnslog.WithError(err).Error("reading resolver config file")
+			p.Unregister()
+			return
+		}
+		p.EmitInventory(dnsConfig.dataset(), entity.NewFromNameWithoutID(p.Context.EntityKey()))
+		<-refreshTimer.C
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDnsConfig(t *testing.T) {
+	etcDir := t.TempDir()
+	resolvConf := `# Generated by NetworkManager
+search corp.example.com example.com
+; legacy comment
+nameserver 10.0.0.2
+nameserver   8.8.8.8
+options edns0 trust-ad
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(etcDir, "resolv.conf"), []byte(resolvConf), 0o644))
+
+	hostEtc := os.Getenv("HOST_ETC")
+	defer os.Setenv("HOST_ETC", hostEtc)
+	_ = os.Setenv("HOST_ETC", etcDir)
+
+	dnsConfig, err := readDnsConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, DnsConfig{
+		Nameservers: []string{"10.0.0.2", "8.8.8.8"},
+		Search:      []string{"corp.example.com", "example.com"},
+	}, dnsConfig)
+	assert.Equal(t, types.PluginInventoryDataset{
+		DnsConfigValue{"nameservers", "10.0.0.2 8.8.8.8"},
+		DnsConfigValue{"search", "corp.example.com example.com"},
+	}, dnsConfig.dataset())
+}
+
+func TestReadDnsConfig_Domain(t *testing.T) {
+	etcDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(etcDir, "resolv.conf"), []byte("domain example.com\nnameserver 127.0.0.53\n"), 0o644))
+
+	hostEtc := os.Getenv("HOST_ETC")
+	defer os.Setenv("HOST_ETC", hostEtc)
+	_ = os.Setenv("HOST_ETC", etcDir)
+
+	dnsConfig, err := readDnsConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, DnsConfig{Nameservers: []string{"127.0.0.53"}, Search: []string{"example.com"}}, dnsConfig)
+}
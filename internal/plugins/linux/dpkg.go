@@ -36,7 +36,8 @@ var dpkglog = log.WithPlugin("Dpkg")
 
 type DpkgPlugin struct {
 	agent.PluginCommon
-	frequency time.Duration
+	frequency          time.Duration
+	normalizedPackages bool
 }
 
 type DpkgItem struct {
@@ -63,6 +64,7 @@ func NewDpkgPlugin(id ids.PluginID, ctx agent.AgentContext) agent.Plugin {
 			config.FREQ_PLUGIN_PACKAGE_MGRS_UPDATES,
 			cfg.DisableAllPlugins,
 		) * time.Second,
+		normalizedPackages: cfg.PackagesNormalizedInventory,
 	}
 }
 
@@ -95,10 +97,14 @@ func (self *DpkgPlugin) guessInstallTime(packageName string, arch string) string
 	return fmt.Sprintf("%d", ctime.Unix())
 }
 
-func (self *DpkgPlugin) fetchPackageInfo() (packages types.PluginInventoryDataset, err error) {
+// fetchPackageInfo returns the dpkg packages and, when enabled, the normalized packages.
+func (self *DpkgPlugin) fetchPackageInfo() (packages, normalized types.PluginInventoryDataset, err error) {
 	output, err := helpers.RunCommand("/usr/bin/dpkg-query", "", "-W", "-f=${Package} ${Status} ${Architecture} ${Version} ${Essential} ${Priority}\n")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if self.normalizedPackages {
+		normalized = normalizeDpkgPackages(output)
 	}
 
 	scanner := bufio.NewScanner(strings.NewReader(output))
@@ -169,11 +175,14 @@ func (self *DpkgPlugin) Run() {
 			ticker.Stop()
 			ticker = time.NewTicker(self.frequency)
 			if counter > 0 {
-				data, err := self.fetchPackageInfo()
+				data, normalized, err := self.fetchPackageInfo()
 				if err != nil {
 					dpkglog.WithError(err).Error("fetching dpkg data")
 				} else {
 					self.EmitInventory(data, entity.NewFromNameWithoutID(self.Context.EntityKey()))
+					if self.normalizedPackages {
+						self.Context.SendData(types.NewPluginOutput(DpkgPackagesPluginID, entity.NewFromNameWithoutID(self.Context.EntityKey()), normalized))
+					}
 				}
 				counter = 0
 			}
@@ -0,0 +1,288 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+var fwrlog = log.WithPlugin("FirewallRules")
+
+// firewallCommandTimeout maximum time a firewall backend command is given to list the rules, as it might hang waiting
+// for the xtables lock.
+const firewallCommandTimeout = 30 * time.Second
+
+// FirewallChain summary of the rules of a firewall chain. Rules are only reported when configured to.
+type FirewallChain struct {
+	ID        string `json:"id"`
+	Backend   string `json:"backend"`
+	Family    string `json:"family,omitempty"`
+	Table     string `json:"table"`
+	Chain     string `json:"chain"`
+	Policy    string `json:"policy,omitempty"`
+	RuleCount int    `json:"rule_count"`
+	Rules     string `json:"rules,omitempty"`
+}
+
+func (c FirewallChain) SortKey() string {
+	return c.ID
+}
+
+// firewallBackend knows how to list the rules of a firewall backend.
+type firewallBackend struct {
+	name    string
+	command string
+	args    []string
+	// tablesFile proc file listing the loaded tables. When set, the backend is only queried if any table is loaded,
+	// so listing the rules doesn't load the kernel modules of an unused backend.
+	tablesFile string
+	parse      func(backend string, output string) []FirewallChain
+}
+
+// firewallBackends supported firewall backends.
+var firewallBackends = []firewallBackend{
+	{name: "iptables", command: "iptables-save", tablesFile: "ip_tables_names", parse: parseIptablesSave},
+	{name: "ip6tables", command: "ip6tables-save", tablesFile: "ip6_tables_names", parse: parseIptablesSave},
+	{name: "nftables", command: "nft", args: []string{"list", "ruleset"}, parse: parseNftRuleset},
+}
+
+// FirewallRulesPlugin reports the number of rules of each iptables and nftables chain, so firewall changes can be
+// detected.
+type FirewallRulesPlugin struct {
+	agent.PluginCommon
+	frequency   time.Duration
+	reportRules bool
+	timeout     time.Duration
+	lookPath    func(file string) (string, error)
+	runCommand  func(ctx context.Context, command string, stdin string, arguments ...string) (string, error)
+}
+
+func NewFirewallRulesPlugin(id ids.PluginID, ctx agent.AgentContext) *FirewallRulesPlugin {
+	cfg := ctx.Config()
+	return &FirewallRulesPlugin{
+		PluginCommon: agent.PluginCommon{ID: id, Context: ctx},
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.FirewallRulesRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_FIREWALL_RULES_UPDATES,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+		reportRules: cfg.FirewallRulesReportText,
+		timeout:     firewallCommandTimeout,
+		lookPath:    exec.LookPath,
+		runCommand:  helpers.RunCommandContext,
+	}
+}
+
+// parseIptablesSave parses the output of "iptables-save", where each table starts with "*<table>", chains are
+// declared as ":<chain> <policy> [<packets>:<bytes>]" and rules are appended as "-A <chain> <rule>".
+func parseIptablesSave(backend string, output string) (chains []FirewallChain) {
+	var table string
+	index := map[string]int{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "*"):
+			table = strings.TrimPrefix(line, "*")
+		case strings.HasPrefix(line, ":"):
+			fields := strings.Fields(strings.TrimPrefix(line, ":"))
+			if len(fields) == 0 {
+				continue
+			}
+			chain := FirewallChain{Backend: backend, Table: table, Chain: fields[0]}
+			// user defined chains have no policy
+			if len(fields) > 1 && fields[1] != "-" {
+				chain.Policy = fields[1]
+			}
+			chain.ID = fmt.Sprintf("%s/%s/%s", backend, table, chain.Chain)
+			index[chain.ID] = len(chains)
+			chains = append(chains, chain)
+		case strings.HasPrefix(line, "-A "):
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			if i, ok := index[fmt.Sprintf("%s/%s/%s", backend, table, fields[1])]; ok {
+				chains[i].RuleCount++
+				chains[i].Rules = appendRule(chains[i].Rules, line)
+			}
+		}
+	}
+	return
+}
+
+// parseNftRuleset parses the output of "nft list ruleset", where the rules of each chain are listed, one per line,
+// within the braces of the chain, within the braces of its table:
+//
+//	table <family> <table> {
+//		chain <chain> {
+//			type filter hook input priority filter; policy accept;
+//			<rule>
+//		}
+//	}
+//
+// Sets, maps and other table objects are skipped. Rules spanning several lines, like long anonymous sets, are
+// counted once.
+func parseNftRuleset(backend string, output string) (chains []FirewallChain) {
+	var family, table string
+	var chain *FirewallChain
+	depth := 0
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		opened := strings.Count(line, "{") - strings.Count(line, "}")
+		fields := strings.Fields(line)
+
+		switch {
+		case depth == 0 && fields[0] == "table" && len(fields) >= 4:
+			family, table = fields[1], fields[2]
+		case depth == 1 && fields[0] == "chain" && len(fields) >= 2:
+			chain = &FirewallChain{
+				ID:      fmt.Sprintf("%s/%s/%s/%s", backend, family, table, fields[1]),
+				Backend: backend,
+				Family:  family,
+				Table:   table,
+				Chain:   fields[1],
+			}
+		case depth == 2 && chain != nil && line == "}":
+			chains = append(chains, *chain)
+			chain = nil
+		case depth == 2 && chain != nil && (fields[0] == "type" || fields[0] == "policy"):
+			chain.Policy = nftChainPolicy(line)
+		case depth == 2 && chain != nil:
+			chain.RuleCount++
+			chain.Rules = appendRule(chain.Rules, line)
+		case depth > 2 && chain != nil:
+			// continuation of a multi-line rule
+			chain.Rules += " " + line
+		}
+		depth += opened
+	}
+	return
+}
+
+// nftChainPolicy returns the policy of a base chain from its "type ...; policy <policy>;" statement.
+func nftChainPolicy(statement string) string {
+	for _, part := range strings.Split(statement, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 2 && fields[0] == "policy" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+func appendRule(rules string, rule string) string {
+	if rules == "" {
+		return rule
+	}
+	return rules + "\n" + rule
+}
+
+// backends returns the firewall backends present in the host.
+func (p *FirewallRulesPlugin) backends() (backends []firewallBackend) {
+	for _, backend := range firewallBackends {
+		if _, err := p.lookPath(backend.command); err != nil {
+			continue
+		}
+		backends = append(backends, backend)
+	}
+	return
+}
+
+// inUse returns false when the tables of the backend are not loaded.
+func (p *FirewallRulesPlugin) inUse(backend firewallBackend) bool {
+	if backend.tablesFile == "" {
+		return true
+	}
+	tables, err := os.ReadFile(helpers.HostProc("net", backend.tablesFile))
+	return err == nil && strings.TrimSpace(string(tables)) != ""
+}
+
+// listRules returns the output of the backend command, which is killed if it doesn't list the rules before the plugin
+// timeout.
+func (p *FirewallRulesPlugin) listRules(backend firewallBackend) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	output, err := p.runCommand(ctx, backend.command, "", backend.args...)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		fwrlog.WithField("backend", backend.name).WithField("timeout", p.timeout).
+			Warn("Listing firewall rules timed out, skipping the backend.")
+		return "", fmt.Errorf("%s timed out after %s", backend.command, p.timeout)
+	}
+	return output, err
+}
+
+func (p *FirewallRulesPlugin) dataset(backends []firewallBackend) (dataset types.PluginInventoryDataset, err error) {
+	var listed, failed int
+	for _, backend := range backends {
+		if !p.inUse(backend) {
+			continue
+		}
+		listed++
+		output, err := p.listRules(backend)
+		if err != nil {
+			fwrlog.WithError(err).WithField("backend", backend.name).Debug("Can't list firewall rules.")
+			failed++
+			continue
+		}
+		for _, chain := range backend.parse(backend.name, output) {
+			if !p.reportRules {
+				chain.Rules = ""
+			}
+			dataset = append(dataset, chain)
+		}
+	}
+	if failed > 0 && failed == listed {
+		return nil, fmt.Errorf("cannot list the rules of any firewall backend")
+	}
+	return dataset, nil
+}
+
+func (p *FirewallRulesPlugin) Run() {
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		fwrlog.Debug("Disabled.")
+		return
+	}
+
+	backends := p.backends()
+	if len(backends) == 0 {
+		fwrlog.Debug("No supported firewall backend found, disabling plugin.")
+		p.Unregister()
+		return
+	}
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		dataset, err := p.dataset(backends)
+		if err != nil {
+			fwrlog.WithError(err).Error("listing firewall rules")
+		} else {
+			p.EmitInventory(dataset, entity.NewFromNameWithoutID(p.Context.EntityKey()))
+		}
+		<-refreshTimer.C
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const iptablesSaveOutput = `# Generated by iptables-save v1.8.7 on Mon Oct 12 10:00:00 2026
+*filter
+:INPUT DROP [120:9600]
+:FORWARD DROP [0:0]
+:OUTPUT ACCEPT [3400:250000]
+:DOCKER - [0:0]
+-A INPUT -i lo -j ACCEPT
+-A INPUT -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 22 -j ACCEPT
+-A FORWARD -o docker0 -j DOCKER
+COMMIT
+# Completed on Mon Oct 12 10:00:00 2026
+*nat
+:PREROUTING ACCEPT [0:0]
+:POSTROUTING ACCEPT [0:0]
+-A POSTROUTING -s 172.17.0.0/16 ! -o docker0 -j MASQUERADE
+COMMIT
+`
+
+const nftRulesetOutput = `table inet filter {
+	set allowed_ports {
+		type inet_service
+		elements = { 22, 443 }
+	}
+
+	chain input {
+		type filter hook input priority filter; policy drop;
+		iif "lo" accept
+		ct state established,related accept
+		tcp dport { 22, 80,
+			    443, 8080 } accept
+	}
+
+	chain forward {
+		type filter hook forward priority filter; policy drop;
+	}
+
+	chain services {
+		tcp dport @allowed_ports accept
+	}
+}
+table ip nat {
+	chain postrouting {
+		type nat hook postrouting priority srcnat; policy accept;
+		oifname "eth0" masquerade
+	}
+}
+`
+
+func TestParseIptablesSave(t *testing.T) {
+	chains := parseIptablesSave("iptables", iptablesSaveOutput)
+
+	assert.Equal(t, []FirewallChain{
+		{ID: "iptables/filter/INPUT", Backend: "iptables", Table: "filter", Chain: "INPUT", Policy: "DROP", RuleCount: 3,
+			Rules: "-A INPUT -i lo -j ACCEPT\n-A INPUT -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT\n-A INPUT -p tcp -m tcp --dport 22 -j ACCEPT"},
+		{ID: "iptables/filter/FORWARD", Backend: "iptables", Table: "filter", Chain: "FORWARD", Policy: "DROP", RuleCount: 1,
+			Rules: "-A FORWARD -o docker0 -j DOCKER"},
+		{ID: "iptables/filter/OUTPUT", Backend: "iptables", Table: "filter", Chain: "OUTPUT", Policy: "ACCEPT"},
+		{ID: "iptables/filter/DOCKER", Backend: "iptables", Table: "filter", Chain: "DOCKER"},
+		{ID: "iptables/nat/PREROUTING", Backend: "iptables", Table: "nat", Chain: "PREROUTING", Policy: "ACCEPT"},
+		{ID: "iptables/nat/POSTROUTING", Backend: "iptables", Table: "nat", Chain: "POSTROUTING", Policy: "ACCEPT", RuleCount: 1,
+			Rules: "-A POSTROUTING -s 172.17.0.0/16 ! -o docker0 -j MASQUERADE"},
+	}, chains)
+}
+
+func TestParseNftRuleset(t *testing.T) {
+	chains := parseNftRuleset("nftables", nftRulesetOutput)
+
+	counts := map[string]int{}
+	policies := map[string]string{}
+	for _, chain := range chains {
+		counts[chain.ID] = chain.RuleCount
+		policies[chain.ID] = chain.Policy
+	}
+	assert.Equal(t, map[string]int{
+		"nftables/inet/filter/input":    3,
+		"nftables/inet/filter/forward":  0,
+		"nftables/inet/filter/services": 1,
+		"nftables/ip/nat/postrouting":   1,
+	}, counts)
+	assert.Equal(t, map[string]string{
+		"nftables/inet/filter/input":    "drop",
+		"nftables/inet/filter/forward":  "drop",
+		"nftables/inet/filter/services": "",
+		"nftables/ip/nat/postrouting":   "accept",
+	}, policies)
+
+	require.Len(t, chains, 4)
+	assert.Equal(t, "inet", chains[0].Family)
+	assert.Equal(t, "filter", chains[0].Table)
+	assert.Equal(t, "iif \"lo\" accept\nct state established,related accept\ntcp dport { 22, 80, 443, 8080 } accept", chains[0].Rules)
+}
+
+func TestFirewallRulesPlugin_Dataset(t *testing.T) {
+	procDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(procDir, "net"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(procDir, "net", "ip_tables_names"), []byte("nat\nfilter\n"), 0o644))
+	// ip6tables tables are not loaded, so they are not listed
+	require.NoError(t, os.WriteFile(filepath.Join(procDir, "net", "ip6_tables_names"), nil, 0o644))
+	t.Setenv("HOST_PROC", procDir)
+
+	tests := []struct {
+		name        string
+		reportRules bool
+		rules       string
+	}{
+		{name: "counts only"},
+		{name: "with rules", reportRules: true, rules: "oifname \"eth0\" masquerade"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var listed []string
+			p := &FirewallRulesPlugin{
+				reportRules: tt.reportRules,
+				timeout:     time.Minute,
+				lookPath: func(file string) (string, error) {
+					return "/usr/sbin/" + file, nil
+				},
+				runCommand: func(_ context.Context, command string, stdin string, arguments ...string) (string, error) {
+					listed = append(listed, command)
+					switch command {
+					case "iptables-save":
+						return iptablesSaveOutput, nil
+					case "nft":
+						assert.Equal(t, []string{"list", "ruleset"}, arguments)
+						return "table ip nat {\n\tchain postrouting {\n\t\toifname \"eth0\" masquerade\n\t}\n}\n", nil
+					}
+					return "", errors.New("unexpected command")
+				},
+			}
+
+			dataset, err := p.dataset(p.backends())
+			require.NoError(t, err)
+			assert.Equal(t, []string{"iptables-save", "nft"}, listed)
+			require.Len(t, dataset, 7)
+			for _, item := range dataset[:6] {
+				assert.Equal(t, "iptables", item.(FirewallChain).Backend)
+				if !tt.reportRules {
+					assert.Empty(t, item.(FirewallChain).Rules)
+				}
+			}
+			assert.Equal(t, FirewallChain{
+				ID:        "nftables/ip/nat/postrouting",
+				Backend:   "nftables",
+				Family:    "ip",
+				Table:     "nat",
+				Chain:     "postrouting",
+				RuleCount: 1,
+				Rules:     tt.rules,
+			}, dataset[6])
+		})
+	}
+}
+
+func TestFirewallRulesPlugin_CommandError(t *testing.T) {
+	p := &FirewallRulesPlugin{
+		timeout: time.Minute,
+		runCommand: func(_ context.Context, command string, stdin string, arguments ...string) (string, error) {
+			return "", errors.New("permission denied")
+		},
+	}
+
+	_, err := p.dataset([]firewallBackend{firewallBackends[2]})
+	assert.Error(t, err)
+}
+
+func TestFirewallRulesPlugin_CommandTimeout(t *testing.T) {
+	p := &FirewallRulesPlugin{
+		timeout: 10 * time.Millisecond,
+		runCommand: func(ctx context.Context, command string, stdin string, arguments ...string) (string, error) {
+			if command == "nft" {
+				return "table ip nat {\n\tchain postrouting {\n\t}\n}\n", nil
+			}
+			// iptables waiting for the xtables lock
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+
+	// the rules of the backends that didn't time out are still reported
+	iptables := firewallBackend{name: "iptables", command: "iptables-save", parse: parseIptablesSave}
+	dataset, err := p.dataset([]firewallBackend{iptables, firewallBackends[2]})
+	require.NoError(t, err)
+	require.Len(t, dataset, 1)
+	assert.Equal(t, "nftables", dataset[0].(FirewallChain).Backend)
+}
+
+func TestFirewallRulesPlugin_NoBackend(t *testing.T) {
+	p := &FirewallRulesPlugin{
+		lookPath: func(file string) (string, error) {
+			return "", exec.ErrNotFound
+		},
+	}
+
+	assert.Empty(t, p.backends())
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+var fwlog = log.WithPlugin("Firmware")
+
+var errNoDMIData = errors.New("no DMI data available")
+
+// FirmwarePlugin reports the BIOS and board information from the DMI data.
+type FirmwarePlugin struct {
+	agent.PluginCommon
+	frequency time.Duration
+}
+
+// FirmwareComponent BIOS or board information. Fields that cannot be read are left empty.
+type FirmwareComponent struct {
+	Component string `json:"id"`
+	Vendor    string `json:"vendor,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Serial    string `json:"serial,omitempty"`
+}
+
+func (c FirmwareComponent) SortKey() string {
+	return c.Component
+}
+
+func NewFirmwarePlugin(id ids.PluginID, ctx agent.AgentContext) *FirmwarePlugin {
+	cfg := ctx.Config()
+	return &FirmwarePlugin{
+		PluginCommon: agent.PluginCommon{ID: id, Context: ctx},
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.FirmwareRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_FIRMWARE_UPDATES,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+	}
+}
+
+// readDMIField returns the content of a DMI id file. Missing files and the ones only readable by root, such as the
+// serial numbers, are reported as empty.
+func readDMIField(name string) string {
+	value, err := os.ReadFile(helpers.HostSys("/class/dmi/id", name))
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			fwlog.WithField("field", name).Debug("Not enough permissions to read DMI field.")
+		}
+		return ""
+	}
+	return strings.TrimSpace(string(value))
+}
+
+func (p *FirmwarePlugin) readFirmware() (types.PluginInventoryDataset, error) {
+	components := []FirmwareComponent{
+		{
+			Component: "bios",
+			Vendor:    readDMIField("bios_vendor"),
+			Version:   readDMIField("bios_version"),
+			Date:      readDMIField("bios_date"),
+		},
+		{
+			Component: "board",
+			Vendor:    readDMIField("board_vendor"),
+			Name:      readDMIField("board_name"),
+			Version:   readDMIField("board_version"),
+			Serial:    readDMIField("board_serial"),
+		},
+	}
+
+	var dataset types.PluginInventoryDataset
+	for _, component := range components {
+		if (component != FirmwareComponent{Component: component.Component}) {
+			dataset = append(dataset, component)
+		}
+	}
+
+	if len(dataset) == 0 {
+		return nil, errNoDMIData
+	}
+
+	return dataset, nil
+}
+
+func (p *FirmwarePlugin) Run() {
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		fwlog.Debug("Disabled.")
+		return
+	}
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		dataset, err := p.readFirmware()
+		if err != nil {
+			fwlog.WithError(err).Warn("reading firmware information")
+			p.Unregister()
+			return
+		}
+		p.EmitInventory(dataset, entity.NewFromNameWithoutID(p.Context.EntityKey()))
+		<-refreshTimer.C
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirmwarePlugin_ReadFirmware(t *testing.T) {
+	mockSys(t, map[string]string{
+		"class/dmi/id/bios_vendor":   "American Megatrends Inc.",
+		"class/dmi/id/bios_version":  "F.42",
+		"class/dmi/id/bios_date":     "03/15/2023",
+		"class/dmi/id/board_vendor":  "ASUSTeK COMPUTER INC.",
+		"class/dmi/id/board_name":    "PRIME B450M-A",
+		"class/dmi/id/board_version": "Rev X.0x",
+		"class/dmi/id/board_serial":  "190436654300342",
+	})
+
+	p := &FirmwarePlugin{}
+	dataset, err := p.readFirmware()
+	require.NoError(t, err)
+	assert.Equal(t, types.PluginInventoryDataset{
+		FirmwareComponent{Component: "bios", Vendor: "American Megatrends Inc.", Version: "F.42", Date: "03/15/2023"},
+		FirmwareComponent{Component: "board", Vendor: "ASUSTeK COMPUTER INC.", Name: "PRIME B450M-A", Version: "Rev X.0x", Serial: "190436654300342"},
+	}, dataset)
+}
+
+func TestFirmwarePlugin_ReadFirmware_RestrictedFields(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+
+	mockSys(t, map[string]string{
+		"class/dmi/id/bios_vendor":  "SeaBIOS",
+		"class/dmi/id/board_name":   "Standard PC",
+		"class/dmi/id/board_serial": "restricted",
+	})
+	require.NoError(t, os.Chmod(filepath.Join(os.Getenv("HOST_SYS"), "class/dmi/id/board_serial"), 0o000))
+
+	p := &FirmwarePlugin{}
+	dataset, err := p.readFirmware()
+	require.NoError(t, err)
+	assert.Equal(t, types.PluginInventoryDataset{
+		FirmwareComponent{Component: "bios", Vendor: "SeaBIOS"},
+		FirmwareComponent{Component: "board", Name: "Standard PC"},
+	}, dataset)
+}
+
+func TestFirmwarePlugin_ReadFirmware_NoDMIData(t *testing.T) {
+	mockSys(t, nil)
+
+	p := &FirmwarePlugin{}
+	_, err := p.readFirmware()
+	assert.ErrorIs(t, err, errNoDMIData)
+}
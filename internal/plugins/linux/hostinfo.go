@@ -16,6 +16,7 @@ import (
 	"regexp"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/newrelic/infrastructure-agent/internal/os/distro"
@@ -47,6 +48,9 @@ type HostInfoLinux struct {
 	AgentMode           string `json:"agent_mode"`
 	ProductUuid         string `json:"product_uuid"`
 	BootId              string `json:"boot_id"`
+	Virtualization      string `json:"virtualization,omitempty"`
+	Locale              string `json:"locale,omitempty"`
+	Charset             string `json:"charset,omitempty"`
 	common.HostInfoData `mapstructure:",squash"`
 }
 
@@ -209,12 +213,37 @@ func (self *HostinfoPlugin) gatherHostinfo(context agent.AgentContext) *HostInfo
 	data.Ram = readProcFile(helpers.HostProc("/meminfo"), regexp.MustCompile(`MemTotal:\s*`))
 	data.UpSince = getUpSince()
 	data.OperatingSystem = runtime.GOOS
+	data.OverrideOS(&data.Distro, context.Config())
+
+	if context.Config() != nil && context.Config().EnableVirtualizationDetection {
+		data.Virtualization = detectVirtualization(data.CloudType())
+	}
+
+	if context.Config() != nil && context.Config().EnableMachineID {
+		data.MachineID = getMachineID()
+	}
+
+	if context.Config() != nil && context.Config().EnableHostLocale {
+		data.Locale, data.Charset = getLocale()
+	}
 
 	helpers.LogStructureDetails(hlog, data, "HostInfoData", "raw", nil)
 
 	return data
 }
 
+// getMachineID returns the systemd machine id, falling back to the D-Bus one on systems without systemd.
+func getMachineID() string {
+	for _, path := range []string{helpers.HostEtc("machine-id"), helpers.HostVar("lib/dbus/machine-id")} {
+		machineID, err := fs.ReadFirstLine(path)
+		if err == nil && strings.TrimSpace(machineID) != "" {
+			return strings.TrimSpace(machineID)
+		}
+	}
+	hlog.Debug("Cannot read machine id.")
+	return ""
+}
+
 func (self *HostinfoPlugin) getHostType() string {
 	manufacturer, err := fs.ReadFirstLine(helpers.HostSys("/devices/virtual/dmi/id/sys_vendor"))
 	if err != nil {
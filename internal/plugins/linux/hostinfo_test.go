@@ -9,6 +9,7 @@ import (
 	"github.com/newrelic/infrastructure-agent/internal/plugins/common"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 	"time"
@@ -17,8 +18,10 @@ import (
 	"github.com/newrelic/infrastructure-agent/internal/os/fs"
 	testing2 "github.com/newrelic/infrastructure-agent/internal/plugins/testing"
 
+	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	. "github.com/newrelic/infrastructure-agent/pkg/go-better-check"
 	. "gopkg.in/check.v1"
@@ -43,7 +46,7 @@ func (s *HostinfoSuite) SetUpTest(c *C) {
 }
 
 func (s *HostinfoSuite) NewPlugin(c *C) *HostinfoPlugin {
-	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false)
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
 	v := NewHostinfoPlugin(s.agent, common.NewHostInfoCommon("test", true, cloudDetector))
 
 	plugin, ok := v.(*HostinfoPlugin)
@@ -73,7 +76,7 @@ func (s *HostinfoSuite) TestGetDistro(c *C) {
 	name := distro.GetDistro()
 	c.Check(name, Not(Equals), "")
 
-	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false)
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
 
 	v := NewHostinfoPlugin(s.agent, common.NewHostInfoCommon("test", true, cloudDetector))
 	plugin, ok := v.(*HostinfoPlugin)
@@ -85,6 +88,59 @@ func (s *HostinfoSuite) TestGetDistro(c *C) {
 	c.Assert(hostInfo.Distro, HasPrefix, name)
 }
 
+func (s *HostinfoSuite) TestOSOverrides(c *C) {
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
+	s.agent.WithConfig(&config.Config{
+		OSLabelOverride:  "Custom Linux 2.1",
+		OSFamilyOverride: "custom",
+	})
+
+	v := NewHostinfoPlugin(s.agent, common.NewHostInfoCommon("test", true, cloudDetector))
+	plugin, ok := v.(*HostinfoPlugin)
+	c.Assert(ok, Equals, true)
+	data := plugin.Data()
+	c.Assert(data, HasLen, 1)
+	hostInfo, ok := data[0].(*HostInfoLinux)
+	c.Assert(ok, Equals, true)
+	c.Assert(hostInfo.Distro, Equals, "Custom Linux 2.1")
+	c.Assert(hostInfo.OperatingSystem, Equals, "custom")
+}
+
+func (s *HostinfoSuite) TestMachineID(c *C) {
+	etcDir := c.MkDir()
+	c.Assert(ioutil.WriteFile(filepath.Join(etcDir, "machine-id"), []byte("b08dfa6083e7567a1921a715000001fb\n"), 0644), IsNil)
+	hostEtc := os.Getenv("HOST_ETC")
+	defer os.Setenv("HOST_ETC", hostEtc)
+	c.Assert(os.Setenv("HOST_ETC", etcDir), IsNil)
+
+	for _, enabled := range []bool{true, false} {
+		s.agent.WithConfig(&config.Config{EnableMachineID: enabled})
+		cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
+		v := NewHostinfoPlugin(s.agent, common.NewHostInfoCommon("test", true, cloudDetector))
+		plugin, ok := v.(*HostinfoPlugin)
+		c.Assert(ok, Equals, true)
+		data := plugin.Data()
+		c.Assert(data, HasLen, 1)
+		hostInfo, ok := data[0].(*HostInfoLinux)
+		c.Assert(ok, Equals, true)
+		if enabled {
+			c.Assert(hostInfo.MachineID, Equals, "b08dfa6083e7567a1921a715000001fb")
+		} else {
+			c.Assert(hostInfo.MachineID, Equals, "")
+		}
+	}
+}
+
+func TestGetMachineID_DBusFallback(t *testing.T) {
+	varDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(varDir, "lib", "dbus"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(varDir, "lib", "dbus", "machine-id"), []byte("4c4c4544004e3510\n"), 0644))
+	t.Setenv("HOST_ETC", t.TempDir())
+	t.Setenv("HOST_VAR", varDir)
+
+	assert.Equal(t, "4c4c4544004e3510", getMachineID())
+}
+
 func (s *HostinfoSuite) TestGetTotalCpu(c *C) {
 	err := ioutil.WriteFile("/tmp/cpuinfo", []byte(cpuinfo), 0644)
 	c.Assert(err, IsNil)
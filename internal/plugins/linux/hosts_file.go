@@ -0,0 +1,137 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+const redactedAddress = "(redacted)"
+
+var hostslog = log.WithPlugin("HostsFile")
+
+// HostsFilePlugin reports the static host mappings defined in /etc/hosts.
+type HostsFilePlugin struct {
+	agent.PluginCommon
+	frequency       time.Duration
+	redactAddresses bool
+}
+
+// HostsFileEntry addresses a hostname or alias is mapped to.
+type HostsFileEntry struct {
+	Hostname string `json:"id"`
+	Address  string `json:"address"`
+}
+
+func (e HostsFileEntry) SortKey() string {
+	return e.Hostname
+}
+
+func NewHostsFilePlugin(id ids.PluginID, ctx agent.AgentContext) *HostsFilePlugin {
+	cfg := ctx.Config()
+	return &HostsFilePlugin{
+		PluginCommon: agent.PluginCommon{ID: id, Context: ctx},
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.HostsFileRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_HOSTS_FILE_UPDATES,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+		redactAddresses: cfg.HostsFileRedactAddresses,
+	}
+}
+
+// parseHostsFile returns an entry per hostname or alias. Hostnames mapped to several addresses (e.g. localhost to
+// both 127.0.0.1 and ::1) are reported with their addresses separated by spaces.
+func parseHostsFile(r io.Reader, redactAddresses bool) (dataset types.PluginInventoryDataset, err error) {
+	var hostnames []string
+	addresses := map[string][]string{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		address := fields[0]
+		if redactAddresses {
+			address = redactedAddress
+		}
+		for _, hostname := range fields[1:] {
+			if _, ok := addresses[hostname]; !ok {
+				hostnames = append(hostnames, hostname)
+			}
+			if !containsString(addresses[hostname], address) {
+				addresses[hostname] = append(addresses[hostname], address)
+			}
+		}
+	}
+
+	for _, hostname := range hostnames {
+		dataset = append(dataset, HostsFileEntry{
+			Hostname: hostname,
+			Address:  strings.Join(addresses[hostname], " "),
+		})
+	}
+
+	return dataset, scanner.Err()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *HostsFilePlugin) readHostsFile() (types.PluginInventoryDataset, error) {
+	file, err := os.Open(helpers.HostEtc("hosts"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseHostsFile(file, p.redactAddresses)
+}
+
+func (p *HostsFilePlugin) Run() {
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		hostslog.Debug("Disabled.")
+		return
+	}
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		dataset, err := p.readHostsFile()
+		if err != nil {
+			hostslog.WithError(err).Error("reading hosts file")
+			p.Unregister()
+			return
+		}
+		p.EmitInventory(dataset, entity.NewFromNameWithoutID(p.Context.EntityKey()))
+		<-refreshTimer.C
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHostsFile = `# static table lookup for hostnames
+127.0.0.1   localhost
+::1         localhost ip6-localhost
+
+10.0.0.10   db.example.com db   # primary database
+#10.0.0.11  old-db.example.com
+`
+
+func TestHostsFilePlugin_ReadHostsFile(t *testing.T) {
+	etcDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(etcDir, "hosts"), []byte(testHostsFile), 0o644))
+
+	hostEtc := os.Getenv("HOST_ETC")
+	defer os.Setenv("HOST_ETC", hostEtc)
+	_ = os.Setenv("HOST_ETC", etcDir)
+
+	tests := []struct {
+		name     string
+		redact   bool
+		expected types.PluginInventoryDataset
+	}{
+		{
+			name:   "addresses included",
+			redact: false,
+			expected: types.PluginInventoryDataset{
+				HostsFileEntry{Hostname: "localhost", Address: "127.0.0.1 ::1"},
+				HostsFileEntry{Hostname: "ip6-localhost", Address: "::1"},
+				HostsFileEntry{Hostname: "db.example.com", Address: "10.0.0.10"},
+				HostsFileEntry{Hostname: "db", Address: "10.0.0.10"},
+			},
+		},
+		{
+			name:   "addresses redacted",
+			redact: true,
+			expected: types.PluginInventoryDataset{
+				HostsFileEntry{Hostname: "localhost", Address: redactedAddress},
+				HostsFileEntry{Hostname: "ip6-localhost", Address: redactedAddress},
+				HostsFileEntry{Hostname: "db.example.com", Address: redactedAddress},
+				HostsFileEntry{Hostname: "db", Address: redactedAddress},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &HostsFilePlugin{redactAddresses: tt.redact}
+
+			dataset, err := p.readHostsFile()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, dataset)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+)
+
+// ServicePluginEnabled returns whether the service-manager plugin for pluginInitSystem should be registered on a host
+// managed by hostInitSystem. Plugins are always enabled when the host init system is unknown or when their sampling
+// interval has been explicitly configured.
+func ServicePluginEnabled(hostInitSystem, pluginInitSystem string, intervalSec int64) bool {
+	if hostInitSystem == helpers.InitSystemUnknown || intervalSec > config.FREQ_DEFAULT_SAMPLING {
+		return true
+	}
+
+	return hostInitSystem == pluginInitSystem
+}
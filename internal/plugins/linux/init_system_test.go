@@ -0,0 +1,38 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServicePluginEnabled(t *testing.T) {
+	testCases := []struct {
+		name        string
+		hostInit    string
+		pluginInit  string
+		intervalSec int64
+		expected    bool
+	}{
+		{"systemd host keeps systemd", helpers.InitSystemSystemd, helpers.InitSystemSystemd, config.FREQ_DEFAULT_SAMPLING, true},
+		{"systemd host skips upstart", helpers.InitSystemSystemd, helpers.InitSystemUpstart, config.FREQ_DEFAULT_SAMPLING, false},
+		{"systemd host skips sysvinit", helpers.InitSystemSystemd, helpers.InitSystemSysvinit, config.FREQ_DEFAULT_SAMPLING, false},
+		{"systemd absent skips systemd", helpers.InitSystemSysvinit, helpers.InitSystemSystemd, config.FREQ_DEFAULT_SAMPLING, false},
+		{"systemd absent keeps sysvinit", helpers.InitSystemSysvinit, helpers.InitSystemSysvinit, config.FREQ_DEFAULT_SAMPLING, true},
+		{"explicitly enabled upstart on systemd host", helpers.InitSystemSystemd, helpers.InitSystemUpstart, 60, true},
+		{"unknown init system keeps everything", helpers.InitSystemUnknown, helpers.InitSystemUpstart, config.FREQ_DEFAULT_SAMPLING, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ServicePluginEnabled(tc.hostInit, tc.pluginInit, tc.intervalSec))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux || darwin
+// +build linux darwin
+
+package linux
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+)
+
+// localeVariables are the variables that define the locale and its character set, from lower to higher precedence.
+var localeVariables = []string{"LANG", "LC_CTYPE", "LC_ALL"}
+
+// getLocale returns the host locale and its character set. The locale configuration file values are overridden
+// by the agent environment, and LC_ALL overrides LANG.
+func getLocale() (locale, charset string) {
+	values := readLocaleConf()
+	for _, name := range localeVariables {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			values[name] = value
+		}
+	}
+
+	locale = values["LANG"]
+	if all := values["LC_ALL"]; all != "" {
+		locale = all
+	}
+
+	ctype := locale
+	if values["LC_ALL"] == "" && values["LC_CTYPE"] != "" {
+		ctype = values["LC_CTYPE"]
+	}
+
+	return locale, localeCharset(ctype)
+}
+
+// readLocaleConf reads the locale variables from /etc/locale.conf, or from /etc/default/locale on Debian based
+// distributions.
+func readLocaleConf() map[string]string {
+	values := map[string]string{}
+	for _, path := range []string{helpers.HostEtc("locale.conf"), helpers.HostEtc("default/locale")} {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			name, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+			if !found {
+				continue
+			}
+			values[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+		_ = file.Close()
+		return values
+	}
+
+	hlog.Debug("Cannot read locale configuration file.")
+	return values
+}
+
+// localeCharset returns the character set of a locale name as in "language[_territory][.charset][@modifier]".
+// The C and POSIX locales use ASCII.
+func localeCharset(locale string) string {
+	if locale == "" {
+		return ""
+	}
+	if locale == "C" || locale == "POSIX" {
+		return "ANSI_X3.4-1968"
+	}
+
+	locale, _, _ = strings.Cut(locale, "@")
+	_, charset, found := strings.Cut(locale, ".")
+	if !found {
+		return ""
+	}
+	return charset
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux || darwin
+// +build linux darwin
+
+package linux
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLocale(t *testing.T) {
+	testCases := []struct {
+		name            string
+		files           map[string]string
+		env             map[string]string
+		expectedLocale  string
+		expectedCharset string
+	}{
+		{
+			name:            "locale.conf",
+			files:           map[string]string{"locale.conf": "LANG=en_US.UTF-8\nLC_TIME=en_GB.UTF-8\n"},
+			expectedLocale:  "en_US.UTF-8",
+			expectedCharset: "UTF-8",
+		},
+		{
+			name:            "Debian default locale",
+			files:           map[string]string{"default/locale": "# File generated by update-locale\nLANG=\"de_DE.ISO-8859-1@euro\"\n"},
+			expectedLocale:  "de_DE.ISO-8859-1@euro",
+			expectedCharset: "ISO-8859-1",
+		},
+		{
+			name:            "environment overrides file",
+			files:           map[string]string{"locale.conf": "LANG=en_US.UTF-8\n"},
+			env:             map[string]string{"LANG": "fr_FR.ISO-8859-15"},
+			expectedLocale:  "fr_FR.ISO-8859-15",
+			expectedCharset: "ISO-8859-15",
+		},
+		{
+			name:            "LC_CTYPE overrides charset",
+			files:           map[string]string{"locale.conf": "LANG=en_US\nLC_CTYPE=en_US.UTF-8\n"},
+			expectedLocale:  "en_US",
+			expectedCharset: "UTF-8",
+		},
+		{
+			name:            "LC_ALL overrides everything",
+			files:           map[string]string{"locale.conf": "LANG=en_US.UTF-8\nLC_CTYPE=en_US.UTF-8\n"},
+			env:             map[string]string{"LC_ALL": "C"},
+			expectedLocale:  "C",
+			expectedCharset: "ANSI_X3.4-1968",
+		},
+		{
+			name: "not configured",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			etcDir := t.TempDir()
+			for path, content := range testCase.files {
+				path = filepath.Join(etcDir, path)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+			}
+			t.Setenv("HOST_ETC", etcDir)
+			for _, name := range localeVariables {
+				t.Setenv(name, testCase.env[name])
+			}
+
+			locale, charset := getLocale()
+			assert.Equal(t, testCase.expectedLocale, locale)
+			assert.Equal(t, testCase.expectedCharset, charset)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+const (
+	defaultRouteDestination = "default"
+
+	// route flags, from linux/route.h
+	routeFlagUp      = 0x0001
+	routeFlagGateway = 0x0002
+)
+
+var routeslog = log.WithPlugin("NetworkRoutes")
+
+// NetworkRoutesPlugin reports the IPv4 routing table of the host.
+type NetworkRoutesPlugin struct {
+	agent.PluginCommon
+	frequency time.Duration
+}
+
+// RouteEntry a route of the routing table, like the ones listed by `ip route`.
+type RouteEntry struct {
+	ID          string `json:"id"`
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway,omitempty"`
+	Interface   string `json:"interface"`
+	Metric      int64  `json:"metric"`
+	MTU         int64  `json:"mtu,omitempty"`
+	Default     bool   `json:"default"`
+}
+
+func (e RouteEntry) SortKey() string {
+	return e.ID
+}
+
+func NewNetworkRoutesPlugin(id ids.PluginID, ctx agent.AgentContext) *NetworkRoutesPlugin {
+	cfg := ctx.Config()
+	return &NetworkRoutesPlugin{
+		PluginCommon: agent.PluginCommon{ID: id, Context: ctx},
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.NetworkRoutesRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_NETWORK_ROUTES_UPDATES,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+	}
+}
+
+// parseRoutes returns the routes, which are up, of a /proc/net/route table. Routes are identified like `ip route`
+// does, so the same destination can be reached through several interfaces or metrics.
+func parseRoutes(r io.Reader) (dataset types.PluginInventoryDataset, err error) {
+	scanner := bufio.NewScanner(r)
+	// header: Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 9 {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route flags %q: %w", fields[3], err)
+		}
+		if flags&routeFlagUp == 0 {
+			continue
+		}
+
+		destination, err := parseRouteAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		mask, err := parseRouteAddress(fields[7])
+		if err != nil {
+			return nil, err
+		}
+		metric, err := strconv.ParseInt(fields[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route metric %q: %w", fields[6], err)
+		}
+		mtu, err := strconv.ParseInt(fields[8], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route MTU %q: %w", fields[8], err)
+		}
+
+		entry := RouteEntry{
+			Interface: fields[0],
+			Metric:    metric,
+			MTU:       mtu,
+		}
+		prefixLen, _ := net.IPMask(mask.To4()).Size()
+		if destination.Equal(net.IPv4zero) && prefixLen == 0 {
+			entry.Destination = defaultRouteDestination
+			entry.Default = true
+		} else {
+			entry.Destination = fmt.Sprintf("%s/%d", destination, prefixLen)
+		}
+		if flags&routeFlagGateway != 0 {
+			gateway, err := parseRouteAddress(fields[2])
+			if err != nil {
+				return nil, err
+			}
+			entry.Gateway = gateway.String()
+		}
+		entry.ID = fmt.Sprintf("%s dev %s metric %d", entry.Destination, entry.Interface, entry.Metric)
+
+		dataset = append(dataset, entry)
+	}
+
+	return dataset, scanner.Err()
+}
+
+// parseRouteAddress parses the IPv4 addresses of /proc/net/route, which are hex encoded in host byte order.
+func parseRouteAddress(hexAddress string) (net.IP, error) {
+	raw, err := hex.DecodeString(hexAddress)
+	if err != nil || len(raw) != net.IPv4len {
+		return nil, fmt.Errorf("invalid route address %q", hexAddress)
+	}
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(raw))
+	return ip, nil
+}
+
+func (p *NetworkRoutesPlugin) readRoutes() (types.PluginInventoryDataset, error) {
+	file, err := os.Open(helpers.HostProc("net", "route"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseRoutes(file)
+}
+
+func (p *NetworkRoutesPlugin) Run() {
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		routeslog.Debug("Disabled.")
+		return
+	}
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		dataset, err := p.readRoutes()
+		if err != nil {
+			routeslog.WithError(err).Error("reading network routes")
+			p.Unregister()
+			return
+		}
+		p.EmitInventory(dataset, entity.NewFromNameWithoutID(p.Context.EntityKey()))
+		<-refreshTimer.C
+	}
+}
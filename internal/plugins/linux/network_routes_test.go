@@ -0,0 +1,54 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRouteTable = `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0101A8C0	0003	0	0	100	00000000	0	0	0
+eth0	0001A8C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+docker0	000011AC	00000000	0001	0	0	0	0000FFFF	1500	0	0
+wlan0	00000000	FE0010AC	0003	0	0	600	00000000	0	0	0
+eth1	0002000A	00000000	0000	0	0	0	00FFFFFF	0	0	0
+`
+
+func TestNetworkRoutesPlugin_ReadRoutes(t *testing.T) {
+	procDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(procDir, "net"), 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(procDir, "net", "route"), []byte(testRouteTable), 0o644))
+
+	hostProc := os.Getenv("HOST_PROC")
+	defer os.Setenv("HOST_PROC", hostProc)
+	_ = os.Setenv("HOST_PROC", procDir)
+
+	p := &NetworkRoutesPlugin{}
+	dataset, err := p.readRoutes()
+	require.NoError(t, err)
+
+	assert.Equal(t, types.PluginInventoryDataset{
+		RouteEntry{ID: "default dev eth0 metric 100", Destination: "default", Gateway: "192.168.1.1", Interface: "eth0", Metric: 100, Default: true},
+		RouteEntry{ID: "192.168.1.0/24 dev eth0 metric 100", Destination: "192.168.1.0/24", Interface: "eth0", Metric: 100},
+		RouteEntry{ID: "172.17.0.0/16 dev docker0 metric 0", Destination: "172.17.0.0/16", Interface: "docker0", MTU: 1500},
+		RouteEntry{ID: "default dev wlan0 metric 600", Destination: "default", Gateway: "172.16.0.254", Interface: "wlan0", Metric: 600, Default: true},
+	}, dataset)
+}
+
+func TestParseRoutes_InvalidAddress(t *testing.T) {
+	table := strings.Replace(testRouteTable, "0101A8C0", "0101A8", 1)
+
+	_, err := parseRoutes(strings.NewReader(table))
+	assert.Error(t, err)
+}
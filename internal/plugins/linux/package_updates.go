@@ -0,0 +1,241 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+var pulog = log.WithPlugin("PackageUpdates")
+
+const (
+	// pendingUpdatesKey inventory item key holding the number of pending updates.
+	pendingUpdatesKey = "pending_updates"
+	// yumUpdatesExitCode exit code returned by "yum/dnf check-update" when there are updates available.
+	yumUpdatesExitCode = 100
+	// packageUpdatesTimeout maximum time the package manager is given to report the available updates, as it might
+	// hang on a locked package database or an unreachable repository.
+	packageUpdatesTimeout = 5 * time.Minute
+)
+
+// errPackageUpdatesTimeout is returned when the package manager doesn't report the updates in time.
+var errPackageUpdatesTimeout = errors.New("package manager timed out checking for updates")
+
+// PendingUpdates summary of the packages with an available update.
+type PendingUpdates struct {
+	Key     string `json:"id"`
+	Count   int    `json:"count"`
+	Manager string `json:"manager"`
+}
+
+func (p PendingUpdates) SortKey() string {
+	return p.Key
+}
+
+// PackageUpdate package with an available update.
+type PackageUpdate struct {
+	Name             string `json:"id"`
+	AvailableVersion string `json:"available_version"`
+}
+
+func (p PackageUpdate) SortKey() string {
+	return p.Name
+}
+
+// packageManager knows how to query a package manager for the available updates.
+type packageManager struct {
+	name  string
+	args  []string
+	parse func(output string) []PackageUpdate
+}
+
+// packageManagers supported package managers, in lookup order.
+var packageManagers = []packageManager{
+	{name: "apt-get", args: []string{"--simulate", "--quiet", "upgrade"}, parse: parseAptUpdates},
+	{name: "dnf", args: []string{"check-update", "--quiet"}, parse: parseYumUpdates},
+	{name: "yum", args: []string{"check-update", "--quiet"}, parse: parseYumUpdates},
+	{name: "zypper", args: []string{"--non-interactive", "--quiet", "list-updates"}, parse: parseZypperUpdates},
+}
+
+// PackageUpdatesPlugin reports the packages with an available update.
+type PackageUpdatesPlugin struct {
+	agent.PluginCommon
+	frequency  time.Duration
+	reportList bool
+	timeout    time.Duration
+	lookPath   func(file string) (string, error)
+	runCommand func(ctx context.Context, command string, stdin string, arguments ...string) (string, error)
+}
+
+func NewPackageUpdatesPlugin(id ids.PluginID, ctx agent.AgentContext) *PackageUpdatesPlugin {
+	cfg := ctx.Config()
+	return &PackageUpdatesPlugin{
+		PluginCommon: agent.PluginCommon{ID: id, Context: ctx},
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.PackageUpdatesRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_PACKAGE_UPDATES,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+		reportList: cfg.PackageUpdatesReportList,
+		timeout:    packageUpdatesTimeout,
+		lookPath:   exec.LookPath,
+		runCommand: helpers.RunCommandContext,
+	}
+}
+
+// parseAptUpdates parses the output of "apt-get --simulate upgrade", where each package to be upgraded is
+// reported as: Inst <name> [<current version>] (<available version> <repository> [<arch>])
+func parseAptUpdates(output string) (updates []PackageUpdate) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "Inst" {
+			continue
+		}
+		update := PackageUpdate{Name: fields[1]}
+		for _, field := range fields[2:] {
+			if strings.HasPrefix(field, "(") {
+				update.AvailableVersion = strings.TrimPrefix(field, "(")
+				break
+			}
+		}
+		updates = append(updates, update)
+	}
+	return
+}
+
+// parseYumUpdates parses the output of "yum/dnf check-update", where each package is reported as:
+// <name>.<arch> <available version> <repository>
+// Long package names are wrapped, so the version and repository are printed in the following line.
+// Packages listed as obsoleted are ignored, as they are already reported as an update.
+func parseYumUpdates(output string) (updates []PackageUpdate) {
+	var wrapped string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Obsoleting Packages") {
+			break
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 1 && !strings.HasPrefix(line, " ") {
+			wrapped = fields[0]
+			continue
+		}
+		if len(fields) == 2 && wrapped != "" {
+			fields = append([]string{wrapped}, fields...)
+		}
+		wrapped = ""
+		if len(fields) != 3 {
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndex(name, "."); i > 0 {
+			name = name[:i]
+		}
+		updates = append(updates, PackageUpdate{Name: name, AvailableVersion: fields[1]})
+	}
+	return
+}
+
+// parseZypperUpdates parses the table printed by "zypper list-updates":
+// S | Repository | Name | Current Version | Available Version | Arch
+func parseZypperUpdates(output string) (updates []PackageUpdate) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		columns := strings.Split(scanner.Text(), "|")
+		if len(columns) < 5 || strings.TrimSpace(columns[0]) != "v" {
+			continue
+		}
+		updates = append(updates, PackageUpdate{
+			Name:             strings.TrimSpace(columns[2]),
+			AvailableVersion: strings.TrimSpace(columns[4]),
+		})
+	}
+	return
+}
+
+// packageManager returns the first supported package manager present in the host.
+func (p *PackageUpdatesPlugin) packageManager() (packageManager, bool) {
+	for _, pm := range packageManagers {
+		if _, err := p.lookPath(pm.name); err == nil {
+			return pm, true
+		}
+	}
+	return packageManager{}, false
+}
+
+// pendingUpdates returns the updates reported by the package manager, which is killed if it doesn't report them
+// before the plugin timeout.
+func (p *PackageUpdatesPlugin) pendingUpdates(pm packageManager) ([]PackageUpdate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	output, err := p.runCommand(ctx, pm.name, "", pm.args...)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, errPackageUpdatesTimeout
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != yumUpdatesExitCode {
+			return nil, err
+		}
+	}
+	return pm.parse(output), nil
+}
+
+func (p *PackageUpdatesPlugin) dataset(pm packageManager, updates []PackageUpdate) types.PluginInventoryDataset {
+	dataset := types.PluginInventoryDataset{
+		PendingUpdates{Key: pendingUpdatesKey, Count: len(updates), Manager: pm.name},
+	}
+	if p.reportList {
+		for _, update := range updates {
+			dataset = append(dataset, update)
+		}
+	}
+	return dataset
+}
+
+func (p *PackageUpdatesPlugin) Run() {
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		pulog.Debug("Disabled.")
+		return
+	}
+
+	pm, ok := p.packageManager()
+	if !ok {
+		pulog.Debug("No supported package manager found, disabling plugin.")
+		p.Unregister()
+		return
+	}
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		updates, err := p.pendingUpdates(pm)
+		if errors.Is(err, errPackageUpdatesTimeout) {
+			pulog.WithField("packageManager", pm.name).WithField("timeout", p.timeout).
+				Warn("Package manager timed out checking for updates, skipping this check.")
+		} else if err != nil {
+			pulog.WithError(err).WithField("packageManager", pm.name).Error("checking for package updates")
+		} else {
+			p.EmitInventory(p.dataset(pm, updates), entity.NewFromNameWithoutID(p.Context.EntityKey()))
+		}
+		<-refreshTimer.C
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const aptUpgradeOutput = `Reading package lists...
+Building dependency tree...
+Reading state information...
+Calculating upgrade...
+The following packages will be upgraded:
+  libc-bin libc6
+2 upgraded, 0 newly installed, 0 to remove and 0 not upgraded.
+Inst libc6 [2.31-0ubuntu9.9] (2.31-0ubuntu9.12 Ubuntu:20.04/focal-updates [amd64])
+Inst libc-bin [2.31-0ubuntu9.9] (2.31-0ubuntu9.12 Ubuntu:20.04/focal-updates [amd64])
+Conf libc6 (2.31-0ubuntu9.12 Ubuntu:20.04/focal-updates [amd64])
+Conf libc-bin (2.31-0ubuntu9.12 Ubuntu:20.04/focal-updates [amd64])`
+
+const yumCheckUpdateOutput = `
+bash.x86_64                           4.4.20-4.el8_6                   baseos
+kernel-core.x86_64                    4.18.0-425.3.1.el8               baseos
+python3-some-very-long-package-name.noarch
+                                      1.2.3-1.el8                      appstream
+Obsoleting Packages
+grub2-tools.x86_64                    1:2.02-142.el8                   baseos
+    grub2-tools.x86_64                1:2.02-123.el8                   @baseos`
+
+const zypperListUpdatesOutput = `S | Repository | Name    | Current Version | Available Version | Arch
+--+------------+---------+-----------------+-------------------+-------
+v | Updates    | curl    | 7.79.1-1.1      | 7.79.1-2.1        | x86_64
+v | Updates    | openssl | 1.1.1l-1.1      | 1.1.1l-2.1        | x86_64`
+
+func TestParsePackageUpdates(t *testing.T) {
+	tests := []struct {
+		name     string
+		parse    func(string) []PackageUpdate
+		output   string
+		expected []PackageUpdate
+	}{
+		{
+			name:   "apt",
+			parse:  parseAptUpdates,
+			output: aptUpgradeOutput,
+			expected: []PackageUpdate{
+				{Name: "libc6", AvailableVersion: "2.31-0ubuntu9.12"},
+				{Name: "libc-bin", AvailableVersion: "2.31-0ubuntu9.12"},
+			},
+		},
+		{
+			name:   "yum",
+			parse:  parseYumUpdates,
+			output: yumCheckUpdateOutput,
+			expected: []PackageUpdate{
+				{Name: "bash", AvailableVersion: "4.4.20-4.el8_6"},
+				{Name: "kernel-core", AvailableVersion: "4.18.0-425.3.1.el8"},
+				{Name: "python3-some-very-long-package-name", AvailableVersion: "1.2.3-1.el8"},
+			},
+		},
+		{
+			name:   "zypper",
+			parse:  parseZypperUpdates,
+			output: zypperListUpdatesOutput,
+			expected: []PackageUpdate{
+				{Name: "curl", AvailableVersion: "7.79.1-2.1"},
+				{Name: "openssl", AvailableVersion: "1.1.1l-2.1"},
+			},
+		},
+		{
+			name:   "no updates",
+			parse:  parseAptUpdates,
+			output: "Reading package lists...\n0 upgraded, 0 newly installed, 0 to remove and 0 not upgraded.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.parse(tt.output))
+		})
+	}
+}
+
+func TestPackageUpdatesPlugin_PendingUpdates(t *testing.T) {
+	// yum and dnf exit with code 100 when there are updates available
+	updatesAvailable := exec.Command("sh", "-c", "exit 100").Run()
+	require.Error(t, updatesAvailable)
+
+	tests := []struct {
+		name       string
+		reportList bool
+		cmdErr     error
+		expected   types.PluginInventoryDataset
+	}{
+		{
+			name:     "count only",
+			cmdErr:   updatesAvailable,
+			expected: types.PluginInventoryDataset{PendingUpdates{Key: pendingUpdatesKey, Count: 3, Manager: "yum"}},
+		},
+		{
+			name:       "count and list",
+			reportList: true,
+			expected: types.PluginInventoryDataset{
+				PendingUpdates{Key: pendingUpdatesKey, Count: 3, Manager: "yum"},
+				PackageUpdate{Name: "bash", AvailableVersion: "4.4.20-4.el8_6"},
+				PackageUpdate{Name: "kernel-core", AvailableVersion: "4.18.0-425.3.1.el8"},
+				PackageUpdate{Name: "python3-some-very-long-package-name", AvailableVersion: "1.2.3-1.el8"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PackageUpdatesPlugin{
+				reportList: tt.reportList,
+				lookPath: func(file string) (string, error) {
+					if file == "yum" {
+						return "/usr/bin/yum", nil
+					}
+					return "", exec.ErrNotFound
+				},
+				timeout: time.Minute,
+				runCommand: func(_ context.Context, command string, stdin string, arguments ...string) (string, error) {
+					assert.Equal(t, "yum", command)
+					return yumCheckUpdateOutput, tt.cmdErr
+				},
+			}
+
+			pm, ok := p.packageManager()
+			require.True(t, ok)
+
+			updates, err := p.pendingUpdates(pm)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, p.dataset(pm, updates))
+		})
+	}
+}
+
+func TestPackageUpdatesPlugin_CommandError(t *testing.T) {
+	p := &PackageUpdatesPlugin{
+		timeout: time.Minute,
+		runCommand: func(_ context.Context, command string, stdin string, arguments ...string) (string, error) {
+			return "", errors.New("repository metadata unavailable")
+		},
+	}
+
+	_, err := p.pendingUpdates(packageManagers[0])
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, errPackageUpdatesTimeout)
+}
+
+func TestPackageUpdatesPlugin_CommandTimeout(t *testing.T) {
+	p := &PackageUpdatesPlugin{
+		timeout: 10 * time.Millisecond,
+		runCommand: func(ctx context.Context, command string, stdin string, arguments ...string) (string, error) {
+			// a package manager waiting for the package database lock
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+
+	_, err := p.pendingUpdates(packageManagers[0])
+	assert.ErrorIs(t, err, errPackageUpdatesTimeout)
+}
+
+func TestPackageUpdatesPlugin_NoPackageManager(t *testing.T) {
+	p := &PackageUpdatesPlugin{
+		lookPath: func(file string) (string, error) {
+			return "", exec.ErrNotFound
+		},
+	}
+
+	_, ok := p.packageManager()
+	assert.False(t, ok)
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+// Package managers reporting the normalized packages inventory.
+const (
+	packageManagerRpm  = "rpm"
+	packageManagerDpkg = "dpkg"
+)
+
+// Inventory sources of the installed packages reported with a common schema for all the package managers, so they can
+// be queried regardless of the distro. Every package manager has its own source, as a host can have both.
+var (
+	RpmPackagesPluginID  = ids.PluginID{Category: "packages", Term: "installed-rpm"}
+	DpkgPackagesPluginID = ids.PluginID{Category: "packages", Term: "installed-dpkg"}
+)
+
+// dpkgInstalledStatus status of the packages currently installed, as "${Status}" is formatted by dpkg-query.
+const dpkgInstalledStatus = "install ok installed"
+
+// PackageItem installed package inventory item, with a common schema for all the package managers.
+type PackageItem struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Architecture string `json:"architecture"`
+	Manager      string `json:"manager"`
+}
+
+func (p PackageItem) SortKey() string {
+	return p.ID
+}
+
+func newPackageItem(name, version, arch, manager string) PackageItem {
+	return PackageItem{
+		// several versions of the same package can be installed (e.g. kernel)
+		ID:           fmt.Sprintf("%s:%s:%s", name, arch, version),
+		Name:         name,
+		Version:      version,
+		Architecture: arch,
+		Manager:      manager,
+	}
+}
+
+// normalizeRpmPackages converts the rpm query output (see rpmPlugin.fetchPackageInfo) into normalized packages.
+// Versions are reported as [epoch:]version-release.
+func normalizeRpmPackages(output string) (packages types.PluginInventoryDataset) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 6 {
+			continue
+		}
+
+		version := fmt.Sprintf("%s-%s", parts[1], parts[2])
+		if epoch := parts[5]; !strings.Contains(epoch, "none") {
+			version = fmt.Sprintf("%s:%s", epoch, version)
+		}
+
+		packages = append(packages, newPackageItem(parts[0], version, parts[3], packageManagerRpm))
+	}
+
+	return
+}
+
+// normalizeDpkgPackages converts the dpkg-query output (see DpkgPlugin.fetchPackageInfo) into normalized packages,
+// skipping the ones not currently installed, as the removed packages whose configuration files are kept.
+// Versions are reported as provided by dpkg, [epoch:]upstream_version[-debian_revision].
+func normalizeDpkgPackages(output string) (packages types.PluginInventoryDataset) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 8 {
+			continue
+		}
+		if strings.Join(parts[1:4], " ") != dpkgInstalledStatus {
+			continue
+		}
+
+		packages = append(packages, newPackageItem(parts[0], parts[5], parts[4], packageManagerDpkg))
+	}
+
+	return
+}
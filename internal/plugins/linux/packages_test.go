@@ -0,0 +1,45 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeRpmPackages(t *testing.T) {
+	output := `bash 5.1.8 6.el9 x86_64 1689000000 (none)
+kernel 5.14.0 284.el9 x86_64 1689000001 (none)
+kernel 5.14.0 362.el9 x86_64 1689000002 (none)
+openssl 3.0.7 24.el9 x86_64 1689000003 1
+invalid line
+`
+
+	assert.Equal(t, types.PluginInventoryDataset{
+		PackageItem{ID: "bash:x86_64:5.1.8-6.el9", Name: "bash", Version: "5.1.8-6.el9", Architecture: "x86_64", Manager: "rpm"},
+		PackageItem{ID: "kernel:x86_64:5.14.0-284.el9", Name: "kernel", Version: "5.14.0-284.el9", Architecture: "x86_64", Manager: "rpm"},
+		PackageItem{ID: "kernel:x86_64:5.14.0-362.el9", Name: "kernel", Version: "5.14.0-362.el9", Architecture: "x86_64", Manager: "rpm"},
+		PackageItem{ID: "openssl:x86_64:1:3.0.7-24.el9", Name: "openssl", Version: "1:3.0.7-24.el9", Architecture: "x86_64", Manager: "rpm"},
+	}, normalizeRpmPackages(output))
+}
+
+func TestNormalizeDpkgPackages(t *testing.T) {
+	output := `bash install ok installed amd64 5.1-6ubuntu1 yes required
+libc6 install ok installed i386 2.35-0ubuntu3.1 no required
+openssl install ok installed amd64 3.0.2-0ubuntu1.10 no optional
+nginx deinstall ok config-files amd64 1.18.0-6ubuntu14 no optional
+curl install ok half-configured amd64 7.81.0-1ubuntu1.13 no optional
+invalid line
+`
+
+	assert.Equal(t, types.PluginInventoryDataset{
+		PackageItem{ID: "bash:amd64:5.1-6ubuntu1", Name: "bash", Version: "5.1-6ubuntu1", Architecture: "amd64", Manager: "dpkg"},
+		PackageItem{ID: "libc6:i386:2.35-0ubuntu3.1", Name: "libc6", Version: "2.35-0ubuntu3.1", Architecture: "i386", Manager: "dpkg"},
+		PackageItem{ID: "openssl:amd64:3.0.2-0ubuntu1.10", Name: "openssl", Version: "3.0.2-0ubuntu1.10", Architecture: "amd64", Manager: "dpkg"},
+	}, normalizeDpkgPackages(output))
+}
@@ -34,8 +34,9 @@ const (
 
 type rpmPlugin struct {
 	agent.PluginCommon
-	frequency    time.Duration
-	erroredLines map[string]struct{}
+	frequency          time.Duration
+	erroredLines       map[string]struct{}
+	normalizedPackages bool
 }
 
 type RpmItem struct {
@@ -63,16 +64,22 @@ func NewRpmPlugin(ctx agent.AgentContext) agent.Plugin {
 			config.FREQ_PLUGIN_PACKAGE_MGRS_UPDATES,
 			cfg.DisableAllPlugins,
 		) * time.Second,
-		erroredLines: make(map[string]struct{}),
+		erroredLines:       make(map[string]struct{}),
+		normalizedPackages: cfg.PackagesNormalizedInventory,
 	}
 }
 
-func (p *rpmPlugin) fetchPackageInfo() (packages types.PluginInventoryDataset, err error) {
+// fetchPackageInfo returns the rpm packages and, when enabled, the normalized packages.
+func (p *rpmPlugin) fetchPackageInfo() (packages, normalized types.PluginInventoryDataset, err error) {
 	output, err := helpers.RunCommand(RpmPath, "", "-qa", "--queryformat=%{NAME} %{VERSION} %{RELEASE} %{ARCH} %{INSTALLTIME} %{EPOCH}\n")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return p.parsePackageInfo(output)
+	if p.normalizedPackages {
+		normalized = normalizeRpmPackages(output)
+	}
+	packages, err = p.parsePackageInfo(output)
+	return
 }
 
 func (p *rpmPlugin) parsePackageInfo(output string) (packages types.PluginInventoryDataset, err error) {
@@ -173,11 +180,14 @@ func (p *rpmPlugin) Run() {
 			ticker.Stop()
 			ticker = time.NewTicker(p.frequency)
 			if counter > 0 {
-				data, err := p.fetchPackageInfo()
+				data, normalized, err := p.fetchPackageInfo()
 				if err != nil {
 					rpmlog.WithError(err).Error("fetching rpm data")
 				} else {
 					p.EmitInventory(data, entity.NewFromNameWithoutID(p.Context.EntityKey()))
+					if p.normalizedPackages {
+						p.Context.SendData(types.NewPluginOutput(RpmPackagesPluginID, entity.NewFromNameWithoutID(p.Context.EntityKey()), normalized))
+					}
 				}
 				counter = 0
 			}
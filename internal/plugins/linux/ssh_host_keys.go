@@ -0,0 +1,123 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+var sshkeyslog = log.WithPlugin("SshHostKeys")
+
+var errInvalidPublicKey = errors.New("invalid public key")
+
+// SshHostKeysPlugin reports the fingerprints of the SSH host public keys, found in /etc/ssh.
+type SshHostKeysPlugin struct {
+	agent.PluginCommon
+	frequency time.Duration
+}
+
+// SshHostKey SSH host public key, identified by its file name.
+type SshHostKey struct {
+	File        string `json:"id"`
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+func (k SshHostKey) SortKey() string {
+	return k.File
+}
+
+func NewSshHostKeysPlugin(id ids.PluginID, ctx agent.AgentContext) *SshHostKeysPlugin {
+	cfg := ctx.Config()
+	return &SshHostKeysPlugin{
+		PluginCommon: agent.PluginCommon{ID: id, Context: ctx},
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.SshHostKeysRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_SSH_HOST_KEYS_UPDATES,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+	}
+}
+
+// parsePublicKey returns the type and the SHA256 fingerprint, as reported by "ssh-keygen -l", of a public key in
+// OpenSSH format: <type> <base64 key> [comment]
+func parsePublicKey(content string) (keyType string, fingerprint string, err error) {
+	fields := strings.Fields(content)
+	if len(fields) < 2 {
+		return "", "", errInvalidPublicKey
+	}
+
+	key, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", "", errInvalidPublicKey
+	}
+
+	sum := sha256.Sum256(key)
+	return fields[0], "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+// readSshHostKeys reads the public keys from the ssh config directory of the host (honoring HOST_ETC).
+func readSshHostKeys() (dataset types.PluginInventoryDataset, err error) {
+	files, err := filepath.Glob(helpers.HostEtc("ssh", "*.pub"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			sshkeyslog.WithError(err).WithField("file", file).Debug("Cannot read SSH host key.")
+			continue
+		}
+
+		keyType, fingerprint, err := parsePublicKey(string(content))
+		if err != nil {
+			sshkeyslog.WithError(err).WithField("file", file).Debug("Cannot parse SSH host key.")
+			continue
+		}
+
+		dataset = append(dataset, SshHostKey{
+			File:        filepath.Base(file),
+			Type:        keyType,
+			Fingerprint: fingerprint,
+		})
+	}
+	return dataset, nil
+}
+
+func (p *SshHostKeysPlugin) Run() {
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		sshkeyslog.Debug("Disabled.")
+		return
+	}
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		dataset, err := readSshHostKeys()
+		if err != nil {
+			sshkeyslog.WithError(err).Error("reading SSH host keys")
+			p.Unregister()
+			return
+		}
+		p.EmitInventory(dataset, entity.NewFromNameWithoutID(p.Context.EntityKey()))
+		<-refreshTimer.C
+	}
+}
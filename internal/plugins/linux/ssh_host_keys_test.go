@@ -0,0 +1,48 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fingerprint as reported by "ssh-keygen -l -f ssh_host_ed25519_key.pub"
+const (
+	testSshHostKey            = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIND/HA08QFM/CiY+tinb8Au1jrJVJ+u0H8plk2IKy1Bj root@host\n"
+	testSshHostKeyFingerprint = "SHA256:Qu+5LALLKyFSCuRf1raz0YK8mhXapX0lDeEYrzphphA"
+)
+
+func TestSshHostKeysPlugin_ReadSshHostKeys(t *testing.T) {
+	etcDir := t.TempDir()
+	sshDir := filepath.Join(etcDir, "ssh")
+	require.NoError(t, os.Mkdir(sshDir, 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sshDir, "ssh_host_ed25519_key.pub"), []byte(testSshHostKey), 0o644))
+	// private keys and invalid public keys are not reported
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sshDir, "ssh_host_ed25519_key"), []byte("private"), 0o600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sshDir, "broken.pub"), []byte("ssh-rsa not-base64!"), 0o644))
+
+	hostEtc := os.Getenv("HOST_ETC")
+	defer os.Setenv("HOST_ETC", hostEtc)
+	_ = os.Setenv("HOST_ETC", etcDir)
+
+	dataset, err := readSshHostKeys()
+	require.NoError(t, err)
+	assert.Equal(t, types.PluginInventoryDataset{
+		SshHostKey{File: "ssh_host_ed25519_key.pub", Type: "ssh-ed25519", Fingerprint: testSshHostKeyFingerprint},
+	}, dataset)
+}
+
+func TestParsePublicKey_Invalid(t *testing.T) {
+	_, _, err := parsePublicKey("ssh-rsa")
+	assert.Equal(t, errInvalidPublicKey, err)
+}
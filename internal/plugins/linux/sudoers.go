@@ -0,0 +1,209 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+var sudoerslog = log.WithPlugin("Sudoers")
+
+// maxSudoersIncludeDepth same nesting limit for included files as sudo.
+const maxSudoersIncludeDepth = 128
+
+// SudoersPlugin reports a summary of the privilege escalation rules defined in /etc/sudoers and its included
+// files. Rules themselves are not reported, to avoid exposing the host security policy.
+type SudoersPlugin struct {
+	agent.PluginCommon
+	frequency time.Duration
+}
+
+type SudoersValue struct {
+	Key   string `json:"id"`
+	Value string `json:"value"`
+}
+
+func (v SudoersValue) SortKey() string {
+	return v.Key
+}
+
+// SudoersSummary summary of the sudoers policy.
+type SudoersSummary struct {
+	Files         int
+	Rules         int
+	NoPasswdRules int
+}
+
+func NewSudoersPlugin(id ids.PluginID, ctx agent.AgentContext) *SudoersPlugin {
+	cfg := ctx.Config()
+	return &SudoersPlugin{
+		PluginCommon: agent.PluginCommon{ID: id, Context: ctx},
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.SudoersRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_SUDOERS_UPDATES,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+	}
+}
+
+// hostEtcPath maps an absolute path under /etc, as referenced by sudoers include directives, to the host /etc
+// directory (honoring HOST_ETC).
+func hostEtcPath(path string) string {
+	if rel := strings.TrimPrefix(path, "/etc/"); rel != path {
+		return helpers.HostEtc(rel)
+	}
+	return path
+}
+
+// sudoersLines returns the logical lines of a sudoers file, joining the ones continued with a backslash.
+func sudoersLines(content string) (lines []string) {
+	var current string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(line, "\\") {
+			current += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		lines = append(lines, current+line)
+		current = ""
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return
+}
+
+// includeDirFiles returns the files read by sudo from an included directory: the ones that don't end with "~" nor
+// contain a ".", in lexical order.
+func includeDirFiles(dir string) (files []string) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		sudoerslog.WithError(err).WithField("dir", dir).Debug("Cannot read sudoers include directory.")
+		return nil
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasSuffix(name, "~") || strings.Contains(name, ".") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	sort.Strings(files)
+	return
+}
+
+// parseSudoers summarizes the sudoers file in path, following its include directives.
+func parseSudoers(path string, summary *SudoersSummary, visited map[string]bool, depth int) error {
+	if depth > maxSudoersIncludeDepth || visited[path] {
+		return nil
+	}
+	visited[path] = true
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	summary.Files++
+
+	for _, line := range sudoersLines(string(content)) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "#include", "@include":
+			if len(fields) > 1 {
+				if err := parseSudoers(hostEtcPath(fields[1]), summary, visited, depth+1); err != nil {
+					sudoerslog.WithError(err).WithField("file", fields[1]).Debug("Cannot read included sudoers file.")
+				}
+			}
+			continue
+		case "#includedir", "@includedir":
+			if len(fields) > 1 {
+				for _, file := range includeDirFiles(hostEtcPath(fields[1])) {
+					if err := parseSudoers(file, summary, visited, depth+1); err != nil {
+						sudoerslog.WithError(err).WithField("file", file).Debug("Cannot read included sudoers file.")
+					}
+				}
+			}
+			continue
+		}
+
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || isSudoersDefinition(line) {
+			continue
+		}
+
+		summary.Rules++
+		if strings.Contains(line, "NOPASSWD:") {
+			summary.NoPasswdRules++
+		}
+	}
+	return nil
+}
+
+// isSudoersDefinition returns true for the lines that don't define a user privilege rule: defaults and aliases.
+func isSudoersDefinition(line string) bool {
+	keyword := strings.Fields(line)[0]
+	return strings.HasPrefix(keyword, "Defaults") || strings.HasSuffix(keyword, "_Alias")
+}
+
+func (s SudoersSummary) dataset() types.PluginInventoryDataset {
+	return types.PluginInventoryDataset{
+		SudoersValue{"files", strconv.Itoa(s.Files)},
+		SudoersValue{"rules", strconv.Itoa(s.Rules)},
+		SudoersValue{"nopasswd_rules", strconv.Itoa(s.NoPasswdRules)},
+		SudoersValue{"nopasswd", strconv.FormatBool(s.NoPasswdRules > 0)},
+	}
+}
+
+func readSudoers() (summary SudoersSummary, err error) {
+	err = parseSudoers(helpers.HostEtc("sudoers"), &summary, map[string]bool{}, 0)
+	return
+}
+
+func (p *SudoersPlugin) Run() {
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		sudoerslog.Debug("Disabled.")
+		return
+	}
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		summary, err := readSudoers()
+		if err != nil {
+			if os.IsNotExist(err) {
+				sudoerslog.Debug("No sudoers file found, disabling plugin.")
+			} else {
+				sudoerslog.WithError(err).Error("reading sudoers file")
+			}
+			p.Unregister()
+			return
+		}
+		p.EmitInventory(summary.dataset(), entity.NewFromNameWithoutID(p.Context.EntityKey()))
+		<-refreshTimer.C
+	}
+}
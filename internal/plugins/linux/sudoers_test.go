@@ -0,0 +1,85 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSudoers = `# This file MUST be edited with the 'visudo' command as root.
+Defaults	env_reset
+Defaults	secure_path="/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+Cmnd_Alias RESTART = /bin/systemctl restart nginx, \
+                     /bin/systemctl restart php-fpm
+
+# User privilege specification
+root	ALL=(ALL:ALL) ALL
+%sudo	ALL=(ALL:ALL) ALL
+
+#includedir /etc/sudoers.d
+`
+
+func TestSudoersPlugin_ReadSudoers(t *testing.T) {
+	etcDir := t.TempDir()
+	sudoersDir := filepath.Join(etcDir, "sudoers.d")
+	require.NoError(t, os.Mkdir(sudoersDir, 0o750))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(etcDir, "sudoers"), []byte(testSudoers), 0o440))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sudoersDir, "deploy"), []byte(
+		"deploy ALL=(root) NOPASSWD: RESTART # restart web services\n"), 0o440))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sudoersDir, "ops"), []byte(
+		"%ops ALL=(ALL) ALL\n@include /etc/sudoers.d/ops\n"), 0o440))
+	// files with a dot or ending with ~ are ignored by sudo
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sudoersDir, "ops.bak"), []byte(
+		"%ops ALL=(ALL) NOPASSWD: ALL\n"), 0o440))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sudoersDir, "ops~"), []byte(
+		"%ops ALL=(ALL) NOPASSWD: ALL\n"), 0o440))
+
+	hostEtc := os.Getenv("HOST_ETC")
+	defer os.Setenv("HOST_ETC", hostEtc)
+	_ = os.Setenv("HOST_ETC", etcDir)
+
+	summary, err := readSudoers()
+	require.NoError(t, err)
+
+	assert.Equal(t, SudoersSummary{Files: 3, Rules: 4, NoPasswdRules: 1}, summary)
+	assert.Equal(t, types.PluginInventoryDataset{
+		SudoersValue{"files", "3"},
+		SudoersValue{"rules", "4"},
+		SudoersValue{"nopasswd_rules", "1"},
+		SudoersValue{"nopasswd", "true"},
+	}, summary.dataset())
+}
+
+func TestSudoersPlugin_NoPasswd(t *testing.T) {
+	etcDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(etcDir, "sudoers"), []byte(testSudoers), 0o440))
+
+	hostEtc := os.Getenv("HOST_ETC")
+	defer os.Setenv("HOST_ETC", hostEtc)
+	_ = os.Setenv("HOST_ETC", etcDir)
+
+	summary, err := readSudoers()
+	require.NoError(t, err)
+	assert.Equal(t, SudoersSummary{Files: 1, Rules: 2}, summary)
+	assert.Contains(t, summary.dataset(), SudoersValue{"nopasswd", "false"})
+}
+
+func TestSudoersPlugin_MissingFile(t *testing.T) {
+	hostEtc := os.Getenv("HOST_ETC")
+	defer os.Setenv("HOST_ETC", hostEtc)
+	_ = os.Setenv("HOST_ETC", t.TempDir())
+
+	_, err := readSudoers()
+	assert.True(t, os.IsNotExist(err))
+}
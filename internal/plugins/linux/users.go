@@ -25,7 +25,9 @@ var usrlog = log.WithPlugin("Users")
 
 type UsersPlugin struct {
 	agent.PluginCommon
-	frequency time.Duration
+	frequency  time.Duration
+	sessions   bool
+	runCommand func(command string, stdin string, arguments ...string) (string, error)
 }
 
 type User struct {
@@ -36,8 +38,28 @@ func (self User) SortKey() string {
 	return self.Name
 }
 
+// Session login session of a user, as registered in the utmp file.
+type Session struct {
+	ID       string `json:"id"`
+	User     string `json:"user"`
+	Terminal string `json:"terminal"`
+	Host     string `json:"host,omitempty"`
+	Since    string `json:"since"`
+}
+
+func (s Session) SortKey() string {
+	return s.ID
+}
+
 var usersPluginID = ids.PluginID{"sessions", "users"}
 
+var sessionsPluginID = ids.PluginID{"sessions", "logged_in"}
+
+// utmpFile returns the path of the file holding the current login sessions, honoring the HOST_VAR override.
+func utmpFile() string {
+	return helpers.HostVar("run", "utmp")
+}
+
 func NewUsersPlugin(ctx agent.AgentContext) agent.Plugin {
 	cfg := ctx.Config()
 	return &UsersPlugin{
@@ -48,17 +70,13 @@ func NewUsersPlugin(ctx agent.AgentContext) agent.Plugin {
 			config.FREQ_PLUGIN_USERS_UPDATES,
 			cfg.DisableAllPlugins,
 		) * time.Second,
+		sessions:   cfg.UsersSessionsEnabled,
+		runCommand: helpers.RunCommand,
 	}
 }
 
-// getUserDetails runs the who command, parses it's output and returns
-// a dataset of users.
-func (self UsersPlugin) getUserDetails() (dataset types.PluginInventoryDataset) {
-	output, err := helpers.RunCommand("/usr/bin/env", "", "who")
-	if err != nil {
-		usrlog.WithError(err).Error("failed to fetch user information")
-		return
-	}
+// getUserDetails parses the who command output and returns a dataset of users.
+func (self UsersPlugin) getUserDetails(output string) (dataset types.PluginInventoryDataset) {
 	users := parseWhoOutput(output)
 	for k := range users {
 		dataset = append(dataset, User{
@@ -87,6 +105,51 @@ func parseWhoOutput(output string) (users map[string]bool) {
 	return users
 }
 
+// parseWhoSessions parses the output from an execution of the `who`
+// command, which reports a login session per line as:
+// <user> <terminal> <login time> [(<remote host>)]
+func parseWhoSessions(output string) (dataset types.PluginInventoryDataset) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		session := Session{
+			ID:       fields[0] + "@" + fields[1],
+			User:     fields[0],
+			Terminal: fields[1],
+		}
+		since := fields[2:]
+		if last := since[len(since)-1]; strings.HasPrefix(last, "(") && strings.HasSuffix(last, ")") {
+			session.Host = strings.Trim(last, "()")
+			since = since[:len(since)-1]
+		}
+		session.Since = strings.Join(since, " ")
+		dataset = append(dataset, session)
+	}
+	return dataset
+}
+
+// who runs the who command against the utmp file of the host.
+func (self *UsersPlugin) who() (string, error) {
+	return self.runCommand("/usr/bin/env", "", "who", utmpFile())
+}
+
+func (self *UsersPlugin) emitInventory() {
+	output, err := self.who()
+	if err != nil {
+		usrlog.WithError(err).Error("failed to fetch user information")
+		output = ""
+	}
+
+	entityKey := entity.NewFromNameWithoutID(self.Context.EntityKey())
+	self.EmitInventory(self.getUserDetails(output), entityKey)
+	if self.sessions {
+		self.Context.SendData(types.NewPluginOutput(sessionsPluginID, entityKey, parseWhoSessions(output)))
+	}
+}
+
 func (self *UsersPlugin) Run() {
 	if self.frequency <= config.FREQ_DISABLE_SAMPLING {
 		usrlog.Debug("Disabled.")
@@ -100,7 +163,7 @@ func (self *UsersPlugin) Run() {
 		return
 	}
 
-	err = watcher.Add("/var/run/utmp")
+	err = watcher.Add(utmpFile())
 	if err != nil {
 		usrlog.WithError(err).Error("can't setup trigger file watcher for users")
 		self.Unregister()
@@ -121,7 +184,7 @@ func (self *UsersPlugin) Run() {
 			{
 				refreshTimer.Reset(self.frequency)
 				if needsFlush {
-					self.EmitInventory(self.getUserDetails(), entity.NewFromNameWithoutID(self.Context.EntityKey()))
+					self.emitInventory()
 					needsFlush = false
 				}
 			}
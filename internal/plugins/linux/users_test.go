@@ -6,9 +6,13 @@
 package linux
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseWhoOutput(t *testing.T) {
@@ -30,3 +34,35 @@ newrelic  pts/1        2018-10-24 15:55 (10.0.2.2)`,
 		assert.Equal(t, expectedUsers, users)
 	}
 }
+
+func TestParseWhoSessions(t *testing.T) {
+	output := `vagrant  pts/0        2018-10-24 15:55 (10.0.2.2)
+root     tty1         Oct 24 14:26
+newrelic pts/1        2018-10-24 16:02 (bastion.example.com)`
+
+	expected := types.PluginInventoryDataset{
+		Session{ID: "vagrant@pts/0", User: "vagrant", Terminal: "pts/0", Host: "10.0.2.2", Since: "2018-10-24 15:55"},
+		Session{ID: "root@tty1", User: "root", Terminal: "tty1", Since: "Oct 24 14:26"},
+		Session{ID: "newrelic@pts/1", User: "newrelic", Terminal: "pts/1", Host: "bastion.example.com", Since: "2018-10-24 16:02"},
+	}
+	assert.Equal(t, expected, parseWhoSessions(output))
+}
+
+func TestUsersPlugin_WhoHonorsHostVar(t *testing.T) {
+	hostVar := os.Getenv("HOST_VAR")
+	defer os.Setenv("HOST_VAR", hostVar)
+	_ = os.Setenv("HOST_VAR", "/host/var")
+
+	var args []string
+	p := &UsersPlugin{
+		runCommand: func(command string, stdin string, arguments ...string) (string, error) {
+			args = arguments
+			return "vagrant  pts/0        2018-10-24 15:55 (10.0.2.2)", nil
+		},
+	}
+
+	output, err := p.who()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"who", filepath.Join("/host/var", "run", "utmp")}, args)
+	assert.Equal(t, types.PluginInventoryDataset{User{Name: "vagrant"}}, p.getUserDetails(output))
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux || darwin
+// +build linux darwin
+
+package linux
+
+import (
+	"strings"
+
+	"github.com/newrelic/infrastructure-agent/internal/os/fs"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
+)
+
+const (
+	virtualizationNone    = "none"
+	virtualizationUnknown = "unknown"
+)
+
+// dmiHypervisor matches the DMI vendor and product strings of a hypervisor. An empty product matches any product.
+type dmiHypervisor struct {
+	vendor  string
+	product string
+	name    string
+}
+
+// dmiHypervisors is evaluated in order, so the more specific entries go first.
+var dmiHypervisors = []dmiHypervisor{
+	{vendor: "", product: "kvm", name: "kvm"},
+	{vendor: "qemu", name: "kvm"},
+	{vendor: "vmware", name: "vmware"},
+	{vendor: "microsoft corporation", product: "virtual machine", name: "hyperv"},
+	{vendor: "innotek", name: "virtualbox"},
+	{vendor: "", product: "virtualbox", name: "virtualbox"},
+	{vendor: "xen", name: "xen"},
+	{vendor: "", product: "hvm domu", name: "xen"},
+	{vendor: "amazon ec2", name: "kvm"},
+	{vendor: "google", name: "kvm"},
+	{vendor: "alibaba cloud", name: "kvm"},
+	{vendor: "openstack", name: "kvm"},
+	{vendor: "", product: "openstack", name: "kvm"},
+	{vendor: "parallels", name: "parallels"},
+	{vendor: "bochs", name: "bochs"},
+	{vendor: "bhyve", name: "bhyve"},
+}
+
+// cloudHypervisors maps the cloud providers to the hypervisor their instances run on.
+var cloudHypervisors = map[cloud.Type]string{
+	cloud.TypeAWS:     "kvm",
+	cloud.TypeGCP:     "kvm",
+	cloud.TypeAlibaba: "kvm",
+	cloud.TypeAzure:   "hyperv",
+}
+
+// detectVirtualization returns the hypervisor the host runs on. DMI data is looked up first, then the Xen
+// hypervisor type and finally the cloud provider. It returns "none" when the DMI data doesn't belong to any
+// known hypervisor, and "unknown" when nothing could be read.
+func detectVirtualization(cloudType cloud.Type) string {
+	vendors := readDMI("sys_vendor", "bios_vendor", "board_vendor")
+	products := readDMI("product_name", "product_version")
+
+	if name := matchDMIHypervisor(vendors, products); name != "" {
+		return name
+	}
+
+	if hypervisor, err := fs.ReadFirstLine(helpers.HostSys("/hypervisor/type")); err == nil && strings.TrimSpace(hypervisor) != "" {
+		return strings.ToLower(strings.TrimSpace(hypervisor))
+	}
+
+	if name, ok := cloudHypervisors[cloudType]; ok {
+		return name
+	}
+
+	if vendors != "" || products != "" {
+		return virtualizationNone
+	}
+
+	return virtualizationUnknown
+}
+
+// readDMI returns the lower-cased content of the given DMI id files, separated by new lines.
+func readDMI(files ...string) string {
+	var values []string
+	for _, file := range files {
+		value, err := fs.ReadFirstLine(helpers.HostSys("/class/dmi/id", file))
+		if err != nil {
+			hlog.WithError(err).WithField("file", file).Debug("Cannot read DMI data.")
+			continue
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, strings.ToLower(value))
+		}
+	}
+	return strings.Join(values, "\n")
+}
+
+func matchDMIHypervisor(vendors, products string) string {
+	for _, hypervisor := range dmiHypervisors {
+		if hypervisor.vendor != "" && !strings.Contains(vendors, hypervisor.vendor) {
+			continue
+		}
+		if hypervisor.product != "" && !strings.Contains(products, hypervisor.product) {
+			continue
+		}
+		return hypervisor.name
+	}
+	return ""
+}
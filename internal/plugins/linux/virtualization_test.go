@@ -0,0 +1,126 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux || darwin
+// +build linux darwin
+
+package linux
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSys writes the given files, relative to the sys directory, and points HOST_SYS to it.
+func mockSys(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	sysDir := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(sysDir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content+"\n"), 0644))
+	}
+	t.Setenv("HOST_SYS", sysDir)
+}
+
+func TestDetectVirtualization(t *testing.T) {
+	testCases := []struct {
+		name      string
+		files     map[string]string
+		cloudType cloud.Type
+		expected  string
+	}{
+		{
+			name: "KVM",
+			files: map[string]string{
+				"class/dmi/id/sys_vendor":   "QEMU",
+				"class/dmi/id/product_name": "Standard PC (Q35 + ICH9, 2009)",
+			},
+			expected: "kvm",
+		},
+		{
+			name: "VMware",
+			files: map[string]string{
+				"class/dmi/id/sys_vendor":   "VMware, Inc.",
+				"class/dmi/id/product_name": "VMware Virtual Platform",
+			},
+			expected: "vmware",
+		},
+		{
+			name: "HyperV",
+			files: map[string]string{
+				"class/dmi/id/sys_vendor":   "Microsoft Corporation",
+				"class/dmi/id/product_name": "Virtual Machine",
+			},
+			expected: "hyperv",
+		},
+		{
+			name: "MicrosoftBareMetal",
+			files: map[string]string{
+				"class/dmi/id/sys_vendor":   "Microsoft Corporation",
+				"class/dmi/id/product_name": "Surface Book",
+			},
+			expected: "none",
+		},
+		{
+			name: "VirtualBox",
+			files: map[string]string{
+				"class/dmi/id/sys_vendor":   "innotek GmbH",
+				"class/dmi/id/product_name": "VirtualBox",
+			},
+			expected: "virtualbox",
+		},
+		{
+			name: "XenOnAWS",
+			files: map[string]string{
+				"class/dmi/id/sys_vendor":   "Xen",
+				"class/dmi/id/product_name": "HVM domU",
+			},
+			cloudType: cloud.TypeAWS,
+			expected:  "xen",
+		},
+		{
+			name: "AWSNitro",
+			files: map[string]string{
+				"class/dmi/id/sys_vendor":   "Amazon EC2",
+				"class/dmi/id/product_name": "m5.large",
+			},
+			expected: "kvm",
+		},
+		{
+			name:     "XenHypervisorType",
+			files:    map[string]string{"hypervisor/type": "xen"},
+			expected: "xen",
+		},
+		{
+			name:      "CloudFallback",
+			cloudType: cloud.TypeAzure,
+			expected:  "hyperv",
+		},
+		{
+			name: "BareMetal",
+			files: map[string]string{
+				"class/dmi/id/sys_vendor":   "Dell Inc.",
+				"class/dmi/id/product_name": "PowerEdge R740",
+			},
+			cloudType: cloud.TypeNoCloud,
+			expected:  "none",
+		},
+		{
+			name:     "NoData",
+			expected: "unknown",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mockSys(t, testCase.files)
+			assert.Equal(t, testCase.expected, detectVirtualization(testCase.cloudType))
+		})
+	}
+}
@@ -109,7 +109,7 @@ func (self *MockAgent) GetServiceForPid(pid int) (service string, ok bool) {
 }
 
 func (self *MockAgent) CloudDetector() *cloud.Detector {
-	return cloud.NewDetector(true, 0, 0, 0, false)
+	return cloud.NewDetector(true, 0, 0, 0, false, nil)
 }
 
 func (m *MockAgent) AddReconnecting(agent.Plugin) {}
@@ -117,6 +117,11 @@ func (self *HostinfoPlugin) gatherHostinfo(context agent.AgentContext, info *hos
 	data.Ram = getRam()
 	data.UpSince = time.Unix(int64(info.BootTime), 0).Format("2006-01-02 15:04:05")
 	data.OperatingSystem = info.OS
+	data.OverrideOS(&data.WindowsPlatform, context.Config())
+
+	if context.Config() != nil && context.Config().EnableMachineID {
+		data.MachineID = getMachineID()
+	}
 
 	helpers.LogStructureDetails(hlog, data, "HostInfoData", "raw", nil)
 
@@ -158,6 +163,22 @@ func getRam() string {
 	return strconv.FormatUint(totalMem, 10)
 }
 
+// getMachineID returns the MachineGuid generated by Windows on installation.
+func getMachineID() string {
+	regKey, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Cryptography`, registry.QUERY_VALUE|registry.WOW64_64KEY)
+	if err != nil {
+		hlog.WithError(err).Debug("Error getting machine id from Windows Registry.")
+		return ""
+	}
+	defer regKey.Close()
+
+	machineID, _, err := regKey.GetStringValue("MachineGuid")
+	if err != nil {
+		hlog.WithError(err).Debug("Error getting machine id from Windows Registry.")
+	}
+	return machineID
+}
+
 func (self *HostinfoPlugin) getHostType() string {
 	hostType := "unknown"
 
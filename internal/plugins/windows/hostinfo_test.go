@@ -38,7 +38,7 @@ func (s *HostinfoSuite) SetUpTest(c *C) {
 }
 
 func (s *HostinfoSuite) NewPlugin(id ids.PluginID, c *C) *HostinfoPlugin {
-	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false)
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
 	v := NewHostinfoPlugin(id, s.agent,
 		common.NewHostInfoCommon("testing", true, cloudDetector))
 	plugin, ok := v.(*HostinfoPlugin)
@@ -0,0 +1,145 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build windows
+// +build windows
+
+package windows
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/internal/plugins/common"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+var stlog = log.WithComponent("ScheduledTasksPlugin")
+
+// Columns of the `schtasks /query /fo csv /v` output. Their names are localized, but not their positions.
+const (
+	schtasksColumnTaskName     = 1
+	schtasksColumnTaskToRun    = 8
+	schtasksColumnState        = 11
+	schtasksColumnRunAsUser    = 14
+	schtasksColumnScheduleType = 18
+	schtasksColumns            = 19
+)
+
+type ScheduledTasksPlugin struct {
+	agent.PluginCommon
+	frequency  time.Duration
+	redactArgs bool
+}
+
+// ScheduledTask task registered in the Windows Task Scheduler. Tasks with several triggers are reported once, with
+// the schedule type of their first trigger.
+type ScheduledTask struct {
+	Name     string `json:"id"`
+	User     string `json:"user"`
+	Schedule string `json:"schedule"`
+	Command  string `json:"command"`
+	State    string `json:"state"`
+}
+
+func (t ScheduledTask) SortKey() string {
+	return t.Name
+}
+
+func NewScheduledTasksPlugin(id ids.PluginID, ctx agent.AgentContext) agent.Plugin {
+	cfg := ctx.Config()
+	return &ScheduledTasksPlugin{
+		PluginCommon: agent.PluginCommon{ID: id, Context: ctx},
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.ScheduledTasksRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_SCHEDULED_TASKS,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+		redactArgs: cfg.ScheduledTasksRedactArgs,
+	}
+}
+
+// parseScheduledTasks parses the verbose CSV output of schtasks, which repeats the header row for each task folder.
+func parseScheduledTasks(r io.Reader, redactArgs bool) (dataset types.PluginInventoryDataset, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var header []string
+	seen := map[string]bool{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return dataset, err
+		}
+		if len(record) < schtasksColumns {
+			continue
+		}
+		if header == nil {
+			header = record
+			continue
+		}
+		if record[schtasksColumnTaskName] == header[schtasksColumnTaskName] {
+			continue
+		}
+
+		name := record[schtasksColumnTaskName]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		command := strings.TrimSpace(record[schtasksColumnTaskToRun])
+		if redactArgs {
+			command = common.RedactCommandArgs(command)
+		}
+		dataset = append(dataset, ScheduledTask{
+			Name:     name,
+			User:     strings.TrimSpace(record[schtasksColumnRunAsUser]),
+			Schedule: strings.TrimSpace(record[schtasksColumnScheduleType]),
+			Command:  command,
+			State:    strings.TrimSpace(record[schtasksColumnState]),
+		})
+	}
+	return dataset, nil
+}
+
+func (p *ScheduledTasksPlugin) getDataset() (types.PluginInventoryDataset, error) {
+	output, err := exec.Command("schtasks", "/query", "/fo", "csv", "/v").Output()
+	if err != nil {
+		return nil, fmt.Errorf("querying scheduled tasks: %w", err)
+	}
+	return parseScheduledTasks(strings.NewReader(string(output)), p.redactArgs)
+}
+
+func (p *ScheduledTasksPlugin) Run() {
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		stlog.Debug("Disabled.")
+		return
+	}
+
+	// Introduce some jitter to wait randomly before reporting based on frequency time
+	time.Sleep(config.JitterFrequency(p.frequency))
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		dataset, err := p.getDataset()
+		if err != nil {
+			stlog.WithError(err).Error("scheduled tasks plugin can't get dataset")
+		} else {
+			p.EmitInventory(dataset, entity.NewFromNameWithoutID(p.Context.EntityKey()))
+		}
+		<-refreshTimer.C
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build windows
+// +build windows
+
+package windows
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchtasksHeader = `"HostName","TaskName","Next Run Time","Status","Logon Mode","Last Run Time","Last Result","Author","Task To Run","Start In","Comment","Scheduled Task State","Idle Time","Power Management","Run As User","Delete Task If Not Rescheduled","Stop Task If Runs X Hours and X Mins","Schedule","Schedule Type","Start Time"`
+
+var testSchtasksOutput = strings.Join([]string{
+	testSchtasksHeader,
+	`"HOST","\Backup","1/1/2024 3:00:00 AM","Ready","Interactive/Background","N/A","267011","ops","""C:\Program Files\Backup\backup.exe"" /password secret","N/A","","Enabled","Disabled","","SYSTEM","Disabled","72:00:00","Scheduling data is not available in this format.","Daily ","3:00:00 AM"`,
+	`"HOST","\Backup","N/A","Ready","Interactive/Background","N/A","267011","ops","""C:\Program Files\Backup\backup.exe"" /password secret","N/A","","Enabled","Disabled","","SYSTEM","Disabled","72:00:00","Scheduling data is not available in this format.","At system start up","N/A"`,
+	"",
+	testSchtasksHeader,
+	`"HOST","\Microsoft\Windows\Defrag\ScheduledDefrag","N/A","Ready","Interactive/Background","N/A","1","Microsoft Corporation","%windir%\system32\defrag.exe -c -h -o","N/A","","Disabled","Disabled","","SYSTEM","Disabled","72:00:00","Scheduling data is not available in this format.","On demand only","N/A"`,
+}, "\r\n")
+
+func TestParseScheduledTasks(t *testing.T) {
+	tests := []struct {
+		name     string
+		redact   bool
+		expected types.PluginInventoryDataset
+	}{
+		{
+			name: "commands with arguments",
+			expected: types.PluginInventoryDataset{
+				ScheduledTask{Name: `\Backup`, User: "SYSTEM", Schedule: "Daily", Command: `"C:\Program Files\Backup\backup.exe" /password secret`, State: "Enabled"},
+				ScheduledTask{Name: `\Microsoft\Windows\Defrag\ScheduledDefrag`, User: "SYSTEM", Schedule: "On demand only", Command: `%windir%\system32\defrag.exe -c -h -o`, State: "Disabled"},
+			},
+		},
+		{
+			name:   "redacted arguments",
+			redact: true,
+			expected: types.PluginInventoryDataset{
+				ScheduledTask{Name: `\Backup`, User: "SYSTEM", Schedule: "Daily", Command: `"C:\Program Files\Backup\backup.exe" (redacted)`, State: "Enabled"},
+				ScheduledTask{Name: `\Microsoft\Windows\Defrag\ScheduledDefrag`, User: "SYSTEM", Schedule: "On demand only", Command: `%windir%\system32\defrag.exe (redacted)`, State: "Disabled"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataset, err := parseScheduledTasks(strings.NewReader(testSchtasksOutput), tt.redact)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, dataset)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package backoff
+
+import (
+	"fmt"
+
+	"github.com/newrelic/infrastructure-agent/internal/instrumentation"
+)
+
+const (
+	// StatusClassConnectionError is the status class of the submissions that didn't get any response.
+	StatusClassConnectionError = "connection_error"
+	// MetricsSender name of the metrics sender the submission retries are counted for.
+	MetricsSender = "metrics"
+	// InventorySender name of the inventory sender the submission retries are counted for.
+	InventorySender = "inventory"
+)
+
+// SubmissionRetries counts the retries of the metrics and inventory submissions by sender and by status class of
+// the response that caused them, as "5xx".
+var SubmissionRetries = instrumentation.AgentMetrics.NewCounterVec("nria_submission_retries_total",
+	"Retried submissions to the ingest endpoints, by sender and status class of the failure that caused them.",
+	"sender", "status_class")
+
+// StatusClass returns the class of a response status code, as "4xx" or "5xx". Status code 0 stands for
+// connection errors.
+func StatusClass(statusCode int) string {
+	if statusCode <= 0 {
+		return StatusClassConnectionError
+	}
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package http
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// clientCertificate provides the client certificate for mutual TLS connections. The key pair is loaded again
+// when the certificate or the key files are modified on disk.
+type clientCertificate struct {
+	certFile string
+	keyFile  string
+
+	lock        sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+func newClientCertificate(certFile, keyFile string) *clientCertificate {
+	return &clientCertificate{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+}
+
+// get can be assigned to tls.Config.GetClientCertificate. When the key pair cannot be reloaded, the previously
+// loaded one is kept.
+func (c *clientCertificate) get(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	certModTime, keyModTime := modTime(c.certFile), modTime(c.keyFile)
+	if c.cert != nil && certModTime.Equal(c.certModTime) && keyModTime.Equal(c.keyModTime) {
+		return c.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			plog.WithError(err).WithField("certFile", c.certFile).
+				Warn("cannot reload client certificate, using the previous one")
+			return c.cert, nil
+		}
+		return nil, err
+	}
+
+	c.cert = &cert
+	c.certModTime = certModTime
+	c.keyModTime = keyModTime
+	return c.cert, nil
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
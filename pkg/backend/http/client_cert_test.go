@@ -0,0 +1,96 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPair writes a self-signed certificate for the given common name, and its private key.
+func writeKeyPair(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func commonName(t *testing.T, c *clientCertificate) string {
+	t.Helper()
+
+	cert, err := c.get(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestClientCertificate_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	now := time.Now()
+	writeKeyPair(t, certFile, keyFile, "first", now.Add(-time.Minute))
+
+	clientCert := newClientCertificate(certFile, keyFile)
+	assert.Equal(t, "first", commonName(t, clientCert))
+
+	writeKeyPair(t, certFile, keyFile, "second", now)
+	assert.Equal(t, "second", commonName(t, clientCert))
+}
+
+func TestClientCertificate_KeepsPreviousOnReloadError(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeKeyPair(t, certFile, keyFile, "first", time.Now().Add(-time.Minute))
+
+	clientCert := newClientCertificate(certFile, keyFile)
+	assert.Equal(t, "first", commonName(t, clientCert))
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("invalid"), 0o600))
+	assert.Equal(t, "first", commonName(t, clientCert))
+}
+
+func TestClientCertificate_LoadError(t *testing.T) {
+	dir := t.TempDir()
+	clientCert := newClientCertificate(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+
+	_, err := clientCert.get(nil)
+	assert.Error(t, err)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	assert.Nil(t, newTLSConfig(&config.Config{}))
+
+	tlsConfig := newTLSConfig(&config.Config{ClientCertFile: "client.crt", ClientKeyFile: "client.key"})
+	require.NotNil(t, tlsConfig)
+	assert.NotNil(t, tlsConfig.GetClientCertificate)
+	assert.Nil(t, tlsConfig.RootCAs)
+}
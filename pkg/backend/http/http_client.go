@@ -6,18 +6,22 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 	"github.com/sirupsen/logrus"
 )
@@ -34,6 +38,16 @@ func GetHttpClient(
 	}
 }
 
+// ClientTimeoutFromConfig returns the timeout of the HTTP clients that submit data, as configured by
+// http_client_timeout, or ClientTimeout if it isn't a valid duration.
+func ClientTimeoutFromConfig(cfg *config.Config) time.Duration {
+	timeout, err := time.ParseDuration(cfg.HTTPClientTimeout)
+	if err != nil || timeout <= 0 {
+		return ClientTimeout
+	}
+	return timeout
+}
+
 func getCertPool(certFile string, certDirectory string) *x509.CertPool {
 	hlog := plog.WithFields(logrus.Fields{
 		"action":    "getCertPool",
@@ -100,20 +114,90 @@ func CheckEndpointReachability(
 	timeout time.Duration,
 	transport http.RoundTripper,
 ) (bool, error) {
-	var timedOut bool
+	c := CheckEndpointConnectivity(ctx, logger, endpointURL, license, userAgent, agentID, timeout, transport)
+	return c.TimedOut, c.Err
+}
+
+// EndpointConnectivity outcome of the reachability check of an endpoint.
+type EndpointConnectivity struct {
+	URL string
+	// ResolvedIPs addresses the host was resolved to. Through a proxy they are the ones of the proxy host.
+	ResolvedIPs []string
+	// TLSHandshake whether a TLS handshake was performed, and TLSHandshakeErr its error, if any.
+	TLSHandshake    bool
+	TLSHandshakeErr error
+	// TLSHandshakeDuration time taken by the TLS handshake, 0 if none was performed.
+	TLSHandshakeDuration time.Duration
+	// StatusCode of the response, 0 if the endpoint didn't reply.
+	StatusCode int
+	Latency    time.Duration
+	TimedOut   bool
+	Err        error
+}
+
+// CheckEndpointConnectivity sends a HEAD request to the endpoint, as CheckEndpointReachability, tracing the name
+// resolution and the TLS handshake of the connection.
+func CheckEndpointConnectivity(
+	ctx context.Context,
+	logger log.Entry,
+	endpointURL string,
+	license string,
+	userAgent string,
+	agentID string,
+	timeout time.Duration,
+	transport http.RoundTripper,
+) (c EndpointConnectivity) {
+	c.URL = endpointURL
 
 	request, err := buildRequest(ctx, endpointURL, "HEAD", userAgent, license, agentID)
 	if err != nil {
-		return false, err
+		c.Err = err
+		return
 	}
 
+	var lock sync.Mutex
+	var remoteIP string
+	var handshakeStart time.Time
+	request = request.WithContext(httptrace.WithClientTrace(request.Context(), &httptrace.ClientTrace{
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			lock.Lock()
+			defer lock.Unlock()
+			for _, addr := range info.Addrs {
+				c.ResolvedIPs = append(c.ResolvedIPs, addr.String())
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			lock.Lock()
+			defer lock.Unlock()
+			if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+				remoteIP = host
+			}
+		},
+		TLSHandshakeStart: func() {
+			lock.Lock()
+			defer lock.Unlock()
+			handshakeStart = time.Now()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			c.TLSHandshake = true
+			c.TLSHandshakeErr = err
+			if !handshakeStart.IsZero() {
+				c.TLSHandshakeDuration = time.Since(handshakeStart)
+			}
+		},
+	}))
+
 	client := GetHttpClient(timeout, transport)
 
 	// all status codes are acceptable as request has been replied by the endpoint
+	start := time.Now()
 	resp, err := client.Do(request)
+	c.Latency = time.Since(start)
 	if err != nil {
 		if e2, ok := err.(net.Error); ok && (e2.Timeout() || e2.Temporary()) {
-			timedOut = true
+			c.TimedOut = true
 		}
 		if _, ok := err.(*url.Error); ok {
 			logger.WithError(err).
@@ -121,15 +205,24 @@ func CheckEndpointReachability(
 				WithField("timeout", timeout).
 				WithField("url", endpointURL).
 				Debug("URL Error detected, may be configuration problem or network connectivity issue.")
-			timedOut = true
+			c.TimedOut = true
 		}
+		c.Err = err
 	}
 
 	if resp != nil {
+		c.StatusCode = resp.StatusCode
 		_ = resp.Body.Close()
 	}
 
-	return timedOut, err
+	lock.Lock()
+	defer lock.Unlock()
+	// no name resolution happens for IP hosts
+	if len(c.ResolvedIPs) == 0 && remoteIP != "" {
+		c.ResolvedIPs = []string{remoteIP}
+	}
+
+	return c
 }
 
 func CheckEndpointHealthiness(
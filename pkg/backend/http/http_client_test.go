@@ -3,12 +3,25 @@
 package http
 
 import (
+	"context"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func TestClientTimeoutFromConfig(t *testing.T) {
+	assert.Equal(t, 2*time.Minute, ClientTimeoutFromConfig(&config.Config{HTTPClientTimeout: "2m"}))
+	assert.Equal(t, ClientTimeout, ClientTimeoutFromConfig(&config.Config{HTTPClientTimeout: "invalid"}))
+	assert.Equal(t, ClientTimeout, ClientTimeoutFromConfig(&config.Config{}))
+}
+
 func TestCertPoolFunctionality(t *testing.T) {
 
 	initialCerts := len(systemCertPool().Subjects())
@@ -103,3 +116,43 @@ rFjnj1ey3aRxYZ9rwHDa7CFbATgp3mMYELNGDKUOV9vMrRTshxlZ+fzu9ypq8XAy
 xP/fwunAyWtQpRJsV2j4UKqO86+QcQqjQAye1n/6oo7RbH9UdNULaGwtG0p5xOmJ
 ub3qy4gaM7Xl/etf5MjsNKGgAt3gHnSWC9Zqgx4sP61XK3T/4JJaXVs=
 -----END CERTIFICATE-----`
+
+func TestCheckEndpointConnectivity(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		assert.Equal(t, "license", r.Header.Get(LicenseHeader))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	c := CheckEndpointConnectivity(context.Background(), log.WithComponent("test"), srv.URL, "license", "agent", "", time.Second, srv.Client().Transport)
+	require.NoError(t, c.Err)
+	assert.False(t, c.TimedOut)
+	assert.Equal(t, srv.URL, c.URL)
+	assert.Equal(t, http.StatusForbidden, c.StatusCode)
+	assert.Equal(t, []string{"127.0.0.1"}, c.ResolvedIPs)
+	assert.True(t, c.TLSHandshake)
+	assert.NoError(t, c.TLSHandshakeErr)
+	assert.Greater(t, c.TLSHandshakeDuration, time.Duration(0))
+}
+
+func TestCheckEndpointConnectivity_NoTLS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := CheckEndpointConnectivity(context.Background(), log.WithComponent("test"), srv.URL, "license", "agent", "", time.Second, &http.Transport{})
+	require.NoError(t, c.Err)
+	assert.False(t, c.TLSHandshake)
+	assert.Zero(t, c.TLSHandshakeDuration)
+}
+
+func TestCheckEndpointConnectivity_UntrustedCertificate(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := CheckEndpointConnectivity(context.Background(), log.WithComponent("test"), srv.URL, "license", "agent", "", time.Second, &http.Transport{})
+	assert.Error(t, c.Err)
+	assert.Equal(t, 0, c.StatusCode)
+	assert.True(t, c.TLSHandshake)
+	assert.Error(t, c.TLSHandshakeErr)
+}
@@ -0,0 +1,352 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package http
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/robertkrimen/otto"
+)
+
+const (
+	pacCacheTTL      = time.Hour
+	pacRetryInterval = time.Minute
+	pacEvalTimeout   = 5 * time.Second
+	pacMaxSize       = 1 << 20 // 1MiB
+)
+
+var (
+	errPacEvalTimeout = errors.New("PAC file evaluation timed out")
+)
+
+// pacFunctions implements the PAC helper functions that don't require name resolution.
+const pacFunctions = `
+function isPlainHostName(host) {
+	return host.indexOf('.') < 0;
+}
+function dnsDomainIs(host, domain) {
+	return host.length >= domain.length && host.substring(host.length - domain.length) === domain;
+}
+function localHostOrDomainIs(host, hostdom) {
+	return host === hostdom || (isPlainHostName(host) && hostdom.indexOf(host + '.') === 0);
+}
+function dnsDomainLevels(host) {
+	return host.split('.').length - 1;
+}
+function shExpMatch(str, shexp) {
+	return __shExpMatch(str, shexp);
+}
+`
+
+// pacProxy chooses the proxy of each request by running the FindProxyForURL function of a proxy auto-config
+// file. The PAC file is fetched when first needed and cached for pacCacheTTL. When it cannot be fetched, it is
+// retried after pacRetryInterval. While it's being fetched, the requests keep using the cached one.
+type pacProxy struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	lock      sync.Mutex
+	vm        *otto.Otto // nil when the PAC file couldn't be fetched
+	expiresAt time.Time
+	fetching  bool
+}
+
+func newPacProxy(pacURL string, client *http.Client) *pacProxy {
+	return &pacProxy{
+		url:    pacURL,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// script fetches the PAC file if the cached one expired, and returns a copy of its runtime, so it can be evaluated
+// without holding the lock. Nil is returned when no PAC file is available.
+func (p *pacProxy) script() *otto.Otto {
+	p.lock.Lock()
+	if p.fetching || p.now().Before(p.expiresAt) {
+		defer p.lock.Unlock()
+		return p.copyVM()
+	}
+	p.fetching = true
+	p.lock.Unlock()
+
+	vm, err := p.fetch()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.fetching = false
+	if err != nil {
+		plog.WithError(err).WithField("pacURL", p.url).
+			Warn("cannot fetch PAC file, using the proxy configuration instead")
+		p.vm = nil
+		p.expiresAt = p.now().Add(pacRetryInterval)
+		return nil
+	}
+
+	p.vm = vm
+	p.expiresAt = p.now().Add(pacCacheTTL)
+	return p.copyVM()
+}
+
+// copyVM must be called holding the lock.
+func (p *pacProxy) copyVM() *otto.Otto {
+	if p.vm == nil {
+		return nil
+	}
+	return p.vm.Copy()
+}
+
+func (p *pacProxy) fetch() (*otto.Otto, error) {
+	resp, err := p.client.Get(p.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	script, err := io.ReadAll(io.LimitReader(resp.Body, pacMaxSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return newPacVM(string(script))
+}
+
+// proxyForURL returns the proxy chosen by the PAC file runtime for the request URL, or nil for DIRECT connections.
+func proxyForURL(vm *otto.Otto, u *url.URL) (*url.URL, error) {
+	result, err := findProxyForURL(vm, u)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating PAC file: %w", err)
+	}
+
+	return parsePacResult(result)
+}
+
+// newPacVM returns a javascript runtime with the PAC helper functions and the given PAC file loaded.
+func newPacVM(script string) (*otto.Otto, error) {
+	vm := otto.New()
+	for name, fn := range map[string]interface{}{
+		"__shExpMatch": shExpMatch,
+		"dnsResolve":   dnsResolve,
+		"isResolvable": isResolvable,
+		"isInNet":      isInNet,
+		"myIpAddress":  myIPAddress,
+	} {
+		if err := vm.Set(name, fn); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := vm.Run(pacFunctions); err != nil {
+		return nil, err
+	}
+
+	if err := runWithTimeout(vm, func() error {
+		_, err := vm.Run(script)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("invalid PAC file: %w", err)
+	}
+
+	if fn, err := vm.Get("FindProxyForURL"); err != nil || !fn.IsFunction() {
+		return nil, errors.New("invalid PAC file: FindProxyForURL function is not defined")
+	}
+
+	return vm, nil
+}
+
+func findProxyForURL(vm *otto.Otto, u *url.URL) (result string, err error) {
+	err = runWithTimeout(vm, func() error {
+		value, err := vm.Call("FindProxyForURL", nil, u.String(), u.Hostname())
+		if err != nil {
+			return err
+		}
+		result = value.String()
+		return nil
+	})
+	return
+}
+
+// runWithTimeout interrupts the javascript runtime if fn doesn't finish after pacEvalTimeout.
+func runWithTimeout(vm *otto.Otto, fn func() error) (err error) {
+	defer func() {
+		if caught := recover(); caught != nil {
+			if caught == errPacEvalTimeout {
+				err = errPacEvalTimeout
+				return
+			}
+			panic(caught)
+		}
+	}()
+
+	vm.Interrupt = make(chan func(), 1)
+	timer := time.AfterFunc(pacEvalTimeout, func() {
+		vm.Interrupt <- func() {
+			panic(errPacEvalTimeout)
+		}
+	})
+	defer timer.Stop()
+
+	return fn()
+}
+
+// parsePacResult returns the proxy URL for the first entry of a FindProxyForURL result, as in
+// "PROXY proxy.example.com:8080; DIRECT". A nil URL is returned for DIRECT connections.
+func parsePacResult(result string) (*url.URL, error) {
+	entry := strings.TrimSpace(strings.Split(result, ";")[0])
+	fields := strings.Fields(entry)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	var scheme string
+	switch strings.ToUpper(fields[0]) {
+	case "DIRECT":
+		return nil, nil
+	case "PROXY", "HTTP":
+		scheme = "http"
+	case "HTTPS":
+		scheme = "https"
+	case "SOCKS", "SOCKS5":
+		scheme = "socks5"
+	default:
+		return nil, fmt.Errorf("unsupported PAC file proxy type: %q", fields[0])
+	}
+
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("invalid PAC file proxy: %q", entry)
+	}
+
+	return url.Parse(scheme + "://" + fields[1])
+}
+
+func shExpMatch(str, shexp string) bool {
+	pattern := regexp.QuoteMeta(shexp)
+	pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+	pattern = strings.ReplaceAll(pattern, `\?`, ".")
+	matched, err := regexp.MatchString("^"+pattern+"$", str)
+	return err == nil && matched
+}
+
+func dnsResolve(host string) string {
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return ""
+	}
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			return ip4.String()
+		}
+	}
+	return ""
+}
+
+func isResolvable(host string) bool {
+	return dnsResolve(host) != ""
+}
+
+func isInNet(host, pattern, mask string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		ip = net.ParseIP(dnsResolve(host))
+	}
+	network := net.ParseIP(pattern).To4()
+	maskIP := net.ParseIP(mask).To4()
+	if ip == nil || network == nil || maskIP == nil {
+		return false
+	}
+	ipMask := net.IPMask(maskIP)
+	return ip.Mask(ipMask).Equal(network.Mask(ipMask))
+}
+
+func myIPAddress() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "127.0.0.1"
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String()
+		}
+	}
+	return "127.0.0.1"
+}
+
+// pacTransport sends the requests through the proxy chosen by the PAC file, or through the fallback transport
+// when the PAC file is not available. Each proxy gets its own transport, built as the one of the proxy
+// configuration option.
+type pacTransport struct {
+	pac               *pacProxy
+	direct            http.RoundTripper
+	fallback          http.RoundTripper
+	newProxyTransport func(u *url.URL) http.RoundTripper
+
+	lock    sync.Mutex
+	proxies map[string]http.RoundTripper
+}
+
+// newPacTransport creates the transport for the proxy_pac_url configuration option. The PAC file is fetched
+// without proxy, validating the certificates with the CA bundle. The proxies chosen by the PAC file are used
+// as the proxy configuration option, including the proxy_validate_certificates handling.
+func newPacTransport(cfg *config.Config, tlsConfig *tls.Config, timeout time.Duration, fallback http.RoundTripper) http.RoundTripper {
+	client := &http.Client{
+		Transport: defaultHttpTransport(cfg, tlsConfig, timeout, nil),
+		Timeout:   timeout,
+	}
+
+	return &pacTransport{
+		pac:      newPacProxy(cfg.ProxyPacURL, client),
+		direct:   defaultHttpTransport(cfg, tlsConfig, timeout, nil),
+		fallback: fallback,
+		newProxyTransport: func(u *url.URL) http.RoundTripper {
+			return newProxyTransport(cfg, tlsConfig, timeout, u)
+		},
+		proxies: make(map[string]http.RoundTripper),
+	}
+}
+
+func (t *pacTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	vm := t.pac.script()
+	if vm == nil {
+		return t.fallback.RoundTrip(req)
+	}
+
+	u, err := proxyForURL(vm, req.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return t.direct.RoundTrip(req)
+	}
+
+	return t.proxyTransport(u).RoundTrip(req)
+}
+
+// proxyTransport returns the transport for the proxy u, reusing the transport already built for it.
+func (t *pacTransport) proxyTransport(u *url.URL) http.RoundTripper {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	transport, ok := t.proxies[u.String()]
+	if !ok {
+		transport = t.newProxyTransport(u)
+		t.proxies[u.String()] = transport
+	}
+	return transport
+}
@@ -0,0 +1,258 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPacFile = `
+function FindProxyForURL(url, host) {
+	if (isPlainHostName(host) || dnsDomainIs(host, ".internal.example.com")) {
+		return "DIRECT";
+	}
+	if (shExpMatch(host, "*.eu.newrelic.com")) {
+		return "HTTPS eu-proxy.example.com:8443; DIRECT";
+	}
+	return "PROXY proxy.example.com:8080; DIRECT";
+}
+`
+
+func pacServer(t *testing.T, script string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		_, _ = fmt.Fprint(w, script)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &fetches
+}
+
+func mustRequest(t *testing.T, rawURL string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, rawURL, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func TestPacProxy_FindProxyForURL(t *testing.T) {
+	server, _ := pacServer(t, testPacFile)
+	pac := newPacProxy(server.URL, server.Client())
+	vm := pac.script()
+	require.NotNil(t, vm)
+
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://infra-api.newrelic.com/infra/v2/metrics", expected: "http://proxy.example.com:8080"},
+		{url: "https://infra-api.eu.newrelic.com/infra/v2/metrics", expected: "https://eu-proxy.example.com:8443"},
+		{url: "http://collector.internal.example.com/metrics", expected: ""},
+		{url: "http://localhost:8080/metrics", expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.url, func(t *testing.T) {
+			u, err := proxyForURL(vm, mustRequest(t, testCase.url).URL)
+			require.NoError(t, err)
+			if testCase.expected == "" {
+				assert.Nil(t, u)
+			} else {
+				require.NotNil(t, u)
+				assert.Equal(t, testCase.expected, u.String())
+			}
+		})
+	}
+}
+
+func TestPacProxy_Cache(t *testing.T) {
+	server, fetches := pacServer(t, testPacFile)
+	pac := newPacProxy(server.URL, server.Client())
+	now := time.Now()
+	pac.now = func() time.Time { return now }
+
+	assert.NotNil(t, pac.script())
+	assert.NotNil(t, pac.script())
+	assert.EqualValues(t, 1, atomic.LoadInt32(fetches))
+
+	now = now.Add(pacCacheTTL + time.Second)
+	assert.NotNil(t, pac.script())
+	assert.EqualValues(t, 2, atomic.LoadInt32(fetches))
+}
+
+func TestPacProxy_FetchNotBlocking(t *testing.T) {
+	fetching := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(fetching)
+		<-release
+		_, _ = fmt.Fprint(w, testPacFile)
+	}))
+	defer server.Close()
+
+	pac := newPacProxy(server.URL, server.Client())
+	fetched := make(chan bool)
+	go func() { fetched <- pac.script() != nil }()
+	<-fetching
+
+	// the requests don't wait for the ongoing fetch, there is no PAC file cached yet
+	assert.Nil(t, pac.script())
+
+	close(release)
+	assert.True(t, <-fetched)
+	assert.NotNil(t, pac.script())
+}
+
+func TestPacProxy_FetchFailure(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	pac := newPacProxy(server.URL, server.Client())
+	assert.Nil(t, pac.script())
+}
+
+func TestPacProxy_InvalidScript(t *testing.T) {
+	testCases := map[string]string{
+		"syntax error":     "function FindProxyForURL(url, host) {",
+		"missing function": "var proxy = 'DIRECT';",
+	}
+
+	for name, script := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server, _ := pacServer(t, script)
+			assert.Nil(t, newPacProxy(server.URL, server.Client()).script())
+		})
+	}
+}
+
+func TestParsePacResult(t *testing.T) {
+	testCases := []struct {
+		result   string
+		expected string
+		wantErr  bool
+	}{
+		{result: "DIRECT"},
+		{result: ""},
+		{result: "PROXY proxy:3128", expected: "http://proxy:3128"},
+		{result: "  PROXY proxy:3128 ; HTTPS other:443", expected: "http://proxy:3128"},
+		{result: "HTTPS proxy:443", expected: "https://proxy:443"},
+		{result: "SOCKS5 socks:1080", expected: "socks5://socks:1080"},
+		{result: "FTP proxy:21", wantErr: true},
+		{result: "PROXY", wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.result, func(t *testing.T) {
+			u, err := parsePacResult(testCase.result)
+			if testCase.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if testCase.expected == "" {
+				assert.Nil(t, u)
+			} else {
+				assert.Equal(t, testCase.expected, u.String())
+			}
+		})
+	}
+}
+
+func TestPacHelpers(t *testing.T) {
+	assert.True(t, shExpMatch("infra-api.newrelic.com", "*.newrelic.com"))
+	assert.True(t, shExpMatch("host1", "host?"))
+	assert.False(t, shExpMatch("newrelic.com.evil", "*.newrelic.com"))
+	assert.True(t, isInNet("10.1.2.3", "10.0.0.0", "255.0.0.0"))
+	assert.False(t, isInNet("192.168.1.1", "10.0.0.0", "255.0.0.0"))
+}
+
+func TestBuildTransport_PacFallback(t *testing.T) {
+	var proxied int32
+	fallbackProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fallbackProxy.Close()
+
+	unavailablePac := httptest.NewServer(http.NotFoundHandler())
+	defer unavailablePac.Close()
+
+	cfg := &config.Config{
+		ProxyPacURL:       unavailablePac.URL,
+		Proxy:             fallbackProxy.URL,
+		IgnoreSystemProxy: true,
+	}
+	client := &http.Client{Transport: BuildTransport(cfg, time.Second)}
+
+	resp, err := client.Get("http://collector.invalid/metrics")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&proxied))
+}
+
+func TestBuildTransport_PacProxy(t *testing.T) {
+	var proxied int32
+	pacChosenProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer pacChosenProxy.Close()
+
+	proxyURL, err := url.Parse(pacChosenProxy.URL)
+	require.NoError(t, err)
+	server, _ := pacServer(t, fmt.Sprintf(`function FindProxyForURL(url, host) { return "PROXY %s"; }`, proxyURL.Host))
+
+	cfg := &config.Config{
+		ProxyPacURL:       server.URL,
+		IgnoreSystemProxy: true,
+	}
+	client := &http.Client{Transport: BuildTransport(cfg, time.Second)}
+
+	resp, err := client.Get("http://collector.invalid/metrics")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&proxied))
+}
+
+func TestBuildTransport_PacProxyValidateCerts(t *testing.T) {
+	var proxied int32
+	pacChosenProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer pacChosenProxy.Close()
+
+	proxyURL, err := url.Parse(pacChosenProxy.URL)
+	require.NoError(t, err)
+	server, _ := pacServer(t, fmt.Sprintf(`function FindProxyForURL(url, host) { return "PROXY %s"; }`, proxyURL.Host))
+
+	// as with the proxy option, HTTP proxies are only warned about when certificates validation is set
+	cfg := &config.Config{
+		ProxyPacURL:        server.URL,
+		ProxyValidateCerts: true,
+		IgnoreSystemProxy:  true,
+	}
+	client := &http.Client{Transport: BuildTransport(cfg, time.Second)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get("http://collector.invalid/metrics")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.EqualValues(t, 2, atomic.LoadInt32(&proxied))
+	assert.Len(t, client.Transport.(*pacTransport).proxies, 1)
+}
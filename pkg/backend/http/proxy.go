@@ -39,26 +39,48 @@ func proxyWithError(err error) proxyFunc {
 	}
 }
 
+// newTLSConfig returns the TLS configuration with the CA bundle and the client certificate, or nil if none of
+// them is configured.
+func newTLSConfig(cfg *config.Config) *tls.Config {
+	var tlsConfig *tls.Config
+	if cfg.CABundleFile != "" || cfg.CABundleDir != "" {
+		tlsConfig = &tls.Config{RootCAs: getCertPool(cfg.CABundleFile, cfg.CABundleDir)}
+	}
+	if cfg.ClientCertFile != "" && cfg.ClientKeyFile != "" {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		tlsConfig.GetClientCertificate = newClientCertificate(cfg.ClientCertFile, cfg.ClientKeyFile).get
+	}
+	return tlsConfig
+}
+
 func defaultHttpTransport(
-	certFile string,
-	certDirectory string,
+	cfg *config.Config,
+	tlsConfig *tls.Config,
 	httpTimeout time.Duration,
 	p proxyFunc,
 ) *http.Transport {
-	var cfg *tls.Config
-	if certFile != "" || certDirectory != "" {
-		cfg = &tls.Config{RootCAs: getCertPool(certFile, certDirectory)}
+	// transports may modify their TLS configuration, so they don't share it
+	if tlsConfig != nil {
+		tlsConfig = tlsConfig.Clone()
 	}
 	// go default Http Transport
-	return &http.Transport{
+	t := &http.Transport{
 		Proxy:                 p,
 		DialContext:           (&net.Dialer{Timeout: httpTimeout, KeepAlive: 30 * time.Second}).DialContext,
 		MaxIdleConns:          100,
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   httpTimeout,
 		ExpectContinueTimeout: 1 * time.Second,
-		TLSClientConfig:       cfg,
+		TLSClientConfig:       tlsConfig,
+	}
+	if cfg.DisableHTTP2 {
+		// a non-nil empty TLSNextProto keeps the connections on HTTP/1.1, even when the server offers HTTP/2
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
 	}
+	return t
 }
 
 // Proxy configuration, storing the URL of the proxy (nil if there is no proxy), or an error in case the URL is wrongly
@@ -126,13 +148,26 @@ func proxyByPriority(cfg *config.Config) proxyConfig {
 // If the configuration option ignore_system_proxy is set, it ignores the HTTPS_PROXY and HTTP_PROXY configuration
 // If the configuration option proxy_validate_certificates is set, it will force the HTTPS proxy options to verify the
 // certificates
+//
+// If the configuration option proxy_pac_url is set, the proxy is chosen by the PAC file, and the above
+// configuration is only used while the PAC file cannot be fetched.
+//
+// If the configuration option disable_http2 is set, the connections are kept on HTTP/1.1.
 func BuildTransport(cfg *config.Config, timeout time.Duration) http.RoundTripper {
+	tlsConfig := newTLSConfig(cfg)
+	if cfg.ProxyPacURL != "" {
+		return newPacTransport(cfg, tlsConfig, timeout, buildProxyTransport(cfg, tlsConfig, timeout))
+	}
+	return buildProxyTransport(cfg, tlsConfig, timeout)
+}
+
+func buildProxyTransport(cfg *config.Config, tlsConfig *tls.Config, timeout time.Duration) http.RoundTripper {
 	proxyConfig := proxyByPriority(cfg)
 
 	if proxyConfig.isEmpty() {
 		return defaultHttpTransport(
-			cfg.CABundleFile,
-			cfg.CABundleDir,
+			cfg,
+			tlsConfig,
 			timeout,
 			nil, // no proxy configuration
 		)
@@ -150,8 +185,8 @@ func BuildTransport(cfg *config.Config, timeout time.Duration) http.RoundTripper
 		err = fmt.Errorf("invalid proxy address %q: %v", proxyConfig.raw, err)
 		logrus.WithError(err).Error()
 		return defaultHttpTransport(
-			cfg.CABundleFile,
-			cfg.CABundleDir,
+			cfg,
+			tlsConfig,
 			timeout,
 			proxyWithError(err))
 	}
@@ -160,15 +195,21 @@ func BuildTransport(cfg *config.Config, timeout time.Duration) http.RoundTripper
 		err = fmt.Errorf("schema from %s must be %q", proxyConfig.source, proxyConfig.forceSchema)
 		logrus.WithError(err).Error()
 		return defaultHttpTransport(
-			cfg.CABundleFile,
-			cfg.CABundleDir,
+			cfg,
+			tlsConfig,
 			timeout,
 			proxyWithError(err))
 	}
 
+	return newProxyTransport(cfg, tlsConfig, timeout, u)
+}
+
+// newProxyTransport returns a transport sending the requests through the proxy u. The HTTPS proxy certificates are
+// validated according to the proxy_validate_certificates option.
+func newProxyTransport(cfg *config.Config, tlsConfig *tls.Config, timeout time.Duration, u *url.URL) *http.Transport {
 	t := defaultHttpTransport(
-		cfg.CABundleFile,
-		cfg.CABundleDir,
+		cfg,
+		tlsConfig,
 		timeout,
 		proxy(u),
 	)
@@ -0,0 +1,84 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package http
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+)
+
+// http2Server starts a TLS server offering HTTP/2, and returns it along with a CA bundle file trusting it.
+func http2Server(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, certPEM, 0600))
+	return srv, caFile
+}
+
+func TestBuildTransport_DisableHTTP2(t *testing.T) {
+	srv, caFile := http2Server(t)
+
+	cfg := &config.Config{CABundleFile: caFile, IgnoreSystemProxy: true, DisableHTTP2: true}
+	transport := BuildTransport(cfg, time.Second)
+	httpTransport, ok := transport.(*http.Transport)
+	require.True(t, ok)
+	assert.False(t, httpTransport.ForceAttemptHTTP2)
+	assert.NotNil(t, httpTransport.TLSNextProto)
+	assert.Empty(t, httpTransport.TLSNextProto)
+
+	resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "HTTP/1.1", resp.Header.Get("X-Proto"))
+}
+
+func TestBuildTransport_DisableHTTP2_Proxies(t *testing.T) {
+	for name, cfg := range map[string]*config.Config{
+		"proxy": {Proxy: "http://proxy.invalid:3128", DisableHTTP2: true},
+		"pac":   {ProxyPacURL: "http://pac.invalid/proxy.pac", DisableHTTP2: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			transport := BuildTransport(cfg, time.Second)
+
+			transports := []http.RoundTripper{transport}
+			if pac, ok := transport.(*pacTransport); ok {
+				proxyURL, err := url.Parse("http://proxy.invalid:3128")
+				require.NoError(t, err)
+				transports = []http.RoundTripper{pac.direct, pac.fallback, pac.proxyTransport(proxyURL)}
+			}
+			for _, transport := range transports {
+				httpTransport, ok := transport.(*http.Transport)
+				require.True(t, ok)
+				assert.NotNil(t, httpTransport.TLSNextProto)
+				assert.Empty(t, httpTransport.TLSNextProto)
+			}
+		})
+	}
+}
+
+func TestBuildTransport_HTTP2NotDisabled(t *testing.T) {
+	cfg := &config.Config{IgnoreSystemProxy: true}
+	httpTransport, ok := BuildTransport(cfg, time.Second).(*http.Transport)
+	require.True(t, ok)
+	assert.Nil(t, httpTransport.TLSNextProto)
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/instrumentation"
+)
+
+// SubmissionStatuses records the outcome of the submissions to the ingest endpoints.
+var SubmissionStatuses = NewSubmissionStatusRegistry()
+
+var submissionsMetric = instrumentation.AgentMetrics.NewCounterVec(
+	"nria_submissions_total", "Submissions to the ingest endpoints, by endpoint and outcome.", "endpoint", "outcome")
+
+// SubmissionStatus is the outcome of the submissions to an ingest endpoint. The fields of the outcomes that didn't
+// happen yet are nil, so they are reported as null.
+type SubmissionStatus struct {
+	Endpoint string `json:"endpoint"`
+	// LastSuccess time of the last submission answered with a 2xx status code.
+	LastSuccess *time.Time `json:"last_success"`
+	// LastError error of the last failed submission and its time.
+	LastError     *string    `json:"last_error"`
+	LastErrorTime *time.Time `json:"last_error_time"`
+}
+
+// SubmissionStatusRegistry keeps the submission outcomes by ingest endpoint.
+type SubmissionStatusRegistry struct {
+	lock     sync.Mutex
+	statuses map[string]*SubmissionStatus
+	now      func() time.Time
+}
+
+// NewSubmissionStatusRegistry creates an empty SubmissionStatusRegistry.
+func NewSubmissionStatusRegistry() *SubmissionStatusRegistry {
+	return &SubmissionStatusRegistry{
+		statuses: map[string]*SubmissionStatus{},
+		now:      time.Now,
+	}
+}
+
+// Register adds an endpoint, so it's reported before its first submission.
+func (r *SubmissionStatusRegistry) Register(endpoint string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.status(endpoint)
+}
+
+// Record records the outcome of a submission to the endpoint: a success when the response has a 2xx status code,
+// otherwise the request error or the response status. It's also counted by the nria_submissions_total agent metric.
+func (r *SubmissionStatusRegistry) Record(endpoint string, resp *http.Response, err error) {
+	if err == nil && resp != nil && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		err = fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	status := r.status(endpoint)
+	if err != nil {
+		submissionsMetric.Inc(endpoint, "failure")
+		errMsg := err.Error()
+		status.LastError = &errMsg
+		status.LastErrorTime = &now
+		return
+	}
+	submissionsMetric.Inc(endpoint, "success")
+	status.LastSuccess = &now
+}
+
+// Statuses returns a copy of the submission statuses, sorted by endpoint.
+func (r *SubmissionStatusRegistry) Statuses() []SubmissionStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	statuses := make([]SubmissionStatus, 0, len(r.statuses))
+	for _, status := range r.statuses {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Endpoint < statuses[j].Endpoint
+	})
+	return statuses
+}
+
+// status returns the status of the endpoint, adding it if it's missing. It must be called holding the lock.
+func (r *SubmissionStatusRegistry) status(endpoint string) *SubmissionStatus {
+	status, ok := r.statuses[endpoint]
+	if !ok {
+		status = &SubmissionStatus{Endpoint: endpoint}
+		r.statuses[endpoint] = status
+	}
+	return status
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package http
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmissionStatusRegistry(t *testing.T) {
+	r := NewSubmissionStatusRegistry()
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	r.Register("https://metrics")
+	r.Register("https://inventory")
+	r.Record("https://inventory", &http.Response{StatusCode: http.StatusAccepted}, nil)
+
+	now = now.Add(time.Minute)
+	r.Record("https://inventory", &http.Response{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}, nil)
+	r.Record("https://dimensional", nil, errors.New("connection refused"))
+
+	statuses := r.Statuses()
+	require.Len(t, statuses, 3)
+
+	assert.Equal(t, "https://dimensional", statuses[0].Endpoint)
+	assert.Nil(t, statuses[0].LastSuccess)
+	require.NotNil(t, statuses[0].LastError)
+	assert.Equal(t, "connection refused", *statuses[0].LastError)
+
+	assert.Equal(t, "https://inventory", statuses[1].Endpoint)
+	require.NotNil(t, statuses[1].LastSuccess)
+	assert.Equal(t, now.Add(-time.Minute), *statuses[1].LastSuccess)
+	require.NotNil(t, statuses[1].LastError)
+	assert.Equal(t, "unexpected response status: 503 Service Unavailable", *statuses[1].LastError)
+	assert.Equal(t, now, *statuses[1].LastErrorTime)
+
+	// never submitted
+	assert.Equal(t, SubmissionStatus{Endpoint: "https://metrics"}, statuses[2])
+}
@@ -34,3 +34,10 @@ func (t *requestDecorator) RoundTrip(req *http.Request) (*http.Response, error)
 
 	return t.rt.RoundTrip(req)
 }
+
+// CloseIdleConnections closes the idle connections of the decorated transport, if it supports it.
+func (t *requestDecorator) CloseIdleConnections() {
+	if closer, ok := t.rt.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/newrelic/infrastructure-agent/internal/agent/id"
 	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
@@ -25,6 +27,8 @@ type IngestError struct {
 	Status     string
 	StatusCode int
 	Body       string
+	// RetryAfter wait requested by the backend through the Retry-After header, if any.
+	RetryAfter time.Duration
 }
 
 func (e *IngestError) Error() string {
@@ -33,7 +37,16 @@ func (e *IngestError) Error() string {
 
 // NewIngestError returns a new IngestError.
 func NewIngestError(msg string, code int, status, body string) *IngestError {
-	return &IngestError{msg, status, code, body}
+	return &IngestError{msg: msg, Status: status, StatusCode: code, Body: body}
+}
+
+// newIngestErrorFromResponse returns the IngestError for a response that didn't accept the deltas.
+func newIngestErrorFromResponse(resp *http.Response, body string) *IngestError {
+	err := NewIngestError("inventory deltas were not accepted", resp.StatusCode, resp.Status, body)
+	if retryAfter, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && retryAfter > 0 {
+		err.RetryAfter = time.Duration(retryAfter) * time.Second
+	}
+	return err
 }
 
 type IngestClient struct {
@@ -58,8 +71,10 @@ func NewIngestClient(
 	if compressionLevel < gzip.NoCompression || compressionLevel > gzip.BestCompression {
 		return nil, fmt.Errorf("gzip: invalid compression level: %d", compressionLevel)
 	}
+	svcUrl = strings.TrimSuffix(svcUrl, "/")
+	backendhttp.SubmissionStatuses.Register(svcUrl)
 	return &IngestClient{
-		svcUrl:           strings.TrimSuffix(svcUrl, "/"),
+		svcUrl:           svcUrl,
 		licenseKey:       licenseKey,
 		userAgent:        userAgent,
 		agentKey:         agentKey,
@@ -97,7 +112,9 @@ func (i *IngestClient) Do(req *http.Request) (*http.Response, error) {
 		req.Header.Set(backendhttp.AgentEntityIdHeader, agentIdn.ID.String())
 	}
 
-	return i.HttpClient(req)
+	resp, err := i.HttpClient(req)
+	backendhttp.SubmissionStatuses.Record(i.svcUrl, resp, err)
+	return resp, err
 }
 
 // PostDeltas posts deltas to inventory ingest. The deltas are assumed to all be coming from one
@@ -144,7 +161,7 @@ func (ic *IngestClient) PostDeltas(entityKeys []string, entityID entity.ID, isAg
 	}
 
 	if resp.StatusCode != http.StatusAccepted {
-		return nil, NewIngestError("inventory deltas were not accepted", resp.StatusCode, resp.Status, string(body))
+		return nil, newIngestErrorFromResponse(resp, string(body))
 	}
 
 	var res struct {
@@ -222,7 +239,7 @@ func (ic *IngestClient) PostDeltasBulk(reqs []PostDeltaBody) ([]BulkDeltaRespons
 	}
 
 	if resp.StatusCode != http.StatusAccepted {
-		return res.Payload, NewIngestError("inventory deltas were not accepted", resp.StatusCode, resp.Status, string(body))
+		return res.Payload, newIngestErrorFromResponse(resp, string(body))
 	}
 
 	return res.Payload, nil
@@ -16,8 +16,6 @@ import (
 	"time"
 
 	http2 "github.com/newrelic/infrastructure-agent/pkg/http"
-	"github.com/newrelic/infrastructure-agent/pkg/log"
-	"github.com/sirupsen/logrus"
 )
 
 // Harvester aggregates and reports metrics and spans.
@@ -276,7 +274,7 @@ func (r response) needsRetry(_ *Config, attempts int) (bool, time.Duration) {
 }
 
 func postData(req *http.Request, client *http.Client) response {
-	if log.IsLevelEnabled(logrus.TraceLevel) {
+	if http2.TracingEnabled() {
 		req = http2.WithTracer(req, "harvester")
 	}
 
@@ -8,6 +8,7 @@ package config
 
 import (
 	"compress/gzip"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,6 +17,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -27,6 +29,7 @@ import (
 	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
 	"github.com/sirupsen/logrus"
 
+	"github.com/newrelic/infrastructure-agent/pkg/config/envvar"
 	config_loader "github.com/newrelic/infrastructure-agent/pkg/config/loader"
 	"github.com/newrelic/infrastructure-agent/pkg/databind/pkg/data"
 	"github.com/newrelic/infrastructure-agent/pkg/disk"
@@ -49,6 +52,9 @@ const (
 	// LogFilterWildcard will match everything.
 	LogFilterWildcard = "*"
 
+	// LogJSONDataKey is the field holding the log entry fields when json format is used.
+	LogJSONDataKey = "context"
+
 	envPrefix                 = "nria"
 	ModeUnknown               = ""
 	ModeRoot                  = "root"
@@ -64,6 +70,16 @@ const (
 	agentTemporaryFolderName  = "tmp"
 )
 
+// Truncation policies of the integration payloads exceeding their max size.
+const (
+	// TruncationPolicyDropLine drops the whole payload.
+	TruncationPolicyDropLine = "drop_line"
+	// TruncationPolicyDropOldestDatasets drops the first datasets of the payload until it fits.
+	TruncationPolicyDropOldestDatasets = "drop_oldest_datasets"
+	// TruncationPolicyTruncateAttributes shortens the string attribute values of the payload until it fits.
+	TruncationPolicyTruncateAttributes = "truncate_attributes"
+)
+
 const (
 	// LogLevelSmart keeps a limited cache of debug logs and output them only when an error happens.
 	LogLevelSmart string = "smart"
@@ -90,6 +106,12 @@ var (
 
 type CustomAttributeMap map[string]interface{}
 
+// TruncatedValueMarker suffixes the attribute values truncated by custom_attributes_max_value_bytes.
+const TruncatedValueMarker = "...[truncated]"
+
+// truncatedAttributes keys of the attributes whose values were truncated, to warn only once.
+var truncatedAttributes sync.Map
+
 type MetricsMap map[string][]string
 
 // IncludeMetricsMap configuration type to Map include_matching_metrics setting env var
@@ -98,7 +120,23 @@ type IncludeMetricsMap MetricsMap
 // IncludeMetricsMap configuration type to Map exclude_matching_metrics setting env var.
 type ExcludeMetricsMap MetricsMap
 
+// logStandardFields are the fields added by the logger to every entry, that can be renamed in the json format.
+var logStandardFields = map[string]bool{
+	logrus.FieldKeyTime:        true,
+	logrus.FieldKeyLevel:       true,
+	logrus.FieldKeyMsg:         true,
+	logrus.FieldKeyFunc:        true,
+	logrus.FieldKeyFile:        true,
+	logrus.FieldKeyLogrusError: true,
+}
+
+// defaultLogJSONFieldMap standard fields renamed by default in the json format.
+var defaultLogJSONFieldMap = map[string]string{
+	logrus.FieldKeyTime: "timestamp",
+}
+
 // LogFilters configuration specifies which log entries should be included/excluded.
+// Values prefixed with "re:" are matched as regular expressions.
 type LogFilters map[string][]interface{}
 
 // Provider will retrieve the configuration.
@@ -167,6 +205,9 @@ func (dc *DynamicConfig) isEmpty() bool {
 type Config struct {
 	dynamicConfig *DynamicConfig `databind:"ignored"`
 
+	// flooredSampleRates metrics samplers whose configured sample rate was raised to the minimum value.
+	flooredSampleRates map[string]bool `databind:"ignored"`
+
 	// Databind provides varaiable (secrets, discovery) replacement capabilities for the configuration.
 	Databind databind.YAMLAgentConfig `yaml:",inline" public:"false"`
 
@@ -235,6 +276,14 @@ type Config struct {
 	// Public: Yes
 	Proxy string `yaml:"proxy" envconfig:"proxy"`
 
+	// ProxyPacURL URL of a proxy auto-config (PAC) file. When set, the proxy for each request is chosen by the
+	// FindProxyForURL function of the PAC file, which is cached for an hour. If the PAC file cannot be fetched, the
+	// proxy option or the HTTPS_PROXY and HTTP_PROXY environment variables are used instead. The
+	// proxy_validate_certificates option applies to the PAC file proxies as to the proxy option.
+	// Default: ""
+	// Public: Yes
+	ProxyPacURL string `yaml:"proxy_pac_url" envconfig:"proxy_pac_url"`
+
 	// ProxyValidateCerts If set to true, when the proxy is configured to use an HTTPS connection, it will only work
 	// when the HTTPS proxy has certificates from a valid Certificate Authority, or when the ca_bundle_file or
 	// ca_bundle_dir configuration properties contain the HTTPS proxy certificates.
@@ -260,6 +309,18 @@ type Config struct {
 	// Public: Yes
 	IgnoreReclaimable bool `yaml:"ignore_reclaimable" envconfig:"ignore_reclaimable"`
 
+	// ZombieProcessCount When enabled, SystemSample reports the number of zombie (defunct) processes in the
+	// zombieProcessCount attribute.
+	// Default: True
+	// Public: Yes
+	ZombieProcessCount bool `yaml:"zombie_process_count" envconfig:"zombie_process_count" os:"linux"`
+
+	// ArpEntryCount When enabled, SystemSample reports the number of entries of the ARP (neighbor) table in the
+	// arpEntryCount attribute. Useful on hosts acting as gateways or routers.
+	// Default: False
+	// Public: Yes
+	ArpEntryCount bool `yaml:"arp_entry_count" envconfig:"arp_entry_count" os:"linux"`
+
 	// DisplayName overrides the auto-generated hostname for reporting. This is useful when you have multiple hosts
 	// with the same name, since Infrastructure uses the hostname as the unique identifier for each host.
 	// Keep in mind this value is also used for the loopback address replacement on entity names.
@@ -269,6 +330,13 @@ type Config struct {
 	// Public: Yes
 	DisplayName string `yaml:"display_name" envconfig:"display_name"`
 
+	// DisplayNameAppendInstanceID When true and the DisplayName, or the hostname when it's not set, looks like a default
+	// name likely shared with other hosts (e.g. the cloud provider assigned hostname), the cloud instance ID is appended
+	// to it to prevent entity merges. A warning is logged for those names regardless of this option.
+	// Default: False
+	// Public: Yes
+	DisplayNameAppendInstanceID bool `yaml:"display_name_append_instance_id" envconfig:"display_name_append_instance_id"`
+
 	// DisableInventorySplit By default the agent splits the inventory data into small groups bounded by the value of
 	// the config option MaxInventorySize; if this option is set to true, the inventory won't be splitted and the agent
 	// will try to send it all in a single request.
@@ -295,11 +363,27 @@ type Config struct {
 
 	// CustomAttributes is a list of custom attributes to annotate the data from this agent instance. Separate keys and
 	// values with colons :, as in KEY: VALUE, and separate each key-value pair with a line break. Keys can be any
-	// valid YAML except slashes /. Values can be any YAML string, including spaces.
+	// valid YAML except slashes /. Values can be any YAML string, including spaces. ${VAR} references within values
+	// are replaced by the value of the VAR environment variable, $${VAR} stands for a literal ${VAR}. They can be
+	// changed by reloading the configuration.
 	// Default: Empty
 	// Public: Yes
 	CustomAttributes CustomAttributeMap `yaml:"custom_attributes" envconfig:"custom_attributes"`
 
+	// CustomAttributesMaxValueBytes Maximum size in bytes of the custom attributes values, and of the labels
+	// integrations annotate their metrics with. Longer values are truncated to this size, ending with "...[truncated]".
+	// When 0, values are not truncated.
+	// Default: 0
+	// Public: Yes
+	CustomAttributesMaxValueBytes int `yaml:"custom_attributes_max_value_bytes" envconfig:"custom_attributes_max_value_bytes"`
+
+	// DeploymentMarker identifies the deployment or release running in the host, as a release version set by the CI
+	// during deploys. When set, it's added as the deploymentMarker attribute of every submitted sample and event, so
+	// they can be correlated with releases. It can be changed by reloading the configuration.
+	// Default: Empty
+	// Public: Yes
+	DeploymentMarker string `yaml:"deployment_marker" envconfig:"deployment_marker"`
+
 	// Verbose When verbose is set to 0, verbose logging is off, but the agent still creates logs. Set this to 1 to
 	// create verbose logs to use in troubleshooting the agent. You can set this to 2 to use Smart Verbose Logs. Set to
 	// 3 to forward debug logs to FluentBit. To enable log traces set this to 4, and to 5 to forward traces to FluentBit.
@@ -337,6 +421,13 @@ type Config struct {
 	// Public: No
 	WebProfile bool `yaml:"web_profile" envconfig:"web_profile" public:"false"`
 
+	// AllowRemoteProfiling When enabled, CPU and heap profiles of the agent can be captured on request through the
+	// capture_profile command-channel command. Profiles are written under AgentDir and can be downloaded once from the
+	// status server with the token logged by the agent.
+	// Default: False
+	// Public: Yes
+	AllowRemoteProfiling bool `yaml:"allow_remote_profiling" envconfig:"allow_remote_profiling"`
+
 	// StripCommandLine When true, the agent removes the command arguments from the 'commandLine' attribute of the
 	// ProcessSample. This is a security measure to prevent leaking sensitive information.
 	// Default: True
@@ -355,6 +446,58 @@ type Config struct {
 	// Public: Yes
 	OverrideHostnameShort string `yaml:"override_hostname_short" envconfig:"override_hostname_short"`
 
+	// OSLabelOverride When set, this is the value that will be reported for the OS label (the distribution on
+	// Linux and macOS, the platform on Windows); otherwise, the detected value is reported.
+	// Default: ""
+	// Public: Yes
+	OSLabelOverride string `yaml:"os_label_override" envconfig:"os_label_override"`
+
+	// OSFamilyOverride When set, this is the value that will be reported for the operating system family;
+	// otherwise, the detected value is reported.
+	// Default: ""
+	// Public: Yes
+	OSFamilyOverride string `yaml:"os_family_override" envconfig:"os_family_override"`
+
+	// EnableVirtualizationDetection When enabled, the agent detects the hypervisor the host runs on, from the DMI
+	// data and the cloud provider, and reports it as the virtualization host attribute ("none" on bare metal).
+	// Default: False
+	// Public: Yes
+	EnableVirtualizationDetection bool `yaml:"enable_virtualization_detection" envconfig:"enable_virtualization_detection" os:"linux"`
+
+	// EnableMachineID When enabled, the machine id is reported as the machine_id host attribute, so hosts can be
+	// correlated across re-imaging or hostname changes. It is read from /etc/machine-id (falling back to
+	// /var/lib/dbus/machine-id) on Linux, and from the MachineGuid registry value on Windows.
+	// Default: False
+	// Public: Yes
+	EnableMachineID bool `yaml:"enable_machine_id" envconfig:"enable_machine_id"`
+
+	// EnablePublicIP When enabled, the public IP of the host is reported as the public_ip host attribute. It's read
+	// from the cloud provider metadata and, when not available there (e.g. hosts behind a NAT), it's requested to the
+	// PublicIPReflectorURL, if set. Disabled by default, as the public IP may be considered sensitive.
+	// Default: False
+	// Public: Yes
+	EnablePublicIP bool `yaml:"enable_public_ip" envconfig:"enable_public_ip"`
+
+	// PublicIPReflectorURL URL of an external service answering with the public IP address of the caller in the
+	// response body, used when the public IP is not available from the cloud metadata. The request goes through the
+	// agent proxy and TLS configuration. When empty, only the cloud metadata is used.
+	// Default: ""
+	// Public: Yes
+	PublicIPReflectorURL string `yaml:"public_ip_reflector_url" envconfig:"public_ip_reflector_url"`
+
+	// PublicIPTTL Time duration the detected public IP is cached before it's detected again.
+	// Valid time units are: "s" (seconds), "m" (minutes), "h" (hours).
+	// Default: 1h
+	// Public: Yes
+	PublicIPTTL string `yaml:"public_ip_ttl" envconfig:"public_ip_ttl"`
+
+	// EnableHostLocale When enabled, the host locale and its character set are reported as the locale and charset
+	// host attributes. They are read from /etc/locale.conf (or /etc/default/locale), overridden by the LANG, LC_CTYPE
+	// and LC_ALL environment variables of the agent.
+	// Default: False
+	// Public: Yes
+	EnableHostLocale bool `yaml:"enable_host_locale" envconfig:"enable_host_locale" os:"linux"`
+
 	// OverrideHostProc When set, this will change the base directory used when constructing paths for location
 	// inside /proc/. This allows us to mock the filesystem in order to make tests.
 	// Default: ""
@@ -421,6 +564,13 @@ type Config struct {
 	// Public: Yes
 	AgentDir string `yaml:"agent_dir" envconfig:"agent_dir"`
 
+	// IdentityCacheFile is the file where the agent persists the entity ID resolved for the host entity between
+	// restarts. Useful on read-only root filesystems or custom layouts. When empty, the ID is stored together with
+	// the inventory data under AgentDir.
+	// Default: ""
+	// Public: Yes
+	IdentityCacheFile string `yaml:"identity_cache_file" envconfig:"identity_cache_file"`
+
 	// SafeBinDir is the directory where the agent expects to see executables for Integrations
 	// Default (Linux): /opt/newrelic-infra
 	// Default (MacOS): /usr/local/var/db/newrelic-infra/
@@ -474,6 +624,25 @@ type Config struct {
 	// Public: Yes
 	PidFile string `yaml:"pid_file" envconfig:"pid_file" os:"linux"`
 
+	// LivenessFile Path of a file the agent writes the current time and an increasing counter to every
+	// liveness_interval_sec, so an external watchdog can detect the agent hangs. The file stops being written when no
+	// sample is produced within the liveness_grace_period_sec, and it's removed on graceful shutdown. If empty, no
+	// file is written.
+	// Default: empty
+	// Public: Yes
+	LivenessFile string `yaml:"liveness_file" envconfig:"liveness_file"`
+
+	// LivenessIntervalSec Interval in seconds the liveness_file is written at.
+	// Default: 15
+	// Public: Yes
+	LivenessIntervalSec int `yaml:"liveness_interval_sec" envconfig:"liveness_interval_sec"`
+
+	// LivenessGracePeriodSec Time in seconds without any sample produced after which the liveness_file stops being
+	// written. It should be longer than the longest metrics sample rate.
+	// Default: 120
+	// Public: Yes
+	LivenessGracePeriodSec int `yaml:"liveness_grace_period_sec" envconfig:"liveness_grace_period_sec"`
+
 	// MaxInventorySize sets the maximum size allowed for inventory data. If a plugin's inventory data exceeds this
 	// value it will be dropped. Inventory deltas will be grouped in batches bounded by this value before being sent
 	// to the NewRelic platform.
@@ -481,6 +650,12 @@ type Config struct {
 	// Public: No
 	MaxInventorySize int `yaml:"max_inventory_size" envconfig:"max_inventory_size" public:"false"`
 
+	// InventoryDroppedMetricEnabled enables the agent self-instrumentation metric "agent.inventoryDropped", counting
+	// per plugin the inventory data dropped for exceeding the MaxInventorySize. Drops are always logged.
+	// Default: False
+	// Public: No
+	InventoryDroppedMetricEnabled bool `yaml:"inventory_dropped_metric_enabled" envconfig:"inventory_dropped_metric_enabled" public:"false"`
+
 	// MaxProcs specifies the number of logical processors available to the agent. Increasing this value can help to
 	// distribute the load between different cores. Default value is 1. If value is set to -1 then it will try to read
 	// the environment variable GOMAXPROCS. If that variable is not set then the default value will be the total
@@ -507,12 +682,52 @@ type Config struct {
 	// Public: Yes
 	MetricsNetworkSampleRate int `yaml:"metrics_network_sample_rate" envconfig:"metrics_network_sample_rate"`
 
+	// MetricsTCPSampleRate Sample rate of Network Connection Samples, counting the TCP sockets of the host by state,
+	// in seconds. Minimum value is 10. If value is -1 then the sampler is disabled.
+	// Default: 30
+	// Public: Yes
+	MetricsTCPSampleRate int `yaml:"metrics_tcp_sample_rate" envconfig:"metrics_tcp_sample_rate"`
+
 	// MetricsProcessSampleRate Sample rate of System Samples in seconds. Minimum value is 20. If value is -1 then
 	// the sampler is disabled.
 	// Default: 20
 	// Public: Yes
 	MetricsProcessSampleRate int `yaml:"metrics_process_sample_rate" envconfig:"metrics_process_sample_rate"`
 
+	// MetricsSampleRateOverrides Sample rate in seconds per metrics sampler, keyed by sampler name: "system",
+	// "storage", "network", "tcp", "process" and "nfs". An override takes precedence over the corresponding
+	// metrics_*_sample_rate option and is subject to the same minimum value. Unknown sampler names are ignored.
+	// Default: Empty
+	// Public: Yes
+	MetricsSampleRateOverrides map[string]int `yaml:"metrics_sample_rate_overrides" envconfig:"metrics_sample_rate_overrides"`
+
+	// DisableSamplerStagger When enabled, every metrics sampler reports its first sample one whole interval after
+	// the agent starts, instead of after a random fraction of its interval. The random start staggers the samplers,
+	// so their samples are not submitted together.
+	// Default: False
+	// Public: Yes
+	DisableSamplerStagger bool `yaml:"disable_sampler_stagger" envconfig:"disable_sampler_stagger"`
+
+	// EnableSampleRatesInventory When enabled, the effective sample rate of each metrics sampler is reported as
+	// inventory, once minimum values and overrides are applied, so the actual sampling cadence can be checked.
+	// Default: False
+	// Public: Yes
+	EnableSampleRatesInventory bool `yaml:"enable_sample_rates_inventory" envconfig:"enable_sample_rates_inventory"`
+
+	// EnableRuntimeInventory When enabled, the Go runtime settings of the agent are reported as inventory: the Go
+	// version, the effective GOMAXPROCS along with the max_procs option and the GOMAXPROCS environment variable it's
+	// resolved from, and the garbage collector target percentage and memory limit.
+	// Default: False
+	// Public: Yes
+	EnableRuntimeInventory bool `yaml:"enable_runtime_inventory" envconfig:"enable_runtime_inventory"`
+
+	// EmitStartupEvent When enabled, an InfrastructureAgentStartup event is emitted once the agent starts, summarizing
+	// the run mode, agent user and version, detected cloud, effective log level and which major features are enabled.
+	// It's also emitted in forward only mode.
+	// Default: True
+	// Public: Yes
+	EmitStartupEvent bool `yaml:"emit_startup_event" envconfig:"emit_startup_event"`
+
 	// HeartBeatSampleRate Interval in seconds for sending the HeartBeatSample.
 	// Default: False
 	// Public: No
@@ -540,6 +755,12 @@ type Config struct {
 	// Public: Yes
 	FileDevicesIgnored []string `yaml:"file_devices_ignored" envconfig:"file_devices_ignored"`
 
+	// StorageMountOptions When enabled, StorageSamples include the options the filesystem is mounted with (e.g.
+	// "rw,nosuid,nodev,noexec"), as read from the mounts file, in the mountOptions attribute.
+	// Default: False
+	// Public: Yes
+	StorageMountOptions bool `yaml:"storage_mount_options" envconfig:"storage_mount_options"`
+
 	// NetworkInterfaceFilters You can use the network interface filters configuration to hide unused or uninteresting
 	// network interfaces from the Infrastructure agent. This helps reduce resource usage, work, and noise in your data.
 	// Default: Empty
@@ -564,6 +785,18 @@ type Config struct {
 	// Public: Yes
 	CABundleDir string `yaml:"ca_bundle_dir" envconfig:"ca_bundle_dir"`
 
+	// ClientCertFile Client certificate file, in PEM format, presented in the TLS connections to New Relic or to the
+	// HTTPS proxy, for those requiring mutual TLS. It requires client_key_file. The certificate is reloaded when the
+	// files change.
+	// Default: ""
+	// Public: Yes
+	ClientCertFile string `yaml:"client_cert_file" envconfig:"client_cert_file"`
+
+	// ClientKeyFile Private key file, in PEM format, of the client_cert_file certificate.
+	// Default: ""
+	// Public: Yes
+	ClientKeyFile string `yaml:"client_key_file" envconfig:"client_key_file"`
+
 	// SupervisorRpcSocket Location of the supervisor (http://supervisord.org/) socket.
 	// Default: /var/run/supervisor.sock
 	// Public: Yes
@@ -587,6 +820,14 @@ type Config struct {
 	// Public: Yes
 	DpkgRefreshSec int64 `yaml:"dpkg_interval_sec" envconfig:"dpkg_interval_sec"`
 
+	// PackagesNormalizedInventory enables reporting the packages collected by the Rpm and Dpkg plugins also under the
+	// packages/installed-rpm and packages/installed-dpkg inventory sources, with a common schema (name, version,
+	// architecture and package manager) for all the distros. Only the dpkg packages currently installed are reported.
+	// It follows the Rpm and Dpkg plugins intervals.
+	// Default: False
+	// Public: Yes
+	PackagesNormalizedInventory bool `yaml:"packages_normalized_inventory" envconfig:"packages_normalized_inventory"`
+
 	// DaemontoolsRefreshSec Sampling period / interval in seconds for Daemontools plugin. Set as value -1 for
 	// disabling it. 10 is the minimum value
 	// Default: 15
@@ -646,6 +887,13 @@ type Config struct {
 	// Public: Yes
 	UpstartIntervalSec int64 `yaml:"upstart_interval_sec" envconfig:"upstart_interval_sec"`
 
+	// InitSystemAutodetect detects the init system managing the host on startup and skips registering the
+	// service-manager plugins (Systemd, Upstart, SysV) that don't apply to it. A plugin is still registered when its
+	// sampling interval is explicitly set to a positive value.
+	// Default: False
+	// Public: Yes
+	InitSystemAutodetect bool `yaml:"init_system_autodetect" envconfig:"init_system_autodetect"`
+
 	// NetworkInterfaceIntervalSec Sampling period / interval in seconds for NetworkInterface plugin. Set as value -1
 	// for disabling it. 30 is the minimum value.
 	// Default: 60
@@ -658,6 +906,14 @@ type Config struct {
 	// Public: Yes
 	CloudSecurityGroupRefreshSec int64 `yaml:"cloud_security_group_refresh_sec" envconfig:"cloud_security_group_refresh_sec"`
 
+	// CloudLifecycleRefreshSec Sampling period / interval in seconds for the cloud lifecycle plugin, which reports
+	// whether the instance is a spot, preemptible or low priority one and whether the provider noticed an upcoming
+	// interruption, emitting an event when it does. Supported on AWS, GCP and Azure. Disabled by default to avoid
+	// extra metadata calls, set as value 0 to use the default interval (30), otherwise 30 is the minimum value.
+	// Default: -1
+	// Public: Yes
+	CloudLifecycleRefreshSec int64 `yaml:"cloud_lifecycle_refresh_sec" envconfig:"cloud_lifecycle_refresh_sec"`
+
 	// KernelModulesRefreshSec Sampling period / interval in seconds for KernelModules plugin. Set as value -1
 	// for disabling it. 10 is the minimum value.
 	// Default: 10
@@ -670,12 +926,104 @@ type Config struct {
 	// Public: Yes
 	UsersRefreshSec int64 `yaml:"users_refresh_sec" envconfig:"users_refresh_sec"`
 
+	// UsersSessionsEnabled reports the login sessions of the users plugin: who is logged in, from which terminal and
+	// remote host, and since when, as registered in the utmp file (honoring HOST_VAR).
+	// Default: False
+	// Public: Yes
+	UsersSessionsEnabled bool `yaml:"users_sessions_enabled" envconfig:"users_sessions_enabled" os:"linux"`
+
 	// SshdConfigRefreshSec Sampling period / interval in seconds for Sshd plugin. Set as value -1
 	// for disabling it. 10 is the minimum value.
 	// Default: 15
 	// Public: Yes
 	SshdConfigRefreshSec int64 `yaml:"sshd_config_refresh_sec" envconfig:"sshd_config_refresh_sec"`
 
+	// SshHostKeysRefreshSec Sampling period / interval in seconds for the SSH host keys plugin, which reports the
+	// type and SHA256 fingerprint of the SSH host public keys in /etc/ssh. Disabled by default, set as value 0 to use
+	// the default interval (60), otherwise 30 is the minimum value.
+	// Default: -1
+	// Public: Yes
+	SshHostKeysRefreshSec int64 `yaml:"ssh_host_keys_refresh_sec" envconfig:"ssh_host_keys_refresh_sec" os:"linux"`
+
+	// DnsConfigRefreshSec Sampling period / interval in seconds for the DNS config plugin, which reports the
+	// nameservers and search domains from /etc/resolv.conf. Disabled by default, set as value 0 to use the default
+	// interval (60), otherwise 30 is the minimum value.
+	// Default: -1
+	// Public: Yes
+	DnsConfigRefreshSec int64 `yaml:"dns_config_refresh_sec" envconfig:"dns_config_refresh_sec"`
+
+	// HostsFileRefreshSec Sampling period / interval in seconds for the hosts file plugin, which reports the static
+	// host mappings from /etc/hosts. Disabled by default, set as value 0 to use the default interval (60), otherwise
+	// 30 is the minimum value.
+	// Default: -1
+	// Public: Yes
+	HostsFileRefreshSec int64 `yaml:"hosts_file_refresh_sec" envconfig:"hosts_file_refresh_sec"`
+
+	// HostsFileRedactAddresses replaces the IP addresses reported by the hosts file plugin, so only the hostnames
+	// defined in /etc/hosts are reported.
+	// Default: False
+	// Public: Yes
+	HostsFileRedactAddresses bool `yaml:"hosts_file_redact_addresses" envconfig:"hosts_file_redact_addresses"`
+
+	// NetworkRoutesRefreshSec Sampling period / interval in seconds for the network routes plugin, which reports the
+	// host IPv4 routing table from /proc/net/route, including the default gateway. Disabled by default, set as value
+	// 0 to use the default interval (60), otherwise 30 is the minimum value.
+	// Default: -1
+	// Public: Yes
+	NetworkRoutesRefreshSec int64 `yaml:"network_routes_refresh_sec" envconfig:"network_routes_refresh_sec" os:"linux"`
+
+	// SudoersRefreshSec Sampling period / interval in seconds for the sudoers plugin, which reports a summary of the
+	// privilege escalation rules defined in /etc/sudoers and its included files: number of rules and whether any of
+	// them allows running commands without password. Rules themselves are not reported. Disabled by default, set as
+	// value 0 to use the default interval (60), otherwise 30 is the minimum value.
+	// Default: -1
+	// Public: Yes
+	SudoersRefreshSec int64 `yaml:"sudoers_refresh_sec" envconfig:"sudoers_refresh_sec" os:"linux"`
+
+	// FirewallRulesRefreshSec Sampling period / interval in seconds for the firewall rules plugin, which reports the
+	// number of rules and the policy of each iptables, ip6tables and nftables chain, so firewall changes can be
+	// detected. Rules themselves are only reported when FirewallRulesReportText is enabled. Disabled by default, set
+	// as value 0 to use the default interval (60), otherwise 30 is the minimum value.
+	// Default: -1
+	// Public: Yes
+	FirewallRulesRefreshSec int64 `yaml:"firewall_rules_refresh_sec" envconfig:"firewall_rules_refresh_sec" os:"linux"`
+
+	// FirewallRulesReportText reports the text of the rules of each chain, besides their count, in the firewall rules
+	// plugin.
+	// Default: False
+	// Public: Yes
+	FirewallRulesReportText bool `yaml:"firewall_rules_report_text" envconfig:"firewall_rules_report_text" os:"linux"`
+
+	// PackageUpdatesRefreshSec Sampling period / interval in seconds for the package updates plugin, which reports
+	// the packages with an available update, according to the host package manager (apt, dnf, yum or zypper).
+	// Disabled by default, set as value 0 to use the default interval (3600), otherwise 30 is the minimum value.
+	// Default: -1
+	// Public: Yes
+	PackageUpdatesRefreshSec int64 `yaml:"package_updates_refresh_sec" envconfig:"package_updates_refresh_sec" os:"linux"`
+
+	// FirmwareRefreshSec Sampling period / interval in seconds for the firmware plugin, which reports the BIOS vendor,
+	// version and date, and the board vendor, name and serial, from the DMI data in /sys/class/dmi/id. Fields that
+	// cannot be read, such as the board serial when not running as root, are not reported. Disabled by default, set
+	// as value 0 to use the default interval (3600), otherwise 30 is the minimum value.
+	// Default: -1
+	// Public: Yes
+	FirmwareRefreshSec int64 `yaml:"firmware_refresh_sec" envconfig:"firmware_refresh_sec" os:"linux"`
+
+	// ContainerRuntimeRefreshSec Sampling period / interval in seconds for the container runtime plugin, which
+	// reports the version of the container runtimes running in the host (Docker, containerd and podman), queried
+	// through their sockets. The Docker host is taken from the DOCKER_HOST environment variable when set, and queried
+	// with the docker_api_version API version. Disabled by default, set as value 0 to use the default interval (3600),
+	// otherwise 30 is the minimum value.
+	// Default: -1
+	// Public: Yes
+	ContainerRuntimeRefreshSec int64 `yaml:"container_runtime_refresh_sec" envconfig:"container_runtime_refresh_sec" os:"linux"`
+
+	// PackageUpdatesReportList reports every package with an available update, besides the total count of pending
+	// updates reported by the package updates plugin.
+	// Default: False
+	// Public: Yes
+	PackageUpdatesReportList bool `yaml:"package_updates_report_list" envconfig:"package_updates_report_list" os:"linux"`
+
 	// WindowsServicesRefreshSec Sampling period / interval in seconds for WindowsServices plugin. Set as value -1
 	// for disabling it. 10 is the minimum value.
 	// Default: 30
@@ -688,6 +1036,20 @@ type Config struct {
 	// Public: Yes
 	WindowsUpdatesRefreshSec int64 `yaml:"windows_updates_refresh_sec" envconfig:"windows_updates_refresh_sec" os:"windows"`
 
+	// ScheduledTasksRefreshSec Sampling period / interval in seconds for the scheduled tasks plugin, which reports
+	// the system and user cron jobs on Linux (from /etc/crontab, /etc/cron.d and /var/spool/cron), and the scheduled
+	// tasks on Windows. Disabled by default, set as value 0 to use the default interval (60), otherwise 30 is the
+	// minimum value.
+	// Default: -1
+	// Public: Yes
+	ScheduledTasksRefreshSec int64 `yaml:"scheduled_tasks_refresh_sec" envconfig:"scheduled_tasks_refresh_sec"`
+
+	// ScheduledTasksRedactArgs replaces the arguments of the commands reported by the scheduled tasks plugin, so
+	// only the executed program is reported. Arguments often hold credentials, as database passwords.
+	// Default: True
+	// Public: Yes
+	ScheduledTasksRedactArgs bool `yaml:"scheduled_tasks_redact_args" envconfig:"scheduled_tasks_redact_args"`
+
 	// LogToStdout By default all logs are displayed in both standard output and a log file. If you want to disable
 	// logs in the standard output you can set this configuration option to FALSE.
 	// Default: True
@@ -712,6 +1074,13 @@ type Config struct {
 	// Public: Yes
 	PayloadCompressionLevel int `yaml:"payload_compression_level" envconfig:"payload_compression_level"`
 
+	// InventoryCompressionLevel sets the gzip compression level of the inventory payloads, overriding the
+	// PayloadCompressionLevel for them. Metrics payloads keep using the PayloadCompressionLevel. Invalid levels are
+	// ignored. Same levels as the PayloadCompressionLevel.
+	// Default: Empty (the PayloadCompressionLevel)
+	// Public: Yes
+	InventoryCompressionLevel *int `yaml:"inventory_compression_level" envconfig:"inventory_compression_level"`
+
 	// PartitionsTTL Time duration to expire the cached list of storage partitions.
 	// Default: 60s
 	// Public: No
@@ -730,6 +1099,62 @@ type Config struct {
 	// Public: Yes
 	StartupConnectionRetries int `yaml:"startup_connection_retries" envconfig:"startup_connection_retries"`
 
+	// HTTPClientTimeout Time duration to wait before timing-out the requests the agent makes to send data, as
+	// metrics or inventory, and to poll the command channel. Increase it on high-latency links.
+	// Default: 30s
+	// Public: Yes
+	HTTPClientTimeout string `yaml:"http_client_timeout" envconfig:"http_client_timeout"`
+
+	// ShutdownFlushTimeout Time duration the agent waits on shutdown for the queued events and the pending inventory
+	// deltas to be sent, once the samplers are stopped. The events not sent by then are dropped, unless the offline
+	// buffer is enabled, which stores them to be sent on the next start. It should be lower than the 10 seconds the
+	// agent service waits for the agent to exit, and than the systemd TimeoutStopSec.
+	// Set it to 0s to exit without flushing.
+	// Default: 5s
+	// Public: Yes
+	ShutdownFlushTimeout string `yaml:"shutdown_flush_timeout" envconfig:"shutdown_flush_timeout"`
+
+	// DisableHTTP2 When enabled, the connections the agent opens to send data, to poll the command channel and to
+	// fetch its identity are kept on HTTP/1.1, even if the server or a proxy offers HTTP/2. Use it behind proxies
+	// that mishandle HTTP/2.
+	// Default: False
+	// Public: Yes
+	DisableHTTP2 bool `yaml:"disable_http2" envconfig:"disable_http2"`
+
+	// SubmissionRetryMax Number of times a metrics submission failed with a server or connection error is retried
+	// before dropping its samples. Inventory deltas are kept until accepted, so they are always retried. The retries
+	// are counted by the nria_submission_retries_total counter served on the agent_metrics_endpoint.
+	// Default: 0
+	// Public: Yes
+	SubmissionRetryMax int `yaml:"submission_retry_max" envconfig:"submission_retry_max"`
+
+	// SubmissionRetryBackoffBaseSec Initial backoff in seconds before retrying a failed metrics or inventory
+	// submission. The backoff doubles on each consecutive failure.
+	// Default: 1
+	// Public: Yes
+	SubmissionRetryBackoffBaseSec int `yaml:"submission_retry_backoff_base_sec" envconfig:"submission_retry_backoff_base_sec"`
+
+	// SubmissionRetryBackoffMaxSec Maximum backoff in seconds before retrying a failed metrics or inventory
+	// submission. The wait never exceeds the Retry-After header sent by the backend. When 0, the built-in maximums
+	// are used: 300 for metrics and 120 for inventory. Invalid license and rate limiting errors keep their longer
+	// backoffs.
+	// Default: 0
+	// Public: Yes
+	SubmissionRetryBackoffMaxSec int `yaml:"submission_retry_backoff_max_sec" envconfig:"submission_retry_backoff_max_sec"`
+
+	// OfflineBufferDir Directory where the metrics batches that cannot be submitted because of a server or connection
+	// error, once retried, are stored. They are submitted, with their original timestamps, once the submissions
+	// succeed again. The stored batches are kept across agent restarts. Disabled when empty.
+	// Default: Empty
+	// Public: Yes
+	OfflineBufferDir string `yaml:"offline_buffer_dir" envconfig:"offline_buffer_dir"`
+
+	// OfflineBufferMaxMB Maximum size in megabytes of the metrics batches stored in the offline_buffer_dir. The
+	// oldest batches are discarded when it's exceeded.
+	// Default: 100
+	// Public: Yes
+	OfflineBufferMaxMB int `yaml:"offline_buffer_max_mb" envconfig:"offline_buffer_max_mb"`
+
 	// FingerprintUpdateFreqSec Defines the frequency in seconds for the agent to reconnect and update the current
 	// fingerprint with its assigned entity ID for the connect.
 	// Default: 60
@@ -771,11 +1196,25 @@ type Config struct {
 	// Public: Yes
 	InventoryQueueLen int `yaml:"inventory_queue_len" envconfig:"inventory_queue_len" public:"true"`
 
+	// InventoryMinSubmissionIntervalSec sets the minimum interval in seconds between inventory deltas submissions.
+	// Inventory changes happening in between are coalesced into the next submission. Zero value submits the deltas
+	// as frequently as they are generated.
+	// Default: 0
+	// Public: Yes
+	InventoryMinSubmissionIntervalSec int64 `yaml:"inventory_min_submission_interval_sec" envconfig:"inventory_min_submission_interval_sec"`
+
 	// AsyncInventoryHandlerEnabled when set to true, enables the inventory handler that parallelize processing that allows handling larger inventory payloads.
 	// Default: false
 	// Public: no
 	AsyncInventoryHandlerEnabled bool `yaml:"async_inventory_handler_enabled" envconfig:"async_inventory_handler_enabled" public:"false"`
 
+	// InventoryHandlerConcurrency sets the number of workers processing the inventory data when the async inventory
+	// handler is enabled. The inventory of an entity is always processed by the same worker, so several workers only
+	// help when reporting inventory for several entities. Values lower than 1 run a single worker.
+	// Default: 1
+	// Public: No
+	InventoryHandlerConcurrency int `yaml:"inventory_handler_concurrency" envconfig:"inventory_handler_concurrency" public:"false"`
+
 	// EnableWinUpdatePlugin enables the windows updates plugin which retrieves the lists of hotfix that are installed
 	// on the host.
 	// Default: False
@@ -802,6 +1241,13 @@ type Config struct {
 	// Public: No
 	CompactThreshold uint64 `yaml:"compaction_threshold" envconfig:"compaction_threshold" public:"false"`
 
+	// CompactionIntervalHours Number of hours between delta storage compactions regardless of the storage size, when
+	// the CompactEnabled config option is set to true. The storage is still compacted when it surpasses the
+	// CompactThreshold, whichever happens first. Set to 0 to compact only by size.
+	// Default: 0
+	// Public: Yes
+	CompactionIntervalHours int `yaml:"compaction_interval_hours" envconfig:"compaction_interval_hours"`
+
 	// IgnoredInventoryPaths is not a configurable option. It maps the values from ignored_inventory config option
 	// Default: Empty
 	// Public: No
@@ -888,6 +1334,34 @@ type Config struct {
 	// Public: Yes
 	PassthroughEnvironment []string `yaml:"passthrough_environment" envconfig:"passthrough_environment"`
 
+	// IntegrationsMaxPayloadBytes Max size in bytes of the payloads of an integration, keyed by integration name as
+	// the name of its config entry. Oversized payloads are handled by the IntegrationsTruncationPolicy of the
+	// integration. The payloads of integrations not listed are not limited.
+	// Default: Empty
+	// Public: Yes
+	IntegrationsMaxPayloadBytes map[string]int `yaml:"integrations_max_payload_bytes" envconfig:"integrations_max_payload_bytes"`
+
+	// IntegrationsTruncationPolicy How the payloads exceeding the IntegrationsMaxPayloadBytes of an integration are
+	// handled, keyed by integration name: "drop_line" drops the whole payload, "drop_oldest_datasets" drops its first
+	// datasets until it fits and "truncate_attributes" shortens its string attribute values until it fits. Payloads
+	// that still don't fit are dropped.
+	// Default: drop_line
+	// Public: Yes
+	IntegrationsTruncationPolicy map[string]string `yaml:"integrations_truncation_policy" envconfig:"integrations_truncation_policy"`
+
+	// IntegrationsExitHistorySize Number of the last exits, with their exit code and duration, kept for every
+	// integration and reported by the status server at /v1/status/integrations. Helps diagnosing intermittent
+	// integration failures. When 0, the exits are not kept.
+	// Default: 10
+	// Public: Yes
+	IntegrationsExitHistorySize int `yaml:"integrations_exit_history_size" envconfig:"integrations_exit_history_size"`
+
+	// IntegrationsExitEventsEnabled When enabled, an InfrastructureEvent is submitted every time an integration exits
+	// with a non-zero exit code, reporting the exit code and the duration of the execution.
+	// Default: False
+	// Public: Yes
+	IntegrationsExitEventsEnabled bool `yaml:"integrations_exit_events_enabled" envconfig:"integrations_exit_events_enabled"`
+
 	// PluginConfigFiles This configuration parameter specify the agent to look for newrelic-infra-plugins.yml
 	// Default: Empty
 	// Public: No
@@ -903,6 +1377,13 @@ type Config struct {
 	// Public: Yes
 	LoggingConfigsDir string `yaml:"logging_configs_dir" envconfig:"logging_configs_dir" public:"true"`
 
+	// ConfigReloadCoalesceWindowMs Time in milliseconds an integrations or log forwarding configuration file must stay
+	// unchanged before the agent reloads it. The changes received within the window, as a file saved in several writes
+	// or many files deployed at once, are coalesced into a single reload.
+	// Default: 500
+	// Public: Yes
+	ConfigReloadCoalesceWindowMs int `yaml:"config_reload_coalesce_window_ms" envconfig:"config_reload_coalesce_window_ms"`
+
 	// LoggingBinDir folder containing binaries for the log forwarder.
 	// Default: /var/db/newrelic-infra/newrelic-integrations/logging/
 	// Public: No
@@ -920,6 +1401,45 @@ type Config struct {
 	// Public: Yes
 	LoggingRetryLimit string `yaml:"logging_retry_limit" envconfig:"logging_retry_limit" public:"true"`
 
+	// MaxLogSources caps the number of log sources (entries of the logging configuration files) forwarded by the
+	// log forwarder. Sources over the limit are discarded and a warning is logged. Zero or negative means no limit.
+	// Default: 0
+	// Public: Yes
+	MaxLogSources int `yaml:"max_log_sources" envconfig:"max_log_sources" public:"true"`
+
+	// LoggingCommonAttributes is a set of attributes added to the records of all the forwarded log sources, in
+	// addition to the per-source attributes. Reserved attribute names (e.g. hostname) are ignored.
+	// Default: Empty
+	// Public: Yes
+	LoggingCommonAttributes KeyValMap `yaml:"logging_common_attributes" envconfig:"logging_common_attributes" public:"true"`
+
+	// LoggingPathDenylist is a list of file path patterns that can't be forwarded by the log forwarder. Log sources
+	// whose file, or any of the files matched by its glob, matches a pattern are rejected. Patterns follow the
+	// filepath.Match syntax. The patterns are added to the default ones, which are always denied.
+	// Default (Linux): /etc/shadow, /etc/shadow-, /etc/gshadow, /etc/gshadow-, /etc/sudoers, /root/.ssh/*, /home/*/.ssh/*
+	// Default (Windows): C:\Windows\System32\config\SAM, C:\Windows\System32\config\SECURITY, C:\Windows\System32\config\SYSTEM
+	// Public: Yes
+	LoggingPathDenylist []string `yaml:"logging_path_denylist" envconfig:"logging_path_denylist" public:"true"`
+
+	// LoggingRestartMaxCount is the number of times the log forwarder is restarted after crashing within
+	// LoggingRestartWindowSec. Once exceeded, the log forwarder is left stopped until the agent is restarted.
+	// Zero means no limit.
+	// Default: 0
+	// Public: Yes
+	LoggingRestartMaxCount int `yaml:"logging_restart_max_count" envconfig:"logging_restart_max_count" public:"true"`
+
+	// LoggingRestartWindowSec is the time window, in seconds, where the log forwarder crashes are counted for the
+	// LoggingRestartMaxCount limit.
+	// Default: 300
+	// Public: Yes
+	LoggingRestartWindowSec int `yaml:"logging_restart_window_sec" envconfig:"logging_restart_window_sec" public:"true"`
+
+	// LoggingRestartMaxBackoffSec is the maximum back-off time, in seconds, between log forwarder restarts after a
+	// crash. The back-off grows exponentially up to this value.
+	// Default: 300
+	// Public: Yes
+	LoggingRestartMaxBackoffSec int `yaml:"logging_restart_max_backoff_sec" envconfig:"logging_restart_max_backoff_sec" public:"true"`
+
 	// FluentBitExePath is the location from where the agent can execute fluent-bit.
 	// Default (Linux): /opt/td-agent-bit/bin/td-agent-bit
 	// Default (Windows): C:\Program Files\New Relic\newrelic-infra\newrelic-integrations\logging\fluent-bit
@@ -984,6 +1504,13 @@ type Config struct {
 	// Public: Yes
 	StatusServerPort int `yaml:"status_server_port" envconfig:"status_server_port"`
 
+	// StatusServerSocket Path of a Unix domain socket the status server listens on instead of the status_server_port,
+	// so it isn't reachable by other users of the host. The socket is created with 0600 permissions, replacing a
+	// stale one left by a previous run, and it's removed on shutdown. It can't be set along with status_server_port.
+	// Default: Empty
+	// Public: Yes
+	StatusServerSocket string `yaml:"status_server_socket" envconfig:"status_server_socket"`
+
 	// StatusEndpoints Status endpoints to check reachability.
 	// Default: IdentityURL, CommandChannelURL, MetricsIngestURL, InventoryIngestURL
 	// Public: Yes
@@ -1024,6 +1551,14 @@ type Config struct {
 	// Public: Yes
 	CloudProvider string `yaml:"cloud_provider" envconfig:"cloud_provider"`
 
+	// CloudDetectDisabledProviders Cloud providers whose metadata endpoints are never probed while detecting the cloud
+	// type, keeping the detection of the rest. Disabled providers don't spend any of the CloudMaxRetryCount retries,
+	// and unknown provider names are ignored with a warning.
+	// Default: Empty
+	// Allowed values: aws, azure, gcp, alibaba
+	// Public: Yes
+	CloudDetectDisabledProviders []string `yaml:"cloud_detect_disabled_providers" envconfig:"cloud_detect_disabled_providers"`
+
 	// CloudMaxRetryCount If the agent is running in a cloud instance, the agent will try to detect the	cloud type and
 	// it will fetch metadata like: instanceID, instanceType, cloudSource, hostType.
 	// This configuration parameter sets the number of retries in case that cloud detection failed. If during the agent
@@ -1052,6 +1587,13 @@ type Config struct {
 	// Public: Yes
 	CloudMetadataExpiryInSec int `yaml:"cloud_metadata_expiry_sec" envconfig:"cloud_metadata_expiry_sec"`
 
+	// CloudMetadataDiskCache When enabled, the last successfully detected cloud metadata is persisted to a file under
+	// AgentDir. On startup, the cached metadata is used right away if younger than CloudMetadataExpiryInSec while it's
+	// refreshed in background, and it's also used when the cloud detection fails, instead of waiting for it.
+	// Default: False
+	// Public: Yes
+	CloudMetadataDiskCache bool `yaml:"cloud_metadata_disk_cache" envconfig:"cloud_metadata_disk_cache"`
+
 	// CloudMetadataDisableKeepAlive If the agent is running in a cloud instance, the agent will try to detect the cloud
 	// type and it will fetch metadata like: instanceID, instanceType, cloudSource, hostType. This configuration
 	// parameter sets HTTP Connection header to close when querying the Cloud provider metadata.
@@ -1065,6 +1607,12 @@ type Config struct {
 	// Public: Yes
 	RemoveEntitiesPeriod string `yaml:"remove_entities_period" envconfig:"remove_entities_period"`
 
+	// RemoveEntitiesAudit When true, an audit record with the entity key and the removal reason is logged every time
+	// a non-reporting entity is removed by the RemoveEntitiesPeriod process.
+	// Default: False
+	// Public: Yes
+	RemoveEntitiesAudit bool `yaml:"remove_entities_audit" envconfig:"remove_entities_audit"`
+
 	// MetricsIngestEndpoint is the path for metrics ingest endpoint. The base URL is defined in the config option
 	// collector URL.
 	// Default: /infra/v2/metrics
@@ -1210,6 +1758,38 @@ type Config struct {
 	// Public: Yes
 	DetailedNFS bool `yaml:"detailed_nfs" envconfig:"detailed_nfs"`
 
+	// MetricsNFSMaxMounts Maximum number of NFS mounts sampled, to bound the number of reported NFSSample events.
+	// Mounts above the limit are dropped in the order they are listed in the mount stats. A value of 0 or lower
+	// samples all the mounts.
+	// Default: 100
+	// Public: Yes
+	MetricsNFSMaxMounts int `yaml:"metrics_nfs_max_mounts" envconfig:"metrics_nfs_max_mounts"`
+
+	// EnableSmartMetrics enables the SmartSample events, reporting the SMART health status, temperature and
+	// reallocated sectors of every disk found by smartctl. The sampler is a no-op when smartctl isn't installed.
+	// Default: False
+	// Public: Yes
+	EnableSmartMetrics bool `yaml:"enable_smart_metrics" envconfig:"enable_smart_metrics" os:"linux"`
+
+	// MetricsSmartSampleRate Sample rate of the SMART samples in seconds. Minimum value is 60. If value is -1 then
+	// the sampler is disabled.
+	// Default: 300
+	// Public: Yes
+	MetricsSmartSampleRate int `yaml:"metrics_smart_sample_rate" envconfig:"metrics_smart_sample_rate" os:"linux"`
+
+	// EnableRaidMetrics enables the RaidSample events, reporting the state of every software RAID (md) array listed in
+	// /proc/mdstat, sampled at the metrics_storage_sample_rate. The sampler is a no-op when there are no arrays.
+	// Default: False
+	// Public: Yes
+	EnableRaidMetrics bool `yaml:"enable_raid_metrics" envconfig:"enable_raid_metrics" os:"linux"`
+
+	// EnableLvmMetrics enables the LvmVolumeGroupSample and LvmLogicalVolumeSample events, reporting the capacity and
+	// free space of the LVM volume groups and the size and thin pool usage of their logical volumes, sampled at the
+	// metrics_storage_sample_rate. The sampler is a no-op when the LVM tools aren't installed.
+	// Default: False
+	// Public: Yes
+	EnableLvmMetrics bool `yaml:"enable_lvm_metrics" envconfig:"enable_lvm_metrics" os:"linux"`
+
 	// Internals
 
 	// concurrency support
@@ -1224,6 +1804,20 @@ type Config struct {
 	// Public: Yes
 	EnableProcessMetrics *bool `yaml:"enable_process_metrics" envconfig:"enable_process_metrics"`
 
+	// EnableProcessThreadCount enables/disables reporting the threadCount attribute of the ProcessSample. Thread counts
+	// are read from /proc/<pid>/status, or from the /proc/<pid>/task entries, under the HOST_PROC directory, and are
+	// left out of the samples of the processes they can't be read from. Thread counts are reported when not set.
+	// Default: empty
+	// Public: Yes
+	EnableProcessThreadCount *bool `yaml:"enable_process_thread_count" envconfig:"enable_process_thread_count"`
+
+	// EnableProcessFdCount enables/disables reporting the fileDescriptorCount attribute of the ProcessSample, which
+	// requires listing /proc/<pid>/fd and can be expensive for processes with thousands of open descriptors. File
+	// descriptors are counted when not set, as long as the agent runs as root or privileged.
+	// Default: empty
+	// Public: Yes
+	EnableProcessFdCount *bool `yaml:"enable_process_fd_count" envconfig:"enable_process_fd_count" os:"linux"`
+
 	// IncludeMetricsMatchers Configuration of the metrics matchers that determine which metric data should the agent
 	// send to the New Relic backend.
 	// If no configuration is defined, the previous behaviour is maintained, i.e., every metric data captured is sent.
@@ -1244,8 +1838,55 @@ type Config struct {
 	// Public: Yes
 	ExcludeMetricsMatchers ExcludeMetricsMap `envconfig:"exclude_matching_metrics" yaml:"exclude_matching_metrics"`
 
+	// EnableDroppedSamplesCount When enabled, the agent counts the samples dropped by the include_matching_metrics
+	// and exclude_matching_metrics matchers per sample type, and adds them every minute to the
+	// nria_dropped_samples_total counter served on the agent_metrics_endpoint, to quantify the filtering impact over
+	// time.
+	// Default: False
+	// Public: Yes
+	EnableDroppedSamplesCount bool `yaml:"enable_dropped_samples_count" envconfig:"enable_dropped_samples_count"`
+
+	// EventTypeAllowlist List of the event types, as "SystemSample", the agent submits. Unlike the metrics matchers,
+	// it applies to every event, either from the samplers or the integrations, dropping the ones of the event types
+	// not listed before they are submitted. Dimensional metrics are not filtered.
+	// Note that the heartbeat of the host is dropped too unless HeartbeatSample is listed.
+	// Default: Empty
+	// Public: Yes
+	EventTypeAllowlist []string `yaml:"event_type_allowlist" envconfig:"event_type_allowlist"`
+
+	// EventTypeDenylist List of the event types, as "NetworkSample", the agent drops instead of submitting them. It
+	// applies to every event, either from the samplers or the integrations, and takes precedence over the
+	// EventTypeAllowlist.
+	// Default: Empty
+	// Public: Yes
+	EventTypeDenylist []string `yaml:"event_type_denylist" envconfig:"event_type_denylist"`
+
+	// EnableConfigParseErrorsMetric When enabled, the agent adds every minute the integrations and log forwarding
+	// configuration files that failed to parse during it to the nria_config_parse_errors_total counter served on the
+	// agent_metrics_endpoint, with the file as label, so a broken configuration being deployed can be alerted on.
+	// Default: False
+	// Public: Yes
+	EnableConfigParseErrorsMetric bool `yaml:"enable_config_parse_errors_metric" envconfig:"enable_config_parse_errors_metric"`
+
+	// EnableFileHandlesMetric When enabled, the agent reads every minute its count of open file descriptors and
+	// their soft and hard limits, and serves them on the agent_metrics_endpoint as the nria_agent_open_file_handles,
+	// nria_agent_file_handles_soft_limit and nria_agent_file_handles_hard_limit gauges, so the agent approaching the
+	// file descriptors exhaustion can be alerted on. Only available on Linux.
+	// Default: False
+	// Public: Yes
+	EnableFileHandlesMetric bool `yaml:"enable_file_handles_metric" envconfig:"enable_file_handles_metric" os:"linux"`
+
+	// MetricNamePrefix Prefix added to the attribute names of the samples the agent submits, to namespace them and
+	// avoid collisions with other data sources. The attributes identifying the sample and its entity, as eventType,
+	// timestamp, entityKey or hostname, are never prefixed.
+	// Default: Empty
+	// Public: Yes
+	MetricNamePrefix string `yaml:"metric_name_prefix" envconfig:"metric_name_prefix"`
+
 	// AgentMetricsEndpoint Set the endpoint (host:port) for the HTTP server the agent will use to server OpenMetrics
-	// if empty the server will be not spawned
+	// if empty the server will be not spawned. The agent internal metrics, as the queue depths or the submission
+	// outcomes, are served in the Prometheus text format with names prefixed by "nria_". A "unix://" prefixed path,
+	// as unix:///var/run/newrelic-infra/metrics.sock, serves them on a Unix domain socket with 0600 permissions.
 	// Default: empty
 	// Public: Yes
 	AgentMetricsEndpoint string `yaml:"agent_metrics_endpoint" envconfig:"agent_metrics_endpoint"`
@@ -1266,13 +1907,31 @@ type Config struct {
 	// Public: No
 	SelfInstrumentationTelemetryEndpoint string `yaml:"self_instrumentation_telemetry_endpoint" envconfig:"self_instrumentation_telemetry_endpoint"`
 
+	// SelfInstrumentationTracesEndpoint OTLP/HTTP traces endpoint, e.g. http://localhost:4318/v1/traces, the agent
+	// exports the traces of its HTTP requests to as OpenTelemetry spans, JSON encoded. Requests are sampled as set
+	// by the log http_trace_sample_rate option. If empty the traces are not exported.
+	// Default: empty
+	// Public: Yes
+	SelfInstrumentationTracesEndpoint string `yaml:"self_instrumentation_traces_endpoint" envconfig:"self_instrumentation_traces_endpoint"`
+
+	// EnableTLSHandshakeMetric When enabled, the agent connects to the collector every minute and reports the time
+	// taken by the TLS handshake as the agent.tlsHandshakeDurationMs self-metric, to diagnose slow TLS inspecting
+	// proxies or network middleboxes. Requires the agent self instrumentation.
+	// Default: False
+	// Public: Yes
+	EnableTLSHandshakeMetric bool `yaml:"enable_tls_handshake_metric" envconfig:"enable_tls_handshake_metric"`
+
 	// NtpMetrics is a map for ntp configuration. It is disabled by default.
 	// Separate keys and values with colons :, as in KEY: VALUE, and separate each key-value pair with a line break.
 	// Key-value can be any of the following:
 	// "enabled: boolean" flag to enable/disable the ntp values (Default: false)
-	// "pool: []string" list of ntp servers (Default: [])
+	// "pool: []string" list of ntp servers, as "host" or "host:timeoutSeconds" to override the timeout (Default: [])
 	// "interval: int" interval in minutes to check ntp servers  (Default: 15)
 	// "timeout: int" ntp request timeout value in seconds (Default: 10)
+	// "min_servers: int" minimum number of servers that must respond to report the ntp offset (Default: 0, any)
+	// When enabled, SystemSample reports the median offset across the responding servers (ntpOffsetMs, positive if the
+	// local clock is ahead), the stratum and host of the server it belongs to (ntpStratum, ntpServerUsed), and
+	// ntpReachable=false when no server responds.
 	// Default: none
 	// Public: Yes
 	NtpMetrics NtpConfig `yaml:"ntp_metrics" envconfig:"ntp_metrics"`
@@ -1297,6 +1956,34 @@ type Config struct {
 	// Default: true
 	// Public: Yes
 	ProcessContainerDecoration bool `envconfig:"process_container_decoration" yaml:"process_container_decoration"`
+
+	// ContainerCPUThrottling enables reporting the cgroup CPU throttling stats (throttled periods and throttled time)
+	// of the container a process belongs to in its ProcessSample. Both cgroup v1 and v2 are supported. It requires
+	// ProcessContainerDecoration to be enabled.
+	// Default: False
+	// Public: Yes
+	ContainerCPUThrottling bool `envconfig:"container_cpu_throttling" yaml:"container_cpu_throttling"`
+
+	// ProcessUserAggregation enables reporting a ProcessUserSample per user, with the number of processes, the CPU
+	// percent and the resident memory of the processes they own. Only processes with a known user are aggregated.
+	// Default: False
+	// Public: Yes
+	ProcessUserAggregation bool `envconfig:"process_user_aggregation" yaml:"process_user_aggregation" os:"linux"`
+
+	// EnableProcessSocketCount enables reporting the socketCount attribute of the ProcessSample, with the number of
+	// TCP and UDP sockets open by the process, to diagnose connection leaks. Sockets are counted correlating the
+	// /proc/<pid>/fd links with the sockets of the process network namespace, so processes whose file descriptors
+	// can't be read are reported without the attribute.
+	// Default: False
+	// Public: Yes
+	EnableProcessSocketCount bool `envconfig:"enable_process_socket_count" yaml:"enable_process_socket_count" os:"linux"`
+
+	// ProcessNameFilters include and exclude lists of regular expressions matched against the process command
+	// name. Processes matching an exclude, or not matching any include when includes are set, are skipped before
+	// their metrics are collected. Unlike IncludeMetricsMatchers, it only considers the process name.
+	// Default: Empty
+	// Public: Yes
+	ProcessNameFilters ProcessNameFilters `envconfig:"process_name_filters" yaml:"process_name_filters" os:"linux"`
 }
 
 // KeyValMap is used whenever a key value pair configuration is required.
@@ -1345,6 +2032,13 @@ type LogConfig struct {
 	IncludeFilters LogFilters `yaml:"include_filters" envconfig:"include_filters"`
 	ExcludeFilters LogFilters `yaml:"exclude_filters" envconfig:"exclude_filters"`
 
+	// FieldMap renames the standard log fields (time, level, msg, func, file, logrus_error) when json format is used.
+	FieldMap map[string]string `yaml:"field_map,omitempty" envconfig:"field_map"`
+
+	// HTTPTraceSampleRate traces 1 in every N agent HTTP requests when the trace level is enabled, 1 or lower
+	// tracing every request.
+	HTTPTraceSampleRate int `yaml:"http_trace_sample_rate,omitempty" envconfig:"http_trace_sample_rate"`
+
 	Rotate LogRotateConfig `yaml:"rotate" envconfig:"rotate"`
 }
 
@@ -1385,19 +2079,52 @@ func (lc *LogConfig) HasIncludeFilter(key, value string) bool {
 	}
 
 	for _, element := range values {
-		if element == value || element == LogFilterWildcard {
+		if LogFilterMatches(element, value) {
 			return true
 		}
 	}
 	return false
 }
 
+// JSONFieldMap returns the names of the standard log fields for the json format, from the default ones plus
+// the configured field_map. An error is returned for unknown fields or when several fields share the same name.
+func (lc *LogConfig) JSONFieldMap() (map[string]string, error) {
+	fieldMap := make(map[string]string, len(defaultLogJSONFieldMap)+len(lc.FieldMap))
+	for field, name := range defaultLogJSONFieldMap {
+		fieldMap[field] = name
+	}
+
+	for field, name := range lc.FieldMap {
+		if !logStandardFields[field] {
+			return nil, fmt.Errorf("invalid log field_map, unknown field: %s", field)
+		}
+		fieldMap[field] = name
+	}
+
+	names := map[string]string{LogJSONDataKey: LogJSONDataKey}
+	for field := range logStandardFields {
+		name, ok := fieldMap[field]
+		if !ok {
+			name = field
+		}
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("invalid log field_map, fields %s and %s are both mapped to: %s", other, field, name)
+		}
+		names[name] = field
+	}
+
+	return fieldMap, nil
+}
+
 // LogRotateConfig map all log rotator configuration options
 type LogRotateConfig struct {
 	MaxSizeMb          *int   `yaml:"max_size_mb" envconfig:"max_size_mb"`
 	MaxFiles           int    `yaml:"max_files" envconfig:"max_files"`
 	CompressionEnabled bool   `yaml:"compression_enabled" envconfig:"compression_enabled"`
 	FilePattern        string `yaml:"file_pattern" envconfig:"file_pattern"`
+	// MaxTotalSizeMb caps the disk used by the current and the rotated log files. The oldest rotated files
+	// are removed once exceeded.
+	MaxTotalSizeMb *int `yaml:"max_total_size_mb,omitempty" envconfig:"max_total_size_mb"`
 }
 
 func (l *LogRotateConfig) IsSet() bool {
@@ -1421,10 +2148,11 @@ func (lc *LogConfig) VerboseEnabled() int {
 
 // NtpConfig map all ntp configuration options.
 type NtpConfig struct {
-	Pool     []string `yaml:"pool" envconfig:"pool"`
-	Enabled  bool     `yaml:"enabled" envconfig:"enabled"`
-	Interval uint     `yaml:"interval" envconfig:"interval"`
-	Timeout  uint     `yaml:"timeout" envconfig:"timeout"`
+	Pool       []string `yaml:"pool" envconfig:"pool"`
+	Enabled    bool     `yaml:"enabled" envconfig:"enabled"`
+	Interval   uint     `yaml:"interval" envconfig:"interval"`
+	Timeout    uint     `yaml:"timeout" envconfig:"timeout"`
+	MinServers uint     `yaml:"min_servers" envconfig:"min_servers"`
 }
 
 func NewNtpConfig() NtpConfig {
@@ -1436,6 +2164,60 @@ func NewNtpConfig() NtpConfig {
 	}
 }
 
+// ParseNtpServer parses an ntp pool entry, either "host" or "host:timeoutSeconds". A zero timeout is returned when
+// the entry doesn't override it. IPv6 addresses are not expected to carry a timeout.
+func ParseNtpServer(entry string) (host string, timeout uint, err error) {
+	host = strings.TrimSpace(entry)
+	if host == "" {
+		return "", 0, fmt.Errorf("empty ntp server")
+	}
+
+	if strings.Count(host, ":") != 1 {
+		return host, 0, nil
+	}
+
+	host, timeoutStr, _ := strings.Cut(host, ":")
+	parsed, err := strconv.ParseUint(timeoutStr, 10, 32)
+	if err != nil || parsed == 0 || host == "" {
+		return "", 0, fmt.Errorf("invalid ntp server %q, expected host or host:timeoutSeconds", entry)
+	}
+
+	return host, uint(parsed), nil
+}
+
+// validateClientCertificate checks that the client certificate and key files are either both or none configured,
+// and that they hold a matching key pair.
+func validateClientCertificate(certFile, keyFile string) error {
+	if certFile == "" && keyFile == "" {
+		return nil
+	}
+	if certFile == "" || keyFile == "" {
+		return errors.New("client_cert_file and client_key_file must be set together")
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		return fmt.Errorf("invalid client certificate %q or key %q: %w", certFile, keyFile, err)
+	}
+	return nil
+}
+
+// normalizeNtpConfig discards the invalid pool entries.
+func normalizeNtpConfig(ntpCfg *NtpConfig) {
+	pool := make([]string, 0, len(ntpCfg.Pool))
+	for _, entry := range ntpCfg.Pool {
+		if _, _, err := ParseNtpServer(entry); err != nil {
+			clog.WithError(err).Warn("Ignoring ntp pool entry.")
+			continue
+		}
+		pool = append(pool, entry)
+	}
+	ntpCfg.Pool = pool
+
+	if ntpCfg.Enabled && int(ntpCfg.MinServers) > len(ntpCfg.Pool) {
+		clog.WithField("minServers", ntpCfg.MinServers).WithField("pool", ntpCfg.Pool).
+			Warn("ntp min_servers is greater than the number of servers in the pool, the ntp offset won't be reported.")
+	}
+}
+
 func coalesce(values ...string) string {
 	for _, value := range values {
 		if value != "" {
@@ -1550,6 +2332,9 @@ type LogForward struct {
 	ProxyCfg         LogForwardProxy
 	RetryLimit       string
 	FluentBitVerbose bool
+	MaxLogSources    int
+	CommonAttributes map[string]string
+	PathDenylist     []string
 }
 
 type LogForwardProxy struct {
@@ -1560,6 +2345,14 @@ type LogForwardProxy struct {
 	ValidateCerts     bool
 }
 
+// loggingPathDenylist returns the default denylist of log file paths extended with the configured patterns, so the
+// sensitive files are never forwarded.
+func loggingPathDenylist(patterns []string) []string {
+	denylist := make([]string, 0, len(defaultLoggingPathDenylist)+len(patterns))
+	denylist = append(denylist, defaultLoggingPathDenylist...)
+	return append(denylist, patterns...)
+}
+
 // NewLogForward creates a valid log forwarder config.
 func NewLogForward(config *Config, troubleshoot Troubleshoot) LogForward {
 	return LogForward{
@@ -1570,6 +2363,9 @@ func NewLogForward(config *Config, troubleshoot Troubleshoot) LogForward {
 		IsFedramp:        config.Fedramp,
 		IsStaging:        config.Staging,
 		RetryLimit:       config.LoggingRetryLimit,
+		MaxLogSources:    config.MaxLogSources,
+		CommonAttributes: config.LoggingCommonAttributes,
+		PathDenylist:     loggingPathDenylist(config.LoggingPathDenylist),
 		FluentBitVerbose: config.Log.Level == LogLevelTrace && config.Log.HasIncludeFilter(TracesFieldName, SupervisorTrace),
 		ProxyCfg: LogForwardProxy{
 			IgnoreSystemProxy: config.IgnoreSystemProxy,
@@ -1853,6 +2649,9 @@ func NewConfig() *Config {
 		ReapInterval:                  defaultReapInterval,
 		SendInterval:                  defaultSendInterval,
 		PidFile:                       defaultPidFile,
+		LivenessIntervalSec:           defaultLivenessIntervalSec,
+		LivenessGracePeriodSec:        defaultLivenessGracePeriodSec,
+		ConfigReloadCoalesceWindowMs:  defaultConfigReloadCoalesceWindowMs,
 		InventoryIngestEndpoint:       defaultInventoryIngestEndpoint,
 		MetricsIngestEndpoint:         defaultMetricsIngestEndpoint,
 		DMIngestEndpoint:              defaultDMIngestEndpoint,
@@ -1867,6 +2666,21 @@ func NewConfig() *Config {
 		TruncTextValues:               defaultTruncTextValues,
 		LogFormat:                     defaultLogFormat,
 		LoggingRetryLimit:             defaultLoggingRetryLimit,
+		DnsConfigRefreshSec:           FREQ_DISABLE_SAMPLING,
+		HostsFileRefreshSec:           FREQ_DISABLE_SAMPLING,
+		NetworkRoutesRefreshSec:       FREQ_DISABLE_SAMPLING,
+		SudoersRefreshSec:             FREQ_DISABLE_SAMPLING,
+		FirewallRulesRefreshSec:       FREQ_DISABLE_SAMPLING,
+		SshHostKeysRefreshSec:         FREQ_DISABLE_SAMPLING,
+		PackageUpdatesRefreshSec:      FREQ_DISABLE_SAMPLING,
+		ScheduledTasksRefreshSec:      FREQ_DISABLE_SAMPLING,
+		ScheduledTasksRedactArgs:      defaultScheduledTasksRedactArgs,
+		EmitStartupEvent:              defaultEmitStartupEvent,
+		FirmwareRefreshSec:            FREQ_DISABLE_SAMPLING,
+		ContainerRuntimeRefreshSec:    FREQ_DISABLE_SAMPLING,
+		CloudLifecycleRefreshSec:      FREQ_DISABLE_SAMPLING,
+		LoggingRestartWindowSec:       defaultLoggingRestartWindowSec,
+		LoggingRestartMaxBackoffSec:   defaultLoggingRestartMaxBackoffSec,
 		HTTPServerHost:                defaultHTTPServerHost,
 		HTTPServerPort:                defaultHTTPServerPort,
 		TCPServerPort:                 defaultTCPServerPort,
@@ -1888,6 +2702,11 @@ func NewConfig() *Config {
 		CloudMetadataDisableKeepAlive: defaultCloudMetadataDisableKeepAlive,
 		RegisterMaxRetryBoSecs:        defaultRegisterMaxRetryBoSecs,
 		IgnoreReclaimable:             defaultIgnoreReclaimable,
+		ZombieProcessCount:            defaultZombieProcessCount,
+		ArpEntryCount:                 defaultArpEntryCount,
+		SubmissionRetryBackoffBaseSec: defaultSubmissionRetryBackoffBaseSec,
+		OfflineBufferMaxMB:            defaultOfflineBufferMaxMB,
+		IntegrationsExitHistorySize:   DefaultIntegrationsExitHistorySize,
 		DnsHostnameResolution:         defaultDnsHostnameResolution,
 		MaxProcs:                      defaultMaxProcs,
 		// At the moment, this is an option that would allow us to rollback to the previous behaviour in case of errors
@@ -1912,13 +2731,20 @@ func NewConfig() *Config {
 		IpData:                      defaultIpData,
 		ContainerMetadataCacheLimit: DefaultContainerCacheMetadataLimit,
 		PartitionsTTL:               defaultPartitionsTTL,
+		PublicIPTTL:                 defaultPublicIPTTL,
 		StartupConnectionTimeout:    defaultStartupConnectionTimeout,
+		HTTPClientTimeout:           defaultHTTPClientTimeout,
+		ShutdownFlushTimeout:        defaultShutdownFlushTimeout,
 		MetricsNFSSampleRate:        DefaultMetricsNFSSampleRate,
+		MetricsNFSMaxMounts:         DefaultMetricsNFSMaxMounts,
+		MetricsSmartSampleRate:      DefaultMetricsSmartSampleRate,
+		MetricsTCPSampleRate:        DefaultMetricsTCPSampleRate,
 		SmartVerboseModeEntryLimit:  DefaultSmartVerboseModeEntryLimit,
 		DefaultIntegrationsTempDir:  defaultIntegrationsTempDir,
 		IncludeMetricsMatchers:      defaultIncludeMetricsMatcherConfig,
 		ExcludeMetricsMatchers:      defaultExcludeMetricsMatcherConfig,
 		InventoryQueueLen:           DefaultInventoryQueue,
+		InventoryHandlerConcurrency: DefaultInventoryHandlerConcurrency,
 		NtpMetrics:                  NewNtpConfig(),
 		Http:                        NewHttpConfig(),
 		AgentTempDir:                defaultAgentTempDir,
@@ -1954,6 +2780,17 @@ func (c Config) GenerateInventoryURL() string {
 	return strings.TrimSuffix(inventoryURL, "/")
 }
 
+// cloudMetadataCacheFile file under AgentDir the cloud metadata is cached to.
+const cloudMetadataCacheFile = "cloud_metadata.json"
+
+// CloudMetadataCacheFile returns the file the cloud metadata is cached to, or empty if the cache is disabled.
+func (c *Config) CloudMetadataCacheFile() string {
+	if !c.CloudMetadataDiskCache {
+		return ""
+	}
+	return filepath.Join(c.AgentDir, cloudMetadataCacheFile)
+}
+
 func (c *Config) DMIngestURL() string {
 	return fmt.Sprintf("%s%s", c.MetricURL, c.DMIngestEndpoint)
 }
@@ -1996,6 +2833,85 @@ func ValidateConfigFrequencySetting(req, min, def int64, disable bool) time.Dura
 	return time.Duration(req)
 }
 
+// applyMetricsSampleRateOverrides folds the per sampler sample rate overrides into the metrics_*_sample_rate
+// options. Overrides below the sampler minimum value are floored to it, as the metrics_*_sample_rate options.
+func applyMetricsSampleRateOverrides(cfg *Config) {
+	samplers := map[string]struct {
+		rate     *int
+		min, def int64
+	}{
+		"system":  {&cfg.MetricsSystemSampleRate, FREQ_INTERVAL_FLOOR_SYSTEM_METRICS, FREQ_INTERVAL_FLOOR_SYSTEM_METRICS},
+		"storage": {&cfg.MetricsStorageSampleRate, FREQ_INTERVAL_FLOOR_STORAGE_METRICS, int64(DefaultStorageSamplerRateSecs)},
+		"network": {&cfg.MetricsNetworkSampleRate, FREQ_INTERVAL_FLOOR_STORAGE_METRICS, FREQ_INTERVAL_FLOOR_STORAGE_METRICS},
+		"tcp":     {&cfg.MetricsTCPSampleRate, FREQ_INTERVAL_FLOOR_TCP_METRICS, FREQ_INTERVAL_FLOOR_TCP_METRICS},
+		"process": {&cfg.MetricsProcessSampleRate, FREQ_INTERVAL_FLOOR_PROCESS_METRICS, FREQ_INTERVAL_FLOOR_PROCESS_METRICS},
+		"nfs":     {&cfg.MetricsNFSSampleRate, FREQ_INTERVAL_FLOOR_STORAGE_METRICS, int64(DefaultMetricsNFSSampleRate)},
+	}
+
+	for name, rate := range cfg.MetricsSampleRateOverrides {
+		sampler, ok := samplers[name]
+		if !ok {
+			clog.WithField("sampler", name).Warn("Ignoring sample rate override for unknown metrics sampler.")
+			continue
+		}
+		if rate > FREQ_DEFAULT_SAMPLING && int64(rate) < sampler.min {
+			cfg.setFlooredSampleRate(name, rate)
+			*sampler.rate = int(sampler.min)
+		} else {
+			*sampler.rate = int(ValidateConfigFrequencySetting(int64(rate), sampler.min, sampler.def, false))
+		}
+		clog.WithFields(logrus.Fields{"sampler": name, "sampleRate": *sampler.rate}).Debug("Metrics sample rate overridden.")
+	}
+}
+
+// validCompressionLevel returns whether level is a gzip compression level supported by the payload submissions.
+func validCompressionLevel(level int) bool {
+	return level >= gzip.NoCompression && level <= gzip.BestCompression
+}
+
+// InventoryPayloadCompressionLevel returns the gzip compression level of the inventory payloads: the
+// InventoryCompressionLevel when set, otherwise the PayloadCompressionLevel.
+func (c *Config) InventoryPayloadCompressionLevel() int {
+	if c.InventoryCompressionLevel != nil {
+		return *c.InventoryCompressionLevel
+	}
+	return c.PayloadCompressionLevel
+}
+
+// normalizeIntegrationsOutputLimits discards the non positive integration payload limits and defaults the unknown
+// truncation policies.
+func normalizeIntegrationsOutputLimits(cfg *Config) {
+	for name, maxBytes := range cfg.IntegrationsMaxPayloadBytes {
+		if maxBytes <= 0 {
+			clog.WithField("integration", name).WithField("maxPayloadBytes", maxBytes).
+				Warn("Ignoring non positive integration max payload bytes, its payloads are not limited.")
+			delete(cfg.IntegrationsMaxPayloadBytes, name)
+		}
+	}
+
+	for name, policy := range cfg.IntegrationsTruncationPolicy {
+		switch policy {
+		case TruncationPolicyDropLine, TruncationPolicyDropOldestDatasets, TruncationPolicyTruncateAttributes:
+		default:
+			clog.WithField("integration", name).WithField("truncationPolicy", policy).
+				Warn("Unknown integration truncation policy, using " + TruncationPolicyDropLine + ".")
+			cfg.IntegrationsTruncationPolicy[name] = TruncationPolicyDropLine
+		}
+	}
+}
+
+// setFlooredSampleRate records the sampler as floored when its sample rate was explicitly set below the minimum
+// value. Sample rates set to 0 (default) are not considered floored.
+func (cfg *Config) setFlooredSampleRate(sampler string, rate int) {
+	if rate <= FREQ_DEFAULT_SAMPLING {
+		return
+	}
+	if cfg.flooredSampleRates == nil {
+		cfg.flooredSampleRates = make(map[string]bool)
+	}
+	cfg.flooredSampleRates[sampler] = true
+}
+
 func JitterFrequency(freqInSec time.Duration) time.Duration {
 	if freqInSec < time.Second {
 		return time.Second
@@ -2136,9 +3052,28 @@ func NormalizeConfig(cfg *Config, cfgMetadata config_loader.YAMLMetadata) (err e
 		return
 	}
 
+	if err = validateClientCertificate(cfg.ClientCertFile, cfg.ClientKeyFile); err != nil {
+		return
+	}
+
+	if cfg.StatusServerSocket != "" && isConfigDefined("status_server_port", cfgMetadata) {
+		err = errors.New("status_server_port and status_server_socket can't be set together")
+		return
+	}
+
 	//  Map new Log configuration
 	cfg.loadLogConfig()
 
+	if _, err = cfg.Log.JSONFieldMap(); err != nil {
+		return
+	}
+
+	for _, filters := range []LogFilters{cfg.Log.IncludeFilters, cfg.Log.ExcludeFilters} {
+		for _, err := range filters.compileRegexps() {
+			nlog.WithError(err).Warn("log filter regular expression cannot be compiled, ignoring it")
+		}
+	}
+
 	// set corresponding log level
 	logLevel, err := log.ParseLevel(cfg.Log.Level)
 	if err != nil {
@@ -2254,7 +3189,7 @@ func NormalizeConfig(cfg *Config, cfgMetadata config_loader.YAMLMetadata) (err e
 	// Caution: PluginConfigFiles is ALWAYS defined with the default value. Is this right? Be aware any change could affect backwards compatibilities.
 	cfg.PluginConfigFiles = defaultPluginConfigFiles
 
-	if cfg.PayloadCompressionLevel < gzip.NoCompression || cfg.PayloadCompressionLevel > gzip.BestCompression {
+	if !validCompressionLevel(cfg.PayloadCompressionLevel) {
 		nlog.WithFields(logrus.Fields{
 			"provided": cfg.PayloadCompressionLevel,
 			"default":  defaultPayloadCompressionLevel,
@@ -2263,6 +3198,15 @@ func NormalizeConfig(cfg *Config, cfgMetadata config_loader.YAMLMetadata) (err e
 	}
 	nlog.WithField("PayloadCompressionLevel", cfg.PayloadCompressionLevel).Debug("Payload Compression Level.")
 
+	if cfg.InventoryCompressionLevel != nil && !validCompressionLevel(*cfg.InventoryCompressionLevel) {
+		nlog.WithFields(logrus.Fields{
+			"provided": *cfg.InventoryCompressionLevel,
+			"default":  cfg.PayloadCompressionLevel,
+		}).Warn("Inventory Compression Level set is invalid, overriding it to the payload compression level")
+		cfg.InventoryCompressionLevel = nil
+	}
+	nlog.WithField("InventoryCompressionLevel", cfg.InventoryPayloadCompressionLevel()).Debug("Inventory Compression Level.")
+
 	nlog.WithField("CompactEnabled", cfg.CompactEnabled).Debug("Repository compaction.")
 
 	if cfg.CompactThreshold == 0 {
@@ -2271,22 +3215,50 @@ func NormalizeConfig(cfg *Config, cfgMetadata config_loader.YAMLMetadata) (err e
 		cfg.CompactThreshold = cfg.CompactThreshold * 1024 * 1024
 	}
 
+	if cfg.CompactionIntervalHours < 0 {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.CompactionIntervalHours,
+			"default":  defaultCompactionIntervalHours,
+		}).Warn("'compaction_interval_hours' property cannot be negative. Assuming default")
+		cfg.CompactionIntervalHours = defaultCompactionIntervalHours
+	}
+	nlog.WithField("CompactionIntervalHours", cfg.CompactionIntervalHours).Debug("Repository compaction interval.")
+
+	applyMetricsSampleRateOverrides(cfg)
+
+	normalizeIntegrationsOutputLimits(cfg)
+
 	if cfg.MetricsSystemSampleRate < FREQ_INTERVAL_FLOOR_SYSTEM_METRICS && cfg.MetricsSystemSampleRate > FREQ_DISABLE_SAMPLING {
+		cfg.setFlooredSampleRate("system", cfg.MetricsSystemSampleRate)
 		cfg.MetricsSystemSampleRate = FREQ_INTERVAL_FLOOR_SYSTEM_METRICS
 	}
 	nlog.WithField("MetricsSystemSampleRate", cfg.MetricsSystemSampleRate).Debug("Metrics System Sample Rate.")
 
 	if cfg.MetricsStorageSampleRate < FREQ_INTERVAL_FLOOR_STORAGE_METRICS && cfg.MetricsStorageSampleRate > FREQ_DISABLE_SAMPLING {
+		cfg.setFlooredSampleRate("storage", cfg.MetricsStorageSampleRate)
 		cfg.MetricsStorageSampleRate = DefaultStorageSamplerRateSecs
 	}
 	nlog.WithField("MetricsStorageSampleRate", cfg.MetricsStorageSampleRate).Debug("Metrics Storage Sample Rate.")
 
 	if cfg.MetricsNetworkSampleRate < FREQ_INTERVAL_FLOOR_STORAGE_METRICS && cfg.MetricsNetworkSampleRate > FREQ_DISABLE_SAMPLING {
+		cfg.setFlooredSampleRate("network", cfg.MetricsNetworkSampleRate)
 		cfg.MetricsNetworkSampleRate = FREQ_INTERVAL_FLOOR_STORAGE_METRICS
 	}
 	nlog.WithField("MetricsNetworkSampleRate", cfg.MetricsNetworkSampleRate).Debug("Metrics Network Sample Rate.")
 
+	if cfg.MetricsTCPSampleRate < FREQ_INTERVAL_FLOOR_TCP_METRICS && cfg.MetricsTCPSampleRate > FREQ_DISABLE_SAMPLING {
+		cfg.setFlooredSampleRate("tcp", cfg.MetricsTCPSampleRate)
+		cfg.MetricsTCPSampleRate = FREQ_INTERVAL_FLOOR_TCP_METRICS
+	}
+	nlog.WithField("MetricsTCPSampleRate", cfg.MetricsTCPSampleRate).Debug("Metrics TCP Sample Rate.")
+
+	if cfg.MetricsSmartSampleRate < FREQ_INTERVAL_FLOOR_SMART_METRICS && cfg.MetricsSmartSampleRate > FREQ_DISABLE_SAMPLING {
+		cfg.MetricsSmartSampleRate = FREQ_INTERVAL_FLOOR_SMART_METRICS
+	}
+	nlog.WithField("MetricsSmartSampleRate", cfg.MetricsSmartSampleRate).Debug("Metrics SMART Sample Rate.")
+
 	if cfg.MetricsProcessSampleRate < FREQ_INTERVAL_FLOOR_PROCESS_METRICS && cfg.MetricsProcessSampleRate > FREQ_DISABLE_SAMPLING {
+		cfg.setFlooredSampleRate("process", cfg.MetricsProcessSampleRate)
 		cfg.MetricsProcessSampleRate = FREQ_INTERVAL_FLOOR_PROCESS_METRICS
 	}
 	nlog.WithField("MetricsProcessSampleRate", cfg.MetricsProcessSampleRate).Debug("Metrics Process Sample Rate.")
@@ -2320,6 +3292,86 @@ func NormalizeConfig(cfg *Config, cfgMetadata config_loader.YAMLMetadata) (err e
 		cfg.StartupConnectionTimeout = defaultStartupConnectionTimeout
 	}
 
+	if _, err := time.ParseDuration(cfg.HTTPClientTimeout); err != nil {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.HTTPClientTimeout,
+			"default":  defaultHTTPClientTimeout,
+		}).Warn("wrong format for 'http_client_timeout' property. Assuming default")
+		cfg.HTTPClientTimeout = defaultHTTPClientTimeout
+	}
+
+	if _, err := time.ParseDuration(cfg.ShutdownFlushTimeout); err != nil {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.ShutdownFlushTimeout,
+			"default":  defaultShutdownFlushTimeout,
+		}).Warn("wrong format for 'shutdown_flush_timeout' property. Assuming default")
+		cfg.ShutdownFlushTimeout = defaultShutdownFlushTimeout
+	}
+
+	if cfg.ConfigReloadCoalesceWindowMs <= 0 {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.ConfigReloadCoalesceWindowMs,
+			"default":  defaultConfigReloadCoalesceWindowMs,
+		}).Warn("'config_reload_coalesce_window_ms' property must be positive. Assuming default")
+		cfg.ConfigReloadCoalesceWindowMs = defaultConfigReloadCoalesceWindowMs
+	}
+
+	if cfg.LivenessIntervalSec <= 0 {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.LivenessIntervalSec,
+			"default":  defaultLivenessIntervalSec,
+		}).Warn("'liveness_interval_sec' property must be positive. Assuming default")
+		cfg.LivenessIntervalSec = defaultLivenessIntervalSec
+	}
+
+	if cfg.LivenessGracePeriodSec <= 0 {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.LivenessGracePeriodSec,
+			"default":  defaultLivenessGracePeriodSec,
+		}).Warn("'liveness_grace_period_sec' property must be positive. Assuming default")
+		cfg.LivenessGracePeriodSec = defaultLivenessGracePeriodSec
+	}
+
+	if cfg.SubmissionRetryMax < 0 {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.SubmissionRetryMax,
+			"default":  defaultSubmissionRetryMax,
+		}).Warn("'submission_retry_max' property cannot be negative. Assuming default")
+		cfg.SubmissionRetryMax = defaultSubmissionRetryMax
+	}
+
+	if cfg.SubmissionRetryBackoffBaseSec <= 0 {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.SubmissionRetryBackoffBaseSec,
+			"default":  defaultSubmissionRetryBackoffBaseSec,
+		}).Warn("'submission_retry_backoff_base_sec' property must be positive. Assuming default")
+		cfg.SubmissionRetryBackoffBaseSec = defaultSubmissionRetryBackoffBaseSec
+	}
+
+	if cfg.SubmissionRetryBackoffMaxSec < 0 {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.SubmissionRetryBackoffMaxSec,
+			"default":  defaultSubmissionRetryBackoffMaxSec,
+		}).Warn("'submission_retry_backoff_max_sec' property cannot be negative. Assuming default")
+		cfg.SubmissionRetryBackoffMaxSec = defaultSubmissionRetryBackoffMaxSec
+	}
+
+	if cfg.OfflineBufferMaxMB <= 0 {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.OfflineBufferMaxMB,
+			"default":  defaultOfflineBufferMaxMB,
+		}).Warn("'offline_buffer_max_mb' property must be positive. Assuming default")
+		cfg.OfflineBufferMaxMB = defaultOfflineBufferMaxMB
+	}
+
+	if cfg.IntegrationsExitHistorySize < 0 {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.IntegrationsExitHistorySize,
+			"default":  DefaultIntegrationsExitHistorySize,
+		}).Warn("'integrations_exit_history_size' property cannot be negative. Assuming default")
+		cfg.IntegrationsExitHistorySize = DefaultIntegrationsExitHistorySize
+	}
+
 	if cfg.MaxMetricsBatchSizeBytes > DefaultMaxMetricsBatchSizeBytes || cfg.MaxMetricsBatchSizeBytes <= 0 {
 		cfg.MaxMetricsBatchSizeBytes = DefaultMaxMetricsBatchSizeBytes
 	}
@@ -2345,6 +3397,14 @@ func NormalizeConfig(cfg *Config, cfgMetadata config_loader.YAMLMetadata) (err e
 		cfg.PartitionsTTL = defaultPartitionsTTL
 	}
 
+	if ttl, err := time.ParseDuration(cfg.PublicIPTTL); err != nil || ttl <= 0 {
+		nlog.WithFields(logrus.Fields{
+			"provided": cfg.PublicIPTTL,
+			"default":  defaultPublicIPTTL,
+		}).Warn("wrong format for 'public_ip_ttl' property. Assuming default")
+		cfg.PublicIPTTL = defaultPublicIPTTL
+	}
+
 	if cfg.FacterHomeDir == "" {
 		home, err := getDefaultFacterHomeDir()
 		if err != nil {
@@ -2354,6 +3414,8 @@ func NormalizeConfig(cfg *Config, cfgMetadata config_loader.YAMLMetadata) (err e
 		cfg.FacterHomeDir = home
 	}
 
+	normalizeNtpConfig(&cfg.NtpMetrics)
+
 	// force WMI sampler on Windows 32-bit
 	if cfg.LegacyStorageSampler == false && runtime.GOOS == "windows" && runtime.GOARCH == "386" {
 		cfg.LegacyStorageSampler = true
@@ -2432,22 +3494,75 @@ func (c *CustomAttributeMap) Decode(value string) error {
 	if err := json.Unmarshal(data, c); err != nil {
 		return err
 	}
+	c.expandEnv()
 	return nil
 }
 
-// DataMap returns the CustomAttributeMap as a data.Map
-func (c *CustomAttributeMap) DataMap() (d data.Map) {
+// UnmarshalYAML decodes the custom attributes from the YAML config, expanding the environment variables they
+// reference.
+func (c *CustomAttributeMap) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var attributes map[string]interface{}
+	if err := unmarshal(&attributes); err != nil {
+		return err
+	}
+	*c = attributes
+	c.expandEnv()
+	return nil
+}
+
+// expandEnv replaces the ${VAR} references within the string values by the value of the VAR environment variable,
+// so attributes can be provisioned through the environment. Undefined variables are expanded as empty strings.
+func (c *CustomAttributeMap) expandEnv() {
+	for k, v := range *c {
+		str, isString := v.(string)
+		if !isString {
+			continue
+		}
+		expanded, missing := envvar.ExpandReferences(str)
+		if len(missing) > 0 {
+			clog.WithFields(logrus.Fields{
+				"attribute": k,
+				"missing":   missing,
+			}).Warn("Custom attribute references undefined environment variables, expanding them as empty.")
+		}
+		(*c)[k] = expanded
+	}
+}
+
+// DataMap returns the CustomAttributeMap as a data.Map, truncating the values longer than maxValueBytes, unless
+// it's 0. Environment variables references are expanded on decoding.
+func (c *CustomAttributeMap) DataMap(maxValueBytes int) (d data.Map) {
 	d = data.Map{}
 
 	for k, v := range *c {
 		if str, isString := v.(string); isString {
-			d[k] = str
+			d[k] = TruncateAttributeValue(k, str, maxValueBytes)
 		}
 	}
 
 	return
 }
 
+// TruncateAttributeValue truncates the value of the attribute to maxValueBytes, unless it's 0, ending it with
+// TruncatedValueMarker if there's room for it. A warning is logged the first time each attribute is truncated.
+func TruncateAttributeValue(key, value string, maxValueBytes int) string {
+	if maxValueBytes <= 0 || len(value) <= maxValueBytes {
+		return value
+	}
+
+	if _, warned := truncatedAttributes.LoadOrStore(key, struct{}{}); !warned {
+		clog.WithFields(logrus.Fields{
+			"attribute": key,
+			"size":      len(value),
+			"maxSize":   maxValueBytes,
+		}).Warn("Attribute value exceeds custom_attributes_max_value_bytes, truncating it.")
+	}
+	if maxValueBytes <= len(TruncatedValueMarker) {
+		return helpers.TruncateUTF8(value, maxValueBytes)
+	}
+	return helpers.TruncateUTF8(value, maxValueBytes-len(TruncatedValueMarker)) + TruncatedValueMarker
+}
+
 func (i *IncludeMetricsMap) Decode(value string) error {
 	data := []byte(value)
 
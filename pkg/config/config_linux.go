@@ -42,6 +42,15 @@ func init() {
 	defaultLoggingConfigsDir = "logging.d"
 	defaultFluentBitParsers = "parsers.conf"
 	defaultFluentBitNRLib = "out_newrelic.so"
+	defaultLoggingPathDenylist = []string{
+		"/etc/shadow",
+		"/etc/shadow-",
+		"/etc/gshadow",
+		"/etc/gshadow-",
+		"/etc/sudoers",
+		"/root/.ssh/*",
+		"/home/*/.ssh/*",
+	}
 
 	// add PATH environment variable to all integrations
 	defaultPassthroughEnvironment = []string{"PATH"}
@@ -3,14 +3,26 @@
 package config
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
+	"github.com/newrelic/infrastructure-agent/pkg/databind/pkg/data"
 	"github.com/newrelic/infrastructure-agent/pkg/helpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -181,6 +193,7 @@ func (s *ConfigSuite) TestYaml(c *C) {
 license_key: abc123
 startup_connection_timeout: 33s
 startup_connection_retries: 10
+http_client_timeout: 2m
 win_process_priority_class: "Normal"
 max_procs: 3
 ignore_system_proxy: true
@@ -203,6 +216,7 @@ verbose: 4
 	c.Assert(err, IsNil)
 	c.Assert(cfg.StartupConnectionRetries, Equals, 10)
 	c.Assert(cfg.StartupConnectionTimeout, Equals, "33s")
+	c.Assert(cfg.HTTPClientTimeout, Equals, "2m")
 	c.Assert(cfg.WinProcessPriorityClass, Equals, "Normal")
 	c.Assert(cfg.MaxProcs, Equals, 3)
 	c.Assert(cfg.IgnoreSystemProxy, Equals, true)
@@ -232,6 +246,8 @@ license_key: abc123
 	defer os.Unsetenv("NRIA_LOG_FILE")
 	os.Setenv("NRIA_LOG_LEVEL", "debug")
 	defer os.Unsetenv("NRIA_LOG_LEVEL")
+	os.Setenv("NRIA_PROCESS_NAME_FILTERS_EXCLUDE", "^kworker,^migration")
+	defer os.Unsetenv("NRIA_PROCESS_NAME_FILTERS_EXCLUDE")
 
 	f, err := ioutil.TempFile("", "env_config_test")
 	c.Assert(err, IsNil)
@@ -245,6 +261,9 @@ license_key: abc123
 	c.Assert(fmt.Sprintf("%v", cfg.IncludeMetricsMatchers), Equals, "map[process.name:[regex \"kube*\"]]")
 	c.Assert(cfg.Log.Level, Equals, LogLevelDebug)
 	c.Assert(cfg.Log.File, Equals, "agent.log")
+	c.Assert(cfg.ProcessNameFilters.Exclude, HasLen, 2)
+	c.Assert(cfg.ProcessNameFilters.Accepts("migration/0"), Equals, false)
+	c.Assert(cfg.ProcessNameFilters.Accepts("nginx"), Equals, true)
 }
 
 func (s *ConfigSuite) TestWrongFormatDurations(c *C) {
@@ -253,6 +272,7 @@ func (s *ConfigSuite) TestWrongFormatDurations(c *C) {
 license_key: abc123
 startup_connection_timeout: a duck
 startup_connection_retry_time: cow and pineapples
+http_client_timeout: forty seconds
 `
 	f, err := ioutil.TempFile("", "wrong_yaml_config_test")
 	c.Assert(err, IsNil)
@@ -263,6 +283,41 @@ startup_connection_retry_time: cow and pineapples
 	cfg, err := LoadConfig(f.Name())
 	c.Assert(err, IsNil)
 	c.Assert(cfg.StartupConnectionTimeout, Equals, defaultStartupConnectionTimeout)
+	c.Assert(cfg.HTTPClientTimeout, Equals, defaultHTTPClientTimeout)
+}
+
+func (s *ConfigSuite) TestWrongSubmissionRetryValues(c *C) {
+	configStr := `
+license_key: abc123
+submission_retry_max: -1
+submission_retry_backoff_base_sec: 0
+submission_retry_backoff_max_sec: -10
+`
+	f, err := ioutil.TempFile("", "wrong_submission_retry_config_test")
+	c.Assert(err, IsNil)
+	f.WriteString(configStr)
+	f.Close()
+
+	cfg, err := LoadConfig(f.Name())
+	c.Assert(err, IsNil)
+	c.Assert(cfg.SubmissionRetryMax, Equals, defaultSubmissionRetryMax)
+	c.Assert(cfg.SubmissionRetryBackoffBaseSec, Equals, defaultSubmissionRetryBackoffBaseSec)
+	c.Assert(cfg.SubmissionRetryBackoffMaxSec, Equals, defaultSubmissionRetryBackoffMaxSec)
+}
+
+func (s *ConfigSuite) TestWrongCompactionIntervalHours(c *C) {
+	configStr := `
+license_key: abc123
+compaction_interval_hours: -24
+`
+	f, err := ioutil.TempFile("", "wrong_compaction_interval_config_test")
+	c.Assert(err, IsNil)
+	f.WriteString(configStr)
+	f.Close()
+
+	cfg, err := LoadConfig(f.Name())
+	c.Assert(err, IsNil)
+	c.Assert(cfg.CompactionIntervalHours, Equals, defaultCompactionIntervalHours)
 }
 
 func (s *ConfigSuite) TestEscapedString(c *C) {
@@ -329,6 +384,10 @@ agent_dir: /my/overriden/agent/dir
 	c.Assert(cfg.OfflineTimeToReset, Equals, DefaultOfflineTimeToReset)
 	c.Assert(cfg.StartupConnectionTimeout, Equals, defaultStartupConnectionTimeout)
 	c.Assert(cfg.StartupConnectionRetries, Equals, defaultStartupConnectionRetries)
+	c.Assert(cfg.HTTPClientTimeout, Equals, defaultHTTPClientTimeout)
+	c.Assert(cfg.SubmissionRetryMax, Equals, defaultSubmissionRetryMax)
+	c.Assert(cfg.SubmissionRetryBackoffBaseSec, Equals, defaultSubmissionRetryBackoffBaseSec)
+	c.Assert(cfg.SubmissionRetryBackoffMaxSec, Equals, defaultSubmissionRetryBackoffMaxSec)
 	c.Assert(cfg.MaxInventorySize, Equals, defaultMaxInventorySize)
 	c.Assert(cfg.DisableInventorySplit, Equals, defaultDisableInventorySplit)
 	c.Assert(cfg.MaxProcs, Equals, defaultMaxProcs)
@@ -758,6 +817,178 @@ log:
 	}
 }
 
+func TestLoadLogConfig_RegexpFilters(t *testing.T) {
+	yamlCfg := `
+license_key: abc123
+log:
+  level: info
+  include_filters:
+    "traces":
+      - "re:^super.*"
+      - "re:(invalid"
+  exclude_filters:
+    "component":
+      - "re:^integration-.*"
+      - "ProcessSample"
+`
+	tmp, err := createTestFile([]byte(yamlCfg))
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+
+	cfg, err := LoadConfig(tmp.Name())
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Log.HasIncludeFilter(TracesFieldName, SupervisorTrace))
+	assert.False(t, cfg.Log.HasIncludeFilter(TracesFieldName, FeatureTrace))
+	// invalid regular expressions are dropped
+	require.Len(t, cfg.Log.IncludeFilters[TracesFieldName], 1)
+	assert.True(t, LogFilterMatches(cfg.Log.IncludeFilters[TracesFieldName][0], "supervisor-fluent-bit"))
+
+	// literal values are kept
+	excluded := cfg.Log.ExcludeFilters[TracesFieldComponent]
+	assert.Contains(t, excluded, "ProcessSample")
+	assert.True(t, LogFilterMatches(excluded[0], "integration-errors"))
+	assert.False(t, LogFilterMatches(excluded[0], "my-integration-errors"))
+}
+
+func TestLoadYamlConfig_ProcessNameFilters(t *testing.T) {
+	yamlCfg := `
+license_key: abc123
+process_name_filters:
+  include:
+    - "^(nginx|java)$"
+  exclude:
+    - "^kworker"
+`
+	tmp, err := createTestFile([]byte(yamlCfg))
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+
+	cfg, err := LoadConfig(tmp.Name())
+	require.NoError(t, err)
+
+	filters := cfg.ProcessNameFilters
+	require.True(t, filters.Enabled())
+	assert.True(t, filters.Accepts("nginx"))
+	assert.True(t, filters.Accepts("java"))
+	assert.False(t, filters.Accepts("javac"))
+	assert.False(t, filters.Accepts("kworker/0:1"))
+}
+
+func TestLoadYamlConfig_ProcessNameFiltersInvalidRegexp(t *testing.T) {
+	yamlCfg := `
+license_key: abc123
+process_name_filters:
+  exclude:
+    - "(invalid"
+`
+	tmp, err := createTestFile([]byte(yamlCfg))
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+
+	_, err = LoadConfig(tmp.Name())
+	assert.Error(t, err)
+}
+
+func TestProcessNameFilters_Accepts(t *testing.T) {
+	nginx := ProcessNameRegexp{regexp.MustCompile("^nginx")}
+	worker := ProcessNameRegexp{regexp.MustCompile("worker")}
+
+	tests := []struct {
+		name     string
+		filters  ProcessNameFilters
+		process  string
+		expected bool
+	}{
+		{name: "no filters", process: "bash", expected: true},
+		{name: "included", filters: ProcessNameFilters{Include: []ProcessNameRegexp{nginx}}, process: "nginx", expected: true},
+		{name: "not included", filters: ProcessNameFilters{Include: []ProcessNameRegexp{nginx}}, process: "bash", expected: false},
+		{name: "excluded", filters: ProcessNameFilters{Exclude: []ProcessNameRegexp{worker}}, process: "kworker", expected: false},
+		{name: "not excluded", filters: ProcessNameFilters{Exclude: []ProcessNameRegexp{worker}}, process: "bash", expected: true},
+		{name: "exclude wins", filters: ProcessNameFilters{Include: []ProcessNameRegexp{nginx}, Exclude: []ProcessNameRegexp{worker}}, process: "nginx-worker", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filters.Accepts(tt.process))
+		})
+	}
+}
+
+func TestLogConfig_JSONFieldMap(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fieldMap map[string]string
+		expected map[string]string
+		err      bool
+	}{
+		{
+			name:     "WhenNotConfigured_Defaults",
+			expected: map[string]string{"time": "timestamp"},
+		},
+		{
+			name:     "WhenConfigured_Merged",
+			fieldMap: map[string]string{"level": "severity", "msg": "message"},
+			expected: map[string]string{"time": "timestamp", "level": "severity", "msg": "message"},
+		},
+		{
+			name:     "WhenDefaultOverridden",
+			fieldMap: map[string]string{"time": "@timestamp"},
+			expected: map[string]string{"time": "@timestamp"},
+		},
+		{
+			name:     "WhenUnknownField_Error",
+			fieldMap: map[string]string{"component": "module"},
+			err:      true,
+		},
+		{
+			name:     "WhenSameTarget_Error",
+			fieldMap: map[string]string{"level": "severity", "msg": "severity"},
+			err:      true,
+		},
+		{
+			name:     "WhenTargetIsAnotherField_Error",
+			fieldMap: map[string]string{"level": "msg"},
+			err:      true,
+		},
+		{
+			name:     "WhenTargetIsDataKey_Error",
+			fieldMap: map[string]string{"msg": "context"},
+			err:      true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			logConfig := LogConfig{FieldMap: testCase.fieldMap}
+
+			fieldMap, err := logConfig.JSONFieldMap()
+			if testCase.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, fieldMap)
+		})
+	}
+}
+
+func TestLoadLogConfig_FieldMapConflict(t *testing.T) {
+	yamlCfg := `
+license_key: abc123
+log:
+  format: json
+  field_map:
+    level: severity
+    msg: severity
+`
+	tmp, err := createTestFile([]byte(yamlCfg))
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+
+	_, err = LoadConfig(tmp.Name())
+	assert.Error(t, err)
+}
+
 func TestLoadLogConfig_BackwardsCompatability(t *testing.T) {
 	toPtr := func(a bool) *bool {
 		return &a
@@ -877,6 +1108,26 @@ ntp_metrics:
 				Interval: 10,
 			},
 		},
+		{
+			name: "Per server timeout and min servers",
+			yamlCfg: `
+license_key: abc123
+ntp_metrics:
+  enabled: true
+  pool:
+    - "internal.ntp:2"
+    - "backup.ntp"
+    - "invalid.ntp:abc"
+  min_servers: 2
+`,
+			expected: NtpConfig{
+				Enabled:    true,
+				Pool:       []string{"internal.ntp:2", "backup.ntp"},
+				Timeout:    defaultNtpTimeout,
+				Interval:   defaultNtpInterval,
+				MinServers: 2,
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -891,6 +1142,61 @@ ntp_metrics:
 	}
 }
 
+// writeKeyPair writes a self-signed certificate and its private key to the given directory.
+func writeKeyPair(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestValidateClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeKeyPair(t, dir, "client")
+	_, otherKeyFile := writeKeyPair(t, dir, "other")
+
+	testCases := []struct {
+		name     string
+		certFile string
+		keyFile  string
+		wantErr  bool
+	}{
+		{name: "not configured"},
+		{name: "valid key pair", certFile: certFile, keyFile: keyFile},
+		{name: "missing key", certFile: certFile, wantErr: true},
+		{name: "missing certificate", keyFile: keyFile, wantErr: true},
+		{name: "mismatched key", certFile: certFile, keyFile: otherKeyFile, wantErr: true},
+		{name: "nonexistent files", certFile: filepath.Join(dir, "none.crt"), keyFile: keyFile, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateClientCertificate(testCase.certFile, testCase.keyFile)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestLoadYamlConfig_withDatabindAndEnvVars(t *testing.T) {
 	yamlData := []byte(`
 variables:
@@ -1140,3 +1446,268 @@ func createTestFile(data []byte) (*os.File, error) {
 	tmp.Close()
 	return tmp, nil
 }
+
+func TestLoadConfig_MetricsSampleRateOverrides(t *testing.T) {
+	yamlCfg := `
+license_key: "xxx"
+metrics_system_sample_rate: 10
+metrics_storage_sample_rate: 30
+metrics_sample_rate_overrides:
+  system: 60
+  process: 1
+  nfs: -1
+  unknown: 15
+`
+	tmp, err := createTestFile([]byte(yamlCfg))
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+
+	cfg, err := LoadConfig(tmp.Name())
+	require.NoError(t, err)
+
+	assert.Equal(t, 60, cfg.MetricsSystemSampleRate)
+	// scalar option is used when the sampler has no override
+	assert.Equal(t, 30, cfg.MetricsStorageSampleRate)
+	// override below the sampler minimum value
+	assert.Equal(t, FREQ_INTERVAL_FLOOR_PROCESS_METRICS, cfg.MetricsProcessSampleRate)
+	assert.Equal(t, FREQ_DISABLE_SAMPLING, cfg.MetricsNFSSampleRate)
+}
+
+func TestLoadConfig_IntegrationsOutputLimits(t *testing.T) {
+	yamlCfg := `
+license_key: "xxx"
+integrations_max_payload_bytes:
+  nri-redis: 1024
+  nri-mysql: 2048
+  nri-nginx: 0
+integrations_truncation_policy:
+  nri-redis: truncate_attributes
+  nri-mysql: shrink
+`
+	tmp, err := createTestFile([]byte(yamlCfg))
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+
+	cfg, err := LoadConfig(tmp.Name())
+	require.NoError(t, err)
+
+	// non positive limits are discarded
+	assert.Equal(t, map[string]int{"nri-redis": 1024, "nri-mysql": 2048}, cfg.IntegrationsMaxPayloadBytes)
+	// unknown policies are defaulted
+	assert.Equal(t, map[string]string{
+		"nri-redis": TruncationPolicyTruncateAttributes,
+		"nri-mysql": TruncationPolicyDropLine,
+	}, cfg.IntegrationsTruncationPolicy)
+}
+
+func TestLoadConfig_InventoryCompressionLevel(t *testing.T) {
+	tests := []struct {
+		name              string
+		yamlCfg           string
+		expectedPayload   int
+		expectedInventory int
+	}{
+		{name: "default", expectedPayload: defaultPayloadCompressionLevel, expectedInventory: defaultPayloadCompressionLevel},
+		{name: "payload level", yamlCfg: "payload_compression_level: 1\n", expectedPayload: 1, expectedInventory: 1},
+		{name: "override", yamlCfg: "payload_compression_level: 1\ninventory_compression_level: 9\n", expectedPayload: 1, expectedInventory: 9},
+		{name: "no compression", yamlCfg: "inventory_compression_level: 0\n", expectedPayload: defaultPayloadCompressionLevel, expectedInventory: 0},
+		{name: "invalid", yamlCfg: "payload_compression_level: 1\ninventory_compression_level: 12\n", expectedPayload: 1, expectedInventory: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp, err := createTestFile([]byte("license_key: abc123\n" + tt.yamlCfg))
+			require.NoError(t, err)
+			defer os.Remove(tmp.Name())
+
+			cfg, err := LoadConfig(tmp.Name())
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedPayload, cfg.PayloadCompressionLevel)
+			assert.Equal(t, tt.expectedInventory, cfg.InventoryPayloadCompressionLevel())
+		})
+	}
+}
+
+func TestLoadConfig_MetricsTCPSampleRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		yamlCfg  string
+		expected int
+	}{
+		{name: "default", expected: DefaultMetricsTCPSampleRate},
+		{name: "below minimum", yamlCfg: "metrics_tcp_sample_rate: 1\n", expected: FREQ_INTERVAL_FLOOR_TCP_METRICS},
+		{name: "disabled", yamlCfg: "metrics_tcp_sample_rate: -1\n", expected: FREQ_DISABLE_SAMPLING},
+		{name: "override", yamlCfg: "metrics_sample_rate_overrides:\n  tcp: 120\n", expected: 120},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp, err := createTestFile([]byte("license_key: abc123\n" + tt.yamlCfg))
+			require.NoError(t, err)
+			defer os.Remove(tmp.Name())
+
+			cfg, err := LoadConfig(tmp.Name())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg.MetricsTCPSampleRate)
+		})
+	}
+}
+
+func TestLoadConfig_StatusServerSocket(t *testing.T) {
+	tests := []struct {
+		name    string
+		yamlCfg string
+		wantErr bool
+	}{
+		{name: "socket", yamlCfg: "status_server_socket: /tmp/status.sock\n"},
+		{name: "port", yamlCfg: "status_server_port: 18003\n"},
+		{name: "socket and port", yamlCfg: "status_server_socket: /tmp/status.sock\nstatus_server_port: 18003\n", wantErr: true},
+		// the port is rejected even if it's the default one
+		{name: "socket and default port", yamlCfg: "status_server_socket: /tmp/status.sock\nstatus_server_port: 8003\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp, err := createTestFile([]byte("license_key: abc123\nstatus_server_enabled: true\n" + tt.yamlCfg))
+			require.NoError(t, err)
+			defer os.Remove(tmp.Name())
+
+			_, err = LoadConfig(tmp.Name())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseNtpServer(t *testing.T) {
+	testCases := []struct {
+		entry           string
+		expectedHost    string
+		expectedTimeout uint
+		expectedErr     bool
+	}{
+		{entry: "time.cloudflare.com", expectedHost: "time.cloudflare.com"},
+		{entry: " internal.ntp:3 ", expectedHost: "internal.ntp", expectedTimeout: 3},
+		{entry: "2001:db8::1", expectedHost: "2001:db8::1"},
+		{entry: "internal.ntp:0", expectedErr: true},
+		{entry: "internal.ntp:fast", expectedErr: true},
+		{entry: ":3", expectedErr: true},
+		{entry: "", expectedErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.entry, func(t *testing.T) {
+			host, timeout, err := ParseNtpServer(testCase.entry)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedHost, host)
+			assert.Equal(t, testCase.expectedTimeout, timeout)
+		})
+	}
+}
+
+func TestCustomAttributesExpandEnv(t *testing.T) {
+	t.Setenv("DEPLOY_ENV", "production")
+
+	yamlData := []byte(`
+license_key: abc123
+custom_attributes:
+  deploy_env: ${DEPLOY_ENV}
+  template: $${DEPLOY_ENV}
+  undefined: ${UNDEFINED_DEPLOY_ENV}
+  replicas: 3
+`)
+	tmp, err := createTestFile(yamlData)
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+
+	cfg, err := LoadConfig(tmp.Name())
+	require.NoError(t, err)
+	assert.Equal(t, CustomAttributeMap{
+		"deploy_env": "production",
+		"template":   "${DEPLOY_ENV}",
+		"undefined":  "",
+		"replicas":   3,
+	}, cfg.CustomAttributes)
+	assert.Equal(t, data.Map{
+		"deploy_env": "production",
+		"template":   "${DEPLOY_ENV}",
+		"undefined":  "",
+	}, cfg.CustomAttributes.DataMap(0))
+}
+
+func TestCustomAttributesDecodeExpandEnv(t *testing.T) {
+	t.Setenv("DEPLOY_ENV", "production")
+
+	attributes := CustomAttributeMap{}
+	require.NoError(t, attributes.Decode(`{"deploy_env": "${DEPLOY_ENV}", "template": "$${DEPLOY_ENV}"}`))
+	assert.Equal(t, CustomAttributeMap{"deploy_env": "production", "template": "${DEPLOY_ENV}"}, attributes)
+}
+
+func TestCustomAttributesDataMapTruncation(t *testing.T) {
+	attributes := CustomAttributeMap{
+		"short":     "value",
+		"long":      strings.Repeat("a", 30),
+		"multibyte": strings.Repeat("€", 10),
+		"number":    3,
+	}
+
+	assert.Equal(t, data.Map{
+		"short":     "value",
+		"long":      strings.Repeat("a", 30),
+		"multibyte": strings.Repeat("€", 10),
+	}, attributes.DataMap(0))
+
+	truncated := attributes.DataMap(20)
+	assert.Equal(t, "value", truncated["short"])
+	assert.Equal(t, "aaaaaa"+TruncatedValueMarker, truncated["long"])
+	// 6 bytes are left for the value, which fit 2 runes of 3 bytes
+	assert.Equal(t, "€€"+TruncatedValueMarker, truncated["multibyte"])
+	for _, v := range truncated {
+		assert.LessOrEqual(t, len(v), 20)
+		assert.True(t, utf8.ValidString(v))
+	}
+}
+
+func TestTruncateAttributeValue_SmallerThanMarker(t *testing.T) {
+	assert.Equal(t, "ab", TruncateAttributeValue("key", "abcdef", 2))
+	assert.Equal(t, "", TruncateAttributeValue("key", "€€", 2))
+}
+
+func TestLoadConfig_MetricsSampleRateOverridesFloor(t *testing.T) {
+	load := func(yamlCfg string) *Config {
+		tmp, err := createTestFile([]byte("license_key: xxx\n" + yamlCfg))
+		require.NoError(t, err)
+		defer os.Remove(tmp.Name())
+
+		cfg, err := LoadConfig(tmp.Name())
+		require.NoError(t, err)
+		return cfg
+	}
+
+	scalar := load("metrics_system_sample_rate: 3\nmetrics_network_sample_rate: 3\n")
+	override := load("metrics_sample_rate_overrides:\n  system: 3\n  network: 3\n")
+
+	// overrides below the sampler minimum value are floored as the scalar options
+	assert.Equal(t, scalar.MetricsSystemSampleRate, override.MetricsSystemSampleRate)
+	assert.Equal(t, scalar.MetricsNetworkSampleRate, override.MetricsNetworkSampleRate)
+	assert.Equal(t, FREQ_INTERVAL_FLOOR_SYSTEM_METRICS, override.MetricsSystemSampleRate)
+	assert.Equal(t, scalar.flooredSampleRates, override.flooredSampleRates)
+}
+
+func TestNewLogForward_PathDenylistExtendsDefaults(t *testing.T) {
+	cfg := NewConfig()
+	cfg.LoggingPathDenylist = []string{"/opt/app/*.key"}
+
+	logFwd := NewLogForward(cfg, Troubleshoot{})
+
+	assert.Subset(t, logFwd.PathDenylist, defaultLoggingPathDenylist)
+	assert.Contains(t, logFwd.PathDenylist, "/opt/app/*.key")
+}
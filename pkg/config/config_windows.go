@@ -67,6 +67,11 @@ func init() {
 
 	defaultFluentBitParsers = "parsers.conf"
 	defaultFluentBitNRLib = "out_newrelic.dll"
+	defaultLoggingPathDenylist = []string{
+		filepath.Join("C:\\", "Windows", "System32", "config", "SAM"),
+		filepath.Join("C:\\", "Windows", "System32", "config", "SECURITY"),
+		filepath.Join("C:\\", "Windows", "System32", "config", "SYSTEM"),
+	}
 
 	defaultAgentTempDir = filepath.Join(defaultAppDataDir, agentTemporaryFolderName)
 }
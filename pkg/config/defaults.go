@@ -47,12 +47,17 @@ var (
 	DefaultMaxMetricBatchEntitiesCount = 300         // Amount limit from Vortex collector service header (8k ~ 300 entities)
 	DefaultMaxMetricBatchEntitiesQueue = 1000        // Limit the amount of queued entities to be processed by Vortex collector service
 	DefaultMetricsNFSSampleRate        = 20
+	DefaultMetricsNFSMaxMounts         = 100
+	DefaultMetricsSmartSampleRate      = 300
+	DefaultMetricsTCPSampleRate        = 30
 	DefaultOfflineTimeToReset          = "24h"
 	DefaultStorageSamplerRateSecs      = 20
 	DefaultStripCommandLine            = true
 	DefaultSmartVerboseModeEntryLimit  = 1000
 	DefaultIntegrationsDir             = "newrelic-integrations"
 	DefaultInventoryQueue              = 0
+	DefaultInventoryHandlerConcurrency = 1
+	DefaultIntegrationsExitHistorySize = 10
 
 	// private
 	defaultAppDataDir                    = ""
@@ -61,7 +66,10 @@ var (
 	defaultInventoryArchiveEnabled       = true
 	defaultCompactEnabled                = true
 	defaultCompactThreshold              = 20 * 1024 * 1024 // (in bytes) compact repo when it hits 20MB
+	defaultCompactionIntervalHours       = 0                // compact repo only by size
 	defaultIgnoreReclaimable             = false
+	defaultZombieProcessCount            = true
+	defaultArpEntryCount                 = false
 	defaultDebugLogSec                   = 600
 	defaultDisableInventorySplit         = false
 	defaultDisableWinSharedWMI           = false
@@ -79,14 +87,28 @@ var (
 	defaultLogFormat                     = LogFormatText
 	defaultLogLevel                      = LogLevelInfo
 	defaultLogForward                    = false
+	defaultLoggingRestartWindowSec       = 300
+	defaultLoggingRestartMaxBackoffSec   = 300
 	defaultLoggingRetryLimit             = "5"         // nolint:gochecknoglobals
 	defaultMaxInventorySize              = 1000 * 1000 // Size limit from Vortex collector service (1MB)
 	defaultPayloadCompressionLevel       = 6           // default compression level used in go, higher than this does not show tangible benefits
 	defaultPidFile                       = "/var/run/newrelic-infra/newrelic-infra.pid"
+	defaultLivenessIntervalSec           = 15
+	defaultLivenessGracePeriodSec        = 120
+	defaultConfigReloadCoalesceWindowMs  = 500
 	defaultPluginActiveConfigsDir        = "integrations.d"
 	defaultSelinuxEnableSemodule         = true
 	defaultStartupConnectionTimeout      = "10s"
+	defaultHTTPClientTimeout             = "30s"
+	defaultShutdownFlushTimeout          = "5s"
+	defaultSubmissionRetryMax            = 0
+	defaultSubmissionRetryBackoffBaseSec = 1 // seconds
+	defaultSubmissionRetryBackoffMaxSec  = 0 // seconds, 0 uses the built-in maximum of each sender
+	defaultOfflineBufferMaxMB            = 100
+	defaultScheduledTasksRedactArgs      = true
+	defaultEmitStartupEvent              = true
 	defaultPartitionsTTL                 = "60s" // TTL for the partitions cache, to avoid polling continuously for them
+	defaultPublicIPTTL                   = "1h"  // TTL for the detected public IP
 	defaultStartupConnectionRetries      = 6     // -1 will try forever with an exponential backoff algorithm
 	defaultSupervisorRpcSock             = "/var/run/supervisor.sock"
 	defaultWinUpdatePlugin               = false
@@ -131,6 +153,7 @@ var (
 	defaultConfigDir               string
 	defaultLoggingConfigsDir       string
 	defaultLoggingHomeDir          string
+	defaultLoggingPathDenylist     []string
 	defaultFluentBitParsers        string
 	defaultFluentBitNRLib          string
 	defaultIntegrationsTempDir     string
@@ -0,0 +1,129 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"gopkg.in/yaml.v2"
+)
+
+// DumpResolved writes the whole resolved configuration, as YAML, including the values derived at runtime (e.g.
+// run mode, agent user or the computed collector URL). When redactSecrets is true, the obfuscated options (like the
+// license key), the data binding variables (holding the vault, CyberArk, KMS or obfuscated credentials) and the
+// credentials of any URL (like the proxy one) are hidden.
+func (c *Config) DumpResolved(w io.Writer, redactSecrets bool) error {
+	fields, err := resolvedFields(reflect.ValueOf(c).Elem(), redactSecrets)
+	if err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("cannot serialize configuration: %w", err)
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+// resolvedFields returns the exported fields of the config struct as an ordered YAML map. Fields are named after
+// their yaml tag, or the snake case field name when not tagged.
+func resolvedFields(value reflect.Value, redactSecrets bool) (fields yaml.MapSlice, err error) {
+	valueType := value.Type()
+	for i := 0; i < value.NumField(); i++ {
+		field := valueType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+
+		fieldValue := value.Field(i).Interface()
+
+		if len(tag) > 1 && tag[1] == "inline" {
+			inlined, err := inlineFields(fieldValue)
+			if err != nil {
+				return nil, err
+			}
+			// Inlined blocks, like the data binding one, aren't public as they may hold credentials.
+			if redactSecrets && field.Tag.Get("public") == "false" {
+				for j := range inlined {
+					inlined[j].Value = helpers.HiddenField
+				}
+			}
+			fields = append(fields, inlined...)
+			continue
+		}
+
+		if name == "" {
+			name = snakeCase(field.Name)
+		}
+		if redactSecrets {
+			fieldValue = redactField(field, fieldValue)
+		}
+		if d, ok := fieldValue.(time.Duration); ok {
+			fieldValue = d.String()
+		}
+		fields = append(fields, yaml.MapItem{Key: name, Value: fieldValue})
+	}
+	return fields, nil
+}
+
+// redactField hides the value of the obfuscated options and the credentials of the URL ones.
+func redactField(field reflect.StructField, value interface{}) interface{} {
+	if field.Tag.Get("public") == "obfuscate" {
+		return helpers.HiddenField
+	}
+	if s, ok := value.(string); ok {
+		return redactURLCredentials(s)
+	}
+	return value
+}
+
+// inlineFields returns the YAML fields of an inlined struct.
+func inlineFields(value interface{}) (fields yaml.MapSlice, err error) {
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize configuration: %w", err)
+	}
+	err = yaml.Unmarshal(out, &fields)
+	return fields, err
+}
+
+// redactURLCredentials hides the password of an URL, like the one in the proxy option.
+func redactURLCredentials(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
+
+// snakeCase converts a Go field name to snake case, e.g. "RunMode" to "run_mode".
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if i > 0 && (unicode.IsLower(runes[i-1]) || nextIsLower) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -49,6 +49,27 @@ func ExpandInContent(content []byte) ([]byte, error) {
 	return newContent, nil
 }
 
+// referenceRegex matches ${VAR} references, and their $${VAR} escaped form.
+var referenceRegex = regexp.MustCompile(`\$?\$\{(\w+)\}`)
+
+// ExpandReferences replaces the ${VAR} references within value by the value of the VAR environment variable.
+// References to undefined variables are replaced by an empty string, and returned as missing. Escaped references,
+// as $${VAR}, are replaced by a literal ${VAR}.
+func ExpandReferences(value string) (expanded string, missing []string) {
+	expanded = referenceRegex.ReplaceAllStringFunc(value, func(reference string) string {
+		if strings.HasPrefix(reference, "$$") {
+			return reference[1:]
+		}
+		evName := referenceRegex.FindStringSubmatch(reference)[1]
+		evVal, exist := os.LookupEnv(evName)
+		if !exist {
+			missing = append(missing, evName)
+		}
+		return evVal
+	})
+	return expanded, missing
+}
+
 // removeYAMLComments removes comments from YAML content
 // golang does not support negative lookaheads
 // there's an alternative library https://github.com/dlclark/regexp2 but here we stick to stdlib
@@ -143,3 +143,30 @@ instances:
 		})
 	}
 }
+
+func TestExpandReferences(t *testing.T) {
+	t.Setenv("DEPLOY_ENV", "production")
+	t.Setenv("REGION", "eu")
+
+	tests := []struct {
+		name        string
+		value       string
+		want        string
+		wantMissing []string
+	}{
+		{"no reference", "plain value", "plain value", nil},
+		{"reference", "${DEPLOY_ENV}", "production", nil},
+		{"several references", "${DEPLOY_ENV}-${REGION}", "production-eu", nil},
+		{"undefined reference", "env: ${UNDEFINED_VAR}", "env: ", []string{"UNDEFINED_VAR"}},
+		{"escaped reference", "$${DEPLOY_ENV}", "${DEPLOY_ENV}", nil},
+		{"escaped and expanded", "$${DEPLOY_ENV}=${DEPLOY_ENV}", "${DEPLOY_ENV}=production", nil},
+		{"lone dollar", "costs $5 or {{ X }}", "costs $5 or {{ X }}", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := ExpandReferences(tt.value)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantMissing, missing)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LogFilterRegexpPrefix prefixes the log filter values that are matched as regular expressions.
+const LogFilterRegexpPrefix = "re:"
+
+// LogFilterRegexp log filter value matched as a regular expression, e.g. "re:supervisor.*".
+type LogFilterRegexp struct {
+	*regexp.Regexp
+}
+
+// MarshalYAML serializes the filter back to its configuration value.
+func (r LogFilterRegexp) MarshalYAML() (interface{}, error) {
+	return LogFilterRegexpPrefix + r.String(), nil
+}
+
+// compileRegexps replaces the values prefixed with "re:" by their compiled LogFilterRegexp. Values that cannot be
+// compiled are dropped, returning an error for each of them.
+func (f LogFilters) compileRegexps() (errs []error) {
+	for key, values := range f {
+		compiled := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			str, ok := value.(string)
+			if !ok || !strings.HasPrefix(str, LogFilterRegexpPrefix) {
+				compiled = append(compiled, value)
+				continue
+			}
+
+			re, err := regexp.Compile(strings.TrimPrefix(str, LogFilterRegexpPrefix))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid log filter %s: %s: %w", key, str, err))
+				continue
+			}
+			compiled = append(compiled, LogFilterRegexp{re})
+		}
+		f[key] = compiled
+	}
+	return errs
+}
+
+// LogFilterMatches returns true if the value is matched by the configured filter element, either literally, by the
+// wildcard or by a regular expression.
+func LogFilterMatches(element interface{}, value interface{}) bool {
+	if element == LogFilterWildcard || element == value {
+		return true
+	}
+
+	re, ok := element.(LogFilterRegexp)
+	if !ok {
+		return false
+	}
+	str, ok := value.(string)
+	return ok && re.MatchString(str)
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"sync"
+)
+
+// ParseErrors counts the integrations and log forwarding configuration files that failed to parse.
+var ParseErrors = NewParseErrorsCounter()
+
+// ParseErrorsCounter counts the configuration parse errors by file since the last interval was flushed.
+type ParseErrorsCounter struct {
+	lock     sync.Mutex
+	interval map[string]uint64
+}
+
+// NewParseErrorsCounter creates an empty ParseErrorsCounter.
+func NewParseErrorsCounter() *ParseErrorsCounter {
+	return &ParseErrorsCounter{
+		interval: map[string]uint64{},
+	}
+}
+
+// Inc counts a parse error of the configuration file.
+func (c *ParseErrorsCounter) Inc(file string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.interval[file]++
+}
+
+// FlushInterval returns the parse errors by file since the previous call, and starts a new interval.
+func (c *ParseErrorsCounter) FlushInterval() map[string]uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	counts := c.interval
+	c.interval = map[string]uint64{}
+	return counts
+}
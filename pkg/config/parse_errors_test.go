@@ -0,0 +1,24 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseErrorsCounter(t *testing.T) {
+	c := NewParseErrorsCounter()
+	assert.Empty(t, c.FlushInterval())
+
+	c.Inc("/etc/newrelic-infra/integrations.d/broken.yml")
+	c.Inc("/etc/newrelic-infra/integrations.d/broken.yml")
+	c.Inc("/etc/newrelic-infra/logging.d/broken.yml")
+
+	assert.Equal(t, map[string]uint64{
+		"/etc/newrelic-infra/integrations.d/broken.yml": 2,
+		"/etc/newrelic-infra/logging.d/broken.yml":      1,
+	}, c.FlushInterval())
+	assert.Empty(t, c.FlushInterval())
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ProcessNameFilters regular expressions matched against the process command name, deciding which processes are
+// sampled. Excluded processes are never sampled, and when any include is set only the included processes are.
+type ProcessNameFilters struct {
+	Include []ProcessNameRegexp `yaml:"include" envconfig:"include"`
+	Exclude []ProcessNameRegexp `yaml:"exclude" envconfig:"exclude"`
+}
+
+// ProcessNameRegexp regular expression of the process_name_filters, compiled when the configuration is loaded.
+type ProcessNameRegexp struct {
+	*regexp.Regexp
+}
+
+// UnmarshalYAML compiles the regular expression from the YAML config.
+func (r *ProcessNameRegexp) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var expr string
+	if err := unmarshal(&expr); err != nil {
+		return err
+	}
+	return r.Decode(expr)
+}
+
+// Decode compiles the regular expression from an environment variable value.
+func (r *ProcessNameRegexp) Decode(value string) error {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return fmt.Errorf("invalid process name filter %q: %w", value, err)
+	}
+	r.Regexp = re
+	return nil
+}
+
+// MarshalYAML serializes the filter back to its configuration value.
+func (r ProcessNameRegexp) MarshalYAML() (interface{}, error) {
+	return r.String(), nil
+}
+
+// Enabled returns true when any process name filter is configured.
+func (f ProcessNameFilters) Enabled() bool {
+	return len(f.Include) > 0 || len(f.Exclude) > 0
+}
+
+// Accepts returns whether a process with the given command name must be sampled.
+func (f ProcessNameFilters) Accepts(name string) bool {
+	for _, re := range f.Exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, re := range f.Include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
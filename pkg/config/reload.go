@@ -0,0 +1,240 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const licenseKeyOption = "license_key"
+
+var (
+	// LogReloadOptions config options, by their yaml name, setting up the agent logging. The log file, forwarding,
+	// stdout and rotation options require a restart.
+	LogReloadOptions = []string{
+		"log.level",
+		"log.format",
+		"log.smart_level_entry_limit",
+		"log.include_filters",
+		"log.exclude_filters",
+		"log.field_map",
+		"log.http_trace_sample_rate",
+		"verbose",
+	}
+	// SampleRateReloadOptions config options, by their yaml name, setting the metrics samplers intervals.
+	SampleRateReloadOptions = []string{
+		"metrics_system_sample_rate",
+		"metrics_storage_sample_rate",
+		"metrics_network_sample_rate",
+		"metrics_tcp_sample_rate",
+		"metrics_process_sample_rate",
+		"metrics_nfs_sample_rate",
+		"metrics_sample_rate_overrides",
+	}
+	// DeploymentMarkerReloadOptions config options, by their yaml name, setting the deployment marker of the samples.
+	DeploymentMarkerReloadOptions = []string{"deployment_marker"}
+	// CustomAttributesReloadOptions config options, by their yaml name, setting the custom attributes of the host.
+	CustomAttributesReloadOptions = []string{"custom_attributes"}
+)
+
+// ReloadHook applies to the running agent the reloaded configuration options it's registered for. The config
+// must not be modified.
+type ReloadHook func(cfg *Config) error
+
+type reloadHook struct {
+	options []string
+	apply   ReloadHook
+}
+
+// ReloadableConfig holds the running agent Config, so it can be refreshed from the configuration file without
+// restarting the agent. Only the options with a registered hook, that applies them to the running agent, are
+// reloaded. Every reload publishes a new Config snapshot, so the ones already handed out are never modified.
+type ReloadableConfig struct {
+	lock       sync.Mutex
+	cfg        *Config
+	configFile string
+	loadFn     func(configFile string) (*Config, error)
+	now        func() time.Time
+	hooks      []reloadHook
+	status     ReloadStatus
+}
+
+// ReloadStatus outcome of the configuration reloads, so operators can confirm a config rollout took effect.
+type ReloadStatus struct {
+	// LastReload time of the last successful reload. Nil when the configuration wasn't reloaded yet.
+	LastReload *time.Time `json:"last_reload,omitempty"`
+	// LastReloadFailed whether the last reload attempt was rejected.
+	LastReloadFailed bool `json:"last_reload_failed"`
+	// LastReloadError reason the last reload attempt was rejected, if it was.
+	LastReloadError string `json:"last_reload_error,omitempty"`
+}
+
+// NewReloadableConfig creates a ReloadableConfig for the running config, loaded from configFile.
+func NewReloadableConfig(cfg *Config, configFile string) *ReloadableConfig {
+	return &ReloadableConfig{
+		cfg:        cfg,
+		configFile: configFile,
+		loadFn:     LoadConfig,
+		now:        time.Now,
+	}
+}
+
+// RegisterHook makes the given options, by their yaml name, reloadable. Nested options are named by their dotted
+// yaml path, as "log.level". The hook is invoked with the reloaded
+// config when any of them changes.
+func (r *ReloadableConfig) RegisterHook(hook ReloadHook, options ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.hooks = append(r.hooks, reloadHook{options: options, apply: hook})
+}
+
+// Config returns the current configuration snapshot, which must not be modified.
+func (r *ReloadableConfig) Config() *Config {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.cfg
+}
+
+// Status returns the outcome of the configuration reloads.
+func (r *ReloadableConfig) Status() ReloadStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.status
+}
+
+// Reload re-reads the configuration file and applies, through their hooks, the reloadable options whose value
+// changed. It returns the names of the changed and skipped options, the latter keeping their running value. In
+// case the configuration cannot be loaded, or a hook fails applying it, the running config is kept and applied
+// again by the hooks that already applied the reloaded one.
+func (r *ReloadableConfig) Reload() (changed []string, skipped []string, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	newCfg, err := r.loadFn(r.configFile)
+	if err != nil {
+		clog.WithError(err).Error("Configuration reload rejected, keeping the running configuration.")
+		r.status.LastReloadFailed = true
+		r.status.LastReloadError = err.Error()
+		return nil, nil, err
+	}
+
+	reloadable := make(map[string]struct{})
+	for _, hook := range r.hooks {
+		for _, option := range hook.options {
+			reloadable[option] = struct{}{}
+		}
+	}
+
+	// The loaded config becomes the new snapshot, once the options that cannot be reloaded get their running value.
+	changed, skipped = reloadOptions("", reflect.ValueOf(r.cfg).Elem(), reflect.ValueOf(newCfg).Elem(), reloadable)
+
+	// The reload only succeeds once every hook has applied its options. Otherwise the hooks already applied are
+	// rolled back to the running config, so the agent keeps running with it.
+	var applied []reloadHook
+	for _, hook := range r.hooks {
+		if !containsAny(changed, hook.options) {
+			continue
+		}
+		if err = hook.apply(newCfg); err != nil {
+			clog.WithError(err).WithField("options", hook.options).Error("Cannot apply reloaded configuration options.")
+			r.rollback(applied)
+			r.status.LastReloadFailed = true
+			r.status.LastReloadError = err.Error()
+			return changed, skipped, err
+		}
+		applied = append(applied, hook)
+	}
+	r.cfg = newCfg
+
+	now := r.now()
+	r.status = ReloadStatus{LastReload: &now}
+
+	clog.WithFields(logrus.Fields{
+		"changed": changed,
+		"skipped": skipped,
+	}).Info("Configuration reloaded.")
+
+	return changed, skipped, nil
+}
+
+// rollback applies again the running config through the given hooks, in reverse order.
+func (r *ReloadableConfig) rollback(hooks []reloadHook) {
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].apply(r.cfg); err != nil {
+			clog.WithError(err).WithField("options", hooks[i].options).
+				Error("Cannot roll back reloaded configuration options, the agent might be running with them.")
+		}
+	}
+}
+
+// reloadOptions compares the running and loaded values of the config options, setting back the running value of the
+// ones that cannot be reloaded. Options holding reloadable nested options, by their dotted name as "log.level", are
+// compared option by option.
+func reloadOptions(prefix string, running, loaded reflect.Value, reloadable map[string]struct{}) (changed []string, skipped []string) {
+	for i := 0; i < running.NumField(); i++ {
+		option := yamlOptionName(running.Type().Field(i))
+		if option == "" || reflect.DeepEqual(running.Field(i).Interface(), loaded.Field(i).Interface()) {
+			continue
+		}
+		option = prefix + option
+
+		if _, ok := reloadable[option]; ok {
+			changed = append(changed, option)
+			continue
+		}
+
+		if running.Field(i).Kind() == reflect.Struct && hasNestedOptions(option, reloadable) {
+			nestedChanged, nestedSkipped := reloadOptions(option+".", running.Field(i), loaded.Field(i), reloadable)
+			changed = append(changed, nestedChanged...)
+			skipped = append(skipped, nestedSkipped...)
+			continue
+		}
+
+		if option == licenseKeyOption {
+			clog.WithField("option", option).Warn("Configuration option change ignored, requires restart.")
+		}
+		loaded.Field(i).Set(running.Field(i))
+		skipped = append(skipped, option)
+	}
+	return changed, skipped
+}
+
+func hasNestedOptions(option string, reloadable map[string]struct{}) bool {
+	for o := range reloadable {
+		if strings.HasPrefix(o, option+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// yamlOptionName returns the yaml config option name of an exported field, or empty if it's not a config option.
+func yamlOptionName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func containsAny(values []string, wanted []string) bool {
+	for _, value := range values {
+		for _, w := range wanted {
+			if value == w {
+				return true
+			}
+		}
+	}
+	return false
+}
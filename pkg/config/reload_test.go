@@ -0,0 +1,256 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadableConfig_Reload(t *testing.T) {
+	running := NewConfig()
+	running.License = "running-license"
+	running.AgentDir = "/var/db/newrelic-infra"
+	running.MetricsSystemSampleRate = 5
+	running.CustomAttributes = CustomAttributeMap{"env": "staging"}
+
+	loaded := NewConfig()
+	loaded.License = "new-license"
+	loaded.AgentDir = "/opt/newrelic-infra"
+	loaded.MetricsSystemSampleRate = 60
+	loaded.CustomAttributes = CustomAttributeMap{"env": "production"}
+
+	r := NewReloadableConfig(running, "newrelic-infra.yml")
+	r.loadFn = func(configFile string) (*Config, error) {
+		assert.Equal(t, "newrelic-infra.yml", configFile)
+		return loaded, nil
+	}
+	var applied []*Config
+	r.RegisterHook(func(cfg *Config) error {
+		applied = append(applied, cfg)
+		return nil
+	}, SampleRateReloadOptions...)
+	r.RegisterHook(func(cfg *Config) error {
+		assert.Fail(t, "log options didn't change")
+		return nil
+	}, LogReloadOptions...)
+
+	changed, skipped, err := r.Reload()
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"metrics_system_sample_rate"}, changed)
+	assert.ElementsMatch(t, []string{"license_key", "agent_dir", "custom_attributes"}, skipped)
+
+	// the hook gets the new snapshot, with the skipped options keeping their running value
+	require.Len(t, applied, 1)
+	assert.Same(t, applied[0], r.Config())
+	assert.Equal(t, 60, r.Config().MetricsSystemSampleRate)
+	assert.Equal(t, "running-license", r.Config().License)
+	assert.Equal(t, "/var/db/newrelic-infra", r.Config().AgentDir)
+	assert.Equal(t, CustomAttributeMap{"env": "staging"}, r.Config().CustomAttributes)
+
+	// the previous snapshot is not modified
+	assert.Equal(t, 5, running.MetricsSystemSampleRate)
+}
+
+func TestReloadableConfig_Reload_NestedOptions(t *testing.T) {
+	forward := true
+	running := NewConfig()
+	running.Log.Level = LogLevelInfo
+	running.Log.File = "/var/log/newrelic-infra.log"
+
+	loaded := NewConfig()
+	loaded.Log.Level = LogLevelDebug
+	loaded.Log.File = "/tmp/newrelic-infra.log"
+	loaded.Log.Forward = &forward
+
+	r := NewReloadableConfig(running, "newrelic-infra.yml")
+	r.loadFn = func(string) (*Config, error) { return loaded, nil }
+	var applied LogConfig
+	r.RegisterHook(func(cfg *Config) error {
+		applied = cfg.Log
+		return nil
+	}, LogReloadOptions...)
+
+	changed, skipped, err := r.Reload()
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"log.level"}, changed)
+	assert.ElementsMatch(t, []string{"log.file", "log.forward"}, skipped)
+	assert.Equal(t, LogLevelDebug, applied.Level)
+	assert.Equal(t, "/var/log/newrelic-infra.log", r.Config().Log.File)
+	assert.Equal(t, running.Log.Forward, r.Config().Log.Forward)
+}
+
+func TestReloadableConfig_Reload_HookFailureRollsBack(t *testing.T) {
+	running := NewConfig()
+	running.MetricsSystemSampleRate = 5
+	running.DeploymentMarker = "v1.42.0"
+
+	loaded := NewConfig()
+	loaded.MetricsSystemSampleRate = 60
+	loaded.DeploymentMarker = "v1.43.0"
+
+	r := NewReloadableConfig(running, "newrelic-infra.yml")
+	r.loadFn = func(string) (*Config, error) { return loaded, nil }
+	var sampleRates []int
+	r.RegisterHook(func(cfg *Config) error {
+		sampleRates = append(sampleRates, cfg.MetricsSystemSampleRate)
+		return nil
+	}, SampleRateReloadOptions...)
+	r.RegisterHook(func(*Config) error { return errors.New("cannot apply marker") }, DeploymentMarkerReloadOptions...)
+
+	_, _, err := r.Reload()
+	require.EqualError(t, err, "cannot apply marker")
+
+	// the applied sample rate is set back to the running one
+	assert.Equal(t, []int{60, 5}, sampleRates)
+	assert.Same(t, running, r.Config())
+}
+
+func TestReloadableConfig_Reload_Concurrent(t *testing.T) {
+	running := NewConfig()
+	running.MetricsSystemSampleRate = 5
+
+	r := NewReloadableConfig(running, "newrelic-infra.yml")
+	r.loadFn = func(string) (*Config, error) {
+		loaded := NewConfig()
+		loaded.MetricsSystemSampleRate = running.MetricsSystemSampleRate + 1
+		return loaded, nil
+	}
+	r.RegisterHook(func(*Config) error { return nil }, SampleRateReloadOptions...)
+
+	// run with -race to check snapshots are published safely while being read
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _, err := r.Reload()
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			cfg := r.Config()
+			assert.True(t, cfg.MetricsSystemSampleRate == 5 || cfg.MetricsSystemSampleRate == 6)
+			_ = r.Status()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 6, r.Config().MetricsSystemSampleRate)
+	assert.Equal(t, 5, running.MetricsSystemSampleRate)
+}
+
+func TestReloadableConfig_Reload_MalformedFile(t *testing.T) {
+	tmp, err := createTestFile([]byte("license_key: xxx\nmetrics_system_sample_rate: [\n"))
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+
+	running := NewConfig()
+	running.MetricsSystemSampleRate = 5
+
+	r := NewReloadableConfig(running, tmp.Name())
+	changed, skipped, err := r.Reload()
+	assert.Error(t, err)
+	assert.Empty(t, changed)
+	assert.Empty(t, skipped)
+	assert.Same(t, running, r.Config())
+	assert.Equal(t, 5, running.MetricsSystemSampleRate)
+}
+
+func TestReloadableConfig_Status(t *testing.T) {
+	reloadTime := time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	r := NewReloadableConfig(NewConfig(), "newrelic-infra.yml")
+	r.now = func() time.Time { return reloadTime }
+	assert.Equal(t, ReloadStatus{}, r.Status())
+
+	r.loadFn = func(string) (*Config, error) { return NewConfig(), nil }
+	_, _, err := r.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, ReloadStatus{LastReload: &reloadTime}, r.Status())
+
+	r.loadFn = func(string) (*Config, error) { return nil, errors.New("malformed yaml") }
+	_, _, err = r.Reload()
+	require.Error(t, err)
+	assert.Equal(t, ReloadStatus{
+		LastReload:       &reloadTime,
+		LastReloadFailed: true,
+		LastReloadError:  "malformed yaml",
+	}, r.Status(), "a failed reload keeps the time of the last successful one")
+
+	r.loadFn = func(string) (*Config, error) {
+		loaded := NewConfig()
+		loaded.MetricsSystemSampleRate = 60
+		return loaded, nil
+	}
+	r.RegisterHook(func(*Config) error { return errors.New("cannot reschedule") }, SampleRateReloadOptions...)
+	_, _, err = r.Reload()
+	require.Error(t, err)
+	assert.Equal(t, ReloadStatus{
+		LastReload:       &reloadTime,
+		LastReloadFailed: true,
+		LastReloadError:  "cannot reschedule",
+	}, r.Status(), "a reload is only successful once its options are applied")
+	assert.NotEqual(t, 60, r.Config().MetricsSystemSampleRate)
+
+	newReloadTime := reloadTime.Add(time.Hour)
+	r.now = func() time.Time { return newReloadTime }
+	r.loadFn = func(string) (*Config, error) { return NewConfig(), nil }
+	_, _, err = r.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, ReloadStatus{LastReload: &newReloadTime}, r.Status())
+}
+
+func TestReloadableConfig_Reload_DeploymentMarker(t *testing.T) {
+	tmp, err := createTestFile([]byte("license_key: xxx\ndeployment_marker: v1.43.0\n"))
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+
+	running := NewConfig()
+	running.DeploymentMarker = "v1.42.0"
+
+	r := NewReloadableConfig(running, tmp.Name())
+	var applied string
+	r.RegisterHook(func(cfg *Config) error {
+		applied = cfg.DeploymentMarker
+		return nil
+	}, DeploymentMarkerReloadOptions...)
+
+	changed, _, err := r.Reload()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"deployment_marker"}, changed)
+	assert.Equal(t, "v1.43.0", applied)
+	assert.Equal(t, "v1.43.0", r.Config().DeploymentMarker)
+}
+
+func TestReloadableConfig_Reload_CustomAttributes(t *testing.T) {
+	tmp, err := createTestFile([]byte("license_key: xxx\ncustom_attributes:\n  env: production\n"))
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+
+	running := NewConfig()
+	running.CustomAttributes = CustomAttributeMap{"env": "staging"}
+
+	r := NewReloadableConfig(running, tmp.Name())
+	var applied CustomAttributeMap
+	r.RegisterHook(func(cfg *Config) error {
+		applied = cfg.CustomAttributes
+		return nil
+	}, CustomAttributesReloadOptions...)
+
+	changed, _, err := r.Reload()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"custom_attributes"}, changed)
+	assert.Equal(t, CustomAttributeMap{"env": "production"}, applied)
+	assert.Equal(t, CustomAttributeMap{"env": "production"}, r.Config().CustomAttributes)
+}
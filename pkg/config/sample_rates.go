@@ -0,0 +1,57 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"time"
+)
+
+// SampleRateStatus effective sample rate of a metrics sampler, once the configuration has been normalized.
+type SampleRateStatus struct {
+	IntervalSec int64 `json:"interval_sec"`
+	// Disabled is true when sampling is disabled (FREQ_DISABLE_SAMPLING).
+	Disabled bool `json:"disabled"`
+	// Floored is true when the configured sample rate was below the sampler minimum value.
+	Floored bool `json:"floored"`
+}
+
+// EffectiveSampleRates returns the sampling interval of every metrics sampler, as resolved after normalizing the
+// configuration. Disabled samplers report FREQ_DISABLE_SAMPLING seconds. In case databind is in use, the refreshed
+// configuration is used.
+func (cfg *Config) EffectiveSampleRates() map[string]time.Duration {
+	c := cfg.Provide()
+
+	rates := map[string]int{
+		"system":  c.MetricsSystemSampleRate,
+		"storage": c.MetricsStorageSampleRate,
+		"network": c.MetricsNetworkSampleRate,
+		"tcp":     c.MetricsTCPSampleRate,
+		"process": c.MetricsProcessSampleRate,
+		"nfs":     c.MetricsNFSSampleRate,
+	}
+
+	sampleRates := make(map[string]time.Duration, len(rates))
+	for sampler, rate := range rates {
+		if rate <= FREQ_DISABLE_SAMPLING {
+			rate = FREQ_DISABLE_SAMPLING
+		}
+		sampleRates[sampler] = time.Duration(rate) * time.Second
+	}
+	return sampleRates
+}
+
+// SampleRatesStatus returns the effective sample rates, flagging the samplers that are disabled or whose configured
+// sample rate was raised to the minimum value.
+func (cfg *Config) SampleRatesStatus() map[string]SampleRateStatus {
+	floored := cfg.Provide().flooredSampleRates
+
+	status := make(map[string]SampleRateStatus)
+	for sampler, interval := range cfg.EffectiveSampleRates() {
+		status[sampler] = SampleRateStatus{
+			IntervalSec: int64(interval / time.Second),
+			Disabled:    interval <= FREQ_DISABLE_SAMPLING*time.Second,
+			Floored:     floored[sampler],
+		}
+	}
+	return status
+}
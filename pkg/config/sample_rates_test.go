@@ -0,0 +1,47 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_EffectiveSampleRates(t *testing.T) {
+	yamlCfg := `
+license_key: "xxx"
+metrics_system_sample_rate: 1
+metrics_storage_sample_rate: -1
+metrics_process_sample_rate: 60
+metrics_sample_rate_overrides:
+  network: 1
+`
+	tmp, err := createTestFile([]byte(yamlCfg))
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+
+	cfg, err := LoadConfig(tmp.Name())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]time.Duration{
+		"system":  FREQ_INTERVAL_FLOOR_SYSTEM_METRICS * time.Second,
+		"storage": FREQ_DISABLE_SAMPLING * time.Second,
+		"network": FREQ_INTERVAL_FLOOR_STORAGE_METRICS * time.Second,
+		"tcp":     time.Duration(DefaultMetricsTCPSampleRate) * time.Second,
+		"process": 60 * time.Second,
+		"nfs":     time.Duration(DefaultMetricsNFSSampleRate) * time.Second,
+	}, cfg.EffectiveSampleRates())
+
+	assert.Equal(t, map[string]SampleRateStatus{
+		"system":  {IntervalSec: FREQ_INTERVAL_FLOOR_SYSTEM_METRICS, Floored: true},
+		"storage": {IntervalSec: FREQ_DISABLE_SAMPLING, Disabled: true},
+		"network": {IntervalSec: FREQ_INTERVAL_FLOOR_STORAGE_METRICS, Floored: true},
+		"tcp":     {IntervalSec: int64(DefaultMetricsTCPSampleRate)},
+		"process": {IntervalSec: 60},
+		"nfs":     {IntervalSec: int64(DefaultMetricsNFSSampleRate)},
+	}, cfg.SampleRatesStatus())
+}
@@ -21,7 +21,9 @@ const (
 	FREQ_INTERVAL_FLOOR_SYSTEM_METRICS  = 15 // seconds, fastest that metrics can be configured to sample
 	FREQ_INTERVAL_FLOOR_STORAGE_METRICS = 15 // seconds
 	FREQ_INTERVAL_FLOOR_NETWORK_METRICS = 15 // seconds
+	FREQ_INTERVAL_FLOOR_TCP_METRICS     = 15 // seconds, reading the sockets tables is expensive on hosts with many connections
 	FREQ_INTERVAL_FLOOR_PROCESS_METRICS = 20 // seconds, process time has great impact on our cap planning, ask before changing
+	FREQ_INTERVAL_FLOOR_SMART_METRICS   = 60 // seconds, smartctl queries every disk
 
 	FREQ_METRICS_SEND_INTERVAL    = FREQ_INTERVAL_FLOOR_METRICS // seconds between sending samples for base metrics (System, Process, etc)
 	INITIAL_REAP_MAX_WAIT_SECONDS = 60                          // seconds to wait for all plugins to report before reporting data anyway
@@ -31,6 +33,12 @@ const (
 	FREQ_PLUGIN_KERNEL_MODULES_UPDATES = 10 //seconds
 	FREQ_PLUGIN_USERS_UPDATES          = 15 //seconds
 	FREQ_PLUGIN_SSHD_CONFIG_UPDATES    = 15 //seconds
+	FREQ_PLUGIN_SSH_HOST_KEYS_UPDATES  = 60 //seconds
+	FREQ_PLUGIN_DNS_CONFIG_UPDATES     = 60 //seconds
+	FREQ_PLUGIN_HOSTS_FILE_UPDATES     = 60 //seconds
+	FREQ_PLUGIN_NETWORK_ROUTES_UPDATES = 60 //seconds
+	FREQ_PLUGIN_SUDOERS_UPDATES        = 60 //seconds
+	FREQ_PLUGIN_FIREWALL_RULES_UPDATES = 60 //seconds
 	FREQ_PLUGIN_SUPERVISOR_UPDATES     = 15 //seconds
 	FREQ_PLUGIN_DAEMONTOOLS_UPDATES    = 15 //seconds
 	FREQ_PLUGIN_SYSTEMD_UPDATES        = 30 // seconds
@@ -44,6 +52,10 @@ const (
 	FREQ_PLUGIN_NETWORK_INTERFACE_UPDATES = 60 // seconds
 	FREQ_PLUGIN_CLOUD_SECURITY_UPDATES    = 60 // seconds
 
+	FREQ_PLUGIN_PACKAGE_UPDATES           = 3600 // seconds, querying the package manager for available updates is expensive
+	FREQ_PLUGIN_FIRMWARE_UPDATES          = 3600 // seconds, firmware only changes on upgrades, which require a reboot
+	FREQ_PLUGIN_CONTAINER_RUNTIME_UPDATES = 3600 // seconds, container runtimes only change on upgrades
+
 	// WINDOWS PLUGINS
 	FREQ_PLUGIN_WINDOWS_SERVICES = 30 // seconds, 0 == off, 30 == minimum otherwise: inventory: running services
 	FREQ_PLUGIN_WINDOWS_UPDATES  = 60 // seconds
@@ -51,6 +63,8 @@ const (
 	// BOTH
 	FREQ_EXTERNAL_USER_DATA      = 30 // seconds between external user data samples (deprecated user json plugin)
 	FREQ_PLUGIN_EXTERNAL_PLUGINS = 30 // seconds
+	FREQ_PLUGIN_SCHEDULED_TASKS  = 60 // seconds, cron jobs on Linux, scheduled tasks on Windows
+	FREQ_PLUGIN_CLOUD_LIFECYCLE  = 30 // seconds, spot interruption notices are given two minutes in advance
 
 	defaultFirstReapInterval = 1 * time.Second  // inventory: reap every second until first successful reap, then switch to DefaultReapInterval
 	defaultReapInterval      = 20 * time.Second // seconds, inventory: fire reap trigger every 10 seconds after first successful reap
@@ -4,11 +4,17 @@ package helpers
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 )
 
+// commandWaitDelay time a command killed on its context cancellation has to release its output, in case any child
+// process keeps it open.
+const commandWaitDelay = 5 * time.Second
+
 type Command struct {
 	*exec.Cmd
 }
@@ -17,6 +23,13 @@ func NewCommand(command string, arguments ...string) *Command {
 	return &Command{Cmd: exec.Command(command, arguments...)}
 }
 
+// NewCommandContext creates a command that is killed once ctx is done.
+func NewCommandContext(ctx context.Context, command string, arguments ...string) *Command {
+	cmd := exec.CommandContext(ctx, command, arguments...)
+	cmd.WaitDelay = commandWaitDelay
+	return &Command{Cmd: cmd}
+}
+
 func (c *Command) WithStdin(stdin string) *Command {
 	c.Cmd.Stdin = bytes.NewBufferString(stdin)
 	return c
@@ -32,3 +45,8 @@ func (c *Command) Run() (string, error) {
 func RunCommand(command string, stdin string, arguments ...string) (string, error) {
 	return NewCommand(command, arguments...).WithStdin(stdin).Run()
 }
+
+// RunCommandContext runs the command as RunCommand does, killing it once ctx is done.
+func RunCommandContext(ctx context.Context, command string, stdin string, arguments ...string) (string, error) {
+	return NewCommandContext(ctx, command, arguments...).WithStdin(stdin).Run()
+}
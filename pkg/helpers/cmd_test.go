@@ -6,9 +6,12 @@
 package helpers
 
 import (
+	"context"
+	"testing"
+	"time"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"testing"
 )
 
 func TestRunCommand(t *testing.T) {
@@ -30,3 +33,14 @@ func TestRunCommand(t *testing.T) {
 	require.Error(t, err)
 	assert.Equal(t, "", obtainedOutput[2])
 }
+
+func TestRunCommandContext_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := RunCommandContext(ctx, "/bin/sleep", "", "10")
+	require.Error(t, err)
+	assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -14,6 +14,14 @@ const (
 	DefaultLinuxOsReleasePath = "/os-release"
 )
 
+// Init systems that can be detected on the host.
+const (
+	InitSystemUnknown  = "unknown"
+	InitSystemSystemd  = "systemd"
+	InitSystemUpstart  = "upstart"
+	InitSystemSysvinit = "sysvinit"
+)
+
 var (
 	osReleaseFilePath = DefaultLinuxOsReleasePath
 )
@@ -95,3 +103,24 @@ func IsAmazonOS() bool {
 
 	return false
 }
+
+// GetInitSystem detects the init system managing the host by looking at the command name of the PID 1 process.
+// Upstart and SysV both run as "init", so upstart is identified by the presence of its jobs directory.
+func GetInitSystem() string {
+	comm, err := ioutil.ReadFile(HostProc("/1/comm"))
+	if err != nil {
+		return InitSystemUnknown
+	}
+
+	switch strings.TrimSpace(string(comm)) {
+	case "systemd":
+		return InitSystemSystemd
+	case "init":
+		if fi, err := os.Stat(HostEtc("/init")); err == nil && fi.IsDir() {
+			return InitSystemUpstart
+		}
+		return InitSystemSysvinit
+	}
+
+	return InitSystemUnknown
+}
@@ -118,3 +118,38 @@ func (s *DetectionSuite) TestIsAmazonOS(c *C) {
 	os.Setenv("HOST_ETC", filepath.Dir(tmpEc2))
 	c.Assert(IsAmazonOS(), Equals, true)
 }
+
+func (s *DetectionSuite) TestGetInitSystem(c *C) {
+	testCases := []struct {
+		name     string
+		comm     string
+		initDir  bool
+		expected string
+	}{
+		{name: "systemd present", comm: "systemd\n", expected: InitSystemSystemd},
+		{name: "upstart", comm: "init\n", initDir: true, expected: InitSystemUpstart},
+		{name: "sysvinit", comm: "init\n", expected: InitSystemSysvinit},
+		{name: "systemd absent in container", comm: "bash\n", expected: InitSystemUnknown},
+		{name: "no pid 1 info", expected: InitSystemUnknown},
+	}
+
+	for _, tc := range testCases {
+		tmpRoot := c.MkDir()
+		procDir := filepath.Join(tmpRoot, "proc")
+		etcDir := filepath.Join(tmpRoot, "etc")
+		c.Assert(os.MkdirAll(filepath.Join(procDir, "1"), 0755), IsNil)
+		c.Assert(os.MkdirAll(etcDir, 0755), IsNil)
+		if tc.comm != "" {
+			c.Assert(ioutil.WriteFile(filepath.Join(procDir, "1", "comm"), []byte(tc.comm), 0644), IsNil)
+		}
+		if tc.initDir {
+			c.Assert(os.Mkdir(filepath.Join(etcDir, "init"), 0755), IsNil)
+		}
+		os.Setenv("HOST_PROC", procDir)
+		os.Setenv("HOST_ETC", etcDir)
+
+		c.Check(GetInitSystem(), Equals, tc.expected, Commentf(tc.name))
+	}
+	os.Unsetenv("HOST_PROC")
+	os.Unsetenv("HOST_ETC")
+}
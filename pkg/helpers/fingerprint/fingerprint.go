@@ -21,6 +21,7 @@ type Harvester interface {
 
 type harvestor struct {
 	config         *config.Config // Agent configuration.
+	displayName    string         // Effective display name, which may differ from the configured one.
 	resolver       hostname.Resolver
 	cloudHarvester cloud.Harvester
 }
@@ -43,14 +44,15 @@ func (ir *MockHarvestor) Harvest() (Fingerprint, error) {
 
 var hlog = log.WithComponent("Harvestor")
 
-// NewHarvestor creates a new Harvester.
-func NewHarvestor(config *config.Config, hostnameResolver hostname.Resolver, cloudHarvester cloud.Harvester) (Harvester, error) {
+// NewHarvestor creates a new Harvester reporting the given display name.
+func NewHarvestor(config *config.Config, displayName string, hostnameResolver hostname.Resolver, cloudHarvester cloud.Harvester) (Harvester, error) {
 	if config == nil {
 		return nil, fmt.Errorf("cannot initialize System Information service: invalid configuration")
 	}
 
 	return &harvestor{
 		config:         config,
+		displayName:    displayName,
 		resolver:       hostnameResolver,
 		cloudHarvester: cloudHarvester,
 	}, nil
@@ -144,7 +146,7 @@ func (ir *harvestor) Harvest() (fp Fingerprint, err error) {
 	return Fingerprint{
 		FullHostname:    fullHostname,
 		Hostname:        shortHostname,
-		DisplayName:     ir.config.DisplayName,
+		DisplayName:     ir.displayName,
 		BootID:          GetBootId(),
 		IpAddresses:     ipAddresses,
 		CloudProviderId: instanceID,
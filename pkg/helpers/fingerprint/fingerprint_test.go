@@ -97,7 +97,7 @@ func TestCloud(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.testName, func(t *testing.T) {
-			fpHarvester, _ := NewHarvestor(config, hostnameResolver, tt.cloudHarvester)
+			fpHarvester, _ := NewHarvestor(config, config.DisplayName, hostnameResolver, tt.cloudHarvester)
 			fp, err := fpHarvester.Harvest()
 			assert.Equal(t, fp.CloudProviderId, tt.expectedInstanceId)
 			if tt.expectedError != nil {
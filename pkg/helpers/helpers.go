@@ -18,6 +18,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 	"github.com/sirupsen/logrus"
@@ -52,12 +53,21 @@ const (
 
 // Calculates the backoff as a function of the base and the maximum intervals, and the count of retries
 func ExpBackoff(base, max time.Duration, count uint32) time.Duration {
+	return ExpBackoffWithStep(base, time.Second, max, count)
+}
+
+// ExpBackoffWithStep calculates the backoff as ExpBackoff, doubling the given step instead of a second on each retry.
+func ExpBackoffWithStep(base, step, max time.Duration, count uint32) time.Duration {
 	// can only shift to the 31st bit return max
 	if count >= MaxBackoffErrorCount {
 		return max
 	}
 	// bitshift to get pow2 cheaply
-	backoff := time.Duration(1<<(count-1))*time.Second + base
+	factor := time.Duration(1 << (count - 1))
+	if step > 0 && factor > (max-base)/step {
+		return max
+	}
+	backoff := factor*step + base
 	if backoff > max {
 		return max
 	}
@@ -451,3 +461,17 @@ func SplitRightSubstring(output, substring, separator string) string {
 
 	return output[start : start+right]
 }
+
+// TruncateUTF8 returns the longest prefix of s that fits in maxBytes without splitting a multibyte rune.
+func TruncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	if maxBytes <= 0 {
+		return ""
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	databind "github.com/newrelic/infrastructure-agent/pkg/databind/pkg/data"
 	"github.com/pkg/errors"
@@ -586,3 +587,25 @@ func TestSplitRightSubstring(t *testing.T) {
 		})
 	}
 }
+
+func TestExpBackoffWithStep(t *testing.T) {
+	base := 10 * time.Second
+	max := time.Minute
+
+	assert.Equal(t, 15*time.Second, ExpBackoffWithStep(base, 5*time.Second, max, 1))
+	assert.Equal(t, 20*time.Second, ExpBackoffWithStep(base, 5*time.Second, max, 2))
+	assert.Equal(t, 50*time.Second, ExpBackoffWithStep(base, 5*time.Second, max, 4))
+	assert.Equal(t, max, ExpBackoffWithStep(base, 5*time.Second, max, 5))
+	assert.Equal(t, max, ExpBackoffWithStep(base, time.Hour, max, 30))
+	assert.Equal(t, ExpBackoff(base, max, 3), ExpBackoffWithStep(base, time.Second, max, 3))
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	assert.Equal(t, "hello", TruncateUTF8("hello", 10))
+	assert.Equal(t, "hel", TruncateUTF8("hello", 3))
+	assert.Equal(t, "", TruncateUTF8("hello", 0))
+	// "ñ" takes 2 bytes and "€" 3 bytes, so they are dropped instead of split
+	assert.Equal(t, "a", TruncateUTF8("añb", 2))
+	assert.Equal(t, "añ", TruncateUTF8("añb", 3))
+	assert.Equal(t, "", TruncateUTF8("€uro", 2))
+}
@@ -0,0 +1,116 @@
+// Copyright New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/instrumentation"
+)
+
+// spanTrace builds the spans of a traced request.
+type spanTrace struct {
+	lock     sync.Mutex
+	recorder instrumentation.SpanRecorder
+	request  instrumentation.Span
+	ended    bool
+	dns      instrumentation.Span
+	connects map[string]instrumentation.Span // by address, as they may be dialed in parallel
+	tls      instrumentation.Span
+}
+
+// withSpanTrace records the request as a client span, with child spans for the name resolution, the connections and
+// the TLS handshake. The request span ends once the first response byte is received or the request fails to be
+// sent. Requests that can't connect are only reported by their failed connection spans.
+func withSpanTrace(req *http.Request, requester string, recorder instrumentation.SpanRecorder) *http.Request {
+	t := &spanTrace{
+		recorder: recorder,
+		request:  instrumentation.NewSpan("HTTP "+req.Method, nil),
+		connects: map[string]instrumentation.Span{},
+	}
+	t.request.Attributes["http.request.method"] = req.Method
+	t.request.Attributes["server.address"] = req.URL.Hostname()
+	t.request.Attributes["url.path"] = req.URL.Path
+	t.request.Attributes["requester"] = requester
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.dns = instrumentation.NewSpan("dns", &t.request)
+			t.dns.Attributes["host"] = info.Host
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.record(t.dns, info.Err)
+		},
+		ConnectStart: func(network, addr string) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			connect := instrumentation.NewSpan("connect", &t.request)
+			connect.Attributes["network"] = network
+			connect.Attributes["addr"] = addr
+			t.connects[addr] = connect
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			if connect, ok := t.connects[addr]; ok {
+				t.record(connect, err)
+				delete(t.connects, addr)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.tls = instrumentation.NewSpan("tls handshake", &t.request)
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.record(t.tls, err)
+			if err != nil {
+				t.endRequest(err)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.request.Attributes["connectionReused"] = info.Reused
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			if info.Err != nil {
+				t.endRequest(info.Err)
+			}
+		},
+		GotFirstResponseByte: func() {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.endRequest(nil)
+		},
+	}
+
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+func (t *spanTrace) record(span instrumentation.Span, err error) {
+	span.End = time.Now()
+	span.Err = err
+	t.recorder.RecordSpan(span)
+}
+
+func (t *spanTrace) endRequest(err error) {
+	if t.ended {
+		return
+	}
+	t.ended = true
+	t.record(t.request, err)
+}
@@ -6,17 +6,56 @@ package http
 import (
 	"crypto/tls"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"net/http/httptrace"
 	"net/textproto"
+	"sync/atomic"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/instrumentation"
 	wlog "github.com/newrelic/infrastructure-agent/pkg/log"
 )
 
 var tlog = wlog.WithComponent("HttpTracer")
 
+// traceSampleRate requests traced per traced one. Rates of 1 or lower trace every request.
+var traceSampleRate atomic.Int64
+
+// SetTraceSampleRate makes WithTracer trace 1 in every rate requests, chosen at random, to keep some trace
+// visibility without flooding the logs. Rates of 1 or lower trace every request.
+func SetTraceSampleRate(rate int) {
+	traceSampleRate.Store(int64(rate))
+}
+
+// traceSampled decides whether a request is traced.
+func traceSampled() bool {
+	rate := traceSampleRate.Load()
+	return rate <= 1 || rand.Int63n(rate) == 0
+}
+
+// TracingEnabled returns whether the requests have to be traced: when the trace log level is enabled or the traces
+// are exported.
+func TracingEnabled() bool {
+	return wlog.IsLevelEnabled(logrus.TraceLevel) || instrumentation.SelfInstrumentationSpans.Enabled()
+}
+
+// WithTracer returns the request with a trace logging its connection events, unless it's not sampled. When the
+// traces are exported the request spans are recorded too, and its events are only logged in the trace log level.
 func WithTracer(req *http.Request, requester string) *http.Request {
+	if !traceSampled() {
+		return req
+	}
+
+	if recorder := instrumentation.SelfInstrumentationSpans; recorder.Enabled() {
+		req = withSpanTrace(req, requester, recorder)
+		if !wlog.IsLevelEnabled(logrus.TraceLevel) {
+			return req
+		}
+	}
+
 	l := tlog.WithField("requester", requester)
 	traceStart := time.Now()
 	var getConnStart, dnsStart, conStart, tlsStart time.Time
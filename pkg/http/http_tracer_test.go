@@ -4,6 +4,12 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/instrumentation"
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 	log2 "github.com/newrelic/infrastructure-agent/test/log"
 	"github.com/sirupsen/logrus"
@@ -11,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
 	"testing"
 )
 
@@ -49,3 +56,112 @@ func TestWithTracer(t *testing.T) {
 	}
 
 }
+
+func TestWithTracer_Sampling(t *testing.T) {
+	t.Cleanup(func() { SetTraceSampleRate(0) })
+
+	request, err := http.NewRequest("GET", "http://localhost", nil)
+	require.NoError(t, err)
+
+	traced := func(requests int) (count int) {
+		for i := 0; i < requests; i++ {
+			if httptrace.ContextClientTrace(WithTracer(request, "test").Context()) != nil {
+				count++
+			}
+		}
+		return count
+	}
+
+	for _, rate := range []int{-1, 0, 1} {
+		SetTraceSampleRate(rate)
+		assert.Equal(t, 100, traced(100), "rate %d", rate)
+	}
+
+	SetTraceSampleRate(4)
+	assert.InDelta(t, 0.25, float64(traced(10000))/10000, 0.05)
+
+	SetTraceSampleRate(100)
+	assert.InDelta(t, 0.01, float64(traced(10000))/10000, 0.005)
+}
+
+func TestWithTracer_ExportSpans(t *testing.T) {
+	exported := make(chan []byte, 10)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		exported <- body
+	}))
+	defer collector.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exporter := instrumentation.NewOTLPSpanExporter(collector.URL+"/v1/traces", collector.Client(), map[string]interface{}{"service.name": "test"})
+	go exporter.Run(ctx, 10*time.Millisecond)
+	recorder := instrumentation.SelfInstrumentationSpans
+	instrumentation.SelfInstrumentationSpans = exporter
+	t.Cleanup(func() { instrumentation.SelfInstrumentationSpans = recorder })
+	require.True(t, TracingEnabled())
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	request, err := http.NewRequest("POST", server.URL+"/metrics", nil)
+	require.NoError(t, err)
+	resp, err := server.Client().Do(WithTracer(request, "test"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	var body []byte
+	select {
+	case body = <-exported:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "spans not exported")
+	}
+
+	var traces struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+					Kind         int    `json:"kind"`
+					Attributes   []struct {
+						Key   string                 `json:"key"`
+						Value map[string]interface{} `json:"value"`
+					} `json:"attributes"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(body, &traces))
+	require.Len(t, traces.ResourceSpans, 1)
+	require.Len(t, traces.ResourceSpans[0].ScopeSpans, 1)
+	spans := traces.ResourceSpans[0].ScopeSpans[0].Spans
+
+	// child spans are recorded as they end, before the request one
+	names := map[string]int{}
+	for i, span := range spans {
+		names[span.Name] = i
+	}
+	require.Contains(t, names, "connect")
+	require.Contains(t, names, "tls handshake")
+	require.Contains(t, names, "HTTP POST")
+
+	requestSpan := spans[names["HTTP POST"]]
+	assert.Equal(t, 3, requestSpan.Kind)
+	assert.Empty(t, requestSpan.ParentSpanID)
+	attributes := map[string]interface{}{}
+	for _, attribute := range requestSpan.Attributes {
+		attributes[attribute.Key] = attribute.Value["stringValue"]
+	}
+	assert.Equal(t, "/metrics", attributes["url.path"])
+	assert.Equal(t, "test", attributes["requester"])
+
+	for _, name := range []string{"connect", "tls handshake"} {
+		assert.Equal(t, requestSpan.TraceID, spans[names[name]].TraceID)
+		assert.Equal(t, requestSpan.SpanID, spans[names[name]].ParentSpanID)
+	}
+}
@@ -99,10 +99,26 @@ func (pr *PluginRegistry) loadPluginInstance(dir string, dirOrFile os.FileInfo)
 	}
 
 	pflog.Debug("Found integration config file.")
-	instanceWrapper, err := loadPluginInstanceWrapper(dirOrFilePath)
+	instances, errs := pr.readPluginInstances(dirOrFilePath, pflog)
+	for _, err := range errs {
+		pflog.WithError(err).Error("Ignoring integration config.")
+	}
+	pr.pluginInstances = append(pr.pluginInstances, instances...)
+}
+
+// ValidatePluginInstanceFile loads a v3 integration config file, as the registry does, without registering its
+// instances. It returns the problems that make the file, or any of its instances, to be ignored.
+func (pr *PluginRegistry) ValidatePluginInstanceFile(path string) []error {
+	_, errs := pr.readPluginInstances(path, plog.WithField("configFile", path))
+	return errs
+}
+
+// readPluginInstances returns the instances of a v3 integration config file, along with the problems that make the
+// file, or any of its instances, to be ignored.
+func (pr *PluginRegistry) readPluginInstances(path string, pflog log.Entry) (instances []*PluginV1Instance, errs []error) {
+	instanceWrapper, err := loadPluginInstanceWrapper(path)
 	if err != nil {
-		pflog.WithError(err).Error("cannot load integration config file")
-		return
+		return nil, []error{fmt.Errorf("cannot load integration config file: %w", err)}
 	}
 
 	pilog := pflog.WithField("integration", instanceWrapper.IntegrationName)
@@ -110,47 +126,32 @@ func (pr *PluginRegistry) loadPluginInstance(dir string, dirOrFile os.FileInfo)
 	// ignore V4 plugins
 	if instanceWrapper.IntegrationName == "" && len(instanceWrapper.Instances) == 0 {
 		pilog.Debug("Ignoring v4 integration. To be loaded later.")
-		return
+		return nil, nil
 	}
 
 	plugin, err := pr.GetPlugin(instanceWrapper.IntegrationName)
 	if err != nil {
-		pilog.WithError(err).Error("Couldn't load integration instances from config file")
-		return
+		return nil, []error{fmt.Errorf("couldn't load integration instances from config file: %w", err)}
 	}
 
-	//finfo, err := os.Stat(dirOrFilePath)
-
-	// generate migration command only for files
-	//if err == nil && !finfo.IsDir() {
-	//	pathToDefinition := plugin.SourceFilePath
-	//	pathToConfigV3 := dirOrFilePath
-	//	defaultPluginInstanceDir := filepath.Join("/etc", "newrelic-infra", "integrations.d")
-	//	pathToConfigV4 := filepath.Join(defaultPluginInstanceDir, plugin.Name+"-v4.yml")
-	//	//migrationCommand := generateMigrationCommand(pathToConfigV3, pathToDefinition, pathToConfigV4, false)
-	//	//pilog.Warn(fmt.Sprintf("Integration v3 config detected. Recommended to migrate this config to v4 format. Command to migrate '%s'", migrationCommand))
-	//}
-
 	// If data binding is enabled, builds the data sources to apply them later
 	if instanceWrapper.DataBind.Enabled() {
 		pilog.Debug("Instantiating Databind sources.")
-		var err error
 		plugin.discovery, err = instanceWrapper.DataBind.DataSources()
 		if err != nil {
-			pilog.WithError(err).Error("variables/discovery data binding problem. Ignoring this plugin")
-			return
+			return nil, []error{fmt.Errorf("variables/discovery data binding problem, ignoring this plugin: %w", err)}
 		}
 	}
 
 	for _, instance := range instanceWrapper.Instances {
 		if _, ok := plugin.Commands[instance.Command]; !ok {
-			pilog.WithField("command", instance.Command).
-				Error("Integration instance command not found in definition")
+			errs = append(errs, fmt.Errorf("integration instance command %q not found in definition", instance.Command))
 			continue
 		}
 		instance.plugin = plugin
-		pr.pluginInstances = append(pr.pluginInstances, instance)
+		instances = append(instances, instance)
 	}
+	return instances, errs
 }
 
 func loadPluginInstanceWrapper(pluginInstanceFilePath string) (pluginInstanceWrapper *PluginInstanceWrapper, err error) {
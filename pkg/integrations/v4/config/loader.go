@@ -6,6 +6,7 @@ package config
 import (
 	"errors"
 	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/files"
+	agentConfig "github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/config/envvar"
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 	"gopkg.in/yaml.v2"
@@ -106,7 +107,17 @@ func (pl *pathLoader) LoadFile(path string) (YAML, error) {
 		return cy, err
 	}
 
-	bytes, err = envvar.ExpandInContent(bytes)
+	cy, err = parseFile(bytes)
+	if err != nil && err != LegacyYAML {
+		agentConfig.ParseErrors.Inc(path)
+	}
+	return cy, err
+}
+
+// parseFile parses the contents of an integrations configuration file, expanding its environment variables.
+func parseFile(bytes []byte) (YAML, error) {
+	cy := YAML{}
+	bytes, err := envvar.ExpandInContent(bytes)
 	if err != nil {
 		return cy, err
 	}
@@ -0,0 +1,40 @@
+// Copyright 2022 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentConfig "github.com/newrelic/infrastructure-agent/pkg/config"
+)
+
+func TestPathLoader_Load_CountsParseErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"valid.yml":     "integrations:\n  - name: nri-foo\n",
+		"malformed.yml": "integrations:\n  - name: nri-foo\n   interval: 15s\n",
+		"empty.yml":     "integrations:\n",
+		"legacy.yml":    "instances:\n  - name: foo\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	agentConfig.ParseErrors.FlushInterval()
+
+	configs, err := NewPathLoader().Load(dir)
+	require.NoError(t, err)
+	assert.Len(t, configs, 1)
+	assert.Contains(t, configs, filepath.Join(dir, "valid.yml"))
+
+	// v3 integrations configs are not errors
+	assert.Equal(t, map[string]uint64{
+		filepath.Join(dir, "malformed.yml"): 1,
+		filepath.Join(dir, "empty.yml"):     1,
+	}, agentConfig.ParseErrors.FlushInterval())
+}
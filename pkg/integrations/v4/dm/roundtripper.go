@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/newrelic/infrastructure-agent/internal/agent/id"
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
 )
 
 const (
@@ -38,5 +39,9 @@ func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.Header.Del(apiKeyHeaderToRemove)
 	req.Header.Add(licenseKeyHeader, t.licenseKey)
 	req.Header.Add(agentEntityHeader, t.idProvide().ID.String())
-	return t.rt.RoundTrip(req)
+	resp, err := t.rt.RoundTrip(req)
+	// the harvester submits to the metric API URL, with no query
+	endpoint := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	backendhttp.SubmissionStatuses.Record(endpoint, resp, err)
+	return resp, err
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/newrelic/infrastructure-agent/internal/agent/id"
 
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
 	telemetry "github.com/newrelic/infrastructure-agent/pkg/backend/telemetryapi"
 	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/dm/cumulative"
 	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/dm/rate"
@@ -44,6 +45,9 @@ func NewConfig(url string, fedramp bool, licenseKey string, submissionPeriod tim
 
 // NewDMSender creates a Dimensional Metrics sender.
 func NewDMSender(config MetricsSenderConfig, transport http.RoundTripper, idProvide id.Provide) (s MetricsSender, err error) {
+	if config.MetricApiURL != "" {
+		backendhttp.SubmissionStatuses.Register(config.MetricApiURL)
+	}
 	s = &sender{
 		harvester: NewLazyLoadedHarvester(config, transport, idProvide),
 		calculator: Calculator{
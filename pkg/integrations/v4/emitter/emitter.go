@@ -13,6 +13,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/newrelic/infrastructure-agent/internal/feature_flags"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/databind/pkg/data"
 	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/protocol"
 
@@ -77,9 +78,11 @@ func (e *VersionAwareEmitter) Emit(definition integration.Definition, extraLabel
 	// Agent creating the Host entity (and decorating it correctly in the backend) in secure forward with Custom Attributes: pkg/plugins/plugins_linux.go:46
 	// But in forward only there is no host entity and custom attributes are not being decorated.
 	// Here then we add CustomAttributes to extraLabels in case we are in that mode.
+	maxValueBytes := e.aCtx.Config().CustomAttributesMaxValueBytes
 	if e.aCtx.Config().IsForwardOnly {
 		extraLabelsCopy := make(map[string]string)
-		customAttributes := e.aCtx.Config().CustomAttributes.DataMap()
+		reloadedAttributes := agent.CustomAttributes(e.aCtx)
+		customAttributes := reloadedAttributes.DataMap(maxValueBytes)
 
 		for k, v := range extraLabels {
 			extraLabelsCopy[k] = v
@@ -90,6 +93,7 @@ func (e *VersionAwareEmitter) Emit(definition integration.Definition, extraLabel
 
 		extraLabels = extraLabelsCopy
 	}
+	extraLabels = truncateLabels(extraLabels, maxValueBytes)
 
 	// dimensional metrics
 	if protocolVersion == protocol.V4 {
@@ -112,6 +116,18 @@ func (e *VersionAwareEmitter) Emit(definition integration.Definition, extraLabel
 	return e.emitV3(fwrequest.NewFwRequestLegacy(definition, extraLabels, entityRewrite, pluginDataV3), protocolVersion)
 }
 
+// truncateLabels returns a copy of the labels with their values truncated to maxValueBytes, unless it's 0.
+func truncateLabels(labels data.Map, maxValueBytes int) data.Map {
+	if maxValueBytes <= 0 {
+		return labels
+	}
+	truncated := make(data.Map, len(labels))
+	for k, v := range labels {
+		truncated[k] = config.TruncateAttributeValue(k, v, maxValueBytes)
+	}
+	return truncated
+}
+
 func (e *VersionAwareEmitter) emitV3(dto fwrequest.FwRequestLegacy, protocolVersion int) error {
 	plugin := agent.NewExternalPluginCommon(dto.Definition.PluginID(dto.Data.Name), e.aCtx, dto.Definition.Name)
 	labels, extraAnnotations := dto.LabelsAndExtraAnnotations()
@@ -811,7 +811,7 @@ func TestEmit_SendCustomAttributes_SendCAInSecureForwardMode(t *testing.T) {
 	for k, v := range extraLabels {
 		expectedLabels[k] = v
 	}
-	for k, v := range customAttributes.DataMap() {
+	for k, v := range customAttributes.DataMap(0) {
 		expectedLabels[k] = v
 	}
 
@@ -906,3 +906,17 @@ type mockedMetricsSender struct {
 func (m *mockedMetricsSender) SendMetrics(metrics []protocol.Metric) {
 	m.Called(metrics)
 }
+
+func Test_truncateLabels(t *testing.T) {
+	labels := data.Map{
+		"label.env":  "production",
+		"label.blob": strings.Repeat("x", 100),
+	}
+
+	assert.Equal(t, labels, truncateLabels(labels, 0))
+
+	truncated := truncateLabels(labels, 20)
+	assert.Equal(t, "production", truncated["label.env"])
+	assert.Equal(t, "xxxxxx"+config.TruncatedValueMarker, truncated["label.blob"])
+	assert.Len(t, labels["label.blob"], 100, "the original labels are not modified")
+}
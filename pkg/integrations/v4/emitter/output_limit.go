@@ -0,0 +1,194 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package emitter
+
+import (
+	"bytes"
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/integration"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/databind/pkg/data"
+)
+
+const (
+	// maxAttributeLength length string attribute values are first truncated to, as the NRDB limit.
+	maxAttributeLength = 4095
+	// minAttributeLength length string attribute values aren't truncated under.
+	minAttributeLength = 64
+)
+
+// OutputLimit max size of the payloads of an integration and how the oversized ones are handled.
+type OutputLimit struct {
+	MaxPayloadBytes  int
+	TruncationPolicy string
+}
+
+// OutputLimits builds the output limits by integration name from the agent config.
+func OutputLimits(cfg *config.Config) map[string]OutputLimit {
+	limits := make(map[string]OutputLimit, len(cfg.IntegrationsMaxPayloadBytes))
+	for name, maxBytes := range cfg.IntegrationsMaxPayloadBytes {
+		policy := cfg.IntegrationsTruncationPolicy[name]
+		if policy == "" {
+			policy = config.TruncationPolicyDropLine
+		}
+		limits[name] = OutputLimit{MaxPayloadBytes: maxBytes, TruncationPolicy: policy}
+	}
+	return limits
+}
+
+// outputLimitEmitter applies the output limits of the integrations to their payloads before forwarding them.
+type outputLimitEmitter struct {
+	next   Emitter
+	limits map[string]OutputLimit
+}
+
+// NewOutputLimitEmitter wraps an emitter to apply the output limits by integration name. The payloads of integrations
+// without limit are forwarded untouched. It returns the emitter itself when there are no limits.
+func NewOutputLimitEmitter(next Emitter, limits map[string]OutputLimit) Emitter {
+	if len(limits) == 0 {
+		return next
+	}
+	return &outputLimitEmitter{next: next, limits: limits}
+}
+
+func (e *outputLimitEmitter) Emit(definition integration.Definition, extraLabels data.Map, entityRewrite []data.EntityRewrite, integrationJSON []byte) error {
+	limit, ok := e.limits[definition.Name]
+	if !ok || len(integrationJSON) <= limit.MaxPayloadBytes {
+		return e.next.Emit(definition, extraLabels, entityRewrite, integrationJSON)
+	}
+
+	logger := elog.
+		WithField("integration_name", definition.Name).
+		WithField("payload_bytes", len(integrationJSON)).
+		WithField("max_payload_bytes", limit.MaxPayloadBytes).
+		WithField("truncation_policy", limit.TruncationPolicy)
+
+	limited, err := applyOutputLimit(integrationJSON, limit)
+	if err != nil {
+		logger.WithError(err).Warn("Dropping oversized integration payload, cannot parse it.")
+		return nil
+	}
+	if limited == nil {
+		logger.Warn("Dropping oversized integration payload.")
+		return nil
+	}
+
+	logger.WithField("limited_payload_bytes", len(limited)).Debug("Oversized integration payload limited.")
+	return e.next.Emit(definition, extraLabels, entityRewrite, limited)
+}
+
+// applyOutputLimit returns the payload fitting the limit after applying its truncation policy, or nil when it doesn't
+// fit, so it's dropped.
+func applyOutputLimit(payload []byte, limit OutputLimit) ([]byte, error) {
+	if limit.TruncationPolicy != config.TruncationPolicyDropOldestDatasets &&
+		limit.TruncationPolicy != config.TruncationPolicyTruncateAttributes {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	// numbers are kept as they are
+	decoder.UseNumber()
+	var p map[string]interface{}
+	if err := decoder.Decode(&p); err != nil {
+		return nil, err
+	}
+
+	if limit.TruncationPolicy == config.TruncationPolicyDropOldestDatasets {
+		return dropOldestDatasets(p, limit.MaxPayloadBytes)
+	}
+	return truncateAttributes(p, limit.MaxPayloadBytes)
+}
+
+// dropOldestDatasets drops the first datasets of the payload until it fits.
+func dropOldestDatasets(p map[string]interface{}, maxBytes int) ([]byte, error) {
+	datasets, ok := p["data"].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	for len(datasets) > 1 {
+		datasets = datasets[1:]
+		p["data"] = datasets
+		b, err := marshalPayload(p)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) <= maxBytes {
+			return b, nil
+		}
+	}
+	return nil, nil
+}
+
+// truncateAttributes shortens the string attribute values of the payload, halving their max length until it fits.
+// The payload header, the entities and the names and types identifying the samples and metrics are kept.
+func truncateAttributes(p map[string]interface{}, maxBytes int) ([]byte, error) {
+	for length := maxAttributeLength; length >= minAttributeLength; length /= 2 {
+		for key, value := range p {
+			if !isPayloadHeader(key) {
+				p[key] = truncateStrings(value, length)
+			}
+		}
+		b, err := marshalPayload(p)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) <= maxBytes {
+			return b, nil
+		}
+	}
+	return nil, nil
+}
+
+func isPayloadHeader(key string) bool {
+	switch key {
+	case "name", "protocol_version", "integration_version", "integration":
+		return true
+	}
+	return false
+}
+
+// truncateStrings truncates the string values of a decoded JSON value to length bytes.
+func truncateStrings(value interface{}, length int) interface{} {
+	switch v := value.(type) {
+	case string:
+		return truncateString(v, length)
+	case []interface{}:
+		for i := range v {
+			v[i] = truncateStrings(v[i], length)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			switch key {
+			case "entity", "event_type", "eventType", "name", "type":
+				continue
+			}
+			v[key] = truncateStrings(item, length)
+		}
+	}
+	return value
+}
+
+// truncateString cuts s to length bytes at most, without splitting a UTF-8 character.
+func truncateString(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	for length > 0 && !utf8.RuneStart(s[length]) {
+		length--
+	}
+	return s[:length]
+}
+
+func marshalPayload(p map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(p); err != nil {
+		return nil, err
+	}
+	// Encode ends the payload with a new line
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
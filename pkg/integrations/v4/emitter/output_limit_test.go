@@ -0,0 +1,180 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package emitter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/integration"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/databind/pkg/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// payloadsEmitter records the emitted payloads.
+type payloadsEmitter struct {
+	payloads [][]byte
+}
+
+func (p *payloadsEmitter) Emit(_ integration.Definition, _ data.Map, _ []data.EntityRewrite, integrationJSON []byte) error {
+	p.payloads = append(p.payloads, integrationJSON)
+	return nil
+}
+
+// oversizedPayload returns a protocol v3 payload with a dataset per entity, with a long string attribute.
+func oversizedPayload(t *testing.T, entities ...string) []byte {
+	t.Helper()
+
+	var datasets []map[string]interface{}
+	for _, name := range entities {
+		datasets = append(datasets, map[string]interface{}{
+			"entity": map[string]interface{}{"name": name, "type": "redis"},
+			"metrics": []map[string]interface{}{{
+				"event_type":   "RedisSample",
+				"query.count":  42,
+				"last.command": strings.Repeat("x", 2000),
+			}},
+		})
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"name":                "com.newrelic.redis",
+		"protocol_version":    "3",
+		"integration_version": "1.0.0",
+		"data":                datasets,
+	})
+	require.NoError(t, err)
+	return payload
+}
+
+func emittedEntities(t *testing.T, payload []byte) []string {
+	t.Helper()
+
+	var p struct {
+		Data []struct {
+			Entity struct {
+				Name string `json:"name"`
+			} `json:"entity"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &p))
+	var names []string
+	for _, ds := range p.Data {
+		names = append(names, ds.Entity.Name)
+	}
+	return names
+}
+
+func TestOutputLimitEmitter_NoLimits(t *testing.T) {
+	next := &payloadsEmitter{}
+	assert.Equal(t, next, NewOutputLimitEmitter(next, OutputLimits(&config.Config{})))
+}
+
+func TestOutputLimitEmitter_ForwardsPayloadsWithinLimits(t *testing.T) {
+	payload := oversizedPayload(t, "redis-1", "redis-2", "redis-3")
+	next := &payloadsEmitter{}
+	e := NewOutputLimitEmitter(next, map[string]OutputLimit{
+		"nri-redis": {MaxPayloadBytes: len(payload), TruncationPolicy: config.TruncationPolicyDropLine},
+	})
+
+	// within the limit of the integration
+	require.NoError(t, e.Emit(integration.Definition{Name: "nri-redis"}, nil, nil, payload))
+	// other integrations aren't limited
+	require.NoError(t, e.Emit(integration.Definition{Name: "nri-mysql"}, nil, nil, append(payload, ' ')))
+
+	assert.Equal(t, [][]byte{payload, append(payload, ' ')}, next.payloads)
+}
+
+func TestOutputLimitEmitter_DropLine(t *testing.T) {
+	payload := oversizedPayload(t, "redis-1")
+	next := &payloadsEmitter{}
+	e := NewOutputLimitEmitter(next, map[string]OutputLimit{
+		"nri-redis": {MaxPayloadBytes: len(payload) - 1, TruncationPolicy: config.TruncationPolicyDropLine},
+	})
+
+	require.NoError(t, e.Emit(integration.Definition{Name: "nri-redis"}, nil, nil, payload))
+
+	assert.Empty(t, next.payloads)
+}
+
+func TestOutputLimitEmitter_DropOldestDatasets(t *testing.T) {
+	payload := oversizedPayload(t, "redis-1", "redis-2", "redis-3")
+	next := &payloadsEmitter{}
+	e := NewOutputLimitEmitter(next, map[string]OutputLimit{
+		// room for two datasets
+		"nri-redis": {MaxPayloadBytes: len(payload) - 1000, TruncationPolicy: config.TruncationPolicyDropOldestDatasets},
+	})
+
+	require.NoError(t, e.Emit(integration.Definition{Name: "nri-redis"}, nil, nil, payload))
+
+	require.Len(t, next.payloads, 1)
+	assert.LessOrEqual(t, len(next.payloads[0]), len(payload)-1000)
+	assert.Equal(t, []string{"redis-2", "redis-3"}, emittedEntities(t, next.payloads[0]))
+
+	// dropped when a single dataset doesn't fit
+	next.payloads = nil
+	e = NewOutputLimitEmitter(next, map[string]OutputLimit{
+		"nri-redis": {MaxPayloadBytes: 1000, TruncationPolicy: config.TruncationPolicyDropOldestDatasets},
+	})
+	require.NoError(t, e.Emit(integration.Definition{Name: "nri-redis"}, nil, nil, payload))
+	assert.Empty(t, next.payloads)
+}
+
+func TestOutputLimitEmitter_TruncateAttributes(t *testing.T) {
+	payload := oversizedPayload(t, "redis-1", "redis-2")
+	next := &payloadsEmitter{}
+	e := NewOutputLimitEmitter(next, map[string]OutputLimit{
+		"nri-redis": {MaxPayloadBytes: 2000, TruncationPolicy: config.TruncationPolicyTruncateAttributes},
+	})
+
+	require.NoError(t, e.Emit(integration.Definition{Name: "nri-redis"}, nil, nil, payload))
+
+	require.Len(t, next.payloads, 1)
+	assert.LessOrEqual(t, len(next.payloads[0]), 2000)
+	var p struct {
+		Name string `json:"name"`
+		Data []struct {
+			Entity  map[string]string        `json:"entity"`
+			Metrics []map[string]interface{} `json:"metrics"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(next.payloads[0], &p))
+	assert.Equal(t, "com.newrelic.redis", p.Name)
+	require.Len(t, p.Data, 2)
+	for _, ds := range p.Data {
+		assert.Equal(t, "redis", ds.Entity["type"])
+		assert.Equal(t, "RedisSample", ds.Metrics[0]["event_type"])
+		assert.Equal(t, float64(42), ds.Metrics[0]["query.count"])
+		assert.Less(t, len(ds.Metrics[0]["last.command"].(string)), 2000)
+	}
+
+	// dropped when it doesn't fit with the attributes at their min length
+	next.payloads = nil
+	e = NewOutputLimitEmitter(next, map[string]OutputLimit{
+		"nri-redis": {MaxPayloadBytes: 100, TruncationPolicy: config.TruncationPolicyTruncateAttributes},
+	})
+	require.NoError(t, e.Emit(integration.Definition{Name: "nri-redis"}, nil, nil, payload))
+	assert.Empty(t, next.payloads)
+}
+
+func TestTruncateString(t *testing.T) {
+	assert.Equal(t, "abc", truncateString("abc", 5))
+	assert.Equal(t, "ab", truncateString("abcdef", 2))
+	// multi-byte characters aren't split
+	assert.Equal(t, "a", truncateString("añb", 2))
+}
+
+func TestOutputLimits(t *testing.T) {
+	cfg := &config.Config{
+		IntegrationsMaxPayloadBytes:  map[string]int{"nri-redis": 1024, "nri-mysql": 2048},
+		IntegrationsTruncationPolicy: map[string]string{"nri-redis": config.TruncationPolicyTruncateAttributes, "nri-nginx": config.TruncationPolicyDropOldestDatasets},
+	}
+
+	assert.Equal(t, map[string]OutputLimit{
+		"nri-redis": {MaxPayloadBytes: 1024, TruncationPolicy: config.TruncationPolicyTruncateAttributes},
+		// default policy
+		"nri-mysql": {MaxPayloadBytes: 2048, TruncationPolicy: config.TruncationPolicyDropLine},
+	}, OutputLimits(cfg))
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package fs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// EventCoalescer merges the file events received for the same name until no new one arrives within the window, so a
+// burst of changes, as a file saved in several writes or many files deployed at once, causes a single reload of each
+// name instead of one per event.
+type EventCoalescer struct {
+	window  time.Duration
+	lock    sync.Mutex
+	pending map[string]fsnotify.Op
+	timers  map[string]*time.Timer
+	events  chan fsnotify.Event
+	done    chan struct{}
+}
+
+// NewEventCoalescer creates an EventCoalescer delivering the merged events once their name has been quiet for window.
+func NewEventCoalescer(window time.Duration) *EventCoalescer {
+	return &EventCoalescer{
+		window:  window,
+		pending: map[string]fsnotify.Op{},
+		timers:  map[string]*time.Timer{},
+		events:  make(chan fsnotify.Event),
+		done:    make(chan struct{}),
+	}
+}
+
+// Add merges the event with the pending ones of its name, restarting the window of the name. It never blocks, so it
+// can be called from the goroutine reading Events.
+func (c *EventCoalescer) Add(event fsnotify.Event) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pending[event.Name] |= event.Op
+	// a timer that already fired is delivering the previous events, so the new ones need a new timer
+	if timer, ok := c.timers[event.Name]; ok && timer.Stop() {
+		timer.Reset(c.window)
+		return
+	}
+	name := event.Name
+	var timer *time.Timer
+	timer = time.AfterFunc(c.window, func() {
+		c.deliver(name, &timer)
+	})
+	c.timers[name] = timer
+}
+
+// Events returns the channel receiving the merged event of each name once its window elapses.
+func (c *EventCoalescer) Events() <-chan fsnotify.Event {
+	return c.events
+}
+
+// Stop discards the pending events. Events must not be read after stopping.
+func (c *EventCoalescer) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, timer := range c.timers {
+		timer.Stop()
+	}
+	c.timers = map[string]*time.Timer{}
+	c.pending = map[string]fsnotify.Op{}
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+}
+
+// deliver sends the merged event of the name, unless the timer was replaced by a newer one. The timer is read holding
+// the lock, as it's set after being created.
+func (c *EventCoalescer) deliver(name string, timer **time.Timer) {
+	c.lock.Lock()
+	if c.timers[name] != *timer {
+		c.lock.Unlock()
+		return
+	}
+	op := c.pending[name]
+	delete(c.pending, name)
+	delete(c.timers, name)
+	c.lock.Unlock()
+
+	select {
+	case c.events <- fsnotify.Event{Name: name, Op: op}:
+	case <-c.done:
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package fs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCoalesceWindow = 100 * time.Millisecond
+
+func TestEventCoalescer_MergesRapidEvents(t *testing.T) {
+	c := NewEventCoalescer(testCoalesceWindow)
+	defer c.Stop()
+
+	// WHEN many events of the same file are received within the window
+	c.Add(fsnotify.Event{Name: "config.yml", Op: fsnotify.Create})
+	for i := 0; i < 100; i++ {
+		c.Add(fsnotify.Event{Name: "config.yml", Op: fsnotify.Write})
+	}
+
+	// THEN a single event is delivered, merging all the operations
+	event := requireEvent(t, c)
+	assert.Equal(t, fsnotify.Event{Name: "config.yml", Op: fsnotify.Create | fsnotify.Write}, event)
+	requireNoEvent(t, c)
+}
+
+func TestEventCoalescer_DeliversEachName(t *testing.T) {
+	c := NewEventCoalescer(testCoalesceWindow)
+	defer c.Stop()
+
+	// WHEN events of different files are received within the window
+	for i := 0; i < 10; i++ {
+		c.Add(fsnotify.Event{Name: "a.yml", Op: fsnotify.Write})
+		c.Add(fsnotify.Event{Name: "b.yml", Op: fsnotify.Remove})
+	}
+
+	// THEN a single event is delivered for each file
+	events := map[string]fsnotify.Op{}
+	for i := 0; i < 2; i++ {
+		event := requireEvent(t, c)
+		events[event.Name] = event.Op
+	}
+	assert.Equal(t, map[string]fsnotify.Op{"a.yml": fsnotify.Write, "b.yml": fsnotify.Remove}, events)
+	requireNoEvent(t, c)
+}
+
+func TestEventCoalescer_DeliversOncePerWindow(t *testing.T) {
+	c := NewEventCoalescer(testCoalesceWindow)
+	defer c.Stop()
+
+	// WHEN the events of a file are received in two bursts separated by more than the window
+	c.Add(fsnotify.Event{Name: "config.yml", Op: fsnotify.Write})
+	c.Add(fsnotify.Event{Name: "config.yml", Op: fsnotify.Write})
+	first := requireEvent(t, c)
+	c.Add(fsnotify.Event{Name: "config.yml", Op: fsnotify.Remove})
+	c.Add(fsnotify.Event{Name: "config.yml", Op: fsnotify.Remove})
+
+	// THEN an event is delivered for each burst
+	assert.Equal(t, fsnotify.Write, first.Op)
+	assert.Equal(t, fsnotify.Remove, requireEvent(t, c).Op)
+	requireNoEvent(t, c)
+}
+
+func TestEventCoalescer_StopDiscardsPendingEvents(t *testing.T) {
+	c := NewEventCoalescer(testCoalesceWindow)
+
+	// WHEN the coalescer is stopped with pending events
+	c.Add(fsnotify.Event{Name: "config.yml", Op: fsnotify.Write})
+	c.Stop()
+
+	// THEN they aren't delivered
+	requireNoEvent(t, c)
+}
+
+func requireEvent(t *testing.T, c *EventCoalescer) fsnotify.Event {
+	t.Helper()
+	select {
+	case event := <-c.Events():
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout waiting for a coalesced event")
+	}
+	return fsnotify.Event{}
+}
+
+func requireNoEvent(t *testing.T, c *EventCoalescer) {
+	t.Helper()
+	select {
+	case event := <-c.Events():
+		assert.Failf(t, "unexpected coalesced event", "%v", event)
+	case <-time.After(3 * testCoalesceWindow):
+	}
+}
@@ -29,6 +29,8 @@ const (
 	memBufferLimit          = 16384
 	fbFileWatchLimit        = 1024
 	fluentBitDbName         = "fb.db"
+	throttleWindow          = 5
+	throttleInterval        = "1s"
 )
 
 // FluentBit INPUT plugin types
@@ -47,6 +49,8 @@ const (
 	fbFilterTypeRecordModifier = "record_modifier"
 	fbFilterTypeLua            = "lua"
 	fbFilterTypeModify         = "modify"
+	fbFilterTypeMultiline      = "multiline"
+	fbFilterTypeThrottle       = "throttle"
 )
 
 // Lua Script calling function
@@ -72,6 +76,19 @@ const (
 	rAttHostname   = "hostname"
 )
 
+// fbBuiltinParsers are the parsers defined in the parsers.conf file shipped with FluentBit.
+var fbBuiltinParsers = []string{
+	"apache", "apache2", "apache_error", "nginx", "k8s-nginx-ingress", "json", "logfmt", "docker", "docker-daemon",
+	"syslog-rfc5424", "syslog-rfc3164-local", "syslog-rfc3164", "mongodb", "envoy", "istio-envoy-proxy", "cri",
+	"kube-custom", "kmsg-netfilter-log", "crio",
+}
+
+// parserNameRegex matches the name of a parser definition within a FluentBit parsers file.
+var parserNameRegex = regexp.MustCompile(`(?i)^\s*Name\s+(\S+)`)
+
+// invalidParserNameChars matches the characters of the log source names not allowed in the generated parser names.
+var invalidParserNameChars = regexp.MustCompile(`[^\w.-]`)
+
 const (
 	fbGrepFieldForTail     = "log"
 	fbGrepFieldForSystemd  = "MESSAGE"
@@ -89,19 +106,22 @@ type YAML struct {
 
 // LogCfg logging integration config from customer defined YAML.
 type LogCfg struct {
-	Name            string            `yaml:"name"`
-	File            string            `yaml:"file"`        // ...
-	MaxLineKb       int               `yaml:"max_line_kb"` // Setup the max value of the buffer while reading lines.
-	Systemd         string            `yaml:"systemd"`     // ...
-	Pattern         string            `yaml:"pattern"`
-	Attributes      map[string]string `yaml:"attributes"`
-	Syslog          *LogSyslogCfg     `yaml:"syslog"`
-	Tcp             *LogTcpCfg        `yaml:"tcp"`
-	Fluentbit       *LogExternalFBCfg `yaml:"fluentbit"`
-	Winlog          *LogWinlogCfg     `yaml:"winlog"`
-	Winevtlog       *LogWinevtlogCfg  `yaml:"winevtlog"`
-	MultilineParser string            `yaml:"multilineParser"`
-	targetFilesCnt  int
+	Name                string            `yaml:"name"`
+	File                string            `yaml:"file"`        // ...
+	MaxLineKb           int               `yaml:"max_line_kb"` // Setup the max value of the buffer while reading lines.
+	Systemd             string            `yaml:"systemd"`     // ...
+	Pattern             string            `yaml:"pattern"`
+	Attributes          map[string]string `yaml:"attributes"`
+	Syslog              *LogSyslogCfg     `yaml:"syslog"`
+	Tcp                 *LogTcpCfg        `yaml:"tcp"`
+	Fluentbit           *LogExternalFBCfg `yaml:"fluentbit"`
+	Winlog              *LogWinlogCfg     `yaml:"winlog"`
+	Winevtlog           *LogWinevtlogCfg  `yaml:"winevtlog"`
+	MultilineParser     string            `yaml:"multilineParser"`
+	Multiline           *LogMultilineCfg  `yaml:"multiline"`              // Custom multiline parser, for file (tail) and tcp inputs.
+	Parser              string            `yaml:"parser"`                 // Parser applied to the records of a file (tail) input.
+	MaxRecordsPerSecond int               `yaml:"max_records_per_second"` // Best-effort rate limit, averaged by FluentBit over a sliding window.
+	targetFilesCnt      int
 }
 
 // LogSyslogCfg logging integration config from customer defined YAML, specific for the Syslog input plugin
@@ -113,6 +133,7 @@ type LogSyslogCfg struct {
 
 type LogWinlogCfg struct {
 	Channel         string   `yaml:"channel"`
+	Channels        []string `yaml:"channels"` // e.g. Application, System, Security. Merged with Channel.
 	CollectEventIds []string `yaml:"collect-eventids"`
 	ExcludeEventIds []string `yaml:"exclude-eventids"`
 	UseANSI         string   `yaml:"use-ansi"`
@@ -120,11 +141,36 @@ type LogWinlogCfg struct {
 
 type LogWinevtlogCfg struct {
 	Channel         string   `yaml:"channel"`
+	Channels        []string `yaml:"channels"` // e.g. Application, System, Security. Merged with Channel.
 	CollectEventIds []string `yaml:"collect-eventids"`
 	ExcludeEventIds []string `yaml:"exclude-eventids"`
 	UseANSI         string   `yaml:"use-ansi"`
 }
 
+// LogMultilineCfg custom multiline parser joining the lines following the one matching the start state regex.
+type LogMultilineCfg struct {
+	StartState     string `yaml:"start_state"`      // Regex matching the first line of a record.
+	Continuation   string `yaml:"continuation"`     // Regex matching the following lines, defaults to the ones not matching StartState.
+	FlushTimeoutMs int    `yaml:"flush_timeout_ms"` // Time to wait for continuation lines.
+}
+
+// Validate returns an error if the start state regex is missing or the regexes cannot be written to the parsers
+// file. The regexes syntax is not checked, as FluentBit compiles them with Onigmo, supporting lookarounds.
+func (m *LogMultilineCfg) Validate() error {
+	if m.StartState == "" {
+		return fmt.Errorf("multiline: start_state regex is required")
+	}
+	for _, expr := range []string{m.StartState, m.Continuation} {
+		if strings.Contains(expr, `"`) {
+			return fmt.Errorf("multiline: double quotes are not supported in regex %s", expr)
+		}
+	}
+	if m.FlushTimeoutMs < 0 {
+		return fmt.Errorf("multiline: invalid flush_timeout_ms %d", m.FlushTimeoutMs)
+	}
+	return nil
+}
+
 type LogTcpCfg struct {
 	Uri       string `yaml:"uri"`
 	Format    string `yaml:"format"`
@@ -143,10 +189,12 @@ func (l *LogCfg) IsValid() bool {
 
 // FBCfg FluentBit automatically generated configuration.
 type FBCfg struct {
-	Inputs      []FBCfgInput
-	Filters     []FBCfgFilter
-	ExternalCfg FBCfgExternal
-	Output      FBCfgOutput
+	ParsersFile      string
+	Inputs           []FBCfgInput
+	Filters          []FBCfgFilter
+	ExternalCfg      FBCfgExternal
+	Output           FBCfgOutput
+	MultilineParsers []FBCfgMultilineParser
 }
 
 // Format will return the FBCfg in the fluent bit config file format.
@@ -189,6 +237,7 @@ type FBCfgInput struct {
 	MemBufferLimit        string // plugin: tail
 	PathKey               string // plugin: tail
 	MultilineParser       string // plugin: tail
+	Parser                string // plugin: tail
 	SkipLongLines         string // always on
 	Systemd_Filter        string // plugin: systemd
 	Channels              string // plugin: winlog
@@ -221,6 +270,28 @@ type FBCfgFilter struct {
 	Script    string            // plugin:lua-Script
 	Call      string            // plugin:lua-Script
 	Modifiers map[string]string //plugin: modify filter
+
+	MultilineKeyContent string // plugin: multiline
+	MultilineParser     string // plugin: multiline
+
+	Rate     int    // plugin: throttle
+	Window   int    // plugin: throttle
+	Interval string // plugin: throttle
+}
+
+// FBCfgMultilineParser FluentBit MULTILINE_PARSER block, stored in a generated parsers file.
+//
+//	[MULTILINE_PARSER]
+//	  name          multiline_java-app
+//	  type          regex
+//	  flush_timeout 1000
+//	  rule          "start_state" "/^\d{4}-/" "cont"
+//	  rule          "cont" "/^(?!^\d{4}-)/" "cont"
+type FBCfgMultilineParser struct {
+	Name         string
+	FlushTimeout int
+	StartState   string
+	Continuation string
 }
 
 // FBCfgOutput FluentBit Output config block, supporting NR output plugin.
@@ -271,8 +342,35 @@ type FBOSConfig struct {
 	UseANSI bool
 }
 
+// FormatParsers will return the multiline parsers in the fluent bit parsers file format.
+func (c FBCfg) FormatParsers() (string, error) {
+	buf := new(bytes.Buffer)
+	tpl, err := template.New("fb parsers").Parse(fbMultilineParsersFormat)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot parse log-forwarder parsers template")
+	}
+	err = tpl.Execute(buf, c)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot write log-forwarder parsers template")
+	}
+
+	return buf.String(), nil
+}
+
 // NewFBConf creates a FluentBit config from several logging integration configs.
 func NewFBConf(loggingCfgs LogsCfg, logFwdCfg *config.LogForward, entityGUID, hostname string) (fb FBCfg, e error) {
+	fb, errs := newFBConf(loggingCfgs, logFwdCfg, entityGUID, hostname)
+	if len(errs) > 0 {
+		return fb, errs[0]
+	}
+	return fb, nil
+}
+
+// newFBConf creates a FluentBit config from several logging integration configs, returning the errors of every log
+// source that cannot be added to it.
+//
+//nolint:nonamedreturns
+func newFBConf(loggingCfgs LogsCfg, logFwdCfg *config.LogForward, entityGUID, hostname string) (fb FBCfg, errs []error) {
 	fb = FBCfg{
 		Inputs:  []FBCfgInput{},
 		Filters: []FBCfgFilter{},
@@ -282,18 +380,32 @@ func NewFBConf(loggingCfgs LogsCfg, logFwdCfg *config.LogForward, entityGUID, ho
 	var fbOSConfig FBOSConfig
 	addOSDependantConfig(&fbOSConfig)
 
+	// denied sources are rejected before capping them, so they don't count towards the maximum
+	loggingCfgs = rejectDeniedLogSources(loggingCfgs, logFwdCfg.PathDenylist)
+	loggingCfgs = limitLogSources(loggingCfgs, logFwdCfg.MaxLogSources)
+	parsers := availableParsers(loggingCfgs)
+
 	totalFiles := 0
 	for i, block := range loggingCfgs {
 		loggingCfgs[i].targetFilesCnt = getTotalTargetFilesForPath(block)
 		totalFiles += loggingCfgs[i].targetFilesCnt
+		if err := validateParser(block, parsers); err != nil {
+			errs = append(errs, err)
+			continue
+		}
 		input, filters, external, err := parseConfigBlock(block, logFwdCfg.HomeDir, fbOSConfig)
 		if err != nil {
-			return
+			errs = append(errs, fmt.Errorf("log source %q: %w", block.Name, err))
+			continue
 		}
 		if (input != FBCfgInput{}) {
 			fb.Inputs = append(fb.Inputs, input)
 		}
 
+		if hasMultiline(block) {
+			fb.MultilineParsers = append(fb.MultilineParsers, newMultilineParser(block))
+		}
+
 		fb.Filters = append(fb.Filters, filters...)
 
 		if (external != FBCfgExternal{} && fb.ExternalCfg != FBCfgExternal{}) {
@@ -321,12 +433,22 @@ func NewFBConf(loggingCfgs LogsCfg, logFwdCfg *config.LogForward, entityGUID, ho
 		}
 	}
 
-	if (len(fb.Inputs) == 0 && fb.ExternalCfg == FBCfgExternal{}) {
+	if len(errs) > 0 || (len(fb.Inputs) == 0 && fb.ExternalCfg == FBCfgExternal{}) {
 		return
 	}
 
+	if len(fb.MultilineParsers) > 0 {
+		parsersContent, err := fb.FormatParsers()
+		if err != nil {
+			return fb, []error{err}
+		}
+		if fb.ParsersFile, err = saveToTempFile([]byte(parsersContent), "nr_fb_parsers"); err != nil {
+			return fb, []error{err}
+		}
+	}
+
 	// This record_modifier FILTER adds common attributes for all the log records
-	fb.Filters = append(fb.Filters, FBCfgFilter{
+	commonFilter := FBCfgFilter{
 		Name:  fbFilterTypeRecordModifier,
 		Match: "*",
 		Records: map[string]string{
@@ -334,7 +456,9 @@ func NewFBConf(loggingCfgs LogsCfg, logFwdCfg *config.LogForward, entityGUID, ho
 			rAttPluginType: logRecordModifierSource,
 			rAttHostname:   hostname,
 		},
-	})
+	}
+	addUserAttributes(commonFilter.Records, logFwdCfg.CommonAttributes)
+	fb.Filters = append(fb.Filters, commonFilter)
 
 	// Newrelic OUTPUT plugin will send all the collected logs to Vortex
 	fb.Output = newNROutput(logFwdCfg)
@@ -342,6 +466,127 @@ func NewFBConf(loggingCfgs LogsCfg, logFwdCfg *config.LogForward, entityGUID, ho
 	return
 }
 
+// limitLogSources discards the logging configs exceeding maxSources, if positive.
+func limitLogSources(loggingCfgs LogsCfg, maxSources int) LogsCfg {
+	if maxSources <= 0 || len(loggingCfgs) <= maxSources {
+		return loggingCfgs
+	}
+
+	cfgLogger.
+		WithField("logSources", len(loggingCfgs)).
+		WithField("maxLogSources", maxSources).
+		Warn("Number of log sources exceeds the configured maximum, discarding the ones over the limit.")
+
+	return loggingCfgs[:maxSources]
+}
+
+// availableParsers returns the names of the parsers that can be referenced by the log sources: the FluentBit built-in
+// ones and the ones defined in the external parsers files.
+func availableParsers(loggingCfgs LogsCfg) map[string]bool {
+	parsers := make(map[string]bool, len(fbBuiltinParsers))
+	for _, name := range fbBuiltinParsers {
+		parsers[name] = true
+	}
+
+	for _, block := range loggingCfgs {
+		if block.Fluentbit == nil || block.Fluentbit.ParsersPath == "" {
+			continue
+		}
+		for _, name := range readParserNames(block.Fluentbit.ParsersPath) {
+			parsers[name] = true
+		}
+	}
+
+	return parsers
+}
+
+// errParserNotFileSource is returned for the log sources setting a parser without being read by the tail input, the
+// only one the parser applies to. Syslog sources have their own parser option and tcp ones their format.
+var errParserNotFileSource = errors.New("parser only applies to file sources")
+
+// validateParser returns an error when the parser of the log source isn't available, or it can't be applied to it.
+func validateParser(l LogCfg, parsers map[string]bool) error {
+	if l.Parser == "" {
+		return nil
+	}
+	if l.File == "" {
+		return fmt.Errorf("log source %q: %w", l.Name, errParserNotFileSource)
+	}
+	if !parsers[l.Parser] {
+		return fmt.Errorf("log source %q: parser %q is not defined in FluentBit nor in any external parsers file", l.Name, l.Parser)
+	}
+	return nil
+}
+
+// readParserNames returns the names of the parsers defined in a FluentBit parsers file.
+func readParserNames(parsersPath string) (names []string) {
+	content, err := ioutil.ReadFile(parsersPath)
+	if err != nil {
+		cfgLogger.WithField("file", parsersPath).WithError(err).Warn("Cannot read external parsers file.")
+		return nil
+	}
+
+	inParser := false
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inParser = strings.EqualFold(trimmed, "[PARSER]")
+			continue
+		}
+		if inParser {
+			if match := parserNameRegex.FindStringSubmatch(trimmed); match != nil {
+				names = append(names, match[1])
+			}
+		}
+	}
+
+	return names
+}
+
+// rejectDeniedLogSources discards the logging configs whose file path matches the denylist.
+func rejectDeniedLogSources(loggingCfgs LogsCfg, denylist []string) LogsCfg {
+	if len(denylist) == 0 {
+		return loggingCfgs
+	}
+
+	allowed := make(LogsCfg, 0, len(loggingCfgs))
+	for _, block := range loggingCfgs {
+		if deniedFile, denied := deniedFilePath(block, denylist); denied {
+			cfgLogger.
+				WithField("name", block.Name).
+				WithField("file", deniedFile).
+				Error("Log source rejected, its file path matches the logging path denylist.")
+			continue
+		}
+		allowed = append(allowed, block)
+	}
+
+	return allowed
+}
+
+// deniedFilePath returns the first path of the log source file, or of the files matched by its glob, that matches
+// any of the denylist patterns.
+func deniedFilePath(l LogCfg, denylist []string) (string, bool) {
+	if l.File == "" || len(denylist) == 0 {
+		return "", false
+	}
+
+	// invalid glob patterns are reported when counting the target files
+	files, _ := filepath.Glob(l.File)
+	files = append([]string{l.File}, files...)
+
+	for _, file := range files {
+		cleanFile := filepath.Clean(file)
+		for _, pattern := range denylist {
+			if matched, _ := filepath.Match(pattern, cleanFile); matched {
+				return file, true
+			}
+		}
+	}
+
+	return "", false
+}
+
 func getTotalTargetFilesForPath(l LogCfg) int {
 	if l.File == "" {
 		return 0
@@ -384,14 +629,24 @@ func parseConfigBlock(l LogCfg, logsHomeDir string, fbOSConfig FBOSConfig) (inpu
 	if (input == FBCfgInput{}) {
 		err = fmt.Errorf("invalid log integration config")
 		return
-	} else {
-		return input, filters, FBCfgExternal{}, nil
 	}
+
+	if l.MaxRecordsPerSecond > 0 {
+		filters = append(filters, newThrottleFilter(l.Name, l.MaxRecordsPerSecond))
+	}
+
+	return input, filters, FBCfgExternal{}, nil
 }
 
 // Single file
 func parseFileInput(l LogCfg, dbPath string) (input FBCfgInput, filters []FBCfgFilter) {
-	input = newFileInput(l.File, dbPath, l.Name, getBufferMaxSize(l), l.MultilineParser)
+	multilineParser := l.MultilineParser
+	if hasMultiline(l) {
+		// the tail input assembles the multiline records before any filter, such as the pattern one, is applied
+		multilineParser = strings.Join(append(strings.FieldsFunc(multilineParser, isParserSeparator), multilineParserName(l.Name)), ", ")
+	}
+	input = newFileInput(l.File, dbPath, l.Name, getBufferMaxSize(l), multilineParser)
+	input.Parser = l.Parser
 	filters = append(filters, newRecordModifierFilterForInput(l.Name, fbInputTypeTail, l.Attributes))
 	filters = parsePattern(l, fbGrepFieldForTail, filters)
 	return input, filters
@@ -426,6 +681,10 @@ func parseTcpInput(l LogCfg) (input FBCfgInput, filters []FBCfgFilter, err error
 	}
 	input = tcpIn
 	filters = append(filters, newRecordModifierFilterForInput(l.Name, fbInputTypeTcp, l.Attributes))
+	if hasMultiline(l) {
+		// multiline records must be assembled before the pattern filter is applied
+		filters = append(filters, newMultilineFilter(l.Name, fbGrepFieldForTcpPlain))
+	}
 	if l.Tcp.Format == "none" {
 		filters = parsePattern(l, fbGrepFieldForTcpPlain, filters)
 	}
@@ -436,13 +695,17 @@ func parseTcpInput(l LogCfg) (input FBCfgInput, filters []FBCfgFilter, err error
 //
 //nolint:nonamedreturns,varnamelen
 func parseWinlogInput(l LogCfg, dbPath string, fbOSConfig FBOSConfig) (input FBCfgInput, filters []FBCfgFilter, err error) {
-	input = newWinlogInput(*l.Winlog, dbPath, l.Name, fbOSConfig)
+	channels, err := winlogChannels(fbInputTypeWinlog, l.Winlog.Channel, l.Winlog.Channels)
+	if err != nil {
+		return FBCfgInput{}, []FBCfgFilter{}, err
+	}
+	input = newWinlogInput(*l.Winlog, channels, dbPath, l.Name, fbOSConfig)
 	filters = append(filters, newRecordModifierFilterForInput(l.Name, fbInputTypeWinlog, l.Attributes))
 	scriptContent, err := createLuaWindowsFilterScript(l.Winlog.CollectEventIds, l.Winlog.ExcludeEventIds)
 	if err != nil {
 		return FBCfgInput{}, []FBCfgFilter{}, err
 	}
-	scriptName, err := saveToTempFile([]byte(scriptContent))
+	scriptName, err := saveToTempFile([]byte(scriptContent), "nr_fb_lua_filter")
 	if err != nil {
 		return FBCfgInput{}, []FBCfgFilter{}, err
 	}
@@ -456,13 +719,17 @@ func parseWinlogInput(l LogCfg, dbPath string, fbOSConfig FBOSConfig) (input FBC
 //
 //nolint:nonamedreturns,varnamelen
 func parseWinevtlogInput(l LogCfg, dbPath string, fbOSConfig FBOSConfig) (input FBCfgInput, filters []FBCfgFilter, err error) {
-	input = newWinevtlogInput(*l.Winevtlog, dbPath, l.Name, fbOSConfig)
+	channels, err := winlogChannels(fbInputTypeWinevtlog, l.Winevtlog.Channel, l.Winevtlog.Channels)
+	if err != nil {
+		return FBCfgInput{}, []FBCfgFilter{}, err
+	}
+	input = newWinevtlogInput(*l.Winevtlog, channels, dbPath, l.Name, fbOSConfig)
 	filters = append(filters, newRecordModifierFilterForInput(l.Name, fbInputTypeWinevtlog, l.Attributes))
 	scriptContent, err := createLuaWindowsFilterScript(l.Winevtlog.CollectEventIds, l.Winevtlog.ExcludeEventIds)
 	if err != nil {
 		return FBCfgInput{}, []FBCfgFilter{}, err
 	}
-	scriptName, err := saveToTempFile([]byte(scriptContent))
+	scriptName, err := saveToTempFile([]byte(scriptContent), "nr_fb_lua_filter")
 	if err != nil {
 		return FBCfgInput{}, []FBCfgFilter{}, err
 	}
@@ -472,6 +739,23 @@ func parseWinevtlogInput(l LogCfg, dbPath string, fbOSConfig FBOSConfig) (input
 	return input, filters, nil
 }
 
+// winlogChannels returns the comma separated list of Windows Event Log channels for the FluentBit input, from the
+// channel and channels options. An error is returned if no channel is provided.
+func winlogChannels(inputType string, channel string, channels []string) (string, error) {
+	all := make([]string, 0, len(channels)+1)
+	for _, c := range append(strings.Split(channel, ","), channels...) {
+		if c = strings.TrimSpace(c); c != "" {
+			all = append(all, c)
+		}
+	}
+
+	if len(all) == 0 {
+		return "", fmt.Errorf("%s: no event log channels provided", inputType)
+	}
+
+	return strings.Join(all, ","), nil
+}
+
 func createLuaWindowsFilterScript(included []string, excluded []string) (scriptContent string, err error) {
 	var fbLuaScript FBWinlogLuaScript
 	fbLuaScript.FnName = fbLuaFnNameWinlogEventFilter
@@ -515,16 +799,16 @@ func createConditions(numberRanges []string, defaultIfEmpty string) (conditions
 	}
 }
 
-func saveToTempFile(config []byte) (string, error) {
+func saveToTempFile(config []byte, pattern string) (string, error) {
 	// create it
-	file, err := ioutil.TempFile("", "nr_fb_lua_filter")
+	file, err := ioutil.TempFile("", pattern)
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
 
 	cfgLogger.WithField("file", file.Name()).WithField("content", string(config)).
-		Debug("Creating temp lua filter for fb.")
+		Debug("Creating temp file for fb.")
 
 	if _, err := file.Write(config); err != nil {
 		return "", err
@@ -532,6 +816,32 @@ func saveToTempFile(config []byte) (string, error) {
 	return file.Name(), nil
 }
 
+// hasMultiline returns true if a custom multiline parser applies to the log source, only file and tcp sources support it.
+func hasMultiline(l LogCfg) bool {
+	return l.Multiline != nil && (l.File != "" || l.Tcp != nil)
+}
+
+func multilineParserName(tag string) string {
+	return "multiline_" + invalidParserNameChars.ReplaceAllString(tag, "_")
+}
+
+func isParserSeparator(r rune) bool {
+	return r == ',' || r == ' '
+}
+
+func newMultilineParser(l LogCfg) FBCfgMultilineParser {
+	continuation := l.Multiline.Continuation
+	if continuation == "" {
+		continuation = fmt.Sprintf("^(?!%s)", l.Multiline.StartState)
+	}
+	return FBCfgMultilineParser{
+		Name:         multilineParserName(l.Name),
+		FlushTimeout: l.Multiline.FlushTimeoutMs,
+		StartState:   l.Multiline.StartState,
+		Continuation: continuation,
+	}
+}
+
 func parsePattern(l LogCfg, fluentBitGrepField string, filters []FBCfgFilter) []FBCfgFilter {
 	if l.Pattern != "" {
 		return append(filters, newGrepFilter(l, fluentBitGrepField))
@@ -570,10 +880,10 @@ func newSystemdInput(service string, dbPath string, tag string) FBCfgInput {
 }
 
 //nolint:exhaustruct
-func newWinlogInput(winlog LogWinlogCfg, dbPath string, tag string, fbOSConfig FBOSConfig) FBCfgInput {
+func newWinlogInput(winlog LogWinlogCfg, channels string, dbPath string, tag string, fbOSConfig FBOSConfig) FBCfgInput {
 	return FBCfgInput{
 		Name:     fbInputTypeWinlog,
-		Channels: winlog.Channel,
+		Channels: channels,
 		Tag:      tag,
 		DB:       dbPath,
 		UseANSI:  determineUseAnsiFlagValue(winlog.UseANSI, fbOSConfig.UseANSI),
@@ -581,10 +891,10 @@ func newWinlogInput(winlog LogWinlogCfg, dbPath string, tag string, fbOSConfig F
 }
 
 //nolint:exhaustruct
-func newWinevtlogInput(winlog LogWinevtlogCfg, dbPath string, tag string, fbOSConfig FBOSConfig) FBCfgInput {
+func newWinevtlogInput(winlog LogWinevtlogCfg, channels string, dbPath string, tag string, fbOSConfig FBOSConfig) FBCfgInput {
 	return FBCfgInput{
 		Name:     fbInputTypeWinevtlog,
-		Channels: winlog.Channel,
+		Channels: channels,
 		Tag:      tag,
 		DB:       dbPath,
 		UseANSI:  determineUseAnsiFlagValue(winlog.UseANSI, fbOSConfig.UseANSI),
@@ -683,15 +993,20 @@ func newRecordModifierFilterForInput(tag string, fbFilterInputType string, userA
 		},
 	}
 
+	addUserAttributes(ret.Records, userAttributes)
+
+	return ret
+}
+
+// addUserAttributes adds the user defined attributes to the records, ignoring reserved attribute names.
+func addUserAttributes(records map[string]string, userAttributes map[string]string) {
 	for key, value := range userAttributes {
 		if !isReserved(key) {
-			ret.Records[key] = value
+			records[key] = value
 		} else {
 			cfgLogger.WithField("attribute", key).Warn("attribute name is a reserved keyword and will be ignored, please use a different name")
 		}
 	}
-
-	return ret
 }
 
 func newGrepFilter(l LogCfg, fluentBitGrepField string) FBCfgFilter {
@@ -702,6 +1017,26 @@ func newGrepFilter(l LogCfg, fluentBitGrepField string) FBCfgFilter {
 	}
 }
 
+func newMultilineFilter(tag string, keyContent string) FBCfgFilter {
+	return FBCfgFilter{
+		Name:                fbFilterTypeMultiline,
+		Match:               tag,
+		MultilineKeyContent: keyContent,
+		MultilineParser:     multilineParserName(tag),
+	}
+}
+
+// newThrottleFilter limits the records of the input to the given rate, averaged over a sliding window of intervals.
+func newThrottleFilter(tag string, rate int) FBCfgFilter {
+	return FBCfgFilter{
+		Name:     fbFilterTypeThrottle,
+		Match:    tag,
+		Rate:     rate,
+		Window:   throttleWindow,
+		Interval: throttleInterval,
+	}
+}
+
 func newLuaFilter(tag string, fileName string) FBCfgFilter {
 	return FBCfgFilter{
 		Name:   fbFilterTypeLua,
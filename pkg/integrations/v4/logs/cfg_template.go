@@ -2,7 +2,12 @@
 // SPDX-License-Identifier: Apache-2.0
 package logs
 
-var fbConfigFormat = `{{- range .Inputs }}
+var fbConfigFormat = `{{- if .ParsersFile }}
+[SERVICE]
+    Parsers_File {{ .ParsersFile }}
+{{ end -}}
+
+{{- range .Inputs }}
 [INPUT]
     Name {{ .Name }}
     {{- if .Path }}
@@ -23,6 +28,9 @@ var fbConfigFormat = `{{- range .Inputs }}
     {{- if .MultilineParser }}
     Multiline.Parser {{ .MultilineParser }}
     {{- end }}
+    {{- if .Parser }}
+    Parser {{ .Parser }}
+    {{- end }}
     {{- if .PathKey }}
     Path_Key {{ .PathKey }}
     {{- end }}
@@ -103,6 +111,17 @@ var fbConfigFormat = `{{- range .Inputs }}
     {{- if .Call }}
     call {{ .Call }}
     {{- end }}
+    {{- if .MultilineKeyContent }}
+    multiline.key_content {{ .MultilineKeyContent }}
+    {{- end }}
+    {{- if .MultilineParser }}
+    multiline.parser {{ .MultilineParser }}
+    {{- end }}
+    {{- if .Rate }}
+    Rate     {{ .Rate }}
+    Window   {{ .Window }}
+    Interval {{ .Interval }}
+    {{- end }}
 {{ end -}}
 
 {{- if .Output }}
@@ -142,6 +161,17 @@ var fbConfigFormat = `{{- range .Inputs }}
 @INCLUDE {{ .ExternalCfg.CfgFilePath }}
 {{ end -}}`
 
+var fbMultilineParsersFormat = `{{- range .MultilineParsers }}
+[MULTILINE_PARSER]
+    name          {{ .Name }}
+    type          regex
+    {{- if .FlushTimeout }}
+    flush_timeout {{ .FlushTimeout }}
+    {{- end }}
+    rule          "start_state" "/{{ .StartState }}/" "cont"
+    rule          "cont" "/{{ .Continuation }}/" "cont"
+{{ end -}}`
+
 var fbLuaScriptFormat = `function {{ .FnName }}(tag, timestamp, record)
     eventId = record["EventID"]
     -- Discard log records matching any of these conditions
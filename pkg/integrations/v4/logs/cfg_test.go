@@ -5,15 +5,20 @@
 package logs
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
 	"testing"
 
 	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	logHelper "github.com/newrelic/infrastructure-agent/test/log"
 
 	"github.com/shirou/gopsutil/v3/host"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -698,6 +703,72 @@ func TestNewFBConf(t *testing.T) {
 			},
 			Output: outputBlock,
 		}},
+		{"input file + max records per second", logFwdCfg, LogsCfg{
+			{
+				Name:                "log-file",
+				File:                "file.path",
+				Pattern:             "foo",
+				MaxRecordsPerSecond: 500,
+			},
+		}, FBCfg{
+			Inputs: []FBCfgInput{
+				{
+					Name:           "tail",
+					Tag:            "log-file",
+					DB:             dbDbPath,
+					Path:           "file.path",
+					BufferMaxSize:  "128k",
+					MemBufferLimit: "16384k",
+					SkipLongLines:  "On",
+					PathKey:        "filePath",
+				},
+			},
+			Filters: []FBCfgFilter{
+				inputRecordModifier("tail", "log-file"),
+				{
+					Name:  "grep",
+					Match: "log-file",
+					Regex: "log foo",
+				},
+				{
+					Name:     "throttle",
+					Match:    "log-file",
+					Rate:     500,
+					Window:   5,
+					Interval: "1s",
+				},
+				filterEntityBlock,
+			},
+			Output: outputBlock,
+		}},
+		{"input systemd + max records per second", logFwdCfg, LogsCfg{
+			{
+				Name:                "some_service",
+				Systemd:             "service",
+				MaxRecordsPerSecond: 10,
+			},
+		}, FBCfg{
+			Inputs: []FBCfgInput{
+				{
+					Name:           "systemd",
+					Tag:            "some_service",
+					DB:             dbDbPath,
+					Systemd_Filter: "_SYSTEMD_UNIT=service.service",
+				},
+			},
+			Filters: []FBCfgFilter{
+				inputRecordModifier("systemd", "some_service"),
+				{
+					Name:     "throttle",
+					Match:    "some_service",
+					Rate:     10,
+					Window:   5,
+					Interval: "1s",
+				},
+				filterEntityBlock,
+			},
+			Output: outputBlock,
+		}},
 	}
 
 	for _, tt := range tests {
@@ -1787,6 +1858,53 @@ func TestCreateConditions(t *testing.T) {
 	}
 }
 
+func TestWinlogChannels(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		channel      string
+		channels     []string
+		wantChannels string
+		wantErr      bool
+	}{
+		{"Single channel", "Security", nil, "Security", false},
+		{"Comma separated channel", "Application, System", nil, "Application,System", false},
+		{"Channels list", "", []string{"Application", "System", "Security"}, "Application,System,Security", false},
+		{"Channel and channels list", "Setup", []string{"Application", " "}, "Setup,Application", false},
+		{"No channels", "", nil, "", true},
+		{"Blank channels", " , ", []string{""}, "", true},
+	}
+	for _, testItem := range tests {
+		test := testItem
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			channels, err := winlogChannels(fbInputTypeWinlog, test.channel, test.channels)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantChannels, channels)
+		})
+	}
+}
+
+func TestFBConfigForWinlog_NoChannels(t *testing.T) {
+	t.Parallel()
+	block := LogCfg{
+		Name:      "win-events",
+		Winevtlog: &LogWinevtlogCfg{},
+	}
+
+	_, _, _, err := parseConfigBlock(block, "", FBOSConfig{})
+	assert.Error(t, err)
+
+	fbCfg, err := NewFBConf(LogsCfg{block}, &logFwdCfg, "0", "")
+	assert.EqualError(t, err, `log source "win-events": winevtlog: no event log channels provided`)
+	assert.Empty(t, fbCfg.Inputs)
+}
+
 func TestDetermineUseAnsiFlagValue(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -1930,6 +2048,221 @@ func TestNewFbConfForTargetFileCount(t *testing.T) {
 	}
 }
 
+func TestNewFBConf_MaxLogSources(t *testing.T) {
+	logsCfg := LogsCfg{
+		{Name: "systemd-1", Systemd: "cupsd"},
+		{Name: "systemd-2", Systemd: "sshd"},
+		{Name: "systemd-3", Systemd: "cron"},
+	}
+
+	tests := []struct {
+		name           string
+		maxLogSources  int
+		expectedInputs []string
+		truncated      bool
+	}{
+		{"no limit", 0, []string{"_SYSTEMD_UNIT=cupsd.service", "_SYSTEMD_UNIT=sshd.service", "_SYSTEMD_UNIT=cron.service"}, false},
+		{"limit not reached", 3, []string{"_SYSTEMD_UNIT=cupsd.service", "_SYSTEMD_UNIT=sshd.service", "_SYSTEMD_UNIT=cron.service"}, false},
+		{"limit exceeded", 2, []string{"_SYSTEMD_UNIT=cupsd.service", "_SYSTEMD_UNIT=sshd.service"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := logHelper.NewInMemoryEntriesHook([]logrus.Level{logrus.WarnLevel})
+			log.AddHook(hook)
+
+			fwdCfg := logFwdCfg
+			fwdCfg.MaxLogSources = tt.maxLogSources
+
+			fbConf, err := NewFBConf(logsCfg, &fwdCfg, "0", "")
+			assert.NoError(t, err)
+
+			var inputs []string
+			for _, input := range fbConf.Inputs {
+				inputs = append(inputs, input.Systemd_Filter)
+			}
+			assert.Equal(t, tt.expectedInputs, inputs)
+			assert.Equal(t, tt.truncated, hook.EntryWithMessageExists(regexp.MustCompile("exceeds the configured maximum")))
+		})
+	}
+}
+
+func TestNewFBConf_Parser(t *testing.T) {
+	parsersFile, err := ioutil.TempFile("", "parsers.conf")
+	assert.NoError(t, err)
+	defer os.Remove(parsersFile.Name())
+	_, err = parsersFile.WriteString("[PARSER]\n    Name   my-app\n    Format regex\n    Regex  ^(?<message>.*)$\n")
+	assert.NoError(t, err)
+	assert.NoError(t, parsersFile.Close())
+
+	external := LogCfg{
+		Name:      "fb-external",
+		Fluentbit: &LogExternalFBCfg{CfgPath: "/path/to/fb.conf", ParsersPath: parsersFile.Name()},
+	}
+
+	tests := []struct {
+		name           string
+		file           string
+		parser         string
+		expectedParser string
+	}{
+		{"no parser", "/var/log/app.log", "", ""},
+		{"builtin parser", "/var/log/app.log", "json", "json"},
+		{"external parser", "/var/log/app.log", "my-app", "my-app"},
+		{"folder", "/var/log/app/*.log", "json", "json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logsCfg := LogsCfg{
+				{Name: "app-log", File: tt.file, Parser: tt.parser},
+				external,
+			}
+
+			fbConf, err := NewFBConf(logsCfg, &logFwdCfg, "0", "")
+			assert.NoError(t, err)
+			assert.Len(t, fbConf.Inputs, 1)
+			assert.Equal(t, tt.expectedParser, fbConf.Inputs[0].Parser)
+
+			result, _, err := fbConf.Format()
+			assert.NoError(t, err)
+			if tt.expectedParser != "" {
+				assert.Contains(t, result, "    Parser "+tt.expectedParser+"\n")
+			} else {
+				assert.NotContains(t, result, "    Parser ")
+			}
+		})
+	}
+}
+
+func TestNewFBConf_InvalidParser(t *testing.T) {
+	tests := []struct {
+		name        string
+		logCfg      LogCfg
+		expectedErr string
+	}{
+		{
+			"undefined parser",
+			LogCfg{Name: "app-log", File: "/var/log/app.log", Parser: "unknown"},
+			`log source "app-log": parser "unknown" is not defined in FluentBit nor in any external parsers file`,
+		},
+		{
+			"systemd source",
+			LogCfg{Name: "sshd", Systemd: "sshd", Parser: "json"},
+			`log source "sshd": parser only applies to file sources`,
+		},
+		{
+			"tcp source",
+			LogCfg{Name: "tcp", Tcp: &LogTcpCfg{Uri: "tcp://0.0.0.0:5170", Format: "json"}, Parser: "json"},
+			`log source "tcp": parser only applies to file sources`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFBConf(LogsCfg{tt.logCfg}, &logFwdCfg, "0", "")
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestNewFBConf_CommonAttributes(t *testing.T) {
+	logsCfg := LogsCfg{
+		{Name: "app-log", File: "/var/log/app.log", Attributes: map[string]string{"source": "app"}},
+		{Name: "systemd-sshd", Systemd: "sshd"},
+	}
+
+	fwdCfg := logFwdCfg
+	fwdCfg.CommonAttributes = map[string]string{
+		"environment": "production",
+		"team":        "alpha-team",
+		rAttHostname:  "overridden",
+	}
+
+	fbConf, err := NewFBConf(logsCfg, &fwdCfg, "0", "my-host")
+	assert.NoError(t, err)
+	assert.Len(t, fbConf.Inputs, 2)
+
+	var commonFilters []FBCfgFilter
+	for _, filter := range fbConf.Filters {
+		if filter.Name == fbFilterTypeRecordModifier && filter.Match == "*" {
+			commonFilters = append(commonFilters, filter)
+		}
+	}
+
+	// a single filter matching the records of every source
+	assert.Equal(t, []FBCfgFilter{
+		{
+			Name:  "record_modifier",
+			Match: "*",
+			Records: map[string]string{
+				"entity.guid.INFRA": "0",
+				"plugin.type":       "nri-agent",
+				"hostname":          "my-host",
+				"environment":       "production",
+				"team":              "alpha-team",
+			},
+		},
+	}, commonFilters)
+}
+
+func TestNewFBConf_PathDenylist(t *testing.T) {
+	logsDir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(logsDir, "app.log"), []byte{}, 0o600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(logsDir, "secret.key"), []byte{}, 0o600))
+
+	fwdCfg := logFwdCfg
+	fwdCfg.PathDenylist = []string{filepath.Clean("/etc/shadow"), filepath.Join(logsDir, "*.key")}
+
+	logsCfg := LogsCfg{
+		{Name: "shadow", File: "/etc/shadow"},
+		{Name: "all-files", File: filepath.Join(logsDir, "*")},
+		{Name: "app-log", File: filepath.Join(logsDir, "app.log")},
+		{Name: "sshd", Systemd: "sshd"},
+	}
+
+	hook := logHelper.NewInMemoryEntriesHook([]logrus.Level{logrus.ErrorLevel})
+	log.AddHook(hook)
+
+	fbConf, err := NewFBConf(logsCfg, &fwdCfg, "0", "")
+	assert.NoError(t, err)
+
+	var inputTags []string
+	for _, input := range fbConf.Inputs {
+		inputTags = append(inputTags, input.Tag)
+	}
+	assert.Equal(t, []string{"app-log", "sshd"}, inputTags)
+
+	var rejected []string
+	for _, entry := range hook.GetEntries() {
+		if entry.Message == "Log source rejected, its file path matches the logging path denylist." {
+			rejected = append(rejected, entry.Data["name"].(string))
+		}
+	}
+	assert.Equal(t, []string{"shadow", "all-files"}, rejected)
+}
+
+func TestNewFBConf_PathDenylistBeforeMaxLogSources(t *testing.T) {
+	fwdCfg := logFwdCfg
+	fwdCfg.PathDenylist = []string{filepath.Clean("/etc/shadow")}
+	fwdCfg.MaxLogSources = 1
+
+	logsCfg := LogsCfg{
+		{Name: "shadow", File: "/etc/shadow"},
+		{Name: "sshd", Systemd: "sshd"},
+	}
+
+	fbConf, err := NewFBConf(logsCfg, &fwdCfg, "0", "")
+	assert.NoError(t, err)
+
+	// the denied source doesn't count towards the maximum
+	var inputTags []string
+	for _, input := range fbConf.Inputs {
+		inputTags = append(inputTags, input.Tag)
+	}
+	assert.Equal(t, []string{"sshd"}, inputTags)
+}
+
 func TestMultilineParserFBCfgFormat(t *testing.T) {
 	expected := `
 [INPUT]
@@ -2119,3 +2452,124 @@ func TestMlParserFBCfgWithMultipleParsers(t *testing.T) {
 	assert.Equal(t, "/path/to/fb/parsers", extCfg.ParsersFilePath)
 	assert.Equal(t, expected, result)
 }
+
+func TestNewFBConf_Multiline(t *testing.T) {
+	multiline := &LogMultilineCfg{StartState: `^\d{4}-\d{2}-\d{2}`, FlushTimeoutMs: 1000}
+	logsCfg := LogsCfg{
+		{Name: "java-file", File: "/var/log/java.log", MultilineParser: "java", Pattern: "ERROR", Multiline: multiline},
+		{Name: "java-tcp", Tcp: &LogTcpCfg{Uri: "tcp://0.0.0.0:5170", Format: "none", Separator: `\n`}, Pattern: "ERROR", Multiline: multiline},
+	}
+
+	fbConf, err := NewFBConf(logsCfg, &logFwdCfg, "0", "")
+	assert.NoError(t, err)
+	defer os.Remove(fbConf.ParsersFile)
+
+	// tail input assembles the records itself, along with the configured built-in parser
+	assert.Equal(t, "java, multiline_java-file", fbConf.Inputs[0].MultilineParser)
+	// tcp records are assembled by a multiline filter placed before the pattern one
+	var tcpFilters []string
+	for _, filter := range fbConf.Filters {
+		if filter.Match == "java-tcp" {
+			tcpFilters = append(tcpFilters, filter.Name)
+		}
+	}
+	assert.Equal(t, []string{"record_modifier", "multiline", "grep"}, tcpFilters)
+
+	parsers, err := ioutil.ReadFile(fbConf.ParsersFile)
+	assert.NoError(t, err)
+	assert.Equal(t, `
+[MULTILINE_PARSER]
+    name          multiline_java-file
+    type          regex
+    flush_timeout 1000
+    rule          "start_state" "/^\d{4}-\d{2}-\d{2}/" "cont"
+    rule          "cont" "/^(?!^\d{4}-\d{2}-\d{2})/" "cont"
+
+[MULTILINE_PARSER]
+    name          multiline_java-tcp
+    type          regex
+    flush_timeout 1000
+    rule          "start_state" "/^\d{4}-\d{2}-\d{2}/" "cont"
+    rule          "cont" "/^(?!^\d{4}-\d{2}-\d{2})/" "cont"
+`, string(parsers))
+
+	result, _, err := fbConf.Format()
+	assert.NoError(t, err)
+	assert.Contains(t, result, "[SERVICE]\n    Parsers_File "+fbConf.ParsersFile+"\n")
+	assert.Contains(t, result, `
+[FILTER]
+    Name  multiline
+    Match java-tcp
+    multiline.key_content log
+    multiline.parser multiline_java-tcp
+`)
+}
+
+func TestNewFBConf_NoMultiline(t *testing.T) {
+	fbConf, err := NewFBConf(LogsCfg{{Name: "app-log", File: "/var/log/app.log"}}, &logFwdCfg, "0", "")
+	assert.NoError(t, err)
+	assert.Empty(t, fbConf.ParsersFile)
+
+	result, _, err := fbConf.Format()
+	assert.NoError(t, err)
+	assert.NotContains(t, result, "[SERVICE]")
+}
+
+func TestMultilineParserName(t *testing.T) {
+	assert.Equal(t, "multiline_java-app.log", multilineParserName("java-app.log"))
+	assert.Equal(t, "multiline_java_app_logs_", multilineParserName("java app/logs#"))
+}
+
+func TestLogMultilineCfg_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		multiline LogMultilineCfg
+		wantErr   bool
+	}{
+		{"valid", LogMultilineCfg{StartState: `^\[`, Continuation: `^\s+at`}, false},
+		{"default continuation", LogMultilineCfg{StartState: `^\[`}, false},
+		{"missing start state", LogMultilineCfg{Continuation: `^\s+at`}, true},
+		{"lookaround", LogMultilineCfg{StartState: `^(?<=\[)`, Continuation: `^(?!\[)`}, false},
+		{"double quotes", LogMultilineCfg{StartState: `^"`}, true},
+		{"negative flush timeout", LogMultilineCfg{StartState: `^\[`, FlushTimeoutMs: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.multiline.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFBCfgFormat_Throttle(t *testing.T) {
+	fbCfg := FBCfg{
+		Filters: []FBCfgFilter{newThrottleFilter("log-file", 100)},
+		Output: FBCfgOutput{
+			Name:       "newrelic",
+			Match:      "*",
+			LicenseKey: "licenseKey",
+		},
+	}
+
+	result, _, err := fbCfg.Format()
+	assert.NoError(t, err)
+	assert.Equal(t, `
+[FILTER]
+    Name  throttle
+    Match log-file
+    Rate     100
+    Window   5
+    Interval 1s
+
+[OUTPUT]
+    Name                newrelic
+    Match               *
+    licenseKey          ${NR_LICENSE_KEY_ENV_VAR}
+    validateProxyCerts  false
+`, result)
+}
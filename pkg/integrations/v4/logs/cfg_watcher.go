@@ -4,30 +4,42 @@ package logs
 
 import (
 	ctx2 "context"
+	"time"
+
 	"github.com/fsnotify/fsnotify"
 	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/fs"
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 )
 
+// defaultReloadCoalesceWindow is the time the configuration must stay unchanged before a reload is signaled, when no
+// window is provided.
+const defaultReloadCoalesceWindow = 500 * time.Millisecond
+
 // ConfigChangesWatcher will look in a path for changes in the configuration.
 type ConfigChangesWatcher struct {
-	watcher *fsnotify.Watcher
-	logger  log.Entry
-	path    string
+	watcher   *fsnotify.Watcher
+	logger    log.Entry
+	path      string
+	coalescer *fs.EventCoalescer
 }
 
-// NewConfigChangesWatcher creates a new instance of ConfigChangesWatcher.
-func NewConfigChangesWatcher(path string) *ConfigChangesWatcher {
+// NewConfigChangesWatcher creates a new instance of ConfigChangesWatcher. The changes received within the window are
+// signaled as a single one, once the path stays unchanged for the window.
+func NewConfigChangesWatcher(path string, window time.Duration) *ConfigChangesWatcher {
 	logger := log.WithComponent("integrations.Supervisor").WithField("process", "config-changes-watcher")
 
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		logger.WithError(err).Warn("Cannot enable configuration automatic reloading for log-forwarder")
 	}
+	if window <= 0 {
+		window = defaultReloadCoalesceWindow
+	}
 	return &ConfigChangesWatcher{
-		watcher: watcher,
-		logger:  logger,
-		path:    path,
+		watcher:   watcher,
+		logger:    logger,
+		path:      path,
+		coalescer: fs.NewEventCoalescer(window),
 	}
 }
 
@@ -53,10 +65,16 @@ func (ccw *ConfigChangesWatcher) watchForChanges(ctx ctx2.Context, changes chan<
 	}
 
 	ccw.logger.Debug("Watching for logging config file changes.")
+	defer ccw.coalescer.Stop()
 	for {
 		select {
 		case event := <-ccw.watcher.Events:
-			ccw.handleFileEvent(&event, changes)
+			ccw.handleFileEvent(&event)
+		case <-ccw.coalescer.Events():
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
 		case err := <-ccw.watcher.Errors:
 			ccw.logger.WithError(err).Debug("Error occurred while watching for logging config file changes.")
 		case <-ctx.Done():
@@ -69,7 +87,7 @@ func (ccw *ConfigChangesWatcher) watchForChanges(ctx ctx2.Context, changes chan<
 	}
 }
 
-func (ccw *ConfigChangesWatcher) handleFileEvent(event *fsnotify.Event, signalReload chan<- struct{}) {
+func (ccw *ConfigChangesWatcher) handleFileEvent(event *fsnotify.Event) {
 	helog := ccw.logger.WithField("function", "handleFileEvent")
 
 	if event == nil {
@@ -109,8 +127,6 @@ func (ccw *ConfigChangesWatcher) handleFileEvent(event *fsnotify.Event, signalRe
 		return
 	}
 
-	select {
-	case signalReload <- struct{}{}:
-	default:
-	}
+	// the log forwarder reloads its whole configuration, so the changes of all the files are coalesced together
+	ccw.coalescer.Add(fsnotify.Event{Name: ccw.path, Op: event.Op})
 }
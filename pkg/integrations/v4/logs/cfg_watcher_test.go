@@ -4,6 +4,7 @@ package logs
 
 import (
 	ctx2 "context"
+	"fmt"
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 	"github.com/sirupsen/logrus"
 	"io/ioutil"
@@ -29,7 +30,7 @@ func Test_HotReload_CreateAndModifyFile(t *testing.T) {
 	}()
 
 	// GIVEN a ConfigChangesWatcher on a temporary directory
-	ccw := NewConfigChangesWatcher(tempDir)
+	ccw := NewConfigChangesWatcher(tempDir, 0)
 
 	changes := make(chan struct{}, 100)
 	ccw.Watch(ctx, changes)
@@ -63,6 +64,39 @@ func Test_HotReload_CreateAndModifyFile(t *testing.T) {
 	requireChanges(t, changes)
 }
 
+func Test_HotReload_CoalescesRapidChanges(t *testing.T) {
+	ctx, cancel := ctx2.WithCancel(ctx2.Background())
+	defer cancel()
+
+	tempDir, err := ioutil.TempDir("", "test_agent")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+
+	// GIVEN a ConfigChangesWatcher with a coalesce window
+	window := 300 * time.Millisecond
+	ccw := NewConfigChangesWatcher(tempDir, window)
+
+	changes := make(chan struct{}, 100)
+	ccw.Watch(ctx, changes)
+
+	// WHEN many files are created and modified within the window
+	for i := 0; i < 10; i++ {
+		cfgFile := filepath.Join(tempDir, fmt.Sprintf("test_agent_%d.yaml", i))
+		require.NoError(t, ioutil.WriteFile(cfgFile, []byte("test"), 0644))
+		require.NoError(t, fileAppend(cfgFile, "test2"))
+	}
+
+	// THEN a single change is signaled
+	requireChanges(t, changes)
+	select {
+	case <-changes:
+		require.Fail(t, "More than one change signaled within the coalesce window")
+	case <-time.After(3 * window):
+	}
+}
+
 func fileAppend(filePath, content string) error {
 	fh, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
 	if err != nil {
@@ -4,8 +4,11 @@ package logs
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"runtime"
 
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 
@@ -128,10 +131,13 @@ func (l *CfgLoader) loadFileCfgs(file string) (cfgs LogsCfg, ok bool) {
 	// each file may contain several log entries
 	fileCfgs, err := l.parseYAML(content)
 	if err != nil {
+		config.ParseErrors.Inc(file)
 		loaderLogger.WithError(err).WithField("file", file).Error("could not parse YAML file")
 		return nil, false
 	}
 
+	fileCfgs = discardWindowsEventLogCfgs(fileCfgs, runtime.GOOS)
+
 	// empty config could be returned if there is a file with no valid config
 	if len(fileCfgs) == 0 {
 		loaderLogger.WithField("file", file).Debug("No configurations found in file.")
@@ -140,6 +146,106 @@ func (l *CfgLoader) loadFileCfgs(file string) (cfgs LogsCfg, ok bool) {
 	return fileCfgs, true
 }
 
+// Placeholders of the entity GUID and hostname the log records are decorated with, as they don't change the validity of
+// the generated configuration.
+const (
+	validationEntityGUID = "validation-entity-guid"
+	validationHostname   = "validation-hostname"
+)
+
+// ValidateFiles reads and parses the logging configuration files, and generates the log forwarder configuration from
+// their log sources, as the log forwarder does, without running it. It returns, by file, every problem that makes the
+// log forwarder ignore the file, any of its log sources or any of their settings, or fail generating its configuration.
+// Files without a YAML extension are ignored.
+func ValidateFiles(files []string, logFwdCfg config.LogForward) map[string][]error {
+	problems := make(map[string][]error)
+	sources := make(map[string]LogsCfg)
+	externalCfgs := make(map[string]LogsCfg)
+	for _, file := range files {
+		if ext := filepath.Ext(file); ext != ".yml" && ext != ".yaml" {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			problems[file] = []error{err}
+			continue
+		}
+
+		cfgs, cfgProblems, err := parseLogSources(content)
+		if err != nil {
+			problems[file] = []error{err}
+			continue
+		}
+		problems[file] = cfgProblems
+		sources[file] = cfgs
+		for _, cfg := range cfgs {
+			if cfg.Fluentbit != nil {
+				externalCfgs[file] = append(externalCfgs[file], cfg)
+			}
+		}
+	}
+
+	// every log source is validated, regardless of the maximum number of them
+	logFwdCfg.MaxLogSources = 0
+	for _, file := range files {
+		cfgs, ok := sources[file]
+		if !ok {
+			continue
+		}
+		// the external configs of the other files are added, as they define the parsers available to every log source
+		for externalFile, external := range externalCfgs {
+			if externalFile != file {
+				cfgs = append(cfgs, external...)
+			}
+		}
+
+		fb, errs := newFBConf(cfgs, &logFwdCfg, validationEntityGUID, validationHostname)
+		removeFBConfTempFiles(fb)
+		problems[file] = append(problems[file], errs...)
+	}
+
+	for file, errs := range problems {
+		if len(errs) == 0 {
+			delete(problems, file)
+		}
+	}
+	return problems
+}
+
+// removeFBConfTempFiles removes the parsers and lua filter temp files written while generating a FluentBit config.
+func removeFBConfTempFiles(fb FBCfg) {
+	if fb.ParsersFile != "" {
+		_ = os.Remove(fb.ParsersFile)
+	}
+	for _, filter := range fb.Filters {
+		if filter.Name == fbFilterTypeLua && filter.Script != "" {
+			_ = os.Remove(filter.Script)
+		}
+	}
+}
+
+// discardWindowsEventLogCfgs removes the Windows Event Log (winlog and winevtlog) sources when not running on
+// Windows, as FluentBit cannot read them.
+func discardWindowsEventLogCfgs(cfgs LogsCfg, goos string) LogsCfg {
+	if goos == "windows" {
+		return cfgs
+	}
+
+	supported := make(LogsCfg, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		if cfg.Winlog != nil || cfg.Winevtlog != nil {
+			loaderLogger.
+				WithField("name", cfg.Name).
+				WithField("os", goos).
+				Warn("Windows Event Log source is only supported on Windows, ignoring it.")
+			continue
+		}
+		supported = append(supported, cfg)
+	}
+	return supported
+}
+
 // loadTroubleshootCfg returns, in case the Troubleshoot mode is enabled, a logging configuration targeted to capture
 // the infra-agent logs.
 func (l *CfgLoader) loadTroubleshootCfg() *LogCfg {
@@ -170,20 +276,41 @@ func (l *CfgLoader) LoadAndFormat() (string, FBCfgExternal, error) {
 }
 
 func (l *CfgLoader) parseYAML(content []byte) (c LogsCfg, err error) {
-	var y YAML
-	if err = yaml.Unmarshal(content, &y); err != nil {
-		return
+	c, problems, err := parseLogSources(content)
+	for _, problem := range problems {
+		loaderLogger.WithError(problem).Warn("Invalid log source configuration, ignoring it.")
 	}
+	return c, err
+}
 
-	// prevent inconsistent data
-	if len(y.Logs) == 0 {
+// parseLogSources parses the log sources of a logging configuration file. The invalid log sources are discarded, and
+// their invalid settings ignored, returning the problems found for each of them.
+func parseLogSources(content []byte) (c LogsCfg, problems []error, err error) {
+	var y YAML
+	if err = yaml.Unmarshal(content, &y); err != nil {
 		return
 	}
 
-	for _, cfg := range y.Logs {
-		if cfg.IsValid() {
-			c = append(c, cfg)
+	for i, cfg := range y.Logs {
+		if !cfg.IsValid() {
+			problems = append(problems, fmt.Errorf("log source #%d lacks a name or an input", i+1))
+			continue
+		}
+		if cfg.Multiline != nil {
+			if err := cfg.Multiline.Validate(); err != nil {
+				problems = append(problems, fmt.Errorf("log source %q: %w", cfg.Name, err))
+				continue
+			}
+			if !hasMultiline(cfg) {
+				problems = append(problems, fmt.Errorf("log source %q: multiline is only supported by file and tcp log sources", cfg.Name))
+				cfg.Multiline = nil
+			}
+		}
+		if cfg.MaxRecordsPerSecond < 0 {
+			problems = append(problems, fmt.Errorf("log source %q: max_records_per_second must be a positive integer", cfg.Name))
+			cfg.MaxRecordsPerSecond = 0
 		}
+		c = append(c, cfg)
 	}
 
 	return
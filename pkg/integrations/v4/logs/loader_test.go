@@ -471,3 +471,146 @@ func addFile(t *testing.T, dir, name, contents string) {
 	filePath := filepath.Join(dir, name)
 	require.NoError(t, ioutil.WriteFile(filePath, []byte(contents), 0666))
 }
+
+func TestDiscardWindowsEventLogCfgs(t *testing.T) {
+	cfgs := LogsCfg{
+		{Name: "file", File: "/var/log/app.log"},
+		{Name: "win-security", Winlog: &LogWinlogCfg{Channel: "Security"}},
+		{Name: "win-system", Winevtlog: &LogWinevtlogCfg{Channels: []string{"System"}}},
+	}
+
+	assert.Equal(t, cfgs, discardWindowsEventLogCfgs(cfgs, "windows"))
+	assert.Equal(t, LogsCfg{cfgs[0]}, discardWindowsEventLogCfgs(cfgs, "linux"))
+}
+
+func TestCfgLoader_parseYAML_Multiline(t *testing.T) {
+	content := []byte(`
+logs:
+  - name: valid
+    file: /var/log/app.log
+    multiline:
+      start_state: '^\d{4}-'
+      flush_timeout_ms: 500
+  - name: quoted-regex
+    file: /var/log/other.log
+    multiline:
+      start_state: '^"'
+  - name: unsupported-source
+    systemd: some-svc
+    multiline:
+      start_state: '^\d{4}-'
+`)
+
+	cfgs, err := NewFolderLoader(newTestConf("", disabledTroubleshootCfg, false), idnProvide, hostnameProvider).parseYAML(content)
+	require.NoError(t, err)
+	assert.Equal(t, LogsCfg{
+		{
+			Name:      "valid",
+			File:      "/var/log/app.log",
+			Multiline: &LogMultilineCfg{StartState: `^\d{4}-`, FlushTimeoutMs: 500},
+		},
+		{
+			Name:    "unsupported-source",
+			Systemd: "some-svc",
+		},
+	}, cfgs)
+}
+
+func TestCfgLoader_parseYAML_MaxRecordsPerSecond(t *testing.T) {
+	content := []byte(`
+logs:
+  - name: limited
+    file: /var/log/app.log
+    max_records_per_second: 100
+  - name: negative
+    file: /var/log/other.log
+    max_records_per_second: -5
+`)
+
+	cfgs, err := NewFolderLoader(newTestConf("", disabledTroubleshootCfg, false), idnProvide, hostnameProvider).parseYAML(content)
+	require.NoError(t, err)
+	assert.Equal(t, LogsCfg{
+		{Name: "limited", File: "/var/log/app.log", MaxRecordsPerSecond: 100},
+		{Name: "negative", File: "/var/log/other.log"},
+	}, cfgs)
+}
+
+func TestCfgLoader_loadFileCfgs_CountsParseErrors(t *testing.T) {
+	dir := t.TempDir()
+	malformed := filepath.Join(dir, "malformed.yml")
+	require.NoError(t, os.WriteFile(malformed, []byte("logs:\n  - name: foo\n   file: /file/path\n"), 0600))
+	valid := filepath.Join(dir, "valid.yml")
+	require.NoError(t, os.WriteFile(valid, []byte("logs:\n  - name: foo\n    file: /file/path\n"), 0600))
+	config.ParseErrors.FlushInterval()
+
+	loader := NewFolderLoader(newTestConf(dir, disabledTroubleshootCfg, false), idnProvide, hostnameProvider)
+	_, ok := loader.loadFileCfgs(malformed)
+	assert.False(t, ok)
+	_, ok = loader.loadFileCfgs(valid)
+	assert.True(t, ok)
+
+	assert.Equal(t, map[string]uint64{malformed: 1}, config.ParseErrors.FlushInterval())
+}
+
+func TestValidateFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+		return file
+	}
+	validate := func(files ...string) map[string][]error {
+		return ValidateFiles(files, config.LogForward{HomeDir: dir})
+	}
+
+	assert.Empty(t, validate(write("valid.yml", "logs:\n  - name: foo\n    file: /file/path\n")))
+	assert.Empty(t, validate(write("ignored.txt", "not: [yaml")))
+	malformed := write("malformed.yml", "logs:\n  - name: foo\n   file: /file/path\n")
+	assert.Len(t, validate(malformed)[malformed], 1)
+	missing := filepath.Join(dir, "missing.yml")
+	assert.Len(t, validate(missing)[missing], 1)
+
+	invalid := write("invalid.yaml", `
+logs:
+  - name: no-input
+  - name: quoted-regex
+    file: /var/log/other.log
+    multiline:
+      start_state: '^"'
+  - name: negative
+    file: /var/log/app.log
+    max_records_per_second: -5
+  - name: systemd-parser
+    systemd: sshd
+    parser: json
+  - name: undefined-parser
+    file: /var/log/app.log
+    parser: undefined
+  - name: bad-tcp
+    tcp:
+      uri: tcp://0.0.0.0
+      format: none
+`)
+	errs := validate(invalid)[invalid]
+	require.Len(t, errs, 6)
+	assert.EqualError(t, errs[0], "log source #1 lacks a name or an input")
+	assert.Contains(t, errs[1].Error(), `log source "quoted-regex": multiline:`)
+	assert.EqualError(t, errs[2], `log source "negative": max_records_per_second must be a positive integer`)
+	// the log forwarder configuration generation errors
+	assert.EqualError(t, errs[3], `log source "systemd-parser": parser only applies to file sources`)
+	assert.Contains(t, errs[4].Error(), `log source "undefined-parser": parser "undefined" is not defined`)
+	assert.Contains(t, errs[5].Error(), `log source "bad-tcp": `)
+}
+
+func TestValidateFiles_ExternalParsersFromOtherFile(t *testing.T) {
+	dir := t.TempDir()
+	parsersFile := filepath.Join(dir, "parsers.conf")
+	require.NoError(t, os.WriteFile(parsersFile, []byte("[PARSER]\n    Name custom\n    Format json\n"), 0600))
+	external := filepath.Join(dir, "external.yml")
+	require.NoError(t, os.WriteFile(external, []byte(
+		"logs:\n  - name: external\n    fluentbit:\n      config_file: /path/to/fb.conf\n      parsers_file: "+parsersFile+"\n"), 0600))
+	app := filepath.Join(dir, "app.yml")
+	require.NoError(t, os.WriteFile(app, []byte("logs:\n  - name: app\n    file: /var/log/app.log\n    parser: custom\n"), 0600))
+
+	assert.Empty(t, ValidateFiles([]string{external, app}, config.LogForward{HomeDir: dir}))
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/constants"
 	"github.com/newrelic/infrastructure-agent/pkg/entity/host"
@@ -29,6 +30,10 @@ import (
 
 var illog = log.WithComponent("integrations.Manager")
 
+// defaultFileEventsDebounce is the time a config file must stay unchanged before being reloaded, so the intermediate
+// writes of a file being saved are not loaded.
+const defaultFileEventsDebounce = 500 * time.Millisecond
+
 // runner-groups contexts indexed per config path, bundling lock to support concurrent access.
 type rgsPerPath struct {
 	l sync.RWMutex
@@ -100,6 +105,8 @@ type Manager struct {
 	handleConfig             configrequest.HandleFn
 	tracker                  *track.Tracker
 	idLookup                 host.IDLookup
+	// fileEventsDebounce is the time a config file must stay unchanged before its events are handled.
+	fileEventsDebounce time.Duration
 }
 
 // groupContext pairs a runner.Group with its cancellation context
@@ -160,6 +167,9 @@ type ManagerConfig struct {
 	TempDir string
 	// PassthroughEnvironment holds a copy of its homonym in config.Config.
 	PassthroughEnvironment []string
+	// ReloadCoalesceWindow is the time a config file must stay unchanged before it's reloaded. The default is used
+	// when it's not positive.
+	ReloadCoalesceWindow time.Duration
 }
 
 func NewManagerConfig(verbose int, tempDir string, features map[string]bool, passthroughEnvs, configFolders, definitionFolders []string) ManagerConfig {
@@ -210,6 +220,10 @@ func NewManager(
 		handleConfig:             configrequest.NewHandleFn(configEntryQ, terminateDefinitionQ, il, illog),
 		tracker:                  tracker,
 		idLookup:                 idLookup,
+		fileEventsDebounce:       defaultFileEventsDebounce,
+	}
+	if cfg.ReloadCoalesceWindow > 0 {
+		mgr.fileEventsDebounce = cfg.ReloadCoalesceWindow
 	}
 
 	// Loads all the configuration files from the provided ConfigPaths.
@@ -380,6 +394,11 @@ func (mgr *Manager) watchForFSChanges(ctx context.Context) {
 
 	wclog := illog.WithField("function", "watchForChanges")
 	wclog.Debug("Watching for integrations file changes.")
+
+	// the events of a file are merged and handled once it stops changing, so partial writes aren't loaded
+	coalescer := fs.NewEventCoalescer(mgr.fileEventsDebounce)
+	defer coalescer.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -387,7 +406,10 @@ func (mgr *Manager) watchForFSChanges(ctx context.Context) {
 			return
 
 		case event := <-mgr.watcher.Events:
-			mgr.handleFileEvent(ctx, &event)
+			coalescer.Add(event)
+
+		case event := <-coalescer.Events():
+			mgr.handleFileEvent(contextWithVerbose(ctx, mgr.managerConfig.Verbose), &event)
 
 		case err := <-mgr.watcher.Errors:
 			wclog.WithError(err).Debug("Error watching file changes.")
@@ -439,11 +461,10 @@ func (mgr *Manager) handleFileEvent(ctx context.Context, event *fsnotify.Event)
 		return
 	}
 
-	mgr.stopRunnerGroup(event.Name)
-
 	if isDelete {
 		if _, err := os.Stat(event.Name); os.IsNotExist(err) {
-			// if the file has been deleted, we don't continue trying to load configurations
+			// if the file has been deleted, its integrations are stopped
+			mgr.stopRunnerGroup(event.Name)
 			return
 		}
 
@@ -460,17 +481,20 @@ func (mgr *Manager) handleFileEvent(ctx context.Context, event *fsnotify.Event)
 		}
 
 	}
-	// creating new configuration and starting the new runner.Group instances
+	// creating new configuration and replacing the running runner.Group instances
 	mgr.runIntegrationFromPath(ctx, event.Name, isCreate, &elog, nil)
 }
 
+// runIntegrationFromPath loads the integrations of the config file and starts them, replacing the ones running from
+// the file. The running integrations are kept when the file can't be loaded, as it may be being edited.
 func (mgr *Manager) runIntegrationFromPath(ctx context.Context, cfgPath string, isCreate bool, elog *log.Entry, cmdFF *runner.CmdFF) {
 	cfg, err := mgr.configLoader.LoadFile(cfgPath)
 	if err != nil {
 		if err == v4Config.LegacyYAML {
 			elog.Debug("Skipping v3 integration.")
+			mgr.stopRunnerGroup(cfgPath)
 		} else {
-			elog.WithError(err).Warn("can't load integrations file. This may happen if you are editing a file and saving intermediate changes")
+			elog.WithError(err).Warn("can't load integrations file, the integrations already running from it are kept. This may happen if you are editing a file and saving intermediate changes")
 		}
 		return
 	}
@@ -481,10 +505,11 @@ func (mgr *Manager) runIntegrationFromPath(ctx context.Context, cfgPath string,
 
 	rc, err := mgr.loadRunnerGroup(cfgPath, cfg, cmdFF)
 	if err != nil {
-		elog.WithError(err).Warn("can't instantiate integrations from file. This may happen if you are editing a file and saving intermediate changes")
+		elog.WithError(err).Warn("can't instantiate integrations from file, the integrations already running from it are kept. This may happen if you are editing a file and saving intermediate changes")
 		return
 	}
 
+	mgr.stopRunnerGroup(cfgPath)
 	mgr.runners.Set(cfgPath, rc)
 	rc.start(ctx)
 }
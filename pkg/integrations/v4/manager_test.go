@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -485,6 +486,126 @@ func TestManager_HotReload_Delete(t *testing.T) {
 	require.NoError(t, emitter.ExpectTimeout("longtime", 100*time.Millisecond))
 }
 
+// countingLoader counts the config files loaded once the manager is created, as the initial loads don't call LoadFile.
+type countingLoader struct {
+	config.Loader
+	loads int32
+}
+
+func (l *countingLoader) LoadFile(file string) (config.YAML, error) {
+	atomic.AddInt32(&l.loads, 1)
+	return l.Loader.LoadFile(file)
+}
+
+func TestManager_HotReload_DebouncesPartialWrites(t *testing.T) {
+	skipIfWindows(t)
+	// GIVEN an integration
+	dir, err := tempFiles(map[string]string{
+		"integration.yaml": v4AppendableConfig,
+	})
+	require.NoError(t, err)
+	defer removeTempFiles(t, dir)
+
+	emitter := &testemit.RecordEmitter{}
+	loader := &countingLoader{Loader: config.NewPathLoader()}
+	mgr := NewManager(ManagerConfig{ConfigPaths: []string{dir}, PassthroughEnvironment: passthroughEnv}, loader, emitter, integration.ErrLookup, definitionQ, configEntryQ, track.NewTracker(nil), host.IDLookup{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mgr.Start(ctx)
+
+	metric := expectOneMetric(t, emitter, "hotreload-test")
+	require.Equal(t, "first", metric["value"])
+
+	// WHEN a new integration file is written in several chunks
+	newFile := filepath.Join(dir, "new-integration.yaml")
+	half := len(v4LongTimeConfig) / 2
+	require.NoError(t, ioutil.WriteFile(newFile, []byte(v4LongTimeConfig[:half]), 0o666))
+	require.NoError(t, fileAppend(newFile, v4LongTimeConfig[half:]))
+
+	// THEN the new integration is started
+	metric = expectOneMetric(t, emitter, "longtime")
+	require.Equal(t, "first", metric["value"])
+	// AND the file is loaded once it's completely written
+	assert.EqualValues(t, 1, atomic.LoadInt32(&loader.loads))
+}
+
+func TestManager_HotReload_CoalescesRapidWrites(t *testing.T) {
+	skipIfWindows(t)
+	// GIVEN an integration
+	dir, err := tempFiles(map[string]string{
+		"integration.yaml": v4AppendableConfig,
+	})
+	require.NoError(t, err)
+	defer removeTempFiles(t, dir)
+
+	// AND a manager with a custom reload coalesce window
+	emitter := &testemit.RecordEmitter{}
+	loader := &countingLoader{Loader: config.NewPathLoader()}
+	cfg := ManagerConfig{ConfigPaths: []string{dir}, PassthroughEnvironment: passthroughEnv, ReloadCoalesceWindow: 300 * time.Millisecond}
+	mgr := NewManager(cfg, loader, emitter, integration.ErrLookup, definitionQ, configEntryQ, track.NewTracker(nil), host.IDLookup{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mgr.Start(ctx)
+
+	metric := expectOneMetric(t, emitter, "hotreload-test")
+	require.Equal(t, "first", metric["value"])
+
+	// WHEN a new integration file is rewritten many times within the window
+	newFile := filepath.Join(dir, "new-integration.yaml")
+	for i := 0; i < 20; i++ {
+		require.NoError(t, ioutil.WriteFile(newFile, []byte(v4LongTimeConfig), 0o666))
+	}
+
+	// THEN the new integration is started
+	metric = expectOneMetric(t, emitter, "longtime")
+	require.Equal(t, "first", metric["value"])
+	// AND the file is loaded a single time
+	assert.EqualValues(t, 1, atomic.LoadInt32(&loader.loads))
+}
+
+func TestManager_HotReload_KeepsRunningOnInvalidEdit(t *testing.T) {
+	skipIfWindows(t)
+	// GIVEN an integration
+	dir, err := tempFiles(map[string]string{
+		"integration.yaml": v4AppendableConfig,
+	})
+	require.NoError(t, err)
+	defer removeTempFiles(t, dir)
+
+	emitter := &testemit.RecordEmitter{}
+	mgr := NewManager(ManagerConfig{ConfigPaths: []string{dir}, PassthroughEnvironment: passthroughEnv}, config.NewPathLoader(), emitter, integration.ErrLookup, definitionQ, configEntryQ, track.NewTracker(nil), host.IDLookup{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mgr.Start(ctx)
+
+	// THAT is correctly running
+	metric := expectOneMetric(t, emitter, "hotreload-test")
+	require.Equal(t, "first", metric["value"])
+	metric = expectOneMetric(t, emitter, "hotreload-test")
+	require.Equal(t, "unset", metric["value"])
+
+	// WHEN the integration file is saved with an invalid content
+	cfgFile := filepath.Join(dir, "integration.yaml")
+	require.NoError(t, ioutil.WriteFile(cfgFile, []byte(invalidFile), 0o600))
+
+	// THEN the integration keeps running, without being restarted
+	for deadline := time.Now().Add(3 * defaultFileEventsDebounce); time.Now().Before(deadline); {
+		metric = expectOneMetric(t, emitter, "hotreload-test")
+		require.Equal(t, "unset", metric["value"])
+	}
+
+	// WHEN the integration file is fixed
+	require.NoError(t, ioutil.WriteFile(cfgFile, []byte(v4AppendableConfig+"      - modifiedValue\n"), 0o600))
+
+	// THEN the integration is restarted with the new configuration
+	testhelpers.Eventually(t, 5*time.Second, func(t require.TestingT) {
+		firstMetric := expectOneMetric(t, emitter, "hotreload-test")
+		require.Equal(t, "first", firstMetric["value"])
+	})
+	metric = expectOneMetric(t, emitter, "hotreload-test")
+	require.Equal(t, "modifiedValue", metric["value"])
+}
+
 func TestManager_PassthroughEnv(t *testing.T) {
 	// GIVEN an integration
 	niDir, err := ioutil.TempDir("", "newrelic-integrations")
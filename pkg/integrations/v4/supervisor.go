@@ -5,9 +5,11 @@ package v4
 import (
 	ctx2 "context"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/newrelic/infrastructure-agent/internal/agent/id"
+	"github.com/newrelic/infrastructure-agent/internal/instrumentation"
 	"github.com/newrelic/infrastructure-agent/internal/integrations/v4/executor"
 	"github.com/newrelic/infrastructure-agent/pkg/backend/backoff"
 	"github.com/newrelic/infrastructure-agent/pkg/config"
@@ -21,6 +23,10 @@ import (
 //nolint:gochecknoglobals
 var maxBackOff = 5 * time.Minute
 
+//nolint:gochecknoglobals
+var pluginRestartsMetric = instrumentation.AgentMetrics.NewCounterVec(
+	"nria_plugin_restarts_total", "Restarts of the plugin processes run by a supervisor, as the log forwarder, after crashing.", "plugin")
+
 // cmdExitStatus is used to signal the outcome of the last process execution.
 type cmdExitStatus int
 
@@ -41,8 +47,28 @@ type Executor interface {
 
 type ParseProcessOutput func(line string) (sanitizedLine string, severity logrus.Level)
 
+// RestartPolicy defines how a crashed process is restarted.
+type RestartPolicy struct {
+	// MaxRestarts is the number of restarts allowed within Window after which the process is left
+	// stopped until a restart is requested. Zero means no limit.
+	MaxRestarts int
+	Window      time.Duration
+	// MaxBackOff caps the back-off duration between restarts. Zero means the default maxBackOff.
+	MaxBackOff time.Duration
+}
+
+// SupervisorState represents the state of the supervised process.
+type SupervisorState struct {
+	Running  bool
+	Stopped  bool // left stopped after exceeding the restart policy limit
+	Restarts int  // restarts within the restart policy window
+}
+
 // Supervisor is a wrapper for starting and supervising external processes.
 type Supervisor struct {
+	// name of the supervised plugin, as reported by the nria_plugin_restarts_total metric.
+	name string
+
 	listenAgentIDChanges   id.UpdateNotifyFn
 	hostnameChangeNotifier hostname.ChangeNotifier
 	listenRestartRequests  func(ctx ctx2.Context, signalRestart chan<- struct{})
@@ -58,6 +84,62 @@ type Supervisor struct {
 	postRunActions func(ctx ctx2.Context, exitStatus cmdExitStatus)
 
 	restartCh chan struct{}
+
+	restartPolicy RestartPolicy
+	crashes       []time.Time // crashes within the restart policy window
+	stateMtx      sync.RWMutex
+	state         SupervisorState
+}
+
+// State returns the current state of the supervised process.
+func (s *Supervisor) State() SupervisorState {
+	s.stateMtx.RLock()
+	defer s.stateMtx.RUnlock()
+
+	return s.state
+}
+
+func (s *Supervisor) setRunning(running bool) {
+	s.stateMtx.Lock()
+	defer s.stateMtx.Unlock()
+
+	s.state.Running = running
+}
+
+func (s *Supervisor) setStopped(stopped bool) {
+	s.stateMtx.Lock()
+	defer s.stateMtx.Unlock()
+
+	s.state.Stopped = stopped
+	if !stopped {
+		s.crashes = nil
+		s.state.Restarts = 0
+	}
+}
+
+// registerCrash records a process crash and returns whether it can be restarted according to the restart policy.
+func (s *Supervisor) registerCrash(now time.Time) (canRestart bool) {
+	s.stateMtx.Lock()
+	defer s.stateMtx.Unlock()
+
+	var crashes []time.Time
+	for _, crash := range s.crashes {
+		if now.Sub(crash) < s.restartPolicy.Window {
+			crashes = append(crashes, crash)
+		}
+	}
+	s.crashes = append(crashes, now)
+	s.state.Restarts = len(s.crashes)
+
+	return s.restartPolicy.MaxRestarts <= 0 || len(s.crashes) <= s.restartPolicy.MaxRestarts
+}
+
+func (s *Supervisor) maxBackOff() time.Duration {
+	if s.restartPolicy.MaxBackOff > 0 {
+		return s.restartPolicy.MaxBackOff
+	}
+
+	return maxBackOff
 }
 
 func (s *Supervisor) Restart() error {
@@ -97,6 +179,7 @@ func (s *Supervisor) Run(ctx ctx2.Context) {
 		case <-s.restartCh:
 			cancel()
 			<-exitStatus // Wait for the process to exit.
+			s.setStopped(false)
 		case change := <-hostnameUpdateCh:
 			// make sure to only restart if the hostname change includes the short hostname
 			if change.What == hostname.Short || change.What == hostname.ShortAndFull {
@@ -109,13 +192,34 @@ func (s *Supervisor) Run(ctx ctx2.Context) {
 				return
 			default:
 			}
-			if status == statusSuccess ||
-				time.Since(startTime) > maxBackOff {
+			if status == statusSuccess {
 				retryBO.Reset()
 				continue
 			}
+			// A process that kept running longer than the max back-off is restarted without backing off further,
+			// but its crash still counts for the restart policy.
+			if time.Since(startTime) > s.maxBackOff() {
+				retryBO.Reset()
+			}
+
+			if !s.registerCrash(time.Now()) {
+				s.log.
+					WithField("maxRestarts", s.restartPolicy.MaxRestarts).
+					WithField("window", s.restartPolicy.Window).
+					Error("Process crashed too many times, it won't be restarted until the agent or the process is restarted.")
+				s.setStopped(true)
+				select {
+				case <-s.restartCh:
+					s.setStopped(false)
+					retryBO.Reset()
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
 
-			retryBOAfter := retryBO.DurationWithMax(maxBackOff)
+			pluginRestartsMetric.Inc(s.name)
+			retryBOAfter := retryBO.DurationWithMax(s.maxBackOff())
 			s.log.WithField("backOff duration", retryBOAfter).Debug("Supervisor backOff.")
 
 			s.backOff(ctx, retryBOAfter)
@@ -131,7 +235,9 @@ func (s *Supervisor) startBackgroundProcess(ctx ctx2.Context, executor Executor)
 		if s.preRunActions != nil {
 			s.preRunActions(ctx)
 		}
+		s.setRunning(true)
 		status := s.startProcess(ctx, executor)
+		s.setRunning(false)
 		if s.postRunActions != nil {
 			s.postRunActions(ctx, status)
 		}
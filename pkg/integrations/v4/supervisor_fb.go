@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -30,13 +31,16 @@ const (
 	FbConfTempFolderNameDefault      = "fb"
 	temporaryFolderPermissions       = 0o755
 	MaxNumberOfFbConfigTempFiles int = 50
+	fbVersionTimeout                 = 10 * time.Second
 )
 
 var (
 	//nolint:gochecknoglobals
-	sFBLogger              = log.WithComponent("integrations.Supervisor").WithField("process", "log-forwarder")
-	luaFilterTempFileRegex = regexp.MustCompile(`nr_fb_lua_filter\d+`)
-	errFbNotAvailable      = errors.New("cannot build FB executer: FB not available")
+	sFBLogger                 = log.WithComponent("integrations.Supervisor").WithField("process", "log-forwarder")
+	fbReferencedTempFileRegex = regexp.MustCompile(`[^\s,]*nr_fb_(?:lua_filter|parsers)\d+`)
+	errFbNotAvailable         = errors.New("cannot build FB executer: FB not available")
+	errFbVersionNotFound      = errors.New("cannot find FB version in its output")
+	fbVersionRegex            = regexp.MustCompile(`Fluent Bit v?(\d+\.\d+\.\d+\S*)`)
 )
 
 // listError error representing a list of errors.
@@ -73,7 +77,11 @@ type fBSupervisorConfig struct {
 	FluentBitParsersPath string
 	FluentBitVerbose     bool
 	ConfTemporaryFolder  string
-	ffRetriever          feature_flags.Retriever
+	RestartPolicy        RestartPolicy
+	// ConfigReloadCoalesceWindow is the time the configuration must stay unchanged before the log forwarder is
+	// restarted to reload it.
+	ConfigReloadCoalesceWindow time.Duration
+	ffRetriever                feature_flags.Retriever
 }
 
 // NewFBSupervisorConfig creates a new fBSupervisorConfig that will contain the FF retriever
@@ -122,6 +130,29 @@ func (c *fBSupervisorConfig) IsLogForwarderAvailable() bool {
 	return true
 }
 
+// FluentBitVersion returns the version of the FluentBit executable used by the log forwarder.
+func (c *fBSupervisorConfig) FluentBitVersion() (string, error) {
+	fluentBitExePath := c.getFbPath()
+	if _, err := os.Stat(fluentBitExePath); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := ctx2.WithTimeout(ctx2.Background(), fbVersionTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, fluentBitExePath, "--version").Output()
+	if err != nil {
+		return "", errors.Wrap(err, "cannot run Fluent Bit to retrieve its version")
+	}
+
+	match := fbVersionRegex.FindSubmatch(output)
+	if match == nil {
+		return "", errFbVersionNotFound
+	}
+
+	return string(match[1]), nil
+}
+
 func (c *fBSupervisorConfig) getFbPath() string {
 	// manually set conf always has precedence
 	if c.fluentBitExePath != "" {
@@ -148,9 +179,10 @@ var ObserverName = "LogForwarderSupervisor" // nolint:gochecknoglobals
 // NewFBSupervisor builds a Fluent Bit supervisor which forwards the output to agent logs.
 func NewFBSupervisor(fbIntCfg fBSupervisorConfig, cfgLoader *logs.CfgLoader, agentIDNotifier id.UpdateNotifyFn, notifier hostname.ChangeNotifier, sendEventFn SendEventFn) *Supervisor {
 	return &Supervisor{
+		name:                   "log_forwarder",
 		listenAgentIDChanges:   agentIDNotifier,
 		hostnameChangeNotifier: notifier,
-		listenRestartRequests:  listenRestartRequests(cfgLoader),
+		listenRestartRequests:  listenRestartRequests(cfgLoader, fbIntCfg.ConfigReloadCoalesceWindow),
 		getBackOffTimer:        time.NewTimer,
 		handleErrs:             handleErrors(sFBLogger),
 		buildExecutor:          buildFbExecutor(fbIntCfg, cfgLoader),
@@ -160,6 +192,7 @@ func NewFBSupervisor(fbIntCfg fBSupervisorConfig, cfgLoader *logs.CfgLoader, age
 		postRunActions:         fbPostRunActions(sendEventFn),
 		parseOutputFn:          logs.ParseFBOutput,
 		restartCh:              make(chan struct{}, 1),
+		restartPolicy:          fbIntCfg.RestartPolicy,
 	}
 }
 
@@ -283,18 +316,23 @@ func removeFbConfigTempFiles(tempDir string, maxNumberOfFbConfigTempFiles int) (
 		configTempFilesToRemove = append(configTempFilesToRemove, fbConfigTempFiles[i].Name())
 	}
 
-	// extract lua filter filenames from config temp files to remove
+	// extract lua filter and parsers filenames from config temp files to remove
 	for _, configTempFileToRemove := range configTempFilesToRemove {
-		if fbLuaFilterTempFilenames, err := extractLuaFilterFilenames(tempDir, configTempFileToRemove); err != nil {
+		if fbReferencedTempFilenames, err := extractReferencedTempFilenames(tempDir, configTempFileToRemove); err != nil {
 			listErrors.Add(err)
 		} else {
-			configTempFilesToRemove = append(configTempFilesToRemove, fbLuaFilterTempFilenames...)
+			configTempFilesToRemove = append(configTempFilesToRemove, fbReferencedTempFilenames...)
 		}
 	}
 
-	// remove all config and lua filter temp files from temporary directory
+	// remove all config, lua filter and parsers temp files. The lua filter and parsers ones are referenced by their
+	// absolute path, as they are created in the system temporary directory.
 	for _, configTempFileToRemove := range configTempFilesToRemove {
-		if err := os.Remove(filepath.Join(tempDir, configTempFileToRemove)); err != nil {
+		path := configTempFileToRemove
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(tempDir, path)
+		}
+		if err := os.Remove(path); err != nil {
 			listErrors.Add(err)
 		} else {
 			removedConfigTempFiles = append(removedConfigTempFiles, configTempFileToRemove)
@@ -322,16 +360,14 @@ func readFbConfigTempFilesFromTempDirectory(tempDir string) ([]fs.DirEntry, erro
 	return fbConfigTempFiles, nil
 }
 
-// extract lua filter temp filenames referenced by fbConfigTempFilename.
-func extractLuaFilterFilenames(tempDir string, fbConfigTempFilename string) ([]string, error) {
+// extract lua filter and parsers temp filenames referenced by fbConfigTempFilename.
+func extractReferencedTempFilenames(tempDir string, fbConfigTempFilename string) ([]string, error) {
 	fbConfigTempFileContent, err := os.ReadFile(filepath.Join(tempDir, fbConfigTempFilename))
 	if err != nil {
 		return nil, fmt.Errorf("failed reading config temp file: %s error: %w", fbConfigTempFilename, err)
 	}
 
-	luaFilterTempFilenames := luaFilterTempFileRegex.FindAllString(string(fbConfigTempFileContent), -1)
-
-	return luaFilterTempFilenames, nil
+	return fbReferencedTempFileRegex.FindAllString(string(fbConfigTempFileContent), -1), nil
 }
 
 // SupervisorEvent will be used to create an InfrastructureEvent when fb start/stop.
@@ -353,8 +389,8 @@ func NewSupervisorEvent(eventSummary string, status cmdExitStatus) *SupervisorEv
 	}
 }
 
-func listenRestartRequests(cfgLoader *logs.CfgLoader) func(ctx ctx2.Context, signalRestart chan<- struct{}) {
-	cw := logs.NewConfigChangesWatcher(cfgLoader.GetConfigDir())
+func listenRestartRequests(cfgLoader *logs.CfgLoader, window time.Duration) func(ctx ctx2.Context, signalRestart chan<- struct{}) {
+	cw := logs.NewConfigChangesWatcher(cfgLoader.GetConfigDir(), window)
 	return func(ctx ctx2.Context, signalRestart chan<- struct{}) {
 		cw.Watch(ctx, signalRestart)
 	}
@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/newrelic/infrastructure-agent/internal/agent/cmdchannel/fflag"
 	"github.com/newrelic/infrastructure-agent/internal/feature_flags"
@@ -100,6 +101,32 @@ func TestFBSupervisorConfig_IsLogForwarderAvailable(t *testing.T) {
 	}
 }
 
+func TestFBSupervisorConfig_FluentBitVersion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake fluent-bit binary is a shell script")
+	}
+
+	tmpDir := t.TempDir()
+	fakeFbPath := filepath.Join(tmpDir, "fluent-bit")
+	fakeFb := "#!/bin/sh\necho 'Fluent Bit v2.0.8'\necho 'Git commit: 9a9d7b2d6a2e6fb8aaddd4aa2a25cc0a7cfbb4b1'\n"
+	require.NoError(t, os.WriteFile(fakeFbPath, []byte(fakeFb), 0o755))
+
+	cfg := fBSupervisorConfig{fluentBitExePath: fakeFbPath}
+	version, err := cfg.FluentBitVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.8", version)
+
+	// unexpected output
+	require.NoError(t, os.WriteFile(fakeFbPath, []byte("#!/bin/sh\necho 'unknown'\n"), 0o755))
+	_, err = cfg.FluentBitVersion()
+	assert.Equal(t, errFbVersionNotFound, err)
+
+	// absent binary
+	cfg = fBSupervisorConfig{fluentBitExePath: filepath.Join(tmpDir, "non-existing")}
+	_, err = cfg.FluentBitVersion()
+	assert.True(t, os.IsNotExist(err))
+}
+
 func TestFBSupervisorConfig_LicenseKeyShouldBePassedAsEnvVar(t *testing.T) {
 	t.Parallel()
 
@@ -244,6 +271,29 @@ func TestRemoveFbConfigTempFiles(t *testing.T) {
 	}
 }
 
+func TestRemoveFbConfigTempFiles_ReferencedByPath(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	systemTmpDir := t.TempDir()
+	parsersFile := filepath.Join(systemTmpDir, "nr_fb_parsers123")
+	luaFilterFile := filepath.Join(systemTmpDir, "nr_fb_lua_filter456")
+	addFile(t, systemTmpDir, "nr_fb_parsers123", "")
+	addFile(t, systemTmpDir, "nr_fb_lua_filter456", "")
+	addFile(t, tmpDir, "nr_fb_config1", fmt.Sprintf("[SERVICE]\n    Parsers_File %s\n[FILTER]\n    script %s\n", parsersFile, luaFilterFile))
+	addFile(t, tmpDir, "nr_fb_config2", "")
+	// the second config file is the most recent one
+	require.NoError(t, os.Chtimes(filepath.Join(tmpDir, "nr_fb_config1"), time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+
+	removed, err := removeFbConfigTempFiles(tmpDir, 1)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"nr_fb_config1", parsersFile, luaFilterFile}, removed)
+	assert.NoFileExists(t, parsersFile)
+	assert.NoFileExists(t, luaFilterFile)
+	assert.FileExists(t, filepath.Join(tmpDir, "nr_fb_config2"))
+}
+
 func addFile(t *testing.T, dir, name, contents string) {
 	t.Helper()
 	filePath := filepath.Join(dir, name)
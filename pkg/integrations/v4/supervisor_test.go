@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/newrelic/infrastructure-agent/pkg/integrations/v4/logs"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
 	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/hostname"
 
 	"github.com/newrelic/infrastructure-agent/internal/agent/id"
@@ -147,6 +148,10 @@ type SupervisorMock struct {
 	testCalls        chan string
 	hostnameNotifier hostname.ChangeNotifier
 	hostnameUpdateCh chan<- hostname.ChangeNotification
+
+	restartPolicy RestartPolicy
+	backOffs      []time.Duration
+	supervisor    *Supervisor
 }
 
 func NewSupervisorMock(mock hostname.ChangeNotifier) *SupervisorMock {
@@ -180,7 +185,10 @@ func (n *NotifierMock) UpdateHostname(testCalls chan<- string) {
 }
 
 func (sm *SupervisorMock) Start(ctx ctx2.Context) {
-	testSupervisor := NewSupervisorFromMock(sm)
+	testSupervisor := sm.supervisor
+	if testSupervisor == nil {
+		testSupervisor = NewSupervisorFromMock(sm)
+	}
 
 	sm.hostnameNotifier.AddObserver("test", sm.hostnameUpdateCh)
 	sm.testCalls <- "supervisor_addobserver"
@@ -204,6 +212,8 @@ func NewSupervisorFromMock(supervisorMock *SupervisorMock) *Supervisor {
 		parseOutputFn:          logs.ParseFBOutput,
 		hostnameChangeNotifier: supervisorMock.hostnameNotifier,
 		restartCh:              make(chan struct{}, 1),
+		restartPolicy:          supervisorMock.restartPolicy,
+		log:                    log.WithComponent("integrations.Supervisor"),
 	}
 }
 
@@ -235,6 +245,7 @@ func (sm *SupervisorMock) handleErrs(ctx ctx2.Context, errs <-chan error) (hadEr
 }
 
 func (sm *SupervisorMock) getBackOffTimer(duration time.Duration) *time.Timer {
+	sm.backOffs = append(sm.backOffs, duration)
 	sm.testCalls <- "with_backoff"
 	return time.NewTimer(0)
 }
@@ -269,3 +280,125 @@ func TestSupervisor_RestartOnHostnameChange(t *testing.T) {
 	}
 	assertNoTestCalls(t, supervisorMock)
 }
+
+func TestSupervisor_RestartPolicy(t *testing.T) {
+	notifierMock := NewNotifierMock()
+	supervisorMock := NewSupervisorMock(notifierMock)
+	supervisorMock.restartPolicy = RestartPolicy{
+		MaxRestarts: 2,
+		Window:      time.Minute,
+		MaxBackOff:  time.Second,
+	}
+	supervisorMock.supervisor = NewSupervisorFromMock(supervisorMock)
+
+	ctx, cancel := ctx2.WithCancel(ctx2.Background())
+	defer cancel()
+
+	go supervisorMock.Start(ctx)
+
+	for _, expectedTestCalls := range []string{
+		"supervisor_addobserver",
+		"build_executor",
+		"handle_errs",
+	} {
+		assertTestCalls(t, supervisorMock, expectedTestCalls)
+	}
+
+	// crashes within the restart limit are restarted after a back-off
+	for i := 0; i < 2; i++ {
+		supervisorMock.executor.triggerFakeError()
+		for _, expectedTestCalls := range []string{
+			"executor_done",
+			"with_backoff",
+			"build_executor",
+			"handle_errs",
+		} {
+			assertTestCalls(t, supervisorMock, expectedTestCalls)
+		}
+	}
+	for _, backOff := range supervisorMock.backOffs {
+		assert.LessOrEqual(t, backOff, time.Second)
+	}
+
+	// exceeding the limit leaves the process stopped
+	supervisorMock.executor.triggerFakeError()
+	assertTestCalls(t, supervisorMock, "executor_done")
+	assert.Eventually(t, func() bool {
+		return supervisorMock.supervisor.State().Stopped
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 3, supervisorMock.supervisor.State().Restarts)
+	assertNoTestCalls(t, supervisorMock)
+
+	// a restart request starts the process again
+	assert.NoError(t, supervisorMock.supervisor.Restart())
+	for _, expectedTestCalls := range []string{
+		"build_executor",
+		"handle_errs",
+	} {
+		assertTestCalls(t, supervisorMock, expectedTestCalls)
+	}
+	assert.Equal(t, SupervisorState{Running: true}, supervisorMock.supervisor.State())
+}
+
+func TestSupervisor_RestartPolicy_LongRunningCrashes(t *testing.T) {
+	notifierMock := NewNotifierMock()
+	supervisorMock := NewSupervisorMock(notifierMock)
+	// every process run lasts longer than the max back-off
+	supervisorMock.restartPolicy = RestartPolicy{
+		MaxRestarts: 2,
+		Window:      time.Minute,
+		MaxBackOff:  time.Nanosecond,
+	}
+	supervisorMock.supervisor = NewSupervisorFromMock(supervisorMock)
+
+	ctx, cancel := ctx2.WithCancel(ctx2.Background())
+	defer cancel()
+
+	go supervisorMock.Start(ctx)
+
+	for _, expectedTestCalls := range []string{
+		"supervisor_addobserver",
+		"build_executor",
+		"handle_errs",
+	} {
+		assertTestCalls(t, supervisorMock, expectedTestCalls)
+	}
+
+	for i := 0; i < 2; i++ {
+		supervisorMock.executor.triggerFakeError()
+		for _, expectedTestCalls := range []string{
+			"executor_done",
+			"with_backoff",
+			"build_executor",
+			"handle_errs",
+		} {
+			assertTestCalls(t, supervisorMock, expectedTestCalls)
+		}
+	}
+
+	// crashes are counted regardless of how long the process was running
+	supervisorMock.executor.triggerFakeError()
+	assertTestCalls(t, supervisorMock, "executor_done")
+	assert.Eventually(t, func() bool {
+		return supervisorMock.supervisor.State().Stopped
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 3, supervisorMock.supervisor.State().Restarts)
+	assertNoTestCalls(t, supervisorMock)
+}
+
+func TestSupervisor_RegisterCrash(t *testing.T) {
+	s := &Supervisor{restartPolicy: RestartPolicy{MaxRestarts: 2, Window: time.Minute}}
+	now := time.Now()
+
+	assert.True(t, s.registerCrash(now))
+	assert.True(t, s.registerCrash(now.Add(10*time.Second)))
+	assert.False(t, s.registerCrash(now.Add(20*time.Second)))
+	// crashes out of the window are not taken into account
+	assert.True(t, s.registerCrash(now.Add(75*time.Second)))
+	assert.Equal(t, 2, s.State().Restarts)
+
+	unlimited := &Supervisor{}
+	for i := 0; i < 10; i++ {
+		assert.True(t, unlimited.registerCrash(now))
+	}
+}
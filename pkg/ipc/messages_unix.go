@@ -13,4 +13,5 @@ const (
 	EnableVerboseLogging Message = signals.NotificationStr
 	Stop                 Message = signals.GracefulStopStr
 	Shutdown             Message = signals.GracefulShutdownStr
+	ReloadConfig         Message = signals.ReloadStr
 )
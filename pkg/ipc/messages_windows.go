@@ -9,4 +9,5 @@ const (
 	EnableVerboseLogging Message = "notification"
 	Stop                 Message = "stop"
 	Shutdown             Message = "shutdown"
+	ReloadConfig         Message = "reload_config"
 )
@@ -86,9 +86,20 @@ func EnableSmartVerboseMode(cachedEntryLimit int) {
 
 	w.smartVerboseMode = true
 	w.setLogCache(cachedEntryLimit)
+	w.cachedEntryCounter = 0
 	SetLevel(logrus.DebugLevel)
 }
 
+// DisableSmartVerboseMode stops caching the debug entries, discarding the cached ones. The log level is kept.
+func DisableSmartVerboseMode() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.smartVerboseMode = false
+	w.setLogCache(0)
+	w.cachedEntryCounter = 0
+}
+
 func Instrument(m instrumentation.Measure) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -92,6 +92,24 @@ func (l logEntryMatcher) match(entry *logrus.Entry) bool {
 		if _, ok := l[key][value]; ok {
 			return true
 		}
+
+		if l.matchRegexp(key, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRegexp returns true if a string value is matched by any of the regular expression filters of the key.
+func (l logEntryMatcher) matchRegexp(key string, value interface{}) bool {
+	if _, isString := value.(string); !isString {
+		return false
+	}
+
+	for element := range l[key] {
+		if _, isRegexp := element.(config.LogFilterRegexp); isRegexp && config.LogFilterMatches(element, value) {
+			return true
+		}
 	}
 	return false
 }
@@ -100,7 +118,7 @@ func (l logEntryMatcher) match(entry *logrus.Entry) bool {
 // as a key in a map.
 func isTypeSupported(obj interface{}) bool {
 	switch obj.(type) {
-	case string, int:
+	case string, int, config.LogFilterRegexp:
 		return true
 	default:
 		return false
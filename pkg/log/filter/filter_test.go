@@ -4,9 +4,11 @@
 package filter
 
 import (
+	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
+	"regexp"
 	"testing"
 )
 
@@ -200,6 +202,28 @@ func TestFormat(t *testing.T) {
 				"time=\"0001-01-01T00:00:00Z\" level=panic trace=supervisor\n",
 			},
 		},
+		{
+			Name: "WhenRegexpValueProvided_FiltersMatchingValues",
+			config: FilteringFormatterConfig{
+				ExcludeFilters: map[string][]interface{}{
+					"component": {
+						config.LogFilterRegexp{Regexp: regexp.MustCompile("^supervisor.*")},
+					},
+				},
+			},
+			Entries: []*logrus.Entry{
+				logrus.WithField("component", "supervisor-fluent-bit"),
+				logrus.WithField("component", "supervisor"),
+				logrus.WithField("component", "my-supervisor"),
+				logrus.WithField("component", 1),
+			},
+			ExpectedLines: []string{
+				"",
+				"",
+				"time=\"0001-01-01T00:00:00Z\" level=panic component=my-supervisor\n",
+				"time=\"0001-01-01T00:00:00Z\" level=panic component=1\n",
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -223,3 +223,32 @@ func BenchmarkFacadeFunc(b *testing.B) {
 		})
 	}
 }
+
+func TestDisableSmartVerboseMode(t *testing.T) {
+	var output bytes.Buffer
+	SetOutput(&output)
+	EnableSmartVerboseMode(2)
+
+	log := WithComponent("LogTester")
+	log.Debug("Cached message")
+	log.Debug("Another cached message")
+	log.Debug("Overflowing cached message")
+
+	// WHEN smart verbose mode is disabled
+	DisableSmartVerboseMode()
+	log.Debug("Debug message")
+
+	// THEN debug entries are written instead of cached, and the cached ones discarded
+	assert.Empty(t, w.logCache)
+	written := output.String()
+	assert.Contains(t, written, "Debug message")
+	assert.NotContains(t, written, "Cached message")
+
+	// AND enabling it again honors the new entry limit
+	EnableSmartVerboseMode(1)
+	log.Debug("Message 1")
+	log.Debug("Message 2")
+	assert.Len(t, w.logCache, 1)
+	assert.Contains(t, w.logCache[0].msg, "Message 2")
+	DisableSmartVerboseMode()
+}
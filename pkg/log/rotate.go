@@ -36,6 +36,8 @@ type FileWithRotationConfig struct {
 	MaxSizeInBytes  int64
 	Compress        bool
 	MaxFiles        int
+	// MaxTotalSizeInBytes caps the size of the current plus the rotated files. Zero value disables it.
+	MaxTotalSizeInBytes int64
 }
 
 // FileWithRotation decorates a file with rotation mechanism.
@@ -181,16 +183,16 @@ func (f *FileWithRotation) asyncPostRotateActions(rotatedFile string) {
 		if f.cfg.Compress {
 			if err := f.compress(rotatedFile, rLog); err != nil {
 				rLog.WithError(err).Error("Failed to compress rotated log file")
-
-				return
-			}
-			// Clean file that was compressed.
-			if err := os.Remove(rotatedFile); err != nil {
+			} else if err := os.Remove(rotatedFile); err != nil {
+				// Clean file that was compressed.
 				rLog.WithError(err).Error("Failed to clean rotated log file after was compressed")
-
-				return
 			}
 		}
+
+		// Clean old files if MaxTotalSizeInBytes is exceeded, once the rotated file size is the compressed one.
+		if err := f.purgeFilesBySize(rLog); err != nil {
+			rLog.WithError(err).Error("Failed to clean old rotated log files exceeding the total size")
+		}
 	}()
 }
 
@@ -248,16 +250,89 @@ func (f *FileWithRotation) purgeFiles(log Entry) error {
 
 	dir := filepath.Dir(f.cfg.File)
 
+	filteredFiles, err := f.rotatedFiles()
+	if err != nil {
+		return err
+	}
+
+	if len(filteredFiles) <= f.cfg.MaxFiles {
+		// Nothing to do.
+		return nil
+	}
+
+	// Remove older files.
+	for _, file := range filteredFiles[f.cfg.MaxFiles:] {
+		fileName := filepath.Join(dir, file.Name())
+
+		log.Debugf("Purging old file: %s", fileName)
+
+		if err := os.Remove(fileName); err != nil {
+			return fmt.Errorf("failed to purge old rotated files, error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// purgeFilesBySize will remove older files in case the size of the current plus the rotated files exceeds
+// MaxTotalSizeInBytes. The current file is never removed.
+func (f *FileWithRotation) purgeFilesBySize(log Entry) error {
+	if f.cfg.MaxTotalSizeInBytes <= 0 {
+		// Nothing to do.
+		return nil
+	}
+
+	dir := filepath.Dir(f.cfg.File)
+
+	var totalSize int64
+	if current, err := os.Stat(f.cfg.File); err == nil {
+		totalSize = current.Size()
+	}
+
+	if totalSize > f.cfg.MaxTotalSizeInBytes {
+		log.Warnf("Current log file: %s size: '%db' exceeds the maximum total size: '%db'",
+			f.cfg.File, totalSize, f.cfg.MaxTotalSizeInBytes)
+	}
+
+	filteredFiles, err := f.rotatedFiles()
+	if err != nil {
+		return err
+	}
+
+	// Keep the newest files that fit the total size, remove the rest.
+	for _, file := range filteredFiles {
+		totalSize += file.Size()
+		if totalSize <= f.cfg.MaxTotalSizeInBytes {
+			continue
+		}
+
+		fileName := filepath.Join(dir, file.Name())
+
+		log.Debugf("Purging old file: %s", fileName)
+
+		if err := os.Remove(fileName); err != nil {
+			return fmt.Errorf("failed to purge old rotated files, error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rotatedFiles returns the rotated files, matching the filename pattern, sorted by last modification time, the
+// newest first.
+func (f *FileWithRotation) rotatedFiles() ([]fs.FileInfo, error) {
+	dir := filepath.Dir(f.cfg.File)
+
 	globPattern := f.generateFileNameGlob()
 	// Get only files that match the pattern. Add star at the end to match also compressed files.
 	matches, err := filepath.Glob(filepath.Join(dir, globPattern+"*"))
 	if err != nil {
-		return fmt.Errorf("could not retrieve files matching the pattern: %s, error: %w", globPattern, err)
+		return nil, fmt.Errorf("could not retrieve files matching the pattern: %s, error: %w", globPattern, err)
 	}
 
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
-		return fmt.Errorf("failed to purge old rotated files, error: %w", err)
+		return nil, fmt.Errorf("failed to purge old rotated files, error: %w", err)
 	}
 
 	filteredFiles := make([]fs.FileInfo, 0)
@@ -271,28 +346,12 @@ func (f *FileWithRotation) purgeFiles(log Entry) error {
 		}
 	}
 
-	if len(filteredFiles) <= f.cfg.MaxFiles {
-		// Nothing to do.
-		return nil
-	}
-
 	// Sort files by last modification time, the newest first.
 	sort.Slice(filteredFiles, func(i, j int) bool {
 		return filteredFiles[i].ModTime().After(filteredFiles[j].ModTime())
 	})
 
-	// Remove older files.
-	for _, file := range filteredFiles[f.cfg.MaxFiles:] {
-		fileName := filepath.Join(dir, file.Name())
-
-		log.Debugf("Purging old file: %s", fileName)
-
-		if err := os.Remove(fileName); err != nil {
-			return fmt.Errorf("failed to purge old rotated files, error: %w", err)
-		}
-	}
-
-	return nil
+	return filteredFiles, nil
 }
 
 // generateFileName will use the specified pattern to create a new filename when the current file is rotated.
@@ -663,6 +663,81 @@ func TestShouldNotPurgeFiles(t *testing.T) {
 	assert.Equal(t, files[1].Name(), filepath.Base(rotatedFile))
 }
 
+func TestPurgeFilesBySize(t *testing.T) {
+	tmp := t.TempDir()
+
+	logFile := filepath.Join(tmp, "newrelic-infra.log")
+
+	// GIVEN a 10 bytes log file and 4 rotated files of 10 bytes each
+	require.NoError(t, ioutil.WriteFile(logFile, []byte(strings.Repeat("a", 10)), filePerm))
+
+	rotatedFiles := []string{
+		fmt.Sprintf("%s.%d.bk.gz", logFile, 1),
+		fmt.Sprintf("%s.%d.bk", logFile, 2),
+		fmt.Sprintf("%s.%d.bk.gz", logFile, 3),
+		fmt.Sprintf("%s.%d.bk", logFile, 4),
+	}
+
+	now := time.Now()
+	for i, rotatedFile := range rotatedFiles {
+		require.NoError(t, ioutil.WriteFile(rotatedFile, []byte(strings.Repeat("a", 10)), filePerm))
+		modTime := now.Add(time.Duration(i-len(rotatedFiles)) * time.Minute)
+		require.NoError(t, os.Chtimes(rotatedFile, modTime, modTime))
+	}
+
+	// WITH a MaxTotalSizeInBytes config of 35 bytes and no MaxFiles
+	cfg := FileWithRotationConfig{
+		File:                logFile,
+		FileNamePattern:     "newrelic-infra.log.hh.bk",
+		MaxTotalSizeInBytes: 35,
+	}
+
+	rotator := NewFileWithRotation(cfg)
+
+	// WHEN purgeFilesBySize
+	err := rotator.purgeFilesBySize(WithComponent("test"))
+	assert.NoError(t, err)
+
+	// THEN the newest rotated files fitting the total size along the current file remain.
+	files, err := ioutil.ReadDir(tmp)
+	assert.NoError(t, err)
+
+	require.Len(t, files, 3)
+	assert.Equal(t, filepath.Base(logFile), files[0].Name())
+	assert.Equal(t, filepath.Base(rotatedFiles[2]), files[1].Name())
+	assert.Equal(t, filepath.Base(rotatedFiles[3]), files[2].Name())
+}
+
+func TestPurgeFilesBySize_CurrentFileExceedsMaxTotalSize(t *testing.T) {
+	tmp := t.TempDir()
+
+	logFile := filepath.Join(tmp, "newrelic-infra.log")
+	rotatedFile := fmt.Sprintf("%s.%d.bk", logFile, 1)
+
+	// GIVEN a current log file bigger than the max total size and a rotated file
+	require.NoError(t, ioutil.WriteFile(logFile, []byte(strings.Repeat("a", 50)), filePerm))
+	require.NoError(t, ioutil.WriteFile(rotatedFile, []byte(strings.Repeat("a", 5)), filePerm))
+
+	cfg := FileWithRotationConfig{
+		File:                logFile,
+		FileNamePattern:     "newrelic-infra.log.hh.bk",
+		MaxTotalSizeInBytes: 20,
+	}
+
+	rotator := NewFileWithRotation(cfg)
+
+	// WHEN purgeFilesBySize
+	err := rotator.purgeFilesBySize(WithComponent("test"))
+	assert.NoError(t, err)
+
+	// THEN rotated files are removed but the current file is kept
+	files, err := ioutil.ReadDir(tmp)
+	assert.NoError(t, err)
+
+	require.Len(t, files, 1)
+	assert.Equal(t, filepath.Base(logFile), files[0].Name())
+}
+
 // TestWithLogger will set a FileWithRotation to the logger and trigger rotation functionality.
 // If global logger will be used inside FileWithRotation it can lead to a deadlock.
 func TestWithLogger(t *testing.T) {
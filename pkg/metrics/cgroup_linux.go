@@ -0,0 +1,127 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+)
+
+// cgroupV2Unlimited value of the cgroup v2 memory.max and cpu.max files when no limit is set.
+const cgroupV2Unlimited = "max"
+
+func cgroupLimitsReader() func() (cgroupLimits, error) {
+	return readCgroupLimits
+}
+
+// readCgroupLimits reads the memory and CPU limits of the cgroup mounted at the cgroup root of the host sys tree.
+// Within a container, it is the cgroup of the container. The cgroup v2 unified hierarchy is used when mounted
+// there, falling back to the cgroup v1 memory and cpu controllers hierarchies otherwise.
+func readCgroupLimits() (cgroupLimits, error) {
+	if _, err := os.Stat(helpers.HostSys("fs", "cgroup", "cgroup.controllers")); err == nil {
+		return readCgroupV2Limits()
+	}
+	return readCgroupV1Limits()
+}
+
+// readCgroupV2Limits reads the limits from the memory.max file, holding the limit in bytes, and the cpu.max file,
+// holding the "<quota> <period>" CPU time in microseconds the cgroup can use per period. The root cgroup has none.
+func readCgroupV2Limits() (limits cgroupLimits, err error) {
+	memoryMax, err := readCgroupFile("memory.max")
+	if err != nil {
+		return limits, err
+	}
+	if memoryMax != "" && memoryMax != cgroupV2Unlimited {
+		bytes, err := strconv.ParseUint(memoryMax, 10, 64)
+		if err != nil {
+			return limits, fmt.Errorf("invalid memory.max value %q: %w", memoryMax, err)
+		}
+		limits.memoryBytes = &bytes
+	}
+
+	cpuMax, err := readCgroupFile("cpu.max")
+	if err != nil {
+		return limits, err
+	}
+	if fields := strings.Fields(cpuMax); len(fields) == 2 && fields[0] != cgroupV2Unlimited {
+		limits.cpuCores, err = cpuCores(fields[0], fields[1])
+		if err != nil {
+			return limits, fmt.Errorf("invalid cpu.max value %q: %w", cpuMax, err)
+		}
+	}
+
+	return limits, nil
+}
+
+// readCgroupV1Limits reads the limits from the memory.limit_in_bytes file of the memory controller, and the
+// cpu.cfs_quota_us and cpu.cfs_period_us files of the cpu controller, whose quota is -1 when unlimited.
+func readCgroupV1Limits() (limits cgroupLimits, err error) {
+	limitInBytes, err := readCgroupFile("memory", "memory.limit_in_bytes")
+	if err != nil {
+		return limits, err
+	}
+	if limitInBytes != "" {
+		bytes, err := strconv.ParseUint(limitInBytes, 10, 64)
+		if err != nil {
+			return limits, fmt.Errorf("invalid memory.limit_in_bytes value %q: %w", limitInBytes, err)
+		}
+		// unlimited memory is reported as the max int64 value rounded down to the page size
+		if bytes < uint64(math.MaxInt64)&^uint64(os.Getpagesize()-1) {
+			limits.memoryBytes = &bytes
+		}
+	}
+
+	quota, err := readCgroupFile("cpu", "cpu.cfs_quota_us")
+	if err != nil {
+		return limits, err
+	}
+	if quota == "" || strings.HasPrefix(quota, "-") {
+		return limits, nil
+	}
+	period, err := readCgroupFile("cpu", "cpu.cfs_period_us")
+	if err != nil {
+		return limits, err
+	}
+	limits.cpuCores, err = cpuCores(quota, period)
+	if err != nil {
+		return limits, fmt.Errorf("invalid cpu.cfs_quota_us and cpu.cfs_period_us values %q and %q: %w", quota, period, err)
+	}
+
+	return limits, nil
+}
+
+// cpuCores returns the number of cores a CPU time quota per period is equivalent to.
+func cpuCores(quota, period string) (*float64, error) {
+	quotaUs, err := strconv.ParseUint(quota, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	periodUs, err := strconv.ParseUint(period, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if periodUs == 0 {
+		return nil, errors.New("zero period")
+	}
+	cores := float64(quotaUs) / float64(periodUs)
+	return &cores, nil
+}
+
+// readCgroupFile returns the trimmed content of a file of the cgroup root, or an empty string if it doesn't exist.
+func readCgroupFile(path ...string) (string, error) {
+	content, err := os.ReadFile(helpers.HostSys(append([]string{"fs", "cgroup"}, path...)...))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot read cgroup file: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
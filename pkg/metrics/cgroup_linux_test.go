@@ -0,0 +1,131 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCgroupTree writes the given cgroup files under the fs/cgroup directory of a fixture sys tree, and uses it as
+// the host sys tree.
+func writeCgroupTree(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	sysDir := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(sysDir, "fs", "cgroup", path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	t.Setenv("HOST_SYS", sysDir)
+}
+
+func TestReadCgroupLimits(t *testing.T) {
+	memory := func(bytes uint64) *uint64 { return &bytes }
+	cores := func(cores float64) *float64 { return &cores }
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected cgroupLimits
+	}{
+		{
+			name: "v2 limited",
+			files: map[string]string{
+				"cgroup.controllers": "cpuset cpu io memory pids\n",
+				"memory.max":         "536870912\n",
+				"cpu.max":            "150000 100000\n",
+			},
+			expected: cgroupLimits{memoryBytes: memory(536870912), cpuCores: cores(1.5)},
+		},
+		{
+			name: "v2 unlimited",
+			files: map[string]string{
+				"cgroup.controllers": "cpuset cpu io memory pids\n",
+				"memory.max":         "max\n",
+				"cpu.max":            "max 100000\n",
+			},
+		},
+		{
+			name: "v2 root cgroup",
+			files: map[string]string{
+				"cgroup.controllers": "cpuset cpu io memory pids\n",
+			},
+		},
+		{
+			name: "v1 limited",
+			files: map[string]string{
+				"memory/memory.limit_in_bytes": "1073741824\n",
+				"cpu/cpu.cfs_quota_us":         "50000\n",
+				"cpu/cpu.cfs_period_us":        "100000\n",
+			},
+			expected: cgroupLimits{memoryBytes: memory(1073741824), cpuCores: cores(0.5)},
+		},
+		{
+			name: "v1 unlimited",
+			files: map[string]string{
+				"memory/memory.limit_in_bytes": "9223372036854771712\n",
+				"cpu/cpu.cfs_quota_us":         "-1\n",
+				"cpu/cpu.cfs_period_us":        "100000\n",
+			},
+		},
+		{
+			name: "v1 with empty unified hierarchy",
+			files: map[string]string{
+				"unified/cgroup.controllers":   "",
+				"memory/memory.limit_in_bytes": "1073741824\n",
+			},
+			expected: cgroupLimits{memoryBytes: memory(1073741824)},
+		},
+		{
+			name: "no cgroups",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeCgroupTree(t, tt.files)
+
+			limits, err := readCgroupLimits()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, limits)
+		})
+	}
+}
+
+func TestReadCgroupLimits_Invalid(t *testing.T) {
+	tests := map[string]map[string]string{
+		"v2 memory": {"cgroup.controllers": "memory\n", "memory.max": "lots\n"},
+		"v2 cpu":    {"cgroup.controllers": "cpu\n", "cpu.max": "150000 0\n"},
+		"v1 memory": {"memory/memory.limit_in_bytes": "-\n"},
+		"v1 cpu":    {"cpu/cpu.cfs_quota_us": "50000\n"},
+	}
+
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			writeCgroupTree(t, files)
+
+			_, err := readCgroupLimits()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHostSample_CgroupLimits(t *testing.T) {
+	writeCgroupTree(t, map[string]string{
+		"cgroup.controllers": "cpuset cpu io memory pids\n",
+		"memory.max":         "536870912\n",
+		"cpu.max":            "max 100000\n",
+	})
+
+	sample, err := NewHostMonitor(nil, false, false).Sample()
+	require.NoError(t, err)
+	require.NotNil(t, sample.MemoryLimitBytes)
+	assert.Equal(t, uint64(536870912), *sample.MemoryLimitBytes)
+	assert.Nil(t, sample.CPULimitCores)
+}
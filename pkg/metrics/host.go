@@ -12,21 +12,55 @@ import (
 )
 
 type HostSample struct {
-	Uptime    uint64   `json:"uptime"`
-	NtpOffset *float64 `json:"ntpOffset,omitempty"`
+	Uptime             uint64  `json:"uptime"`
+	ZombieProcessCount *uint64 `json:"zombieProcessCount,omitempty"`
+	ArpEntryCount      *uint64 `json:"arpEntryCount,omitempty"`
+	// MemoryLimitBytes and CPULimitCores are the limits of the cgroup the agent runs in, absent when unlimited.
+	MemoryLimitBytes *uint64  `json:"memoryLimitBytes,omitempty"`
+	CPULimitCores    *float64 `json:"cpuLimitCores,omitempty"`
+	NtpSample
+}
+
+// cgroupLimits memory and CPU limits of a cgroup. Nil limits are unlimited.
+type cgroupLimits struct {
+	memoryBytes *uint64
+	cpuCores    *float64
+}
+
+// NtpSample holds the ntp pool query results. NtpOffset is expressed in seconds and is positive if the local clock is
+// behind, while NtpOffsetMs is expressed in milliseconds and is positive if the local clock is ahead.
+type NtpSample struct {
+	NtpOffset     *float64 `json:"ntpOffset,omitempty"`
+	NtpOffsetMs   *float64 `json:"ntpOffsetMs,omitempty"`
+	NtpStratum    *uint8   `json:"ntpStratum,omitempty"`
+	NtpServerUsed string   `json:"ntpServerUsed,omitempty"`
+	NtpReachable  *bool    `json:"ntpReachable,omitempty"`
 }
 
 type HostMonitor struct {
-	ntpMonitor NtpMonitor
-	ntpOffset  *float64 // cache for last offset value retrieved
+	ntpMonitor    NtpMonitor
+	ntpSample     NtpSample                    // cache for last ntp values retrieved
+	zombieCounter func() (uint64, error)       // nil when the zombie processes are not counted
+	arpCounter    func() (uint64, error)       // nil when the ARP table entries are not counted
+	cgroupLimits  func() (cgroupLimits, error) // nil when the platform has no cgroups
 }
 
 type NtpMonitor interface {
-	Offset() (time.Duration, error)
+	Query() (NtpResult, error)
 }
 
-func NewHostMonitor(ntpMonitor NtpMonitor) *HostMonitor {
-	return &HostMonitor{ntpMonitor: ntpMonitor}
+// NewHostMonitor creates a HostMonitor. Zombie processes and ARP table entries are only counted when
+// zombieProcessCount and arpEntryCount, respectively, are true and the platform supports it. The cgroup limits are
+// reported whenever the platform supports them.
+func NewHostMonitor(ntpMonitor NtpMonitor, zombieProcessCount bool, arpEntryCount bool) *HostMonitor {
+	monitor := &HostMonitor{ntpMonitor: ntpMonitor, cgroupLimits: cgroupLimitsReader()}
+	if zombieProcessCount {
+		monitor.zombieCounter = zombieProcessCounter()
+	}
+	if arpEntryCount {
+		monitor.arpCounter = arpEntryCounter()
+	}
+	return monitor
 }
 
 func (m *HostMonitor) Sample() (*HostSample, error) {
@@ -37,20 +71,67 @@ func (m *HostMonitor) Sample() (*HostSample, error) {
 	}
 	hostSample.Uptime = uptime
 
+	if m.zombieCounter != nil {
+		zombies, err := m.zombieCounter()
+		if err != nil {
+			syslog.WithError(err).Warn("cannot count zombie processes")
+		} else {
+			hostSample.ZombieProcessCount = &zombies
+		}
+	}
+
+	if m.arpCounter != nil {
+		entries, err := m.arpCounter()
+		if err != nil {
+			syslog.WithError(err).Warn("cannot count ARP table entries")
+		} else {
+			hostSample.ArpEntryCount = &entries
+		}
+	}
+
+	if m.cgroupLimits != nil {
+		limits, err := m.cgroupLimits()
+		if err != nil {
+			syslog.WithError(err).Warn("cannot read cgroup limits")
+		} else {
+			hostSample.MemoryLimitBytes = limits.memoryBytes
+			hostSample.CPULimitCores = limits.cpuCores
+		}
+	}
+
 	if m.ntpMonitor != nil {
-		ntpOffset, err := m.ntpMonitor.Offset()
+		result, err := m.ntpMonitor.Query()
 		if err != nil {
-			// skip the error and use cached offset if interval error
-			if !errors.Is(err, ErrNotInInterval) {
+			// skip the error and use cached values if interval error
+			if errors.Is(err, ErrNotEnoughServers) {
+				syslog.WithError(err).Warn("skipping ntp offset")
+				m.ntpSample = NtpSample{NtpReachable: boolPtr(true)}
+			} else if errors.Is(err, ErrGettingNtpOffset) {
+				// report the servers as unreachable so the loss of time sync can be alerted on
+				syslog.WithError(err).Error("cannot get ntp offset")
+				m.ntpSample = NtpSample{NtpReachable: boolPtr(false)}
+			} else if !errors.Is(err, ErrNotInInterval) {
 				syslog.WithError(err).Error("cannot get ntp offset")
-				m.ntpOffset = nil
+				m.ntpSample = NtpSample{}
 			}
 		} else {
-			seconds := ntpOffset.Seconds()
-			m.ntpOffset = &seconds
+			seconds := result.Offset.Seconds()
+			offsetMs := -float64(result.Offset) / float64(time.Millisecond)
+			stratum := result.Stratum
+			m.ntpSample = NtpSample{
+				NtpOffset:     &seconds,
+				NtpOffsetMs:   &offsetMs,
+				NtpStratum:    &stratum,
+				NtpServerUsed: result.Server,
+				NtpReachable:  boolPtr(true),
+			}
 		}
-		hostSample.NtpOffset = m.ntpOffset
+		hostSample.NtpSample = m.ntpSample
 	}
 
 	return hostSample, nil
 }
+
+func boolPtr(value bool) *bool {
+	return &value
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+)
+
+const zombieProcessState = "Z"
+
+func zombieProcessCounter() func() (uint64, error) {
+	return countZombieProcesses
+}
+
+func arpEntryCounter() func() (uint64, error) {
+	return countArpEntries
+}
+
+// countZombieProcesses returns the number of processes in the zombie state, from the stat file of each process
+// in the proc tree. Processes that finish while being read are not counted.
+func countZombieProcesses() (uint64, error) {
+	entries, err := os.ReadDir(helpers.HostProc())
+	if err != nil {
+		return 0, fmt.Errorf("cannot read proc tree: %w", err)
+	}
+
+	var zombies uint64
+	for _, entry := range entries {
+		if _, err := strconv.ParseUint(entry.Name(), 10, 32); err != nil || !entry.IsDir() {
+			continue
+		}
+		stat, err := os.ReadFile(helpers.HostProc(entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		if processState(string(stat)) == zombieProcessState {
+			zombies++
+		}
+	}
+
+	return zombies, nil
+}
+
+// processState returns the state field of a /proc/<pid>/stat file. The command name that precedes it is enclosed
+// in parentheses and may contain spaces, so the state is looked up after the last closing parenthesis.
+func processState(stat string) string {
+	end := strings.LastIndex(stat, ")")
+	if end < 0 {
+		return ""
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// countArpEntries returns the number of entries of the ARP (neighbor) table, listed by /proc/net/arp after its
+// header line.
+func countArpEntries() (uint64, error) {
+	file, err := os.Open(helpers.HostProc("net", "arp"))
+	if err != nil {
+		return 0, fmt.Errorf("cannot read ARP table: %w", err)
+	}
+	defer file.Close()
+
+	var entries uint64
+	scanner := bufio.NewScanner(file)
+	// header: IP address HW type Flags HW address Mask Device
+	scanner.Scan()
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) != "" {
+			entries++
+		}
+	}
+
+	return entries, scanner.Err()
+}
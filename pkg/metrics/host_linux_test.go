@@ -0,0 +1,93 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountZombieProcesses(t *testing.T) {
+	procDir := t.TempDir()
+	stats := map[string]string{
+		"1":    "1 (systemd) S 0 1 1 0 -1 4194560 0 0 0 0",
+		"42":   "42 (bash) R 1 42 42 0 -1 4194560 0 0 0 0",
+		"100":  "100 (worker) Z 42 100 42 0 -1 4227084 0 0 0 0",
+		"101":  "101 (my app) Z) Z 42 101 42 0 -1 4227084 0 0 0 0",
+		"2000": "2000 (sleep) D 1 2000 2000 0 -1 4194304 0 0 0 0",
+	}
+	for pid, stat := range stats {
+		require.NoError(t, os.MkdirAll(filepath.Join(procDir, pid), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(procDir, pid, "stat"), []byte(stat+"\n"), 0644))
+	}
+	// non process entries and processes without stat file are skipped
+	require.NoError(t, os.MkdirAll(filepath.Join(procDir, "sys"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(procDir, "3000"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(procDir, "meminfo"), []byte("MemTotal: 1024 kB\n"), 0644))
+	t.Setenv("HOST_PROC", procDir)
+
+	zombies, err := countZombieProcesses()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), zombies)
+}
+
+func TestHostSample_ZombieProcessCount(t *testing.T) {
+	monitor := NewHostMonitor(nil, true, false)
+	monitor.zombieCounter = func() (uint64, error) { return 3, nil }
+
+	sample, err := monitor.Sample()
+	require.NoError(t, err)
+	require.NotNil(t, sample.ZombieProcessCount)
+	assert.Equal(t, uint64(3), *sample.ZombieProcessCount)
+
+	sample, err = NewHostMonitor(nil, false, false).Sample()
+	require.NoError(t, err)
+	assert.Nil(t, sample.ZombieProcessCount)
+}
+
+func TestCountArpEntries(t *testing.T) {
+	procDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procDir, "net"), 0755))
+	arpTable := `IP address       HW type     Flags       HW address            Mask     Device
+192.168.1.1      0x1         0x2         a4:91:b1:0c:22:10     *        eth0
+192.168.1.20     0x1         0x2         3c:22:fb:45:0a:be     *        eth0
+192.168.1.35     0x1         0x0         00:00:00:00:00:00     *        eth0
+172.17.0.2       0x1         0x2         02:42:ac:11:00:02     *        docker0
+`
+	require.NoError(t, os.WriteFile(filepath.Join(procDir, "net", "arp"), []byte(arpTable), 0644))
+	t.Setenv("HOST_PROC", procDir)
+
+	entries, err := countArpEntries()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), entries)
+}
+
+func TestCountArpEntries_EmptyTable(t *testing.T) {
+	procDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procDir, "net"), 0755))
+	arpTable := "IP address       HW type     Flags       HW address            Mask     Device\n"
+	require.NoError(t, os.WriteFile(filepath.Join(procDir, "net", "arp"), []byte(arpTable), 0644))
+	t.Setenv("HOST_PROC", procDir)
+
+	entries, err := countArpEntries()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), entries)
+}
+
+func TestHostSample_ArpEntryCount(t *testing.T) {
+	monitor := NewHostMonitor(nil, false, true)
+	monitor.arpCounter = func() (uint64, error) { return 12, nil }
+
+	sample, err := monitor.Sample()
+	require.NoError(t, err)
+	require.NotNil(t, sample.ArpEntryCount)
+	assert.Equal(t, uint64(12), *sample.ArpEntryCount)
+
+	sample, err = NewHostMonitor(nil, false, false).Sample()
+	require.NoError(t, err)
+	assert.Nil(t, sample.ArpEntryCount)
+}
@@ -0,0 +1,21 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build !linux
+// +build !linux
+
+package metrics
+
+// zombieProcessCounter returns nil as zombie processes are only counted on Linux.
+func zombieProcessCounter() func() (uint64, error) {
+	return nil
+}
+
+// arpEntryCounter returns nil as ARP table entries are only counted on Linux.
+func arpEntryCounter() func() (uint64, error) {
+	return nil
+}
+
+// cgroupLimitsReader returns nil as cgroups are only available on Linux.
+func cgroupLimitsReader() func() (cgroupLimits, error) {
+	return nil
+}
@@ -4,16 +4,18 @@
 package metrics
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHostSample_CachedNtpOffset(t *testing.T) {
 	timeout := uint(5)
 	interval := uint(15)
-	ntpMonitor := NewNtp([]string{"one"}, timeout, interval)
+	ntpMonitor := NewNtp([]string{"one"}, timeout, interval, 0)
 	ntpMonitor.ntpQuery = ntpQueryMock([]ntpResp{
 		{
 			resp: buildValidNtpResponse(50 * time.Millisecond),
@@ -21,7 +23,7 @@ func TestHostSample_CachedNtpOffset(t *testing.T) {
 		},
 	}...)
 
-	hostMonitor := NewHostMonitor(ntpMonitor)
+	hostMonitor := NewHostMonitor(ntpMonitor, false, false)
 
 	expectedOffset := (50 * time.Millisecond).Seconds()
 	expectedNtpSample := &expectedOffset
@@ -37,3 +39,44 @@ func TestHostSample_CachedNtpOffset(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, expectedNtpSample, sample.NtpOffset)
 }
+
+func TestHostSample_NtpValues(t *testing.T) {
+	ntpMonitor := NewNtp([]string{"one", "two", "three"}, 5, 15, 0)
+	ntpMonitor.ntpQuery = ntpQueryMock([]ntpResp{
+		{buildValidNtpResponse(50 * time.Millisecond), nil},
+		{nil, errors.New("this is an error")},
+		{buildValidNtpResponse(30 * time.Millisecond), nil},
+	}...)
+
+	sample, err := NewHostMonitor(ntpMonitor, false, false).Sample()
+	require.NoError(t, err)
+
+	// the local clock is behind, so the offset in milliseconds is negative
+	require.NotNil(t, sample.NtpOffsetMs)
+	assert.InDelta(t, -40, *sample.NtpOffsetMs, 0.001)
+	require.NotNil(t, sample.NtpOffset)
+	assert.InDelta(t, 0.04, *sample.NtpOffset, 0.000001)
+	require.NotNil(t, sample.NtpStratum)
+	assert.Equal(t, uint8(1), *sample.NtpStratum)
+	assert.Equal(t, "three", sample.NtpServerUsed)
+	require.NotNil(t, sample.NtpReachable)
+	assert.True(t, *sample.NtpReachable)
+}
+
+func TestHostSample_NtpUnreachable(t *testing.T) {
+	ntpMonitor := NewNtp([]string{"one", "two"}, 5, 15, 0)
+	ntpMonitor.ntpQuery = ntpQueryMock([]ntpResp{
+		{nil, errors.New("this is an error1")},
+		{nil, errors.New("this is an error2")},
+	}...)
+
+	sample, err := NewHostMonitor(ntpMonitor, false, false).Sample()
+	require.NoError(t, err)
+
+	require.NotNil(t, sample.NtpReachable)
+	assert.False(t, *sample.NtpReachable)
+	assert.Nil(t, sample.NtpOffset)
+	assert.Nil(t, sample.NtpOffsetMs)
+	assert.Nil(t, sample.NtpStratum)
+	assert.Empty(t, sample.NtpServerUsed)
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+)
+
+// tcpStatesByCode TCP socket states by their hex code in the "st" column of the proc sockets tables.
+var tcpStatesByCode = map[string]string{
+	"01": tcpEstablished,
+	"02": tcpSynSent,
+	"03": tcpSynRecv,
+	"04": tcpFinWait1,
+	"05": tcpFinWait2,
+	"06": tcpTimeWait,
+	"07": tcpClose,
+	"08": tcpCloseWait,
+	"09": tcpLastAck,
+	"0A": tcpListen,
+	"0B": tcpClosing,
+}
+
+// readTCPConnectionStates reads the state of the IPv4 and IPv6 TCP sockets from /proc/net/tcp and /proc/net/tcp6.
+// The tcp6 table doesn't exist when IPv6 is disabled.
+func readTCPConnectionStates(count func(state string)) error {
+	for _, table := range []string{"tcp", "tcp6"} {
+		file, err := os.Open(helpers.HostProc("net", table))
+		if table == "tcp6" && errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = parseTCPTable(file, count)
+		_ = file.Close()
+		if err != nil {
+			return fmt.Errorf("cannot parse %s sockets table: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// parseTCPTable reads the state of every socket of a proc sockets table, listed one per line after the header:
+//
+//	sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+//	 0: 0100007F:0CEA 00000000:0000 0A 00000000:00000000 00:00000000 00000000   0        0 21561 ...
+func parseTCPTable(table io.Reader, count func(state string)) error {
+	scanner := bufio.NewScanner(table)
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		count(tcpStatesByCode[fields[3]])
+	}
+	return scanner.Err()
+}
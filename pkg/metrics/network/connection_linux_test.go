@@ -0,0 +1,81 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package network
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tcpTable = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:0CEA 00000000:0000 0A 00000000:00000000 00:00000000 00000000   113        0 21561 1 0000000000000000 100 0 0 10 0
+   1: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 18302 1 0000000000000000 100 0 0 10 0
+   2: 0F02000A:0016 0202000A:D2F4 01 00000000:00000000 02:0009B8A6 00000000     0        0 40221 4 0000000000000000 20 4 29 10 -1
+   3: 0F02000A:A3B6 2A4D0F68:01BB 06 00000000:00000000 03:000016D5 00000000     0        0 0 3 0000000000000000
+   4: 0F02000A:A3C0 2A4D0F68:01BB 06 00000000:00000000 03:00001649 00000000     0        0 0 3 0000000000000000
+   5: 0F02000A:C1A2 2A4D0F68:01BB 08 00000000:00000000 00:00000000 00000000  1000        0 40519 1 0000000000000000 20 4 30 10 -1
+`
+
+const tcp6Table = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 18304 1 0000000000000000 100 0 0 10 0
+   1: 0000000000000000FFFF00000F02000A:1F90 0000000000000000FFFF00000202000A:C350 01 00000000:00000000 02:0009C020 00000000  1000        0 41102 2 0000000000000000 20 4 31 10 -1
+   2: 0000000000000000FFFF00000F02000A:1F90 0000000000000000FFFF00000202000A:C352 0C 00000000:00000000 02:0009C020 00000000  1000        0 0 2 0000000000000000
+`
+
+func writeTCPTables(t *testing.T, tables map[string]string) {
+	t.Helper()
+
+	procDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procDir, "net"), 0755))
+	for name, table := range tables {
+		require.NoError(t, os.WriteFile(filepath.Join(procDir, "net", name), []byte(table), 0644))
+	}
+	t.Setenv("HOST_PROC", procDir)
+}
+
+func TestNetworkConnectionSampler_Sample(t *testing.T) {
+	writeTCPTables(t, map[string]string{"tcp": tcpTable, "tcp6": tcp6Table})
+
+	samples, err := NewNetworkConnectionSampler(nil).Sample()
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+
+	connectionSample, ok := samples[0].(*NetworkConnectionSample)
+	require.True(t, ok)
+	assert.Equal(t, "NetworkConnectionSample", connectionSample.EventType)
+	// the socket in the NEW_SYN_RECV state is only counted in the total
+	assert.Equal(t, uint64(9), connectionSample.TCPTotal)
+	assert.Equal(t, uint64(3), connectionSample.TCPListen)
+	assert.Equal(t, uint64(2), connectionSample.TCPEstablished)
+	assert.Equal(t, uint64(2), connectionSample.TCPTimeWait)
+	assert.Equal(t, uint64(1), connectionSample.TCPCloseWait)
+	assert.Equal(t, uint64(0), connectionSample.TCPSynReceived)
+}
+
+func TestNetworkConnectionSampler_Sample_NoIPv6(t *testing.T) {
+	writeTCPTables(t, map[string]string{"tcp": tcpTable})
+
+	samples, err := NewNetworkConnectionSampler(nil).Sample()
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, uint64(6), samples[0].(*NetworkConnectionSample).TCPTotal)
+}
+
+func TestNetworkConnectionSampler_Sample_Error(t *testing.T) {
+	writeTCPTables(t, nil)
+
+	_, err := NewNetworkConnectionSampler(nil).Sample()
+	assert.Error(t, err)
+
+	sampler := NewNetworkConnectionSampler(nil)
+	sampler.readStates = func(count func(state string)) error {
+		return errors.New("permission denied")
+	}
+	_, err = sampler.Sample()
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux || windows
+// +build linux windows
+
+package network
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+)
+
+var ncslog = log.WithComponent("NetworkConnectionSampler")
+
+// TCP socket states, named as in the Linux kernel.
+const (
+	tcpEstablished = "ESTABLISHED"
+	tcpSynSent     = "SYN_SENT"
+	tcpSynRecv     = "SYN_RECV"
+	tcpFinWait1    = "FIN_WAIT1"
+	tcpFinWait2    = "FIN_WAIT2"
+	tcpTimeWait    = "TIME_WAIT"
+	tcpClose       = "CLOSE"
+	tcpCloseWait   = "CLOSE_WAIT"
+	tcpLastAck     = "LAST_ACK"
+	tcpListen      = "LISTEN"
+	tcpClosing     = "CLOSING"
+)
+
+// NetworkConnectionSample counts the TCP sockets of the host, IPv4 and IPv6, by state. Sockets in a state not
+// listed are only counted in the total.
+type NetworkConnectionSample struct {
+	sample.BaseEvent
+
+	TCPTotal       uint64 `json:"tcpTotal"`
+	TCPEstablished uint64 `json:"tcpEstablished"`
+	TCPSynSent     uint64 `json:"tcpSynSent"`
+	TCPSynReceived uint64 `json:"tcpSynReceived"`
+	TCPFinWait1    uint64 `json:"tcpFinWait1"`
+	TCPFinWait2    uint64 `json:"tcpFinWait2"`
+	TCPTimeWait    uint64 `json:"tcpTimeWait"`
+	TCPClose       uint64 `json:"tcpClose"`
+	TCPCloseWait   uint64 `json:"tcpCloseWait"`
+	TCPLastAck     uint64 `json:"tcpLastAck"`
+	TCPListen      uint64 `json:"tcpListen"`
+	TCPClosing     uint64 `json:"tcpClosing"`
+}
+
+func (s *NetworkConnectionSample) count(state string) {
+	s.TCPTotal++
+	switch state {
+	case tcpEstablished:
+		s.TCPEstablished++
+	case tcpSynSent:
+		s.TCPSynSent++
+	case tcpSynRecv:
+		s.TCPSynReceived++
+	case tcpFinWait1:
+		s.TCPFinWait1++
+	case tcpFinWait2:
+		s.TCPFinWait2++
+	case tcpTimeWait:
+		s.TCPTimeWait++
+	case tcpClose:
+		s.TCPClose++
+	case tcpCloseWait:
+		s.TCPCloseWait++
+	case tcpLastAck:
+		s.TCPLastAck++
+	case tcpListen:
+		s.TCPListen++
+	case tcpClosing:
+		s.TCPClosing++
+	}
+}
+
+// NetworkConnectionSampler samples the number of TCP sockets of the host by state, to diagnose connection
+// exhaustion.
+type NetworkConnectionSampler struct {
+	sampleInterval time.Duration
+	// readStates calls count with the state of every TCP socket of the host.
+	readStates func(count func(state string)) error
+}
+
+func NewNetworkConnectionSampler(context agent.AgentContext) *NetworkConnectionSampler {
+	samplerIntervalSec := config.DefaultMetricsTCPSampleRate
+	if context != nil {
+		samplerIntervalSec = context.Config().MetricsTCPSampleRate
+	}
+
+	return &NetworkConnectionSampler{
+		sampleInterval: time.Second * time.Duration(samplerIntervalSec),
+		readStates:     readTCPConnectionStates,
+	}
+}
+
+func (ncs *NetworkConnectionSampler) Name() string { return "NetworkConnectionSampler" }
+
+func (ncs *NetworkConnectionSampler) Interval() time.Duration {
+	return ncs.sampleInterval
+}
+
+func (ncs *NetworkConnectionSampler) Disabled() bool {
+	return ncs.Interval() <= config.FREQ_DISABLE_SAMPLING
+}
+
+func (ncs *NetworkConnectionSampler) OnStartup() {}
+
+func (ncs *NetworkConnectionSampler) Sample() (results sample.EventBatch, err error) {
+	defer func() {
+		if panicErr := recover(); panicErr != nil {
+			err = fmt.Errorf("Panic in NetworkConnectionSampler.Sample: %v\nStack: %s", panicErr, debug.Stack())
+		}
+	}()
+
+	connectionSample := &NetworkConnectionSample{}
+	connectionSample.Type("NetworkConnectionSample")
+
+	if err = ncs.readStates(connectionSample.count); err != nil {
+		return nil, fmt.Errorf("cannot read TCP sockets: %w", err)
+	}
+
+	helpers.LogStructureDetails(ncslog, connectionSample, "NetworkConnectionSample", "final", nil)
+
+	return sample.EventBatch{connectionSample}, nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build windows
+// +build windows
+
+package network
+
+import (
+	gopsnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// tcpStatesByStatus TCP socket states by their MIB_TCP_STATE name, as reported by gopsutil.
+var tcpStatesByStatus = map[string]string{
+	"ESTABLISHED":  tcpEstablished,
+	"SYN_SENT":     tcpSynSent,
+	"SYN_RECEIVED": tcpSynRecv,
+	"FIN_WAIT_1":   tcpFinWait1,
+	"FIN_WAIT_2":   tcpFinWait2,
+	"TIME_WAIT":    tcpTimeWait,
+	"CLOSED":       tcpClose,
+	"CLOSE_WAIT":   tcpCloseWait,
+	"LAST_ACK":     tcpLastAck,
+	"LISTEN":       tcpListen,
+	"CLOSING":      tcpClosing,
+}
+
+// readTCPConnectionStates reads the state of the IPv4 and IPv6 TCP sockets from the extended TCP tables of the
+// IP Helper API.
+func readTCPConnectionStates(count func(state string)) error {
+	connections, err := gopsnet.ConnectionsWithoutUids("tcp")
+	if err != nil {
+		return err
+	}
+	for _, connection := range connections {
+		count(tcpStatesByStatus[connection.Status])
+	}
+	return nil
+}
@@ -9,9 +9,12 @@ package metrics
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/beevik/ntp"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"go.uber.org/multierr"
 )
 
@@ -24,32 +27,68 @@ var (
 	ErrEmptyNtpHosts    = errors.New("ntp host list is empty")
 	ErrGettingNtpOffset = errors.New("cannot get ntp offset")
 	ErrNotInInterval    = errors.New("cannot query ntp servers offset inside interval")
+	ErrNotEnoughServers = errors.New("not enough ntp servers responded")
 )
 
+// NtpResult is the result of querying the ntp pool.
+type NtpResult struct {
+	Offset  time.Duration // median of the servers clock offsets, positive if the local clock is behind
+	Stratum uint8         // stratum of the server the median offset belongs to
+	Server  string        // host of the server the median offset belongs to
+}
+
 type Ntp struct {
-	pool      []string
-	timeout   time.Duration // ntp request timeout in seconds
-	interval  time.Duration // ntp request interval in minutes
-	updatedAt time.Time     // last time the ntp offset was fetched
-	now       func() time.Time
-	ntpQuery  func(host string, opt ntp.QueryOptions) (*ntp.Response, error)
+	pool       []string
+	timeout    time.Duration            // ntp request timeout in seconds
+	timeouts   map[string]time.Duration // ntp request timeout overridden per host
+	minServers int                      // minimum number of hosts that must respond
+	interval   time.Duration            // ntp request interval in minutes
+	updatedAt  time.Time                // last time the ntp offset was fetched
+	now        func() time.Time
+	ntpQuery   func(host string, opt ntp.QueryOptions) (*ntp.Response, error)
 }
 
 // NewNtp creates a new Ntp instance
+// pool entries are either "host" or "host:timeoutSeconds"
 // timeout is expressed in secods
 // interval is expressed in minutes.
-func NewNtp(pool []string, timeout uint, interval uint) *Ntp {
+func NewNtp(pool []string, timeout uint, interval uint, minServers uint) *Ntp {
 	validInterval := guardInterval(interval)
 	validTimeout := guardTimeout(timeout)
+
+	var hosts []string
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range pool {
+		host, hostTimeout, err := config.ParseNtpServer(entry)
+		if err != nil {
+			syslog.WithError(err).Warn("ignoring ntp host")
+			continue
+		}
+		if hostTimeout > 0 {
+			timeouts[host] = time.Second * time.Duration(hostTimeout)
+		}
+		hosts = append(hosts, host)
+	}
+
 	return &Ntp{
-		pool:     pool,
-		timeout:  time.Second * time.Duration(validTimeout),
-		interval: time.Minute * time.Duration(validInterval),
-		now:      time.Now,
-		ntpQuery: ntp.QueryWithOptions,
+		pool:       hosts,
+		timeout:    time.Second * time.Duration(validTimeout),
+		timeouts:   timeouts,
+		minServers: int(minServers),
+		interval:   time.Minute * time.Duration(validInterval),
+		now:        time.Now,
+		ntpQuery:   ntp.QueryWithOptions,
 	}
 }
 
+// hostTimeout returns the request timeout for the host, falling back to the global one.
+func (p *Ntp) hostTimeout(host string) time.Duration {
+	if timeout, ok := p.timeouts[host]; ok {
+		return timeout
+	}
+	return p.timeout
+}
+
 // guardTimeout ensures that interval is not 0
 // when interval is 0 the used library defaults to 5s, so we check it here
 // to decouple from that decision in case that it changes.
@@ -71,13 +110,19 @@ func guardInterval(interval uint) uint {
 
 // Offset returns the Ntp servers offset.
 func (p *Ntp) Offset() (time.Duration, error) {
+	result, err := p.Query()
+	return result.Offset, err
+}
+
+// Query returns the median offset from the Ntp servers along with the server it belongs to.
+func (p *Ntp) Query() (NtpResult, error) {
 	if len(p.pool) == 0 {
-		return 0, ErrEmptyNtpHosts
+		return NtpResult{}, ErrEmptyNtpHosts
 	}
 
 	if p.now().Sub(p.updatedAt) < p.interval {
 		// return error in case outside query interval
-		return 0, ErrNotInInterval
+		return NtpResult{}, ErrNotInInterval
 	}
 
 	// update current interval even if error
@@ -85,14 +130,15 @@ func (p *Ntp) Offset() (time.Duration, error) {
 		p.updatedAt = p.now()
 	}()
 
-	var offsets []time.Duration
+	var results []NtpResult
 
 	var ntpQueryErr error
 	for _, host := range p.pool {
-		response, err := p.ntpQuery(host, ntp.QueryOptions{Timeout: p.timeout})
+		timeout := p.hostTimeout(host)
+		response, err := p.ntpQuery(host, ntp.QueryOptions{Timeout: timeout})
 		if err != nil {
 			ntpQueryErr = multierr.Append(ntpQueryErr, err)
-			syslog.WithError(err).WithField("ntp_host", host).WithField("timeout", p.timeout).Debug("error getting ntp offset")
+			syslog.WithError(err).WithField("ntp_host", host).WithField("timeout", timeout).Debug("error getting ntp offset")
 
 			continue
 		}
@@ -100,24 +146,40 @@ func (p *Ntp) Offset() (time.Duration, error) {
 		err = response.Validate()
 		if err != nil {
 			ntpQueryErr = multierr.Append(ntpQueryErr, err)
-			syslog.WithError(err).WithField("ntp_host", host).WithField("timeout", p.timeout).Debug("error validating ntp response, skipping")
+			syslog.WithError(err).WithField("ntp_host", host).WithField("timeout", timeout).Debug("error validating ntp response, skipping")
 
 			continue
 		}
 
 		syslog.WithField("ntp_host", host).WithField("response", response).Trace("valid ntp response retrieved")
-		offsets = append(offsets, response.ClockOffset)
+		results = append(results, NtpResult{Offset: response.ClockOffset, Stratum: response.Stratum, Server: host})
 	}
 
-	if len(offsets) == 0 {
-		return 0, multierr.Append(ErrGettingNtpOffset, ntpQueryErr)
+	if len(results) == 0 {
+		return NtpResult{}, multierr.Append(ErrGettingNtpOffset, ntpQueryErr)
 	}
 
-	// calculate average from all hosts values
-	var total time.Duration
-	for _, offset := range offsets {
-		total += offset
+	// a partial offset could be skewed by a single misbehaving server
+	if len(results) < p.minServers {
+		return NtpResult{}, fmt.Errorf("%w: %d of %d required", ErrNotEnoughServers, len(results), p.minServers)
+	}
+
+	return medianNtpResult(results), nil
+}
+
+// medianNtpResult returns the result with the median offset. When the number of results is even the offset is
+// the mean of the two middle ones, and the server and stratum are taken from the lower one.
+func medianNtpResult(results []NtpResult) NtpResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Offset < results[j].Offset
+	})
+
+	middle := len(results) / 2
+	if len(results)%2 == 1 {
+		return results[middle]
 	}
 
-	return total / time.Duration(len(offsets)), nil
+	median := results[middle-1]
+	median.Offset = (results[middle-1].Offset + results[middle].Offset) / 2
+	return median
 }
@@ -14,6 +14,7 @@ import (
 
 	"github.com/beevik/ntp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewNtp_Interval(t *testing.T) {
@@ -49,7 +50,7 @@ func TestNewNtp_Interval(t *testing.T) {
 	timeout := uint(100)
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			ntp := NewNtp(testCase.pool, timeout, testCase.interval)
+			ntp := NewNtp(testCase.pool, timeout, testCase.interval, 0)
 			assert.Equal(t, testCase.expectedInterval, ntp.interval)
 			assert.Equal(t, testCase.expectedPool, ntp.pool)
 			assert.Equal(t, time.Duration(timeout)*time.Second, ntp.timeout)
@@ -77,7 +78,7 @@ func TestNewNtp_Timeout(t *testing.T) {
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			ntp := NewNtp([]string{"one", "two", "three"}, testCase.timeout, 1000)
+			ntp := NewNtp([]string{"one", "two", "three"}, testCase.timeout, 1000, 0)
 			assert.Equal(t, []string{"one", "two", "three"}, ntp.pool)
 			assert.Equal(t, 1000*time.Minute, ntp.interval)
 			assert.Equal(t, testCase.expectedTimeout, ntp.timeout)
@@ -142,7 +143,7 @@ func TestOffset_Interval(t *testing.T) {
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			ntpMonitor := NewNtp(testCase.pool, testCase.timeout, testCase.interval)
+			ntpMonitor := NewNtp(testCase.pool, testCase.timeout, testCase.interval, 0)
 			ntpMonitor.ntpQuery = ntpQueryMock(testCase.ntpResponses...)
 			ntpMonitor.now = testCase.now
 			ntpMonitor.updatedAt = testCase.updatedAt
@@ -154,10 +155,10 @@ func TestOffset_Interval(t *testing.T) {
 	}
 }
 
-func TestOffset_OffsetAverage(t *testing.T) {
+func TestOffset_OffsetMedian(t *testing.T) {
 	timeout := uint(5)
 	interval := uint(15)
-	ntpMonitor := NewNtp([]string{"one", "two", "three"}, timeout, interval)
+	ntpMonitor := NewNtp([]string{"one", "two", "three"}, timeout, interval, 0)
 	ntpMonitor.ntpQuery = ntpQueryMock([]ntpResp{
 		{
 			resp: buildValidNtpResponse(110 * time.Millisecond),
@@ -174,14 +175,14 @@ func TestOffset_OffsetAverage(t *testing.T) {
 	}...)
 	ntpMonitor.now = nowMock("2022-09-28 16:02:45")
 	offset, err := ntpMonitor.Offset()
-	assert.Equal(t, time.Millisecond*51, offset)
+	assert.Equal(t, time.Millisecond*23, offset)
 	assert.Equal(t, nil, err)
 }
 
 func TestOffset_AnyHostErrorShouldNotReturnError(t *testing.T) {
 	timeout := uint(5)
 	interval := uint(15)
-	ntpMonitor := NewNtp([]string{"one", "two", "three"}, timeout, interval)
+	ntpMonitor := NewNtp([]string{"one", "two", "three"}, timeout, interval, 0)
 	ntpMonitor.ntpQuery = ntpQueryMock([]ntpResp{
 		{
 			resp: buildValidNtpResponse(50 * time.Millisecond),
@@ -204,7 +205,7 @@ func TestOffset_AnyHostErrorShouldNotReturnError(t *testing.T) {
 func TestOffset_AllHostErrorShouldReturnError(t *testing.T) {
 	timeout := uint(5)
 	interval := uint(15)
-	ntpMonitor := NewNtp([]string{"one", "two", "three"}, timeout, interval)
+	ntpMonitor := NewNtp([]string{"one", "two", "three"}, timeout, interval, 0)
 	ntpMonitor.ntpQuery = ntpQueryMock([]ntpResp{
 		{
 			err: errors.New("this is an error1"),
@@ -227,7 +228,7 @@ func TestOffset_InvalidNtpResponse(t *testing.T) {
 
 	timeout := uint(5)
 	interval := uint(15)
-	ntpMonitor := NewNtp([]string{"one", "two", "three"}, timeout, interval)
+	ntpMonitor := NewNtp([]string{"one", "two", "three"}, timeout, interval, 0)
 	ntpMonitor.ntpQuery = ntpQueryMock([]ntpResp{
 		{
 			resp: buildInvalidNtpResponse(),
@@ -253,7 +254,7 @@ func TestOffset_AnyHostInvalidShouldNotReturnError(t *testing.T) {
 
 	timeout := uint(5)
 	interval := uint(15)
-	ntpMonitor := NewNtp([]string{"one", "two", "three"}, timeout, interval)
+	ntpMonitor := NewNtp([]string{"one", "two", "three"}, timeout, interval, 0)
 	ntpMonitor.ntpQuery = ntpQueryMock([]ntpResp{
 		{
 			resp: buildValidNtpResponse(50 * time.Millisecond),
@@ -324,3 +325,72 @@ func buildInvalidNtpResponse() *ntp.Response {
 		Leap: ntp.LeapNotInSync,
 	}
 }
+
+func TestOffset_PerHostTimeout(t *testing.T) {
+	ntpMonitor := NewNtp([]string{"internal.ntp:1", "time.cloudflare.com"}, 5, 15, 0)
+	assert.Equal(t, []string{"internal.ntp", "time.cloudflare.com"}, ntpMonitor.pool)
+
+	timeouts := map[string]time.Duration{}
+	ntpMonitor.ntpQuery = func(host string, opt ntp.QueryOptions) (*ntp.Response, error) {
+		timeouts[host] = opt.Timeout
+		return buildValidNtpResponse(10 * time.Millisecond), nil
+	}
+	ntpMonitor.now = nowMock("2022-09-28 16:02:45")
+
+	_, err := ntpMonitor.Offset()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{
+		"internal.ntp":        time.Second,
+		"time.cloudflare.com": 5 * time.Second,
+	}, timeouts)
+}
+
+func TestOffset_MinServers(t *testing.T) {
+	responses := []ntpResp{
+		{resp: buildValidNtpResponse(50 * time.Millisecond)},
+		{err: errors.New("this is an error")},
+		{resp: buildValidNtpResponse(40 * time.Millisecond)},
+	}
+
+	testCases := []struct {
+		name           string
+		minServers     uint
+		expectedOffset time.Duration
+		expectedError  error
+	}{
+		{name: "not set", minServers: 0, expectedOffset: 45 * time.Millisecond},
+		{name: "enough servers", minServers: 2, expectedOffset: 45 * time.Millisecond},
+		{name: "not enough servers", minServers: 3, expectedError: ErrNotEnoughServers},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ntpMonitor := NewNtp([]string{"one", "two", "three"}, 5, 15, testCase.minServers)
+			ntpMonitor.ntpQuery = ntpQueryMock(responses...)
+			ntpMonitor.now = nowMock("2022-09-28 16:02:45")
+
+			offset, err := ntpMonitor.Offset()
+			assert.Equal(t, testCase.expectedOffset, offset)
+			assert.ErrorIs(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func TestQuery_MedianServer(t *testing.T) {
+	ntpMonitor := NewNtp([]string{"one", "two", "three", "four"}, 5, 15, 0)
+	responses := map[string]*ntp.Response{
+		"one":   buildValidNtpResponse(110 * time.Millisecond),
+		"two":   buildValidNtpResponse(-20 * time.Millisecond),
+		"three": buildValidNtpResponse(30 * time.Millisecond),
+		"four":  buildValidNtpResponse(10 * time.Millisecond),
+	}
+	responses["four"].Stratum = 2
+	ntpMonitor.ntpQuery = func(host string, opt ntp.QueryOptions) (*ntp.Response, error) {
+		return responses[host], nil
+	}
+	ntpMonitor.now = nowMock("2022-09-28 16:02:45")
+
+	result, err := ntpMonitor.Query()
+	require.NoError(t, err)
+	assert.Equal(t, NtpResult{Offset: 20 * time.Millisecond, Stratum: 2, Server: "four"}, result)
+}
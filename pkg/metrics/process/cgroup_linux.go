@@ -0,0 +1,105 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/pkg/errors"
+)
+
+var errCPUCgroupNotFound = errors.New("cpu cgroup not found")
+
+// cpuThrottling holds the CPU throttling stats of a cgroup.
+type cpuThrottling struct {
+	nrThrottled     uint64
+	throttledTimeMs float64
+}
+
+// readCPUThrottling reads the CPU throttling stats of the cgroup the process belongs to. Both cgroup v1 (cpu
+// controller hierarchy) and cgroup v2 (unified hierarchy) are supported.
+func readCPUThrottling(pid int32) (cpuThrottling, error) {
+	cpuStatPath, err := cpuStatPath(pid)
+	if err != nil {
+		return cpuThrottling{}, err
+	}
+
+	content, err := ioutil.ReadFile(cpuStatPath)
+	if err != nil {
+		return cpuThrottling{}, err
+	}
+
+	return parseCPUStat(content)
+}
+
+// cpuStatPath returns the path of the cpu.stat file for the cgroup of the process, as listed in /proc/<pid>/cgroup.
+// Each line follows the format "hierarchy-ID:controller-list:cgroup-path".
+func cpuStatPath(pid int32) (string, error) {
+	content, err := ioutil.ReadFile(helpers.HostProc(strconv.Itoa(int(pid)), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+
+	var unifiedPath string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		controllers, cgroupPath := fields[1], fields[2]
+
+		// cgroup v2 unified hierarchy
+		if fields[0] == "0" && controllers == "" {
+			unifiedPath = cgroupPath
+			continue
+		}
+
+		for _, controller := range strings.Split(controllers, ",") {
+			if controller == "cpu" {
+				return helpers.HostSys("fs", "cgroup", controllers, cgroupPath, "cpu.stat"), nil
+			}
+		}
+	}
+
+	if unifiedPath != "" {
+		return helpers.HostSys("fs", "cgroup", unifiedPath, "cpu.stat"), nil
+	}
+
+	return "", errCPUCgroupNotFound
+}
+
+// parseCPUStat parses the content of a cpu.stat file. Throttled time is reported in nanoseconds by cgroup v1
+// (throttled_time) and in microseconds by cgroup v2 (throttled_usec).
+func parseCPUStat(content []byte) (cpuThrottling, error) {
+	var stats cpuThrottling
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return stats, errors.Wrapf(err, "for cpu.stat entry: %s", scanner.Text())
+		}
+
+		switch fields[0] {
+		case "nr_throttled":
+			stats.nrThrottled = value
+		case "throttled_time":
+			stats.throttledTimeMs = float64(value) / 1e6
+		case "throttled_usec":
+			stats.throttledTimeMs = float64(value) / 1e3
+		}
+	}
+
+	return stats, nil
+}
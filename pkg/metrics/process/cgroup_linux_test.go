@@ -0,0 +1,83 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package process
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockCgroupHost(t *testing.T, procCgroup string, cpuStatPath string, cpuStat string) {
+	t.Helper()
+
+	hostProc, hostSys := os.Getenv("HOST_PROC"), os.Getenv("HOST_SYS")
+	t.Cleanup(func() {
+		_ = os.Setenv("HOST_PROC", hostProc)
+		_ = os.Setenv("HOST_SYS", hostSys)
+	})
+
+	procDir, err := ioutil.TempDir("", "proc")
+	require.NoError(t, err)
+	sysDir, err := ioutil.TempDir("", "sys")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(procDir)
+		_ = os.RemoveAll(sysDir)
+	})
+	_ = os.Setenv("HOST_PROC", procDir)
+	_ = os.Setenv("HOST_SYS", sysDir)
+
+	require.NoError(t, os.MkdirAll(path.Join(procDir, "12345"), 0o755))
+	require.NoError(t, ioutil.WriteFile(path.Join(procDir, "12345", "cgroup"), []byte(procCgroup), 0o600))
+
+	if cpuStatPath != "" {
+		statFile := path.Join(sysDir, cpuStatPath)
+		require.NoError(t, os.MkdirAll(path.Dir(statFile), 0o755))
+		require.NoError(t, ioutil.WriteFile(statFile, []byte(cpuStat), 0o600))
+	}
+}
+
+func TestReadCPUThrottling_CgroupV1(t *testing.T) {
+	mockCgroupHost(t,
+		"12:memory:/docker/abc123\n4:cpu,cpuacct:/docker/abc123\n1:name=systemd:/docker/abc123\n",
+		"fs/cgroup/cpu,cpuacct/docker/abc123/cpu.stat",
+		"nr_periods 420\nnr_throttled 42\nthrottled_time 1500000000\n")
+
+	stats, err := readCPUThrottling(12345)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(42), stats.nrThrottled)
+	assert.Equal(t, float64(1500), stats.throttledTimeMs)
+}
+
+func TestReadCPUThrottling_CgroupV2(t *testing.T) {
+	mockCgroupHost(t,
+		"0::/system.slice/docker-abc123.scope\n",
+		"fs/cgroup/system.slice/docker-abc123.scope/cpu.stat",
+		"usage_usec 8000\nuser_usec 5000\nsystem_usec 3000\nnr_periods 30\nnr_throttled 7\nthrottled_usec 2500\n")
+
+	stats, err := readCPUThrottling(12345)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(7), stats.nrThrottled)
+	assert.Equal(t, 2.5, stats.throttledTimeMs)
+}
+
+func TestReadCPUThrottling_NoCPUCgroup(t *testing.T) {
+	mockCgroupHost(t, "12:memory:/docker/abc123\n", "", "")
+
+	_, err := readCPUThrottling(12345)
+	assert.Equal(t, errCPUCgroupNotFound, err)
+}
+
+func TestReadCPUThrottling_MissingCPUStat(t *testing.T) {
+	mockCgroupHost(t, "0::/system.slice/docker-abc123.scope\n", "", "")
+
+	_, err := readCPUThrottling(12345)
+	assert.Error(t, err)
+}
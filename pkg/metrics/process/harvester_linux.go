@@ -8,6 +8,8 @@
 package process
 
 import (
+	"time"
+
 	"github.com/newrelic/infrastructure-agent/internal/agent"
 	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/metrics"
@@ -24,11 +26,15 @@ func newHarvester(ctx agent.AgentContext, cache *cache) *linuxHarvester {
 	privileged := cfg == nil || cfg.RunMode == config.ModeRoot || cfg.RunMode == config.ModePrivileged
 	disableZeroRSSFilter := cfg != nil && cfg.DisableZeroRSSFilter
 	stripCommandLine := (cfg != nil && cfg.StripCommandLine) || (cfg == nil && config.DefaultStripCommandLine)
+	threadCount := cfg == nil || cfg.EnableProcessThreadCount == nil || *cfg.EnableProcessThreadCount
+	fdCount := cfg == nil || cfg.EnableProcessFdCount == nil || *cfg.EnableProcessFdCount
 
 	return &linuxHarvester{
 		privileged:           privileged,
 		disableZeroRSSFilter: disableZeroRSSFilter,
 		stripCommandLine:     stripCommandLine,
+		threadCount:          threadCount,
+		fdCount:              fdCount,
 		serviceForPid:        ctx.GetServiceForPid,
 		cache:                cache,
 	}
@@ -39,8 +45,12 @@ type linuxHarvester struct {
 	privileged           bool
 	disableZeroRSSFilter bool
 	stripCommandLine     bool
+	threadCount          bool
+	fdCount              bool
 	cache                *cache
 	serviceForPid        func(int) (string, bool)
+	// host boot time, lazily read to calculate process start times
+	bootTime time.Time
 }
 
 var _ Harvester = (*linuxHarvester)(nil) // static interface assertion
@@ -79,6 +89,8 @@ func (ps *linuxHarvester) Do(pid int32, elapsedSeconds float64) (*types.ProcessS
 		return nil, errors.Wrap(err, "can't populate static attributes")
 	}
 
+	ps.populateStartTime(sample, cached.process)
+
 	// As soon as we have successfully stored the static (reusable) values, we can cache the entry
 	if !hasCachedSample {
 		ps.cache.Add(pid, cached)
@@ -124,6 +136,21 @@ func (ps *linuxHarvester) populateStaticData(sample *types.ProcessSample, proces
 	return nil
 }
 
+// populateStartTime populates the sample with the process start time, which is reported by the kernel in clock ticks
+// since the host boot time.
+func (ps *linuxHarvester) populateStartTime(sample *types.ProcessSample, process *linuxProcess) {
+	if ps.bootTime.IsZero() {
+		bootTime, err := readBootTime()
+		if err != nil {
+			mplog.WithError(err).Debug("Can't read host boot time, process start time won't be reported.")
+			return
+		}
+		ps.bootTime = bootTime
+	}
+
+	sample.StartTime = process.StartTime(ps.bootTime).Unix()
+}
+
 // populateGauges populates the sample with gauge data that represents the process state at a given point
 func (ps *linuxHarvester) populateGauges(sample *types.ProcessSample, process Snapshot) error {
 	var err error
@@ -144,19 +171,29 @@ func (ps *linuxHarvester) populateGauges(sample *types.ProcessSample, process Sn
 		sample.CPUSystemPercent = 0
 	}
 
-	if ps.privileged {
+	// file descriptors that can't be read, like the ones of processes of other users when the agent runs as
+	// privileged, are not reported instead of discarding the whole sample
+	if ps.privileged && ps.fdCount {
 		fds, err := process.NumFDs()
 		if err != nil {
-			return err
-		}
-		if fds >= 0 {
+			mplog.WithError(err).WithField("processID", sample.ProcessID).Debug("Can't count process file descriptors.")
+		} else if fds >= 0 {
 			sample.FdCount = &fds
 		}
 	}
 
 	// Extra status data
 	sample.Status = process.Status()
-	sample.ThreadCount = process.NumThreads()
+	// thread counts that can't be read, as when the agent isn't allowed to read the process entries, are not reported
+	// instead of discarding the whole sample
+	if ps.threadCount {
+		threads, err := readThreadCount(process.Pid())
+		if err != nil {
+			mplog.WithError(err).WithField("processID", sample.ProcessID).Debug("Can't count process threads.")
+		} else {
+			sample.ThreadCount = threads
+		}
+	}
 	sample.MemoryVMSBytes = process.VmSize()
 	sample.MemoryRSSBytes = process.VmRSS()
 
@@ -4,9 +4,11 @@ package process
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"os/user"
+	"path"
 	"strings"
 	"testing"
 	"time"
@@ -14,6 +16,7 @@ import (
 	"github.com/newrelic/infrastructure-agent/internal/agent/mocks"
 	"github.com/newrelic/infrastructure-agent/internal/testhelpers"
 	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/metrics/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -96,6 +99,112 @@ func TestLinuxHarvester_Do(t *testing.T) {
 	assert.Equal(t, "ProcessSample", sample.EventType)
 }
 
+func TestLinuxHarvester_ThreadCount(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOST_PROC", tmpDir)
+
+	pidStat := "1232 (newrelic-infra) S 1 1232 1232 0 -1 1077960960 4799 282681 88 142 24 15 193 94 20 0 12 0 12345 464912384 4490 18446744073709551615 1 1 0 0 0 0 0 0 2143420159 0 0 0 17 0 0 0 14 0 0 0 0 0 0 0 0 0 0"
+	for _, pid := range []string{"1232", "1233", "1234"} {
+		require.NoError(t, os.MkdirAll(path.Join(tmpDir, pid), 0o755))
+		require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, pid, "stat"), []byte(strings.Replace(pidStat, "1232", pid, 1)), 0o600))
+	}
+	// Given a process with 7 threads in its status
+	status := "Name:\tnewrelic-infra\nState:\tS (sleeping)\nThreads:\t7\nSigQ:\t0/63445\n"
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "1232", "status"), []byte(status), 0o600))
+	// And a process without status, with 3 tasks
+	for _, task := range []string{"1233", "1240", "1241"} {
+		require.NoError(t, os.MkdirAll(path.Join(tmpDir, "1233", "task", task), 0o755))
+	}
+	// And a process whose threads can't be read
+
+	disabled, enabled := false, true
+	testCases := []struct {
+		name     string
+		pid      int32
+		enabled  *bool
+		expected int32
+	}{
+		{"not set", 1232, nil, 7},
+		{"enabled", 1232, &enabled, 7},
+		{"disabled", 1232, &disabled, 0},
+		{"tasks", 1233, &enabled, 3},
+		{"unreadable", 1234, &enabled, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// And a process harvester with the thread count toggle
+			ctx := new(mocks.AgentContext)
+			ctx.On("Config").Return(&config.Config{RunMode: config.ModeUnprivileged, EnableProcessThreadCount: tc.enabled})
+			cache := newCache()
+			h := newHarvester(ctx, &cache)
+
+			stats, err := readProcStat(tc.pid)
+			require.NoError(t, err)
+
+			// When populating the process gauges
+			sample := &types.ProcessSample{}
+			require.NoError(t, h.populateGauges(sample, &linuxProcess{pid: tc.pid, stats: stats}))
+
+			// Then the thread count is only reported when enabled and readable
+			assert.Equal(t, tc.expected, sample.ThreadCount)
+		})
+	}
+}
+
+func TestLinuxHarvester_FdCount(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOST_PROC", tmpDir)
+
+	// Given a process with 3 open file descriptors
+	pidStat := "1232 (newrelic-infra) S 1 1232 1232 0 -1 1077960960 4799 282681 88 142 24 15 193 94 20 0 12 0 12345 464912384 4490 18446744073709551615 1 1 0 0 0 0 0 0 2143420159 0 0 0 17 0 0 0 14 0 0 0 0 0 0 0 0 0 0"
+	require.NoError(t, os.MkdirAll(path.Join(tmpDir, "1232", "fd"), 0o755))
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "1232", "stat"), []byte(pidStat), 0o600))
+	for _, fd := range []string{"0", "1", "2"} {
+		require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "1232", "fd", fd), nil, 0o600))
+	}
+	// And a process whose file descriptors can't be read
+	require.NoError(t, os.MkdirAll(path.Join(tmpDir, "1233"), 0o755))
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "1233", "stat"), []byte(strings.Replace(pidStat, "1232", "1233", 1)), 0o600))
+
+	fds := int32(3)
+	disabled, enabled := false, true
+	testCases := []struct {
+		name     string
+		pid      int32
+		runMode  string
+		enabled  *bool
+		expected *int32
+	}{
+		{"not set", 1232, config.ModeRoot, nil, &fds},
+		{"enabled", 1232, config.ModePrivileged, &enabled, &fds},
+		{"disabled", 1232, config.ModeRoot, &disabled, nil},
+		{"unprivileged", 1232, config.ModeUnprivileged, &enabled, nil},
+		{"unreadable", 1233, config.ModeRoot, &enabled, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// And a process harvester with the fd count toggle
+			ctx := new(mocks.AgentContext)
+			ctx.On("Config").Return(&config.Config{RunMode: tc.runMode, EnableProcessFdCount: tc.enabled})
+			cache := newCache()
+			h := newHarvester(ctx, &cache)
+
+			stats, err := readProcStat(tc.pid)
+			require.NoError(t, err)
+
+			// When populating the process gauges
+			sample := &types.ProcessSample{}
+			process := &linuxProcess{pid: tc.pid, stats: stats, privileged: h.privileged}
+			require.NoError(t, h.populateGauges(sample, process))
+
+			// Then the file descriptors are only reported when enabled and readable
+			assert.Equal(t, tc.expected, sample.FdCount)
+		})
+	}
+}
+
 func TestLinuxHarvester_Do_Privileged(t *testing.T) {
 	current, err := user.Current()
 	require.NoError(t, err)
@@ -26,6 +26,16 @@ type processSampler struct {
 	hasAlreadyRun     bool
 	interval          time.Duration
 	cache             *cache
+	// containerCPUThrottling enables decorating contained processes with their cgroup CPU throttling stats
+	containerCPUThrottling bool
+	// userAggregation enables reporting the processes resources aggregated by user
+	userAggregation bool
+	// socketCount enables decorating the processes with the number of network sockets they have open
+	socketCount bool
+	// nameFilters skip the processes by their command name before they are harvested
+	nameFilters config.ProcessNameFilters
+	// commandName returns the command name of a process without harvesting it
+	commandName func(pid int32) (string, error)
 }
 
 var (
@@ -42,6 +52,10 @@ func NewProcessSampler(ctx agent.AgentContext) sampler.Sampler {
 	apiVersion := ""
 	dockerContainerdNamespace := ""
 	interval := config.FREQ_INTERVAL_FLOOR_PROCESS_METRICS
+	containerCPUThrottling := false
+	userAggregation := false
+	socketCount := false
+	var nameFilters config.ProcessNameFilters
 	var containerSamplers []metrics.ContainerSampler
 	if hasConfig {
 		cfg := ctx.Config()
@@ -49,6 +63,10 @@ func NewProcessSampler(ctx agent.AgentContext) sampler.Sampler {
 		apiVersion = cfg.DockerApiVersion
 		dockerContainerdNamespace = cfg.DockerContainerdNamespace
 		interval = cfg.MetricsProcessSampleRate
+		containerCPUThrottling = cfg.ContainerCPUThrottling
+		userAggregation = cfg.ProcessUserAggregation
+		socketCount = cfg.EnableProcessSocketCount
+		nameFilters = cfg.ProcessNameFilters
 	}
 
 	if (hasConfig && ctx.Config().ProcessContainerDecoration) || !hasConfig {
@@ -63,6 +81,12 @@ func NewProcessSampler(ctx agent.AgentContext) sampler.Sampler {
 		containerSamplers: containerSamplers,
 		cache:             &cache,
 		interval:          time.Second * time.Duration(interval),
+
+		containerCPUThrottling: containerCPUThrottling,
+		userAggregation:        userAggregation,
+		socketCount:            socketCount,
+		nameFilters:            nameFilters,
+		commandName:            readCommandName,
 	}
 }
 
@@ -122,10 +146,21 @@ func (ps *processSampler) Sample() (results sample.EventBatch, err error) {
 		}
 	}
 
+	// throttling stats are read once per container
+	containersThrottling := map[string]*cpuThrottling{}
+	// network socket tables are read once per network namespace
+	netSockets := map[string]socketInodes{}
+
+	var processSamples []*types.ProcessSample
+
 	for _, pid := range pids {
 		var processSample *types.ProcessSample
 		var err error
 
+		if !ps.acceptsProcess(pid) {
+			continue
+		}
+
 		processSample, err = ps.harvest.Do(pid, elapsedSeconds)
 		if err != nil {
 			procLog := mplog.WithError(err)
@@ -143,14 +178,76 @@ func (ps *processSampler) Sample() (results sample.EventBatch, err error) {
 			}
 		}
 
+		if ps.containerCPUThrottling && processSample.ContainerID != "" {
+			ps.decorateCPUThrottling(processSample, containersThrottling)
+		}
+
+		if ps.socketCount {
+			ps.decorateSocketCount(processSample, netSockets)
+		}
+
+		if ps.userAggregation {
+			processSamples = append(processSamples, processSample)
+		}
+
 		results = append(results, ps.normalizeSample(processSample))
 	}
 
+	for _, userSample := range aggregateByUser(processSamples) {
+		results = append(results, userSample)
+	}
+
 	ps.cache.items.RemoveUntilLen(len(pids))
 	ps.hasAlreadyRun = true
 	return results, nil
 }
 
+// acceptsProcess returns whether the process is sampled according to the process name filters. Processes whose
+// name can't be read are left to the harvester.
+func (ps *processSampler) acceptsProcess(pid int32) bool {
+	if !ps.nameFilters.Enabled() {
+		return true
+	}
+
+	name, err := ps.commandName(pid)
+	if err != nil {
+		mplog.WithError(err).WithField("pid", pid).Debug("Can't read process name to filter it.")
+		return true
+	}
+	return ps.nameFilters.Accepts(name)
+}
+
+// decorateCPUThrottling adds the CPU throttling stats of the container cgroup to a contained process sample.
+// Stats are cached by container ID for the current sampling, a nil entry meaning they couldn't be read.
+func (ps *processSampler) decorateCPUThrottling(s *types.ProcessSample, cache map[string]*cpuThrottling) {
+	throttling, ok := cache[s.ContainerID]
+	if !ok {
+		stats, err := readCPUThrottling(s.ProcessID)
+		if err != nil {
+			mplog.WithError(err).WithField("containerID", s.ContainerID).Debug("Can't read container CPU throttling.")
+		} else {
+			throttling = &stats
+		}
+		cache[s.ContainerID] = throttling
+	}
+
+	if throttling != nil {
+		s.ContainerCPUThrottledPeriods = &throttling.nrThrottled
+		s.ContainerCPUThrottledTimeMs = &throttling.throttledTimeMs
+	}
+}
+
+// decorateSocketCount adds the number of network sockets open by the process to its sample. Processes whose file
+// descriptors can't be read, like the ones of other users, are reported without it.
+func (ps *processSampler) decorateSocketCount(s *types.ProcessSample, cache map[string]socketInodes) {
+	count, err := countNetSockets(s.ProcessID, cache)
+	if err != nil {
+		mplog.WithError(err).WithField("processID", s.ProcessID).Debug("Can't count process network sockets.")
+		return
+	}
+	s.SocketCount = &count
+}
+
 func (ps *processSampler) normalizeSample(s *types.ProcessSample) sample.Event {
 	if len(s.ContainerLabels) > 0 {
 		sb, err := json.Marshal(s)
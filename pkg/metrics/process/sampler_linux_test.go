@@ -6,6 +6,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
 	"time"
 
@@ -128,6 +131,138 @@ func TestProcessSampler_Sample_DockerDecoratorEnabledWithNoConfig(t *testing.T)
 	assert.Equal(t, expected, sampler.containerSamplers)
 }
 
+func TestProcessSampler_Sample_UserAggregation(t *testing.T) {
+	ctx := new(mocks.AgentContext)
+	ctx.On("Config").Return(&config.Config{ProcessUserAggregation: true})
+	ps := NewProcessSampler(ctx).(*processSampler) //nolint:forcetypeassert
+	ps.containerSamplers = nil
+	ps.harvest = &harvesterMock{samples: map[int32]*types.ProcessSample{
+		1: {ProcessID: 1, User: "root", CPUPercent: 1, MemoryRSSBytes: 100},
+		2: {ProcessID: 2, User: "root", CPUPercent: 2, MemoryRSSBytes: 200},
+		3: {ProcessID: 3, User: "nobody", CPUPercent: 4, MemoryRSSBytes: 400},
+	}}
+
+	samples, err := ps.Sample()
+	require.NoError(t, err)
+	require.Len(t, samples, 5)
+
+	var userSamples []*types.ProcessUserSample
+	for _, s := range samples {
+		if userSample, ok := s.(*types.ProcessUserSample); ok {
+			userSamples = append(userSamples, userSample)
+		}
+	}
+	require.Len(t, userSamples, 2)
+	assert.Equal(t, "nobody", userSamples[0].User)
+	assert.Equal(t, 1, userSamples[0].ProcessCount)
+	assert.Equal(t, "root", userSamples[1].User)
+	assert.Equal(t, 2, userSamples[1].ProcessCount)
+	assert.InDelta(t, 3, userSamples[1].CPUPercent, 0.0001)
+	assert.Equal(t, int64(300), userSamples[1].MemoryRSSBytes)
+}
+
+func TestProcessSampler_Sample_NameFilters(t *testing.T) {
+	ctx := new(mocks.AgentContext)
+	ctx.On("Config").Return(&config.Config{ProcessNameFilters: config.ProcessNameFilters{
+		Include: []config.ProcessNameRegexp{{Regexp: regexp.MustCompile("^(nginx|java)$")}, {Regexp: regexp.MustCompile("^kworker")}},
+		Exclude: []config.ProcessNameRegexp{{Regexp: regexp.MustCompile("^kworker")}},
+	}})
+	ps := NewProcessSampler(ctx).(*processSampler) //nolint:forcetypeassert
+	ps.containerSamplers = nil
+	harvester := &countingHarvesterMock{harvesterMock: harvesterMock{samples: map[int32]*types.ProcessSample{
+		1: {ProcessID: 1, CommandName: "nginx"},
+		2: {ProcessID: 2, CommandName: "kworker/0:1"},
+		3: {ProcessID: 3, CommandName: "bash"},
+		4: {ProcessID: 4, CommandName: "java"},
+		5: {ProcessID: 5, CommandName: "gone"},
+	}}}
+	ps.harvest = harvester
+	ps.commandName = func(pid int32) (string, error) {
+		if pid == 5 {
+			return "", errors.New("no such process")
+		}
+		return harvester.samples[pid].CommandName, nil
+	}
+
+	samples, err := ps.Sample()
+	require.NoError(t, err)
+
+	var sampled []int32
+	for _, s := range samples {
+		sampled = append(sampled, s.(*types.ProcessSample).ProcessID) //nolint:forcetypeassert
+	}
+	// processes whose name can't be read are left to the harvester
+	assert.ElementsMatch(t, []int32{1, 4, 5}, sampled)
+	// filtered processes are not harvested
+	assert.ElementsMatch(t, []int32{1, 4, 5}, harvester.harvested)
+}
+
+func TestProcessSampler_Sample_SocketCount(t *testing.T) {
+	mockSocketsHost(t, map[int32]mockProcess{
+		1: {netNS: "net:[4026531992]", tables: hostNSTables(), fds: []string{"socket:[1001]", "socket:[1002]", "/dev/null"}},
+		2: {netNS: "net:[4026531992]", tables: hostNSTables(), fds: []string{"/dev/null"}},
+		// file descriptors can't be read
+		3: {netNS: "net:[4026531992]", tables: hostNSTables()},
+	})
+
+	ctx := new(mocks.AgentContext)
+	ctx.On("Config").Return(&config.Config{EnableProcessSocketCount: true})
+	ps := NewProcessSampler(ctx).(*processSampler) //nolint:forcetypeassert
+	ps.containerSamplers = nil
+	ps.harvest = &harvesterMock{samples: map[int32]*types.ProcessSample{
+		1: {ProcessID: 1},
+		2: {ProcessID: 2},
+		3: {ProcessID: 3},
+	}}
+
+	samples, err := ps.Sample()
+	require.NoError(t, err)
+
+	socketCounts := map[int32]*int32{}
+	for _, s := range samples {
+		processSample := s.(*types.ProcessSample) //nolint:forcetypeassert
+		socketCounts[processSample.ProcessID] = processSample.SocketCount
+	}
+	// processes whose sockets can't be counted are reported without them
+	require.Len(t, socketCounts, 3)
+	require.NotNil(t, socketCounts[1])
+	assert.Equal(t, int32(2), *socketCounts[1])
+	require.NotNil(t, socketCounts[2])
+	assert.Equal(t, int32(0), *socketCounts[2])
+	assert.Nil(t, socketCounts[3])
+}
+
+func TestProcessSampler_Sample_SocketCountDisabled(t *testing.T) {
+	mockSocketsHost(t, map[int32]mockProcess{
+		1: {netNS: "net:[4026531992]", tables: hostNSTables(), fds: []string{"socket:[1001]"}},
+	})
+
+	ctx := new(mocks.AgentContext)
+	ctx.On("Config").Return(&config.Config{})
+	ps := NewProcessSampler(ctx).(*processSampler) //nolint:forcetypeassert
+	ps.containerSamplers = nil
+	ps.harvest = &harvesterMock{samples: map[int32]*types.ProcessSample{1: {ProcessID: 1}}}
+
+	samples, err := ps.Sample()
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Nil(t, samples[0].(*types.ProcessSample).SocketCount) //nolint:forcetypeassert
+}
+
+func TestReadCommandName(t *testing.T) {
+	procDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procDir, "42"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(procDir, "42", "comm"), []byte("my app\n"), 0644))
+	t.Setenv("HOST_PROC", procDir)
+
+	name, err := readCommandName(42)
+	require.NoError(t, err)
+	assert.Equal(t, "my app", name)
+
+	_, err = readCommandName(43)
+	assert.Error(t, err)
+}
+
 type harvesterMock struct {
 	samples map[int32]*types.ProcessSample
 }
@@ -144,6 +279,16 @@ func (hm *harvesterMock) Do(pid int32, _ float64) (*types.ProcessSample, error)
 	return hm.samples[pid], nil
 }
 
+type countingHarvesterMock struct {
+	harvesterMock
+	harvested []int32
+}
+
+func (hm *countingHarvesterMock) Do(pid int32, elapsedSeconds float64) (*types.ProcessSample, error) {
+	hm.harvested = append(hm.harvested, pid)
+	return hm.harvesterMock.Do(pid, elapsedSeconds)
+}
+
 func BenchmarkProcessSampler(b *testing.B) {
 	pm := NewProcessSampler(&dummyAgentContext{})
 
@@ -164,6 +309,34 @@ func BenchmarkProcessSampler_NoCache(b *testing.B) {
 	}
 }
 
+// Tests procs monitor when the process name filters include every process, so they are all harvested
+func BenchmarkProcessSampler_NameFilters_IncludeAll(b *testing.B) {
+	pm := NewProcessSampler(&dummyAgentContext{
+		cfg: &config.Config{
+			ProcessNameFilters: config.ProcessNameFilters{
+				Include: []config.ProcessNameRegexp{{Regexp: regexp.MustCompile(".*")}},
+			},
+		}})
+
+	for i := 0; i < b.N; i++ {
+		_, _ = pm.Sample()
+	}
+}
+
+// Tests procs monitor when the process name filters exclude every process, so none of them is harvested
+func BenchmarkProcessSampler_NameFilters_ExcludeAll(b *testing.B) {
+	pm := NewProcessSampler(&dummyAgentContext{
+		cfg: &config.Config{
+			ProcessNameFilters: config.ProcessNameFilters{
+				Exclude: []config.ProcessNameRegexp{{Regexp: regexp.MustCompile(".*")}},
+			},
+		}})
+
+	for i := 0; i < b.N; i++ {
+		_, _ = pm.Sample()
+	}
+}
+
 // DummyAgentContext replaces mock agent context because mocks management can have impact in benchmarks
 type dummyAgentContext struct {
 	agent.AgentContext
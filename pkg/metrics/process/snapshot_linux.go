@@ -46,6 +46,8 @@ var getEntCommand = helpers.RunCommand //nolint:gochecknoglobals
 var (
 	errMalformedGetentEntry  = errors.New("malformed getent entry")
 	errInvalidUidsForProcess = errors.New("invalid uids for process")
+	errBootTimeNotFound      = errors.New("btime entry not found")
+	errUserNotInPasswd       = errors.New("user not found in passwd file")
 )
 
 func init() {
@@ -104,6 +106,15 @@ func (pw *linuxProcess) Pid() int32 {
 func (pw *linuxProcess) Username() (string, error) {
 	var err error
 	if pw.user == "" { // caching user
+		// when the host etc folder is overridden, the users are looked up in its passwd file
+		if os.Getenv("HOST_ETC") != "" {
+			if uid, err := pw.uid(); err == nil {
+				if pw.user, err = usernameFromPasswd(helpers.HostEtc("passwd"), uid); err == nil {
+					return pw.user, nil
+				}
+			}
+		}
+
 		// try to get it from gopsutil and return it if ok
 		pw.user, err = pw.process.Username()
 		if err == nil {
@@ -138,6 +149,26 @@ func (pw *linuxProcess) uid() (int32, error) {
 	return uuids[0], nil
 }
 
+// usernameFromPasswd returns the username for the uid from a passwd file.
+// passwd format example:
+// deleteme:x:63367:63367:Dynamic User:/:/usr/sbin/nologin
+func usernameFromPasswd(path string, uid int32) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	uidStr := strconv.Itoa(int(uid))
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) > 2 && fields[2] == uidStr && fields[0] != "" {
+			return fields[0], nil
+		}
+	}
+
+	return "", errUserNotInPasswd
+}
+
 // usernameFromGetent returns the username using getent https://man7.org/linux/man-pages/man1/getent.1.html
 // getent passwd format example:
 // deleteme:x:63367:63367:Dynamic User:/:/usr/sbin/nologin
@@ -167,17 +198,57 @@ func (pw *linuxProcess) NumFDs() (int32, error) {
 	if !pw.privileged {
 		return -1, nil
 	}
-	pid := pw.process.Pid
-	statPath := helpers.HostProc(strconv.Itoa(int(pid)), "fd")
+	statPath := helpers.HostProc(strconv.Itoa(int(pw.pid)), "fd")
 	d, err := os.Open(statPath)
 	if err != nil {
 		return 0, err
 	}
 	defer d.Close()
 	fnames, err := d.Readdirnames(-1)
+	if err != nil {
+		return 0, err
+	}
 	return int32(len(fnames)), nil
 }
 
+// readThreadCount returns the number of threads of the process, from the Threads field of /proc/<pid>/status, or
+// counting the entries of /proc/<pid>/task when the field can't be read.
+func readThreadCount(pid int32) (int32, error) {
+	pidDir := strconv.Itoa(int(pid))
+	if content, err := ioutil.ReadFile(helpers.HostProc(pidDir, "status")); err == nil {
+		if threads, ok := parseStatusThreads(string(content)); ok {
+			return threads, nil
+		}
+	}
+
+	d, err := os.Open(helpers.HostProc(pidDir, "task"))
+	if err != nil {
+		return 0, err
+	}
+	defer d.Close()
+	tasks, err := d.Readdirnames(-1)
+	if err != nil {
+		return 0, err
+	}
+	return int32(len(tasks)), nil
+}
+
+// parseStatusThreads returns the value of the Threads field of the content of a /proc/<pid>/status file.
+func parseStatusThreads(content string) (int32, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		value, found := strings.CutPrefix(line, "Threads:")
+		if !found {
+			continue
+		}
+		threads, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+		if err != nil {
+			return 0, false
+		}
+		return int32(threads), true
+	}
+	return 0, false
+}
+
 /////////////////////////////
 // Data to be derived from /proc/<pid>/stat
 /////////////////////////////
@@ -189,6 +260,7 @@ type procStats struct {
 	state      string
 	vmRSS      int64
 	vmSize     int64
+	startTime  int64 // clock ticks since boot
 	cpu        CPUInfo
 }
 
@@ -200,6 +272,7 @@ const (
 	statUtime      = 11
 	statStime      = 12
 	statNumThreads = 17
+	statStartTime  = 19
 	statVsize      = 20
 	statRss        = 21
 )
@@ -268,6 +341,12 @@ func parseProcStat(content string) (procStats, error) {
 	}
 	stats.numThreads = int32(nthreads)
 
+	// Start time, in clock ticks since boot
+	stats.startTime, err = strconv.ParseInt(fields[statStartTime], 10, 64)
+	if err != nil {
+		return stats, errors.Wrapf(err, "for stats: %s", string(content))
+	}
+
 	// VM Memory size
 	stats.vmSize, err = strconv.ParseInt(fields[statVsize], 10, 64)
 	if err != nil {
@@ -337,6 +416,33 @@ func (pw *linuxProcess) Command() string {
 	return pw.stats.command
 }
 
+// StartTime returns the time the process started, given the host boot time.
+func (pw *linuxProcess) StartTime(bootTime time.Time) time.Time {
+	return bootTime.Add(time.Duration(pw.stats.startTime) * time.Second / time.Duration(clockTicks))
+}
+
+// readBootTime returns the host boot time from the btime entry of the /proc/stat file.
+func readBootTime() (time.Time, error) {
+	content, err := ioutil.ReadFile(helpers.HostProc("stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "btime" {
+			continue
+		}
+		btime, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "for btime: %s", line)
+		}
+		return time.Unix(btime, 0), nil
+	}
+
+	return time.Time{}, errBootTimeNotFound
+}
+
 //////////////////////////
 // Data to be derived from /proc/<pid>/cmdline: command line, and command line without arguments
 //////////////////////////
@@ -378,3 +484,13 @@ func (pw *linuxProcess) CmdLine(withArgs bool) (string, error) {
 	pw.cmdLine = helpers.SanitizeCommandLine(string(cmdLineBytes))
 	return pw.cmdLine, nil
 }
+
+// readCommandName returns the command name of a process, as reported in the commandName attribute, from
+// /proc/<pid>/comm. It's much cheaper than creating the process snapshot, so processes can be filtered by name.
+func readCommandName(pid int32) (string, error) {
+	comm, err := ioutil.ReadFile(helpers.HostProc(strconv.Itoa(int(pid)), "comm"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(comm), "\n"), nil
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/newrelic/infrastructure-agent/pkg/helpers"
 
@@ -143,6 +144,7 @@ func TestParseProcStatMultipleWordsProcess(t *testing.T) {
 		state:      "S",
 		vmRSS:      87003136,
 		vmSize:     1005015040,
+		startTime:  6384148,
 		cpu: CPUInfo{
 			Percent: 0,
 			User:    3.78,
@@ -165,6 +167,7 @@ func TestParseProcStatSingleWordProcess(t *testing.T) {
 		state:      "S",
 		vmRSS:      18391040,
 		vmSize:     464912384,
+		startTime:  1071,
 		cpu: CPUInfo{
 			Percent: 0,
 
@@ -184,13 +187,13 @@ func TestParseProcStatUntrimmedCommand(t *testing.T) {
 		expected procStats
 	}{{
 		input:    "11155 (/usr/bin/spamd ) S 1 11155 11155 0 -1 1077944640 19696 1028 0 0 250 32 0 0 20 0 1 0 6285571 300249088 18439 18446744073709551615 4194304 4198572 140721992060048 140721992059288 139789215727443 0 0 4224 92163 18446744072271262725 0 0 17 1 0 0 0 0 0 6298944 6299796 18743296 140721992060730 140721992060807 140721992060807 140721992060905 0\n",
-		expected: procStats{command: "/usr/bin/spamd ", state: "S", ppid: 1, cpu: CPUInfo{User: 2.50, System: 0.32}, numThreads: 1, vmSize: 300249088, vmRSS: 18439 * pageSize},
+		expected: procStats{command: "/usr/bin/spamd ", state: "S", ppid: 1, cpu: CPUInfo{User: 2.50, System: 0.32}, numThreads: 1, vmSize: 300249088, vmRSS: 18439 * pageSize, startTime: 6285571},
 	}, {
 		input:    "11159 (spamd child) S 11155 11155 11155 0 -1 1077944384 459 0 0 0 1 0 0 0 20 0 1 0 6285738 300249088 17599 18446744073709551615 4194304 4198572 140721992060048 140721992059288 139789215727443 0 0 4224 2048 18446744072271262725 0 0 17 0 0 0 0 0 0 6298944 6299796 18743296 140721992060730 140721992060807 140721992060807 140721992060905 0\n",
-		expected: procStats{command: "spamd child", state: "S", ppid: 11155, cpu: CPUInfo{User: 0.01, System: 0}, numThreads: 1, vmSize: 300249088, vmRSS: 17599 * pageSize},
+		expected: procStats{command: "spamd child", state: "S", ppid: 11155, cpu: CPUInfo{User: 0.01, System: 0}, numThreads: 1, vmSize: 300249088, vmRSS: 17599 * pageSize, startTime: 6285738},
 	}, {
 		input:    "11160 ( spamd child) S 11155 11155 11155 0 -1 1077944384 459 0 0 0 0 0 0 0 20 0 1 0 6285738 300249088 17599 18446744073709551615 4194304 4198572 140721992060048 140721992059288 139789215727443 0 0 4224 2048 18446744072271262725 0 0 17 0 0 0 0 0 0 6298944 6299796 18743296 140721992060730 140721992060807 140721992060807 140721992060905 0\n",
-		expected: procStats{command: " spamd child", state: "S", ppid: 11155, cpu: CPUInfo{User: 0, System: 0}, numThreads: 1, vmSize: 300249088, vmRSS: 17599 * pageSize},
+		expected: procStats{command: " spamd child", state: "S", ppid: 11155, cpu: CPUInfo{User: 0, System: 0}, numThreads: 1, vmSize: 300249088, vmRSS: 17599 * pageSize, startTime: 6285738},
 	}}
 
 	for n, c := range cases {
@@ -202,6 +205,41 @@ func TestParseProcStatUntrimmedCommand(t *testing.T) {
 	}
 }
 
+func TestLinuxProcess_StartTimeAndPpid(t *testing.T) {
+	hostProc := os.Getenv("HOST_PROC")
+	defer os.Setenv("HOST_PROC", hostProc)
+	tmpDir := t.TempDir()
+	processDir := path.Join(tmpDir, "1232")
+	require.NoError(t, os.MkdirAll(processDir, 0o755))
+	_ = os.Setenv("HOST_PROC", tmpDir)
+
+	procStat := "cpu  2255 34 2290 22625563 6290 127 456 0 0 0\nbtime 1700000000\nprocesses 2915\n"
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "stat"), []byte(procStat), 0o600))
+	pidStat := "1232 (newrelic-infra) S 1 1232 1232 0 -1 1077960960 4799 282681 88 142 24 15 193 94 20 0 12 0 12345 464912384 4490 18446744073709551615 1 1 0 0 0 0 0 0 2143420159 0 0 0 17 0 0 0 14 0 0 0 0 0 0 0 0 0 0"
+	require.NoError(t, ioutil.WriteFile(path.Join(processDir, "stat"), []byte(pidStat), 0o600))
+
+	stats, err := readProcStat(1232)
+	require.NoError(t, err)
+	bootTime, err := readBootTime()
+	require.NoError(t, err)
+
+	lp := linuxProcess{pid: 1232, stats: stats}
+	assert.Equal(t, int32(1), lp.Ppid())
+	assert.Equal(t, time.Unix(1700000000, 0), bootTime)
+	assert.Equal(t, bootTime.Add(time.Duration(12345)*time.Second/time.Duration(clockTicks)), lp.StartTime(bootTime))
+}
+
+func TestReadBootTime_NotFound(t *testing.T) {
+	hostProc := os.Getenv("HOST_PROC")
+	defer os.Setenv("HOST_PROC", hostProc)
+	tmpDir := t.TempDir()
+	_ = os.Setenv("HOST_PROC", tmpDir)
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "stat"), []byte("cpu  2255 34 2290\n"), 0o600))
+
+	_, err := readBootTime()
+	assert.ErrorIs(t, err, errBootTimeNotFound)
+}
+
 func Test_usernameFromGetent(t *testing.T) { //nolint:paralleltest
 	testCases := []struct {
 		name             string
@@ -246,3 +284,25 @@ func Test_usernameFromGetent(t *testing.T) { //nolint:paralleltest
 		})
 	}
 }
+
+func Test_usernameFromPasswd(t *testing.T) {
+	passwd := path.Join(t.TempDir(), "passwd")
+	require.NoError(t, os.WriteFile(passwd, []byte(
+		"root:x:0:0:root:/root:/bin/bash\n"+
+			"deleteme:x:63367:63367:Dynamic User:/:/usr/sbin/nologin\n"+
+			"malformed line\n"), 0o600))
+
+	username, err := usernameFromPasswd(passwd, 63367)
+	require.NoError(t, err)
+	assert.Equal(t, "deleteme", username)
+
+	username, err = usernameFromPasswd(passwd, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "root", username)
+
+	_, err = usernameFromPasswd(passwd, 1000)
+	assert.ErrorIs(t, err, errUserNotInPasswd)
+
+	_, err = usernameFromPasswd(path.Join(t.TempDir(), "missing"), 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package process
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+)
+
+// netSocketTables are the /proc/<pid>/net files listing the network sockets of a network namespace.
+var netSocketTables = []string{"tcp", "tcp6", "udp", "udp6"} //nolint:gochecknoglobals
+
+// socketInodes is the set of inodes of the network sockets of a network namespace.
+type socketInodes map[uint64]struct{}
+
+// countNetSockets returns the number of network sockets open by the process, correlating the socket inodes of the
+// /proc/<pid>/fd links with the ones listed in the network tables of the process network namespace. The tables are
+// cached by namespace for the current sampling.
+func countNetSockets(pid int32, cache map[string]socketInodes) (int32, error) {
+	pidDir := strconv.Itoa(int(pid))
+	fdDir := helpers.HostProc(pidDir, "fd")
+
+	d, err := os.Open(fdDir)
+	if err != nil {
+		return 0, err
+	}
+	defer d.Close()
+	fds, err := d.Readdirnames(-1)
+	if err != nil {
+		return 0, err
+	}
+
+	// the tables of processes whose namespace can't be identified are read for them only
+	netNS, nsErr := os.Readlink(helpers.HostProc(pidDir, "ns", "net"))
+	inodes, ok := cache[netNS]
+	if nsErr != nil || !ok {
+		inodes = readSocketInodes(pidDir)
+		if nsErr == nil {
+			cache[netNS] = inodes
+		}
+	}
+
+	var count int32
+	for _, fd := range fds {
+		// closed since the directory was listed, or not a socket
+		link, err := os.Readlink(helpers.HostProc(pidDir, "fd", fd))
+		if err != nil {
+			continue
+		}
+		inode, ok := parseSocketLink(link)
+		if !ok {
+			continue
+		}
+		if _, ok := inodes[inode]; ok {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// readSocketInodes reads the inodes of the sockets listed in the network tables of the process network namespace.
+// Tables that can't be read, as the IPv6 ones when it's disabled, are skipped.
+func readSocketInodes(pidDir string) socketInodes {
+	inodes := socketInodes{}
+	for _, table := range netSocketTables {
+		f, err := os.Open(helpers.HostProc(pidDir, "net", table))
+		if err != nil {
+			continue
+		}
+		parseSocketTable(bufio.NewScanner(f), inodes)
+		_ = f.Close()
+	}
+	return inodes
+}
+
+// parseSocketTable adds the inodes of a /proc/net/{tcp,udp}[6] table to the set. After the header line, each line
+// follows the format "sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...".
+func parseSocketTable(scanner *bufio.Scanner, inodes socketInodes) {
+	const inodeField = 9
+
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) <= inodeField {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[inodeField], 10, 64)
+		if err != nil || inode == 0 {
+			continue
+		}
+		inodes[inode] = struct{}{}
+	}
+}
+
+// parseSocketLink returns the inode of a "socket:[<inode>]" file descriptor link.
+func parseSocketLink(link string) (uint64, bool) {
+	if !strings.HasPrefix(link, "socket:[") || !strings.HasSuffix(link, "]") {
+		return 0, false
+	}
+	inode, err := strconv.ParseUint(link[len("socket:["):len(link)-1], 10, 64)
+	return inode, err == nil
+}
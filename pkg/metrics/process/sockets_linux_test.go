@@ -0,0 +1,143 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package process
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	tcpTableHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	udpTableHeader = "   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops\n"
+)
+
+// mockProcess describes a process of the mocked proc tree.
+type mockProcess struct {
+	netNS  string
+	fds    []string
+	tables map[string]string
+}
+
+func mockSocketsHost(t *testing.T, processes map[int32]mockProcess) {
+	t.Helper()
+
+	hostProc := os.Getenv("HOST_PROC")
+	t.Cleanup(func() {
+		_ = os.Setenv("HOST_PROC", hostProc)
+	})
+
+	procDir, err := ioutil.TempDir("", "proc")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(procDir)
+	})
+	_ = os.Setenv("HOST_PROC", procDir)
+
+	for pid, p := range processes {
+		pidDir := path.Join(procDir, strconv.Itoa(int(pid)))
+		require.NoError(t, os.MkdirAll(path.Join(pidDir, "net"), 0o755))
+		if p.netNS != "" {
+			require.NoError(t, os.MkdirAll(path.Join(pidDir, "ns"), 0o755))
+			require.NoError(t, os.Symlink(p.netNS, path.Join(pidDir, "ns", "net")))
+		}
+		if p.fds != nil {
+			require.NoError(t, os.MkdirAll(path.Join(pidDir, "fd"), 0o755))
+		}
+		for i, link := range p.fds {
+			require.NoError(t, os.Symlink(link, path.Join(pidDir, "fd", strconv.Itoa(i))))
+		}
+		for table, content := range p.tables {
+			require.NoError(t, ioutil.WriteFile(path.Join(pidDir, "net", table), []byte(content), 0o600))
+		}
+	}
+}
+
+func tableLine(inode uint64) string {
+	return fmt.Sprintf("   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 %d 1 0000000000000000 100 0 0 10 0\n", inode)
+}
+
+func hostNSTables() map[string]string {
+	return map[string]string{
+		"tcp":  tcpTableHeader + tableLine(1001) + tableLine(1002) + tableLine(0),
+		"tcp6": tcpTableHeader + tableLine(1003),
+		"udp":  udpTableHeader + tableLine(1004),
+		// udp6 is missing, as when IPv6 is disabled
+	}
+}
+
+func TestCountNetSockets(t *testing.T) {
+	mockSocketsHost(t, map[int32]mockProcess{
+		// tcp, tcp6 and udp sockets, along with a unix socket, a pipe and a regular file that are not counted
+		100: {netNS: "net:[4026531992]", tables: hostNSTables(), fds: []string{
+			"socket:[1001]", "socket:[1003]", "socket:[1004]", "socket:[9999]", "pipe:[2000]", "/var/log/app.log",
+		}},
+		// no network socket
+		200: {netNS: "net:[4026531992]", tables: hostNSTables(), fds: []string{"/dev/null", "anon_inode:[eventpoll]"}},
+		// a socket of another network namespace, as a container process
+		300: {netNS: "net:[4026532500]", tables: map[string]string{"tcp": tcpTableHeader + tableLine(3001)}, fds: []string{
+			"socket:[3001]", "socket:[1002]",
+		}},
+		// file descriptors can't be read
+		400: {netNS: "net:[4026531992]", tables: hostNSTables()},
+	})
+
+	cache := map[string]socketInodes{}
+
+	count, err := countNetSockets(100, cache)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	count, err = countNetSockets(200, cache)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+
+	count, err = countNetSockets(300, cache)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+
+	_, err = countNetSockets(400, cache)
+	assert.Error(t, err)
+
+	// socket tables are read once per network namespace
+	assert.Len(t, cache, 2)
+	assert.Len(t, cache["net:[4026531992]"], 4)
+}
+
+func TestCountNetSockets_UnknownNetNamespace(t *testing.T) {
+	mockSocketsHost(t, map[int32]mockProcess{
+		100: {tables: hostNSTables(), fds: []string{"socket:[1001]", "socket:[1002]"}},
+		200: {tables: map[string]string{"udp": udpTableHeader + tableLine(2001)}, fds: []string{"socket:[2001]", "socket:[1001]"}},
+	})
+
+	cache := map[string]socketInodes{}
+
+	// the tables of processes whose namespace can't be identified are read for each of them
+	count, err := countNetSockets(100, cache)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+
+	count, err = countNetSockets(200, cache)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+
+	assert.Empty(t, cache)
+}
+
+func TestParseSocketLink(t *testing.T) {
+	inode, ok := parseSocketLink("socket:[12345]")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(12345), inode)
+
+	for _, link := range []string{"pipe:[12345]", "socket:[]", "socket:[abc]", "/tmp/socket:[1]", "socket:[1"} {
+		_, ok = parseSocketLink(link)
+		assert.False(t, ok, link)
+	}
+}
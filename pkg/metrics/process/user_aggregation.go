@@ -0,0 +1,41 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package process
+
+import (
+	"sort"
+
+	"github.com/newrelic/infrastructure-agent/pkg/metrics/types"
+)
+
+// aggregateByUser returns a ProcessUserSample per user, sorted by user name, adding up the process count, CPU and
+// resident memory of their processes. Processes without user are not aggregated.
+func aggregateByUser(samples []*types.ProcessSample) []*types.ProcessUserSample {
+	byUser := map[string]*types.ProcessUserSample{}
+	for _, processSample := range samples {
+		if processSample.User == "" {
+			continue
+		}
+
+		userSample, ok := byUser[processSample.User]
+		if !ok {
+			userSample = &types.ProcessUserSample{User: processSample.User}
+			userSample.Type("ProcessUserSample")
+			byUser[processSample.User] = userSample
+		}
+
+		userSample.ProcessCount++
+		userSample.CPUPercent += processSample.CPUPercent
+		userSample.MemoryRSSBytes += processSample.MemoryRSSBytes
+	}
+
+	userSamples := make([]*types.ProcessUserSample, 0, len(byUser))
+	for _, userSample := range byUser {
+		userSamples = append(userSamples, userSample)
+	}
+	sort.Slice(userSamples, func(i, j int) bool {
+		return userSamples[i].User < userSamples[j].User
+	})
+
+	return userSamples
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package process
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/infrastructure-agent/pkg/metrics/types"
+)
+
+func TestAggregateByUser(t *testing.T) {
+	samples := []*types.ProcessSample{
+		{ProcessID: 1, User: "root", CPUPercent: 1.5, MemoryRSSBytes: 1000},
+		{ProcessID: 2, User: "www-data", CPUPercent: 10, MemoryRSSBytes: 4000},
+		{ProcessID: 3, User: "root", CPUPercent: 2.25, MemoryRSSBytes: 500},
+		{ProcessID: 4, User: "www-data", CPUPercent: 5, MemoryRSSBytes: 6000},
+		{ProcessID: 5, User: "root", CPUPercent: 0, MemoryRSSBytes: 250},
+		{ProcessID: 6, CPUPercent: 50, MemoryRSSBytes: 10000},
+	}
+
+	userSamples := aggregateByUser(samples)
+	require.Len(t, userSamples, 2)
+
+	assert.Equal(t, "ProcessUserSample", userSamples[0].EventType)
+	assert.Equal(t, "root", userSamples[0].User)
+	assert.Equal(t, 3, userSamples[0].ProcessCount)
+	assert.InDelta(t, 3.75, userSamples[0].CPUPercent, 0.0001)
+	assert.Equal(t, int64(1750), userSamples[0].MemoryRSSBytes)
+
+	assert.Equal(t, "www-data", userSamples[1].User)
+	assert.Equal(t, 2, userSamples[1].ProcessCount)
+	assert.InDelta(t, 15, userSamples[1].CPUPercent, 0.0001)
+	assert.Equal(t, int64(10000), userSamples[1].MemoryRSSBytes)
+}
+
+func TestAggregateByUser_NoSamples(t *testing.T) {
+	assert.Empty(t, aggregateByUser(nil))
+}
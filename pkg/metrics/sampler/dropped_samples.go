@@ -0,0 +1,57 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package sampler
+
+import (
+	"sync"
+)
+
+// DroppedSamples counts the samples dropped by the metrics matchers.
+var DroppedSamples = NewDroppedSamplesCounter()
+
+// DroppedSamplesCounter counts the samples dropped by the metrics matchers by sample type, both since the agent
+// started and since the last interval was flushed.
+type DroppedSamplesCounter struct {
+	lock     sync.Mutex
+	total    map[string]uint64
+	interval map[string]uint64
+}
+
+// NewDroppedSamplesCounter creates an empty DroppedSamplesCounter.
+func NewDroppedSamplesCounter() *DroppedSamplesCounter {
+	return &DroppedSamplesCounter{
+		total:    map[string]uint64{},
+		interval: map[string]uint64{},
+	}
+}
+
+// Inc counts a dropped sample of sampleType.
+func (c *DroppedSamplesCounter) Inc(sampleType string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.total[sampleType]++
+	c.interval[sampleType]++
+}
+
+// Counts returns a copy of the dropped samples by sample type since the agent started.
+func (c *DroppedSamplesCounter) Counts() map[string]uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	counts := make(map[string]uint64, len(c.total))
+	for sampleType, count := range c.total {
+		counts[sampleType] = count
+	}
+	return counts
+}
+
+// FlushInterval returns the dropped samples by sample type since the previous call, and starts a new interval.
+func (c *DroppedSamplesCounter) FlushInterval() map[string]uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	counts := c.interval
+	c.interval = map[string]uint64{}
+	return counts
+}
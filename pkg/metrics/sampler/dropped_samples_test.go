@@ -0,0 +1,27 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package sampler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDroppedSamplesCounter(t *testing.T) {
+	c := NewDroppedSamplesCounter()
+	assert.Empty(t, c.Counts())
+	assert.Empty(t, c.FlushInterval())
+
+	c.Inc("ProcessSample")
+	c.Inc("ProcessSample")
+	c.Inc("OtherSample")
+
+	assert.Equal(t, map[string]uint64{"ProcessSample": 2, "OtherSample": 1}, c.FlushInterval())
+	assert.Empty(t, c.FlushInterval())
+
+	c.Inc("ProcessSample")
+
+	assert.Equal(t, map[string]uint64{"ProcessSample": 1}, c.FlushInterval())
+	assert.Equal(t, map[string]uint64{"ProcessSample": 3, "OtherSample": 1}, c.Counts())
+}
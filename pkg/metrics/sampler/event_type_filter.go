@@ -0,0 +1,51 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package sampler
+
+import (
+	"strings"
+
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+)
+
+// EventTypeFilter decides which events are submitted by their event type, as "SystemSample".
+type EventTypeFilter struct {
+	allow map[string]struct{}
+	deny  map[string]struct{}
+}
+
+// NewEventTypeFilter creates a filter submitting only the event types of the allowlist, when not empty, unless they
+// are in the denylist too. It returns nil when both lists are empty, as every event is submitted.
+func NewEventTypeFilter(allowlist, denylist []string) *EventTypeFilter {
+	allow := eventTypeSet(allowlist)
+	deny := eventTypeSet(denylist)
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil
+	}
+
+	return &EventTypeFilter{allow: allow, deny: deny}
+}
+
+// Allows returns whether the event type of the event is submitted. Events that don't expose their type are only
+// submitted when there's no allowlist.
+func (f *EventTypeFilter) Allows(event sample.Event) bool {
+	eventType := sample.TypeOf(event)
+	if _, denied := f.deny[eventType]; denied {
+		return false
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	_, allowed := f.allow[eventType]
+	return allowed
+}
+
+func eventTypeSet(eventTypes []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			set[eventType] = struct{}{}
+		}
+	}
+	return set
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package sampler
+
+import (
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/pkg/metrics/types"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+	"github.com/stretchr/testify/assert"
+)
+
+// untypedEvent doesn't expose its event type.
+type untypedEvent struct {
+	sample.Event
+}
+
+func TestNewEventTypeFilter_NilWhenEmpty(t *testing.T) {
+	assert.Nil(t, NewEventTypeFilter(nil, nil))
+	assert.Nil(t, NewEventTypeFilter([]string{" "}, []string{""}))
+}
+
+func TestEventTypeFilter_Allows(t *testing.T) {
+	systemSample := &sample.BaseEvent{EventType: "SystemSample"}
+	networkSample := &sample.BaseEvent{EventType: "NetworkSample"}
+	flatProcessSample := &types.FlatProcessSample{"eventType": "ProcessSample"}
+
+	allowing := NewEventTypeFilter([]string{" SystemSample ", "ProcessSample"}, nil)
+	assert.True(t, allowing.Allows(systemSample))
+	assert.True(t, allowing.Allows(flatProcessSample))
+	assert.False(t, allowing.Allows(networkSample))
+	assert.False(t, allowing.Allows(untypedEvent{}))
+
+	denying := NewEventTypeFilter(nil, []string{"NetworkSample"})
+	assert.True(t, denying.Allows(systemSample))
+	assert.False(t, denying.Allows(networkSample))
+	assert.True(t, denying.Allows(untypedEvent{}))
+
+	both := NewEventTypeFilter([]string{"SystemSample", "NetworkSample"}, []string{"NetworkSample"})
+	assert.True(t, both.Allows(systemSample))
+	assert.False(t, both.Allows(networkSample))
+}
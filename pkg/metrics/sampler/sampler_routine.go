@@ -9,23 +9,38 @@ import (
 	"sync"
 	"time"
 
+	openmetrics "github.com/newrelic/infrastructure-agent/internal/instrumentation"
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 	"github.com/newrelic/infrastructure-agent/pkg/sample"
 )
 
 type SamplerRoutine struct {
-	name           string
-	stopChannel    chan bool
-	waitForCleanup *sync.WaitGroup
+	name            string
+	stopChannel     chan bool
+	waitForCleanup  *sync.WaitGroup
+	intervalLock    sync.Mutex
+	interval        time.Duration
+	intervalChanged chan struct{} // Notifies the routine to reschedule with the new interval.
 }
 
 var mslog = log.WithField("component", "Sampler routine")
 
+var samplesMetric = openmetrics.AgentMetrics.NewCounterVec(
+	"nria_sampler_samples_total", "Samples produced by the metrics samplers, by sampler.", "sampler")
+
 func StartSamplerRoutine(sampler Sampler, sampleQueue chan sample.EventBatch) *SamplerRoutine {
+	return StartStaggeredSamplerRoutine(sampler, sampleQueue, 0)
+}
+
+// StartStaggeredSamplerRoutine starts a sampler routine that samples for the first time after startOffset, instead of
+// after a whole interval, and then at every interval. Offsets not shorter than the interval are ignored.
+func StartStaggeredSamplerRoutine(sampler Sampler, sampleQueue chan sample.EventBatch, startOffset time.Duration) *SamplerRoutine {
 	sr := &SamplerRoutine{
-		name:           sampler.Name(),
-		stopChannel:    make(chan bool),
-		waitForCleanup: &sync.WaitGroup{},
+		name:            sampler.Name(),
+		stopChannel:     make(chan bool),
+		waitForCleanup:  &sync.WaitGroup{},
+		interval:        sampler.Interval(),
+		intervalChanged: make(chan struct{}, 1),
 	}
 
 	sampler.OnStartup()
@@ -33,7 +48,12 @@ func StartSamplerRoutine(sampler Sampler, sampleQueue chan sample.EventBatch) *S
 	sr.waitForCleanup.Add(1)
 
 	go func() {
-		ticker := time.NewTicker(sampler.Interval())
+		firstFire := sr.Interval()
+		staggered := startOffset > 0 && startOffset < firstFire
+		if staggered {
+			firstFire = startOffset
+		}
+		ticker := time.NewTicker(firstFire)
 		defer func() {
 			ticker.Stop()
 			sr.waitForCleanup.Done()
@@ -42,6 +62,10 @@ func StartSamplerRoutine(sampler Sampler, sampleQueue chan sample.EventBatch) *S
 		for {
 			select {
 			case <-ticker.C:
+				if staggered {
+					staggered = false
+					ticker.Reset(sr.Interval())
+				}
 
 				samples, err := func(s Sampler) (sample.EventBatch, error) {
 					_, trx := instrumentation.SelfInstrumentation.StartTransaction(context.Background(), fmt.Sprintf("sampler.%s", s.Name()))
@@ -53,11 +77,17 @@ func StartSamplerRoutine(sampler Sampler, sampleQueue chan sample.EventBatch) *S
 					mslog.WithError(err).WithField("samplerName", sr.name).Error("can't get sample from sampler")
 					continue
 				}
+				samplesMetric.Add(float64(len(samples)), sr.name)
 				select {
 				case sampleQueue <- samples:
 				case <-sr.stopChannel:
 					return
 				}
+			case <-sr.intervalChanged:
+				interval := sr.Interval()
+				staggered = false
+				ticker.Reset(interval)
+				mslog.WithField("name", sr.name).WithField("interval", interval).Debug("Rescheduled sampler routine.")
 			case <-sr.stopChannel:
 				return
 			}
@@ -67,6 +97,27 @@ func StartSamplerRoutine(sampler Sampler, sampleQueue chan sample.EventBatch) *S
 	return sr
 }
 
+// Interval returns the interval the sampler routine samples at.
+func (sr *SamplerRoutine) Interval() time.Duration {
+	sr.intervalLock.Lock()
+	defer sr.intervalLock.Unlock()
+
+	return sr.interval
+}
+
+// SetInterval reschedules the sampler routine to sample at the given interval, which must be positive.
+func (sr *SamplerRoutine) SetInterval(interval time.Duration) {
+	sr.intervalLock.Lock()
+	sr.interval = interval
+	sr.intervalLock.Unlock()
+
+	// a pending notification already reschedules with the latest interval
+	select {
+	case sr.intervalChanged <- struct{}{}:
+	default:
+	}
+}
+
 func (sr *SamplerRoutine) Stop() {
 	close(sr.stopChannel)
 	sr.waitForCleanup.Wait()
@@ -57,3 +57,52 @@ func TestSamplerRoutine(t *testing.T) {
 		}
 	}
 }
+
+type slowSampler struct {
+	mockSampler
+}
+
+func (m *slowSampler) Interval() time.Duration { return time.Hour }
+
+func TestSamplerRoutine_SetInterval(t *testing.T) {
+	m := &slowSampler{}
+	sampleQueue := make(chan sample.EventBatch)
+	routine := StartSamplerRoutine(m, sampleQueue)
+	defer routine.Stop()
+	assert.Equal(t, time.Hour, routine.Interval())
+
+	routine.SetInterval(time.Microsecond)
+	assert.Equal(t, time.Microsecond, routine.Interval())
+
+	select {
+	case <-sampleQueue:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "sampler routine wasn't rescheduled")
+	}
+}
+
+type hourlySampler struct {
+	slowSampler
+}
+
+func (m *hourlySampler) Sample() (sample.EventBatch, error) { return eventBatch, nil }
+
+func TestStartStaggeredSamplerRoutine(t *testing.T) {
+	m := &hourlySampler{}
+	sampleQueue := make(chan sample.EventBatch)
+	routine := StartStaggeredSamplerRoutine(m, sampleQueue, 10*time.Millisecond)
+	defer routine.Stop()
+
+	select {
+	case <-sampleQueue:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "sampler routine didn't sample at the start offset")
+	}
+	assert.Equal(t, time.Hour, routine.Interval())
+
+	select {
+	case <-sampleQueue:
+		assert.Fail(t, "sampler routine kept sampling at the start offset")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
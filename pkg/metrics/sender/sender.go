@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/log"
 	"github.com/newrelic/infrastructure-agent/pkg/metrics/sampler"
 	"github.com/newrelic/infrastructure-agent/pkg/sample"
@@ -20,6 +21,16 @@ const (
 
 var slog = log.WithField("component", "Metrics Sender")
 
+// samplerSampleRates maps the samplers to their key in the effective sample rates of the configuration.
+var samplerSampleRates = map[string]string{
+	"SystemSampler":            "system",
+	"StorageSampler":           "storage",
+	"NetworkSampler":           "network",
+	"NetworkConnectionSampler": "tcp",
+	"ProcessSampler":           "process",
+	"NFSSampler":               "nfs",
+}
+
 // Sender is responsible for submitting data to the collector endpoint.
 type Sender struct {
 	ctx                  agent.AgentContext
@@ -27,6 +38,9 @@ type Sender struct {
 	stopChannel          chan bool       // Channel will be closed when we want to stop all internal goroutines
 	sampleQueue          chan sample.EventBatch
 	samplers             []sampler.Sampler
+	routinesLock         sync.Mutex
+	samplerRoutines      map[string]*sampler.SamplerRoutine // Running sampler routines, by sampler name.
+	startOffsets         map[string]time.Duration           // Delay of the first sample, by sampler name.
 }
 
 func NewSender(ctx agent.AgentContext) *Sender {
@@ -34,6 +48,7 @@ func NewSender(ctx agent.AgentContext) *Sender {
 		ctx:                  ctx,
 		sampleQueue:          make(chan sample.EventBatch, SAMPLE_QUEUE_CAPACITY),
 		internalRoutineWaits: &sync.WaitGroup{},
+		startOffsets:         make(map[string]time.Duration),
 	}
 }
 
@@ -45,6 +60,11 @@ func (s *Sender) RegisterSampler(sampler sampler.Sampler) {
 	}
 
 	s.samplers = append(s.samplers, sampler)
+
+	// stagger the first sample of every sampler, so they don't fire together at startup
+	if !s.ctx.Config().DisableSamplerStagger {
+		s.startOffsets[sampler.Name()] = config.JitterFrequency(sampler.Interval())
+	}
 }
 
 // Start will register the sender with the collector, then start a couple of background
@@ -82,13 +102,13 @@ func (s *Sender) Stop() (err error) {
 
 // Periodically gather all samples and send them to Insights
 func (s *Sender) scheduleSamplers() {
-	var samplerRoutines []*sampler.SamplerRoutine
-
+	s.routinesLock.Lock()
+	s.samplerRoutines = make(map[string]*sampler.SamplerRoutine, len(s.samplers))
 	for _, t := range s.samplers {
 		slog.WithField("sampler", t.Name()).Debug("Starting sampler")
-		sr := sampler.StartSamplerRoutine(t, s.sampleQueue)
-		samplerRoutines = append(samplerRoutines, sr)
+		s.samplerRoutines[t.Name()] = sampler.StartStaggeredSamplerRoutine(t, s.sampleQueue, s.startOffsets[t.Name()])
 	}
+	s.routinesLock.Unlock()
 
 	for {
 		select {
@@ -101,10 +121,33 @@ func (s *Sender) scheduleSamplers() {
 
 		case <-s.stopChannel:
 			// Stop channel has been closed - exit.
-			for _, sr := range samplerRoutines {
+			s.routinesLock.Lock()
+			for _, sr := range s.samplerRoutines {
 				sr.Stop()
 			}
+			s.samplerRoutines = nil
+			s.routinesLock.Unlock()
 			return
 		}
 	}
 }
+
+// SetSampleRates reschedules the running samplers with the given sample rates, keyed as the configuration effective
+// sample rates. Samplers cannot be enabled nor disabled this way, as that requires restarting the agent.
+func (s *Sender) SetSampleRates(sampleRates map[string]time.Duration) {
+	s.routinesLock.Lock()
+	defer s.routinesLock.Unlock()
+
+	for name, sr := range s.samplerRoutines {
+		interval, ok := sampleRates[samplerSampleRates[name]]
+		if !ok || interval == sr.Interval() {
+			continue
+		}
+		if interval <= config.FREQ_DISABLE_SAMPLING*time.Second {
+			slog.WithField("sampler", name).Warn("Disabling a running sampler requires restarting the agent, ignoring it.")
+			continue
+		}
+		slog.WithField("sampler", name).WithField("interval", interval).Info("Sampler rescheduled.")
+		sr.SetInterval(interval)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package metrics_sender
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/mocks"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+)
+
+type dailySampler struct {
+	name string
+}
+
+func (s *dailySampler) Sample() (sample.EventBatch, error) { return nil, nil }
+func (s *dailySampler) OnStartup()                         {}
+func (s *dailySampler) Name() string                       { return s.name }
+func (s *dailySampler) Interval() time.Duration            { return 24 * time.Hour }
+func (s *dailySampler) Disabled() bool                     { return false }
+
+func newTestSender(cfg *config.Config) *Sender {
+	ctx := new(mocks.AgentContext)
+	ctx.On("Config").Return(cfg)
+	return NewSender(ctx)
+}
+
+func TestSender_RegisterSampler_StaggersStart(t *testing.T) {
+	sender := newTestSender(&config.Config{})
+	sender.RegisterSampler(&dailySampler{name: "FirstSampler"})
+	sender.RegisterSampler(&dailySampler{name: "SecondSampler"})
+
+	first, ok := sender.startOffsets["FirstSampler"]
+	require.True(t, ok)
+	second, ok := sender.startOffsets["SecondSampler"]
+	require.True(t, ok)
+
+	for _, offset := range []time.Duration{first, second} {
+		assert.Greater(t, offset, time.Duration(0))
+		assert.Less(t, offset, 24*time.Hour)
+	}
+	// the offsets are drawn from the 86399 seconds of the interval
+	assert.NotEqual(t, first, second)
+}
+
+func TestSender_RegisterSampler_StaggerDisabled(t *testing.T) {
+	sender := newTestSender(&config.Config{DisableSamplerStagger: true})
+	sender.RegisterSampler(&dailySampler{name: "FirstSampler"})
+
+	assert.Len(t, sender.samplers, 1)
+	assert.Empty(t, sender.startOffsets)
+}
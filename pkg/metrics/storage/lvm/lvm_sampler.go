@@ -0,0 +1,283 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+// Package lvm samples the capacity of the LVM volume groups and logical volumes through the vgs and lvs commands.
+package lvm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+)
+
+var lslog = log.WithComponent("LvmSampler")
+
+const (
+	vgsBin     = "vgs"
+	lvsBin     = "lvs"
+	lvmTimeout = 30 * time.Second
+
+	// first character of the lv_attr of a thin pool
+	thinPoolVolumeType = 't'
+)
+
+// sizes are reported in bytes, without unit suffix
+var lvmReportArgs = []string{"--reportformat", "json", "--units", "b", "--nosuffix"}
+
+// VolumeGroupSample the capacity of a LVM volume group.
+type VolumeGroupSample struct {
+	sample.BaseEvent
+
+	VolumeGroup string  `json:"volumeGroup"`
+	SizeBytes   uint64  `json:"vgSizeBytes"`
+	FreeBytes   uint64  `json:"vgFreeBytes"`
+	UsedBytes   uint64  `json:"vgUsedBytes"`
+	UsedPercent float64 `json:"vgUsedPercent"`
+	// Number of logical and physical volumes of the volume group
+	LogicalVolumes  uint64 `json:"logicalVolumeCount"`
+	PhysicalVolumes uint64 `json:"physicalVolumeCount"`
+}
+
+// LogicalVolumeSample the size of a LVM logical volume, and the usage of thin pools and thin volumes.
+type LogicalVolumeSample struct {
+	sample.BaseEvent
+
+	LogicalVolume string `json:"logicalVolume"`
+	VolumeGroup   string `json:"volumeGroup"`
+	// LVM attributes of the volume, e.g. twi-aotz-- for a thin pool
+	Attributes string `json:"lvAttributes"`
+	// Thin pool of a thin volume
+	ThinPool  string `json:"thinPool,omitempty"`
+	SizeBytes uint64 `json:"lvSizeBytes"`
+	// Percentage of the data and metadata used by thin pools, snapshots and thin volumes
+	DataUsedPercent     *float64 `json:"dataUsedPercent,omitempty"`
+	MetadataUsedPercent *float64 `json:"metadataUsedPercent,omitempty"`
+	// IsThinPool is true for the thin pools, whose exhaustion makes their thin volumes fail
+	IsThinPool bool `json:"isThinPool"`
+}
+
+// Sampler reports the capacity of every LVM volume group and logical volume. It's a no-op when the LVM tools aren't
+// installed.
+type Sampler struct {
+	enabled    bool
+	sampleRate time.Duration
+	lookPath   func(file string) (string, error)
+	// runLvm runs a LVM report command with the arguments and returns its output.
+	runLvm func(command string, arguments ...string) ([]byte, error)
+}
+
+func NewSampler(context agent.AgentContext) *Sampler {
+	sampleRateSec := config.DefaultStorageSamplerRateSecs
+	enabled := false
+	if context != nil {
+		sampleRateSec = context.Config().MetricsStorageSampleRate
+		enabled = context.Config().EnableLvmMetrics
+	}
+
+	return &Sampler{
+		enabled:    enabled,
+		sampleRate: time.Second * time.Duration(sampleRateSec),
+		lookPath:   exec.LookPath,
+		runLvm:     runLvm,
+	}
+}
+
+func (s *Sampler) OnStartup() {
+	if _, err := s.lookPath(vgsBin); err != nil {
+		lslog.WithError(err).Warn("LVM metrics are enabled but the LVM tools are not available, no LVM sample will be reported.")
+	}
+}
+
+func (s *Sampler) Name() string {
+	return "LvmSampler"
+}
+
+func (s *Sampler) Interval() time.Duration {
+	return s.sampleRate
+}
+
+func (s *Sampler) Disabled() bool {
+	return !s.enabled || s.Interval() <= config.FREQ_DISABLE_SAMPLING
+}
+
+func (s *Sampler) Sample() (eventBatch sample.EventBatch, err error) {
+	defer func() {
+		if panicErr := recover(); panicErr != nil {
+			err = fmt.Errorf("Panic in lvm.Sampler: %v\nStack: %s", panicErr, debug.Stack())
+		}
+	}()
+
+	vgs, err := s.lookPath(vgsBin)
+	if err != nil {
+		lslog.WithError(err).Debug("vgs not found, skipping LVM metrics.")
+		return nil, nil
+	}
+	volumeGroups, err := s.volumeGroups(vgs)
+	if err != nil {
+		lslog.WithError(err).Warn("Unable to retrieve the LVM volume groups.")
+		return nil, nil
+	}
+	for _, volumeGroup := range volumeGroups {
+		volumeGroup.Type("LvmVolumeGroupSample")
+		eventBatch = append(eventBatch, volumeGroup)
+	}
+	// no logical volume without volume groups
+	if len(volumeGroups) == 0 {
+		return eventBatch, nil
+	}
+
+	lvs, err := s.lookPath(lvsBin)
+	if err != nil {
+		lslog.WithError(err).Debug("lvs not found, skipping LVM logical volume metrics.")
+		return eventBatch, nil
+	}
+	logicalVolumes, err := s.logicalVolumes(lvs)
+	if err != nil {
+		lslog.WithError(err).Warn("Unable to retrieve the LVM logical volumes.")
+		return eventBatch, nil
+	}
+	for _, logicalVolume := range logicalVolumes {
+		logicalVolume.Type("LvmLogicalVolumeSample")
+		eventBatch = append(eventBatch, logicalVolume)
+	}
+	return eventBatch, nil
+}
+
+// lvmReport the JSON report of the vgs and lvs commands, whose values are all strings.
+type lvmReport struct {
+	Report []struct {
+		VG []map[string]string `json:"vg"`
+		LV []map[string]string `json:"lv"`
+	} `json:"report"`
+}
+
+func (s *Sampler) report(command string, fields string) (report lvmReport, err error) {
+	output, err := s.runLvm(command, append(lvmReportArgs, "--options", fields)...)
+	if err != nil {
+		return report, err
+	}
+	if err = json.Unmarshal(output, &report); err != nil {
+		return report, fmt.Errorf("cannot parse %s report: %w", command, err)
+	}
+	return report, nil
+}
+
+func (s *Sampler) volumeGroups(vgs string) ([]*VolumeGroupSample, error) {
+	report, err := s.report(vgs, "vg_name,vg_size,vg_free,lv_count,pv_count")
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []*VolumeGroupSample
+	for _, r := range report.Report {
+		for _, vg := range r.VG {
+			vgSample, err := newVolumeGroupSample(vg)
+			if err != nil {
+				lslog.WithError(err).WithField("volumeGroup", vg["vg_name"]).Warn("Unable to parse the LVM volume group.")
+				continue
+			}
+			samples = append(samples, vgSample)
+		}
+	}
+	return samples, nil
+}
+
+func newVolumeGroupSample(vg map[string]string) (*VolumeGroupSample, error) {
+	vgSample := &VolumeGroupSample{VolumeGroup: vg["vg_name"]}
+	for field, value := range map[string]*uint64{
+		"vg_size":  &vgSample.SizeBytes,
+		"vg_free":  &vgSample.FreeBytes,
+		"lv_count": &vgSample.LogicalVolumes,
+		"pv_count": &vgSample.PhysicalVolumes,
+	} {
+		parsed, err := strconv.ParseUint(vg[field], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", field, err)
+		}
+		*value = parsed
+	}
+	if vgSample.FreeBytes <= vgSample.SizeBytes {
+		vgSample.UsedBytes = vgSample.SizeBytes - vgSample.FreeBytes
+	}
+	if vgSample.SizeBytes > 0 {
+		vgSample.UsedPercent = float64(vgSample.UsedBytes) / float64(vgSample.SizeBytes) * 100
+	}
+	return vgSample, nil
+}
+
+func (s *Sampler) logicalVolumes(lvs string) ([]*LogicalVolumeSample, error) {
+	report, err := s.report(lvs, "lv_name,vg_name,lv_attr,lv_size,pool_lv,data_percent,metadata_percent")
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []*LogicalVolumeSample
+	for _, r := range report.Report {
+		for _, lv := range r.LV {
+			lvSample, err := newLogicalVolumeSample(lv)
+			if err != nil {
+				lslog.WithError(err).WithField("logicalVolume", lv["lv_name"]).Warn("Unable to parse the LVM logical volume.")
+				continue
+			}
+			samples = append(samples, lvSample)
+		}
+	}
+	return samples, nil
+}
+
+func newLogicalVolumeSample(lv map[string]string) (*LogicalVolumeSample, error) {
+	size, err := strconv.ParseUint(lv["lv_size"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lv_size: %w", err)
+	}
+	lvSample := &LogicalVolumeSample{
+		LogicalVolume: lv["lv_name"],
+		VolumeGroup:   lv["vg_name"],
+		Attributes:    lv["lv_attr"],
+		ThinPool:      lv["pool_lv"],
+		SizeBytes:     size,
+		IsThinPool:    len(lv["lv_attr"]) > 0 && lv["lv_attr"][0] == thinPoolVolumeType,
+	}
+	// the percentages are empty for the volumes that don't track them
+	if lvSample.DataUsedPercent, err = parsePercent(lv["data_percent"]); err != nil {
+		return nil, fmt.Errorf("invalid data_percent: %w", err)
+	}
+	if lvSample.MetadataUsedPercent, err = parsePercent(lv["metadata_percent"]); err != nil {
+		return nil, fmt.Errorf("invalid metadata_percent: %w", err)
+	}
+	return lvSample, nil
+}
+
+func parsePercent(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &percent, nil
+}
+
+// runLvm runs a LVM report command, bounded by a timeout as it may wait for the LVM locks.
+func runLvm(command string, arguments ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lvmTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, command, arguments...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", command, err)
+	}
+	return output, nil
+}
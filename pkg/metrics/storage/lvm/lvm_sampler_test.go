@@ -0,0 +1,126 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package lvm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVgs reports a volume group and a volume group with an invalid size, in bytes only when requested.
+const fakeVgs = `#!/bin/sh
+case "$*" in
+*"--units b --nosuffix"*) ;;
+*) echo "unexpected arguments: $*" >&2; exit 5 ;;
+esac
+printf '%s\n' '{"report": [{"vg": [
+  {"vg_name": "vg0", "vg_size": "107374182400", "vg_free": "26843545600", "lv_count": "3", "pv_count": "2"},
+  {"vg_name": "broken", "vg_size": "1.00g", "vg_free": "0", "lv_count": "0", "pv_count": "1"}]}]}'
+`
+
+// fakeLvs reports a linear volume, a thin pool and a thin volume.
+const fakeLvs = `#!/bin/sh
+printf '%s\n' '{"report": [{"lv": [
+  {"lv_name": "root", "vg_name": "vg0", "lv_attr": "-wi-ao----", "lv_size": "21474836480", "pool_lv": "", "data_percent": "", "metadata_percent": ""},
+  {"lv_name": "pool", "vg_name": "vg0", "lv_attr": "twi-aotz--", "lv_size": "53687091200", "pool_lv": "", "data_percent": "91.25", "metadata_percent": "12.50"},
+  {"lv_name": "data", "vg_name": "vg0", "lv_attr": "Vwi-aotz--", "lv_size": "107374182400", "pool_lv": "pool", "data_percent": "45.63", "metadata_percent": ""}]}]}'
+`
+
+func installCommands(t *testing.T, commands map[string]string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, script := range commands {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0755))
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestSampler_Sample(t *testing.T) {
+	installCommands(t, map[string]string{vgsBin: fakeVgs, lvsBin: fakeLvs})
+
+	samples, err := NewSampler(nil).Sample()
+	require.NoError(t, err)
+	// the volume group with an invalid size is skipped
+	require.Len(t, samples, 4)
+
+	vg := samples[0].(*VolumeGroupSample)
+	assert.Equal(t, "LvmVolumeGroupSample", vg.EventType)
+	assert.Equal(t, "vg0", vg.VolumeGroup)
+	assert.Equal(t, uint64(107374182400), vg.SizeBytes)
+	assert.Equal(t, uint64(26843545600), vg.FreeBytes)
+	assert.Equal(t, uint64(80530636800), vg.UsedBytes)
+	assert.Equal(t, 75.0, vg.UsedPercent)
+	assert.Equal(t, uint64(3), vg.LogicalVolumes)
+	assert.Equal(t, uint64(2), vg.PhysicalVolumes)
+
+	linear := samples[1].(*LogicalVolumeSample)
+	assert.Equal(t, "LvmLogicalVolumeSample", linear.EventType)
+	assert.Equal(t, "root", linear.LogicalVolume)
+	assert.Equal(t, "vg0", linear.VolumeGroup)
+	assert.Equal(t, uint64(21474836480), linear.SizeBytes)
+	assert.False(t, linear.IsThinPool)
+	assert.Nil(t, linear.DataUsedPercent)
+	assert.Nil(t, linear.MetadataUsedPercent)
+
+	pool := samples[2].(*LogicalVolumeSample)
+	assert.Equal(t, "pool", pool.LogicalVolume)
+	assert.Equal(t, "twi-aotz--", pool.Attributes)
+	assert.True(t, pool.IsThinPool)
+	assert.Equal(t, 91.25, *pool.DataUsedPercent)
+	assert.Equal(t, 12.5, *pool.MetadataUsedPercent)
+
+	thin := samples[3].(*LogicalVolumeSample)
+	assert.Equal(t, "data", thin.LogicalVolume)
+	assert.Equal(t, "pool", thin.ThinPool)
+	assert.False(t, thin.IsThinPool)
+	assert.Equal(t, 45.63, *thin.DataUsedPercent)
+	assert.Nil(t, thin.MetadataUsedPercent)
+}
+
+func TestSampler_Sample_NoLvm(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	samples, err := NewSampler(nil).Sample()
+	assert.NoError(t, err)
+	assert.Empty(t, samples)
+}
+
+func TestSampler_Sample_NoVolumeGroups(t *testing.T) {
+	installCommands(t, map[string]string{
+		vgsBin: "#!/bin/sh\nprintf '{\"report\": [{\"vg\": []}]}'\n",
+		lvsBin: fakeLvs,
+	})
+
+	samples, err := NewSampler(nil).Sample()
+	assert.NoError(t, err)
+	assert.Empty(t, samples)
+}
+
+func TestSampler_Sample_LvsError(t *testing.T) {
+	installCommands(t, map[string]string{vgsBin: fakeVgs, lvsBin: "#!/bin/sh\nexit 5\n"})
+
+	samples, err := NewSampler(nil).Sample()
+	require.NoError(t, err)
+	// the volume groups are still reported
+	require.Len(t, samples, 1)
+	assert.Equal(t, "vg0", samples[0].(*VolumeGroupSample).VolumeGroup)
+}
+
+func TestSampler_Disabled(t *testing.T) {
+	sampler := NewSampler(nil)
+	assert.True(t, sampler.Disabled())
+
+	sampler.enabled = true
+	assert.False(t, sampler.Disabled())
+
+	sampler.sampleRate = -1
+	assert.True(t, sampler.Disabled())
+}
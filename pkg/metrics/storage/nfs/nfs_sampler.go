@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/newrelic/infrastructure-agent/internal/agent"
@@ -25,6 +26,9 @@ type Sampler struct {
 	lastSamples map[string]statsCache
 	sampleRate  time.Duration
 	detailed    bool
+	maxMounts   int
+	// dropped mounts on the last run, to only log them when they change
+	droppedMounts string
 }
 
 type statsCache struct {
@@ -158,6 +162,20 @@ type DetailedSample struct {
 	// A running counter, incremented on each request as the current size of the
 	// pending queue.
 	CumulativePendingQueue *uint64 `json:"cumulativePendingQueue,omitempty"`
+	// Average time in milliseconds between the transmission of the READ requests and their reply, over the
+	// operations of the last interval.
+	ReadRTTMs *float64 `json:"readRttMs,omitempty"`
+	// Average time in milliseconds the READ requests waited in the backlog queue before being transmitted, over the
+	// operations of the last interval.
+	ReadBacklogWaitMs *float64 `json:"readBacklogWaitMs,omitempty"`
+	// Average round trip time in milliseconds of the WRITE requests over the last interval.
+	WriteRTTMs *float64 `json:"writeRttMs,omitempty"`
+	// Average backlog wait in milliseconds of the WRITE requests over the last interval.
+	WriteBacklogWaitMs *float64 `json:"writeBacklogWaitMs,omitempty"`
+	// Average round trip time in milliseconds of the GETATTR requests over the last interval.
+	GetAttrRTTMs *float64 `json:"getattrRttMs,omitempty"`
+	// Average backlog wait in milliseconds of the GETATTR requests over the last interval.
+	GetAttrBacklogWaitMs *float64 `json:"getattrBacklogWaitMs,omitempty"`
 }
 
 func (s *Sampler) OnStartup() {}
@@ -180,7 +198,14 @@ func (s *Sampler) Sample() (eventBatch sample.EventBatch, err error) {
 			err = fmt.Errorf("Panic in nfs.Sampler: %v\nStack: %s", panicErr, debug.Stack())
 		}
 	}()
-	samples, err := populateNFS(s.lastSamples, s.detailed)
+	samples, dropped, err := populateNFS(s.lastSamples, s.detailed, s.maxMounts)
+	if droppedMounts := strings.Join(dropped, ","); droppedMounts != s.droppedMounts {
+		if droppedMounts != "" {
+			sslog.WithField("maxMounts", s.maxMounts).WithField("droppedMounts", droppedMounts).
+				Warn("NFS mounts limit reached, dropping the samples of the remaining mounts.")
+		}
+		s.droppedMounts = droppedMounts
+	}
 	if err != nil {
 		if errors.Is(err, ErrNFSNotFound) {
 			sslog.WithError(err).Debug("Unable to retrieve NFS stats.")
@@ -199,9 +224,11 @@ func (s *Sampler) Sample() (eventBatch sample.EventBatch, err error) {
 func NewSampler(context agent.AgentContext) *Sampler {
 	sampleRateSec := config.DefaultMetricsNFSSampleRate
 	detailed := false
+	maxMounts := config.DefaultMetricsNFSMaxMounts
 	if context != nil {
 		sampleRateSec = context.Config().MetricsNFSSampleRate
 		detailed = context.Config().DetailedNFS
+		maxMounts = context.Config().MetricsNFSMaxMounts
 	}
 
 	return &Sampler{
@@ -209,5 +236,6 @@ func NewSampler(context agent.AgentContext) *Sampler {
 		lastSamples: map[string]statsCache{},
 		sampleRate:  time.Second * time.Duration(sampleRateSec),
 		detailed:    detailed,
+		maxMounts:   maxMounts,
 	}
 }
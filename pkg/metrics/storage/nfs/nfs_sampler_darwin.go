@@ -5,6 +5,6 @@
 
 package nfs
 
-func populateNFS(cache map[string]statsCache, detailed bool) ([]*Sample, error) {
-	return nil, nil
+func populateNFS(cache map[string]statsCache, detailed bool, maxMounts int) ([]*Sample, []string, error) {
+	return nil, nil, nil
 }
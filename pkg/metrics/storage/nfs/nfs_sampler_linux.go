@@ -10,39 +10,57 @@ import (
 	"math"
 	"time"
 
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
 	"github.com/prometheus/procfs"
 	"github.com/shirou/gopsutil/v3/disk"
 )
 
-func populateNFS(cache map[string]statsCache, detailed bool) ([]*Sample, error) {
+// populateNFS samples up to maxMounts NFS mounts, when greater than 0, and returns the mount points of the
+// dropped ones.
+func populateNFS(cache map[string]statsCache, detailed bool, maxMounts int) ([]*Sample, []string, error) {
 	mounts, err := getMounts()
 	if err != nil {
-		return nil, fmt.Errorf("error retrieving mounts for NFS: %s", err)
+		return nil, nil, fmt.Errorf("error retrieving mounts for NFS: %s", err)
 	}
 
 	checkTime := time.Now()
 	samples := []*Sample{}
+	var dropped []string
 	for _, m := range mounts {
 		if m.Type == "nfs" || m.Type == "nfs4" {
+			if maxMounts > 0 && len(samples) >= maxMounts {
+				dropped = append(dropped, m.Mount)
+				continue
+			}
+			lms, cached := cache[m.Mount]
 			sample, err := parseNFSMount(cache, m, checkTime)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			if detailed {
-				parseDetailedNFSStats(sample, m.Stats.(*procfs.MountStatsNFS))
+				ms := m.Stats.(*procfs.MountStatsNFS)
+				parseDetailedNFSStats(sample, ms)
+				if cached {
+					populateNFSLatencyMetrics(lms.last.Operations, ms.Operations, sample)
+				}
 			}
 			samples = append(samples, sample)
 		}
 	}
 	if len(samples) == 0 {
-		return nil, ErrNFSNotFound
+		return nil, nil, ErrNFSNotFound
 	}
 
-	return samples, nil
+	return samples, dropped, nil
 }
 
+// getMounts reads the mounts of the agent process from the HOST_PROC mountstats.
 func getMounts() ([]*procfs.Mount, error) {
-	proc, err := procfs.Self()
+	fs, err := procfs.NewFS(helpers.HostProc())
+	if err != nil {
+		return nil, err
+	}
+	proc, err := fs.Self()
 	if err != nil {
 		return nil, err
 	}
@@ -138,6 +156,38 @@ func populateNFSPerSecMetrics(lms statsCache, ops []procfs.NFSOperationStats, sa
 	sample.WriteBytesPerSec = &writeBytesPerSec
 }
 
+// populateNFSLatencyMetrics sets the average round trip time and backlog wait of the major operations requested
+// since the last run. They are left unset for the operations without requests.
+func populateNFSLatencyMetrics(last, current []procfs.NFSOperationStats, sample *Sample) {
+	lastOps := make(map[string]procfs.NFSOperationStats, len(last))
+	for _, op := range last {
+		lastOps[op.Operation] = op
+	}
+	for _, op := range current {
+		switch op.Operation {
+		case "READ":
+			sample.ReadRTTMs, sample.ReadBacklogWaitMs = nfsOpLatency(lastOps[op.Operation], op)
+		case "WRITE":
+			sample.WriteRTTMs, sample.WriteBacklogWaitMs = nfsOpLatency(lastOps[op.Operation], op)
+		case "GETATTR":
+			sample.GetAttrRTTMs, sample.GetAttrBacklogWaitMs = nfsOpLatency(lastOps[op.Operation], op)
+		}
+	}
+}
+
+func nfsOpLatency(last, current procfs.NFSOperationStats) (rtt *float64, backlogWait *float64) {
+	// counters are reset when the volume is remounted
+	if current.Requests <= last.Requests ||
+		current.CumulativeTotalResponseMilliseconds < last.CumulativeTotalResponseMilliseconds ||
+		current.CumulativeQueueMilliseconds < last.CumulativeQueueMilliseconds {
+		return nil, nil
+	}
+	requests := float64(current.Requests - last.Requests)
+	rtt = parseFloat(float64(current.CumulativeTotalResponseMilliseconds-last.CumulativeTotalResponseMilliseconds) / requests)
+	backlogWait = parseFloat(float64(current.CumulativeQueueMilliseconds-last.CumulativeQueueMilliseconds) / requests)
+	return rtt, backlogWait
+}
+
 func compareNFSOps(last, current []procfs.NFSOperationStats, lastRun, checkTime time.Time) (float64, float64, float64) {
 	lastTotal, lastRead, lastWrite := parseNFSOps(last)
 	currentTotal, currentRead, currentWrite := parseNFSOps(current)
@@ -6,12 +6,17 @@
 package nfs
 
 import (
+	"fmt"
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/prometheus/procfs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_compareNFSOps(t *testing.T) {
@@ -182,3 +187,107 @@ func Test_parseFloat(t *testing.T) {
 		})
 	}
 }
+
+const nfsMountStats = `device 10.0.0.1:/export mounted on %s with fstype nfs4 statvers=1.1
+	opts:	rw,vers=4.1,rsize=1048576,wsize=1048576,proto=tcp
+	age:	3600
+	bytes:	1024 2048 0 0 1024 2048 1 1
+	events:	1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20 21 22 23 24 25 26 27
+	xprt:	tcp 0 1 2 3 4 5 6 7 8 9 10 11 12
+	per-op statistics
+	        NULL: 0 0 0 0 0 0 0 0 0
+	        READ: %d %d 0 0 0 %d %d 0 0
+	       WRITE: %d %d 0 0 0 %d %d 0 0
+	     GETATTR: 10 10 0 0 0 5 20 30 0
+
+`
+
+// writeMountStats writes the agent process mountstats in a fake HOST_PROC, with the READ and WRITE requests and
+// their cumulative queue and response milliseconds.
+func writeMountStats(t *testing.T, procDir string, mountPoints []string, read, write [3]uint64) {
+	t.Helper()
+
+	var mountStats strings.Builder
+	mountStats.WriteString("device proc mounted on /proc with fstype proc\n")
+	for _, mountPoint := range mountPoints {
+		mountStats.WriteString(fmt.Sprintf(nfsMountStats, mountPoint,
+			read[0], read[0], read[1], read[2], write[0], write[0], write[1], write[2]))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(procDir, "1"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(procDir, "1", "mountstats"), []byte(mountStats.String()), 0644))
+	if _, err := os.Lstat(filepath.Join(procDir, "self")); os.IsNotExist(err) {
+		require.NoError(t, os.Symlink("1", filepath.Join(procDir, "self")))
+	}
+	t.Setenv("HOST_PROC", procDir)
+}
+
+func Test_populateNFS_Latency(t *testing.T) {
+	procDir := t.TempDir()
+	mountPoint := t.TempDir()
+	cache := map[string]statsCache{}
+
+	writeMountStats(t, procDir, []string{mountPoint}, [3]uint64{100, 50, 400}, [3]uint64{10, 0, 100})
+	samples, dropped, err := populateNFS(cache, true, 0)
+	require.NoError(t, err)
+	assert.Empty(t, dropped)
+	require.Len(t, samples, 1)
+	assert.Equal(t, mountPoint, *samples[0].Mountpoint)
+	// latencies are only reported from the second run
+	assert.Nil(t, samples[0].ReadRTTMs)
+	assert.Nil(t, samples[0].ReadBacklogWaitMs)
+
+	writeMountStats(t, procDir, []string{mountPoint}, [3]uint64{120, 90, 500}, [3]uint64{10, 0, 100})
+	samples, _, err = populateNFS(cache, true, 0)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, 5.0, *samples[0].ReadRTTMs)
+	assert.Equal(t, 2.0, *samples[0].ReadBacklogWaitMs)
+	// no requests on the interval
+	assert.Nil(t, samples[0].WriteRTTMs)
+	assert.Nil(t, samples[0].WriteBacklogWaitMs)
+	assert.Nil(t, samples[0].GetAttrRTTMs)
+
+	samples, _, err = populateNFS(cache, false, 0)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Nil(t, samples[0].ReadRTTMs)
+	assert.Nil(t, samples[0].Age)
+}
+
+func Test_populateNFS_MaxMounts(t *testing.T) {
+	procDir := t.TempDir()
+	mountPoints := []string{t.TempDir(), t.TempDir(), t.TempDir()}
+	writeMountStats(t, procDir, mountPoints, [3]uint64{}, [3]uint64{})
+
+	samples, dropped, err := populateNFS(map[string]statsCache{}, false, 2)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, mountPoints[0], *samples[0].Mountpoint)
+	assert.Equal(t, mountPoints[1], *samples[1].Mountpoint)
+	assert.Equal(t, []string{mountPoints[2]}, dropped)
+
+	samples, dropped, err = populateNFS(map[string]statsCache{}, false, 0)
+	require.NoError(t, err)
+	assert.Len(t, samples, 3)
+	assert.Empty(t, dropped)
+}
+
+func Test_populateNFS_NotFound(t *testing.T) {
+	writeMountStats(t, t.TempDir(), nil, [3]uint64{}, [3]uint64{})
+
+	_, _, err := populateNFS(map[string]statsCache{}, false, 0)
+	assert.ErrorIs(t, err, ErrNFSNotFound)
+}
+
+func Test_nfsOpLatency(t *testing.T) {
+	last := procfs.NFSOperationStats{Requests: 10, CumulativeQueueMilliseconds: 10, CumulativeTotalResponseMilliseconds: 100}
+
+	rtt, backlogWait := nfsOpLatency(last, procfs.NFSOperationStats{Requests: 14, CumulativeQueueMilliseconds: 12, CumulativeTotalResponseMilliseconds: 110})
+	assert.Equal(t, 2.5, *rtt)
+	assert.Equal(t, 0.5, *backlogWait)
+
+	// counters reset
+	rtt, backlogWait = nfsOpLatency(last, procfs.NFSOperationStats{Requests: 4, CumulativeQueueMilliseconds: 1, CumulativeTotalResponseMilliseconds: 8})
+	assert.Nil(t, rtt)
+	assert.Nil(t, backlogWait)
+}
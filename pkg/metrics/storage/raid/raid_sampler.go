@@ -0,0 +1,146 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+// Package raid samples the state of the software RAID (md) arrays listed in /proc/mdstat.
+package raid
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime/debug"
+	"time"
+
+	"github.com/prometheus/procfs"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/helpers"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+)
+
+var rslog = log.WithComponent("RaidSampler")
+
+// Array states reported by /proc/mdstat. An array being synced reports the sync action instead of "active".
+const (
+	stateRecovering = "recovering"
+	stateResyncing  = "resyncing"
+	stateChecking   = "checking"
+)
+
+// Sample the state of a software RAID array.
+type Sample struct {
+	sample.BaseEvent
+
+	// Array name, e.g. md0
+	Device string `json:"device"`
+	// Array state: active, inactive, recovering, resyncing or checking
+	State string `json:"state"`
+	// Degraded is true when any of the disks the array requires is missing or failed.
+	Degraded bool `json:"degraded"`
+	// Rebuilding is true when the array is recovering onto a disk or resyncing its redundancy.
+	Rebuilding   bool  `json:"rebuilding"`
+	DisksTotal   int64 `json:"disksTotal"`
+	DisksActive  int64 `json:"disksActive"`
+	DisksDown    int64 `json:"disksDown"`
+	DisksFailed  int64 `json:"disksFailed"`
+	DisksSpare   int64 `json:"disksSpare"`
+	BlocksTotal  int64 `json:"blocksTotal"`
+	BlocksSynced int64 `json:"blocksSynced"`
+	// Progress and estimated remaining time of the current recovery, resync or check.
+	SyncPercent       *float64 `json:"syncPercent,omitempty"`
+	SyncFinishMinutes *float64 `json:"syncFinishMinutes,omitempty"`
+}
+
+// Sampler reports the state of every md array. It's a no-op when there are no arrays.
+type Sampler struct {
+	enabled    bool
+	sampleRate time.Duration
+}
+
+func NewSampler(context agent.AgentContext) *Sampler {
+	sampleRateSec := config.DefaultStorageSamplerRateSecs
+	enabled := false
+	if context != nil {
+		sampleRateSec = context.Config().MetricsStorageSampleRate
+		enabled = context.Config().EnableRaidMetrics
+	}
+
+	return &Sampler{
+		enabled:    enabled,
+		sampleRate: time.Second * time.Duration(sampleRateSec),
+	}
+}
+
+func (s *Sampler) OnStartup() {}
+
+func (s *Sampler) Name() string {
+	return "RaidSampler"
+}
+
+func (s *Sampler) Interval() time.Duration {
+	return s.sampleRate
+}
+
+func (s *Sampler) Disabled() bool {
+	return !s.enabled || s.Interval() <= config.FREQ_DISABLE_SAMPLING
+}
+
+func (s *Sampler) Sample() (eventBatch sample.EventBatch, err error) {
+	defer func() {
+		if panicErr := recover(); panicErr != nil {
+			err = fmt.Errorf("Panic in raid.Sampler: %v\nStack: %s", panicErr, debug.Stack())
+		}
+	}()
+
+	arrays, err := mdStat()
+	if errors.Is(err, fs.ErrNotExist) {
+		rslog.Debug("No mdstat file found, skipping RAID metrics.")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, array := range arrays {
+		raidSample := newSample(array)
+		raidSample.Type("RaidSample")
+		eventBatch = append(eventBatch, raidSample)
+	}
+	return eventBatch, nil
+}
+
+// mdStat reads the md arrays from the mdstat file of the host proc filesystem.
+func mdStat() ([]procfs.MDStat, error) {
+	procFS, err := procfs.NewFS(helpers.HostProc())
+	if err != nil {
+		return nil, err
+	}
+	return procFS.MDStat()
+}
+
+func newSample(array procfs.MDStat) *Sample {
+	raidSample := &Sample{
+		Device:       array.Name,
+		State:        array.ActivityState,
+		Degraded:     array.DisksDown > 0 || array.DisksFailed > 0,
+		Rebuilding:   array.ActivityState == stateRecovering || array.ActivityState == stateResyncing,
+		DisksTotal:   array.DisksTotal,
+		DisksActive:  array.DisksActive,
+		DisksDown:    array.DisksDown,
+		DisksFailed:  array.DisksFailed,
+		DisksSpare:   array.DisksSpare,
+		BlocksTotal:  array.BlocksTotal,
+		BlocksSynced: array.BlocksSynced,
+	}
+	if raidSample.Rebuilding || array.ActivityState == stateChecking {
+		syncPercent := array.BlocksSyncedPct
+		syncFinishMinutes := array.BlocksSyncedFinishTime
+		raidSample.SyncPercent = &syncPercent
+		raidSample.SyncFinishMinutes = &syncFinishMinutes
+	}
+	return raidSample
+}
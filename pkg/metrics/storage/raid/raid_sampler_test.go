@@ -0,0 +1,131 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package raid
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mdstat lists a healthy array, a degraded array recovering onto a new disk, an array with a failed disk, an
+// inactive array and an array being resynced.
+const mdstat = `Personalities : [raid1] [raid6] [raid5] [raid4]
+md0 : active raid1 sdb1[1] sda1[0]
+      1046528 blocks super 1.2 [2/2] [UU]
+
+md1 : active raid1 sdd1[2] sdc1[0]
+      1046528 blocks super 1.2 [2/1] [U_]
+      [=====>...............]  recovery = 27.5% (288000/1046528) finish=1.2min speed=10240K/sec
+
+md2 : active raid5 sdg1[3](F) sdf1[1] sde1[0]
+      2093056 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [UU_]
+
+md3 : inactive sdh1[0](S)
+      1046528 blocks super 1.2
+
+md4 : active raid1 sdj1[1] sdi1[0]
+      1046528 blocks super 1.2 [2/2] [UU]
+      [>....................]  resync =  5.0% (52352/1046528) finish=3.1min speed=5235K/sec
+
+unused devices: <none>
+`
+
+func writeMdstat(t *testing.T, content string) {
+	t.Helper()
+
+	proc := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(proc, "mdstat"), []byte(content), 0644))
+	t.Setenv("HOST_PROC", proc)
+}
+
+func TestSampler_Sample(t *testing.T) {
+	writeMdstat(t, mdstat)
+
+	samples, err := NewSampler(nil).Sample()
+	require.NoError(t, err)
+	require.Len(t, samples, 5)
+
+	healthy := samples[0].(*Sample)
+	assert.Equal(t, "RaidSample", healthy.EventType)
+	assert.Equal(t, "md0", healthy.Device)
+	assert.Equal(t, "active", healthy.State)
+	assert.False(t, healthy.Degraded)
+	assert.False(t, healthy.Rebuilding)
+	assert.Equal(t, int64(2), healthy.DisksTotal)
+	assert.Equal(t, int64(2), healthy.DisksActive)
+	assert.Equal(t, int64(1046528), healthy.BlocksSynced)
+	assert.Nil(t, healthy.SyncPercent)
+
+	recovering := samples[1].(*Sample)
+	assert.Equal(t, "md1", recovering.Device)
+	assert.Equal(t, "recovering", recovering.State)
+	assert.True(t, recovering.Degraded)
+	assert.True(t, recovering.Rebuilding)
+	assert.Equal(t, int64(1), recovering.DisksActive)
+	assert.Equal(t, int64(1), recovering.DisksDown)
+	assert.Equal(t, int64(288000), recovering.BlocksSynced)
+	assert.Equal(t, 27.5, *recovering.SyncPercent)
+	assert.Equal(t, 1.2, *recovering.SyncFinishMinutes)
+
+	failed := samples[2].(*Sample)
+	assert.Equal(t, "md2", failed.Device)
+	assert.Equal(t, "active", failed.State)
+	assert.True(t, failed.Degraded)
+	assert.False(t, failed.Rebuilding)
+	assert.Equal(t, int64(3), failed.DisksTotal)
+	assert.Equal(t, int64(1), failed.DisksFailed)
+
+	inactive := samples[3].(*Sample)
+	assert.Equal(t, "md3", inactive.Device)
+	assert.Equal(t, "inactive", inactive.State)
+	assert.False(t, inactive.Degraded)
+	assert.Equal(t, int64(1), inactive.DisksSpare)
+
+	resyncing := samples[4].(*Sample)
+	assert.Equal(t, "md4", resyncing.Device)
+	assert.Equal(t, "resyncing", resyncing.State)
+	assert.False(t, resyncing.Degraded)
+	assert.True(t, resyncing.Rebuilding)
+	assert.Equal(t, 5.0, *resyncing.SyncPercent)
+}
+
+func TestSampler_Sample_NoArrays(t *testing.T) {
+	writeMdstat(t, "Personalities : \nunused devices: <none>\n")
+
+	samples, err := NewSampler(nil).Sample()
+	assert.NoError(t, err)
+	assert.Empty(t, samples)
+}
+
+func TestSampler_Sample_NoMdstat(t *testing.T) {
+	t.Setenv("HOST_PROC", t.TempDir())
+
+	samples, err := NewSampler(nil).Sample()
+	assert.NoError(t, err)
+	assert.Empty(t, samples)
+}
+
+func TestSampler_Sample_InvalidMdstat(t *testing.T) {
+	writeMdstat(t, "md0 : active raid1 sdb1[1] sda1[0]\n      not blocks\n\n\n")
+
+	_, err := NewSampler(nil).Sample()
+	assert.Error(t, err)
+}
+
+func TestSampler_Disabled(t *testing.T) {
+	sampler := NewSampler(nil)
+	assert.True(t, sampler.Disabled())
+
+	sampler.enabled = true
+	assert.False(t, sampler.Disabled())
+
+	sampler.sampleRate = -1
+	assert.True(t, sampler.Disabled())
+}
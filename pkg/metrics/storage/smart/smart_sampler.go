@@ -0,0 +1,240 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+// Package smart samples the SMART health of the host disks through smartctl.
+package smart
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime/debug"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/sample"
+)
+
+var sslog = log.WithComponent("SmartSampler")
+
+const (
+	smartctlBin     = "smartctl"
+	smartctlTimeout = 30 * time.Second
+
+	// ATA attribute counting the sectors remapped to the spare area.
+	ataReallocatedSectorCountID = 5
+
+	// smartctl exit status bits for a command line that couldn't be parsed and a device that couldn't be opened.
+	// The other bits report the disk health, and the output is still valid.
+	smartctlExitStatusFatalMask = 0x3
+)
+
+// Sample the SMART health of a disk.
+type Sample struct {
+	sample.BaseEvent
+
+	// Device name, e.g. /dev/sda
+	Device string `json:"device"`
+	// Device protocol: ATA, NVMe or SCSI
+	Protocol string `json:"protocol,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Serial   string `json:"serialNumber,omitempty"`
+	// SMART overall health self-assessment: PASSED or FAILED
+	HealthStatus string `json:"smartHealthStatus,omitempty"`
+	// Current temperature of the disk
+	TemperatureCelsius *float64 `json:"temperatureCelsius,omitempty"`
+	// Number of sectors reallocated to the spare area, from the ATA attribute 5 or the SCSI grown defect list. Not
+	// reported by NVMe disks.
+	ReallocatedSectors *uint64 `json:"reallocatedSectors,omitempty"`
+}
+
+// Sampler reports the SMART health of every disk found by smartctl. It's a no-op when smartctl isn't installed.
+type Sampler struct {
+	enabled    bool
+	sampleRate time.Duration
+	lookPath   func(file string) (string, error)
+	// runSmartctl runs smartctl with the arguments and returns its output.
+	runSmartctl func(smartctl string, arguments ...string) ([]byte, error)
+}
+
+func NewSampler(context agent.AgentContext) *Sampler {
+	sampleRateSec := config.DefaultMetricsSmartSampleRate
+	enabled := false
+	if context != nil {
+		sampleRateSec = context.Config().MetricsSmartSampleRate
+		enabled = context.Config().EnableSmartMetrics
+	}
+
+	return &Sampler{
+		enabled:     enabled,
+		sampleRate:  time.Second * time.Duration(sampleRateSec),
+		lookPath:    exec.LookPath,
+		runSmartctl: runSmartctl,
+	}
+}
+
+func (s *Sampler) OnStartup() {
+	if _, err := s.lookPath(smartctlBin); err != nil {
+		sslog.WithError(err).Warn("SMART metrics are enabled but smartctl is not available, no SmartSample will be reported.")
+	}
+}
+
+func (s *Sampler) Name() string {
+	return "SmartSampler"
+}
+
+func (s *Sampler) Interval() time.Duration {
+	return s.sampleRate
+}
+
+func (s *Sampler) Disabled() bool {
+	return !s.enabled || s.Interval() <= config.FREQ_DISABLE_SAMPLING
+}
+
+func (s *Sampler) Sample() (eventBatch sample.EventBatch, err error) {
+	defer func() {
+		if panicErr := recover(); panicErr != nil {
+			err = fmt.Errorf("Panic in smart.Sampler: %v\nStack: %s", panicErr, debug.Stack())
+		}
+	}()
+
+	smartctl, err := s.lookPath(smartctlBin)
+	if err != nil {
+		sslog.WithError(err).Debug("smartctl not found, skipping SMART metrics.")
+		return nil, nil
+	}
+
+	devices, err := s.scan(smartctl)
+	if err != nil {
+		sslog.WithError(err).Warn("Unable to scan the disks for SMART metrics.")
+		return nil, nil
+	}
+
+	for _, device := range devices {
+		smartSample, err := s.sampleDevice(smartctl, device)
+		if err != nil {
+			sslog.WithError(err).WithField("device", device.Name).Warn("Unable to retrieve SMART metrics.")
+			continue
+		}
+		if smartSample == nil {
+			sslog.WithField("device", device.Name).Debug("Disk is in standby, skipping SMART metrics.")
+			continue
+		}
+		smartSample.Type("SmartSample")
+		eventBatch = append(eventBatch, smartSample)
+	}
+	return eventBatch, nil
+}
+
+// smartctlDevice a device listed by "smartctl --scan".
+type smartctlDevice struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+func (s *Sampler) scan(smartctl string) ([]smartctlDevice, error) {
+	output, err := s.runSmartctl(smartctl, "--scan", "--json")
+	if err != nil {
+		return nil, err
+	}
+	var scan struct {
+		Devices []smartctlDevice `json:"devices"`
+	}
+	if err = json.Unmarshal(output, &scan); err != nil {
+		return nil, fmt.Errorf("cannot parse smartctl scan: %w", err)
+	}
+	return scan.Devices, nil
+}
+
+// smartctlInfo the fields of "smartctl --json --info --health --attributes" reported in the sample.
+type smartctlInfo struct {
+	Device struct {
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature *struct {
+		Current float64 `json:"current"`
+	} `json:"temperature"`
+	ATASmartAttributes *struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	SCSIGrownDefectList *uint64 `json:"scsi_grown_defect_list"`
+}
+
+// sampleDevice returns the SMART sample of the device, or nil when the disk is in standby, as it's not woken up.
+func (s *Sampler) sampleDevice(smartctl string, device smartctlDevice) (*Sample, error) {
+	args := []string{"--json", "--info", "--health", "--attributes", "--nocheck=standby,0"}
+	if device.Type != "" {
+		args = append(args, "--device="+device.Type)
+	}
+	output, err := s.runSmartctl(smartctl, append(args, device.Name)...)
+	if err != nil {
+		return nil, err
+	}
+	var info smartctlInfo
+	if err = json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("cannot parse smartctl output: %w", err)
+	}
+	if info.SmartStatus == nil && info.Temperature == nil {
+		return nil, nil
+	}
+
+	smartSample := &Sample{
+		Device:   device.Name,
+		Protocol: info.Device.Protocol,
+		Model:    info.ModelName,
+		Serial:   info.SerialNumber,
+	}
+	if info.SmartStatus != nil {
+		smartSample.HealthStatus = "FAILED"
+		if info.SmartStatus.Passed {
+			smartSample.HealthStatus = "PASSED"
+		}
+	}
+	if info.Temperature != nil {
+		smartSample.TemperatureCelsius = &info.Temperature.Current
+	}
+	if info.ATASmartAttributes != nil {
+		for _, attribute := range info.ATASmartAttributes.Table {
+			if attribute.ID == ataReallocatedSectorCountID {
+				reallocated := attribute.Raw.Value
+				smartSample.ReallocatedSectors = &reallocated
+			}
+		}
+	}
+	if info.SCSIGrownDefectList != nil {
+		smartSample.ReallocatedSectors = info.SCSIGrownDefectList
+	}
+	return smartSample, nil
+}
+
+// runSmartctl runs smartctl, whose output is kept when the exit status only reports the disk health.
+func runSmartctl(smartctl string, arguments ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), smartctlTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, smartctl, arguments...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode()&smartctlExitStatusFatalMask == 0 {
+		return output, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("smartctl failed: %w", err)
+	}
+	return output, nil
+}
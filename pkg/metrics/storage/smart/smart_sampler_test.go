@@ -0,0 +1,134 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//go:build linux
+// +build linux
+
+package smart
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSmartctl reports an ATA disk with a failing attribute, a SCSI disk, an NVMe disk, a disk in standby and a disk
+// that can't be opened, setting the exit status as smartctl does.
+const fakeSmartctl = `#!/bin/sh
+for arg in "$@"; do device="$arg"; done
+case "$1 $device" in
+"--scan --json")
+  printf '%s\n' '{"devices": [
+    {"name": "/dev/sda", "type": "sat", "protocol": "ATA"},
+    {"name": "/dev/sdb", "type": "scsi", "protocol": "SCSI"},
+    {"name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"},
+    {"name": "/dev/sdc", "type": "sat", "protocol": "ATA"},
+    {"name": "/dev/sdd", "type": "sat", "protocol": "ATA"}]}'
+  ;;
+*/dev/sda)
+  printf '%s\n' '{"device": {"name": "/dev/sda", "type": "sat", "protocol": "ATA"},
+    "model_name": "WDC WD40EFRX", "serial_number": "WD-1234",
+    "smart_status": {"passed": false},
+    "ata_smart_attributes": {"table": [
+      {"id": 1, "name": "Raw_Read_Error_Rate", "raw": {"value": 12}},
+      {"id": 5, "name": "Reallocated_Sector_Ct", "raw": {"value": 24}}]},
+    "temperature": {"current": 41}}'
+  exit 24
+  ;;
+*/dev/sdb)
+  printf '%s\n' '{"device": {"name": "/dev/sdb", "type": "scsi", "protocol": "SCSI"},
+    "model_name": "SEAGATE ST4000", "smart_status": {"passed": true},
+    "scsi_grown_defect_list": 3, "temperature": {"current": 35}}'
+  ;;
+*/dev/nvme0)
+  printf '%s\n' '{"device": {"name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"},
+    "model_name": "Samsung SSD 970", "serial_number": "S4EW", "smart_status": {"passed": true},
+    "temperature": {"current": 38.5}}'
+  ;;
+*/dev/sdc)
+  printf '%s\n' '{"device": {"name": "/dev/sdc", "type": "sat", "protocol": "ATA"}, "power_mode": "STANDBY"}'
+  ;;
+*/dev/sdd)
+  printf '%s\n' '{"smartctl": {"messages": [{"string": "Smartctl open device: /dev/sdd failed", "severity": "error"}]}}'
+  exit 2
+  ;;
+esac
+`
+
+func installSmartctl(t *testing.T, script string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, smartctlBin), []byte(script), 0755))
+	t.Setenv("PATH", dir)
+}
+
+func TestSampler_Sample(t *testing.T) {
+	installSmartctl(t, fakeSmartctl)
+
+	samples, err := NewSampler(nil).Sample()
+	require.NoError(t, err)
+	// the disk in standby and the one that can't be opened are skipped
+	require.Len(t, samples, 3)
+
+	ata := samples[0].(*Sample)
+	assert.Equal(t, "SmartSample", ata.EventType)
+	assert.Equal(t, "/dev/sda", ata.Device)
+	assert.Equal(t, "ATA", ata.Protocol)
+	assert.Equal(t, "WDC WD40EFRX", ata.Model)
+	assert.Equal(t, "WD-1234", ata.Serial)
+	assert.Equal(t, "FAILED", ata.HealthStatus)
+	assert.Equal(t, 41.0, *ata.TemperatureCelsius)
+	assert.Equal(t, uint64(24), *ata.ReallocatedSectors)
+
+	scsi := samples[1].(*Sample)
+	assert.Equal(t, "/dev/sdb", scsi.Device)
+	assert.Equal(t, "PASSED", scsi.HealthStatus)
+	assert.Equal(t, 35.0, *scsi.TemperatureCelsius)
+	assert.Equal(t, uint64(3), *scsi.ReallocatedSectors)
+
+	nvme := samples[2].(*Sample)
+	assert.Equal(t, "/dev/nvme0", nvme.Device)
+	assert.Equal(t, "NVMe", nvme.Protocol)
+	assert.Equal(t, "PASSED", nvme.HealthStatus)
+	assert.Equal(t, 38.5, *nvme.TemperatureCelsius)
+	assert.Nil(t, nvme.ReallocatedSectors)
+}
+
+func TestSampler_Sample_NoSmartctl(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	samples, err := NewSampler(nil).Sample()
+	assert.NoError(t, err)
+	assert.Empty(t, samples)
+}
+
+func TestSampler_Sample_ScanError(t *testing.T) {
+	installSmartctl(t, "#!/bin/sh\nprintf 'not json'\n")
+
+	samples, err := NewSampler(nil).Sample()
+	assert.NoError(t, err)
+	assert.Empty(t, samples)
+
+	sampler := NewSampler(nil)
+	sampler.runSmartctl = func(string, ...string) ([]byte, error) {
+		return nil, errors.New("permission denied")
+	}
+	samples, err = sampler.Sample()
+	assert.NoError(t, err)
+	assert.Empty(t, samples)
+}
+
+func TestSampler_Disabled(t *testing.T) {
+	sampler := NewSampler(nil)
+	assert.True(t, sampler.Disabled())
+
+	sampler.enabled = true
+	assert.False(t, sampler.Disabled())
+
+	sampler.sampleRate = -1
+	assert.True(t, sampler.Disabled())
+}
@@ -37,6 +37,7 @@ type BaseSample struct {
 	Device         string `json:"device"`
 	IsReadOnly     string `json:"isReadOnly"`
 	FileSystemType string `json:"filesystemType"`
+	MountOptions   string `json:"mountOptions,omitempty"`
 	CountersSource string `json:"countersSource,omitempty"` // Source for the IOCounters: wmi, pdh, diskstats
 
 	UsedBytes               *float64 `json:"diskUsedBytes,omitempty"`
@@ -248,6 +249,9 @@ func (ss *Sampler) Sample() (samples sample.EventBatch, err error) {
 		s.Type("StorageSample")
 		s.ElapsedSampleDeltaMs = elapsedMs
 		populatePartition(p, s)
+		if cfg != nil && cfg.StorageMountOptions {
+			s.MountOptions = p.Opts
+		}
 		populateUsage(fsUsage, s)
 
 		// we can have multiple mountpoints for the same device
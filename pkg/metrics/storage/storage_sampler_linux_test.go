@@ -17,6 +17,7 @@ import (
 	"github.com/shirou/gopsutil/v3/disk"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDeviceRegexp(t *testing.T) {
@@ -467,3 +468,65 @@ func TestIgnoredDevice(t *testing.T) {
 	assert.EqualValues(t, usageTotal1, *sample.TotalBytes)
 	assert.EqualValues(t, usageFree1, *sample.FreeBytes)
 }
+
+func TestFetchPartitions_MountOptions(t *testing.T) {
+	procDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procDir, "self"), 0755))
+	mountsContent := "/dev/sda1 / ext4 rw,relatime 0 0\n" +
+		"proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0\n" +
+		"/dev/sdb1 /data xfs ro,nosuid,noexec,relatime 0 0\n"
+	require.NoError(t, os.WriteFile(filepath.Join(procDir, "self", mounts), []byte(mountsContent), 0644))
+	t.Setenv("HOST_PROC", procDir)
+
+	partitions, err := fetchPartitions(false)
+	require.NoError(t, err)
+
+	opts := map[string]string{}
+	for _, p := range partitions {
+		opts[p.Device] = p.Opts
+	}
+	assert.Equal(t, map[string]string{
+		"/dev/sda1": "rw,relatime",
+		"/dev/sdb1": "ro,nosuid,noexec,relatime",
+	}, opts)
+}
+
+func TestSampleMountOptions(t *testing.T) {
+	partitions := []PartitionStat{
+		{
+			Device:     "/dev/sda1",
+			Mountpoint: "/",
+			Fstype:     "ext4",
+			Opts:       "rw,relatime",
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		enabled  bool
+		expected string
+	}{
+		{name: "Enabled", enabled: true, expected: "rw,relatime"},
+		{name: "Disabled", enabled: false, expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := new(mocks.AgentContext)
+			ctx.On("Config").Return(&config.Config{
+				StorageMountOptions: testCase.enabled,
+			})
+
+			ss := NewSampler(ctx)
+			ss.storageUtilities = &MockStorageSampleWrapper{partitions: partitions}
+
+			results, err := ss.Sample()
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+
+			sample, ok := results[0].(*Sample)
+			require.True(t, ok)
+			assert.Equal(t, testCase.expected, sample.MountOptions)
+		})
+	}
+}
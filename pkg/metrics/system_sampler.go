@@ -52,7 +52,7 @@ func NewSystemSampler(context agent.AgentContext, storageSampler *storage.Sample
 		DiskMonitor:    NewDiskMonitor(storageSampler),
 		LoadMonitor:    NewLoadMonitor(),
 		MemoryMonitor:  NewMemoryMonitor(cfg.IgnoreReclaimable),
-		HostMonitor:    NewHostMonitor(ntpMonitor),
+		HostMonitor:    NewHostMonitor(ntpMonitor, cfg.ZombieProcessCount, cfg.ArpEntryCount),
 		context:        context,
 		waitForCleanup: &sync.WaitGroup{},
 		hostIDProvider: hostIDProvider,
@@ -31,8 +31,10 @@ type ProcessSample struct {
 	CmdLine               string   `json:"commandLine,omitempty"`
 	Status                string   `json:"state,omitempty"`
 	ParentProcessID       int32    `json:"parentProcessId,omitempty"`
+	StartTime             int64    `json:"startTime,omitempty"` // unix seconds
 	ThreadCount           int32    `json:"threadCount,omitempty"`
 	FdCount               *int32   `json:"fileDescriptorCount,omitempty"`
+	SocketCount           *int32   `json:"socketCount,omitempty"`
 	IOReadCountPerSecond  *float64 `json:"ioReadCountPerSecond,omitempty"`
 	IOWriteCountPerSecond *float64 `json:"ioWriteCountPerSecond,omitempty"`
 	IOReadBytesPerSecond  *float64 `json:"ioReadBytesPerSecond,omitempty"`
@@ -41,11 +43,23 @@ type ProcessSample struct {
 	IOTotalWriteCount     *uint64  `json:"ioTotalWriteCount,omitempty"`
 	IOTotalReadBytes      *uint64  `json:"ioTotalReadBytes,omitempty"`
 	IOTotalWriteBytes     *uint64  `json:"ioTotalWriteBytes,omitempty"`
+	// Container cgroup CPU throttling
+	ContainerCPUThrottledPeriods *uint64  `json:"containerCpuThrottledPeriods,omitempty"`
+	ContainerCPUThrottledTimeMs  *float64 `json:"containerCpuThrottledTimeMs,omitempty"`
 	// Auxiliary values, not to be reported
 	LastIOCounters  *process.IOCountersStat `json:"-"`
 	ContainerLabels map[string]string       `json:"-"`
 }
 
+// ProcessUserSample aggregates the resources used by the processes owned by the same user.
+type ProcessUserSample struct {
+	sample.BaseEvent
+	User           string  `json:"userName"`
+	ProcessCount   int     `json:"processCount"`
+	CPUPercent     float64 `json:"cpuPercent"`
+	MemoryRSSBytes int64   `json:"memoryResidentSizeBytes"`
+}
+
 // FlatProcessSample stores the process sampling information as a map
 type FlatProcessSample map[string]interface{}
 
@@ -55,6 +69,11 @@ func (f *FlatProcessSample) Type(eventType string) {
 	(*f)["eventType"] = eventType
 }
 
+func (f *FlatProcessSample) GetEventType() string {
+	eventType, _ := (*f)["eventType"].(string)
+	return eventType
+}
+
 func (f *FlatProcessSample) Entity(key entity.Key) {
 	(*f)["entityKey"] = key
 }
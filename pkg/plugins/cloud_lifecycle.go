@@ -0,0 +1,133 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package plugins
+
+import (
+	"errors"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
+)
+
+const (
+	cloudLifecycleEntryID          = "instance"
+	cloudInterruptionEventCategory = "cloud"
+	cloudInterruptionEventSummary  = "Cloud instance interruption noticed"
+)
+
+var cllog = log.WithPlugin("CloudLifecycle")
+
+// CloudLifecycle inventory entry with the purchasing option and interruption status of the cloud instance.
+type CloudLifecycle struct {
+	ID                  string `json:"id"`
+	CloudType           string `json:"cloud_type"`
+	Spot                bool   `json:"spot"`
+	InterruptionPending bool   `json:"interruption_pending"`
+	InterruptionAction  string `json:"interruption_action,omitempty"`
+	InterruptionTime    string `json:"interruption_time,omitempty"`
+}
+
+func (c CloudLifecycle) SortKey() string {
+	return c.ID
+}
+
+// lifecycleHarvester provides the lifecycle of the detected cloud instance.
+type lifecycleHarvester interface {
+	GetCloudType() cloud.Type
+	GetLifecycle() (cloud.Lifecycle, error)
+}
+
+// CloudLifecyclePlugin polls the provider metadata for the lifecycle of the cloud instance, reporting it as
+// inventory and emitting an event once an interruption is noticed.
+type CloudLifecyclePlugin struct {
+	agent.PluginCommon
+	harvester           lifecycleHarvester
+	frequency           time.Duration
+	interruptionEmitted bool
+}
+
+// registerCloudLifecyclePlugin registers the cloud lifecycle plugin if the cloud harvester provides the lifecycle.
+func registerCloudLifecyclePlugin(a *agent.Agent) {
+	if harvester, ok := a.GetCloudHarvester().(lifecycleHarvester); ok {
+		a.RegisterPlugin(NewCloudLifecyclePlugin(a.Context, harvester))
+	}
+}
+
+func NewCloudLifecyclePlugin(ctx agent.AgentContext, harvester lifecycleHarvester) agent.Plugin {
+	cfg := ctx.Config()
+	return &CloudLifecyclePlugin{
+		PluginCommon: agent.PluginCommon{ID: ids.CloudLifecycleID, Context: ctx},
+		harvester:    harvester,
+		frequency: config.ValidateConfigFrequencySetting(
+			cfg.CloudLifecycleRefreshSec,
+			config.FREQ_MINIMUM_INVENTORY_SAMPLE_RATE,
+			config.FREQ_PLUGIN_CLOUD_LIFECYCLE,
+			cfg.DisableAllPlugins,
+		) * time.Second,
+	}
+}
+
+func (p *CloudLifecyclePlugin) Run() {
+	if p.Context.Config().DisableCloudMetadata {
+		cllog.Debug("Cloud lifecycle disabled by disable_cloud_metadata.")
+		return
+	}
+
+	if p.frequency <= config.FREQ_DISABLE_SAMPLING {
+		cllog.Debug("Disabled.")
+		return
+	}
+
+	refreshTimer := time.NewTicker(p.frequency)
+	for {
+		if err := p.poll(); errors.Is(err, cloud.ErrMethodNotImplemented) || errors.Is(err, cloud.ErrCouldNotDetect) {
+			cllog.WithError(err).Debug("Cloud lifecycle not available, stopping.")
+			refreshTimer.Stop()
+			return
+		} else if err != nil {
+			cllog.WithError(err).Debug("Reading cloud lifecycle.")
+		}
+		<-refreshTimer.C
+	}
+}
+
+// poll reports the instance lifecycle, emitting an event the first time an interruption is noticed.
+func (p *CloudLifecyclePlugin) poll() error {
+	lifecycle, err := p.harvester.GetLifecycle()
+	if err != nil {
+		return err
+	}
+
+	entry := CloudLifecycle{
+		ID:                  cloudLifecycleEntryID,
+		CloudType:           string(p.harvester.GetCloudType()),
+		Spot:                lifecycle.Spot,
+		InterruptionPending: lifecycle.InterruptionPending,
+		InterruptionAction:  lifecycle.InterruptionAction,
+		InterruptionTime:    lifecycle.InterruptionTime,
+	}
+	p.EmitInventory(types.PluginInventoryDataset{entry}, entity.NewFromNameWithoutID(p.Context.EntityKey()))
+
+	if lifecycle.InterruptionPending && !p.interruptionEmitted {
+		cllog.WithField("action", lifecycle.InterruptionAction).
+			WithField("time", lifecycle.InterruptionTime).
+			Warn("Cloud instance interruption noticed.")
+		p.EmitEvent(map[string]interface{}{
+			"eventType":          "InfrastructureEvent",
+			"category":           cloudInterruptionEventCategory,
+			"summary":            cloudInterruptionEventSummary,
+			"cloudType":          entry.CloudType,
+			"interruptionAction": lifecycle.InterruptionAction,
+			"interruptionTime":   lifecycle.InterruptionTime,
+		}, entity.Key(p.Context.EntityKey()))
+	}
+	p.interruptionEmitted = lifecycle.InterruptionPending
+
+	return nil
+}
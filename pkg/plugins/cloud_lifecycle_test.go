@@ -0,0 +1,99 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package plugins
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/mocks"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLifecycleHarvester struct {
+	lifecycle cloud.Lifecycle
+	err       error
+}
+
+func (f *fakeLifecycleHarvester) GetCloudType() cloud.Type {
+	return cloud.TypeAWS
+}
+
+func (f *fakeLifecycleHarvester) GetLifecycle() (cloud.Lifecycle, error) {
+	return f.lifecycle, f.err
+}
+
+func newCloudLifecycleContext(cfg *config.Config) *mocks.AgentContext {
+	ctx := new(mocks.AgentContext)
+	ctx.On("EntityKey").Return("FakeAgent")
+	ctx.On("Config").Return(cfg)
+	ctx.On("SendData", mock.Anything).Return()
+	ctx.On("SendEvent", mock.Anything, mock.Anything).Return()
+	return ctx
+}
+
+func TestCloudLifecyclePlugin_Poll(t *testing.T) {
+	cfg := &config.Config{CloudLifecycleRefreshSec: 0}
+	ctx := newCloudLifecycleContext(cfg)
+	harvester := &fakeLifecycleHarvester{lifecycle: cloud.Lifecycle{Spot: true}}
+	p := NewCloudLifecyclePlugin(ctx, harvester).(*CloudLifecyclePlugin)
+	assert.Equal(t, config.FREQ_PLUGIN_CLOUD_LIFECYCLE*time.Second, p.frequency)
+
+	ctx.SendDataWg.Add(4)
+
+	require.NoError(t, p.poll())
+	ctx.AssertNumberOfCalls(t, "SendEvent", 0)
+	ctx.AssertCalled(t, "SendData", types.NewPluginOutput(ids.CloudLifecycleID, entity.NewFromNameWithoutID("FakeAgent"), types.PluginInventoryDataset{
+		CloudLifecycle{ID: "instance", CloudType: "aws", Spot: true},
+	}))
+
+	// the interruption is reported as inventory and notified with an event
+	harvester.lifecycle = cloud.Lifecycle{Spot: true, InterruptionPending: true, InterruptionAction: "terminate", InterruptionTime: "2017-09-18T08:22:00Z"}
+	require.NoError(t, p.poll())
+	ctx.AssertCalled(t, "SendData", types.NewPluginOutput(ids.CloudLifecycleID, entity.NewFromNameWithoutID("FakeAgent"), types.PluginInventoryDataset{
+		CloudLifecycle{ID: "instance", CloudType: "aws", Spot: true, InterruptionPending: true, InterruptionAction: "terminate", InterruptionTime: "2017-09-18T08:22:00Z"},
+	}))
+	ctx.AssertNumberOfCalls(t, "SendEvent", 1)
+	event := ctx.Calls[len(ctx.Calls)-1].Arguments.Get(0)
+	eventData, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.Contains(t, string(eventData), `"category":"cloud"`)
+	assert.Contains(t, string(eventData), `"interruptionAction":"terminate"`)
+	assert.Contains(t, string(eventData), `"eventType":"InfrastructureEvent"`)
+
+	// the event is emitted once per interruption
+	require.NoError(t, p.poll())
+	ctx.AssertNumberOfCalls(t, "SendEvent", 1)
+
+	harvester.lifecycle = cloud.Lifecycle{Spot: true}
+	require.NoError(t, p.poll())
+	ctx.AssertNumberOfCalls(t, "SendEvent", 1)
+	ctx.AssertNumberOfCalls(t, "SendData", 4)
+}
+
+func TestCloudLifecyclePlugin_Poll_Error(t *testing.T) {
+	ctx := newCloudLifecycleContext(&config.Config{})
+	p := NewCloudLifecyclePlugin(ctx, &fakeLifecycleHarvester{err: cloud.ErrMethodNotImplemented}).(*CloudLifecyclePlugin)
+
+	assert.ErrorIs(t, p.poll(), cloud.ErrMethodNotImplemented)
+	ctx.AssertNotCalled(t, "SendData", mock.Anything)
+}
+
+func TestCloudLifecyclePlugin_DisabledByDefault(t *testing.T) {
+	cfg := config.NewConfig()
+	ctx := newCloudLifecycleContext(cfg)
+	p := NewCloudLifecyclePlugin(ctx, &fakeLifecycleHarvester{}).(*CloudLifecyclePlugin)
+
+	p.Run()
+
+	assert.Equal(t, config.FREQ_DISABLE_SAMPLING*time.Second, p.frequency)
+	ctx.AssertNotCalled(t, "SendData", mock.Anything)
+}
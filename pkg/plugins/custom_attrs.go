@@ -12,7 +12,6 @@ import (
 
 type CustomAttrsPlugin struct {
 	agent.PluginCommon
-	customAttributes map[string]interface{}
 }
 
 type CustomAttrs map[string]interface{}
@@ -27,20 +26,34 @@ func NewCustomAttrsPlugin(ctx agent.AgentContext) agent.Plugin {
 			ID:      ids.CustomAttrsID,
 			Context: ctx,
 		},
-		customAttributes: ctx.Config().CustomAttributes,
 	}
 }
 
+// truncateCustomAttrs returns the custom attributes with their string values truncated to maxValueBytes, as they
+// decorate the samples of the host.
+func truncateCustomAttrs(customAttributes config.CustomAttributeMap, maxValueBytes int) map[string]interface{} {
+	truncated := make(map[string]interface{}, len(customAttributes))
+	for k, v := range customAttributes {
+		if str, isString := v.(string); isString {
+			v = config.TruncateAttributeValue(k, str, maxValueBytes)
+		}
+		truncated[k] = v
+	}
+	return truncated
+}
+
 // This plugin is pretty simple - it simply returns once with the object containing current custom attributes.
+// It's run again when the custom attributes are reloaded.
 func (self *CustomAttrsPlugin) Run() {
 	self.Context.AddReconnecting(self)
 
-	data := types.PluginInventoryDataset{CustomAttrs(self.customAttributes)}
+	customAttributes := truncateCustomAttrs(agent.CustomAttributes(self.Context), self.Context.Config().CustomAttributesMaxValueBytes)
+	data := types.PluginInventoryDataset{CustomAttrs(customAttributes)}
 	entityKey := self.Context.EntityKey()
 
 	aclog.
 		WithField(config.TracesFieldName, config.FeatureTrace).
-		Tracef("run, entity: %s, data: %+v", entityKey, self.customAttributes)
+		Tracef("run, entity: %s, data: %+v", entityKey, customAttributes)
 
 	self.EmitInventory(data, entity.NewFromNameWithoutID(entityKey))
 }
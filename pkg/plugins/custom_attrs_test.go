@@ -0,0 +1,51 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package plugins
+
+import (
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/mocks"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCustomAttrsPlugin_TruncatesValues(t *testing.T) {
+	agentID := "FakeAgent"
+	cfg := &config.Config{
+		CustomAttributes: config.CustomAttributeMap{
+			"team":        "infrastructure",
+			"description": "a description longer than the maximum size",
+			"replicas":    3,
+		},
+		CustomAttributesMaxValueBytes: 20,
+	}
+
+	ctx := new(mocks.AgentContext)
+	ctx.On("Config").Return(cfg)
+	ctx.On("AddReconnecting", mock.Anything).Return()
+	ctx.On("EntityKey").Return(agentID)
+	ch := make(chan mock.Arguments)
+	ctx.On("SendData", mock.Anything).Run(func(args mock.Arguments) {
+		ch <- args
+	})
+	ctx.SendDataWg.Add(1)
+
+	plugin := NewCustomAttrsPlugin(ctx)
+	go plugin.Run()
+
+	args := <-ch
+
+	expected := types.NewPluginOutput(ids.CustomAttrsID, entity.NewFromNameWithoutID(agentID),
+		types.PluginInventoryDataset{CustomAttrs{
+			"team":        "infrastructure",
+			"description": "a desc" + config.TruncatedValueMarker,
+			"replicas":    3,
+		}})
+	assert.Equal(t, expected, args[0])
+	ctx.AssertExpectations(t)
+}
@@ -61,10 +61,10 @@ func (self *HostAliasesPlugin) getHostAliasesDataset() (dataset types.PluginInve
 		Source: sysinfo.HOST_SOURCE_HOSTNAME_SHORT,
 	})
 
-	// Retrieve the host alias from config
-	if self.Context.Config().DisplayName != "" {
+	// Retrieve the host alias from the agent lookup table, as it may have been disambiguated from the config one
+	if displayName := self.Context.IDLookup()[sysinfo.HOST_SOURCE_DISPLAY_NAME]; displayName != "" {
 		dataset = append(dataset, sysinfo.HostAliases{
-			Alias:  self.Context.Config().DisplayName,
+			Alias:  displayName,
 			Source: sysinfo.HOST_SOURCE_DISPLAY_NAME,
 		})
 	}
@@ -31,6 +31,22 @@ var (
 		Category: "metadata",
 		Term:     "system",
 	}
+	SampleRatesID = PluginID{
+		Category: "metadata",
+		Term:     "sample_rates",
+	}
+	RuntimeID = PluginID{
+		Category: "metadata",
+		Term:     "agent_runtime",
+	}
+	CloudLifecycleID = PluginID{
+		Category: "cloud",
+		Term:     "lifecycle",
+	}
+	StartupEventID = PluginID{
+		Category: "metadata",
+		Term:     "startup_event",
+	}
 	EmptyInventorySource = PluginID{}
 )
 
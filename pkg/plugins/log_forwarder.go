@@ -0,0 +1,53 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package plugins
+
+import (
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/log"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+var lfplog = log.WithPlugin("LogForwarder")
+
+// FluentBitVersionFn returns the version of the FluentBit executable used by the log forwarder.
+type FluentBitVersionFn func() (string, error)
+
+// LogForwarderPlugin reports the log forwarder details as inventory.
+type LogForwarderPlugin struct {
+	agent.PluginCommon
+	fluentBitVersion FluentBitVersionFn
+}
+
+// LogForwarderItem inventory item describing the log forwarder.
+type LogForwarderItem struct {
+	Name    string `json:"id"`
+	Version string `json:"version"`
+}
+
+func (l LogForwarderItem) SortKey() string {
+	return l.Name
+}
+
+func NewLogForwarderPlugin(id ids.PluginID, ctx agent.AgentContext, fluentBitVersion FluentBitVersionFn) agent.Plugin {
+	return &LogForwarderPlugin{
+		PluginCommon:     agent.PluginCommon{ID: id, Context: ctx},
+		fluentBitVersion: fluentBitVersion,
+	}
+}
+
+// Run reports once the FluentBit version, as it doesn't change while the agent is running.
+func (p *LogForwarderPlugin) Run() {
+	version, err := p.fluentBitVersion()
+	if err != nil {
+		lfplog.WithError(err).Warn("Cannot retrieve Fluent Bit version.")
+		return
+	}
+
+	p.Context.AddReconnecting(p)
+
+	dataset := types.PluginInventoryDataset{LogForwarderItem{Name: "fluent-bit", Version: version}}
+	p.EmitInventory(dataset, entity.NewFromNameWithoutID(p.Context.EntityKey()))
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package plugins
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/mocks"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLogForwarderPlugin_ReportsFluentBitVersion(t *testing.T) {
+	pluginID := ids.NewPluginID("metadata", "log_forwarder")
+	agentID := "FakeAgent"
+
+	ctx := new(mocks.AgentContext)
+	ctx.On("AddReconnecting", mock.Anything).Return()
+	ctx.On("EntityKey").Return(agentID)
+	ch := make(chan mock.Arguments)
+	ctx.On("SendData", mock.Anything).Run(func(args mock.Arguments) {
+		ch <- args
+	})
+	ctx.SendDataWg.Add(1)
+
+	plugin := NewLogForwarderPlugin(*pluginID, ctx, func() (string, error) { return "2.0.8", nil })
+	go plugin.Run()
+
+	args := <-ch
+
+	expected := types.NewPluginOutput(*pluginID, entity.NewFromNameWithoutID(agentID),
+		types.PluginInventoryDataset{LogForwarderItem{Name: "fluent-bit", Version: "2.0.8"}})
+	assert.Equal(t, expected, args[0])
+	ctx.AssertExpectations(t)
+}
+
+func TestLogForwarderPlugin_NoVersion(t *testing.T) {
+	ctx := new(mocks.AgentContext)
+
+	plugin := NewLogForwarderPlugin(*ids.NewPluginID("metadata", "log_forwarder"), ctx, func() (string, error) {
+		return "", errors.New("fluent-bit not found")
+	})
+	plugin.Run()
+
+	ctx.AssertNotCalled(t, "SendData", mock.Anything)
+}
@@ -3,9 +3,14 @@
 package plugins
 
 import (
+	"time"
+
 	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/plugins/common"
+	backendhttp "github.com/newrelic/infrastructure-agent/pkg/backend/http"
 	"github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
 )
 
 func isHeartbeatOnlyMode(cfg *config.Config) bool {
@@ -22,3 +27,23 @@ func registerIntegrationsOnlyPlugin(agt *agent.Agent) {
 
 	agt.RegisterPlugin(NewIntegrationsOnlyPlugin(ids.PluginID{Category: "metadata", Term: "infra_agent"}, agt.Context))
 }
+
+// newHostInfoCommon returns the OS agnostic host information, reporting the public IP if enabled.
+func newHostInfoCommon(agt *agent.Agent) *common.HostInfoCommon {
+	cfg := agt.GetContext().Config()
+	hostInfo := common.NewHostInfoCommon(agt.Context.Version(), !cfg.DisableCloudMetadata, agt.GetCloudHarvester())
+	if !cfg.EnablePublicIP {
+		return hostInfo
+	}
+
+	var cloudHarvester cloud.PublicIPHarvester
+	if publicIPHarvester, ok := agt.GetCloudHarvester().(cloud.PublicIPHarvester); ok && !cfg.DisableCloudMetadata {
+		cloudHarvester = publicIPHarvester
+	}
+	// The reflector is requested through the same proxy and TLS settings as the data submission.
+	timeout := backendhttp.ClientTimeoutFromConfig(cfg)
+	client := backendhttp.GetHttpClient(timeout, backendhttp.BuildTransport(cfg, timeout))
+	ttl, _ := time.ParseDuration(cfg.PublicIPTTL)
+
+	return hostInfo.WithPublicIP(common.NewPublicIPResolver(cloudHarvester, cfg.PublicIPReflectorURL, client, ttl))
+}
@@ -4,7 +4,6 @@ package plugins
 
 import (
 	"github.com/newrelic/infrastructure-agent/internal/agent"
-	"github.com/newrelic/infrastructure-agent/internal/plugins/common"
 	"github.com/newrelic/infrastructure-agent/internal/plugins/darwin"
 	"github.com/newrelic/infrastructure-agent/pkg/metrics"
 	"github.com/newrelic/infrastructure-agent/pkg/metrics/network"
@@ -18,7 +17,7 @@ import (
 
 func RegisterPlugins(a *agent.Agent) error {
 	a.RegisterPlugin(darwin.NewHostinfoPlugin(a.Context,
-		common.NewHostInfoCommon(a.Context.Version(), !a.Context.Config().DisableCloudMetadata, a.GetCloudHarvester())))
+		newHostInfoCommon(a)))
 	a.RegisterPlugin(NewHostAliasesPlugin(a.Context, a.GetCloudHarvester()))
 	config := a.Context.Config()
 
@@ -27,6 +26,14 @@ func RegisterPlugins(a *agent.Agent) error {
 	}
 	a.RegisterPlugin(NewCustomAttrsPlugin(a.Context))
 	a.RegisterPlugin(NewAgentConfigPlugin(*ids.NewPluginID("metadata", "agent_config"), a.Context))
+	if config.EnableSampleRatesInventory {
+		a.RegisterPlugin(NewSampleRatesPlugin(a.Context))
+	}
+	if config.EnableRuntimeInventory {
+		a.RegisterPlugin(NewRuntimePlugin(a.Context))
+	}
+	registerCloudLifecyclePlugin(a)
+	registerStartupEventPlugin(a)
 
 	if config.FilesConfigOn {
 		a.RegisterPlugin(NewConfigFilePlugin(*ids.NewPluginID("files", "config"), a.Context))
@@ -53,7 +60,7 @@ func RegisterPlugins(a *agent.Agent) error {
 
 	var ntpMonitor metrics.NtpMonitor
 	if config.NtpMetrics.Enabled {
-		ntpMonitor = metrics.NewNtp(config.NtpMetrics.Pool, config.NtpMetrics.Timeout, config.NtpMetrics.Interval)
+		ntpMonitor = metrics.NewNtp(config.NtpMetrics.Pool, config.NtpMetrics.Timeout, config.NtpMetrics.Interval, config.NtpMetrics.MinServers)
 	}
 	systemSampler := metrics.NewSystemSampler(a.Context, storageSampler, ntpMonitor, hostid.NewProviderEnv())
 
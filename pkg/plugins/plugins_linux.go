@@ -4,7 +4,6 @@ package plugins
 
 import (
 	agnt "github.com/newrelic/infrastructure-agent/internal/agent"
-	"github.com/newrelic/infrastructure-agent/internal/plugins/common"
 	pluginsLinux "github.com/newrelic/infrastructure-agent/internal/plugins/linux"
 	config2 "github.com/newrelic/infrastructure-agent/pkg/config"
 	"github.com/newrelic/infrastructure-agent/pkg/helpers"
@@ -13,7 +12,10 @@ import (
 	"github.com/newrelic/infrastructure-agent/pkg/metrics/process"
 	metricsSender "github.com/newrelic/infrastructure-agent/pkg/metrics/sender"
 	"github.com/newrelic/infrastructure-agent/pkg/metrics/storage"
+	"github.com/newrelic/infrastructure-agent/pkg/metrics/storage/lvm"
 	"github.com/newrelic/infrastructure-agent/pkg/metrics/storage/nfs"
+	"github.com/newrelic/infrastructure-agent/pkg/metrics/storage/raid"
+	"github.com/newrelic/infrastructure-agent/pkg/metrics/storage/smart"
 	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
 	"github.com/newrelic/infrastructure-agent/pkg/plugins/proxy"
 	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
@@ -34,6 +36,8 @@ func RegisterPlugins(agent *agnt.Agent) error {
 		agent.RegisterPlugin(NewK8sIntegrationsPlugin(agent.Context, agent.Plugins))
 	}
 
+	registerStartupEventPlugin(agent)
+
 	if config.IsForwardOnly {
 		return nil
 	}
@@ -42,10 +46,17 @@ func RegisterPlugins(agent *agnt.Agent) error {
 
 	// Enabling the hostinfo plugin will make the host appear in the UI
 	agent.RegisterPlugin(pluginsLinux.NewHostinfoPlugin(agent.Context,
-		common.NewHostInfoCommon(agent.Context.Version(), !agent.Context.Config().DisableCloudMetadata, agent.GetCloudHarvester())))
+		newHostInfoCommon(agent)))
 
 	agent.RegisterPlugin(NewHostAliasesPlugin(agent.Context, agent.GetCloudHarvester()))
 	agent.RegisterPlugin(NewAgentConfigPlugin(ids.PluginID{"metadata", "agent_config"}, agent.Context))
+	if config.EnableSampleRatesInventory {
+		agent.RegisterPlugin(NewSampleRatesPlugin(agent.Context))
+	}
+	if config.EnableRuntimeInventory {
+		agent.RegisterPlugin(NewRuntimePlugin(agent.Context))
+	}
+	registerCloudLifecyclePlugin(agent)
 	if config.ProxyConfigPlugin {
 		agent.RegisterPlugin(proxy.ConfigPlugin(agent.Context))
 	}
@@ -63,11 +74,24 @@ func RegisterPlugins(agent *agnt.Agent) error {
 		return nil
 	}
 
+	// container runtimes can be queried from the agent container through their mounted sockets
+	agent.RegisterPlugin(pluginsLinux.NewContainerRuntimePlugin(ids.PluginID{"system", "container_runtime"}, agent.Context))
+
 	// register remaining plugins
 	if !config.IsContainerized {
+		initSystem := helpers.InitSystemUnknown
+		if config.InitSystemAutodetect {
+			initSystem = helpers.GetInitSystem()
+			slog.WithField("initSystem", initSystem).Debug("Detected host init system.")
+		}
+
 		// register our plugins
-		agent.RegisterPlugin(pluginsLinux.NewUpstartPlugin(ids.PluginID{"services", "upstart"}, agent.Context))
-		agent.RegisterPlugin(pluginsLinux.NewSystemdPlugin(agent.Context))
+		if pluginsLinux.ServicePluginEnabled(initSystem, helpers.InitSystemUpstart, config.UpstartIntervalSec) {
+			agent.RegisterPlugin(pluginsLinux.NewUpstartPlugin(ids.PluginID{"services", "upstart"}, agent.Context))
+		}
+		if pluginsLinux.ServicePluginEnabled(initSystem, helpers.InitSystemSystemd, config.SystemdIntervalSec) {
+			agent.RegisterPlugin(pluginsLinux.NewSystemdPlugin(agent.Context))
+		}
 		agent.RegisterPlugin(pluginsLinux.NewFacterPlugin(agent.Context))
 		if config.FilesConfigOn {
 			agent.RegisterPlugin(NewConfigFilePlugin(ids.PluginID{"files", "config"}, agent.Context))
@@ -76,6 +100,13 @@ func RegisterPlugins(agent *agnt.Agent) error {
 		agent.RegisterPlugin(pluginsLinux.NewDaemontoolsPlugin(ids.PluginID{"services", "daemontools"}, agent.Context))
 		agent.RegisterPlugin(pluginsLinux.NewSupervisorPlugin(ids.PluginID{"services", "supervisord"}, agent.Context))
 		agent.RegisterPlugin(NewNetworkInterfacePlugin(ids.PluginID{"system", "network_interfaces"}, agent.Context))
+		agent.RegisterPlugin(pluginsLinux.NewDnsConfigPlugin(ids.PluginID{"config", "dns"}, agent.Context))
+		agent.RegisterPlugin(pluginsLinux.NewHostsFilePlugin(ids.PluginID{"config", "hosts"}, agent.Context))
+		agent.RegisterPlugin(pluginsLinux.NewNetworkRoutesPlugin(ids.PluginID{"system", "network_routes"}, agent.Context))
+		agent.RegisterPlugin(pluginsLinux.NewCronPlugin(ids.PluginID{"services", "cron"}, agent.Context))
+		agent.RegisterPlugin(pluginsLinux.NewSshHostKeysPlugin(ids.PluginID{"config", "ssh_host_keys"}, agent.Context))
+		agent.RegisterPlugin(pluginsLinux.NewPackageUpdatesPlugin(ids.PluginID{"packages", "updates"}, agent.Context))
+		agent.RegisterPlugin(pluginsLinux.NewFirmwarePlugin(ids.PluginID{"system", "firmware"}, agent.Context))
 
 		if config.RunMode == config2.ModeRoot || config.RunMode == config2.ModePrivileged {
 			id := ids.PluginID{"kernel", "sysctl"}
@@ -90,8 +121,12 @@ func RegisterPlugins(agent *agnt.Agent) error {
 				agent.RegisterPlugin(pluginsLinux.NewSysctlPollingMonitor(id, agent.Context))
 			}
 			agent.RegisterPlugin(pluginsLinux.NewKernelModulesPlugin(ids.PluginID{"kernel", "modules"}, agent.Context))
-			agent.RegisterPlugin(pluginsLinux.NewSysvInitPlugin(ids.PluginID{"services", "pidfile"}, agent.Context))
+			if pluginsLinux.ServicePluginEnabled(initSystem, helpers.InitSystemSysvinit, config.SysvInitIntervalSec) {
+				agent.RegisterPlugin(pluginsLinux.NewSysvInitPlugin(ids.PluginID{"services", "pidfile"}, agent.Context))
+			}
 			agent.RegisterPlugin(pluginsLinux.NewSshdConfigPlugin(ids.PluginID{"config", "sshd"}, agent.Context))
+			agent.RegisterPlugin(pluginsLinux.NewSudoersPlugin(ids.PluginID{"config", "sudoers"}, agent.Context))
+			agent.RegisterPlugin(pluginsLinux.NewFirewallRulesPlugin(ids.PluginID{"config", "firewall"}, agent.Context))
 
 			// platform specific plugins
 			switch helpers.GetLinuxDistro() {
@@ -119,10 +154,11 @@ func RegisterPlugins(agent *agnt.Agent) error {
 	storageSampler := storage.NewSampler(agent.Context)
 	nfsSampler := nfs.NewSampler(agent.Context)
 	networkSampler := network.NewNetworkSampler(agent.Context)
+	networkConnectionSampler := network.NewNetworkConnectionSampler(agent.Context)
 
 	var ntpMonitor metrics.NtpMonitor
 	if config.NtpMetrics.Enabled {
-		ntpMonitor = metrics.NewNtp(config.NtpMetrics.Pool, config.NtpMetrics.Timeout, config.NtpMetrics.Interval)
+		ntpMonitor = metrics.NewNtp(config.NtpMetrics.Pool, config.NtpMetrics.Timeout, config.NtpMetrics.Interval, config.NtpMetrics.MinServers)
 	}
 	systemSampler := metrics.NewSystemSampler(agent.Context, storageSampler, ntpMonitor, hostid.NewProviderEnv())
 
@@ -146,6 +182,17 @@ func RegisterPlugins(agent *agnt.Agent) error {
 	sender.RegisterSampler(storageSampler)
 	sender.RegisterSampler(nfsSampler)
 	sender.RegisterSampler(networkSampler)
+	sender.RegisterSampler(networkConnectionSampler)
+	// opt-in, so it's not reported as a disabled sampler
+	if config.EnableSmartMetrics {
+		sender.RegisterSampler(smart.NewSampler(agent.Context))
+	}
+	if config.EnableRaidMetrics {
+		sender.RegisterSampler(raid.NewSampler(agent.Context))
+	}
+	if config.EnableLvmMetrics {
+		sender.RegisterSampler(lvm.NewSampler(agent.Context))
+	}
 	sender.RegisterSampler(procSampler)
 
 	agent.RegisterMetricsSender(sender)
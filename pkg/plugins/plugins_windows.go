@@ -3,7 +3,6 @@
 package plugins
 
 import (
-	"github.com/newrelic/infrastructure-agent/internal/plugins/common"
 	"github.com/newrelic/infrastructure-agent/pkg/metrics/network"
 	metricsSender "github.com/newrelic/infrastructure-agent/pkg/metrics/sender"
 	"github.com/newrelic/infrastructure-agent/pkg/metrics/storage"
@@ -19,15 +18,24 @@ import (
 func RegisterPlugins(a *agent.Agent) error {
 	config := a.GetContext().Config()
 
+	registerStartupEventPlugin(a)
+
 	if config.IsForwardOnly {
 		return nil
 	}
 
 	// Enabling the hostinfo plugin will make the host appear in the UI
 	a.RegisterPlugin(pluginsWindows.NewHostinfoPlugin(ids.PluginID{"metadata", "system"}, a.Context,
-		common.NewHostInfoCommon(a.Context.Version(), !a.Context.Config().DisableCloudMetadata, a.GetCloudHarvester())))
+		newHostInfoCommon(a)))
 	a.RegisterPlugin(NewHostAliasesPlugin(a.Context, a.GetCloudHarvester()))
 	a.RegisterPlugin(NewAgentConfigPlugin(ids.PluginID{"metadata", "agent_config"}, a.Context))
+	if config.EnableSampleRatesInventory {
+		a.RegisterPlugin(NewSampleRatesPlugin(a.Context))
+	}
+	if config.EnableRuntimeInventory {
+		a.RegisterPlugin(NewRuntimePlugin(a.Context))
+	}
+	registerCloudLifecyclePlugin(a)
 	if config.ProxyConfigPlugin {
 		a.RegisterPlugin(proxy.ConfigPlugin(a.Context))
 	}
@@ -49,6 +57,7 @@ func RegisterPlugins(a *agent.Agent) error {
 
 	a.RegisterPlugin(NewNetworkInterfacePlugin(ids.PluginID{"system", "network_interfaces"}, a.Context))
 	a.RegisterPlugin(pluginsWindows.NewServicesPlugin(ids.PluginID{"services", "windows_services"}, a.Context))
+	a.RegisterPlugin(pluginsWindows.NewScheduledTasksPlugin(ids.PluginID{"services", "scheduled_tasks"}, a.Context))
 	if config.EnableWinUpdatePlugin {
 		a.RegisterPlugin(pluginsWindows.NewUpdatesPlugin(ids.PluginID{"packages", "windows_updates"}, a.Context))
 	}
@@ -73,14 +82,17 @@ func RegisterPlugins(a *agent.Agent) error {
 		slog.WithError(err).Debug("Warming up Network Sampler Cache.")
 	}
 
+	networkConnectionSampler := network.NewNetworkConnectionSampler(a.Context)
+
 	var ntpMonitor metrics.NtpMonitor
 	if config.NtpMetrics.Enabled {
-		ntpMonitor = metrics.NewNtp(config.NtpMetrics.Pool, config.NtpMetrics.Timeout, config.NtpMetrics.Interval)
+		ntpMonitor = metrics.NewNtp(config.NtpMetrics.Pool, config.NtpMetrics.Timeout, config.NtpMetrics.Interval, config.NtpMetrics.MinServers)
 	}
 	systemSampler := metrics.NewSystemSampler(a.Context, storageSampler, ntpMonitor, hostid.NewProviderEnv())
 	sender.RegisterSampler(systemSampler)
 	sender.RegisterSampler(storageSampler)
 	sender.RegisterSampler(networkSampler)
+	sender.RegisterSampler(networkConnectionSampler)
 	sender.RegisterSampler(procSampler)
 	a.RegisterMetricsSender(sender)
 
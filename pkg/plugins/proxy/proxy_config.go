@@ -75,6 +75,10 @@ func ConfigPlugin(ctx agent.AgentContext) agent.Plugin {
 		e.Id = "proxy"
 		proxyConfig = append(proxyConfig, e)
 	}
+	if e := urlEntry(cfg.ProxyPacURL); e != nil {
+		e.Id = "proxy_pac_url"
+		proxyConfig = append(proxyConfig, e)
+	}
 	if e := pathEntry(cfg.CABundleDir); e != nil {
 		e.Id = "ca_bundle_dir"
 		proxyConfig = append(proxyConfig, e)
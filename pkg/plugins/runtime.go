@@ -0,0 +1,70 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package plugins
+
+import (
+	"os"
+	"runtime"
+	"runtime/metrics"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+const (
+	gcPercentMetric     = "/gc/gogc:percent"
+	gcMemoryLimitMetric = "/gc/gomemlimit:bytes"
+)
+
+// RuntimeSetting Go runtime setting the agent runs with.
+type RuntimeSetting struct {
+	Name  string      `json:"id"`
+	Value interface{} `json:"value"`
+}
+
+func (s RuntimeSetting) SortKey() string {
+	return s.Name
+}
+
+// RuntimePlugin reports the Go runtime settings of the agent, so the environment it runs on can be checked.
+type RuntimePlugin struct {
+	agent.PluginCommon
+}
+
+func NewRuntimePlugin(ctx agent.AgentContext) agent.Plugin {
+	return &RuntimePlugin{
+		PluginCommon: agent.PluginCommon{ID: ids.RuntimeID, Context: ctx},
+	}
+}
+
+func (p *RuntimePlugin) Run() {
+	p.Context.AddReconnecting(p)
+
+	p.EmitInventory(runtimeSettings(p.Context.Config().MaxProcs), entity.NewFromNameWithoutID(p.Context.EntityKey()))
+}
+
+// runtimeSettings returns the current runtime settings, sorted by name. GOMAXPROCS is the effective one: the max_procs
+// option when it's positive, otherwise the GOMAXPROCS environment variable or the number of CPUs.
+func runtimeSettings(maxProcs int) types.PluginInventoryDataset {
+	gc := []metrics.Sample{{Name: gcMemoryLimitMetric}, {Name: gcPercentMetric}}
+	metrics.Read(gc)
+
+	dataset := types.PluginInventoryDataset{
+		// math.MaxInt64 when there's no limit
+		RuntimeSetting{Name: "gc_memory_limit_bytes", Value: int64(gc[0].Value.Uint64())},
+		// -1 when the garbage collector is off
+		RuntimeSetting{Name: "gc_percent", Value: int64(gc[1].Value.Uint64())},
+		RuntimeSetting{Name: "go_version", Value: runtime.Version()},
+		RuntimeSetting{Name: "gomaxprocs", Value: runtime.GOMAXPROCS(0)},
+	}
+	if env, ok := os.LookupEnv("GOMAXPROCS"); ok {
+		dataset = append(dataset, RuntimeSetting{Name: "gomaxprocs_env", Value: env})
+	}
+
+	return append(dataset,
+		RuntimeSetting{Name: "max_procs", Value: maxProcs},
+		RuntimeSetting{Name: "num_cpu", Value: runtime.NumCPU()},
+	)
+}
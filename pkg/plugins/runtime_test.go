@@ -0,0 +1,125 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/mocks"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	runtimeSettingsChildEnv = "NRIA_TEST_RUNTIME_SETTINGS_CHILD"
+	runtimeSettingsPrefix   = "runtime settings: "
+)
+
+func settingsByName(dataset types.PluginInventoryDataset) map[string]interface{} {
+	settings := map[string]interface{}{}
+	for _, item := range dataset {
+		setting := item.(RuntimeSetting)
+		settings[setting.Name] = setting.Value
+	}
+	return settings
+}
+
+func TestRuntimePlugin(t *testing.T) {
+	ctx := new(mocks.AgentContext)
+	ctx.On("AddReconnecting", mock.Anything).Return()
+	ctx.On("EntityKey").Return("FakeAgent")
+	ctx.On("Config").Return(&config.Config{MaxProcs: 1})
+	ch := make(chan mock.Arguments)
+	ctx.On("SendData", mock.Anything).Run(func(args mock.Arguments) {
+		ch <- args
+	})
+	ctx.SendDataWg.Add(1)
+
+	go NewRuntimePlugin(ctx).Run()
+
+	args := <-ch
+	output := args[0].(types.PluginOutput)
+	assert.Equal(t, ids.RuntimeID, output.Id)
+	assert.Equal(t, entity.NewFromNameWithoutID("FakeAgent"), output.Entity)
+
+	settings := settingsByName(output.Data)
+	assert.Equal(t, runtime.Version(), settings["go_version"])
+	assert.Equal(t, runtime.GOMAXPROCS(0), settings["gomaxprocs"])
+	assert.Equal(t, runtime.NumCPU(), settings["num_cpu"])
+	assert.Equal(t, 1, settings["max_procs"])
+
+	// sorted by name
+	for i := 1; i < len(output.Data); i++ {
+		assert.Less(t, output.Data[i-1].SortKey(), output.Data[i].SortKey())
+	}
+	ctx.AssertExpectations(t)
+}
+
+func TestRuntimeSettings_GC(t *testing.T) {
+	defer debug.SetGCPercent(debug.SetGCPercent(50))
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(1 << 30))
+
+	settings := settingsByName(runtimeSettings(1))
+	assert.Equal(t, int64(50), settings["gc_percent"])
+	assert.Equal(t, int64(1<<30), settings["gc_memory_limit_bytes"])
+
+	debug.SetGCPercent(-1)
+	debug.SetMemoryLimit(math.MaxInt64)
+
+	settings = settingsByName(runtimeSettings(1))
+	assert.Equal(t, int64(-1), settings["gc_percent"])
+	assert.Equal(t, int64(math.MaxInt64), settings["gc_memory_limit_bytes"])
+}
+
+func TestRuntimeSettings_MaxProcsFromConfig(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(2))
+
+	settings := settingsByName(runtimeSettings(2))
+	assert.Equal(t, 2, settings["gomaxprocs"])
+	assert.Equal(t, 2, settings["max_procs"])
+}
+
+// The GOMAXPROCS environment variable is read when the process starts, so the agent is run as a child process.
+func TestRuntimeSettings_MaxProcsFromEnv(t *testing.T) {
+	if os.Getenv(runtimeSettingsChildEnv) != "" {
+		// max_procs: -1 keeps the GOMAXPROCS of the environment
+		runtime.GOMAXPROCS(-1)
+		settings, err := json.Marshal(settingsByName(runtimeSettings(-1)))
+		require.NoError(t, err)
+		fmt.Println(runtimeSettingsPrefix + string(settings))
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRuntimeSettings_MaxProcsFromEnv$")
+	cmd.Env = append(os.Environ(), runtimeSettingsChildEnv+"=1", "GOMAXPROCS=3")
+	out, err := cmd.Output()
+	require.NoError(t, err, string(out))
+
+	var settings map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, runtimeSettingsPrefix) {
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, runtimeSettingsPrefix)), &settings))
+		}
+	}
+	require.NotNil(t, settings, string(out))
+
+	assert.Equal(t, float64(3), settings["gomaxprocs"])
+	assert.Equal(t, "3", settings["gomaxprocs_env"])
+	assert.Equal(t, float64(-1), settings["max_procs"])
+}
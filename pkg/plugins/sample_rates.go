@@ -0,0 +1,60 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package plugins
+
+import (
+	"sort"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+)
+
+// SampleRate effective sample rate of a metrics sampler.
+type SampleRate struct {
+	Sampler     string `json:"id"`
+	IntervalSec int64  `json:"interval_sec"`
+	Disabled    bool   `json:"disabled"`
+	Floored     bool   `json:"floored"`
+}
+
+func (s SampleRate) SortKey() string {
+	return s.Sampler
+}
+
+// SampleRatesPlugin reports the effective sample rates of the metrics samplers, as resolved by the configuration
+// normalization.
+type SampleRatesPlugin struct {
+	agent.PluginCommon
+}
+
+func NewSampleRatesPlugin(ctx agent.AgentContext) agent.Plugin {
+	return &SampleRatesPlugin{
+		PluginCommon: agent.PluginCommon{ID: ids.SampleRatesID, Context: ctx},
+	}
+}
+
+func (p *SampleRatesPlugin) Run() {
+	p.Context.AddReconnecting(p)
+
+	rates := p.Context.Config().SampleRatesStatus()
+	samplers := make([]string, 0, len(rates))
+	for sampler := range rates {
+		samplers = append(samplers, sampler)
+	}
+	sort.Strings(samplers)
+
+	var dataset types.PluginInventoryDataset
+	for _, sampler := range samplers {
+		status := rates[sampler]
+		dataset = append(dataset, SampleRate{
+			Sampler:     sampler,
+			IntervalSec: status.IntervalSec,
+			Disabled:    status.Disabled,
+			Floored:     status.Floored,
+		})
+	}
+
+	p.EmitInventory(dataset, entity.NewFromNameWithoutID(p.Context.EntityKey()))
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/mocks"
+	"github.com/newrelic/infrastructure-agent/internal/agent/types"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleRatesPlugin(t *testing.T) {
+	cfgFile, err := ioutil.TempFile("", "sample_rates")
+	require.NoError(t, err)
+	defer os.Remove(cfgFile.Name())
+	_, err = cfgFile.WriteString(`
+license_key: abc123
+enable_sample_rates_inventory: true
+metrics_system_sample_rate: 1
+metrics_storage_sample_rate: 60
+metrics_network_sample_rate: -1
+metrics_sample_rate_overrides:
+  process: 30
+`)
+	require.NoError(t, err)
+	require.NoError(t, cfgFile.Close())
+
+	cfg, err := config.LoadConfig(cfgFile.Name())
+	require.NoError(t, err)
+
+	ctx := new(mocks.AgentContext)
+	ctx.On("AddReconnecting", mock.Anything).Return()
+	ctx.On("EntityKey").Return("FakeAgent")
+	ctx.On("Config").Return(cfg)
+	ch := make(chan mock.Arguments)
+	ctx.On("SendData", mock.Anything).Run(func(args mock.Arguments) {
+		ch <- args
+	})
+	ctx.SendDataWg.Add(1)
+
+	go NewSampleRatesPlugin(ctx).Run()
+
+	args := <-ch
+	expected := types.NewPluginOutput(ids.SampleRatesID, entity.NewFromNameWithoutID("FakeAgent"), types.PluginInventoryDataset{
+		SampleRate{Sampler: "network", IntervalSec: config.FREQ_DISABLE_SAMPLING, Disabled: true},
+		SampleRate{Sampler: "nfs", IntervalSec: int64(config.DefaultMetricsNFSSampleRate)},
+		SampleRate{Sampler: "process", IntervalSec: 30},
+		SampleRate{Sampler: "storage", IntervalSec: 60},
+		// raised to the minimum value
+		SampleRate{Sampler: "system", IntervalSec: config.FREQ_INTERVAL_FLOOR_SYSTEM_METRICS, Floored: true},
+		SampleRate{Sampler: "tcp", IntervalSec: int64(config.DefaultMetricsTCPSampleRate)},
+	})
+	assert.Equal(t, expected, args[0])
+	ctx.AssertExpectations(t)
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package plugins
+
+import (
+	"github.com/newrelic/infrastructure-agent/internal/agent"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
+)
+
+const startupEventType = "InfrastructureAgentStartup"
+
+// cloudTypeProvider provides the type of the detected cloud.
+type cloudTypeProvider interface {
+	GetCloudType() cloud.Type
+}
+
+// StartupEventPlugin emits, once, an event summarizing how the agent runs, so fleets can be audited.
+type StartupEventPlugin struct {
+	agent.PluginCommon
+	cloud cloudTypeProvider
+}
+
+// registerStartupEventPlugin registers the startup event plugin if enabled. It must be registered in every run mode,
+// forward only included.
+func registerStartupEventPlugin(a *agent.Agent) {
+	if a.GetContext().Config().EmitStartupEvent {
+		a.RegisterPlugin(NewStartupEventPlugin(a.Context, a.GetCloudHarvester()))
+	}
+}
+
+func NewStartupEventPlugin(ctx agent.AgentContext, cloudTypeProvider cloudTypeProvider) agent.Plugin {
+	return &StartupEventPlugin{
+		PluginCommon: agent.PluginCommon{ID: ids.StartupEventID, Context: ctx},
+		cloud:        cloudTypeProvider,
+	}
+}
+
+func (p *StartupEventPlugin) Run() {
+	p.EmitEvent(p.startupEvent(), entity.Key(p.Context.EntityKey()))
+	// no inventory is reported, so the initial inventory reap doesn't wait for it
+	p.Unregister()
+}
+
+func (p *StartupEventPlugin) startupEvent() map[string]interface{} {
+	cfg := p.Context.Config()
+
+	event := map[string]interface{}{
+		"eventType":             startupEventType,
+		"runMode":               cfg.RunMode,
+		"agentUser":             cfg.AgentUser,
+		"agentVersion":          p.Context.Version(),
+		"cloudType":             string(p.cloud.GetCloudType()),
+		"logLevel":              cfg.Log.Level,
+		"logForwardEnabled":     cfg.Log.Forward != nil && *cfg.Log.Forward,
+		"forwardOnly":           cfg.IsForwardOnly,
+		"integrationsOnly":      cfg.IsIntegrationsOnly,
+		"containerized":         cfg.IsContainerized,
+		"inventoryEnabled":      !cfg.IsForwardOnly,
+		"processMetricsEnabled": cfg.EnableProcessMetrics != nil && *cfg.EnableProcessMetrics,
+		"httpServerEnabled":     cfg.HTTPServerEnabled,
+		"statusServerEnabled":   cfg.StatusServerEnabled,
+		"cloudMetadataEnabled":  !cfg.DisableCloudMetadata,
+	}
+	// forward only and heartbeat only modes don't run the metrics samplers
+	samplersRun := !cfg.IsForwardOnly && !isHeartbeatOnlyMode(cfg)
+	for sampler, status := range cfg.SampleRatesStatus() {
+		event[sampler+"SamplerEnabled"] = samplersRun && !status.Disabled
+	}
+	return event
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package plugins
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/newrelic/infrastructure-agent/internal/agent/mocks"
+	"github.com/newrelic/infrastructure-agent/pkg/config"
+	"github.com/newrelic/infrastructure-agent/pkg/entity"
+	"github.com/newrelic/infrastructure-agent/pkg/plugins/ids"
+	"github.com/newrelic/infrastructure-agent/pkg/sysinfo/cloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCloudType cloud.Type
+
+func (f fakeCloudType) GetCloudType() cloud.Type {
+	return cloud.Type(f)
+}
+
+func TestStartupEventPlugin_Run(t *testing.T) {
+	forward := true
+	tests := []struct {
+		name     string
+		cfg      *config.Config
+		expected map[string]interface{}
+	}{
+		{
+			name: "regular mode",
+			cfg: &config.Config{
+				RunMode:                  config.ModeUnprivileged,
+				AgentUser:                "nri-agent",
+				Log:                      config.LogConfig{Level: config.LogLevelDebug, Forward: &forward},
+				MetricsSystemSampleRate:  5,
+				MetricsProcessSampleRate: config.FREQ_DISABLE_SAMPLING,
+			},
+			expected: map[string]interface{}{
+				"runMode":               config.ModeUnprivileged,
+				"agentUser":             "nri-agent",
+				"logLevel":              config.LogLevelDebug,
+				"logForwardEnabled":     true,
+				"forwardOnly":           false,
+				"inventoryEnabled":      true,
+				"systemSamplerEnabled":  true,
+				"processSamplerEnabled": false,
+			},
+		},
+		{
+			name: "forward only mode",
+			cfg: &config.Config{
+				RunMode:                 config.ModeRoot,
+				AgentUser:               "root",
+				Log:                     config.LogConfig{Level: config.LogLevelInfo},
+				IsForwardOnly:           true,
+				MetricsSystemSampleRate: 5,
+			},
+			expected: map[string]interface{}{
+				"runMode":              config.ModeRoot,
+				"agentUser":            "root",
+				"logLevel":             config.LogLevelInfo,
+				"logForwardEnabled":    false,
+				"forwardOnly":          true,
+				"inventoryEnabled":     false,
+				"systemSamplerEnabled": false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := new(mocks.AgentContext)
+			ctx.On("EntityKey").Return("FakeAgent")
+			ctx.On("Config").Return(tt.cfg)
+			ctx.On("Version").Return("1.2.3")
+			ctx.On("SendEvent", mock.Anything, entity.Key("FakeAgent")).Return()
+			ctx.On("Unregister", ids.StartupEventID).Return()
+
+			NewStartupEventPlugin(ctx, fakeCloudType(cloud.TypeAWS)).Run()
+
+			ctx.AssertNumberOfCalls(t, "SendEvent", 1)
+			ctx.AssertCalled(t, "Unregister", ids.StartupEventID)
+			var eventData map[string]interface{}
+			for _, call := range ctx.Calls {
+				if call.Method == "SendEvent" {
+					event, err := json.Marshal(call.Arguments.Get(0))
+					require.NoError(t, err)
+					require.NoError(t, json.Unmarshal(event, &eventData))
+				}
+			}
+			assert.Equal(t, startupEventType, eventData["eventType"])
+			assert.Equal(t, "1.2.3", eventData["agentVersion"])
+			assert.Equal(t, "aws", eventData["cloudType"])
+			for attribute, value := range tt.expected {
+				assert.Equal(t, value, eventData[attribute], attribute)
+			}
+		})
+	}
+}
@@ -16,6 +16,19 @@ type Event interface {
 	Timestamp(timestamp int64)
 }
 
+// typedEvent is implemented by the events exposing their event type.
+type typedEvent interface {
+	GetEventType() string
+}
+
+// TypeOf returns the event type of an event, or an empty string when the event doesn't expose it.
+func TypeOf(event Event) string {
+	if e, ok := event.(typedEvent); ok {
+		return e.GetEventType()
+	}
+	return ""
+}
+
 // EventBatch is a slice of Event
 type EventBatch []Event
 
@@ -34,6 +47,11 @@ func (bse *BaseEvent) Type(eventType string) {
 	bse.EventType = eventType
 }
 
+// GetEventType returns the event type
+func (bse *BaseEvent) GetEventType() string {
+	return bse.EventType
+}
+
 // Entity sets the event entity
 func (bse *BaseEvent) Entity(key entity.Key) {
 	bse.EntityKey = string(key)
@@ -80,23 +80,50 @@ type Detector struct {
 	initialized          bool          // Flag to determine when the Detector is initialized.
 	inProgress           bool          // Flag to determine when Detector initialization is in progress.
 	disableKeepAlive     bool          // Disables HTTP keep-alives and will only use the connection to the server for a single HTTP request.
+	disabledProviders    map[Type]bool // Cloud providers whose metadata endpoints are never probed.
+	cacheFile            string        // File the detected metadata is persisted to, if any.
+	cacheLock            sync.Mutex
+	now                  func() time.Time
+	sleep                func(time.Duration)
 }
 
-// NewDetector returns a new Detector instance.
-func NewDetector(disableCloudMetadata bool, maxRetriesNumber, retryBackOffSec, expiryInSec int, disableKeepAlive bool) *Detector {
+// NewDetector returns a new Detector instance. The metadata endpoints of the disabledProviders are never probed,
+// unknown provider names are ignored.
+func NewDetector(disableCloudMetadata bool, maxRetriesNumber, retryBackOffSec, expiryInSec int, disableKeepAlive bool, disabledProviders []string) *Detector {
 	return &Detector{
 		maxRetriesNumber:     maxRetriesNumber,
 		retryBackOff:         time.Duration(retryBackOffSec) * time.Second,
 		expiryInSec:          expiryInSec,
 		disableCloudMetadata: disableCloudMetadata,
 		disableKeepAlive:     disableKeepAlive,
+		disabledProviders:    parseDisabledProviders(disabledProviders),
+		now:                  time.Now,
+		sleep:                time.Sleep,
 	}
 }
 
+func parseDisabledProviders(providers []string) map[Type]bool {
+	disabled := map[Type]bool{}
+	for _, provider := range providers {
+		cloudType := Type(provider)
+		if !cloudType.IsValidCloud() {
+			dlog.WithField("provider", provider).Warn("Ignoring unknown cloud provider to disable in cloud detection.")
+			continue
+		}
+		disabled[cloudType] = true
+	}
+	return disabled
+}
+
 type DetectorOption func(*Detector)
 
 func WithProvider(cloudType Type) DetectorOption {
 	return func(detector *Detector) {
+		if detector.disabledProviders[cloudType] {
+			dlog.WithField("cloudType", cloudType).Warn("Cloud provider is disabled for cloud detection, ignoring it.")
+			return
+		}
+
 		switch cloudType {
 		case TypeAWS:
 			detector.setHarvester(NewAWSHarvester(detector.disableKeepAlive))
@@ -148,14 +175,52 @@ func (d *Detector) initialize(harvesters ...Harvester) {
 		return
 	}
 
+	// Disabled providers are dropped before detecting, so they neither get probed nor spend retries.
+	harvesters = d.enabledHarvesters(harvesters)
+	if len(harvesters) == 0 {
+		d.finishInit()
+		return
+	}
+
+	cache := d.loadCache()
+	if cache != nil && d.isCacheFresh(cache) {
+		dlog.WithField("cloudType", cache.CloudType).Debug("Using the cached cloud metadata.")
+		d.setHarvester(newCachedHarvester(cache, harvesters...))
+		d.finishInit()
+		go d.refreshCache(cache, harvesters...)
+		return
+	}
+
 	d.initializeStart()
 
 	err := d.detect(harvesters...)
 	if err != nil {
+		// Fall back to the cached metadata instead of blocking until the detection succeeds.
+		if cache != nil {
+			dlog.WithField("cloudType", cache.CloudType).Info("Cloud detection failed, using the cached cloud metadata.")
+			d.setHarvester(newCachedHarvester(cache, harvesters...))
+			d.finishInit()
+		}
 		go d.detectRetrying(harvesters...)
 	}
 }
 
+// enabledHarvesters returns the harvesters of the providers that are not disabled.
+func (d *Detector) enabledHarvesters(harvesters []Harvester) []Harvester {
+	enabled := make([]Harvester, 0, len(harvesters))
+	for _, harvester := range harvesters {
+		if harvester == nil {
+			continue
+		}
+		if d.disabledProviders[harvester.GetCloudType()] {
+			dlog.WithField("cloudType", harvester.GetCloudType()).Debug("Skipping disabled cloud provider detection.")
+			continue
+		}
+		enabled = append(enabled, harvester)
+	}
+	return enabled
+}
+
 func (d *Detector) GetHarvester() (Harvester, error) {
 	if cloudHarvester := d.getHarvester(); cloudHarvester != nil {
 		return cloudHarvester, nil
@@ -316,6 +381,7 @@ func (d *Detector) detect(harvesters ...Harvester) error {
 			}).Debug("Detected cloud type and retrieved instance ID")
 
 			d.setHarvester(harvester)
+			d.saveCache(harvester, instanceID)
 			d.finishInit()
 			return nil
 		}
@@ -0,0 +1,247 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package cloud
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errNotCached is returned by the cached harvester for the metadata that couldn't be fetched before caching it.
+var errNotCached = errors.New("cloud metadata not cached")
+
+// metadataCache last successfully detected cloud metadata, persisted to disk to be available on restarts.
+type metadataCache struct {
+	Timestamp       time.Time `json:"timestamp"`
+	CloudType       Type      `json:"cloudType"`
+	CloudSource     string    `json:"cloudSource"`
+	InstanceID      string    `json:"instanceId"`
+	HostType        string    `json:"hostType,omitempty"`
+	Region          string    `json:"region,omitempty"`
+	AccountID       string    `json:"accountId,omitempty"`
+	Zone            string    `json:"zone,omitempty"`
+	InstanceImageID string    `json:"instanceImageId,omitempty"`
+}
+
+// WithDiskCache persists the detected cloud metadata to cacheFile. On startup, the cached metadata is used right
+// away while younger than the metadata expiry, and also when the detection fails. No cache is used when empty.
+func WithDiskCache(cacheFile string) DetectorOption {
+	return func(detector *Detector) {
+		detector.cacheFile = cacheFile
+	}
+}
+
+// loadCache returns the cached metadata, or nil if there is none usable.
+func (d *Detector) loadCache() *metadataCache {
+	if d.cacheFile == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(d.cacheFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			dlog.WithError(err).WithField("file", d.cacheFile).Warn("Cannot read the cloud metadata cache.")
+		}
+		return nil
+	}
+
+	var cache metadataCache
+	if err = json.Unmarshal(content, &cache); err != nil {
+		dlog.WithError(err).WithField("file", d.cacheFile).Warn("Ignoring invalid cloud metadata cache.")
+		return nil
+	}
+	if !cache.CloudType.IsValidCloud() || cache.InstanceID == "" || d.disabledProviders[cache.CloudType] {
+		return nil
+	}
+	return &cache
+}
+
+// isCacheFresh returns whether the cached metadata is younger than the metadata expiry.
+func (d *Detector) isCacheFresh(cache *metadataCache) bool {
+	return d.expiryInSec > 0 && d.now().Sub(cache.Timestamp) < time.Duration(d.expiryInSec)*time.Second
+}
+
+// saveCache persists the metadata of the detected harvester.
+func (d *Detector) saveCache(harvester Harvester, instanceID string) {
+	if d.cacheFile == "" {
+		return
+	}
+
+	cache := metadataCache{
+		Timestamp:   d.now(),
+		CloudType:   harvester.GetCloudType(),
+		CloudSource: harvester.GetCloudSource(),
+		InstanceID:  instanceID,
+	}
+	cache.HostType, _ = harvester.GetHostType()
+	cache.Region, _ = harvester.GetRegion()
+	cache.AccountID, _ = harvester.GetAccountID()
+	cache.Zone, _ = harvester.GetZone()
+	cache.InstanceImageID, _ = harvester.GetInstanceImageID()
+
+	content, err := json.Marshal(cache)
+	if err != nil {
+		dlog.WithError(err).Warn("Cannot encode the cloud metadata cache.")
+		return
+	}
+
+	d.cacheLock.Lock()
+	defer d.cacheLock.Unlock()
+
+	if err = os.MkdirAll(filepath.Dir(d.cacheFile), 0o755); err != nil {
+		dlog.WithError(err).WithField("file", d.cacheFile).Warn("Cannot create the cloud metadata cache directory.")
+		return
+	}
+	// Written to a temporary file first, so a crash doesn't leave a partially written cache.
+	tmpFile := d.cacheFile + ".tmp"
+	if err = os.WriteFile(tmpFile, content, 0o644); err != nil {
+		dlog.WithError(err).WithField("file", d.cacheFile).Warn("Cannot write the cloud metadata cache.")
+		return
+	}
+	if err = os.Rename(tmpFile, d.cacheFile); err != nil {
+		dlog.WithError(err).WithField("file", d.cacheFile).Warn("Cannot write the cloud metadata cache.")
+	}
+}
+
+// maxRefreshBackOff maximum wait between the cache refresh attempts when the metadata doesn't expire.
+const maxRefreshBackOff = time.Hour
+
+// refreshCache detects the cloud metadata in background once the cached one is in use, replacing it on success. The
+// detection is retried until it succeeds, doubling the retry backoff up to the metadata expiry, so the cached metadata
+// isn't kept for the whole agent run after a transient failure.
+func (d *Detector) refreshCache(cache *metadataCache, harvesters ...Harvester) {
+	backOff := d.retryBackOff
+	if backOff <= 0 {
+		backOff = time.Second
+	}
+	maxBackOff := time.Duration(d.expiryInSec) * time.Second
+	if maxBackOff <= 0 {
+		maxBackOff = maxRefreshBackOff
+	}
+
+	for !d.refreshCacheOnce(cache, harvesters...) {
+		if backOff > maxBackOff {
+			backOff = maxBackOff
+		}
+		dlog.WithField("retryIn", backOff).Debug("Couldn't refresh the cloud metadata, keeping the cached one.")
+		d.sleep(backOff)
+		backOff *= 2
+	}
+}
+
+// refreshCacheOnce replaces the cached metadata by the one of the first harvester detecting the instance, returning
+// whether any did.
+func (d *Detector) refreshCacheOnce(cache *metadataCache, harvesters ...Harvester) bool {
+	for _, harvester := range harvesters {
+		instanceID, err := harvester.GetInstanceID()
+		if err != nil {
+			continue
+		}
+		if instanceID != cache.InstanceID || harvester.GetCloudType() != cache.CloudType {
+			dlog.WithFields(logrus.Fields{
+				"cachedInstanceId": cache.InstanceID,
+				"instanceId":       instanceID,
+				"cloudType":        harvester.GetCloudType(),
+			}).Info("Cloud instance changed, invalidating the cloud metadata cache.")
+		}
+		d.setHarvester(harvester)
+		d.saveCache(harvester, instanceID)
+		return true
+	}
+	return false
+}
+
+// cachedHarvester serves the metadata from the disk cache. The metadata that can't be cached, like the instance
+// lifecycle or public IP, is read through the harvester of the cached cloud type.
+type cachedHarvester struct {
+	cache     metadataCache
+	harvester Harvester
+}
+
+// newCachedHarvester returns a harvester serving the cached metadata, passing through to the harvester of the cached
+// cloud type, if any, the metadata that isn't cached.
+func newCachedHarvester(cache *metadataCache, harvesters ...Harvester) *cachedHarvester {
+	cached := &cachedHarvester{cache: *cache}
+	for _, harvester := range harvesters {
+		if harvester.GetCloudType() == cache.CloudType {
+			cached.harvester = harvester
+			break
+		}
+	}
+	return cached
+}
+
+func cachedValue(value string) (string, error) {
+	if value == "" {
+		return "", errNotCached
+	}
+	return value, nil
+}
+
+// GetInstanceID returns the cached instance ID.
+func (c *cachedHarvester) GetInstanceID() (string, error) {
+	return cachedValue(c.cache.InstanceID)
+}
+
+// GetHostType returns the cached instance type.
+func (c *cachedHarvester) GetHostType() (string, error) {
+	return cachedValue(c.cache.HostType)
+}
+
+// GetCloudType returns the cached cloud type.
+func (c *cachedHarvester) GetCloudType() Type {
+	return c.cache.CloudType
+}
+
+// GetCloudSource returns the cached cloud source.
+func (c *cachedHarvester) GetCloudSource() string {
+	return c.cache.CloudSource
+}
+
+// GetRegion returns the cached region.
+func (c *cachedHarvester) GetRegion() (string, error) {
+	return cachedValue(c.cache.Region)
+}
+
+// GetAccountID returns the cached account ID.
+func (c *cachedHarvester) GetAccountID() (string, error) {
+	return cachedValue(c.cache.AccountID)
+}
+
+// GetZone returns the cached zone.
+func (c *cachedHarvester) GetZone() (string, error) {
+	return cachedValue(c.cache.Zone)
+}
+
+// GetInstanceImageID returns the cached instance image ID.
+func (c *cachedHarvester) GetInstanceImageID() (string, error) {
+	return cachedValue(c.cache.InstanceImageID)
+}
+
+// GetLifecycle returns the lifecycle read by the harvester of the cached cloud type, as it can change at any time.
+func (c *cachedHarvester) GetLifecycle() (Lifecycle, error) {
+	lifecycleHarvester, ok := c.harvester.(LifecycleHarvester)
+	if !ok {
+		return Lifecycle{}, ErrMethodNotImplemented
+	}
+	return lifecycleHarvester.GetLifecycle()
+}
+
+// GetPublicIP returns the public IP read by the harvester of the cached cloud type, as it can change on restarts.
+func (c *cachedHarvester) GetPublicIP() (string, error) {
+	publicIPHarvester, ok := c.harvester.(PublicIPHarvester)
+	if !ok {
+		return "", ErrMethodNotImplemented
+	}
+	return publicIPHarvester.GetPublicIP()
+}
+
+// GetHarvester returns the cachedHarvester.
+func (c *cachedHarvester) GetHarvester() (Harvester, error) {
+	return c, nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package cloud
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// instanceHarvester harvester returning a fixed instance ID, once unblocked if a channel is provided.
+type instanceHarvester struct {
+	*MockHarvester
+	instanceID string
+	unblock    chan struct{}
+}
+
+func newInstanceHarvester(cloudType Type, instanceID string) *instanceHarvester {
+	return &instanceHarvester{MockHarvester: NewMockHarvester(cloudType), instanceID: instanceID}
+}
+
+func (h *instanceHarvester) GetInstanceID() (string, error) {
+	if h.unblock != nil {
+		<-h.unblock
+	}
+	if h.instanceID == "" {
+		return "", errors.New("metadata endpoint throttled")
+	}
+	return h.instanceID, nil
+}
+
+func writeCache(t *testing.T, file string, cache metadataCache) {
+	t.Helper()
+
+	content, err := json.Marshal(cache)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, content, 0o644))
+}
+
+func readCache(t *testing.T, file string) metadataCache {
+	t.Helper()
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	var cache metadataCache
+	require.NoError(t, json.Unmarshal(content, &cache))
+	return cache
+}
+
+func newCachingDetector(cacheFile string, now time.Time) *Detector {
+	detector := NewDetector(false, 0, 0, 300, false, nil)
+	WithDiskCache(cacheFile)(detector)
+	detector.now = func() time.Time { return now }
+	return detector
+}
+
+func TestDetector_DiskCache_PersistsDetection(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "cache", "cloud_metadata.json")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	detector := newCachingDetector(cacheFile, now)
+
+	detector.initialize(newInstanceHarvester(TypeAWS, "i-123"))
+
+	require.True(t, detector.isInitialized())
+	assert.Equal(t, metadataCache{
+		Timestamp:   now,
+		CloudType:   TypeAWS,
+		CloudSource: "test cloud source",
+		InstanceID:  "i-123",
+		HostType:    "test host type",
+		Region:      "myRegion",
+	}, readCache(t, cacheFile))
+}
+
+func TestDetector_DiskCache_FreshCacheUsedWhileRefreshing(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "cloud_metadata.json")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeCache(t, cacheFile, metadataCache{Timestamp: now.Add(-time.Minute), CloudType: TypeAWS, InstanceID: "i-cached", Region: "cached-region"})
+	detector := newCachingDetector(cacheFile, now)
+
+	harvester := newInstanceHarvester(TypeAWS, "i-new")
+	harvester.unblock = make(chan struct{})
+	detector.initialize(harvester)
+
+	// the cached metadata is available without waiting for the metadata endpoint
+	require.True(t, detector.isInitialized())
+	instanceID, err := detector.GetInstanceID()
+	require.NoError(t, err)
+	assert.Equal(t, "i-cached", instanceID)
+	region, err := detector.GetRegion()
+	require.NoError(t, err)
+	assert.Equal(t, "cached-region", region)
+
+	// the instance ID changed, so the cache is replaced by the refreshed metadata
+	close(harvester.unblock)
+	require.Eventually(t, func() bool {
+		instanceID, err := detector.GetInstanceID()
+		return err == nil && instanceID == "i-new"
+	}, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return readCache(t, cacheFile).InstanceID == "i-new"
+	}, time.Second, 10*time.Millisecond)
+}
+
+// failingHarvester harvester failing to return the instance ID the given number of times.
+type failingHarvester struct {
+	*MockHarvester
+	instanceID string
+	failures   atomic.Int32
+}
+
+func (h *failingHarvester) GetInstanceID() (string, error) {
+	if h.failures.Add(-1) >= 0 {
+		return "", errors.New("metadata endpoint throttled")
+	}
+	return h.instanceID, nil
+}
+
+func TestDetector_DiskCache_RefreshRetriedUntilSuccess(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "cloud_metadata.json")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeCache(t, cacheFile, metadataCache{Timestamp: now.Add(-time.Minute), CloudType: TypeAWS, InstanceID: "i-cached"})
+	detector := newCachingDetector(cacheFile, now)
+	detector.retryBackOff = 100 * time.Second
+	waits := make(chan time.Duration, 10)
+	detector.sleep = func(d time.Duration) { waits <- d }
+
+	harvester := &failingHarvester{MockHarvester: NewMockHarvester(TypeAWS), instanceID: "i-new"}
+	harvester.failures.Store(3)
+	detector.initialize(harvester)
+
+	// the refresh backs off up to the metadata expiry
+	require.Eventually(t, func() bool {
+		instanceID, err := detector.GetInstanceID()
+		return err == nil && instanceID == "i-new"
+	}, time.Second, 10*time.Millisecond)
+	close(waits)
+	var backOffs []time.Duration
+	for wait := range waits {
+		backOffs = append(backOffs, wait)
+	}
+	assert.Equal(t, []time.Duration{100 * time.Second, 200 * time.Second, 300 * time.Second}, backOffs)
+	require.Eventually(t, func() bool {
+		return readCache(t, cacheFile).InstanceID == "i-new"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestDetector_DiskCache_StaleCacheUsedOnDetectionFailure(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "cloud_metadata.json")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeCache(t, cacheFile, metadataCache{Timestamp: now.Add(-time.Hour), CloudType: TypeGCP, InstanceID: "i-cached"})
+	detector := newCachingDetector(cacheFile, now)
+
+	detector.initialize(newInstanceHarvester(TypeGCP, ""))
+
+	require.True(t, detector.isInitialized())
+	assert.Equal(t, TypeGCP, detector.GetCloudType())
+	instanceID, err := detector.GetInstanceID()
+	require.NoError(t, err)
+	assert.Equal(t, "i-cached", instanceID)
+	_, err = detector.GetHostType()
+	assert.ErrorIs(t, err, errNotCached)
+}
+
+func TestDetector_DiskCache_StaleCacheReplacedOnDetection(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "cloud_metadata.json")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeCache(t, cacheFile, metadataCache{Timestamp: now.Add(-time.Hour), CloudType: TypeAWS, InstanceID: "i-cached"})
+	detector := newCachingDetector(cacheFile, now)
+
+	detector.initialize(newInstanceHarvester(TypeAWS, "i-new"))
+
+	instanceID, err := detector.GetInstanceID()
+	require.NoError(t, err)
+	assert.Equal(t, "i-new", instanceID)
+	cache := readCache(t, cacheFile)
+	assert.Equal(t, "i-new", cache.InstanceID)
+	assert.Equal(t, now, cache.Timestamp)
+}
+
+func TestDetector_DiskCache_IgnoresDisabledProviderCache(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "cloud_metadata.json")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeCache(t, cacheFile, metadataCache{Timestamp: now, CloudType: TypeAzure, InstanceID: "i-cached"})
+	detector := NewDetector(false, 0, 0, 300, false, []string{"azure"})
+	WithDiskCache(cacheFile)(detector)
+	detector.now = func() time.Time { return now }
+
+	assert.Nil(t, detector.loadCache())
+}
+
+func TestDetector_DiskCache_Disabled(t *testing.T) {
+	detector := NewDetector(false, 0, 0, 300, false, nil)
+
+	detector.initialize(newInstanceHarvester(TypeAWS, "i-123"))
+
+	assert.Nil(t, detector.loadCache())
+	assert.Equal(t, TypeAWS, detector.GetCloudType())
+}
@@ -0,0 +1,227 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	// AWS lifecycle metadata paths, relative to awsMetaDataPath.
+	awsInstanceLifeCyclePath  = "instance-life-cycle"
+	awsSpotInstanceActionPath = "spot/instance-action"
+	awsSpotLifeCycle          = "spot"
+
+	gcpPreemptiblePath = "scheduling/preemptible"
+	gcpPreemptedPath   = "preempted"
+	gcpTrue            = "TRUE"
+
+	azurePriorityPath        = "/metadata/instance/compute/priority?api-version=2021-02-01&format=text"
+	azureScheduledEventsPath = "/metadata/scheduledevents?api-version=2020-07-01"
+	azurePreemptEventType    = "Preempt"
+)
+
+// Metadata endpoints queried for the instance lifecycle, variables so tests can replace them.
+var (
+	gcpInstanceMetadataURL = "http://metadata.google.internal/computeMetadata/v1/instance/"
+	azureMetadataHostname  = "http://169.254.169.254"
+)
+
+// Lifecycle purchasing option and interruption status of a cloud instance.
+type Lifecycle struct {
+	// Spot is true for the spot, preemptible and low priority instances, which the provider may interrupt.
+	Spot bool
+	// InterruptionPending is true when the provider has noticed an upcoming interruption of the instance.
+	InterruptionPending bool
+	// InterruptionAction is the action the provider takes on the instance when interrupting it, if known.
+	InterruptionAction string
+	// InterruptionTime is when the instance is interrupted, as reported by the provider, if known.
+	InterruptionTime string
+}
+
+// LifecycleHarvester is implemented by the harvesters able to report the instance lifecycle.
+type LifecycleHarvester interface {
+	// GetLifecycle returns the purchasing option and interruption status of the instance.
+	GetLifecycle() (Lifecycle, error)
+}
+
+// GetLifecycle returns the lifecycle of the detected cloud instance.
+func (d *Detector) GetLifecycle() (Lifecycle, error) {
+	cloudHarvester, err := d.GetHarvester()
+	if err != nil {
+		return Lifecycle{}, err
+	}
+	lifecycleHarvester, ok := cloudHarvester.(LifecycleHarvester)
+	if !ok {
+		return Lifecycle{}, ErrMethodNotImplemented
+	}
+	return lifecycleHarvester.GetLifecycle()
+}
+
+// GetLifecycle returns whether the instance is a spot one and, in that case, whether a spot interruption notice
+// is pending.
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/spot-instance-termination-notices.html
+func (a *AWSHarvester) GetLifecycle() (lifecycle Lifecycle, err error) {
+	lifeCycle, err := a.GetAWSMetadataValue(awsInstanceLifeCyclePath, a.disableKeepAlive)
+	if err != nil {
+		return lifecycle, err
+	}
+	lifecycle.Spot = lifeCycle == awsSpotLifeCycle
+	if !lifecycle.Spot {
+		return lifecycle, nil
+	}
+
+	token, err := a.getToken()
+	if err != nil {
+		return lifecycle, err
+	}
+	request, err := http.NewRequest(http.MethodGet, formatURL(a.awsEC2MetadataHostname, awsSpotInstanceActionPath), nil)
+	if err != nil {
+		return lifecycle, fmt.Errorf("unable to prepare AWS metadata request: %v", err)
+	}
+	request.Header.Add(tokenHeader, token)
+
+	// The instance action is only available once the interruption is noticed, returning 404 otherwise.
+	body, found, err := fetchLifecycleMetadata(a.httpClient, request)
+	if err != nil || !found {
+		return lifecycle, err
+	}
+
+	var action struct {
+		Action string `json:"action"`
+		Time   string `json:"time"`
+	}
+	if err = json.Unmarshal(body, &action); err != nil {
+		return lifecycle, fmt.Errorf("unable to decode AWS spot instance action: %v", err)
+	}
+	lifecycle.InterruptionPending = true
+	lifecycle.InterruptionAction = action.Action
+	lifecycle.InterruptionTime = action.Time
+	return lifecycle, nil
+}
+
+// GetLifecycle returns whether the instance is a preemptible one and, in that case, whether it's been preempted.
+// https://cloud.google.com/compute/docs/instances/spot#detect-preemption
+func (gcp *GCPHarvester) GetLifecycle() (lifecycle Lifecycle, err error) {
+	preemptible, err := gcp.getMetadataValue(gcpPreemptiblePath)
+	if err != nil {
+		return lifecycle, err
+	}
+	lifecycle.Spot = strings.EqualFold(preemptible, gcpTrue)
+	if !lifecycle.Spot {
+		return lifecycle, nil
+	}
+
+	preempted, err := gcp.getMetadataValue(gcpPreemptedPath)
+	if err != nil {
+		return lifecycle, err
+	}
+	if strings.EqualFold(preempted, gcpTrue) {
+		lifecycle.InterruptionPending = true
+		lifecycle.InterruptionAction = "preempt"
+	}
+	return lifecycle, nil
+}
+
+func (gcp *GCPHarvester) getMetadataValue(path string) (string, error) {
+	request, err := http.NewRequest(http.MethodGet, gcpInstanceMetadataURL+path, nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to prepare GCP metadata request: %v", err)
+	}
+	request.Header.Add("Metadata-Flavor", "Google")
+
+	body, found, err := fetchLifecycleMetadata(clientWithFastTimeout(gcp.disableKeepAlive), request)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("GCP metadata %s not found", path)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// GetLifecycle returns whether the instance is a spot or low priority one and, in that case, whether a preemption
+// is scheduled.
+// https://learn.microsoft.com/en-us/azure/virtual-machines/linux/scheduled-events
+func (a *AzureHarvester) GetLifecycle() (lifecycle Lifecycle, err error) {
+	client := clientWithFastTimeout(a.disableKeepAlive)
+
+	request, err := newAzureMetadataRequest(azurePriorityPath)
+	if err != nil {
+		return lifecycle, err
+	}
+	priority, _, err := fetchLifecycleMetadata(client, request)
+	if err != nil {
+		return lifecycle, err
+	}
+	switch strings.TrimSpace(string(priority)) {
+	case "Spot", "Low":
+		lifecycle.Spot = true
+	default:
+		return lifecycle, nil
+	}
+
+	if request, err = newAzureMetadataRequest(azureScheduledEventsPath); err != nil {
+		return lifecycle, err
+	}
+	body, found, err := fetchLifecycleMetadata(client, request)
+	if err != nil || !found {
+		return lifecycle, err
+	}
+
+	var scheduled struct {
+		Events []struct {
+			EventType string `json:"EventType"`
+			NotBefore string `json:"NotBefore"`
+		} `json:"Events"`
+	}
+	if err = json.Unmarshal(body, &scheduled); err != nil {
+		return lifecycle, fmt.Errorf("unable to decode Azure scheduled events: %v", err)
+	}
+	for _, event := range scheduled.Events {
+		if event.EventType == azurePreemptEventType {
+			lifecycle.InterruptionPending = true
+			lifecycle.InterruptionAction = "preempt"
+			lifecycle.InterruptionTime = event.NotBefore
+			break
+		}
+	}
+	return lifecycle, nil
+}
+
+func newAzureMetadataRequest(path string) (*http.Request, error) {
+	request, err := http.NewRequest(http.MethodGet, azureMetadataHostname+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare Azure metadata request: %v", err)
+	}
+	request.Header.Add("Metadata", "true")
+	return request, nil
+}
+
+// fetchLifecycleMetadata returns the body of a metadata response, and false if the metadata is not found.
+func fetchLifecycleMetadata(client *http.Client, request *http.Request) (body []byte, found bool, err error) {
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to fetch cloud metadata: %s", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		_, _ = io.Copy(ioutil.Discard, response.Body)
+		return nil, false, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, response.Body)
+		return nil, false, fmt.Errorf("cloud metadata request returned non-OK response: %d %s", response.StatusCode, response.Status)
+	}
+
+	if body, err = ioutil.ReadAll(response.Body); err != nil {
+		return nil, false, fmt.Errorf("unable to read cloud metadata response: %s", err)
+	}
+	return body, true, nil
+}
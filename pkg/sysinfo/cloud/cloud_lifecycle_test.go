@@ -0,0 +1,244 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package cloud
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAWSLifecycleServer(t *testing.T, lifeCycle, instanceAction string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "token")
+	})
+	mux.HandleFunc("/latest/meta-data/instance-life-cycle", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get(tokenHeader))
+		w.Header().Set("Content-type", "text/plain")
+		_, _ = fmt.Fprint(w, lifeCycle)
+	})
+	mux.HandleFunc("/latest/meta-data/spot/instance-action", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get(tokenHeader))
+		if instanceAction == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprint(w, instanceAction)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestAWSHarvester_GetLifecycle(t *testing.T) {
+	tests := []struct {
+		name           string
+		lifeCycle      string
+		instanceAction string
+		expected       Lifecycle
+	}{
+		{
+			name:      "on demand",
+			lifeCycle: "on-demand",
+			expected:  Lifecycle{},
+		},
+		{
+			name:      "spot without interruption",
+			lifeCycle: "spot",
+			expected:  Lifecycle{Spot: true},
+		},
+		{
+			name:           "spot with interruption notice",
+			lifeCycle:      "spot",
+			instanceAction: `{"action": "terminate", "time": "2017-09-18T08:22:00Z"}`,
+			expected: Lifecycle{
+				Spot:                true,
+				InterruptionPending: true,
+				InterruptionAction:  "terminate",
+				InterruptionTime:    "2017-09-18T08:22:00Z",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newAWSLifecycleServer(t, tt.lifeCycle, tt.instanceAction)
+			defer ts.Close()
+
+			h := NewAWSHarvester(true)
+			h.awsEC2MetadataHostname = ts.URL
+
+			lifecycle, err := h.GetLifecycle()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, lifecycle)
+		})
+	}
+}
+
+func TestAWSHarvester_GetLifecycle_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	h := NewAWSHarvester(true)
+	h.awsEC2MetadataHostname = ts.URL
+
+	_, err := h.GetLifecycle()
+	assert.Error(t, err)
+}
+
+func TestGCPHarvester_GetLifecycle(t *testing.T) {
+	tests := []struct {
+		name        string
+		preemptible string
+		preempted   string
+		expected    Lifecycle
+	}{
+		{
+			name:        "standard",
+			preemptible: "FALSE",
+			expected:    Lifecycle{},
+		},
+		{
+			name:        "preemptible",
+			preemptible: "TRUE",
+			preempted:   "FALSE",
+			expected:    Lifecycle{Spot: true},
+		},
+		{
+			name:        "preempted",
+			preemptible: "TRUE",
+			preempted:   "TRUE",
+			expected:    Lifecycle{Spot: true, InterruptionPending: true, InterruptionAction: "preempt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/instance/scheduling/preemptible", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+				_, _ = fmt.Fprint(w, tt.preemptible)
+			})
+			mux.HandleFunc("/instance/preempted", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+				_, _ = fmt.Fprint(w, tt.preempted)
+			})
+			ts := httptest.NewServer(mux)
+			defer ts.Close()
+
+			defaultURL := gcpInstanceMetadataURL
+			gcpInstanceMetadataURL = ts.URL + "/instance/"
+			defer func() { gcpInstanceMetadataURL = defaultURL }()
+
+			lifecycle, err := NewGCPHarvester(true).GetLifecycle()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, lifecycle)
+		})
+	}
+}
+
+func TestAzureHarvester_GetLifecycle(t *testing.T) {
+	tests := []struct {
+		name            string
+		priority        string
+		scheduledEvents string
+		expected        Lifecycle
+	}{
+		{
+			name:     "regular",
+			priority: "Regular",
+			expected: Lifecycle{},
+		},
+		{
+			name:            "spot without scheduled events",
+			priority:        "Spot",
+			scheduledEvents: `{"DocumentIncarnation": 0, "Events": []}`,
+			expected:        Lifecycle{Spot: true},
+		},
+		{
+			name:            "low priority not preempted",
+			priority:        "Low",
+			scheduledEvents: `{"DocumentIncarnation": 1, "Events": [{"EventType": "Reboot", "NotBefore": "Mon, 19 Sep 2016 18:29:47 GMT"}]}`,
+			expected:        Lifecycle{Spot: true},
+		},
+		{
+			name:            "spot preempted",
+			priority:        "Spot",
+			scheduledEvents: `{"DocumentIncarnation": 2, "Events": [{"EventType": "Preempt", "NotBefore": "Mon, 19 Sep 2016 18:29:47 GMT"}]}`,
+			expected: Lifecycle{
+				Spot:                true,
+				InterruptionPending: true,
+				InterruptionAction:  "preempt",
+				InterruptionTime:    "Mon, 19 Sep 2016 18:29:47 GMT",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/metadata/instance/compute/priority", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "true", r.Header.Get("Metadata"))
+				_, _ = fmt.Fprint(w, tt.priority)
+			})
+			mux.HandleFunc("/metadata/scheduledevents", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "true", r.Header.Get("Metadata"))
+				_, _ = fmt.Fprint(w, tt.scheduledEvents)
+			})
+			ts := httptest.NewServer(mux)
+			defer ts.Close()
+
+			defaultHostname := azureMetadataHostname
+			azureMetadataHostname = ts.URL
+			defer func() { azureMetadataHostname = defaultHostname }()
+
+			lifecycle, err := NewAzureHarvester(true).GetLifecycle()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, lifecycle)
+		})
+	}
+}
+
+func TestDetector_GetLifecycle_NotImplemented(t *testing.T) {
+	detector := NewDetector(false, 0, 0, 0, false, nil)
+	detector.initialize(newInstanceHarvester(TypeAlibaba, "i-123"))
+
+	_, err := detector.GetLifecycle()
+	assert.ErrorIs(t, err, ErrMethodNotImplemented)
+}
+
+func TestDetector_GetLifecycle_DiskCache(t *testing.T) {
+	ts := newAWSLifecycleServer(t, "spot", `{"action": "stop", "time": "2017-09-18T08:22:00Z"}`)
+	defer ts.Close()
+
+	cacheFile := filepath.Join(t.TempDir(), "cloud_metadata.json")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeCache(t, cacheFile, metadataCache{Timestamp: now.Add(-time.Minute), CloudType: TypeAWS, InstanceID: "i-cached"})
+	detector := newCachingDetector(cacheFile, now)
+
+	harvester := NewAWSHarvester(true)
+	harvester.awsEC2MetadataHostname = ts.URL
+	detector.initialize(harvester)
+
+	// the lifecycle is read from the metadata endpoint while the cached metadata is in use
+	cloudHarvester, err := detector.GetHarvester()
+	require.NoError(t, err)
+	require.IsType(t, &cachedHarvester{}, cloudHarvester)
+	lifecycle, err := detector.GetLifecycle()
+	require.NoError(t, err)
+	assert.Equal(t, Lifecycle{
+		Spot:                true,
+		InterruptionPending: true,
+		InterruptionAction:  "stop",
+		InterruptionTime:    "2017-09-18T08:22:00Z",
+	}, lifecycle)
+}
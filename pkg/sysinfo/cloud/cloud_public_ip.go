@@ -0,0 +1,117 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package cloud
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	awsPublicIPv4Path     = "public-ipv4"
+	gcpExternalIPPath     = "network-interfaces/0/access-configs/0/external-ip"
+	azurePublicIPPath     = "/metadata/instance/network/interface/0/ipv4/ipAddress/0/publicIpAddress?api-version=2021-02-01&format=text"
+	alibabaPublicIPv4Path = "public-ipv4"
+)
+
+// alibabaMetadataURL is the Alibaba instance metadata endpoint, a variable so tests can replace it.
+var alibabaMetadataURL = "http://100.100.100.200/latest/meta-data/"
+
+// ErrNoPublicIP is the error returned when the instance has no public IP assigned.
+var ErrNoPublicIP = errors.New("cloud instance has no public IP")
+
+// PublicIPHarvester is implemented by the harvesters able to report the instance public IP.
+type PublicIPHarvester interface {
+	// GetPublicIP returns the public IP address assigned to the instance.
+	GetPublicIP() (string, error)
+}
+
+// GetPublicIP returns the public IP of the detected cloud instance.
+func (d *Detector) GetPublicIP() (string, error) {
+	cloudHarvester, err := d.GetHarvester()
+	if err != nil {
+		return "", err
+	}
+	publicIPHarvester, ok := cloudHarvester.(PublicIPHarvester)
+	if !ok {
+		return "", ErrMethodNotImplemented
+	}
+	return publicIPHarvester.GetPublicIP()
+}
+
+// GetPublicIP returns the public IPv4 address of the instance.
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/instancedata-data-categories.html
+func (a *AWSHarvester) GetPublicIP() (string, error) {
+	token, err := a.getToken()
+	if err != nil {
+		return "", err
+	}
+	request, err := http.NewRequest(http.MethodGet, formatURL(a.awsEC2MetadataHostname, awsPublicIPv4Path), nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to prepare AWS metadata request: %v", err)
+	}
+	request.Header.Add(tokenHeader, token)
+
+	return fetchPublicIP(a.httpClient, request)
+}
+
+// GetPublicIP returns the external IP of the first access config of the instance's first network interface.
+// https://cloud.google.com/compute/docs/metadata/predefined-metadata-keys
+func (gcp *GCPHarvester) GetPublicIP() (string, error) {
+	request, err := http.NewRequest(http.MethodGet, gcpInstanceMetadataURL+gcpExternalIPPath, nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to prepare GCP metadata request: %v", err)
+	}
+	request.Header.Add("Metadata-Flavor", "Google")
+
+	return fetchPublicIP(clientWithFastTimeout(gcp.disableKeepAlive), request)
+}
+
+// GetPublicIP returns the public IP of the instance's first network interface, only reported by Azure when
+// it's a basic SKU public IP.
+// https://learn.microsoft.com/en-us/azure/virtual-machines/instance-metadata-service
+func (a *AzureHarvester) GetPublicIP() (string, error) {
+	request, err := newAzureMetadataRequest(azurePublicIPPath)
+	if err != nil {
+		return "", err
+	}
+
+	return fetchPublicIP(clientWithFastTimeout(a.disableKeepAlive), request)
+}
+
+// GetPublicIP returns the public IPv4 address of the instance.
+// https://www.alibabacloud.com/help/en/ecs/user-guide/view-instance-metadata
+func (a *AlibabaHarvester) GetPublicIP() (string, error) {
+	request, err := http.NewRequest(http.MethodGet, alibabaMetadataURL+alibabaPublicIPv4Path, nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to prepare Alibaba metadata request: %v", err)
+	}
+
+	return fetchPublicIP(clientWithFastTimeout(a.disableKeepAlive), request)
+}
+
+// fetchPublicIP returns the IP address in the body of a metadata response, or ErrNoPublicIP if the metadata is not
+// found or empty.
+func fetchPublicIP(client *http.Client, request *http.Request) (string, error) {
+	body, found, err := fetchLifecycleMetadata(client, request)
+	if err != nil {
+		return "", err
+	}
+	if !found || strings.TrimSpace(string(body)) == "" {
+		return "", ErrNoPublicIP
+	}
+	return ParsePublicIP(string(body))
+}
+
+// ParsePublicIP returns the IP address in the given text, or an error if it's not a valid address.
+func ParsePublicIP(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	ip := net.ParseIP(text)
+	if ip == nil {
+		return "", fmt.Errorf("invalid public IP address: %q", text)
+	}
+	return ip.String(), nil
+}
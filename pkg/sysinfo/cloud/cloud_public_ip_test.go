@@ -0,0 +1,152 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+package cloud
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAWSHarvester_GetPublicIP(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "token")
+	})
+	mux.HandleFunc("/latest/meta-data/public-ipv4", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get(tokenHeader))
+		_, _ = fmt.Fprint(w, "203.0.113.10\n")
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	h := NewAWSHarvester(true)
+	h.awsEC2MetadataHostname = ts.URL
+
+	publicIP, err := h.GetPublicIP()
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.10", publicIP)
+}
+
+func TestAWSHarvester_GetPublicIP_NotAssigned(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "token")
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	h := NewAWSHarvester(true)
+	h.awsEC2MetadataHostname = ts.URL
+
+	_, err := h.GetPublicIP()
+	assert.ErrorIs(t, err, ErrNoPublicIP)
+}
+
+func TestDetector_GetPublicIP_DiskCache(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "token")
+	})
+	mux.HandleFunc("/latest/meta-data/public-ipv4", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "203.0.113.10")
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cacheFile := filepath.Join(t.TempDir(), "cloud_metadata.json")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeCache(t, cacheFile, metadataCache{Timestamp: now.Add(-time.Minute), CloudType: TypeAWS, InstanceID: "i-cached"})
+	detector := newCachingDetector(cacheFile, now)
+
+	harvester := NewAWSHarvester(true)
+	harvester.awsEC2MetadataHostname = ts.URL
+	detector.initialize(harvester)
+
+	// the public IP is read from the metadata endpoint while the cached metadata is in use
+	cloudHarvester, err := detector.GetHarvester()
+	require.NoError(t, err)
+	require.IsType(t, &cachedHarvester{}, cloudHarvester)
+	publicIP, err := detector.GetPublicIP()
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.10", publicIP)
+}
+
+func TestGCPHarvester_GetPublicIP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/instance/network-interfaces/0/access-configs/0/external-ip", r.URL.Path)
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		_, _ = fmt.Fprint(w, "198.51.100.7")
+	}))
+	defer ts.Close()
+
+	defaultURL := gcpInstanceMetadataURL
+	gcpInstanceMetadataURL = ts.URL + "/instance/"
+	defer func() { gcpInstanceMetadataURL = defaultURL }()
+
+	publicIP, err := NewGCPHarvester(true).GetPublicIP()
+	require.NoError(t, err)
+	assert.Equal(t, "198.51.100.7", publicIP)
+}
+
+func TestAzureHarvester_GetPublicIP(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected string
+		err      error
+	}{
+		{name: "public IP", response: "20.42.0.1", expected: "20.42.0.1"},
+		{name: "no public IP", response: "", err: ErrNoPublicIP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/metadata/instance/network/interface/0/ipv4/ipAddress/0/publicIpAddress", r.URL.Path)
+				assert.Equal(t, "true", r.Header.Get("Metadata"))
+				_, _ = fmt.Fprint(w, tt.response)
+			}))
+			defer ts.Close()
+
+			defaultHostname := azureMetadataHostname
+			azureMetadataHostname = ts.URL
+			defer func() { azureMetadataHostname = defaultHostname }()
+
+			publicIP, err := NewAzureHarvester(true).GetPublicIP()
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.expected, publicIP)
+		})
+	}
+}
+
+func TestAlibabaHarvester_GetPublicIP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/latest/meta-data/public-ipv4", r.URL.Path)
+		_, _ = fmt.Fprint(w, "47.88.0.2")
+	}))
+	defer ts.Close()
+
+	defaultURL := alibabaMetadataURL
+	alibabaMetadataURL = ts.URL + "/latest/meta-data/"
+	defer func() { alibabaMetadataURL = defaultURL }()
+
+	publicIP, err := NewAlibabaHarvester(true).GetPublicIP()
+	require.NoError(t, err)
+	assert.Equal(t, "47.88.0.2", publicIP)
+}
+
+func TestParsePublicIP(t *testing.T) {
+	publicIP, err := ParsePublicIP(" 2001:db8::1\n")
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::1", publicIP)
+
+	_, err = ParsePublicIP("<html>router error</html>")
+	assert.Error(t, err)
+}
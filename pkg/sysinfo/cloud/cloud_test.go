@@ -304,7 +304,7 @@ func (m *MockHarvester) GetHarvester() (Harvester, error) {
 }
 
 func (s *CloudDetectionSuite) TestDetectSuccessful(c *C) {
-	detector := NewDetector(false, 10, 0, 0, false)
+	detector := NewDetector(false, 10, 0, 0, false, nil)
 
 	gcpHarvester := NewMockHarvester(TypeGCP)
 	awsHarvester := NewMockHarvester(TypeAWS)
@@ -334,7 +334,7 @@ func (s *CloudDetectionSuite) TestDetectSuccessful(c *C) {
 }
 
 func (s *CloudDetectionSuite) TestDetectFail(c *C) {
-	detector := NewDetector(false, 10, 0, 0, false)
+	detector := NewDetector(false, 10, 0, 0, false, nil)
 
 	awsHarvester := NewMockHarvester(TypeAWS)
 	azureHarvester := NewMockHarvester(TypeAzure)
@@ -363,6 +363,55 @@ func (s *CloudDetectionSuite) TestDetectFail(c *C) {
 	c.Assert(detector.GetCloudType(), Equals, TypeNoCloud)
 }
 
+func (s *CloudDetectionSuite) TestDetectSkipsDisabledProviders(c *C) {
+	detector := NewDetector(false, 10, 0, 0, false, []string{"azure", "alibaba"})
+
+	awsHarvester := NewMockHarvester(TypeAWS)
+	azureHarvester := NewMockHarvester(TypeAzure)
+	alibabaHarvester := NewMockHarvester(TypeAlibaba)
+
+	detector.initialize(awsHarvester, azureHarvester, alibabaHarvester)
+	for i := 0; i < 100 && !detector.isInitialized(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	c.Assert(detector.isInitialized(), Equals, true)
+	c.Assert(detector.GetCloudType(), Equals, TypeNoCloud)
+	c.Assert(awsHarvester.retryCount, Equals, 11) // 1 initial detection + 10 retries in background.
+	c.Assert(azureHarvester.retryCount, Equals, 0)
+	c.Assert(alibabaHarvester.retryCount, Equals, 0)
+}
+
+func (s *CloudDetectionSuite) TestDetectAllProvidersDisabled(c *C) {
+	detector := NewDetector(false, 10, 60, 0, false, []string{"aws", "gcp"})
+
+	awsHarvester := NewMockHarvester(TypeAWS)
+	gcpHarvester := NewMockHarvester(TypeGCP)
+
+	detector.initialize(awsHarvester, gcpHarvester)
+
+	// No retries nor backoff waits are spent on disabled providers.
+	c.Assert(detector.isInitialized(), Equals, true)
+	c.Assert(detector.GetCloudType(), Equals, TypeNoCloud)
+	c.Assert(awsHarvester.retryCount, Equals, 0)
+	c.Assert(gcpHarvester.retryCount, Equals, 0)
+}
+
+func (s *CloudDetectionSuite) TestDetectWithDisabledProvider(c *C) {
+	detector := NewDetector(false, 10, 0, 0, false, []string{"gcp"})
+
+	WithProvider(TypeGCP)(detector)
+
+	c.Assert(detector.isInitialized(), Equals, false)
+	c.Assert(detector.getHarvester(), IsNil)
+}
+
+func (s *CloudDetectionSuite) TestDisabledProvidersIgnoreUnknownNames(c *C) {
+	disabled := parseDisabledProviders([]string{"aws", "unknown", "", "alibaba"})
+
+	c.Assert(disabled, DeepEquals, map[Type]bool{TypeAWS: true, TypeAlibaba: true})
+}
+
 func (s *CloudDetectionSuite) TestDetectWithProvider(chk *C) {
 	tests := []struct {
 		provider    string
@@ -409,7 +458,7 @@ func (s *CloudDetectionSuite) TestDetectWithProvider(chk *C) {
 	}
 
 	for _, test := range tests {
-		detector := NewDetector(false, 10, 0, 0, false)
+		detector := NewDetector(false, 10, 0, 0, false, nil)
 
 		done := make(chan struct{})
 
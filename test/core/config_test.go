@@ -33,7 +33,7 @@ func TestHttpHeaders_Inventory(t *testing.T) {
 		GUID: "abcdef",
 	})
 
-	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false)
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
 	a.RegisterPlugin(plugins.NewHostAliasesPlugin(a.Context, cloudDetector))
 	a.RegisterPlugin(plugins.NewAgentConfigPlugin(*ids.NewPluginID("metadata", "agent_config"), a.Context))
 
@@ -46,7 +46,7 @@ func TestMetadata(t *testing.T) {
 	a := infra.NewAgent(testClient.Client)
 	a.Context.SetAgentIdentity(entity.Identity{10, "abcdef"})
 
-	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false)
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
 	a.RegisterPlugin(plugins.NewHostAliasesPlugin(a.Context, cloudDetector))
 	a.RegisterPlugin(plugins.NewAgentConfigPlugin(*ids.NewPluginID("metadata", "agent_config"), a.Context))
 
@@ -31,7 +31,7 @@ func TestHostAliases(t *testing.T) {
 	})
 	a.Context.SetAgentIdentity(entity.Identity{10, "abcdef"})
 
-	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false)
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
 	a.RegisterPlugin(plugins.NewHostAliasesPlugin(a.Context, cloudDetector))
 	go a.Run()
 
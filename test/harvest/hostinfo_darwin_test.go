@@ -35,7 +35,7 @@ func TestHostInfoDarwin(t *testing.T) {
 	log.SetLevel(logrus.DebugLevel)
 	a.Context.SetAgentIdentity(entity.Identity{10, "abcdef"})
 
-	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false)
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
 	a.RegisterPlugin(darwin.NewHostinfoPlugin(a.Context, common.NewHostInfoCommon("test", true, cloudDetector)))
 	go a.Run()
 
@@ -32,7 +32,7 @@ func TestHostInfoLinux(t *testing.T) {
 	})
 	a.Context.SetAgentIdentity(entity.Identity{10, "abcdef"})
 
-	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false)
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
 	a.RegisterPlugin(pluginsLinux.NewHostinfoPlugin(a.Context,
 		common.NewHostInfoCommon(a.Context.Version(), !a.Context.Config().DisableCloudMetadata, cloudDetector)))
 	go a.Run()
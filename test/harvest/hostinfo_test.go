@@ -110,7 +110,7 @@ func TestHostInfo(t *testing.T) {
 	defer procDir.Clear()
 	os.Setenv("HOST_PROC", procDir.Path)
 
-	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false)
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
 	hostInfoPlugin := pluginsLinux.NewHostinfoPlugin(ctx, common.NewHostInfoCommon(ctx.Version(), !ctx.Config().DisableCloudMetadata, cloudDetector))
 	hostInfoPlugin.Run()
 	ctx.AssertExpectations(t)
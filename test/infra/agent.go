@@ -75,13 +75,13 @@ func NewAgentWithConnectClientAndConfig(connectClient *http.Client, dataClient b
 	dataDir := filepath.Join(cfg.AgentDir, "data")
 	st := delta.NewStore(dataDir, "default", cfg.MaxInventorySize, cfg.InventoryArchiveEnabled)
 
-	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false)
+	cloudDetector := cloud.NewDetector(true, 0, 0, 0, false, nil)
 
 	lookups := agent.NewIdLookup(hostname.CreateResolver(cfg.OverrideHostname, cfg.OverrideHostnameShort, cfg.DnsHostnameResolution), cloudDetector, cfg.DisplayName)
 
 	ctx := agent.NewContext(cfg, "1.2.3", testhelpers.NewFakeHostnameResolver("foobar", "foo", nil), lookups, matcher, matcher)
 
-	fingerprintHarvester, err := fingerprint.NewHarvestor(cfg, testhelpers.NullHostnameResolver, cloudDetector)
+	fingerprintHarvester, err := fingerprint.NewHarvestor(cfg, cfg.DisplayName, testhelpers.NullHostnameResolver, cloudDetector)
 	if err != nil {
 		panic(err)
 	}